@@ -30,6 +30,35 @@ type AccountRepresentation struct {
 	IDDocumentCountry    *string                        `json:"idDocumentCountry,omitempty"`
 	Locale               *string                        `json:"locale,omitempty"`
 	Accreditations       *[]AccreditationRepresentation `json:"accreditations,omitempty"`
+	ETag                 *string                        `json:"-"`
+	ClearedFields        []string                       `json:"-"`
+}
+
+// Names of the account fields which can be removed by a JSON merge patch
+const (
+	FieldPhoneNumber          = "phoneNumber"
+	FieldGender               = "gender"
+	FieldBirthDate            = "birthDate"
+	FieldLocale               = "locale"
+	FieldBirthLocation        = "birthLocation"
+	FieldNationality          = "nationality"
+	FieldIDDocumentType       = "idDocumentType"
+	FieldIDDocumentNumber     = "idDocumentNumber"
+	FieldIDDocumentExpiration = "idDocumentExpiration"
+	FieldIDDocumentCountry    = "idDocumentCountry"
+)
+
+var clearableAccountFields = map[string]bool{
+	FieldPhoneNumber:          true,
+	FieldGender:               true,
+	FieldBirthDate:            true,
+	FieldLocale:               true,
+	FieldBirthLocation:        true,
+	FieldNationality:          true,
+	FieldIDDocumentType:       true,
+	FieldIDDocumentNumber:     true,
+	FieldIDDocumentExpiration: true,
+	FieldIDDocumentCountry:    true,
 }
 
 // AccreditationRepresentation is a representation of accreditations
@@ -70,6 +99,17 @@ type UpdatePasswordBody struct {
 	ConfirmPassword string `json:"confirmPassword"`
 }
 
+// PasswordCheckBody is the definition of the expected body content of CheckPassword method
+type PasswordCheckBody struct {
+	Password string `json:"password"`
+}
+
+// PasswordCheckRepresentation is the result of the evaluation of a password against the password policy of the realm
+type PasswordCheckRepresentation struct {
+	Valid      *bool    `json:"valid"`
+	Violations []string `json:"violations"`
+}
+
 // LabelBody struct
 type LabelBody struct {
 	Label string `json:"label,omitempty"`
@@ -160,6 +200,11 @@ func ConvertToKCUser(user AccountRepresentation) kc.UserRepresentation {
 	return userRep
 }
 
+// GetETag returns the version of the account, sent in the ETag header
+func (user AccountRepresentation) GetETag() *string {
+	return user.ETag
+}
+
 // Validators
 
 // Validate is a validator for AccountRepresentation
@@ -180,6 +225,9 @@ func (user AccountRepresentation) Validate() error {
 		ValidateParameterLength(msg.IDDocumentNumber, user.IDDocumentNumber, 1, 50, false).
 		ValidateParameterDateMultipleLayout(msg.IDDocumentExpiration, user.IDDocumentExpiration, constants.SupportedDateLayouts, false).
 		ValidateParameterRegExp(msg.IDDocumentCountry, user.IDDocumentCountry, constants.RegExpCountryCode, false).
+		ValidateParameterFunc(func() error {
+			return keycloakb.ValidateClearedFields(user.ClearedFields, clearableAccountFields)
+		}).
 		Status()
 }
 
@@ -192,6 +240,13 @@ func (updatePwd UpdatePasswordBody) Validate() error {
 		Status()
 }
 
+// Validate is a validator for PasswordCheckBody
+func (checkPwd PasswordCheckBody) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(msg.Password, &checkPwd.Password, RegExpPassword, true).
+		Status()
+}
+
 // Validate is a validator for CredentialRepresentation
 func (credential CredentialRepresentation) Validate() error {
 	return validation.NewParameterValidator().
@@ -124,6 +124,15 @@ func TestValidateAccountRepresentation(t *testing.T) {
 	for _, account := range accounts {
 		assert.NotNil(t, account.Validate())
 	}
+
+	t.Run("Cleared fields", func(t *testing.T) {
+		var account = createValidAccountRepresentation()
+		account.ClearedFields = []string{FieldPhoneNumber, FieldIDDocumentExpiration}
+		assert.Nil(t, account.Validate())
+
+		account.ClearedFields = []string{"email"}
+		assert.NotNil(t, account.Validate())
+	})
 }
 
 func TestValidateUpdatePasswordRepresentation(t *testing.T) {
@@ -154,6 +163,11 @@ func TestValidateUpdatePasswordRepresentation(t *testing.T) {
 
 }
 
+func TestValidatePasswordCheckBody(t *testing.T) {
+	assert.Nil(t, PasswordCheckBody{Password: "p@55w0rd"}.Validate())
+	assert.NotNil(t, PasswordCheckBody{Password: ""}.Validate())
+}
+
 func TestValidateCredentialRepresentation(t *testing.T) {
 	{
 		credential := createValidCredentialRepresentation()
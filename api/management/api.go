@@ -3,6 +3,8 @@ package apimanagement
 import (
 	"context"
 	"encoding/json"
+	"regexp"
+	"time"
 
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
 
@@ -43,6 +45,58 @@ type UserRepresentation struct {
 	Label                *string                        `json:"label,omitempty"`
 	Accreditations       *[]AccreditationRepresentation `json:"accreditations,omitempty"`
 	CreatedTimestamp     *int64                         `json:"createdTimestamp,omitempty"`
+	Lock                 *UserLockRepresentation        `json:"lock,omitempty"`
+	AccountExpiryDate    *string                        `json:"accountExpiryDate,omitempty"`
+	ETag                 *string                        `json:"-"`
+	ClearedFields        []string                       `json:"-"`
+}
+
+// Names of the user fields which can be removed by a JSON merge patch
+const (
+	FieldEmail                = "email"
+	FieldPhoneNumber          = "phoneNumber"
+	FieldLabel                = "label"
+	FieldGender               = "gender"
+	FieldBirthDate            = "birthDate"
+	FieldLocale               = "locale"
+	FieldBirthLocation        = "birthLocation"
+	FieldNationality          = "nationality"
+	FieldIDDocumentType       = "idDocumentType"
+	FieldIDDocumentNumber     = "idDocumentNumber"
+	FieldIDDocumentExpiration = "idDocumentExpiration"
+	FieldIDDocumentCountry    = "idDocumentCountry"
+	FieldAccountExpiryDate    = "accountExpiryDate"
+)
+
+var clearableUserFields = map[string]bool{
+	FieldEmail:                true,
+	FieldPhoneNumber:          true,
+	FieldLabel:                true,
+	FieldGender:               true,
+	FieldBirthDate:            true,
+	FieldLocale:               true,
+	FieldBirthLocation:        true,
+	FieldNationality:          true,
+	FieldIDDocumentType:       true,
+	FieldIDDocumentNumber:     true,
+	FieldIDDocumentExpiration: true,
+	FieldIDDocumentCountry:    true,
+	FieldAccountExpiryDate:    true,
+}
+
+// UserLockRepresentation describes why a user account is locked and when it will be automatically unlocked
+type UserLockRepresentation struct {
+	Reason   *string `json:"reason,omitempty"`
+	Comment  *string `json:"comment,omitempty"`
+	LockedBy *string `json:"lockedBy,omitempty"`
+	LockedAt *int64  `json:"lockedAt,omitempty"`
+	UnlockAt *int64  `json:"unlockAt,omitempty"`
+}
+
+// UserAccountStatusRepresentation struct
+type UserAccountStatusRepresentation struct {
+	Enabled bool                    `json:"enabled"`
+	Lock    *UserLockRepresentation `json:"lock,omitempty"`
 }
 
 // UserCheck is a representation of a user check
@@ -58,9 +112,30 @@ type UserCheck struct {
 
 // AccreditationRepresentation is a representation of accreditations
 type AccreditationRepresentation struct {
-	Type       *string `json:"type"`
-	ExpiryDate *string `json:"expiryDate"`
-	Expired    *bool   `json:"expired,omitempty"`
+	Type             *string `json:"type"`
+	ExpiryDate       *string `json:"expiryDate"`
+	Expired          *bool   `json:"expired,omitempty"`
+	Revoked          *bool   `json:"revoked,omitempty"`
+	RevocationDate   *string `json:"revocationDate,omitempty"`
+	RevocationReason *string `json:"revocationReason,omitempty"`
+	RevokedBy        *string `json:"revokedBy,omitempty"`
+}
+
+// AccreditationHistoryRepresentation is an accreditation granted to a user. Revoked accreditations keep the details of their revocation
+type AccreditationHistoryRepresentation struct {
+	Type             *string `json:"type"`
+	GrantedAt        *int64  `json:"grantedAt"`
+	GrantedBy        *string `json:"grantedBy,omitempty"`
+	CheckID          *int64  `json:"checkId,omitempty"`
+	ExpiryDate       *string `json:"expiryDate,omitempty"`
+	RevokedAt        *int64  `json:"revokedAt,omitempty"`
+	RevocationReason *string `json:"revocationReason,omitempty"`
+	RevokedBy        *string `json:"revokedBy,omitempty"`
+}
+
+// AccreditationReasonRepresentation is the reason given by an operator to revoke or reinstate an accreditation
+type AccreditationReasonRepresentation struct {
+	Reason *string `json:"reason,omitempty"`
 }
 
 // UsersPageRepresentation used to manage paging in GetUsers
@@ -80,12 +155,28 @@ type RealmRepresentation struct {
 
 // ClientRepresentation struct
 type ClientRepresentation struct {
-	ID       *string `json:"id,omitempty"`
-	Name     *string `json:"name,omitempty"`
-	BaseURL  *string `json:"baseUrl,omitempty"`
-	ClientID *string `json:"clientId,omitempty"`
-	Protocol *string `json:"protocol,omitempty"`
-	Enabled  *bool   `json:"enabled,omitempty"`
+	ID                        *string   `json:"id,omitempty"`
+	Name                      *string   `json:"name,omitempty"`
+	Description               *string   `json:"description,omitempty"`
+	RootURL                   *string   `json:"rootUrl,omitempty"`
+	BaseURL                   *string   `json:"baseUrl,omitempty"`
+	ClientID                  *string   `json:"clientId,omitempty"`
+	Protocol                  *string   `json:"protocol,omitempty"`
+	Enabled                   *bool     `json:"enabled,omitempty"`
+	PublicClient              *bool     `json:"publicClient,omitempty"`
+	BearerOnly                *bool     `json:"bearerOnly,omitempty"`
+	StandardFlowEnabled       *bool     `json:"standardFlowEnabled,omitempty"`
+	ImplicitFlowEnabled       *bool     `json:"implicitFlowEnabled,omitempty"`
+	DirectAccessGrantsEnabled *bool     `json:"directAccessGrantsEnabled,omitempty"`
+	ServiceAccountsEnabled    *bool     `json:"serviceAccountsEnabled,omitempty"`
+	RedirectURIs              *[]string `json:"redirectUris,omitempty"`
+	WebOrigins                *[]string `json:"webOrigins,omitempty"`
+}
+
+// ClientSecretRepresentation struct
+type ClientSecretRepresentation struct {
+	Type  *string `json:"type,omitempty"`
+	Value *string `json:"value,omitempty"`
 }
 
 // RequiredActionRepresentation struct
@@ -141,6 +232,79 @@ type ActionRepresentation struct {
 	Scope *string `json:"scope"`
 }
 
+// AuthorizationCheckRepresentation describes an action performed by the members of a group, or by a user, on a target
+type AuthorizationCheckRepresentation struct {
+	GroupID     *string `json:"groupId,omitempty"`
+	UserID      *string `json:"userId,omitempty"`
+	Action      *string `json:"action,omitempty"`
+	TargetRealm *string `json:"targetRealm,omitempty"`
+	TargetGroup *string `json:"targetGroup,omitempty"`
+}
+
+// AuthorizationExplanationRepresentation explains the decision taken by the authorization manager for an AuthorizationCheckRepresentation
+type AuthorizationExplanationRepresentation struct {
+	Action      string                                        `json:"action"`
+	Scope       string                                        `json:"scope"`
+	TargetRealm string                                        `json:"targetRealm"`
+	TargetGroup *string                                       `json:"targetGroup,omitempty"`
+	Allowed     bool                                          `json:"allowed"`
+	Groups      []GroupAuthorizationExplanationRepresentation `json:"groups"`
+}
+
+// GroupAuthorizationExplanationRepresentation lists the authorizations of a group which grant the checked action, or the one which is missing
+type GroupAuthorizationExplanationRepresentation struct {
+	GroupName    string                            `json:"groupName"`
+	Allowed      bool                              `json:"allowed"`
+	MatchedRules []AuthorizationRuleRepresentation `json:"matchedRules"`
+	MissingRules []AuthorizationRuleRepresentation `json:"missingRules"`
+}
+
+// AuthorizationRuleRepresentation is an authorization row. Wildcards lists the targets matched through '*'
+type AuthorizationRuleRepresentation struct {
+	Action          string   `json:"action"`
+	TargetRealm     *string  `json:"targetRealm,omitempty"`
+	TargetGroupName *string  `json:"targetGroupName,omitempty"`
+	Wildcards       []string `json:"wildcards,omitempty"`
+}
+
+// AuthorizationsCopyRepresentation describes the group the authorizations are copied to.
+// RealmMapping and GroupMapping rename the target realms and target group names of the copied authorizations
+type AuthorizationsCopyRepresentation struct {
+	TargetRealm   *string            `json:"targetRealm,omitempty"`
+	TargetGroupID *string            `json:"targetGroupId,omitempty"`
+	RealmMapping  *map[string]string `json:"realmMapping,omitempty"`
+	GroupMapping  *map[string]string `json:"groupMapping,omitempty"`
+}
+
+// AuthorizationTemplateRepresentation struct
+type AuthorizationTemplateRepresentation struct {
+	Name   *string                                    `json:"name,omitempty"`
+	Matrix *map[string]map[string]map[string]struct{} `json:"matrix"`
+}
+
+// AuthorizationTemplateApplicationRepresentation describes the template applied to a group and how its target realms and target group names are renamed
+type AuthorizationTemplateApplicationRepresentation struct {
+	TemplateName *string            `json:"templateName,omitempty"`
+	RealmMapping *map[string]string `json:"realmMapping,omitempty"`
+	GroupMapping *map[string]string `json:"groupMapping,omitempty"`
+}
+
+// AuthorizationVersionRepresentation is a recorded version of the authorizations of a group. Date is in milliseconds since epoch
+type AuthorizationVersionRepresentation struct {
+	Version        int                              `json:"version"`
+	AuthorRealm    string                           `json:"authorRealm"`
+	Author         string                           `json:"author"`
+	Date           int64                            `json:"date"`
+	Authorizations AuthorizationsRepresentation     `json:"authorizations"`
+	Diff           AuthorizationsDiffRepresentation `json:"diff"`
+}
+
+// AuthorizationsDiffRepresentation lists the authorization rules added and removed between two versions
+type AuthorizationsDiffRepresentation struct {
+	Added   []AuthorizationRuleRepresentation `json:"added"`
+	Removed []AuthorizationRuleRepresentation `json:"removed"`
+}
+
 // PasswordRepresentation struct
 type PasswordRepresentation struct {
 	Value *string `json:"value,omitempty"`
@@ -171,9 +335,18 @@ const (
 )
 
 var (
-	allowedBoConfKeys    = map[string]bool{BOConfKeyCustomers: true, BOConfKeyTeams: true}
-	allowedAdminConfMode = map[string]bool{"trustID": true, "corporate": true}
-	allowedBarcodeType   = map[string]bool{"CODE128": true}
+	allowedBoConfKeys      = map[string]bool{BOConfKeyCustomers: true, BOConfKeyTeams: true}
+	allowedAdminConfMode   = map[string]bool{"trustID": true, "corporate": true}
+	allowedBarcodeType     = map[string]bool{"CODE128": true}
+	allowedClientProtocols = map[string]bool{"openid-connect": true}
+	allowedDuplicateCheck  = map[string]bool{dto.DuplicateCheckWarn: true, dto.DuplicateCheckBlock: true}
+	allowedFourEyesActions = map[string]bool{
+		"MGMT_DeleteUser":                    true,
+		"MGMT_ResetPassword":                 true,
+		"MGMT_UpdateAuthorizations":          true,
+		"MGMT_DeleteCredentialsForUser":      true,
+		"MGMT_UpdateRealmAdminConfiguration": true,
+	}
 )
 
 // BackOfficeConfiguration type
@@ -181,9 +354,36 @@ type BackOfficeConfiguration map[string]map[string][]string
 
 // RealmAdminConfiguration struct
 type RealmAdminConfiguration struct {
-	Mode            *string                   `json:"mode"`
-	AvailableChecks map[string]bool           `json:"available-checks"`
-	Accreditations  []RealmAdminAccreditation `json:"accreditations"`
+	Mode                  *string                    `json:"mode"`
+	AvailableChecks       map[string]bool            `json:"available-checks"`
+	Accreditations        []RealmAdminAccreditation  `json:"accreditations"`
+	AccountDeactivation   *AccountDeactivationPolicy `json:"account-deactivation,omitempty"`
+	FourEyesActions       []string                   `json:"four-eyes-actions,omitempty"`
+	SoftDeletion          *SoftDeletionPolicy        `json:"soft-deletion,omitempty"`
+	DuplicateCheck        *string                    `json:"duplicate-check,omitempty"`
+	BreachedPasswordCheck *bool                      `json:"breached-password-check,omitempty"`
+	AccreditationExpiry   *AccreditationExpiryPolicy `json:"accreditation-expiry,omitempty"`
+}
+
+// AccountDeactivationPolicy struct. Accounts are disabled after InactivityDays days without connection. A warning email
+// is sent WarningDays days before an account is disabled (because of inactivity or because its expiry date is reached)
+type AccountDeactivationPolicy struct {
+	InactivityDays *int `json:"inactivity-days,omitempty"`
+	WarningDays    *int `json:"warning-days,omitempty"`
+}
+
+// AccreditationExpiryPolicy struct. A reminder email using the Keycloak email template EmailTemplate and the subject key
+// EmailSubject is sent WarningDays days before an accreditation expires
+type AccreditationExpiryPolicy struct {
+	WarningDays   *int    `json:"warning-days,omitempty"`
+	EmailTemplate *string `json:"email-template,omitempty"`
+	EmailSubject  *string `json:"email-subject,omitempty"`
+}
+
+// SoftDeletionPolicy struct. When configured, deleted users are disabled and can be restored during RetentionDays days
+// before being purged
+type SoftDeletionPolicy struct {
+	RetentionDays *int `json:"retention-days,omitempty"`
 }
 
 // RealmAdminAccreditation struct
@@ -193,12 +393,45 @@ type RealmAdminAccreditation struct {
 	Condition *string `json:"condition"`
 }
 
+// PendingRequestRepresentation struct. A management operation waiting for the approval of a second operator. Payload is the
+// body of the operation. Password is only returned to the approver of a password reset when the password has been generated
+type PendingRequestRepresentation struct {
+	ID             int64           `json:"id"`
+	Action         string          `json:"action"`
+	UserID         *string         `json:"userId,omitempty"`
+	GroupID        *string         `json:"groupId,omitempty"`
+	CredentialID   *string         `json:"credentialId,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	RequesterRealm string          `json:"requesterRealm"`
+	Requester      string          `json:"requester"`
+	RequestDate    int64           `json:"requestDate"`
+	Status         string          `json:"status"`
+	ApproverRealm  *string         `json:"approverRealm,omitempty"`
+	Approver       *string         `json:"approver,omitempty"`
+	ResolutionDate *int64          `json:"resolutionDate,omitempty"`
+	Password       *string         `json:"password,omitempty"`
+}
+
 // FederatedIdentityRepresentation struct
 type FederatedIdentityRepresentation struct {
 	UserID   *string `json:"userID,omitempty"`
 	Username *string `json:"username,omitempty"`
 }
 
+// UserLookupRepresentation struct. Users can be looked up either by ID document number or by identity
+type UserLookupRepresentation struct {
+	IDDocumentNumber *string `json:"idDocumentNumber,omitempty"`
+	FirstName        *string `json:"firstName,omitempty"`
+	LastName         *string `json:"lastName,omitempty"`
+	BirthDate        *string `json:"birthDate,omitempty"`
+}
+
+// DuplicateUsersRepresentation struct. Users of a realm sharing the same identity or the same identity document
+type DuplicateUsersRepresentation struct {
+	Criteria *string  `json:"criteria"`
+	UserIDs  []string `json:"userIds"`
+}
+
 // RequiredAction type
 type RequiredAction string
 
@@ -276,22 +509,144 @@ func ConvertToAPIUser(ctx context.Context, userKc kc.UserRepresentation, logger
 		userRep.TrustIDGroups = &value
 	}
 	if values := userKc.GetAttribute(constants.AttrbAccreditations); len(values) > 0 {
-		var accreds []AccreditationRepresentation
-		for _, accredJSON := range values {
-			var accred AccreditationRepresentation
-			if json.Unmarshal([]byte(accredJSON), &accred) == nil {
-				accred.Expired = keycloakb.IsDateInThePast(accred.ExpiryDate)
-				accreds = append(accreds, accred)
-			} else {
-				logger.Warn(ctx, "msg", "Can't unmarshall JSON", "json", accredJSON)
-			}
-		}
+		var accreds = ConvertToAPIAccreditations(ctx, values, logger)
 		userRep.Accreditations = &accreds
 	}
 
 	return userRep
 }
 
+// ConvertToAPIAccreditations converts the accreditations stored as JSON in a Keycloak attribute to API structs
+func ConvertToAPIAccreditations(ctx context.Context, values []string, logger keycloakb.Logger) []AccreditationRepresentation {
+	var accreds = []AccreditationRepresentation{}
+	for _, accredJSON := range values {
+		var accred AccreditationRepresentation
+		if json.Unmarshal([]byte(accredJSON), &accred) == nil {
+			accred.Expired = keycloakb.IsDateInThePast(accred.ExpiryDate)
+			accreds = append(accreds, accred)
+		} else {
+			logger.Warn(ctx, "msg", "Can't unmarshall JSON", "json", accredJSON)
+		}
+	}
+	return accreds
+}
+
+// ConvertToAPIAccreditationsHistory converts the accreditations ever granted to a user from DB struct to API struct
+func ConvertToAPIAccreditationsHistory(accreds []dto.DBAccreditation) []AccreditationHistoryRepresentation {
+	var res = []AccreditationHistoryRepresentation{}
+	for _, accred := range accreds {
+		var accredType = accred.Type
+		var grantedAt = accred.GrantDate.UnixNano() / int64(time.Millisecond)
+		var history = AccreditationHistoryRepresentation{
+			Type:             &accredType,
+			GrantedAt:        &grantedAt,
+			GrantedBy:        accred.Operator,
+			CheckID:          accred.CheckID,
+			ExpiryDate:       ConvertToAPIAccountExpiry(accred.ExpiryDate),
+			RevocationReason: accred.RevocationReason,
+			RevokedBy:        accred.RevokedBy,
+		}
+		if accred.RevocationDate != nil {
+			var revokedAt = accred.RevocationDate.UnixNano() / int64(time.Millisecond)
+			history.RevokedAt = &revokedAt
+		}
+		res = append(res, history)
+	}
+	return res
+}
+
+// ConvertToAPIUserLock converts a user lock from DB struct to API struct
+func ConvertToAPIUserLock(lock dto.DBUserLock) UserLockRepresentation {
+	var lockedAt = lock.LockedAt.UnixNano() / int64(time.Millisecond)
+	var res = UserLockRepresentation{
+		Reason:   &lock.Reason,
+		Comment:  lock.Comment,
+		LockedBy: lock.LockedBy,
+		LockedAt: &lockedAt,
+	}
+	if lock.UnlockAt != nil {
+		var unlockAt = lock.UnlockAt.UnixNano() / int64(time.Millisecond)
+		res.UnlockAt = &unlockAt
+	}
+	return res
+}
+
+// ConvertToDBAccountExpiry converts an account expiry date to the time from which the account is disabled.
+// Returns nil if the date is empty, which means the account does not expire
+func ConvertToDBAccountExpiry(expiryDate string) *time.Time {
+	for _, layout := range constants.SupportedDateLayouts {
+		if date, err := time.Parse(layout, expiryDate); err == nil {
+			return &date
+		}
+	}
+	return nil
+}
+
+// ConvertToAPIAccountExpiry converts an account expiry time to an API date
+func ConvertToAPIAccountExpiry(expiry *time.Time) *string {
+	if expiry == nil {
+		return nil
+	}
+	var res = expiry.UTC().Format(constants.SupportedDateLayouts[0])
+	return &res
+}
+
+// ConvertToAPIAuthorizationVersion creates an API authorization version from a DB one
+func ConvertToAPIAuthorizationVersion(version dto.AuthorizationVersion) AuthorizationVersionRepresentation {
+	return AuthorizationVersionRepresentation{
+		Version:        version.Version,
+		AuthorRealm:    version.AuthorRealm,
+		Author:         version.Author,
+		Date:           version.Date.UnixNano() / int64(time.Millisecond),
+		Authorizations: ConvertToAPIAuthorizations(dto.ToAuthorizations(version.RealmID, version.GroupName, version.Authorizations)),
+		Diff:           ConvertToAPIAuthorizationsDiff(version.Diff),
+	}
+}
+
+// ConvertToAPIPendingRequest creates an API pending request from a DB one
+func ConvertToAPIPendingRequest(request dto.PendingRequest) PendingRequestRepresentation {
+	var res = PendingRequestRepresentation{
+		ID:             request.ID,
+		Action:         request.Action,
+		UserID:         request.UserID,
+		GroupID:        request.GroupID,
+		CredentialID:   request.CredentialID,
+		RequesterRealm: request.RequesterRealm,
+		Requester:      request.Requester,
+		RequestDate:    request.RequestDate.UnixNano() / int64(time.Millisecond),
+		Status:         request.Status,
+		ApproverRealm:  request.ApproverRealm,
+		Approver:       request.Approver,
+	}
+	if request.Payload != nil {
+		res.Payload = json.RawMessage(*request.Payload)
+	}
+	if request.ResolutionDate != nil {
+		var date = request.ResolutionDate.UnixNano() / int64(time.Millisecond)
+		res.ResolutionDate = &date
+	}
+	return res
+}
+
+// ConvertToAPIAuthorizationsDiff creates an API authorizations diff from a DB one
+func ConvertToAPIAuthorizationsDiff(diff dto.AuthorizationsDiff) AuthorizationsDiffRepresentation {
+	var convert = func(rules []dto.AuthorizationRule) []AuthorizationRuleRepresentation {
+		var res = make([]AuthorizationRuleRepresentation, 0, len(rules))
+		for _, rule := range rules {
+			res = append(res, AuthorizationRuleRepresentation{
+				Action:          rule.Action,
+				TargetRealm:     rule.TargetRealmID,
+				TargetGroupName: rule.TargetGroupName,
+			})
+		}
+		return res
+	}
+	return AuthorizationsDiffRepresentation{
+		Added:   convert(diff.Added),
+		Removed: convert(diff.Removed),
+	}
+}
+
 // ConvertToAPIUsersPage converts paged users results from KC model to API one
 func ConvertToAPIUsersPage(ctx context.Context, users kc.UsersPageRepresentation, logger keycloakb.Logger) UsersPageRepresentation {
 	var slice = []UserRepresentation{}
@@ -346,6 +701,71 @@ func ConvertToKCGroup(group GroupRepresentation) kc.GroupRepresentation {
 	}
 }
 
+// ConvertToAPIClient creates an API client representation from a KC client
+func ConvertToAPIClient(client kc.ClientRepresentation) ClientRepresentation {
+	return ClientRepresentation{
+		ID:                        client.ID,
+		Name:                      client.Name,
+		Description:               client.Description,
+		RootURL:                   client.RootURL,
+		BaseURL:                   client.BaseURL,
+		ClientID:                  client.ClientID,
+		Protocol:                  client.Protocol,
+		Enabled:                   client.Enabled,
+		PublicClient:              client.PublicClient,
+		BearerOnly:                client.BearerOnly,
+		StandardFlowEnabled:       client.StandardFlowEnabled,
+		ImplicitFlowEnabled:       client.ImplicitFlowEnabled,
+		DirectAccessGrantsEnabled: client.DirectAccessGrantsEnabled,
+		ServiceAccountsEnabled:    client.ServiceAccountsEnabled,
+		RedirectURIs:              client.RedirectUris,
+		WebOrigins:                client.WebOrigins,
+	}
+}
+
+// ConvertToKCClient creates a KC client representation from an API client
+func ConvertToKCClient(client ClientRepresentation) kc.ClientRepresentation {
+	return kc.ClientRepresentation{
+		ID:                        client.ID,
+		Name:                      client.Name,
+		Description:               client.Description,
+		RootURL:                   client.RootURL,
+		BaseURL:                   client.BaseURL,
+		ClientID:                  client.ClientID,
+		Protocol:                  client.Protocol,
+		Enabled:                   client.Enabled,
+		PublicClient:              client.PublicClient,
+		BearerOnly:                client.BearerOnly,
+		StandardFlowEnabled:       client.StandardFlowEnabled,
+		ImplicitFlowEnabled:       client.ImplicitFlowEnabled,
+		DirectAccessGrantsEnabled: client.DirectAccessGrantsEnabled,
+		ServiceAccountsEnabled:    client.ServiceAccountsEnabled,
+		RedirectUris:              client.RedirectURIs,
+		WebOrigins:                client.WebOrigins,
+	}
+}
+
+// ConvertToKCRole creates a KC role representation from an API role
+func ConvertToKCRole(role RoleRepresentation) kc.RoleRepresentation {
+	return kc.RoleRepresentation{
+		ID:          role.ID,
+		Name:        role.Name,
+		Composite:   role.Composite,
+		ClientRole:  role.ClientRole,
+		ContainerID: role.ContainerID,
+		Description: role.Description,
+	}
+}
+
+// ConvertToKCRoles creates KC role representations from API roles
+func ConvertToKCRoles(roles []RoleRepresentation) []kc.RoleRepresentation {
+	var rolesRep = []kc.RoleRepresentation{}
+	for _, role := range roles {
+		rolesRep = append(rolesRep, ConvertToKCRole(role))
+	}
+	return rolesRep
+}
+
 // ConvertToAPIAuthorizations creates a API authorization representation from an array of DB Authorization
 func ConvertToAPIAuthorizations(authorizations []configuration.Authorization) AuthorizationsRepresentation {
 	var matrix = make(map[string]map[string]map[string]struct{})
@@ -458,22 +878,77 @@ func CreateDefaultRealmAdminConfiguration() RealmAdminConfiguration {
 	return RealmAdminConfiguration{Mode: &mode, AvailableChecks: checks, Accreditations: make([]RealmAdminAccreditation, 0)}
 }
 
+// ConvertDuplicateUsers creates an API DuplicateUsersRepresentation from a group of duplicate users
+func ConvertDuplicateUsers(duplicate dto.DuplicateUsers) DuplicateUsersRepresentation {
+	var criteria = duplicate.Criteria
+	return DuplicateUsersRepresentation{
+		Criteria: &criteria,
+		UserIDs:  duplicate.UserIDs,
+	}
+}
+
 // ConvertRealmAdminConfigurationFromDBStruct converts a RealmAdminConfiguration from DB struct to API struct
-func ConvertRealmAdminConfigurationFromDBStruct(conf configuration.RealmAdminConfiguration) RealmAdminConfiguration {
-	return RealmAdminConfiguration{
-		Mode:            conf.Mode,
-		AvailableChecks: conf.AvailableChecks,
-		Accreditations:  ConvertRealmAccreditationsFromDBStruct(conf.Accreditations),
+func ConvertRealmAdminConfigurationFromDBStruct(conf dto.RealmAdminConfiguration) RealmAdminConfiguration {
+	var res = RealmAdminConfiguration{
+		Mode:                  conf.Mode,
+		AvailableChecks:       conf.AvailableChecks,
+		Accreditations:        ConvertRealmAccreditationsFromDBStruct(conf.Accreditations),
+		FourEyesActions:       conf.FourEyesActions,
+		DuplicateCheck:        conf.DuplicateCheck,
+		BreachedPasswordCheck: conf.BreachedPasswordCheck,
+	}
+	if conf.AccountDeactivation != nil {
+		res.AccountDeactivation = &AccountDeactivationPolicy{
+			InactivityDays: conf.AccountDeactivation.InactivityDays,
+			WarningDays:    conf.AccountDeactivation.WarningDays,
+		}
+	}
+	if conf.SoftDeletion != nil {
+		res.SoftDeletion = &SoftDeletionPolicy{
+			RetentionDays: conf.SoftDeletion.RetentionDays,
+		}
+	}
+	if conf.AccreditationExpiry != nil {
+		res.AccreditationExpiry = &AccreditationExpiryPolicy{
+			WarningDays:   conf.AccreditationExpiry.WarningDays,
+			EmailTemplate: conf.AccreditationExpiry.EmailTemplate,
+			EmailSubject:  conf.AccreditationExpiry.EmailSubject,
+		}
 	}
+	return res
 }
 
 // ConvertToDBStruct converts a realm admin configuration into its database version
-func (rac RealmAdminConfiguration) ConvertToDBStruct() configuration.RealmAdminConfiguration {
-	return configuration.RealmAdminConfiguration{
-		Mode:            rac.Mode,
-		AvailableChecks: rac.AvailableChecks,
-		Accreditations:  rac.ConvertRealmAccreditationsToDBStruct(),
+func (rac RealmAdminConfiguration) ConvertToDBStruct() dto.RealmAdminConfiguration {
+	var res = dto.RealmAdminConfiguration{
+		RealmAdminConfiguration: configuration.RealmAdminConfiguration{
+			Mode:            rac.Mode,
+			AvailableChecks: rac.AvailableChecks,
+			Accreditations:  rac.ConvertRealmAccreditationsToDBStruct(),
+		},
+		FourEyesActions:       rac.FourEyesActions,
+		DuplicateCheck:        rac.DuplicateCheck,
+		BreachedPasswordCheck: rac.BreachedPasswordCheck,
+	}
+	if rac.AccountDeactivation != nil {
+		res.AccountDeactivation = &dto.AccountDeactivationPolicy{
+			InactivityDays: rac.AccountDeactivation.InactivityDays,
+			WarningDays:    rac.AccountDeactivation.WarningDays,
+		}
+	}
+	if rac.SoftDeletion != nil {
+		res.SoftDeletion = &dto.SoftDeletionPolicy{
+			RetentionDays: rac.SoftDeletion.RetentionDays,
+		}
+	}
+	if rac.AccreditationExpiry != nil {
+		res.AccreditationExpiry = &dto.AccreditationExpiryPolicy{
+			WarningDays:   rac.AccreditationExpiry.WarningDays,
+			EmailTemplate: rac.AccreditationExpiry.EmailTemplate,
+			EmailSubject:  rac.AccreditationExpiry.EmailSubject,
+		}
 	}
+	return res
 }
 
 // ConvertRealmAccreditationsToDBStruct converts a slice of realm admin accreditation into its database version
@@ -529,6 +1004,11 @@ func NewBackOfficeConfigurationFromJSON(confJSON string) (BackOfficeConfiguratio
 	return boConf, validator.Status()
 }
 
+// GetETag returns the version of the user, sent in the ETag header
+func (user UserRepresentation) GetETag() *string {
+	return user.ETag
+}
+
 // Validate is a validator for UserRepresentation
 func (user UserRepresentation) Validate() error {
 	var v = validation.NewParameterValidator().
@@ -550,6 +1030,11 @@ func (user UserRepresentation) Validate() error {
 		ValidateParameterDateMultipleLayout(constants.IDDocumentExpiration, user.IDDocumentExpiration, constants.SupportedDateLayouts, false).
 		ValidateParameterRegExp(constants.IDDocumentCountry, user.IDDocumentCountry, constants.RegExpCountryCode, false)
 
+	// An empty account expiry date removes the expiry of the account
+	if user.AccountExpiryDate != nil && *user.AccountExpiryDate != "" {
+		v = v.ValidateParameterDateMultipleLayout(constants.AccountExpiryDate, user.AccountExpiryDate, constants.SupportedDateLayouts, true)
+	}
+
 	if user.Groups != nil {
 		for _, groupID := range *(user.Groups) {
 			v = v.ValidateParameterRegExp(constants.GroupID, &groupID, constants.RegExpID, true)
@@ -562,6 +1047,10 @@ func (user UserRepresentation) Validate() error {
 		}
 	}
 
+	v = v.ValidateParameterFunc(func() error {
+		return keycloakb.ValidateClearedFields(user.ClearedFields, clearableUserFields)
+	})
+
 	return v.Status()
 }
 
@@ -575,6 +1064,125 @@ func (role RoleRepresentation) Validate() error {
 		Status()
 }
 
+// Validate is a validator for ClientRepresentation
+func (client ClientRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.ClientID, client.ClientID, constants.RegExpClientID, false).
+		ValidateParameterRegExp(constants.Name, client.Name, constants.RegExpClientName, false).
+		ValidateParameterRegExp(constants.Description, client.Description, constants.RegExpDescription, false).
+		ValidateParameterRegExp(constants.RootURL, client.RootURL, constants.RegExpRedirectURI, false).
+		ValidateParameterRegExp(constants.BaseURL, client.BaseURL, constants.RegExpClientRedirectURI, false).
+		ValidateParameterIn(constants.Protocol, client.Protocol, allowedClientProtocols, false).
+		ValidateParameterFunc(func() error {
+			if client.RedirectURIs == nil {
+				return nil
+			}
+			return ValidateRedirectURIs(*client.RedirectURIs)
+		}).
+		ValidateParameterFunc(func() error {
+			if client.WebOrigins == nil {
+				return nil
+			}
+			for _, origin := range *client.WebOrigins {
+				if matched, _ := regexp.MatchString(constants.RegExpWebOrigin, origin); !matched {
+					return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.WebOrigins)
+				}
+			}
+			return nil
+		}).
+		Status()
+}
+
+// ValidateRedirectURIs checks the redirect URIs of a client
+func ValidateRedirectURIs(redirectURIs []string) error {
+	for _, uri := range redirectURIs {
+		if matched, _ := regexp.MatchString(constants.RegExpClientRedirectURI, uri); !matched {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.RedirectURIs)
+		}
+	}
+	return nil
+}
+
+// Validate is a validator for UserLockRepresentation
+func (lock UserLockRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.Reason, lock.Reason, constants.RegExpLockReason, true).
+		ValidateParameterRegExp(constants.Comment, lock.Comment, constants.RegExpLockComment, false).
+		ValidateParameterFunc(func() error {
+			if lock.UnlockAt != nil && *lock.UnlockAt <= time.Now().UnixNano()/int64(time.Millisecond) {
+				return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.UnlockAt)
+			}
+			return nil
+		}).
+		Status()
+}
+
+// Validate is a validator for AccreditationReasonRepresentation
+func (reason AccreditationReasonRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.Reason, reason.Reason, constants.RegExpAccreditationReason, true).
+		Status()
+}
+
+// Validate is a validator for AuthorizationCheckRepresentation
+func (check AuthorizationCheckRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.GroupID, check.GroupID, constants.RegExpID, check.UserID == nil).
+		ValidateParameterRegExp(constants.UserID, check.UserID, constants.RegExpID, check.GroupID == nil).
+		ValidateParameterFunc(func() error {
+			if check.GroupID != nil && check.UserID != nil {
+				return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.UserID)
+			}
+			return nil
+		}).
+		ValidateParameterRegExp(constants.Action, check.Action, constants.RegExpName, true).
+		ValidateParameterRegExp(constants.TargetRealm, check.TargetRealm, constants.RegExpRealmName, false).
+		ValidateParameterRegExp(constants.TargetGroup, check.TargetGroup, constants.RegExpName, false).
+		Status()
+}
+
+// Validate is a validator for AuthorizationsCopyRepresentation
+func (authzCopy AuthorizationsCopyRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.TargetRealm, authzCopy.TargetRealm, constants.RegExpRealmName, false).
+		ValidateParameterRegExp(constants.TargetGroupID, authzCopy.TargetGroupID, constants.RegExpID, true).
+		ValidateParameterFunc(func() error {
+			return validateMapping(constants.RealmMapping, authzCopy.RealmMapping, constants.RegExpRealmName)
+		}).
+		ValidateParameterFunc(func() error {
+			return validateMapping(constants.GroupMapping, authzCopy.GroupMapping, constants.RegExpName)
+		}).
+		Status()
+}
+
+// Validate is a validator for AuthorizationTemplateApplicationRepresentation
+func (application AuthorizationTemplateApplicationRepresentation) Validate() error {
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.TemplateName, application.TemplateName, constants.RegExpName, true).
+		ValidateParameterFunc(func() error {
+			return validateMapping(constants.RealmMapping, application.RealmMapping, constants.RegExpRealmName)
+		}).
+		ValidateParameterFunc(func() error {
+			return validateMapping(constants.GroupMapping, application.GroupMapping, constants.RegExpName)
+		}).
+		Status()
+}
+
+func validateMapping(prmName string, mapping *map[string]string, regExp string) error {
+	if mapping == nil {
+		return nil
+	}
+	for from, to := range *mapping {
+		if matched, _ := regexp.MatchString(regExp, from); !matched {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + prmName)
+		}
+		if matched, _ := regexp.MatchString(regExp, to); !matched {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + prmName)
+		}
+	}
+	return nil
+}
+
 // Validate is a validator for GroupRepresentation
 func (group GroupRepresentation) Validate() error {
 	return validation.NewParameterValidator().
@@ -606,9 +1214,73 @@ func (rac RealmAdminConfiguration) Validate() error {
 	return validation.NewParameterValidator().
 		ValidateParameterIn("mode", rac.Mode, allowedAdminConfMode, true).
 		ValidateParameterFunc(rac.validateAvailableChecks).
+		ValidateParameterFunc(rac.validateAccountDeactivation).
+		ValidateParameterFunc(rac.validateFourEyesActions).
+		ValidateParameterFunc(rac.validateSoftDeletion).
+		ValidateParameterFunc(rac.validateAccreditationExpiry).
+		ValidateParameterIn("duplicate-check", rac.DuplicateCheck, allowedDuplicateCheck, false).
 		Status()
 }
 
+func (rac RealmAdminConfiguration) validateSoftDeletion() error {
+	if rac.SoftDeletion == nil {
+		return nil
+	}
+	var retention = rac.SoftDeletion.RetentionDays
+	if retention == nil {
+		return errorhandler.CreateBadRequestError(constants.MsgErrMissingParam + ".soft-deletion.retention-days")
+	}
+	if *retention < 1 || *retention > 3650 {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".soft-deletion.retention-days")
+	}
+	return nil
+}
+
+func (rac RealmAdminConfiguration) validateAccreditationExpiry() error {
+	if rac.AccreditationExpiry == nil {
+		return nil
+	}
+	var policy = rac.AccreditationExpiry
+	if policy.WarningDays == nil {
+		return errorhandler.CreateBadRequestError(constants.MsgErrMissingParam + ".accreditation-expiry.warning-days")
+	}
+	if *policy.WarningDays < 1 || *policy.WarningDays > 365 {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".accreditation-expiry.warning-days")
+	}
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp("accreditation-expiry.email-template", policy.EmailTemplate, constants.RegExpEmailTemplate, false).
+		ValidateParameterRegExp("accreditation-expiry.email-subject", policy.EmailSubject, constants.RegExpName, false).
+		Status()
+}
+
+func (rac RealmAdminConfiguration) validateFourEyesActions() error {
+	for _, action := range rac.FourEyesActions {
+		if !allowedFourEyesActions[action] {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".four-eyes-actions")
+		}
+	}
+	return nil
+}
+
+func (rac RealmAdminConfiguration) validateAccountDeactivation() error {
+	if rac.AccountDeactivation == nil {
+		return nil
+	}
+	var policy = rac.AccountDeactivation
+	if policy.InactivityDays != nil && (*policy.InactivityDays < 1 || *policy.InactivityDays > 3650) {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".account-deactivation.inactivity-days")
+	}
+	if policy.WarningDays != nil {
+		if *policy.WarningDays < 1 || *policy.WarningDays > 365 {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".account-deactivation.warning-days")
+		}
+		if policy.InactivityDays != nil && *policy.WarningDays >= *policy.InactivityDays {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".account-deactivation.warning-days")
+		}
+	}
+	return nil
+}
+
 func (rac RealmAdminConfiguration) validateAvailableChecks() error {
 	var accredConditions, err = rac.validateAccreditations()
 	if err != nil {
@@ -633,7 +1305,11 @@ func (rac RealmAdminConfiguration) validateAccreditations() (map[string]bool, er
 		if err := accred.Validate(); err != nil {
 			return nil, err
 		}
-		accredConditions[*accred.Condition] = true
+		// Checks can only be enabled when there is an accreditation they can grant
+		var rule, _ = keycloakb.ParseAccreditationRule(*accred.Condition)
+		for _, checkKey := range rule.CheckKeys() {
+			accredConditions[checkKey] = true
+		}
 	}
 
 	return accredConditions, nil
@@ -645,7 +1321,7 @@ func (acc RealmAdminAccreditation) Validate() error {
 		ValidateParameterLargeDuration("validity", acc.Validity, true).
 		ValidateParameterNotNil("condition", acc.Condition).
 		ValidateParameterFunc(func() error {
-			if !validation.IsStringInSlice(configuration.AvailableCheckKeys, *acc.Condition) {
+			if _, err := keycloakb.ParseAccreditationRule(*acc.Condition); err != nil {
 				return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + ".condition")
 			}
 			return nil
@@ -672,6 +1348,18 @@ func (fedID FederatedIdentityRepresentation) Validate() error {
 		Status()
 }
 
+// Validate is a validator for UserLookupRepresentation
+func (lookup UserLookupRepresentation) Validate() error {
+	var byIdentity = lookup.IDDocumentNumber == nil
+	return validation.NewParameterValidator().
+		ValidateParameterRegExp(constants.IDDocumentNumber, lookup.IDDocumentNumber, constants.RegExpIDDocumentNumber, false).
+		ValidateParameterLength(constants.IDDocumentNumber, lookup.IDDocumentNumber, 1, 50, false).
+		ValidateParameterRegExp(constants.Firstname, lookup.FirstName, constants.RegExpFirstName, byIdentity).
+		ValidateParameterRegExp(constants.Lastname, lookup.LastName, constants.RegExpLastName, byIdentity).
+		ValidateParameterDateMultipleLayout(constants.Birthdate, lookup.BirthDate, constants.SupportedDateLayouts, byIdentity).
+		Status()
+}
+
 // ConvertToAPIUserChecks converts user checks from DB struct to API struct
 func ConvertToAPIUserChecks(checks []dto.DBCheck) []UserCheck {
 	if len(checks) == 0 {
@@ -733,4 +1421,15 @@ const (
 	RegExpLifespan  = constants.RegExpLifespan
 	RegExpGroupIds  = constants.RegExpGroupIds
 	RegExpNumber    = constants.RegExpNumber
+	RegExpBool      = constants.RegExpBool
+
+	// User locks
+	RegExpLockReason  = constants.RegExpLockReason
+	RegExpLockComment = constants.RegExpLockComment
+
+	// Accreditations
+	RegExpAccreditationReason = constants.RegExpAccreditationReason
+
+	// Users import/export
+	RegExpUsersFileFormat = constants.RegExpUsersFileFormat
 )
@@ -130,6 +130,21 @@ func TestConvertToAPIUser(t *testing.T) {
 	})
 }
 
+func TestConvertToAPIAccreditations(t *testing.T) {
+	var ctx = context.TODO()
+	var logger = log.NewNopLogger()
+
+	assert.Len(t, ConvertToAPIAccreditations(ctx, nil, logger), 0)
+
+	var accreds = ConvertToAPIAccreditations(ctx, []string{`{"type":"one","expiryDate":"05.04.2020","revoked":true,"revocationReason":"fraud","revokedBy":"operator"}`, `{`}, logger)
+	assert.Len(t, accreds, 1)
+	assert.Equal(t, "one", *accreds[0].Type)
+	assert.True(t, *accreds[0].Expired)
+	assert.True(t, *accreds[0].Revoked)
+	assert.Equal(t, "fraud", *accreds[0].RevocationReason)
+	assert.Equal(t, "operator", *accreds[0].RevokedBy)
+}
+
 func TestConvertToAPIUsersPage(t *testing.T) {
 	var ctx = context.TODO()
 	var logger = log.NewNopLogger()
@@ -202,6 +217,24 @@ func TestConvertToKCGroup(t *testing.T) {
 	assert.Equal(t, name, *ConvertToKCGroup(group).Name)
 }
 
+func TestConvertToKCRoles(t *testing.T) {
+	var id = "role-id"
+	var name = "role name"
+	var description = "role description"
+	var clientRole = false
+	var roles = []RoleRepresentation{{ID: &id, Name: &name, Description: &description, ClientRole: &clientRole}}
+
+	var kcRoles = ConvertToKCRoles(roles)
+	assert.Len(t, kcRoles, 1)
+	assert.Equal(t, id, *kcRoles[0].ID)
+	assert.Equal(t, name, *kcRoles[0].Name)
+	assert.Equal(t, description, *kcRoles[0].Description)
+	assert.Equal(t, clientRole, *kcRoles[0].ClientRole)
+	assert.Nil(t, kcRoles[0].Composite)
+
+	assert.Len(t, ConvertToKCRoles(nil), 0)
+}
+
 func TestConvertToDBAuthorizations(t *testing.T) {
 	// Nil matrix authorizations
 	{
@@ -340,7 +373,7 @@ func TestFederatedIdentityRepresentation(t *testing.T) {
 
 func TestConvertRealmAdminConfiguration(t *testing.T) {
 	t.Run("Empty struct", func(t *testing.T) {
-		var config = configuration.RealmAdminConfiguration{}
+		var config = dto.RealmAdminConfiguration{}
 		var res = ConvertRealmAdminConfigurationFromDBStruct(config)
 		assert.Nil(t, res.Mode)
 		assert.Len(t, res.AvailableChecks, 0)
@@ -357,10 +390,22 @@ func TestConvertRealmAdminConfiguration(t *testing.T) {
 			Condition: &condition,
 			Validity:  &validity,
 		}
-		var config = configuration.RealmAdminConfiguration{
-			Mode:            &mode,
-			AvailableChecks: map[string]bool{"true": true, "false": false},
-			Accreditations:  []configuration.RealmAdminAccreditation{accred},
+		var inactivityDays = 90
+		var duplicateCheck = dto.DuplicateCheckWarn
+		var breachedPasswordCheck = true
+		var templateName = "accreditation-reminder.ftl"
+		var config = dto.RealmAdminConfiguration{
+			RealmAdminConfiguration: configuration.RealmAdminConfiguration{
+				Mode:            &mode,
+				AvailableChecks: map[string]bool{"true": true, "false": false},
+				Accreditations:  []configuration.RealmAdminAccreditation{accred},
+			},
+			AccountDeactivation:   &dto.AccountDeactivationPolicy{InactivityDays: &inactivityDays},
+			FourEyesActions:       []string{"MGMT_DeleteUser"},
+			SoftDeletion:          &dto.SoftDeletionPolicy{RetentionDays: &inactivityDays},
+			DuplicateCheck:        &duplicateCheck,
+			BreachedPasswordCheck: &breachedPasswordCheck,
+			AccreditationExpiry:   &dto.AccreditationExpiryPolicy{WarningDays: &inactivityDays, EmailTemplate: &templateName},
 		}
 		var res = ConvertRealmAdminConfigurationFromDBStruct(config)
 		assert.Equal(t, mode, *res.Mode)
@@ -371,6 +416,14 @@ func TestConvertRealmAdminConfiguration(t *testing.T) {
 		assert.Equal(t, typeValue, *res.Accreditations[0].Type)
 		assert.Equal(t, condition, *res.Accreditations[0].Condition)
 		assert.Equal(t, validity, *res.Accreditations[0].Validity)
+		assert.Equal(t, inactivityDays, *res.AccountDeactivation.InactivityDays)
+		assert.Equal(t, []string{"MGMT_DeleteUser"}, res.FourEyesActions)
+		assert.Equal(t, inactivityDays, *res.SoftDeletion.RetentionDays)
+		assert.Equal(t, duplicateCheck, *res.DuplicateCheck)
+		assert.True(t, *res.BreachedPasswordCheck)
+		assert.Equal(t, inactivityDays, *res.AccreditationExpiry.WarningDays)
+		assert.Equal(t, templateName, *res.AccreditationExpiry.EmailTemplate)
+		assert.Nil(t, res.AccreditationExpiry.EmailSubject)
 		assert.Equal(t, config, res.ConvertToDBStruct())
 	})
 }
@@ -412,6 +465,27 @@ func TestValidateUserRepresentation(t *testing.T) {
 		assert.Nil(t, user.Validate())
 	}
 
+	t.Run("Account expiry date", func(t *testing.T) {
+		user := createValidUserRepresentation()
+		user.AccountExpiryDate = ptr("31.12.2030")
+		assert.Nil(t, user.Validate())
+
+		user.AccountExpiryDate = ptr("")
+		assert.Nil(t, user.Validate())
+
+		user.AccountExpiryDate = ptr("2030-12-31T00:00")
+		assert.NotNil(t, user.Validate())
+	})
+
+	t.Run("Cleared fields", func(t *testing.T) {
+		user := createValidUserRepresentation()
+		user.ClearedFields = []string{FieldPhoneNumber, FieldNationality, FieldAccountExpiryDate}
+		assert.Nil(t, user.Validate())
+
+		user.ClearedFields = []string{FieldPhoneNumber, "username"}
+		assert.NotNil(t, user.Validate())
+	})
+
 	groups := []string{"f467ed7c", "7767ed7c-0a1d-4eee-9bb8-669c6f89c007"}
 	roles := []string{"abcded7", "7767ed7c-0a1d-4eee-9bb8-669c6f898888"}
 	empty := ""
@@ -460,6 +534,284 @@ func TestValidateRoleRepresentation(t *testing.T) {
 	}
 }
 
+func TestValidateClientRepresentation(t *testing.T) {
+	{
+		client := createValidClientRepresentation()
+		assert.Nil(t, client.Validate())
+	}
+
+	var clients []ClientRepresentation
+	for i := 0; i < 7; i++ {
+		clients = append(clients, createValidClientRepresentation())
+	}
+
+	clients[0].ClientID = ptr("client id")
+	clients[1].Name = ptr("")
+	clients[2].RootURL = ptr("/relative")
+	clients[3].Protocol = ptr("saml")
+	clients[4].RedirectURIs = &[]string{"https://app.example.com/*", "no uri"}
+	clients[5].WebOrigins = &[]string{"https://app.example.com/path"}
+	clients[6].BaseURL = ptr("not an url")
+
+	for _, client := range clients {
+		assert.NotNil(t, client.Validate())
+	}
+}
+
+func TestConvertClient(t *testing.T) {
+	var client = createValidClientRepresentation()
+	var kcClient = ConvertToKCClient(client)
+	assert.Equal(t, client.RedirectURIs, kcClient.RedirectUris)
+	assert.Equal(t, client.WebOrigins, kcClient.WebOrigins)
+	assert.Equal(t, client, ConvertToAPIClient(kcClient))
+}
+
+func TestValidateUserLockRepresentation(t *testing.T) {
+	var inOneHour = time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	var oneHourAgo = time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+
+	t.Run("Valid lock", func(t *testing.T) {
+		var lock = UserLockRepresentation{Reason: ptr("FRAUD_SUSPICION"), Comment: ptr("Called by the bank\nto be checked"), UnlockAt: &inOneHour}
+		assert.Nil(t, lock.Validate())
+	})
+	t.Run("Missing reason", func(t *testing.T) {
+		var lock = UserLockRepresentation{Comment: ptr("comment")}
+		assert.NotNil(t, lock.Validate())
+	})
+	t.Run("Invalid reason", func(t *testing.T) {
+		var lock = UserLockRepresentation{Reason: ptr("fraud suspicion")}
+		assert.NotNil(t, lock.Validate())
+	})
+	t.Run("Comment is too long", func(t *testing.T) {
+		var lock = UserLockRepresentation{Reason: ptr("FRAUD"), Comment: ptr(strings.Repeat("a", 501))}
+		assert.NotNil(t, lock.Validate())
+	})
+	t.Run("Unlock date in the past", func(t *testing.T) {
+		var lock = UserLockRepresentation{Reason: ptr("FRAUD"), UnlockAt: &oneHourAgo}
+		assert.NotNil(t, lock.Validate())
+	})
+}
+
+func TestValidateAccreditationReasonRepresentation(t *testing.T) {
+	assert.Nil(t, AccreditationReasonRepresentation{Reason: ptr("Identity document reported as stolen")}.Validate())
+	assert.NotNil(t, AccreditationReasonRepresentation{}.Validate())
+	assert.NotNil(t, AccreditationReasonRepresentation{Reason: ptr(strings.Repeat("a", 256))}.Validate())
+}
+
+func TestConvertToAPIUserLock(t *testing.T) {
+	var lockedAt = time.Date(2020, 6, 15, 10, 30, 0, 0, time.UTC)
+	var lock = dto.DBUserLock{RealmID: "realm", UserID: "user-id", Reason: "FRAUD", Comment: ptr("comment"), LockedBy: ptr("agent"), LockedAt: lockedAt}
+
+	var res = ConvertToAPIUserLock(lock)
+	assert.Equal(t, "FRAUD", *res.Reason)
+	assert.Equal(t, "comment", *res.Comment)
+	assert.Equal(t, "agent", *res.LockedBy)
+	assert.Equal(t, int64(1592217000000), *res.LockedAt)
+	assert.Nil(t, res.UnlockAt)
+
+	var unlockAt = lockedAt.Add(24 * time.Hour)
+	lock.UnlockAt = &unlockAt
+	res = ConvertToAPIUserLock(lock)
+	assert.Equal(t, int64(1592303400000), *res.UnlockAt)
+}
+
+func TestConvertToAPIAccreditationsHistory(t *testing.T) {
+	assert.Len(t, ConvertToAPIAccreditationsHistory(nil), 0)
+
+	var grantDate = time.Date(2020, 6, 15, 10, 30, 0, 0, time.UTC)
+	var expiryDate = time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	var revocationDate = grantDate.Add(24 * time.Hour)
+	var checkID = int64(42)
+	var accreds = []dto.DBAccreditation{
+		{Type: "SHADOW", GrantDate: grantDate, ExpiryDate: &expiryDate, CheckID: &checkID, Operator: ptr("agent"), RevocationDate: &revocationDate,
+			RevocationReason: ptr("fraud"), RevokedBy: ptr("supervisor")},
+		{Type: "SHADOW", GrantDate: revocationDate, ExpiryDate: &expiryDate, Operator: ptr("supervisor")},
+	}
+
+	var res = ConvertToAPIAccreditationsHistory(accreds)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "SHADOW", *res[0].Type)
+	assert.Equal(t, int64(1592217000000), *res[0].GrantedAt)
+	assert.Equal(t, "agent", *res[0].GrantedBy)
+	assert.Equal(t, checkID, *res[0].CheckID)
+	assert.Equal(t, "15.06.2025", *res[0].ExpiryDate)
+	assert.Equal(t, int64(1592303400000), *res[0].RevokedAt)
+	assert.Equal(t, "fraud", *res[0].RevocationReason)
+	assert.Equal(t, "supervisor", *res[0].RevokedBy)
+	assert.Equal(t, int64(1592303400000), *res[1].GrantedAt)
+	assert.Nil(t, res[1].CheckID)
+	assert.Nil(t, res[1].RevokedAt)
+}
+
+func TestConvertAccountExpiry(t *testing.T) {
+	assert.Nil(t, ConvertToDBAccountExpiry(""))
+	assert.Nil(t, ConvertToAPIAccountExpiry(nil))
+
+	var expected = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, expected, *ConvertToDBAccountExpiry("31.12.2030"))
+	assert.Equal(t, expected, *ConvertToDBAccountExpiry("2030-12-31"))
+	assert.Equal(t, "31.12.2030", *ConvertToAPIAccountExpiry(&expected))
+}
+
+func TestConvertToAPIPendingRequest(t *testing.T) {
+	var userID = "7fd1ab16-2d52-4da5-8a28-3d0a9e2a1e16"
+	var payload = `{"matrix":{}}`
+	var requestDate = time.Unix(1600000000, 0)
+	var resolutionDate = time.Unix(1600000060, 0)
+	var approver = "approver"
+
+	var res = ConvertToAPIPendingRequest(dto.PendingRequest{
+		ID:             7,
+		RealmName:      "DEP",
+		Action:         "MGMT_DeleteUser",
+		UserID:         &userID,
+		Payload:        &payload,
+		Secret:         []byte("encrypted"),
+		RequesterRealm: "master",
+		Requester:      "admin",
+		RequestDate:    requestDate,
+		Status:         dto.PendingRequestStatusApproved,
+		Approver:       &approver,
+		ResolutionDate: &resolutionDate,
+	})
+	assert.Equal(t, int64(7), res.ID)
+	assert.Equal(t, &userID, res.UserID)
+	assert.Nil(t, res.GroupID)
+	assert.Equal(t, payload, string(res.Payload))
+	assert.Equal(t, int64(1600000000000), res.RequestDate)
+	assert.Equal(t, int64(1600000060000), *res.ResolutionDate)
+	assert.Equal(t, &approver, res.Approver)
+	assert.Nil(t, res.Password)
+}
+
+func TestConvertToAPIAuthorizationVersion(t *testing.T) {
+	var targetRealm = "DEP"
+	var targetGroup = "agents"
+	var getUser = dto.AuthorizationRule{Action: "MGMT_GetUser", TargetRealmID: &targetRealm, TargetGroupName: &targetGroup}
+	var getRealm = dto.AuthorizationRule{Action: "MGMT_GetRealm", TargetRealmID: &targetRealm}
+	var version = dto.AuthorizationVersion{
+		RealmID:        "DEP",
+		GroupName:      "dep_admins",
+		Version:        3,
+		AuthorRealm:    "master",
+		Author:         "admin",
+		Date:           time.Unix(1600000000, 0),
+		Authorizations: []dto.AuthorizationRule{getUser, getRealm},
+		Diff:           dto.AuthorizationsDiff{Added: []dto.AuthorizationRule{getUser}},
+	}
+
+	var res = ConvertToAPIAuthorizationVersion(version)
+	assert.Equal(t, 3, res.Version)
+	assert.Equal(t, "master", res.AuthorRealm)
+	assert.Equal(t, "admin", res.Author)
+	assert.Equal(t, int64(1600000000000), res.Date)
+	assert.Contains(t, (*res.Authorizations.Matrix)["MGMT_GetUser"][targetRealm], targetGroup)
+	assert.Len(t, (*res.Authorizations.Matrix)["MGMT_GetRealm"][targetRealm], 0)
+	assert.Equal(t, []AuthorizationRuleRepresentation{{Action: "MGMT_GetUser", TargetRealm: &targetRealm, TargetGroupName: &targetGroup}}, res.Diff.Added)
+	assert.Len(t, res.Diff.Removed, 0)
+	assert.NotNil(t, res.Diff.Removed)
+}
+
+func TestValidateAuthorizationCheckRepresentation(t *testing.T) {
+	var groupID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c007"
+	var userID = "7767ed7c-0a1d-4eee-9bb8-669c6f89c007"
+	var createValid = func() AuthorizationCheckRepresentation {
+		return AuthorizationCheckRepresentation{GroupID: &groupID, Action: ptr("MGMT_GetUser"), TargetRealm: ptr("DEP"), TargetGroup: ptr("agents")}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		var check = createValid()
+		assert.Nil(t, check.Validate())
+
+		check.GroupID = nil
+		check.UserID = &userID
+		assert.Nil(t, check.Validate())
+	})
+
+	var checks []AuthorizationCheckRepresentation
+	for i := 0; i < 6; i++ {
+		checks = append(checks, createValid())
+	}
+	checks[0].GroupID = nil
+	checks[1].UserID = &userID
+	checks[2].Action = nil
+	checks[3].Action = ptr("MGMT GetUser")
+	checks[4].TargetRealm = ptr("*")
+	checks[5].TargetGroup = ptr("")
+
+	for idx, check := range checks {
+		assert.NotNil(t, check.Validate(), "Check is expected to be invalid. Test #%d failed", idx)
+	}
+}
+
+func TestValidateAuthorizationsCopyRepresentation(t *testing.T) {
+	var groupID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c007"
+	var createValid = func() AuthorizationsCopyRepresentation {
+		return AuthorizationsCopyRepresentation{
+			TargetRealm:   ptr("NEWCUSTOMER"),
+			TargetGroupID: &groupID,
+			RealmMapping:  &map[string]string{"DEP": "NEWCUSTOMER"},
+			GroupMapping:  &map[string]string{"dep_agents": "newcustomer_agents"},
+		}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		var authzCopy = createValid()
+		assert.Nil(t, authzCopy.Validate())
+
+		authzCopy.TargetRealm = nil
+		authzCopy.RealmMapping = nil
+		authzCopy.GroupMapping = nil
+		assert.Nil(t, authzCopy.Validate())
+	})
+
+	var copies []AuthorizationsCopyRepresentation
+	for i := 0; i < 5; i++ {
+		copies = append(copies, createValid())
+	}
+	copies[0].TargetRealm = ptr("*")
+	copies[1].TargetGroupID = nil
+	copies[2].TargetGroupID = ptr("not an id")
+	copies[3].RealmMapping = &map[string]string{"DEP": "*"}
+	copies[4].GroupMapping = &map[string]string{"": "agents"}
+
+	for idx, authzCopy := range copies {
+		assert.NotNil(t, authzCopy.Validate(), "Copy is expected to be invalid. Test #%d failed", idx)
+	}
+}
+
+func TestValidateAuthorizationTemplateApplicationRepresentation(t *testing.T) {
+	var createValid = func() AuthorizationTemplateApplicationRepresentation {
+		return AuthorizationTemplateApplicationRepresentation{
+			TemplateName: ptr("customer_admins"),
+			RealmMapping: &map[string]string{"TEMPLATE": "NEWCUSTOMER"},
+			GroupMapping: &map[string]string{"agents": "newcustomer_agents"},
+		}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		var application = createValid()
+		assert.Nil(t, application.Validate())
+
+		application.RealmMapping = nil
+		application.GroupMapping = nil
+		assert.Nil(t, application.Validate())
+	})
+
+	var applications []AuthorizationTemplateApplicationRepresentation
+	for i := 0; i < 4; i++ {
+		applications = append(applications, createValid())
+	}
+	applications[0].TemplateName = nil
+	applications[1].TemplateName = ptr("customer admins")
+	applications[2].RealmMapping = &map[string]string{"*": "NEWCUSTOMER"}
+	applications[3].GroupMapping = &map[string]string{"agents": "new agents"}
+
+	for idx, application := range applications {
+		assert.NotNil(t, application.Validate(), "Application is expected to be invalid. Test #%d failed", idx)
+	}
+}
+
 func TestValidateGroupRepresentation(t *testing.T) {
 	{
 		group := createValidGroupRepresentation()
@@ -569,6 +921,93 @@ func TestValidateRealmAdminConfiguration(t *testing.T) {
 		realmAdminConf.Accreditations[0].Condition = &invalid
 		assert.NotNil(t, realmAdminConf.Validate())
 	})
+	t.Run("Accreditation condition rules", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		realmAdminConf.AvailableChecks["IDNow"] = true
+		var rule = "(physical-check AND nationality in [CH, LI]) OR (IDNow AND age >= 18)"
+		realmAdminConf.Accreditations[0].Condition = &rule
+		assert.Nil(t, realmAdminConf.Validate())
+
+		rule = "physical-check AND age >= adult"
+		assert.NotNil(t, realmAdminConf.Validate())
+
+		// IDNow is enabled but no accreditation can be granted by this check
+		rule = "physical-check AND nationality = CH"
+		assert.NotNil(t, realmAdminConf.Validate())
+	})
+	t.Run("Account deactivation policy", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		var days = func(value int) *int {
+			return &value
+		}
+		realmAdminConf.AccountDeactivation = &AccountDeactivationPolicy{InactivityDays: days(180), WarningDays: days(14)}
+		assert.Nil(t, realmAdminConf.Validate())
+
+		realmAdminConf.AccountDeactivation = &AccountDeactivationPolicy{WarningDays: days(30)}
+		assert.Nil(t, realmAdminConf.Validate())
+
+		for _, invalid := range []AccountDeactivationPolicy{
+			{InactivityDays: days(0)},
+			{InactivityDays: days(5000)},
+			{WarningDays: days(0)},
+			{InactivityDays: days(10), WarningDays: days(10)},
+		} {
+			var policy = invalid
+			realmAdminConf.AccountDeactivation = &policy
+			assert.NotNil(t, realmAdminConf.Validate())
+		}
+	})
+	t.Run("Soft deletion policy", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		var days = func(value int) *int {
+			return &value
+		}
+		realmAdminConf.SoftDeletion = &SoftDeletionPolicy{RetentionDays: days(30)}
+		assert.Nil(t, realmAdminConf.Validate())
+
+		for _, invalid := range []SoftDeletionPolicy{{}, {RetentionDays: days(0)}, {RetentionDays: days(5000)}} {
+			var policy = invalid
+			realmAdminConf.SoftDeletion = &policy
+			assert.NotNil(t, realmAdminConf.Validate())
+		}
+	})
+	t.Run("Accreditation expiry policy", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		var days = func(value int) *int {
+			return &value
+		}
+		realmAdminConf.AccreditationExpiry = &AccreditationExpiryPolicy{WarningDays: days(30), EmailTemplate: ptr("accreditation-reminder.ftl"), EmailSubject: ptr("accreditationReminderSubject")}
+		assert.Nil(t, realmAdminConf.Validate())
+
+		for _, invalid := range []AccreditationExpiryPolicy{
+			{},
+			{WarningDays: days(0)},
+			{WarningDays: days(400)},
+			{WarningDays: days(30), EmailTemplate: ptr("../reminder.ftl")},
+			{WarningDays: days(30), EmailSubject: ptr("reminder subject")},
+		} {
+			var policy = invalid
+			realmAdminConf.AccreditationExpiry = &policy
+			assert.NotNil(t, realmAdminConf.Validate())
+		}
+	})
+	t.Run("Duplicate check", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		var mode = "block"
+		realmAdminConf.DuplicateCheck = &mode
+		assert.Nil(t, realmAdminConf.Validate())
+
+		mode = "ignore"
+		assert.NotNil(t, realmAdminConf.Validate())
+	})
+	t.Run("Four eyes actions", func(t *testing.T) {
+		var realmAdminConf = createValidRealmAdminConfiguration()
+		realmAdminConf.FourEyesActions = []string{"MGMT_DeleteUser", "MGMT_UpdateRealmAdminConfiguration"}
+		assert.Nil(t, realmAdminConf.Validate())
+
+		realmAdminConf.FourEyesActions = []string{"MGMT_DeleteUser", "MGMT_GetUser"}
+		assert.NotNil(t, realmAdminConf.Validate())
+	})
 }
 
 func TestValidateRequiredAction(t *testing.T) {
@@ -605,6 +1044,25 @@ func TestValidateFederatedIdentityRepresentation(t *testing.T) {
 	assert.NotNil(t, fi.Validate())
 }
 
+func TestValidateUserLookupRepresentation(t *testing.T) {
+	t.Run("By ID document number", func(t *testing.T) {
+		var lookup = UserLookupRepresentation{IDDocumentNumber: ptr("X123456")}
+		assert.Nil(t, lookup.Validate())
+	})
+	t.Run("By identity", func(t *testing.T) {
+		var lookup = UserLookupRepresentation{FirstName: ptr("John"), LastName: ptr("Doe"), BirthDate: ptr("25.12.1980")}
+		assert.Nil(t, lookup.Validate())
+	})
+	t.Run("Incomplete identity", func(t *testing.T) {
+		var lookup = UserLookupRepresentation{FirstName: ptr("John"), LastName: ptr("Doe")}
+		assert.NotNil(t, lookup.Validate())
+	})
+	t.Run("Invalid birth date", func(t *testing.T) {
+		var lookup = UserLookupRepresentation{FirstName: ptr("John"), LastName: ptr("Doe"), BirthDate: ptr("1980/12/25")}
+		assert.NotNil(t, lookup.Validate())
+	})
+}
+
 func createValidUserRepresentation() UserRepresentation {
 	var groups = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", "7767ed7c-0a1d-4eee-9bb8-669c6f89c007"}
 	var roles = []string{"abcded7c-0a1d-4eee-9bb8-669c6f89c0ee", "7767ed7c-0a1d-4eee-9bb8-669c6f898888"}
@@ -631,6 +1089,23 @@ func createValidUserRepresentation() UserRepresentation {
 	return user
 }
 
+func createValidClientRepresentation() ClientRepresentation {
+	boolTrue := true
+
+	return ClientRepresentation{
+		ClientID:            ptr("my-client.app"),
+		Name:                ptr("My client"),
+		Description:         ptr("description"),
+		RootURL:             ptr("https://app.example.com"),
+		BaseURL:             ptr("/home"),
+		Protocol:            ptr("openid-connect"),
+		Enabled:             &boolTrue,
+		StandardFlowEnabled: &boolTrue,
+		RedirectURIs:        &[]string{"https://app.example.com/*", "/callback", "myapp:/oauth"},
+		WebOrigins:          &[]string{"https://app.example.com", "+"},
+	}
+}
+
 func createValidRoleRepresentation() RoleRepresentation {
 	boolTrue := true
 
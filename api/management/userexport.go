@@ -0,0 +1,93 @@
+package apimanagement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+)
+
+// UsersExportWriter writes exported users in a stream
+type UsersExportWriter interface {
+	Write(user UserRepresentation) error
+	Flush() error
+}
+
+var csvExportColumns = []string{"id", "username", "email", "emailVerified", "phoneNumber", "phoneNumberVerified", "firstName", "lastName",
+	"gender", "birthDate", "birthLocation", "nationality", "idDocumentType", "idDocumentNumber", "idDocumentExpiration",
+	"idDocumentCountry", "locale", "enabled", "createdTimestamp"}
+
+type csvUsersExportWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+type ndjsonUsersExportWriter struct {
+	encoder *json.Encoder
+}
+
+// NewUsersExportWriter creates a UsersExportWriter for the given format
+func NewUsersExportWriter(format string, w io.Writer) (UsersExportWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvUsersExportWriter{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonUsersExportWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Format)
+	}
+}
+
+func (e *csvUsersExportWriter) Write(user UserRepresentation) error {
+	if !e.headerWritten {
+		if err := e.writer.Write(csvExportColumns); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+	return e.writer.Write([]string{
+		csvString(user.ID), csvString(user.Username), csvString(user.Email), csvBool(user.EmailVerified),
+		csvString(user.PhoneNumber), csvBool(user.PhoneNumberVerified), csvString(user.FirstName), csvString(user.LastName),
+		csvString(user.Gender), csvString(user.BirthDate), csvString(user.BirthLocation), csvString(user.Nationality),
+		csvString(user.IDDocumentType), csvString(user.IDDocumentNumber), csvString(user.IDDocumentExpiration),
+		csvString(user.IDDocumentCountry), csvString(user.Locale), csvBool(user.Enabled), csvInt64(user.CreatedTimestamp),
+	})
+}
+
+func (e *csvUsersExportWriter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *ndjsonUsersExportWriter) Write(user UserRepresentation) error {
+	// json.Encoder writes a new line after each value
+	return e.encoder.Encode(user)
+}
+
+func (e *ndjsonUsersExportWriter) Flush() error {
+	return nil
+}
+
+func csvString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func csvBool(value *bool) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*value)
+}
+
+func csvInt64(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
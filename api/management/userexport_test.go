@@ -0,0 +1,53 @@
+package apimanagement
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsersExportWriter(t *testing.T) {
+	var verified = true
+	var timestamp = int64(1585137600000)
+	var user = UserRepresentation{
+		ID:               ptr("4a4e5a06-b9cd-4d0f-9d0b-7e5d8ed6a9e5"),
+		Username:         ptr("toto"),
+		LastName:         ptr("Doe, John"),
+		EmailVerified:    &verified,
+		Nationality:      ptr("CH"),
+		CreatedTimestamp: &timestamp,
+	}
+
+	t.Run("Unknown format", func(t *testing.T) {
+		var _, err = NewUsersExportWriter("xml", &bytes.Buffer{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var buffer bytes.Buffer
+		var writer, err = NewUsersExportWriter(FormatCSV, &buffer)
+		assert.Nil(t, err)
+
+		assert.Nil(t, writer.Write(user))
+		assert.Nil(t, writer.Write(UserRepresentation{Username: ptr("titi")}))
+		assert.Nil(t, writer.Flush())
+
+		assert.Equal(t, "id,username,email,emailVerified,phoneNumber,phoneNumberVerified,firstName,lastName,gender,birthDate,birthLocation,"+
+			"nationality,idDocumentType,idDocumentNumber,idDocumentExpiration,idDocumentCountry,locale,enabled,createdTimestamp\n"+
+			"4a4e5a06-b9cd-4d0f-9d0b-7e5d8ed6a9e5,toto,,true,,,,\"Doe, John\",,,,CH,,,,,,,1585137600000\n"+
+			",titi,,,,,,,,,,,,,,,,,\n", buffer.String())
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		var buffer bytes.Buffer
+		var writer, err = NewUsersExportWriter(FormatNDJSON, &buffer)
+		assert.Nil(t, err)
+
+		assert.Nil(t, writer.Write(UserRepresentation{Username: ptr("toto")}))
+		assert.Nil(t, writer.Write(UserRepresentation{Username: ptr("titi")}))
+		assert.Nil(t, writer.Flush())
+
+		assert.Equal(t, "{\"username\":\"toto\"}\n{\"username\":\"titi\"}\n", buffer.String())
+	})
+}
@@ -0,0 +1,182 @@
+package apimanagement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+)
+
+// Supported formats for users import/export
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Status of an imported user
+const (
+	ImportStatusCreated          = "created"
+	ImportStatusSkippedDuplicate = "skipped-duplicate"
+	ImportStatusInvalid          = "invalid"
+	ImportStatusFailed           = "failed"
+	ImportStatusNotProcessed     = "not-processed"
+)
+
+// UserImportEntry is a user read from an import file
+type UserImportEntry struct {
+	Line   int
+	User   UserRepresentation
+	Reason *string
+}
+
+// UserImportResult is the result of the import of a single user
+type UserImportResult struct {
+	Line     int     `json:"line"`
+	Username *string `json:"username,omitempty"`
+	UserID   *string `json:"userId,omitempty"`
+	Status   string  `json:"status"`
+	Reason   *string `json:"reason,omitempty"`
+}
+
+// UsersImportReport is the result of a users import
+type UsersImportReport struct {
+	DryRun  bool               `json:"dryRun"`
+	Results []UserImportResult `json:"results"`
+}
+
+var csvImportColumns = map[string]func(*UserRepresentation, *string){
+	"username":             func(u *UserRepresentation, v *string) { u.Username = v },
+	"email":                func(u *UserRepresentation, v *string) { u.Email = v },
+	"phoneNumber":          func(u *UserRepresentation, v *string) { u.PhoneNumber = v },
+	"firstName":            func(u *UserRepresentation, v *string) { u.FirstName = v },
+	"lastName":             func(u *UserRepresentation, v *string) { u.LastName = v },
+	"gender":               func(u *UserRepresentation, v *string) { u.Gender = v },
+	"birthDate":            func(u *UserRepresentation, v *string) { u.BirthDate = v },
+	"birthLocation":        func(u *UserRepresentation, v *string) { u.BirthLocation = v },
+	"nationality":          func(u *UserRepresentation, v *string) { u.Nationality = v },
+	"idDocumentType":       func(u *UserRepresentation, v *string) { u.IDDocumentType = v },
+	"idDocumentNumber":     func(u *UserRepresentation, v *string) { u.IDDocumentNumber = v },
+	"idDocumentExpiration": func(u *UserRepresentation, v *string) { u.IDDocumentExpiration = v },
+	"idDocumentCountry":    func(u *UserRepresentation, v *string) { u.IDDocumentCountry = v },
+	"locale":               func(u *UserRepresentation, v *string) { u.Locale = v },
+	"groups": func(u *UserRepresentation, v *string) {
+		if v != nil {
+			var groups = strings.Split(*v, ",")
+			for i := range groups {
+				groups[i] = strings.TrimSpace(groups[i])
+			}
+			u.Groups = &groups
+		}
+	},
+}
+
+// ParseUsersImport reads the users contained in an import file. The content is either a CSV file whose first line contains the
+// column names or a NDJSON file (one JSON user representation per line). Invalid lines do not make the whole import fail: the
+// corresponding entry is returned with a reason
+func ParseUsersImport(format string, content string) ([]UserImportEntry, error) {
+	var entries []UserImportEntry
+	var err error
+
+	switch format {
+	case FormatCSV:
+		entries, err = parseUsersCSV(content)
+	case FormatNDJSON:
+		entries, err = parseUsersNDJSON(content)
+	default:
+		return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		if entry.Reason == nil {
+			entries[i].Reason = validateImportedUser(entry.User)
+		}
+	}
+	return entries, nil
+}
+
+func parseUsersCSV(content string) ([]UserImportEntry, error) {
+	var reader = csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var header, err = reader.Read()
+	if err != nil {
+		return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.BodyContent)
+	}
+	for _, column := range header {
+		if _, ok := csvImportColumns[column]; !ok {
+			return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.BodyContent + "." + column)
+		}
+	}
+
+	var entries []UserImportEntry
+	for line := 2; ; line++ {
+		var record, err = reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var entry = UserImportEntry{Line: line}
+		if err != nil || len(record) != len(header) {
+			entry.Reason = ptrString(constants.MsgErrInvalidParam + "." + constants.BodyContent)
+		} else {
+			for i, value := range record {
+				if value != "" {
+					var cell = value
+					csvImportColumns[header[i]](&entry.User, &cell)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func parseUsersNDJSON(content string) ([]UserImportEntry, error) {
+	var entries []UserImportEntry
+	var scanner = bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		var text = strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry = UserImportEntry{Line: line}
+		if err := json.Unmarshal([]byte(text), &entry.User); err != nil {
+			entry.Reason = ptrString(constants.MsgErrInvalidJSONRequest)
+		}
+		// Identifier or computed fields can't be provided in an import file
+		entry.User.ID = nil
+		entry.User.Accreditations = nil
+		entry.User.CreatedTimestamp = nil
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.BodyContent)
+	}
+	return entries, nil
+}
+
+func validateImportedUser(user UserRepresentation) *string {
+	if err := user.Validate(); err != nil {
+		return ptrString(err.Error())
+	}
+	if user.Username == nil {
+		return ptrString(constants.MsgErrMissingParam + "." + constants.Username)
+	}
+	if user.Groups == nil || len(*user.Groups) == 0 {
+		return ptrString(constants.MsgErrMissingParam + "." + constants.Groups)
+	}
+	return nil
+}
+
+func ptrString(value string) *string {
+	return &value
+}
@@ -0,0 +1,76 @@
+package apimanagement
+
+import (
+	"testing"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUsersImport(t *testing.T) {
+	var groupID = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var otherGroupID = "2a85eb3b-b2b4-4e4b-8bd5-5e2d0f8e11a3"
+
+	t.Run("Unknown format", func(t *testing.T) {
+		var _, err = ParseUsersImport("xml", "<users/>")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var content = "username,firstName,nationality,groups\n" +
+			"toto,Toto,CH,\"" + groupID + "," + otherGroupID + "\"\n" +
+			"titi,,,\n" +
+			"tutu,Tutu\n" +
+			"tata,Tata,Switzerland," + groupID + "\n"
+
+		var entries, err = ParseUsersImport(FormatCSV, content)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 4)
+
+		assert.Equal(t, 2, entries[0].Line)
+		assert.Nil(t, entries[0].Reason)
+		assert.Equal(t, "toto", *entries[0].User.Username)
+		assert.Equal(t, "Toto", *entries[0].User.FirstName)
+		assert.Equal(t, []string{groupID, otherGroupID}, *entries[0].User.Groups)
+
+		assert.Nil(t, entries[1].User.FirstName)
+		assert.Equal(t, constants.MsgErrMissingParam+"."+constants.Groups, *entries[1].Reason)
+
+		assert.Equal(t, constants.MsgErrInvalidParam+"."+constants.BodyContent, *entries[2].Reason)
+
+		assert.NotNil(t, entries[3].Reason)
+		assert.Contains(t, *entries[3].Reason, constants.Nationality)
+	})
+
+	t.Run("CSV with unknown column", func(t *testing.T) {
+		var _, err = ParseUsersImport(FormatCSV, "username,password\ntoto,secret\n")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CSV without header", func(t *testing.T) {
+		var _, err = ParseUsersImport(FormatCSV, "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		var content = `{"id":"4a4e5a06-b9cd-4d0f-9d0b-7e5d8ed6a9e5","username":"toto","groups":["` + groupID + `"]}` + "\n" +
+			"\n" +
+			"not json\n" +
+			`{"groups":["` + groupID + `"]}`
+
+		var entries, err = ParseUsersImport(FormatNDJSON, content)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 3)
+
+		assert.Equal(t, 1, entries[0].Line)
+		assert.Nil(t, entries[0].Reason)
+		assert.Nil(t, entries[0].User.ID)
+		assert.Equal(t, "toto", *entries[0].User.Username)
+
+		assert.Equal(t, 3, entries[1].Line)
+		assert.Equal(t, constants.MsgErrInvalidJSONRequest, *entries[1].Reason)
+
+		assert.Equal(t, 4, entries[2].Line)
+		assert.Equal(t, constants.MsgErrMissingParam+"."+constants.Username, *entries[2].Reason)
+	})
+}
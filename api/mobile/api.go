@@ -24,6 +24,13 @@ type AccreditationRepresentation struct {
 	Expired    *bool   `json:"expired,omitempty"`
 }
 
+// AccreditationCredentialRepresentation is a signed verifiable credential attesting an active accreditation
+type AccreditationCredentialRepresentation struct {
+	Type       *string `json:"type"`
+	ExpiryDate *string `json:"expiryDate"`
+	Credential *string `json:"credential"`
+}
+
 // CheckRepresentation is a representation of a check
 type CheckRepresentation struct {
 	Type   *string `json:"type"`
@@ -28,6 +28,7 @@ type UserRepresentation struct {
 	IDDocumentNumber     *string    `json:"idDocumentNumber,omitempty"`
 	IDDocumentExpiration *time.Time `json:"idDocumentExpiration,omitempty"`
 	IDDocumentCountry    *string    `json:"idDocumentCountry,omitempty"`
+	ETag                 *string    `json:"-"`
 }
 
 // CheckRepresentation struct
@@ -111,6 +112,11 @@ func (c *CheckRepresentation) ConvertToDBCheck() dto.DBCheck {
 	return check
 }
 
+// GetETag returns the version of the user, sent in the ETag header
+func (u UserRepresentation) GetETag() *string {
+	return u.ETag
+}
+
 // ExportToKeycloak exports user details into a Keycloak UserRepresentation
 func (u *UserRepresentation) ExportToKeycloak(kcUser *kc.UserRepresentation) {
 	var bFalse = false
@@ -132,9 +132,19 @@ const (
 	cfgSsePublicURL             = "sse-public-url"
 	cfgDbAesGcmKey              = "db-aesgcm-key"
 	cfgDbAesGcmTagSize          = "db-aesgcm-tag-size"
+	cfgDbHmacKey                = "db-hmac-key"
+	cfgDbBlindIndexBackfill     = "db-blind-index-backfill"
+	cfgAutoUnlockInterval       = "auto-unlock-interval"
+	cfgDeactivationInterval     = "account-deactivation-interval"
+	cfgUserPurgeInterval        = "user-purge-interval"
+	cfgAccredExpiryInterval     = "accreditation-expiry-interval"
+	cfgBreachedPasswordsDir     = "breached-passwords-directory"
 	cfgArchiveRwDbParams        = "db-archive-rw"
 	cfgDbArchiveAesGcmKey       = "db-archive-aesgcm-key"
 	cfgDbArchiveAesGcmTagSize   = "db-archive-aesgcm-tag-size"
+	cfgCredentialsSigningKey    = "mobile-credentials-signing-key"
+	cfgCredentialsKeyID         = "mobile-credentials-key-id"
+	cfgCredentialsIssuer        = "mobile-credentials-issuer"
 )
 
 func init() {
@@ -318,12 +328,33 @@ func main() {
 		logger.Error(ctx, "msg", "could not create AES-GCM encrypting tool instance (users)", "error", err)
 		return
 	}
+	// Security - HMAC blind indexes for users PII lookups
+	blindIndexer, err := keycloakb.NewBlindIndexerFromBase64(c.GetString(cfgDbHmacKey))
+	if err != nil {
+		logger.Error(ctx, "msg", "could not create HMAC blind indexer instance (users)", "error", err)
+		return
+	}
 	archiveAesEncryption, err := security.NewAesGcmEncrypterFromBase64(c.GetString(cfgDbArchiveAesGcmKey), c.GetInt(cfgDbArchiveAesGcmTagSize))
 	if err != nil {
 		logger.Error(ctx, "msg", "could not create AES-GCM encrypting tool instance (archive)", "error", err)
 		return
 	}
 
+	// Security - Signing of the accreditation credentials issued to the mobile app (disabled when no key is configured)
+	var credentialSigner keycloakb.CredentialSigner
+	var credentialsIssuer = c.GetString(cfgCredentialsIssuer)
+	if signingKey := c.GetString(cfgCredentialsSigningKey); signingKey != "" {
+		credentialSigner, err = keycloakb.NewCredentialSignerFromPEM(signingKey, c.GetString(cfgCredentialsKeyID))
+		if err != nil {
+			logger.Error(ctx, "msg", "could not create signer of the mobile credentials", "error", err)
+			return
+		}
+		if credentialsIssuer == "" {
+			logger.Error(ctx, "msg", "issuer of the mobile credentials (mobile-credentials-issuer) cannot be empty")
+			return
+		}
+	}
+
 	// Security - allowed trustID groups
 	var trustIDGroups = c.GetStringSlice(cfgTrustIDGroups)
 
@@ -475,6 +506,16 @@ func main() {
 		}
 	}
 
+	// Offline corpus of breached passwords. It is only used for the realms enabling the check in their admin configuration
+	var breachedPasswordChecker keycloakb.BreachedPasswordChecker
+	if breachedPasswordsDir := c.GetString(cfgBreachedPasswordsDir); breachedPasswordsDir != "" {
+		if _, err := os.Stat(breachedPasswordsDir); err != nil {
+			logger.Error(ctx, "msg", "could not find the breached passwords directory", "err", err.Error())
+			return
+		}
+		breachedPasswordChecker = keycloakb.NewFileBreachedPasswordChecker(breachedPasswordsDir)
+	}
+
 	// Health check configuration
 	var healthChecker = healthcheck.NewHealthChecker(keycloakb.ComponentName, logger)
 	var healthCheckCacheDuration = c.GetDuration("livenessprobe-cache-duration") * time.Millisecond
@@ -518,19 +559,21 @@ func main() {
 		eventsDBModule := database.NewEventsDBModule(eventsDBConn)
 
 		// module for storing and retrieving details of the users
-		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, validationLogger)
+		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, validationLogger)
 
 		// module for archiving users
 		var archiveDBModule = keycloakb.NewArchiveDBModule(archiveRwDBConn, archiveAesEncryption, validationLogger)
 
+		// module for reading the admin configuration of the realms
+		var configDBModule = keycloakb.NewConfigurationDBModule(configurationRoDBConn, validationLogger)
+
 		// accreditations module
-		var accredsModule keycloakb.AccreditationsModule
-		{
-			var configurationReaderDBModule = configuration.NewConfigurationReaderDBModule(configurationRoDBConn, validationLogger)
-			accredsModule = keycloakb.NewAccreditationsModule(keycloakClient, configurationReaderDBModule, validationLogger)
-		}
+		var accredsModule = keycloakb.NewAccreditationsModule(keycloakClient, usersDBModule, configDBModule, validationLogger)
 
-		validationComponent := validation.NewComponent(keycloakClient, technicalTokenProvider, usersDBModule, archiveDBModule, eventsDBModule, accredsModule, validationLogger)
+		// module detecting users registered twice
+		var duplicatesModule = keycloakb.NewDuplicatesModule(keycloakClient, usersDBModule, configDBModule, eventsDBModule, validationLogger)
+
+		validationComponent := validation.NewComponent(keycloakClient, technicalTokenProvider, usersDBModule, archiveDBModule, eventsDBModule, accredsModule, duplicatesModule, validationLogger)
 
 		var rateLimitValidation = rateLimit[RateKeyValidation]
 		validationEndpoints = validation.Endpoints{
@@ -598,12 +641,28 @@ func main() {
 		var configDBModule = createConfigurationDBModule(configurationRwDBConn, influxMetrics, managementLogger)
 
 		// module for storing and retrieving details of the users
-		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, managementLogger)
+		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, managementLogger)
+
+		// module for archiving the soft deleted users
+		var archiveDBModule = keycloakb.NewArchiveDBModule(archiveRwDBConn, archiveAesEncryption, managementLogger)
+
+		// module for recording the accreditations history
+		var accredsModule = keycloakb.NewAccreditationsModule(keycloakClient, usersDBModule, configDBModule, managementLogger)
+
+		// module for detecting the users sharing the same identity
+		var duplicatesModule = keycloakb.NewDuplicatesModule(keycloakClient, usersDBModule, configDBModule, eventsDBModule, managementLogger)
+
+		// module for refusing the passwords appearing in the corpus of breached passwords
+		var breachedPwdModule = keycloakb.NewBreachedPasswordModule(breachedPasswordChecker, keycloakClient, technicalTokenProvider, configDBModule, managementLogger)
 
 		var keycloakComponent management.Component
+		var pendingRequestsComponent management.PendingRequestsComponent
 		{
-			keycloakComponent = management.NewComponent(keycloakClient, usersDBModule, eventsDBModule, configDBModule, trustIDGroups, managementLogger)
-			keycloakComponent = management.MakeAuthorizationManagementComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(keycloakComponent)
+			var fourEyesComponent = management.NewFourEyesComponent(
+				management.NewComponent(keycloakClient, usersDBModule, archiveDBModule, accredsModule, duplicatesModule, breachedPwdModule, eventsDBModule, configDBModule, trustIDGroups, managementLogger),
+				keycloakClient, configDBModule, breachedPwdModule, eventsDBModule, aesEncryption, managementLogger)
+			keycloakComponent = management.MakeAuthorizationManagementComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(fourEyesComponent)
+			pendingRequestsComponent = management.MakeAuthorizationPendingRequestsComponentMW(log.With(managementLogger, "mw", "endpoint"), authorizationManager)(fourEyesComponent)
 		}
 
 		var rateLimitMgmt = rateLimit[RateKeyManagement]
@@ -615,14 +674,28 @@ func main() {
 
 			GetClients:         prepareEndpoint(management.MakeGetClientsEndpoint(keycloakComponent), "get_clients_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetClient:          prepareEndpoint(management.MakeGetClientEndpoint(keycloakComponent), "get_client_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			CreateClient:       prepareEndpoint(management.MakeCreateClientEndpoint(keycloakComponent, managementLogger), "create_client_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			UpdateClient:       prepareEndpoint(management.MakeUpdateClientEndpoint(keycloakComponent), "update_client_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			DisableClient:      prepareEndpoint(management.MakeDisableClientEndpoint(keycloakComponent), "disable_client_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			UpdateRedirectURIs: prepareEndpoint(management.MakeUpdateClientRedirectURIsEndpoint(keycloakComponent), "update_client_redirect_uris_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			RotateClientSecret: prepareEndpointWithoutLogging(management.MakeRotateClientSecretEndpoint(keycloakComponent), "rotate_client_secret_endpoint", influxMetrics, tracer, rateLimitMgmt),
 			GetRequiredActions: prepareEndpoint(management.MakeGetRequiredActionsEndpoint(keycloakComponent), "get_required-actions_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 
 			CreateUser:                prepareEndpoint(management.MakeCreateUserEndpoint(keycloakComponent, managementLogger), "create_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			ImportUsers:               prepareEndpoint(management.MakeImportUsersEndpoint(keycloakComponent), "import_users_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			ExportUsers:               prepareEndpoint(management.MakeExportUsersEndpoint(keycloakComponent), "export_users_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			LookupUsers:               prepareEndpoint(management.MakeLookupUsersEndpoint(keycloakComponent), "lookup_users_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetDuplicateUsers:         prepareEndpoint(management.MakeGetDuplicateUsersEndpoint(keycloakComponent), "get_duplicate_users_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetUser:                   prepareEndpoint(management.MakeGetUserEndpoint(keycloakComponent), "get_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			UpdateUser:                prepareEndpoint(management.MakeUpdateUserEndpoint(keycloakComponent), "update_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			LockUser:                  prepareEndpoint(management.MakeLockUserEndpoint(keycloakComponent), "lock_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			UnlockUser:                prepareEndpoint(management.MakeUnlockUserEndpoint(keycloakComponent), "unlock_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetUserAccreditations:     prepareEndpoint(management.MakeGetUserAccreditationsEndpoint(keycloakComponent), "get_user_accreditations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetAccreditationsHistory:  prepareEndpoint(management.MakeGetUserAccreditationsHistoryEndpoint(keycloakComponent), "get_user_accreditations_history_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			RevokeAccreditation:       prepareEndpoint(management.MakeRevokeAccreditationEndpoint(keycloakComponent), "revoke_accreditation_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			ReinstateAccreditation:    prepareEndpoint(management.MakeReinstateAccreditationEndpoint(keycloakComponent), "reinstate_accreditation_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			DeleteUser:                prepareEndpoint(management.MakeDeleteUserEndpoint(keycloakComponent), "delete_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			RestoreUser:               prepareEndpoint(management.MakeRestoreUserEndpoint(keycloakComponent), "restore_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetUsers:                  prepareEndpoint(management.MakeGetUsersEndpoint(keycloakComponent), "get_users_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetUserChecks:             prepareEndpoint(management.MakeGetUserChecksEndpoint(keycloakComponent), "get_user_checks", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetUserAccountStatus:      prepareEndpoint(management.MakeGetUserAccountStatusEndpoint(keycloakComponent), "get_user_accountstatus", influxMetrics, managementLogger, tracer, rateLimitMgmt),
@@ -634,19 +707,35 @@ func main() {
 			SetTrustIDGroupsToUser:    prepareEndpoint(management.MakeSetTrustIDGroupsToUserEndpoint(keycloakComponent), "set_user_trustid_groups_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetRolesOfUser:            prepareEndpoint(management.MakeGetRolesOfUserEndpoint(keycloakComponent), "get_user_roles", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 
-			GetRoles: prepareEndpoint(management.MakeGetRolesEndpoint(keycloakComponent), "get_roles_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetRole:  prepareEndpoint(management.MakeGetRoleEndpoint(keycloakComponent), "get_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetRoles:   prepareEndpoint(management.MakeGetRolesEndpoint(keycloakComponent), "get_roles_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetRole:    prepareEndpoint(management.MakeGetRoleEndpoint(keycloakComponent), "get_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			CreateRole: prepareEndpoint(management.MakeCreateRoleEndpoint(keycloakComponent, managementLogger), "create_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			DeleteRole: prepareEndpoint(management.MakeDeleteRoleEndpoint(keycloakComponent), "delete_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 
 			GetGroups:            prepareEndpoint(management.MakeGetGroupsEndpoint(keycloakComponent), "get_groups_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			CreateGroup:          prepareEndpoint(management.MakeCreateGroupEndpoint(keycloakComponent, managementLogger), "create_group_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			UpdateGroup:          prepareEndpoint(management.MakeUpdateGroupEndpoint(keycloakComponent), "update_group_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			DeleteGroup:          prepareEndpoint(management.MakeDeleteGroupEndpoint(keycloakComponent), "delete_group_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			GetAuthorizations:    prepareEndpoint(management.MakeGetAuthorizationsEndpoint(keycloakComponent), "get_authorizations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 			UpdateAuthorizations: prepareEndpoint(management.MakeUpdateAuthorizationsEndpoint(keycloakComponent), "update_authorizations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-
-			GetClientRoles:       prepareEndpoint(management.MakeGetClientRolesEndpoint(keycloakComponent), "get_client_roles_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			CreateClientRole:     prepareEndpoint(management.MakeCreateClientRoleEndpoint(keycloakComponent, managementLogger), "create_client_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			GetClientRoleForUser: prepareEndpoint(management.MakeGetClientRolesForUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
-			AddClientRoleToUser:  prepareEndpoint(management.MakeAddClientRolesToUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			ExplainAuthorization: prepareEndpoint(management.MakeExplainAuthorizationEndpoint(keycloakComponent), "explain_authorization_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			CopyAuthorizations:   prepareEndpoint(management.MakeCopyAuthorizationsEndpoint(keycloakComponent), "copy_authorizations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+
+			GetAuthorizationTemplates:   prepareEndpoint(management.MakeGetAuthorizationTemplatesEndpoint(keycloakComponent), "get_authorization_templates_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			UpdateAuthorizationTemplate: prepareEndpoint(management.MakeUpdateAuthorizationTemplateEndpoint(keycloakComponent), "update_authorization_template_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			DeleteAuthorizationTemplate: prepareEndpoint(management.MakeDeleteAuthorizationTemplateEndpoint(keycloakComponent), "delete_authorization_template_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			ApplyAuthorizationTemplate:  prepareEndpoint(management.MakeApplyAuthorizationTemplateEndpoint(keycloakComponent), "apply_authorization_template_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetAuthorizationVersions:    prepareEndpoint(management.MakeGetAuthorizationVersionsEndpoint(keycloakComponent), "get_authorization_versions_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetAuthorizationsDiff:       prepareEndpoint(management.MakeGetAuthorizationsDiffEndpoint(keycloakComponent), "get_authorizations_diff_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			RollbackAuthorizations:      prepareEndpoint(management.MakeRollbackAuthorizationsEndpoint(keycloakComponent), "rollback_authorizations_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+
+			GetClientRoles:           prepareEndpoint(management.MakeGetClientRolesEndpoint(keycloakComponent), "get_client_roles_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			CreateClientRole:         prepareEndpoint(management.MakeCreateClientRoleEndpoint(keycloakComponent, managementLogger), "create_client_role_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetClientRoleForUser:     prepareEndpoint(management.MakeGetClientRolesForUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			AddClientRoleToUser:      prepareEndpoint(management.MakeAddClientRolesToUserEndpoint(keycloakComponent), "get_client_roles_for_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			DeleteClientRoleFromUser: prepareEndpoint(management.MakeDeleteClientRolesFromUserEndpoint(keycloakComponent), "delete_client_roles_from_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			AddRealmRoleToUser:       prepareEndpoint(management.MakeAddRealmRolesToUserEndpoint(keycloakComponent), "add_realm_roles_to_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			DeleteRealmRoleFromUser:  prepareEndpoint(management.MakeDeleteRealmRolesFromUserEndpoint(keycloakComponent), "delete_realm_roles_from_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 
 			ResetPassword:                  prepareEndpointWithoutLogging(management.MakeResetPasswordEndpoint(keycloakComponent), "reset_password_endpoint", influxMetrics, tracer, rateLimitMgmt),
 			ExecuteActionsEmail:            prepareEndpoint(management.MakeExecuteActionsEmailEndpoint(keycloakComponent), "execute_actions_email_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
@@ -670,6 +759,11 @@ func main() {
 			GetUserRealmBackOfficeConfiguration: prepareEndpoint(management.MakeGetUserRealmBackOfficeConfigurationEndpoint(keycloakComponent), "get_user_realm_back_office_config_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 
 			LinkShadowUser: prepareEndpoint(management.MakeLinkShadowUserEndpoint(keycloakComponent), "link_shadow_user_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+
+			GetPendingRequests:    prepareEndpoint(management.MakeGetPendingRequestsEndpoint(pendingRequestsComponent), "get_pending_requests_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			GetPendingRequest:     prepareEndpoint(management.MakeGetPendingRequestEndpoint(pendingRequestsComponent), "get_pending_request_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
+			ApprovePendingRequest: prepareEndpointWithoutLogging(management.MakeApprovePendingRequestEndpoint(pendingRequestsComponent), "approve_pending_request_endpoint", influxMetrics, tracer, rateLimitMgmt),
+			RejectPendingRequest:  prepareEndpoint(management.MakeRejectPendingRequestEndpoint(pendingRequestsComponent), "reject_pending_request_endpoint", influxMetrics, managementLogger, tracer, rateLimitMgmt),
 		}
 	}
 
@@ -689,10 +783,19 @@ func main() {
 		}
 
 		// module for storing and retrieving details of the self-registered users
-		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, accountLogger)
+		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, accountLogger)
+
+		// module for recording the accreditations history
+		var accredsModule = keycloakb.NewAccreditationsModule(keycloakClient, usersDBModule, configDBModule, accountLogger)
+
+		// module reading the password policy of the realms with the technical user
+		var passwordPolicyModule = keycloakb.NewPasswordPolicyModule(keycloakClient, technicalTokenProvider, accountLogger)
+
+		// module for refusing the passwords appearing in the corpus of breached passwords
+		var breachedPwdModule = keycloakb.NewBreachedPasswordModule(breachedPasswordChecker, keycloakClient, technicalTokenProvider, configDBModule, accountLogger)
 
 		// new module for account service
-		accountComponent := account.NewComponent(keycloakClient.AccountClient(), eventsDBModule, configDBModule, usersDBModule, accountLogger)
+		accountComponent := account.NewComponent(keycloakClient.AccountClient(), eventsDBModule, configDBModule, usersDBModule, accredsModule, passwordPolicyModule, breachedPwdModule, accountLogger)
 		accountComponent = account.MakeAuthorizationAccountComponentMW(log.With(accountLogger, "mw", "endpoint"), configDBModule)(accountComponent)
 
 		var rateLimitAccount = rateLimit[RateKeyAccount]
@@ -701,6 +804,7 @@ func main() {
 			UpdateAccount:             prepareEndpoint(account.MakeUpdateAccountEndpoint(accountComponent), "update_account", influxMetrics, accountLogger, tracer, rateLimitAccount),
 			DeleteAccount:             prepareEndpoint(account.MakeDeleteAccountEndpoint(accountComponent), "delete_account", influxMetrics, accountLogger, tracer, rateLimitAccount),
 			UpdatePassword:            prepareEndpointWithoutLogging(account.MakeUpdatePasswordEndpoint(accountComponent), "update_password", influxMetrics, tracer, rateLimitAccount),
+			CheckPassword:             prepareEndpointWithoutLogging(account.MakeCheckPasswordEndpoint(accountComponent), "check_password", influxMetrics, tracer, rateLimitAccount),
 			GetCredentials:            prepareEndpoint(account.MakeGetCredentialsEndpoint(accountComponent), "get_credentials", influxMetrics, accountLogger, tracer, rateLimitAccount),
 			GetCredentialRegistrators: prepareEndpoint(account.MakeGetCredentialRegistratorsEndpoint(accountComponent), "get_credential_registrators", influxMetrics, accountLogger, tracer, rateLimitAccount),
 			DeleteCredential:          prepareEndpoint(account.MakeDeleteCredentialEndpoint(accountComponent), "delete_credential", influxMetrics, accountLogger, tracer, rateLimitAccount),
@@ -725,15 +829,18 @@ func main() {
 		}
 
 		// module for storing and retrieving details of the self-registered users
-		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, mobileLogger)
+		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, mobileLogger)
 
 		// new module for mobile service
-		mobileComponent := mobile.NewComponent(keycloakClient, configDBModule, usersDBModule, technicalTokenProvider, mobileLogger)
+		mobileComponent := mobile.NewComponent(keycloakClient, configDBModule, usersDBModule, technicalTokenProvider, credentialSigner, credentialsIssuer, mobileLogger)
 		mobileComponent = mobile.MakeAuthorizationMobileComponentMW(log.With(mobileLogger, "mw", "endpoint"), configDBModule)(mobileComponent)
 
 		var rateLimitMobile = rateLimit[RateKeyMobile]
 		mobileEndpoints = mobile.Endpoints{
-			GetUserInformation: prepareEndpoint(mobile.MakeGetUserInformationEndpoint(mobileComponent), "get_user_information", influxMetrics, mobileLogger, tracer, rateLimitMobile),
+			GetUserInformation:          prepareEndpoint(mobile.MakeGetUserInformationEndpoint(mobileComponent), "get_user_information", influxMetrics, mobileLogger, tracer, rateLimitMobile),
+			GetAccreditationCredentials: prepareEndpoint(mobile.MakeGetAccreditationCredentialsEndpoint(mobileComponent), "get_accreditation_credentials", influxMetrics, mobileLogger, tracer, rateLimitMobile),
+			GetCredentialsJWKS:          prepareEndpoint(mobile.MakeGetCredentialsJWKSEndpoint(mobileComponent), "get_credentials_jwks", influxMetrics, mobileLogger, tracer, rateLimitMobile),
+			GetCredentialsStatusList:    prepareEndpoint(mobile.MakeGetCredentialsStatusListEndpoint(mobileComponent), "get_credentials_status_list", influxMetrics, mobileLogger, tracer, rateLimitMobile),
 		}
 	}
 
@@ -750,7 +857,7 @@ func main() {
 			var configDBModule = createConfigurationDBModule(configurationRwDBConn, influxMetrics, registerLogger)
 
 			// module for storing and retrieving details of the self-registered users
-			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, registerLogger)
+			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, registerLogger)
 
 			// new module for register service
 			registerComponentBuilder := register.NewComponentBuilder(keycloakPublicURL, keycloakClient, technicalTokenProvider, usersDBModule, configDBModule, eventsDBModule, registerLogger)
@@ -790,20 +897,22 @@ func main() {
 		eventsDBModule := database.NewEventsDBModule(eventsDBConn)
 
 		// module for storing and retrieving details of the users
-		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, kycLogger)
+		var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, kycLogger)
 
 		// module for archiving users
 		var archiveDBModule = keycloakb.NewArchiveDBModule(archiveRwDBConn, archiveAesEncryption, kycLogger)
 
+		// module for reading the admin configuration of the realms
+		var configDBModule = keycloakb.NewConfigurationDBModule(configurationRoDBConn, kycLogger)
+
 		// accreditations module
-		var accredsModule keycloakb.AccreditationsModule
-		{
-			var configurationReaderDBModule = configuration.NewConfigurationReaderDBModule(configurationRoDBConn, kycLogger)
-			accredsModule = keycloakb.NewAccreditationsModule(keycloakClient, configurationReaderDBModule, kycLogger)
-		}
+		var accredsModule = keycloakb.NewAccreditationsModule(keycloakClient, usersDBModule, configDBModule, kycLogger)
+
+		// module detecting users registered twice
+		var duplicatesModule = keycloakb.NewDuplicatesModule(keycloakClient, usersDBModule, configDBModule, eventsDBModule, kycLogger)
 
 		// new module for KYC service
-		kycComponent := kyc.NewComponent(technicalTokenProvider, registerRealm, keycloakClient, usersDBModule, archiveDBModule, eventsDBModule, accredsModule, kycLogger)
+		kycComponent := kyc.NewComponent(technicalTokenProvider, registerRealm, keycloakClient, usersDBModule, archiveDBModule, eventsDBModule, accredsModule, duplicatesModule, kycLogger)
 		kycComponent = kyc.MakeAuthorizationRegisterComponentMW(registerRealm, authorizationManager, endpointPhysicalCheckAvailabilityChecker, log.With(kycLogger, "mw", "endpoint"))(kycComponent)
 
 		var rateLimitKyc = rateLimit[RateKeyKYC]
@@ -921,15 +1030,29 @@ func main() {
 
 		var getClientsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClients)
 		var getClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClient)
+		var createClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateClient)
+		var updateClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateClient)
+		var disableClientHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DisableClient)
+		var updateClientRedirectURIsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateRedirectURIs)
+		var rotateClientSecretHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RotateClientSecret)
 
 		var getRequiredActionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRequiredActions)
 
 		var createUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateUser)
+		var importUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ImportUsers)
+		var exportUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ExportUsers)
+		var lookupUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.LookupUsers)
+		var getDuplicateUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetDuplicateUsers)
 		var getUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUser)
 		var updateUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateUser)
 		var lockUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.LockUser)
 		var unlockUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UnlockUser)
+		var getUserAccreditationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUserAccreditations)
+		var getAccreditationsHistoryHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAccreditationsHistory)
+		var revokeAccreditationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RevokeAccreditation)
+		var reinstateAccreditationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ReinstateAccreditation)
 		var deleteUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteUser)
+		var restoreUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RestoreUser)
 		var getUsersHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetUsers)
 		var getRolesForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRolesOfUser)
 		var getGroupsForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetGroupsOfUser)
@@ -943,17 +1066,32 @@ func main() {
 
 		var getClientRoleForUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClientRoleForUser)
 		var addClientRoleToUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddClientRoleToUser)
+		var deleteClientRoleFromUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteClientRoleFromUser)
+		var addRealmRoleToUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.AddRealmRoleToUser)
+		var deleteRealmRoleFromUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteRealmRoleFromUser)
 
 		var getRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRoles)
 		var getRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetRole)
+		var createRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateRole)
+		var deleteRoleHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteRole)
 		var getClientRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetClientRoles)
 		var createClientRolesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateClientRole)
 
 		var getGroupsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetGroups)
 		var createGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CreateGroup)
+		var updateGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateGroup)
 		var deleteGroupHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteGroup)
 		var getAuthorizationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAuthorizations)
 		var updateAuthorizationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateAuthorizations)
+		var explainAuthorizationHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ExplainAuthorization)
+		var copyAuthorizationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.CopyAuthorizations)
+		var getAuthorizationTemplatesHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAuthorizationTemplates)
+		var updateAuthorizationTemplateHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.UpdateAuthorizationTemplate)
+		var deleteAuthorizationTemplateHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.DeleteAuthorizationTemplate)
+		var applyAuthorizationTemplateHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ApplyAuthorizationTemplate)
+		var getAuthorizationVersionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAuthorizationVersions)
+		var getAuthorizationsDiffHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetAuthorizationsDiff)
+		var rollbackAuthorizationsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RollbackAuthorizations)
 		var getManagementActionsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetActions)
 
 		var resetPasswordHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ResetPassword)
@@ -980,6 +1118,11 @@ func main() {
 
 		var linkShadowUserHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.LinkShadowUser)
 
+		var getPendingRequestsHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetPendingRequests)
+		var getPendingRequestHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.GetPendingRequest)
+		var approvePendingRequestHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.ApprovePendingRequest)
+		var rejectPendingRequestHandler = configureManagementHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(managementEndpoints.RejectPendingRequest)
+
 		// actions
 		managementSubroute.Path("/actions").Methods("GET").Handler(getManagementActionsHandler)
 
@@ -989,7 +1132,12 @@ func main() {
 
 		// clients
 		managementSubroute.Path("/realms/{realm}/clients").Methods("GET").Handler(getClientsHandler)
+		managementSubroute.Path("/realms/{realm}/clients").Methods("POST").Handler(createClientHandler)
 		managementSubroute.Path("/realms/{realm}/clients/{clientID}").Methods("GET").Handler(getClientHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}").Methods("PUT").Handler(updateClientHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}/disable").Methods("PUT").Handler(disableClientHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}/redirect-uris").Methods("PUT").Handler(updateClientRedirectURIsHandler)
+		managementSubroute.Path("/realms/{realm}/clients/{clientID}/client-secret").Methods("POST").Handler(rotateClientSecretHandler)
 
 		// required-actions
 		managementSubroute.Path("/realms/{realm}/required-actions").Methods("GET").Handler(getRequiredActionsHandler)
@@ -1000,11 +1148,20 @@ func main() {
 		// users
 		managementSubroute.Path("/realms/{realm}/users").Methods("GET").Handler(getUsersHandler)
 		managementSubroute.Path("/realms/{realm}/users").Methods("POST").Handler(createUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/import").Methods("POST").Handler(importUsersHandler)
+		managementSubroute.Path("/realms/{realm}/users/export").Methods("GET").Handler(exportUsersHandler)
+		managementSubroute.Path("/realms/{realm}/users/lookup").Methods("POST").Handler(lookupUsersHandler)
+		managementSubroute.Path("/realms/{realm}/users/duplicates").Methods("GET").Handler(getDuplicateUsersHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}").Methods("GET").Handler(getUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}").Methods("PUT").Handler(updateUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}").Methods("DELETE").Handler(deleteUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/restore").Methods("POST").Handler(restoreUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/lock").Methods("PUT").Handler(lockUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/unlock").Methods("PUT").Handler(unlockUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/accreditations").Methods("GET").Handler(getUserAccreditationsHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/accreditations/history").Methods("GET").Handler(getAccreditationsHistoryHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/accreditations/{accreditationType}/revoke").Methods("PUT").Handler(revokeAccreditationHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/accreditations/{accreditationType}/reinstate").Methods("PUT").Handler(reinstateAccreditationHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/groups").Methods("GET").Handler(getGroupsForUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/groups/{groupID}").Methods("POST").Handler(addGroupToUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/groups/{groupID}").Methods("DELETE").Handler(deleteGroupForUserHandler)
@@ -1017,6 +1174,9 @@ func main() {
 		// role mappings
 		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}").Methods("GET").Handler(getClientRoleForUserHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}").Methods("POST").Handler(addClientRoleToUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/clients/{clientID}").Methods("DELETE").Handler(deleteClientRoleFromUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/realm").Methods("POST").Handler(addRealmRoleToUserHandler)
+		managementSubroute.Path("/realms/{realm}/users/{userID}/role-mappings/realm").Methods("DELETE").Handler(deleteRealmRoleFromUserHandler)
 
 		managementSubroute.Path("/realms/{realm}/users/{userID}/reset-password").Methods("PUT").Handler(resetPasswordHandler)
 		managementSubroute.Path("/realms/{realm}/users/{userID}/execute-actions-email").Methods("PUT").Handler(executeActionsEmailHandler)
@@ -1035,16 +1195,30 @@ func main() {
 
 		// roles
 		managementSubroute.Path("/realms/{realm}/roles").Methods("GET").Handler(getRolesHandler)
+		managementSubroute.Path("/realms/{realm}/roles").Methods("POST").Handler(createRoleHandler)
 		managementSubroute.Path("/realms/{realm}/roles-by-id/{roleID}").Methods("GET").Handler(getRoleHandler)
+		managementSubroute.Path("/realms/{realm}/roles-by-id/{roleID}").Methods("DELETE").Handler(deleteRoleHandler)
 		managementSubroute.Path("/realms/{realm}/clients/{clientID}/roles").Methods("GET").Handler(getClientRolesHandler)
 		managementSubroute.Path("/realms/{realm}/clients/{clientID}/roles").Methods("POST").Handler(createClientRolesHandler)
 
 		// groups
 		managementSubroute.Path("/realms/{realm}/groups").Methods("GET").Handler(getGroupsHandler)
 		managementSubroute.Path("/realms/{realm}/groups").Methods("POST").Handler(createGroupHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}").Methods("PUT").Handler(updateGroupHandler)
 		managementSubroute.Path("/realms/{realm}/groups/{groupID}").Methods("DELETE").Handler(deleteGroupHandler)
 		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations").Methods("GET").Handler(getAuthorizationsHandler)
 		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations").Methods("PUT").Handler(updateAuthorizationsHandler)
+		managementSubroute.Path("/realms/{realm}/authorizations/explain").Methods("POST").Handler(explainAuthorizationHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations/copy").Methods("POST").Handler(copyAuthorizationsHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations/template").Methods("PUT").Handler(applyAuthorizationTemplateHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations/versions").Methods("GET").Handler(getAuthorizationVersionsHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations/versions/diff").Methods("GET").Handler(getAuthorizationsDiffHandler)
+		managementSubroute.Path("/realms/{realm}/groups/{groupID}/authorizations/versions/{version}/rollback").Methods("POST").Handler(rollbackAuthorizationsHandler)
+
+		// authorization templates
+		managementSubroute.Path("/authorization-templates").Methods("GET").Handler(getAuthorizationTemplatesHandler)
+		managementSubroute.Path("/authorization-templates/{templateName}").Methods("PUT").Handler(updateAuthorizationTemplateHandler)
+		managementSubroute.Path("/authorization-templates/{templateName}").Methods("DELETE").Handler(deleteAuthorizationTemplateHandler)
 
 		// custom configuration per realm
 		managementSubroute.Path("/realms/{realm}/configuration").Methods("GET").Handler(getRealmCustomConfigurationHandler)
@@ -1059,6 +1233,12 @@ func main() {
 		// brokering - shadow users
 		managementSubroute.Path("/realms/{realm}/users/{userID}/federated-identity/{provider}").Methods("POST").Handler(linkShadowUserHandler)
 
+		// operations waiting for the approval of a second operator
+		managementSubroute.Path("/realms/{realm}/pending-requests").Methods("GET").Handler(getPendingRequestsHandler)
+		managementSubroute.Path("/realms/{realm}/pending-requests/{requestID}").Methods("GET").Handler(getPendingRequestHandler)
+		managementSubroute.Path("/realms/{realm}/pending-requests/{requestID}/approve").Methods("POST").Handler(approvePendingRequestHandler)
+		managementSubroute.Path("/realms/{realm}/pending-requests/{requestID}/reject").Methods("POST").Handler(rejectPendingRequestHandler)
+
 		// KYC handlers
 		var kycGetActionsHandler = configureKYCHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, endpointPhysicalCheckAvailabilityChecker, false, logger)(kycEndpoints.GetActions)
 		var kycGetUserInSocialRealmHandler = configureKYCHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, endpointPhysicalCheckAvailabilityChecker, true, logger)(kycEndpoints.GetUserInSocialRealm)
@@ -1099,6 +1279,7 @@ func main() {
 
 		// Account
 		var updatePasswordHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.UpdatePassword)
+		var checkPasswordHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.CheckPassword)
 		var getCredentialsHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.GetCredentials)
 		var getCredentialRegistratorsHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.GetCredentialRegistrators)
 		var deleteCredentialHandler = configureAccountHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, audienceRequired, tracer, logger)(accountEndpoints.DeleteCredential)
@@ -1119,6 +1300,7 @@ func main() {
 
 		route.Path("/account/credentials").Methods("GET").Handler(getCredentialsHandler)
 		route.Path("/account/credentials/password").Methods("POST").Handler(updatePasswordHandler)
+		route.Path("/account/credentials/password/check").Methods("POST").Handler(checkPasswordHandler)
 		route.Path("/account/credentials/registrators").Methods("GET").Handler(getCredentialRegistratorsHandler)
 		route.Path("/account/credentials/{credentialID}").Methods("DELETE").Handler(deleteCredentialHandler)
 		route.Path("/account/credentials/{credentialID}").Methods("PUT").Handler(updateLabelCredentialHandler)
@@ -1155,6 +1337,17 @@ func main() {
 
 		route.Path("/mobile/userinfo").Methods("GET").Handler(getUserInfoHandler)
 
+		// Accreditation credentials. Keys and status lists are public: verifiers check the credentials without any account
+		if credentialSigner != nil {
+			var getAccreditationCredentialsHandler = configureMobileHandler(keycloakb.ComponentName, ComponentID, idGenerator, keycloakClient, mobileAudienceRequired, tracer, logger)(mobileEndpoints.GetAccreditationCredentials)
+			var getCredentialsJWKSHandler = configurePublicMobileHandler(keycloakb.ComponentName, ComponentID, idGenerator, tracer, logger)(mobileEndpoints.GetCredentialsJWKS)
+			var getCredentialsStatusListHandler = configurePublicMobileHandler(keycloakb.ComponentName, ComponentID, idGenerator, tracer, logger)(mobileEndpoints.GetCredentialsStatusList)
+
+			route.Path("/mobile/credentials").Methods("GET").Handler(getAccreditationCredentialsHandler)
+			route.Path("/mobile/credentials/jwks").Methods("GET").Handler(getCredentialsJWKSHandler)
+			route.Path("/mobile/credentials/status/{realm}").Methods("GET").Handler(getCredentialsStatusListHandler)
+		}
+
 		var handler http.Handler = route
 
 		if accessLogsEnabled {
@@ -1210,6 +1403,101 @@ func main() {
 		}()
 	}
 
+	// Blind indexes backfill.
+	if c.GetBool(cfgDbBlindIndexBackfill) {
+		go func() {
+			var backfillLogger = log.With(logger, "svc", "blind-index-backfill")
+			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, backfillLogger)
+			var backfill = keycloakb.NewBlindIndexBackfill(usersDBModule, keycloakClient, technicalTokenProvider, backfillLogger)
+			if count, err := backfill.Run(context.Background()); err != nil {
+				backfillLogger.Error(ctx, "msg", "Blind indexes backfill failed", "count", count, "err", err.Error())
+			} else {
+				backfillLogger.Info(ctx, "msg", "Blind indexes backfill completed", "count", count)
+			}
+		}()
+	}
+
+	// Automatic unlock of users whose lock expired.
+	if autoUnlockInterval := c.GetDuration(cfgAutoUnlockInterval); autoUnlockInterval > 0 {
+		go func() {
+			var autoUnlockLogger = log.With(logger, "svc", "auto-unlock")
+			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, autoUnlockLogger)
+			var eventsDBModule = database.NewEventsDBModule(eventsDBConn)
+			var autoUnlock = keycloakb.NewAutoUnlock(usersDBModule, keycloakClient, technicalTokenProvider, eventsDBModule, autoUnlockLogger)
+			var tic = time.NewTicker(autoUnlockInterval)
+			defer tic.Stop()
+			for range tic.C {
+				if count, err := autoUnlock.Run(context.Background()); err != nil {
+					autoUnlockLogger.Error(ctx, "msg", "Automatic unlock of users failed", "err", err.Error())
+				} else if count > 0 {
+					autoUnlockLogger.Info(ctx, "msg", "Users automatically unlocked", "count", count)
+				}
+			}
+		}()
+	}
+
+	// Deactivation of expired and inactive accounts.
+	if accountDeactivationInterval := c.GetDuration(cfgDeactivationInterval); accountDeactivationInterval > 0 {
+		go func() {
+			var deactivationLogger = log.With(logger, "svc", "account-deactivation")
+			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, deactivationLogger)
+			var configDBModule = keycloakb.NewConfigurationDBModule(configurationRoDBConn, deactivationLogger)
+			var eventsRODBModule = keycloakb.NewEventsDBModule(eventsRODBConn)
+			var eventsDBModule = database.NewEventsDBModule(eventsDBConn)
+			var deactivation = keycloakb.NewAccountDeactivation(technicalRealm, usersDBModule, configDBModule, eventsRODBModule, keycloakClient,
+				technicalTokenProvider, eventsDBModule, deactivationLogger)
+			var tic = time.NewTicker(accountDeactivationInterval)
+			defer tic.Stop()
+			for range tic.C {
+				if count, err := deactivation.Run(context.Background()); err != nil {
+					deactivationLogger.Error(ctx, "msg", "Deactivation of accounts failed", "err", err.Error())
+				} else if count > 0 {
+					deactivationLogger.Info(ctx, "msg", "Accounts deactivated", "count", count)
+				}
+			}
+		}()
+	}
+
+	// Purge of soft deleted users whose retention period is over.
+	if userPurgeInterval := c.GetDuration(cfgUserPurgeInterval); userPurgeInterval > 0 {
+		go func() {
+			var purgeLogger = log.With(logger, "svc", "user-purge")
+			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, purgeLogger)
+			var eventsDBModule = database.NewEventsDBModule(eventsDBConn)
+			var purge = keycloakb.NewUserPurge(usersDBModule, keycloakClient, technicalTokenProvider, eventsDBModule, purgeLogger)
+			var tic = time.NewTicker(userPurgeInterval)
+			defer tic.Stop()
+			for range tic.C {
+				if count, err := purge.Run(context.Background()); err != nil {
+					purgeLogger.Error(ctx, "msg", "Purge of deleted users failed", "err", err.Error())
+				} else if count > 0 {
+					purgeLogger.Info(ctx, "msg", "Deleted users purged", "count", count)
+				}
+			}
+		}()
+	}
+
+	// Reminders of expiring accreditations and audit of the expired ones.
+	if accredExpiryInterval := c.GetDuration(cfgAccredExpiryInterval); accredExpiryInterval > 0 {
+		go func() {
+			var accredExpiryLogger = log.With(logger, "svc", "accreditation-expiry")
+			var usersDBModule = keycloakb.NewUsersDetailsDBModule(usersRwDBConn, aesEncryption, blindIndexer, accredExpiryLogger)
+			var configDBModule = keycloakb.NewConfigurationDBModule(configurationRoDBConn, accredExpiryLogger)
+			var eventsDBModule = database.NewEventsDBModule(eventsDBConn)
+			var accredExpiry = keycloakb.NewAccreditationExpiry(technicalRealm, usersDBModule, configDBModule, keycloakClient, technicalTokenProvider,
+				eventsDBModule, accredExpiryLogger)
+			var tic = time.NewTicker(accredExpiryInterval)
+			defer tic.Stop()
+			for range tic.C {
+				if count, err := accredExpiry.Run(context.Background()); err != nil {
+					accredExpiryLogger.Error(ctx, "msg", "Processing of expiring accreditations failed", "err", err.Error())
+				} else if count > 0 {
+					accredExpiryLogger.Info(ctx, "msg", "Accreditation expiry notifications sent", "count", count)
+				}
+			}
+		}()
+	}
+
 	// Influx writing.
 	go func() {
 		var tic = time.NewTicker(influxWriteInterval)
@@ -1245,6 +1533,11 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	// Security - Audience check
 	v.SetDefault(cfgAudienceRequired, "")
 	v.SetDefault(cfgMobileAudienceRequired, "")
+
+	// Security - Mobile credentials
+	v.SetDefault(cfgCredentialsSigningKey, "")
+	v.SetDefault(cfgCredentialsKeyID, "")
+	v.SetDefault(cfgCredentialsIssuer, "")
 	v.SetDefault(cfgTrustIDGroups,
 		[]string{
 			"l1_support_agent",
@@ -1257,6 +1550,13 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	v.SetDefault(cfgDbAesGcmKey, "")
 	v.SetDefault(cfgDbArchiveAesGcmTagSize, 16)
 	v.SetDefault(cfgDbArchiveAesGcmKey, "")
+	v.SetDefault(cfgDbHmacKey, "")
+	v.SetDefault(cfgDbBlindIndexBackfill, false)
+	v.SetDefault(cfgAutoUnlockInterval, "1m")
+	v.SetDefault(cfgDeactivationInterval, "24h")
+	v.SetDefault(cfgUserPurgeInterval, "1h")
+	v.SetDefault(cfgAccredExpiryInterval, "24h")
+	v.SetDefault(cfgBreachedPasswordsDir, "")
 
 	// CORS configuration
 	v.SetDefault(cfgAllowedOrigins, []string{})
@@ -1375,6 +1675,12 @@ func config(ctx context.Context, logger log.Logger) *viper.Viper {
 	v.BindEnv(cfgDbAesGcmKey, "CT_BRIDGE_DB_AES_KEY")
 	censoredParameters[cfgDbAesGcmKey] = true
 
+	v.BindEnv(cfgDbHmacKey, "CT_BRIDGE_DB_HMAC_KEY")
+	censoredParameters[cfgDbHmacKey] = true
+
+	v.BindEnv(cfgCredentialsSigningKey, "CT_BRIDGE_MOBILE_CREDENTIALS_SIGNING_KEY")
+	censoredParameters[cfgCredentialsSigningKey] = true
+
 	// Load and log config.
 	v.SetConfigFile(v.GetString(cfgConfigFile))
 	var err = v.ReadInConfig()
@@ -1497,6 +1803,15 @@ func configureMobileHandler(ComponentName string, ComponentID string, idGenerato
 	}
 }
 
+func configurePublicMobileHandler(ComponentName string, ComponentID string, idGenerator idgenerator.IDGenerator, tracer tracing.OpentracingClient, logger log.Logger) func(endpoint endpoint.Endpoint) http.Handler {
+	return func(endpoint endpoint.Endpoint) http.Handler {
+		var handler http.Handler
+		handler = mobile.MakeMobileHandler(endpoint, logger)
+		handler = middleware.MakeHTTPCorrelationIDMW(idGenerator, tracer, logger, ComponentName, ComponentID)(handler)
+		return handler
+	}
+}
+
 func configureKYCHandler(ComponentName string, ComponentID string, idGenerator idgenerator.IDGenerator, keycloakClient *keycloakapi.Client,
 	audienceRequired string, tracer tracing.OpentracingClient, availabilityChecker middleware.EndpointAvailabilityChecker,
 	verifyAvailableChecks bool, logger log.Logger) func(endpoint endpoint.Endpoint) http.Handler {
@@ -15,6 +15,9 @@ const (
 	MsgErrUnknown              = "unknowError"
 	MsgErrNotConfigured        = "notConfigured"
 	MsgErrUnverified           = "unverifiedFlag"
+	MsgErrAlreadyResolved      = "alreadyResolved"
+	MsgErrDuplicate            = "duplicate"
+	MsgErrPreconditionFailed   = "preconditionFailed"
 
 	BodyContent                       = "bodyContent"
 	RealmConfiguration                = "realmConfiguration"
@@ -69,10 +72,34 @@ const (
 	Groups                            = "groups"
 	ClientID                          = "clientId"
 	RedirectURI                       = "redirectURI"
+	RedirectURIs                      = "redirectUris"
+	WebOrigins                        = "webOrigins"
+	RootURL                           = "rootUrl"
+	BaseURL                           = "baseUrl"
+	Protocol                          = "protocol"
 	Exclude                           = "exclude"
 	Unit                              = "unit"
 	Max                               = "max"
 	Timeshift                         = "timeshift"
 	IdentityProvider                  = "identityProvider"
 	TrustIDGroupName                  = "trustIDGroupName"
+	Format                            = "format"
+	Reason                            = "reason"
+	Comment                           = "comment"
+	UnlockAt                          = "unlockAt"
+	AccountExpiryDate                 = "accountExpiryDate"
+	Action                            = "action"
+	TargetRealm                       = "targetRealm"
+	TargetGroup                       = "targetGroup"
+	TargetGroupID                     = "targetGroupId"
+	RealmMapping                      = "realmMapping"
+	GroupMapping                      = "groupMapping"
+	TemplateName                      = "templateName"
+	Version                           = "version"
+	FromVersion                       = "from"
+	ToVersion                         = "to"
+	PendingRequest                    = "pendingRequest"
+	PendingRequestID                  = "pendingRequestId"
+	DeletedUser                       = "deletedUser"
+	Accreditation                     = "accreditation"
 )
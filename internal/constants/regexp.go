@@ -10,7 +10,10 @@ const (
 	RegExpDescription = regExpLen255
 
 	// Client
-	RegExpClientID = `^[a-zA-Z0-9-_.]{1,255}$`
+	RegExpClientID          = `^[a-zA-Z0-9-_.]{1,255}$`
+	RegExpClientName        = regExpLen255
+	RegExpClientRedirectURI = `^(\w+:(\/?\/?)[^\s]+|\/[^\s]*|\*)$`
+	RegExpWebOrigin         = `^(\w+:\/\/[^\s\/]+|\*|\+)$`
 
 	// User
 	RegExpUsername         = `^[a-zA-Z0-9-_.]{1,128}$`
@@ -41,6 +44,18 @@ const (
 	RegExpLifespan  = `^[0-9]{1,10}$`
 	RegExpGroupIds  = `^([a-z0-9]{8}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{12})(,[a-z0-9]{8}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{12}){0,20}$`
 	RegExpNumber    = `^\d+$`
+	RegExpBool      = `^(true|false)$`
+
+	// User locks
+	RegExpLockReason  = `^[a-zA-Z0-9_-]{1,64}$`
+	RegExpLockComment = `^(?s).{1,500}$`
+
+	// Accreditations
+	RegExpAccreditationReason = regExpLen255
+	RegExpEmailTemplate       = `^[a-zA-Z0-9_-]{1,128}\.ftl$`
+
+	// Users import/export
+	RegExpUsersFileFormat = `^(csv|ndjson)$`
 )
 
 var (
@@ -11,6 +11,7 @@ import (
 type ArchiveUserRepresentation struct {
 	ID                   *string                              `json:"-"`
 	Username             *string                              `json:"username,omitempty"`
+	Enabled              *bool                                `json:"enabled,omitempty"`
 	Gender               *string                              `json:"gender,omitempty"`
 	FirstName            *string                              `json:"firstName,omitempty"`
 	LastName             *string                              `json:"lastName,omitempty"`
@@ -33,9 +34,12 @@ type ArchiveUserRepresentation struct {
 
 // ArchiveAccreditationRepresentation is a representation of accreditations
 type ArchiveAccreditationRepresentation struct {
-	Type       *string `json:"type"`
-	ExpiryDate *string `json:"expiryDate"`
-	Revoked    *bool   `json:"revoked,omitempty"`
+	Type             *string `json:"type"`
+	ExpiryDate       *string `json:"expiryDate"`
+	Revoked          *bool   `json:"revoked,omitempty"`
+	RevocationDate   *string `json:"revocationDate,omitempty"`
+	RevocationReason *string `json:"revocationReason,omitempty"`
+	RevokedBy        *string `json:"revokedBy,omitempty"`
 }
 
 // ToArchiveUserRepresentation converts a Keycloak user to an ArchiveUserRepresentation
@@ -56,6 +60,7 @@ func ToArchiveUserRepresentation(user kc.UserRepresentation) ArchiveUserRepresen
 	return ArchiveUserRepresentation{
 		ID:                  user.ID,
 		Username:            user.Username,
+		Enabled:             user.Enabled,
 		Gender:              gender,
 		FirstName:           user.FirstName,
 		LastName:            user.LastName,
@@ -19,6 +19,7 @@ func TestToArchiveUserRepresentation(t *testing.T) {
 	var bFalse = false
 	var kcUser = kc.UserRepresentation{
 		ID:         ptr("user-id"),
+		Enabled:    &bFalse,
 		Attributes: &attrbs,
 	}
 	var accred = ArchiveAccreditationRepresentation{
@@ -32,6 +33,7 @@ func TestToArchiveUserRepresentation(t *testing.T) {
 	t.Run("No accreditations", func(t *testing.T) {
 		var user = ToArchiveUserRepresentation(kcUser)
 		assert.Equal(t, kcUser.ID, user.ID)
+		assert.Equal(t, kcUser.Enabled, user.Enabled)
 		assert.Len(t, user.Accreditations, 0)
 	})
 
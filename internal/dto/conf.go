@@ -1,4 +1,168 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/cloudtrust/common-service/configuration"
+)
+
 // BackOfficeConfiguration definition
 type BackOfficeConfiguration map[string]map[string][]string
+
+// RealmAdminConfiguration is the admin configuration of a realm. It completes the common admin configuration with
+// the bridge specific policies. Embedded fields are serialized at the same level to remain readable by the common reader
+type RealmAdminConfiguration struct {
+	configuration.RealmAdminConfiguration
+	AccountDeactivation   *AccountDeactivationPolicy `json:"account-deactivation,omitempty"`
+	FourEyesActions       []string                   `json:"four-eyes-actions,omitempty"`
+	SoftDeletion          *SoftDeletionPolicy        `json:"soft-deletion,omitempty"`
+	DuplicateCheck        *string                    `json:"duplicate-check,omitempty"`
+	BreachedPasswordCheck *bool                      `json:"breached-password-check,omitempty"`
+	AccreditationExpiry   *AccreditationExpiryPolicy `json:"accreditation-expiry,omitempty"`
+}
+
+// Actions taken when a user validation creates a duplicate identity
+const (
+	DuplicateCheckWarn  = "warn"
+	DuplicateCheckBlock = "block"
+)
+
+// AccountDeactivationPolicy describes when accounts of a realm are automatically disabled
+type AccountDeactivationPolicy struct {
+	InactivityDays *int `json:"inactivity-days,omitempty"`
+	WarningDays    *int `json:"warning-days,omitempty"`
+}
+
+// AccreditationExpiryPolicy describes when users are reminded that their accreditations are about to expire
+type AccreditationExpiryPolicy struct {
+	WarningDays   *int    `json:"warning-days,omitempty"`
+	EmailTemplate *string `json:"email-template,omitempty"`
+	EmailSubject  *string `json:"email-subject,omitempty"`
+}
+
+// SoftDeletionPolicy describes how long deleted users are kept disabled before being purged
+type SoftDeletionPolicy struct {
+	RetentionDays *int `json:"retention-days,omitempty"`
+}
+
+// Status of the pending requests
+const (
+	PendingRequestStatusPending  = "PENDING"
+	PendingRequestStatusApproved = "APPROVED"
+	PendingRequestStatusRejected = "REJECTED"
+	PendingRequestStatusFailed   = "FAILED"
+)
+
+// PendingRequest is a management operation waiting for the approval of a second operator. Payload is the JSON body of the
+// operation and Secret the encrypted password of a password reset
+type PendingRequest struct {
+	ID             int64
+	RealmName      string
+	Action         string
+	UserID         *string
+	GroupID        *string
+	CredentialID   *string
+	Payload        *string
+	Secret         []byte
+	RequesterRealm string
+	Requester      string
+	RequestDate    time.Time
+	Status         string
+	ApproverRealm  *string
+	Approver       *string
+	ResolutionDate *time.Time
+}
+
+// AuthorizationVersion is a recorded state of the authorizations of a group, with the changes made since the previous version
+type AuthorizationVersion struct {
+	RealmID        string
+	GroupName      string
+	Version        int
+	AuthorRealm    string
+	Author         string
+	Date           time.Time
+	Authorizations []AuthorizationRule
+	Diff           AuthorizationsDiff
+}
+
+// AuthorizationRule is an authorization of a group, without the realm and the name of the group
+type AuthorizationRule struct {
+	Action          string  `json:"action"`
+	TargetRealmID   *string `json:"target-realm-id,omitempty"`
+	TargetGroupName *string `json:"target-group-name,omitempty"`
+}
+
+// AuthorizationsDiff lists the authorization rules added and removed between two versions
+type AuthorizationsDiff struct {
+	Added   []AuthorizationRule `json:"added"`
+	Removed []AuthorizationRule `json:"removed"`
+}
+
+// ToAuthorizationRules converts authorizations to rules
+func ToAuthorizationRules(authorizations []configuration.Authorization) []AuthorizationRule {
+	var rules = make([]AuthorizationRule, 0, len(authorizations))
+	for _, authz := range authorizations {
+		var rule = AuthorizationRule{
+			TargetRealmID:   authz.TargetRealmID,
+			TargetGroupName: authz.TargetGroupName,
+		}
+		if authz.Action != nil {
+			rule.Action = *authz.Action
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ToAuthorizations converts rules to the authorizations of the given group
+func ToAuthorizations(realmID, groupName string, rules []AuthorizationRule) []configuration.Authorization {
+	var authorizations = make([]configuration.Authorization, 0, len(rules))
+	for _, rule := range rules {
+		var action = rule.Action
+		authorizations = append(authorizations, configuration.Authorization{
+			RealmID:         &realmID,
+			GroupName:       &groupName,
+			Action:          &action,
+			TargetRealmID:   rule.TargetRealmID,
+			TargetGroupName: rule.TargetGroupName,
+		})
+	}
+	return authorizations
+}
+
+// DiffAuthorizationRules returns the rules of "to" missing in "from" as added and the rules of "from" missing in "to" as removed
+func DiffAuthorizationRules(from, to []AuthorizationRule) AuthorizationsDiff {
+	var diff = AuthorizationsDiff{
+		Added:   []AuthorizationRule{},
+		Removed: []AuthorizationRule{},
+	}
+	var fromKeys = make(map[string]struct{})
+	for _, rule := range from {
+		fromKeys[rule.key()] = struct{}{}
+	}
+	var toKeys = make(map[string]struct{})
+	for _, rule := range to {
+		toKeys[rule.key()] = struct{}{}
+		if _, ok := fromKeys[rule.key()]; !ok {
+			diff.Added = append(diff.Added, rule)
+		}
+	}
+	for _, rule := range from {
+		if _, ok := toKeys[rule.key()]; !ok {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+	return diff
+}
+
+func (rule AuthorizationRule) key() string {
+	var key = rule.Action + "|"
+	if rule.TargetRealmID != nil {
+		key += "r:" + *rule.TargetRealmID
+	}
+	key += "|"
+	if rule.TargetGroupName != nil {
+		key += "g:" + *rule.TargetGroupName
+	}
+	return key
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/cloudtrust/common-service/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationRulesConversion(t *testing.T) {
+	var realmID = "DEP"
+	var groupName = "dep_admins"
+	var action = "MGMT_GetUser"
+	var targetRealm = "DEP"
+	var targetGroup = "agents"
+	var authorizations = []configuration.Authorization{
+		{RealmID: &realmID, GroupName: &groupName, Action: &action, TargetRealmID: &targetRealm, TargetGroupName: &targetGroup},
+		{RealmID: &realmID, GroupName: &groupName, Action: &action},
+	}
+
+	var rules = ToAuthorizationRules(authorizations)
+	assert.Equal(t, []AuthorizationRule{
+		{Action: action, TargetRealmID: &targetRealm, TargetGroupName: &targetGroup},
+		{Action: action},
+	}, rules)
+
+	assert.Equal(t, authorizations, ToAuthorizations(realmID, groupName, rules))
+}
+
+func TestDiffAuthorizationRules(t *testing.T) {
+	var dep = "DEP"
+	var other = "OTHER"
+	var agents = "agents"
+	var getUser = AuthorizationRule{Action: "MGMT_GetUser", TargetRealmID: &dep, TargetGroupName: &agents}
+	var getUserOther = AuthorizationRule{Action: "MGMT_GetUser", TargetRealmID: &other, TargetGroupName: &agents}
+	var getRealm = AuthorizationRule{Action: "MGMT_GetRealm", TargetRealmID: &dep}
+	var getActions = AuthorizationRule{Action: "MGMT_GetActions"}
+
+	t.Run("No changes", func(t *testing.T) {
+		var diff = DiffAuthorizationRules([]AuthorizationRule{getUser, getRealm}, []AuthorizationRule{getRealm, getUser})
+		assert.Len(t, diff.Added, 0)
+		assert.Len(t, diff.Removed, 0)
+	})
+	t.Run("From nothing", func(t *testing.T) {
+		var diff = DiffAuthorizationRules(nil, []AuthorizationRule{getUser})
+		assert.Equal(t, []AuthorizationRule{getUser}, diff.Added)
+		assert.Len(t, diff.Removed, 0)
+	})
+	t.Run("Added and removed", func(t *testing.T) {
+		var diff = DiffAuthorizationRules([]AuthorizationRule{getUser, getRealm}, []AuthorizationRule{getUserOther, getRealm, getActions})
+		assert.Equal(t, []AuthorizationRule{getUserOther, getActions}, diff.Added)
+		assert.Equal(t, []AuthorizationRule{getUser}, diff.Removed)
+	})
+}
@@ -2,6 +2,9 @@ package dto
 
 import (
 	"time"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	kc "github.com/cloudtrust/keycloak-client"
 )
 
 // DBUser struct
@@ -13,6 +16,24 @@ type DBUser struct {
 	IDDocumentNumber     *string `json:"id_document_num,omitempty"`
 	IDDocumentExpiration *string `json:"id_document_exp,omitempty"`
 	IDDocumentCountry    *string `json:"id_document_country,omitempty"`
+
+	// Identity is stored in Keycloak: these values are not persisted with the details, they are only used to maintain the identity blind index
+	FirstName *string `json:"-"`
+	LastName  *string `json:"-"`
+	BirthDate *string `json:"-"`
+}
+
+// SetIdentity sets the identity values used to maintain the identity blind index
+func (u *DBUser) SetIdentity(kcUser kc.UserRepresentation) {
+	u.FirstName = kcUser.FirstName
+	u.LastName = kcUser.LastName
+	u.BirthDate = kcUser.GetAttributeString(constants.AttrbBirthDate)
+}
+
+// DBUserKey identifies the details of a user
+type DBUserKey struct {
+	RealmID string
+	UserID  string
 }
 
 // DBCheck struct
@@ -26,3 +47,100 @@ type DBCheck struct {
 	ProofType *string
 	Comment   *string
 }
+
+// DBUserLock is the metadata of a lock put on a user account by an operator
+type DBUserLock struct {
+	RealmID  string
+	UserID   string
+	Reason   string
+	Comment  *string
+	LockedBy *string
+	LockedAt time.Time
+	UnlockAt *time.Time
+}
+
+// DBUserDeletion is a soft deleted user. The user is kept disabled until its purge date
+type DBUserDeletion struct {
+	RealmID      string
+	UserID       string
+	DeletedBy    *string
+	DeletionDate time.Time
+	PurgeDate    time.Time
+}
+
+// Criteria used to detect duplicate users
+const (
+	DuplicateCriteriaIdentity       = "identity"
+	DuplicateCriteriaDocumentNumber = "idDocumentNumber"
+)
+
+// DuplicateUsers is a group of users of a realm sharing the same identity or the same identity document
+type DuplicateUsers struct {
+	Criteria string
+	UserIDs  []string
+}
+
+// DBAccountExpiry is the date after which a user account is automatically disabled
+type DBAccountExpiry struct {
+	RealmID     string
+	UserID      string
+	ExpiryDate  time.Time
+	WarningDate *time.Time
+}
+
+// Notifications sent about an accreditation
+const (
+	AccreditationNotificationReminder = "REMINDER"
+	AccreditationNotificationExpired  = "EXPIRED"
+)
+
+// DBAccreditationNotification is a notification about an accreditation of a user. An accreditation is identified by its type and
+// its expiry date: a renewed accreditation is notified again
+type DBAccreditationNotification struct {
+	RealmID           string
+	UserID            string
+	AccreditationType string
+	ExpiryDate        string
+	Notification      string
+	NotificationDate  time.Time
+}
+
+// Reasons of the revocations of accreditations which are not requested by an operator
+const (
+	AccreditationRevocationIdentityUpdated = "IDENTITY_UPDATED"
+	AccreditationRevocationUserDeleted     = "USER_DELETED"
+)
+
+// DBAccreditation is an accreditation granted to a user. Accreditations are never deleted: the revoked ones keep their revocation
+// details and a reinstated accreditation is granted again
+type DBAccreditation struct {
+	ID               int64
+	RealmID          string
+	UserID           string
+	Type             string
+	GrantDate        time.Time
+	ExpiryDate       *time.Time
+	CheckID          *int64
+	Operator         *string
+	RevocationDate   *time.Time
+	RevocationReason *string
+	RevokedBy        *string
+}
+
+// DBAccreditationRevocation is the revocation of the active accreditations of a user. All the active accreditations are revoked
+// when Type is nil
+type DBAccreditationRevocation struct {
+	RealmID   string
+	UserID    string
+	Type      *string
+	Date      time.Time
+	Reason    string
+	RevokedBy *string
+}
+
+// DBAccreditationsStatus is the status of all the accreditations granted in a realm: the greatest accreditation ID and the
+// IDs of the revoked accreditations
+type DBAccreditationsStatus struct {
+	LastID     int64
+	RevokedIDs []int64
+}
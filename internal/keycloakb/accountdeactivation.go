@@ -0,0 +1,339 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	accountExpiryPageSize = 100
+
+	lockReasonAccountExpired = "ACCOUNT_EXPIRED"
+	lockReasonInactivity     = "INACTIVITY"
+
+	emailTemplateAccountExpiration = "account-expiration-warning.ftl"
+	emailSubjectAccountExpiration  = "accountExpirationWarningSubject"
+	emailTemplateInactivity        = "account-inactivity-warning.ftl"
+	emailSubjectInactivity         = "accountInactivityWarningSubject"
+)
+
+// AccountDeactivationKeycloakClient is the minimum Keycloak client interface for the deactivation of accounts
+type AccountDeactivationKeycloakClient interface {
+	GetRealms(accessToken string) ([]kc.RealmRepresentation, error)
+	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
+	UpdateUser(accessToken string, realmName, userID string, user kc.UserRepresentation) error
+	SendEmail(accessToken string, reqRealmName string, realmName string, emailRep kc.EmailRepresentation) error
+}
+
+// AccountDeactivationUsersDBModule is the minimum users DB module interface for the deactivation of accounts
+type AccountDeactivationUsersDBModule interface {
+	GetExpiringAccounts(ctx context.Context, realm string, until time.Time, now time.Time, max int) ([]dto.DBAccountExpiry, error)
+	SetAccountExpiryWarned(ctx context.Context, realm string, userID string, warningDate time.Time) error
+	StoreAccountExpiry(ctx context.Context, realm string, userID string, expiryDate *time.Time) error
+	GetInactivityWarning(ctx context.Context, realm string, userID string) (*time.Time, error)
+	StoreInactivityWarning(ctx context.Context, realm string, userID string, warningDate time.Time) error
+	DeleteInactivityWarning(ctx context.Context, realm string, userID string) error
+	StoreUserLock(ctx context.Context, lock dto.DBUserLock) error
+}
+
+// AccountDeactivationConfigDBModule is the minimum configuration DB module interface for the deactivation of accounts
+type AccountDeactivationConfigDBModule interface {
+	GetAdminConfiguration(context.Context, string) (dto.RealmAdminConfiguration, error)
+}
+
+// LastConnectionsDBModule gives the last connection of the inactive users
+type LastConnectionsDBModule interface {
+	GetUsersLastConnectionBefore(context.Context, string, time.Time) (map[string]int64, error)
+}
+
+// AccountDeactivation disables the expired and inactive accounts and warns their owners beforehand
+type AccountDeactivation interface {
+	Run(ctx context.Context) (int, error)
+}
+
+type accountDeactivation struct {
+	technicalRealm string
+	usersDBModule  AccountDeactivationUsersDBModule
+	configDBModule AccountDeactivationConfigDBModule
+	eventsDBModule LastConnectionsDBModule
+	keycloakClient AccountDeactivationKeycloakClient
+	tokenProvider  TokenProvider
+	eventsReporter EventsReporter
+	logger         Logger
+	now            func() time.Time
+}
+
+// NewAccountDeactivation creates an AccountDeactivation
+func NewAccountDeactivation(technicalRealm string, usersDBModule AccountDeactivationUsersDBModule, configDBModule AccountDeactivationConfigDBModule,
+	eventsDBModule LastConnectionsDBModule, keycloakClient AccountDeactivationKeycloakClient, tokenProvider TokenProvider,
+	eventsReporter EventsReporter, logger Logger) AccountDeactivation {
+	return &accountDeactivation{
+		technicalRealm: technicalRealm,
+		usersDBModule:  usersDBModule,
+		configDBModule: configDBModule,
+		eventsDBModule: eventsDBModule,
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		eventsReporter: eventsReporter,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Run processes the accounts of all realms. Returns the number of disabled accounts.
+// An account which can't be processed is skipped: it will be processed again during the next run
+func (a *accountDeactivation) Run(ctx context.Context) (int, error) {
+	var accessToken, err = a.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return 0, err
+	}
+
+	realms, err := a.keycloakClient.GetRealms(accessToken)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get realms", "err", err.Error())
+		return 0, err
+	}
+
+	var count = 0
+	for _, realm := range realms {
+		if realm.ID == nil || realm.Realm == nil {
+			continue
+		}
+		var policy = a.getPolicy(ctx, *realm.ID)
+		count += a.processExpiredAccounts(ctx, accessToken, *realm.Realm, policy)
+		count += a.processInactiveAccounts(ctx, accessToken, *realm.Realm, policy)
+	}
+	return count, nil
+}
+
+func (a *accountDeactivation) getPolicy(ctx context.Context, realmID string) dto.AccountDeactivationPolicy {
+	var adminConfig, err = a.configDBModule.GetAdminConfiguration(ctx, realmID)
+	if err != nil {
+		// Realms without admin configuration have no deactivation policy: expiry dates are still processed
+		return dto.AccountDeactivationPolicy{}
+	}
+	if adminConfig.AccountDeactivation == nil {
+		return dto.AccountDeactivationPolicy{}
+	}
+	return *adminConfig.AccountDeactivation
+}
+
+func days(value *int) time.Duration {
+	if value == nil {
+		return 0
+	}
+	return time.Duration(*value) * 24 * time.Hour
+}
+
+func (a *accountDeactivation) processExpiredAccounts(ctx context.Context, accessToken string, realmName string, policy dto.AccountDeactivationPolicy) int {
+	var now = a.now()
+	var expiries, err = a.usersDBModule.GetExpiringAccounts(ctx, realmName, now.Add(days(policy.WarningDays)), now, accountExpiryPageSize)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get expiring accounts", "err", err.Error(), "realm", realmName)
+		return 0
+	}
+
+	var count = 0
+	for _, expiry := range expiries {
+		if expiry.ExpiryDate.After(now) {
+			a.warnAccountExpiration(ctx, accessToken, expiry)
+		} else if a.disableExpiredAccount(ctx, accessToken, expiry) {
+			count++
+		}
+	}
+	return count
+}
+
+func (a *accountDeactivation) warnAccountExpiration(ctx context.Context, accessToken string, expiry dto.DBAccountExpiry) {
+	var user, err = a.getUser(ctx, accessToken, expiry.RealmID, expiry.UserID)
+	if err != nil {
+		return
+	}
+	if user.Enabled != nil && !*user.Enabled {
+		// Nothing to warn about: the account is already disabled
+		return
+	}
+
+	var expiryDate = expiry.ExpiryDate.UTC().Format(constants.SupportedDateLayouts[0])
+	if a.sendEmail(ctx, accessToken, expiry.RealmID, user, emailTemplateAccountExpiration, emailSubjectAccountExpiration, expiryDate) {
+		a.reportEvent(ctx, "ACCOUNT_EXPIRATION_WARNING_EMAIL_SENT", expiry.RealmID, expiry.UserID, user.Username,
+			"expiry_date", expiryDate)
+	}
+	if err = a.usersDBModule.SetAccountExpiryWarned(ctx, expiry.RealmID, expiry.UserID, a.now()); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't store account expiration warning", "err", err.Error(), "realm", expiry.RealmID, "userID", expiry.UserID)
+	}
+}
+
+func (a *accountDeactivation) disableExpiredAccount(ctx context.Context, accessToken string, expiry dto.DBAccountExpiry) bool {
+	var user, err = a.getUser(ctx, accessToken, expiry.RealmID, expiry.UserID)
+	if err != nil && !isNotFound(err) {
+		return false
+	}
+	var disabled = false
+	if err == nil {
+		disabled = a.disableUser(ctx, accessToken, expiry.RealmID, user, lockReasonAccountExpired)
+		if !disabled && (user.Enabled == nil || *user.Enabled) {
+			// Disabling failed: the expiry is kept to retry during the next run
+			return false
+		}
+	}
+	if err = a.usersDBModule.StoreAccountExpiry(ctx, expiry.RealmID, expiry.UserID, nil); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't delete account expiry", "err", err.Error(), "realm", expiry.RealmID, "userID", expiry.UserID)
+	}
+	return disabled
+}
+
+func (a *accountDeactivation) processInactiveAccounts(ctx context.Context, accessToken string, realmName string, policy dto.AccountDeactivationPolicy) int {
+	if policy.InactivityDays == nil {
+		return 0
+	}
+	var now = a.now()
+	var inactivity = days(policy.InactivityDays)
+	var warning = days(policy.WarningDays)
+
+	var lastConnections, err = a.eventsDBModule.GetUsersLastConnectionBefore(ctx, realmName, now.Add(warning-inactivity))
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get last connections", "err", err.Error(), "realm", realmName)
+		return 0
+	}
+
+	var count = 0
+	for userID, lastConnection := range lastConnections {
+		if a.processInactiveAccount(ctx, accessToken, realmName, userID, time.Unix(lastConnection, 0), inactivity, warning) {
+			count++
+		}
+	}
+	return count
+}
+
+func (a *accountDeactivation) processInactiveAccount(ctx context.Context, accessToken string, realmName string, userID string,
+	lastConnection time.Time, inactivity time.Duration, warning time.Duration) bool {
+	var now = a.now()
+	var deadline = lastConnection.Add(inactivity)
+
+	if warning > 0 {
+		var warningDate, err = a.usersDBModule.GetInactivityWarning(ctx, realmName, userID)
+		if err != nil {
+			a.logger.Warn(ctx, "msg", "Can't get inactivity warning", "err", err.Error(), "realm", realmName, "userID", userID)
+			return false
+		}
+		if warningDate == nil || warningDate.Before(lastConnection) {
+			// Users are always warned before their account is disabled
+			a.warnInactivity(ctx, accessToken, realmName, userID, now.Add(warning))
+			return false
+		}
+		if warningDate.Add(warning).After(deadline) {
+			deadline = warningDate.Add(warning)
+		}
+	}
+	if deadline.After(now) {
+		return false
+	}
+
+	var user, err = a.getUser(ctx, accessToken, realmName, userID)
+	if err != nil || !a.disableUser(ctx, accessToken, realmName, user, lockReasonInactivity) {
+		return false
+	}
+	// A user enabled again by an administrator will be warned again before being disabled
+	if err = a.usersDBModule.DeleteInactivityWarning(ctx, realmName, userID); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't delete inactivity warning", "err", err.Error(), "realm", realmName, "userID", userID)
+	}
+	return true
+}
+
+func (a *accountDeactivation) warnInactivity(ctx context.Context, accessToken string, realmName string, userID string, deactivationDate time.Time) {
+	var user, err = a.getUser(ctx, accessToken, realmName, userID)
+	if err != nil || (user.Enabled != nil && !*user.Enabled) {
+		return
+	}
+
+	var formattedDate = deactivationDate.UTC().Format(constants.SupportedDateLayouts[0])
+	if a.sendEmail(ctx, accessToken, realmName, user, emailTemplateInactivity, emailSubjectInactivity, formattedDate) {
+		a.reportEvent(ctx, "INACTIVITY_WARNING_EMAIL_SENT", realmName, userID, user.Username, "deactivation_date", formattedDate)
+	}
+	if err = a.usersDBModule.StoreInactivityWarning(ctx, realmName, userID, a.now()); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't store inactivity warning", "err", err.Error(), "realm", realmName, "userID", userID)
+	}
+}
+
+func (a *accountDeactivation) getUser(ctx context.Context, accessToken string, realmName string, userID string) (kc.UserRepresentation, error) {
+	var user, err = a.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil && !isNotFound(err) {
+		a.logger.Warn(ctx, "msg", "Can't get user from Keycloak", "err", err.Error(), "realm", realmName, "userID", userID)
+	}
+	return user, err
+}
+
+func isNotFound(err error) bool {
+	var httpErr, ok = err.(kc.HTTPError)
+	return ok && httpErr.HTTPStatus == http.StatusNotFound
+}
+
+// disableUser returns true if the user has been disabled by this call
+func (a *accountDeactivation) disableUser(ctx context.Context, accessToken string, realmName string, user kc.UserRepresentation, reason string) bool {
+	if user.Enabled != nil && !*user.Enabled {
+		return false
+	}
+
+	ConvertLegacyAttribute(&user)
+	var enabled = false
+	user.Enabled = &enabled
+	if err := a.keycloakClient.UpdateUser(accessToken, realmName, *user.ID, user); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't disable user", "err", err.Error(), "realm", realmName, "userID", *user.ID)
+		return false
+	}
+
+	var lock = dto.DBUserLock{
+		RealmID:  realmName,
+		UserID:   *user.ID,
+		Reason:   reason,
+		LockedAt: a.now(),
+	}
+	if err := a.usersDBModule.StoreUserLock(ctx, lock); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't store user lock", "err", err.Error(), "realm", realmName, "userID", *user.ID)
+	}
+
+	a.reportEvent(ctx, "LOCK_ACCOUNT", realmName, *user.ID, user.Username, "reason", reason, "automatic", "true")
+	return true
+}
+
+func (a *accountDeactivation) sendEmail(ctx context.Context, accessToken string, realmName string, user kc.UserRepresentation, template string, subject string, date string) bool {
+	if user.Email == nil {
+		return false
+	}
+
+	var templateParameters = map[string]string{"date": date}
+	var emailRep = kc.EmailRepresentation{
+		Recipient: user.Email,
+		Theming: &kc.EmailThemingRepresentation{
+			SubjectKey:         &subject,
+			Template:           &template,
+			TemplateParameters: &templateParameters,
+			Locale:             user.GetAttributeString(constants.AttrbLocale),
+		},
+	}
+	if err := a.keycloakClient.SendEmail(accessToken, a.technicalRealm, realmName, emailRep); err != nil {
+		a.logger.Warn(ctx, "msg", "Could not send email", "err", err.Error(), "template", template, "realm", realmName, "userID", *user.ID)
+		return false
+	}
+	return true
+}
+
+func (a *accountDeactivation) reportEvent(ctx context.Context, apiCall string, realmName string, userID string, username *string, additionalInfo ...string) {
+	var name = ""
+	if username != nil {
+		name = *username
+	}
+	var values = []string{database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, name,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo(additionalInfo...)}
+	if err := a.eventsReporter.ReportEvent(ctx, apiCall, "back-office", values...); err != nil {
+		LogUnrecordedEvent(ctx, a.logger, apiCall, err.Error(), values...)
+	}
+}
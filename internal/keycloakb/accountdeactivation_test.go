@@ -0,0 +1,182 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountDeactivation(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockUsersDB = mock.NewAccountDeactivationUsersDBModule(mockCtrl)
+	var mockConfigDB = mock.NewAccountDeactivationConfigDBModule(mockCtrl)
+	var mockEventsDB = mock.NewLastConnectionsDBModule(mockCtrl)
+	var mockKeycloakClient = mock.NewAccountDeactivationKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+
+	var deactivation = NewAccountDeactivation("master", mockUsersDB, mockConfigDB, mockEventsDB, mockKeycloakClient, mockTokenProvider,
+		mockEventsReporter, log.NewNopLogger())
+	var now = time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC)
+	deactivation.(*accountDeactivation).now = func() time.Time { return now }
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var anyError = errors.New("any error")
+	var realmID = "realm-id"
+	var realmName = "realm"
+	var realms = []kc.RealmRepresentation{{ID: &realmID, Realm: &realmName}}
+	var userID = "user-id"
+	var username = "username"
+	var email = "user@example.com"
+	var bTrue = true
+	var bFalse = false
+	var enabledUser = kc.UserRepresentation{ID: &userID, Username: &username, Email: &email, Enabled: &bTrue}
+	var disabledUser = kc.UserRepresentation{ID: &userID, Username: &username, Email: &email, Enabled: &bFalse}
+	var inactivityDays = 90
+	var warningDays = 10
+	var adminConfig = dto.RealmAdminConfiguration{AccountDeactivation: &dto.AccountDeactivationPolicy{InactivityDays: &inactivityDays, WarningDays: &warningDays}}
+	var warningLimit = now.Add(10 * 24 * time.Hour)
+	var inactivityLimit = now.Add(-80 * 24 * time.Hour)
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = deactivation.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get realms", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(nil, anyError)
+		var _, err = deactivation.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Realm without policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, anyError)
+		mockUsersDB.EXPECT().GetExpiringAccounts(ctx, realmName, now, now, accountExpiryPageSize).Return(nil, anyError)
+
+		var count, err = deactivation.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Expiring account", func(t *testing.T) {
+		var expiry = dto.DBAccountExpiry{RealmID: realmName, UserID: userID, ExpiryDate: now.Add(48 * time.Hour)}
+
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockUsersDB.EXPECT().GetExpiringAccounts(ctx, realmName, warningLimit, now, accountExpiryPageSize).Return([]dto.DBAccountExpiry{expiry}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(enabledUser, nil)
+		mockKeycloakClient.EXPECT().SendEmail(accessToken, "master", realmName, gomock.Any()).DoAndReturn(
+			func(_, _, _ string, emailRep kc.EmailRepresentation) error {
+				assert.Equal(t, email, *emailRep.Recipient)
+				assert.Equal(t, emailTemplateAccountExpiration, *emailRep.Theming.Template)
+				assert.Equal(t, "17.06.2020", (*emailRep.Theming.TemplateParameters)["date"])
+				return nil
+			})
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "ACCOUNT_EXPIRATION_WARNING_EMAIL_SENT", "back-office", gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().SetAccountExpiryWarned(ctx, realmName, userID, now).Return(nil)
+		mockEventsDB.EXPECT().GetUsersLastConnectionBefore(ctx, realmName, inactivityLimit).Return(nil, nil)
+
+		var count, err = deactivation.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Expired accounts", func(t *testing.T) {
+		var expiry = dto.DBAccountExpiry{RealmID: realmName, UserID: userID, ExpiryDate: now.Add(-time.Hour)}
+		var otherID = "other-id"
+		var deletedID = "deleted-id"
+		var expiries = []dto.DBAccountExpiry{expiry, {RealmID: realmName, UserID: otherID}, {RealmID: realmName, UserID: deletedID}}
+
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockUsersDB.EXPECT().GetExpiringAccounts(ctx, realmName, warningLimit, now, accountExpiryPageSize).Return(expiries, nil)
+		// User is disabled
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(enabledUser, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(
+			func(_, _, _ string, user kc.UserRepresentation) error {
+				assert.False(t, *user.Enabled)
+				return nil
+			})
+		mockUsersDB.EXPECT().StoreUserLock(ctx, dto.DBUserLock{RealmID: realmName, UserID: userID, Reason: lockReasonAccountExpired, LockedAt: now}).Return(nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "LOCK_ACCOUNT", "back-office", gomock.Any()).Return(anyError)
+		mockUsersDB.EXPECT().StoreAccountExpiry(ctx, realmName, userID, nil).Return(nil)
+		// Disabling fails: expiry is kept
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, otherID).Return(kc.UserRepresentation{ID: &otherID, Enabled: &bTrue}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, otherID, gomock.Any()).Return(anyError)
+		// User does not exist anymore
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, deletedID).Return(kc.UserRepresentation{}, kc.HTTPError{HTTPStatus: 404})
+		mockUsersDB.EXPECT().StoreAccountExpiry(ctx, realmName, deletedID, nil).Return(nil)
+		mockEventsDB.EXPECT().GetUsersLastConnectionBefore(ctx, realmName, inactivityLimit).Return(nil, anyError)
+
+		var count, err = deactivation.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Inactive accounts", func(t *testing.T) {
+		var lastConnection = now.Add(-85 * 24 * time.Hour)
+		var oldConnection = now.Add(-200 * 24 * time.Hour)
+		var oldWarning = now.Add(-11 * 24 * time.Hour)
+		var recentWarning = now.Add(-24 * time.Hour)
+		var warnedID = "warned-id"
+		var recentlyWarnedID = "recently-warned-id"
+		var lastConnections = map[string]int64{
+			userID:           lastConnection.Unix(),
+			warnedID:         oldConnection.Unix(),
+			recentlyWarnedID: oldConnection.Unix(),
+		}
+
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockUsersDB.EXPECT().GetExpiringAccounts(ctx, realmName, warningLimit, now, accountExpiryPageSize).Return(nil, nil)
+		mockEventsDB.EXPECT().GetUsersLastConnectionBefore(ctx, realmName, inactivityLimit).Return(lastConnections, nil)
+		// Not warned yet
+		mockUsersDB.EXPECT().GetInactivityWarning(ctx, realmName, userID).Return(nil, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(enabledUser, nil)
+		mockKeycloakClient.EXPECT().SendEmail(accessToken, "master", realmName, gomock.Any()).Return(anyError)
+		mockUsersDB.EXPECT().StoreInactivityWarning(ctx, realmName, userID, now).Return(nil)
+		// Warned long enough ago
+		mockUsersDB.EXPECT().GetInactivityWarning(ctx, realmName, warnedID).Return(&oldWarning, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, warnedID).Return(kc.UserRepresentation{ID: &warnedID, Enabled: &bTrue}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, warnedID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().StoreUserLock(ctx, gomock.Any()).Return(nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "LOCK_ACCOUNT", "back-office", gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().DeleteInactivityWarning(ctx, realmName, warnedID).Return(nil)
+		// Warned recently
+		mockUsersDB.EXPECT().GetInactivityWarning(ctx, realmName, recentlyWarnedID).Return(&recentWarning, nil)
+
+		var count, err = deactivation.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Inactive account already disabled", func(t *testing.T) {
+		var noWarning = dto.RealmAdminConfiguration{AccountDeactivation: &dto.AccountDeactivationPolicy{InactivityDays: &inactivityDays}}
+		var lastConnections = map[string]int64{userID: now.Add(-100 * 24 * time.Hour).Unix()}
+
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(noWarning, nil)
+		mockUsersDB.EXPECT().GetExpiringAccounts(ctx, realmName, now, now, accountExpiryPageSize).Return(nil, nil)
+		mockEventsDB.EXPECT().GetUsersLastConnectionBefore(ctx, realmName, now.Add(-90*24*time.Hour)).Return(lastConnections, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(disabledUser, nil)
+
+		var count, err = deactivation.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
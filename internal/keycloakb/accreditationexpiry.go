@@ -0,0 +1,240 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	accreditationExpiryPageSize = 100
+
+	// Accreditations which expired longer ago are not reported: they expired before the processing was enabled
+	accreditationExpiredReportPeriod = 30 * 24 * time.Hour
+
+	emailTemplateAccreditationExpiration = "accreditation-expiration-warning.ftl"
+	emailSubjectAccreditationExpiration  = "accreditationExpirationWarningSubject"
+)
+
+// AccreditationExpiryKeycloakClient is the minimum Keycloak client interface for the processing of expiring accreditations
+type AccreditationExpiryKeycloakClient interface {
+	GetRealms(accessToken string) ([]kc.RealmRepresentation, error)
+	GetUsers(accessToken string, reqRealmName, targetRealmName string, paramKV ...string) (kc.UsersPageRepresentation, error)
+	SendEmail(accessToken string, reqRealmName string, realmName string, emailRep kc.EmailRepresentation) error
+}
+
+// AccreditationNotificationsDBModule is the minimum users DB module interface for the processing of expiring accreditations
+type AccreditationNotificationsDBModule interface {
+	ClaimAccreditationNotification(ctx context.Context, notification dto.DBAccreditationNotification) (bool, error)
+	ReleaseAccreditationNotification(ctx context.Context, notification dto.DBAccreditationNotification) error
+}
+
+// AccreditationExpiry reminds users that their accreditations are about to expire and reports the expired accreditations
+type AccreditationExpiry interface {
+	Run(ctx context.Context) (int, error)
+}
+
+type accreditationExpiry struct {
+	technicalRealm string
+	usersDBModule  AccreditationNotificationsDBModule
+	configDBModule AdminConfigurationDBModule
+	keycloakClient AccreditationExpiryKeycloakClient
+	tokenProvider  TokenProvider
+	eventsReporter EventsReporter
+	logger         Logger
+	now            func() time.Time
+}
+
+// NewAccreditationExpiry creates an AccreditationExpiry
+func NewAccreditationExpiry(technicalRealm string, usersDBModule AccreditationNotificationsDBModule, configDBModule AdminConfigurationDBModule,
+	keycloakClient AccreditationExpiryKeycloakClient, tokenProvider TokenProvider, eventsReporter EventsReporter, logger Logger) AccreditationExpiry {
+	return &accreditationExpiry{
+		technicalRealm: technicalRealm,
+		usersDBModule:  usersDBModule,
+		configDBModule: configDBModule,
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		eventsReporter: eventsReporter,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Run processes the accreditations of the users of all realms. Returns the number of notifications (reminders and expiries).
+// Each notification is claimed in database before being sent: instances running concurrently never send the same notification
+// twice and a notification which can't be sent is released to be retried during the next run
+func (a *accreditationExpiry) Run(ctx context.Context) (int, error) {
+	var accessToken, err = a.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return 0, err
+	}
+
+	realms, err := a.keycloakClient.GetRealms(accessToken)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get realms", "err", err.Error())
+		return 0, err
+	}
+
+	var count = 0
+	for _, realm := range realms {
+		if realm.ID == nil || realm.Realm == nil {
+			continue
+		}
+		count += a.processRealm(ctx, accessToken, *realm.Realm, a.getPolicy(ctx, *realm.ID))
+	}
+	return count, nil
+}
+
+func (a *accreditationExpiry) getPolicy(ctx context.Context, realmID string) *dto.AccreditationExpiryPolicy {
+	var adminConfig, err = a.configDBModule.GetAdminConfiguration(ctx, realmID)
+	if err != nil {
+		// Users of realms without admin configuration are not reminded: expiries are still reported
+		if err != sql.ErrNoRows {
+			a.logger.Warn(ctx, "msg", "Can't get admin configuration", "err", err.Error(), "realmID", realmID)
+		}
+		return nil
+	}
+	return adminConfig.AccreditationExpiry
+}
+
+func (a *accreditationExpiry) processRealm(ctx context.Context, accessToken string, realmName string, policy *dto.AccreditationExpiryPolicy) int {
+	var now = a.now()
+	var warningLimit = now
+	if policy != nil {
+		warningLimit = now.Add(days(policy.WarningDays))
+	}
+
+	var count = 0
+	for first := 0; ; first += accreditationExpiryPageSize {
+		var page, err = a.keycloakClient.GetUsers(accessToken, a.technicalRealm, realmName, "first", strconv.Itoa(first), "max", strconv.Itoa(accreditationExpiryPageSize))
+		if err != nil {
+			a.logger.Warn(ctx, "msg", "Can't get users page", "err", err.Error(), "realm", realmName, "first", first)
+			return count
+		}
+		for _, user := range page.Users {
+			if user.ID == nil {
+				continue
+			}
+			for _, accredJSON := range user.GetAttribute(constants.AttrbAccreditations) {
+				var accred AccreditationRepresentation
+				if json.Unmarshal([]byte(accredJSON), &accred) != nil || accred.Type == nil || accred.ExpiryDate == nil || isRevoked(accred) {
+					continue
+				}
+				var expiryDate, err = time.Parse(dateLayout, *accred.ExpiryDate)
+				if err != nil {
+					continue
+				}
+				if !now.Before(expiryDate) {
+					if now.Sub(expiryDate) <= accreditationExpiredReportPeriod && a.reportExpiredAccreditation(ctx, realmName, user, accred) {
+						count++
+					}
+				} else if expiryDate.Before(warningLimit) && a.warnAccreditationExpiration(ctx, accessToken, realmName, user, accred, policy) {
+					count++
+				}
+			}
+		}
+		if len(page.Users) < accreditationExpiryPageSize {
+			return count
+		}
+	}
+}
+
+func (a *accreditationExpiry) reportExpiredAccreditation(ctx context.Context, realmName string, user kc.UserRepresentation, accred AccreditationRepresentation) bool {
+	return a.notify(ctx, realmName, user, accred, dto.AccreditationNotificationExpired, func() bool {
+		a.reportEvent(ctx, "ACCREDITATION_EXPIRED", realmName, user, "accreditation_type", *accred.Type, "expiry_date", *accred.ExpiryDate)
+		return true
+	})
+}
+
+func (a *accreditationExpiry) warnAccreditationExpiration(ctx context.Context, accessToken string, realmName string, user kc.UserRepresentation,
+	accred AccreditationRepresentation, policy *dto.AccreditationExpiryPolicy) bool {
+	if user.Email == nil || (user.Enabled != nil && !*user.Enabled) {
+		return false
+	}
+	return a.notify(ctx, realmName, user, accred, dto.AccreditationNotificationReminder, func() bool {
+		if !a.sendEmail(ctx, accessToken, realmName, user, accred, policy) {
+			return false
+		}
+		a.reportEvent(ctx, "ACCREDITATION_EXPIRATION_WARNING_EMAIL_SENT", realmName, user, "accreditation_type", *accred.Type, "expiry_date", *accred.ExpiryDate)
+		return true
+	})
+}
+
+// notify claims a notification and sends it. Returns true if the notification has been sent by this call
+func (a *accreditationExpiry) notify(ctx context.Context, realmName string, user kc.UserRepresentation, accred AccreditationRepresentation,
+	notificationType string, send func() bool) bool {
+	var notification = dto.DBAccreditationNotification{
+		RealmID:           realmName,
+		UserID:            *user.ID,
+		AccreditationType: *accred.Type,
+		ExpiryDate:        *accred.ExpiryDate,
+		Notification:      notificationType,
+		NotificationDate:  a.now(),
+	}
+	var claimed, err = a.usersDBModule.ClaimAccreditationNotification(ctx, notification)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't claim accreditation notification", "err", err.Error(), "realm", realmName, "userID", *user.ID,
+			"notification", notificationType)
+		return false
+	}
+	if !claimed {
+		// Already sent during a previous run or by another instance
+		return false
+	}
+	if send() {
+		return true
+	}
+	if err = a.usersDBModule.ReleaseAccreditationNotification(ctx, notification); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't release accreditation notification", "err", err.Error(), "realm", realmName, "userID", *user.ID,
+			"notification", notificationType)
+	}
+	return false
+}
+
+func (a *accreditationExpiry) sendEmail(ctx context.Context, accessToken string, realmName string, user kc.UserRepresentation,
+	accred AccreditationRepresentation, policy *dto.AccreditationExpiryPolicy) bool {
+	var template = emailTemplateAccreditationExpiration
+	if policy.EmailTemplate != nil {
+		template = *policy.EmailTemplate
+	}
+	var subject = emailSubjectAccreditationExpiration
+	if policy.EmailSubject != nil {
+		subject = *policy.EmailSubject
+	}
+
+	var templateParameters = map[string]string{"date": *accred.ExpiryDate, "accreditation": *accred.Type}
+	var emailRep = kc.EmailRepresentation{
+		Recipient: user.Email,
+		Theming: &kc.EmailThemingRepresentation{
+			SubjectKey:         &subject,
+			Template:           &template,
+			TemplateParameters: &templateParameters,
+			Locale:             user.GetAttributeString(constants.AttrbLocale),
+		},
+	}
+	if err := a.keycloakClient.SendEmail(accessToken, a.technicalRealm, realmName, emailRep); err != nil {
+		a.logger.Warn(ctx, "msg", "Could not send email", "err", err.Error(), "template", template, "realm", realmName, "userID", *user.ID)
+		return false
+	}
+	return true
+}
+
+func (a *accreditationExpiry) reportEvent(ctx context.Context, apiCall string, realmName string, user kc.UserRepresentation, additionalInfo ...string) {
+	var username = ""
+	if user.Username != nil {
+		username = *user.Username
+	}
+	var values = []string{database.CtEventRealmName, realmName, database.CtEventUserID, *user.ID, database.CtEventUsername, username,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo(additionalInfo...)}
+	if err := a.eventsReporter.ReportEvent(ctx, apiCall, "back-office", values...); err != nil {
+		LogUnrecordedEvent(ctx, a.logger, apiCall, err.Error(), values...)
+	}
+}
@@ -0,0 +1,160 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccreditationExpiry(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockUsersDB = mock.NewAccreditationNotificationsDBModule(mockCtrl)
+	var mockConfigDB = mock.NewConfigurationDBModule(mockCtrl)
+	var mockKeycloakClient = mock.NewAccreditationExpiryKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+
+	var expiry = NewAccreditationExpiry("master", mockUsersDB, mockConfigDB, mockKeycloakClient, mockTokenProvider, mockEventsReporter, log.NewNopLogger())
+	var now = time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC)
+	expiry.(*accreditationExpiry).now = func() time.Time { return now }
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var anyError = errors.New("any error")
+	var realmID = "realm-id"
+	var realmName = "realm"
+	var realms = []kc.RealmRepresentation{{ID: &realmID, Realm: &realmName}}
+	var userID = "user-id"
+	var username = "username"
+	var email = "user@example.com"
+	var attributes = make(kc.Attributes)
+	attributes.Set(constants.AttrbAccreditations, []string{
+		`{"type":"SOON","expiryDate":"20.06.2020"}`,
+		`{"type":"EXPIRED","expiryDate":"10.06.2020"}`,
+		`{"type":"OLD","expiryDate":"01.01.2019"}`,
+		`{"type":"REVOKED","expiryDate":"20.06.2020","revoked":true}`,
+		`{"type":"LATER","expiryDate":"01.01.2021"}`,
+		`invalid`,
+	})
+	var user = kc.UserRepresentation{ID: &userID, Username: &username, Email: &email, Attributes: &attributes}
+	var page = kc.UsersPageRepresentation{Users: []kc.UserRepresentation{user}}
+	var warningDays = 10
+	var template = "reminder.ftl"
+	var adminConfig = dto.RealmAdminConfiguration{AccreditationExpiry: &dto.AccreditationExpiryPolicy{WarningDays: &warningDays, EmailTemplate: &template}}
+	var claim = func(accredType, notificationType string, claimed bool) func(context.Context, dto.DBAccreditationNotification) (bool, error) {
+		return func(_ context.Context, notification dto.DBAccreditationNotification) (bool, error) {
+			assert.Equal(t, accredType, notification.AccreditationType)
+			assert.Equal(t, notificationType, notification.Notification)
+			return claimed, nil
+		}
+	}
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = expiry.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get realms", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(nil, anyError)
+		var _, err = expiry.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't get users", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, "master", realmName, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, anyError)
+		var count, err = expiry.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Realm without configuration: expiry is reported once", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, "master", realmName, "first", "0", "max", "100").Return(page, nil)
+		mockUsersDB.EXPECT().ClaimAccreditationNotification(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, notification dto.DBAccreditationNotification) (bool, error) {
+			assert.Equal(t, realmName, notification.RealmID)
+			assert.Equal(t, userID, notification.UserID)
+			assert.Equal(t, "EXPIRED", notification.AccreditationType)
+			assert.Equal(t, "10.06.2020", notification.ExpiryDate)
+			assert.Equal(t, dto.AccreditationNotificationExpired, notification.Notification)
+			return true, nil
+		})
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "ACCREDITATION_EXPIRED", "back-office", gomock.Any()).Return(anyError)
+
+		var count, err = expiry.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Reminder sent, expiry already reported by another instance", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, "master", realmName, "first", "0", "max", "100").Return(page, nil)
+		gomock.InOrder(
+			mockUsersDB.EXPECT().ClaimAccreditationNotification(ctx, gomock.Any()).DoAndReturn(claim("SOON", dto.AccreditationNotificationReminder, true)),
+			mockKeycloakClient.EXPECT().SendEmail(accessToken, "master", realmName, gomock.Any()).DoAndReturn(
+				func(_, _, _ string, emailRep kc.EmailRepresentation) error {
+					assert.Equal(t, email, *emailRep.Recipient)
+					assert.Equal(t, template, *emailRep.Theming.Template)
+					assert.Equal(t, emailSubjectAccreditationExpiration, *emailRep.Theming.SubjectKey)
+					assert.Equal(t, "20.06.2020", (*emailRep.Theming.TemplateParameters)["date"])
+					assert.Equal(t, "SOON", (*emailRep.Theming.TemplateParameters)["accreditation"])
+					return nil
+				}),
+			mockEventsReporter.EXPECT().ReportEvent(ctx, "ACCREDITATION_EXPIRATION_WARNING_EMAIL_SENT", "back-office", gomock.Any()).Return(nil),
+			mockUsersDB.EXPECT().ClaimAccreditationNotification(ctx, gomock.Any()).DoAndReturn(claim("EXPIRED", dto.AccreditationNotificationExpired, false)),
+		)
+
+		var count, err = expiry.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Reminder can't be sent: notification is released", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, "master", realmName, "first", "0", "max", "100").Return(page, nil)
+		gomock.InOrder(
+			mockUsersDB.EXPECT().ClaimAccreditationNotification(ctx, gomock.Any()).Return(true, nil),
+			mockKeycloakClient.EXPECT().SendEmail(accessToken, "master", realmName, gomock.Any()).Return(anyError),
+			mockUsersDB.EXPECT().ReleaseAccreditationNotification(ctx, gomock.Any()).Return(anyError),
+			mockUsersDB.EXPECT().ClaimAccreditationNotification(ctx, gomock.Any()).Return(false, anyError),
+		)
+
+		var count, err = expiry.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Disabled user is not reminded", func(t *testing.T) {
+		var bFalse = false
+		var disabledUser = user
+		disabledUser.Enabled = &bFalse
+
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockConfigDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, "master", realmName, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{Users: []kc.UserRepresentation{disabledUser}}, nil)
+		mockUsersDB.EXPECT().ClaimAccreditationNotification(ctx, gomock.Any()).DoAndReturn(claim("EXPIRED", dto.AccreditationNotificationExpired, false))
+
+		var count, err = expiry.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
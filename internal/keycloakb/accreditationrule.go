@@ -0,0 +1,424 @@
+package keycloakb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudtrust/common-service/configuration"
+	"github.com/cloudtrust/common-service/validation"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	accreditationRuleMaxLength = 1024
+
+	// Fields which can be used in the accreditation rules
+	ruleFieldAge               = "age"
+	ruleFieldGender            = "gender"
+	ruleFieldNationality       = "nationality"
+	ruleFieldBirthLocation     = "birthLocation"
+	ruleFieldIDDocumentType    = "idDocumentType"
+	ruleFieldIDDocumentCountry = "idDocumentCountry"
+	ruleFieldAttributePrefix   = "attributes."
+
+	ruleKeywordAnd = "AND"
+	ruleKeywordOr  = "OR"
+	ruleKeywordNot = "NOT"
+	ruleKeywordIn  = "IN"
+)
+
+var (
+	ruleFields = []string{ruleFieldAge, ruleFieldGender, ruleFieldNationality, ruleFieldBirthLocation, ruleFieldIDDocumentType, ruleFieldIDDocumentCountry}
+	// Operators are ordered so that the longest ones are recognized first
+	ruleOperators        = []string{"!=", "<=", ">=", "=", "<", ">"}
+	ruleNumericOperators = []string{"<=", ">=", "<", ">"}
+)
+
+// AccreditationRuleInput is the data an accreditation rule is evaluated against: the check which has just been performed,
+// the user as stored in Keycloak and the details of the user stored in database
+type AccreditationRuleInput struct {
+	Check  string
+	User   kc.UserRepresentation
+	DBUser dto.DBUser
+	Now    time.Time
+}
+
+// AccreditationRule is a parsed accreditation condition. A condition is a boolean expression combining with AND, OR, NOT and
+// parentheses the keys of the checks (like IDNow), which are true when the accreditation is evaluated after this check, and
+// comparisons of a field with a value (nationality = CH, age >= 18) or with a list of values (nationality in [CH, LI]).
+// Fields are age, gender, nationality, birthLocation, idDocumentType, idDocumentCountry and attributes.<name> for any other
+// Keycloak attribute. String comparisons are case insensitive and a field without value never satisfies a comparison.
+// A condition made of a single check key, the only form supported formerly, keeps its meaning
+type AccreditationRule struct {
+	root      ruleNode
+	checkKeys []string
+}
+
+// ParseAccreditationRule parses an accreditation condition
+func ParseAccreditationRule(condition string) (AccreditationRule, error) {
+	if len(condition) > accreditationRuleMaxLength {
+		return AccreditationRule{}, errors.New("condition is too long")
+	}
+	var tokens, err = tokenizeRule(condition)
+	if err != nil {
+		return AccreditationRule{}, err
+	}
+	var parser = ruleParser{tokens: tokens}
+	var root ruleNode
+	if root, err = parser.parseOr(); err != nil {
+		return AccreditationRule{}, err
+	}
+	if !parser.done() {
+		return AccreditationRule{}, fmt.Errorf("unexpected %s", parser.peek())
+	}
+	return AccreditationRule{root: root, checkKeys: parser.checkKeys}, nil
+}
+
+// CheckKeys returns the check keys the rule refers to
+func (r AccreditationRule) CheckKeys() []string {
+	return r.checkKeys
+}
+
+// AppliesTo tells whether an accreditation with this rule can be granted after a check: the rule has to refer to this check
+// or to no check at all
+func (r AccreditationRule) AppliesTo(check string) bool {
+	return len(r.checkKeys) == 0 || validation.IsStringInSlice(r.checkKeys, check)
+}
+
+// Evaluate tells whether the rule is satisfied
+func (r AccreditationRule) Evaluate(input AccreditationRuleInput) bool {
+	return r.root.evaluate(input)
+}
+
+type ruleNode interface {
+	evaluate(input AccreditationRuleInput) bool
+}
+
+type ruleAnd struct {
+	left, right ruleNode
+}
+
+func (n ruleAnd) evaluate(input AccreditationRuleInput) bool {
+	return n.left.evaluate(input) && n.right.evaluate(input)
+}
+
+type ruleOr struct {
+	left, right ruleNode
+}
+
+func (n ruleOr) evaluate(input AccreditationRuleInput) bool {
+	return n.left.evaluate(input) || n.right.evaluate(input)
+}
+
+type ruleNot struct {
+	node ruleNode
+}
+
+func (n ruleNot) evaluate(input AccreditationRuleInput) bool {
+	return !n.node.evaluate(input)
+}
+
+type ruleCheck struct {
+	checkKey string
+}
+
+func (n ruleCheck) evaluate(input AccreditationRuleInput) bool {
+	return input.Check == n.checkKey
+}
+
+type ruleComparison struct {
+	field    string
+	operator string
+	values   []string
+}
+
+func (n ruleComparison) evaluate(input AccreditationRuleInput) bool {
+	var value = input.fieldValue(n.field)
+	if value == nil || *value == "" {
+		return false
+	}
+	switch n.operator {
+	case ruleKeywordIn:
+		for _, v := range n.values {
+			if ruleValuesEqual(*value, v) {
+				return true
+			}
+		}
+		return false
+	case "=":
+		return ruleValuesEqual(*value, n.values[0])
+	case "!=":
+		return !ruleValuesEqual(*value, n.values[0])
+	}
+
+	// Numeric operators: the value given in the rule has been checked while parsing
+	var number, err = strconv.Atoi(*value)
+	if err != nil {
+		return false
+	}
+	var reference, _ = strconv.Atoi(n.values[0])
+	switch n.operator {
+	case "<":
+		return number < reference
+	case "<=":
+		return number <= reference
+	case ">":
+		return number > reference
+	default:
+		return number >= reference
+	}
+}
+
+func ruleValuesEqual(value, reference string) bool {
+	if number, err := strconv.Atoi(value); err == nil {
+		if referenceNumber, err := strconv.Atoi(reference); err == nil {
+			return number == referenceNumber
+		}
+	}
+	return strings.EqualFold(value, reference)
+}
+
+func (in AccreditationRuleInput) fieldValue(field string) *string {
+	switch field {
+	case ruleFieldAge:
+		return in.age()
+	case ruleFieldGender:
+		return in.User.GetAttributeString(constants.AttrbGender)
+	case ruleFieldNationality:
+		return in.DBUser.Nationality
+	case ruleFieldBirthLocation:
+		return in.DBUser.BirthLocation
+	case ruleFieldIDDocumentType:
+		return in.DBUser.IDDocumentType
+	case ruleFieldIDDocumentCountry:
+		return in.DBUser.IDDocumentCountry
+	}
+	return in.User.GetAttributeString(kc.AttributeKey(strings.TrimPrefix(field, ruleFieldAttributePrefix)))
+}
+
+func (in AccreditationRuleInput) age() *string {
+	var birthDate = in.User.GetAttributeString(constants.AttrbBirthDate)
+	if birthDate == nil {
+		return nil
+	}
+	for _, layout := range constants.SupportedDateLayouts {
+		if date, err := time.Parse(layout, *birthDate); err == nil {
+			var age = in.Now.Year() - date.Year()
+			if in.Now.Month() < date.Month() || (in.Now.Month() == date.Month() && in.Now.Day() < date.Day()) {
+				age--
+			}
+			var res = strconv.Itoa(age)
+			return &res
+		}
+	}
+	return nil
+}
+
+type ruleToken struct {
+	value  string
+	quoted bool
+	end    bool
+}
+
+func (t ruleToken) String() string {
+	if t.quoted {
+		return `"` + t.value + `"`
+	}
+	return t.value
+}
+
+func (t ruleToken) isKeyword(keyword string) bool {
+	return !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+func isRuleWordChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.'
+}
+
+func tokenizeRule(condition string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for i := 0; i < len(condition); {
+		var c = condition[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("()[],", c) >= 0:
+			tokens = append(tokens, ruleToken{value: string(c)})
+			i++
+		case c == '"':
+			var end = strings.IndexByte(condition[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, ruleToken{value: condition[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case isRuleWordChar(c):
+			var start = i
+			for i < len(condition) && isRuleWordChar(condition[i]) {
+				i++
+			}
+			tokens = append(tokens, ruleToken{value: condition[start:i]})
+		default:
+			var operator = ""
+			for _, op := range ruleOperators {
+				if strings.HasPrefix(condition[i:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, ruleToken{value: operator})
+			i += len(operator)
+		}
+	}
+	return tokens, nil
+}
+
+type ruleParser struct {
+	tokens    []ruleToken
+	pos       int
+	checkKeys []string
+}
+
+func (p *ruleParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *ruleParser) peek() ruleToken {
+	if p.done() {
+		return ruleToken{value: "end of condition", end: true}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) next() ruleToken {
+	var token = p.peek()
+	p.pos++
+	return token
+}
+
+func (p *ruleParser) expect(value string) error {
+	if token := p.next(); token.quoted || token.value != value {
+		return fmt.Errorf("expected %s instead of %s", value, token)
+	}
+	return nil
+}
+
+func (p *ruleParser) parseOr() (ruleNode, error) {
+	var left, err = p.parseAnd()
+	for err == nil && !p.done() && p.peek().isKeyword(ruleKeywordOr) {
+		p.next()
+		var right ruleNode
+		if right, err = p.parseAnd(); err == nil {
+			left = ruleOr{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *ruleParser) parseAnd() (ruleNode, error) {
+	var left, err = p.parseNot()
+	for err == nil && !p.done() && p.peek().isKeyword(ruleKeywordAnd) {
+		p.next()
+		var right ruleNode
+		if right, err = p.parseNot(); err == nil {
+			left = ruleAnd{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *ruleParser) parseNot() (ruleNode, error) {
+	if !p.done() && p.peek().isKeyword(ruleKeywordNot) {
+		p.next()
+		var node, err = p.parseNot()
+		return ruleNot{node: node}, err
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	var token = p.next()
+	if !token.quoted && token.value == "(" {
+		var node, err = p.parseOr()
+		if err == nil {
+			err = p.expect(")")
+		}
+		return node, err
+	}
+	if token.quoted || token.end {
+		return nil, fmt.Errorf("unexpected %s", token)
+	}
+	if validation.IsStringInSlice(configuration.AvailableCheckKeys, token.value) {
+		if !validation.IsStringInSlice(p.checkKeys, token.value) {
+			p.checkKeys = append(p.checkKeys, token.value)
+		}
+		return ruleCheck{checkKey: token.value}, nil
+	}
+	if !isRuleField(token.value) {
+		return nil, fmt.Errorf("unknown field %s", token)
+	}
+	return p.parseComparison(token.value)
+}
+
+func isRuleField(name string) bool {
+	if strings.HasPrefix(name, ruleFieldAttributePrefix) {
+		return len(name) > len(ruleFieldAttributePrefix)
+	}
+	return validation.IsStringInSlice(ruleFields, name)
+}
+
+func (p *ruleParser) parseComparison(field string) (ruleNode, error) {
+	var operator = p.next()
+	if operator.isKeyword(ruleKeywordIn) {
+		var values, err = p.parseList()
+		return ruleComparison{field: field, operator: ruleKeywordIn, values: values}, err
+	}
+	if operator.quoted || !validation.IsStringInSlice(ruleOperators, operator.value) {
+		return nil, fmt.Errorf("expected an operator after %s instead of %s", field, operator)
+	}
+	var value, err = p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if validation.IsStringInSlice(ruleNumericOperators, operator.value) {
+		if _, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%s %s expects a number", field, operator)
+		}
+	}
+	return ruleComparison{field: field, operator: operator.value, values: []string{value}}, nil
+}
+
+func (p *ruleParser) parseList() ([]string, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var values []string
+	for {
+		var value, err = p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if token := p.next(); token.quoted || (token.value != "," && token.value != "]") {
+			return nil, fmt.Errorf("expected , or ] instead of %s", token)
+		} else if token.value == "]" {
+			return values, nil
+		}
+	}
+}
+
+func (p *ruleParser) parseValue() (string, error) {
+	var token = p.next()
+	if token.quoted || (!token.end && isRuleWordChar(token.value[0])) {
+		return token.value, nil
+	}
+	return "", fmt.Errorf("expected a value instead of %s", token)
+}
@@ -0,0 +1,88 @@
+package keycloakb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccreditationRule(t *testing.T) {
+	t.Run("Valid rules", func(t *testing.T) {
+		for condition, checkKeys := range map[string][]string{
+			CredsPhysical: {CredsPhysical},
+			CredsIDNow + " OR (" + CredsPhysical + " and not " + CredsIDNow + ")": {CredsIDNow, CredsPhysical},
+			CredsPhysical + " AND nationality in [CH, LI]":                        {CredsPhysical},
+			`idDocumentType = "PASSPORT"`:                                         nil,
+			"attributes.level >= 2 AND gender != M":                               nil,
+			"NOT (age < 18) AND birthLocation = Bern":                             nil,
+		} {
+			var rule, err = ParseAccreditationRule(condition)
+			assert.Nil(t, err, condition)
+			assert.Equal(t, checkKeys, rule.CheckKeys(), condition)
+		}
+	})
+
+	t.Run("Invalid rules", func(t *testing.T) {
+		for _, condition := range []string{"", CredsIDNow + " AND", "(" + CredsPhysical, CredsPhysical + ")", `"` + CredsPhysical + `"`, "unknown = 1", "attributes. = 1",
+			"age >= adult", "nationality =", "nationality in []", "nationality in [CH", "nationality in CH", CredsIDNow + " & " + CredsPhysical, `gender = "M`} {
+			var _, err = ParseAccreditationRule(condition)
+			assert.NotNil(t, err, condition)
+		}
+	})
+
+	t.Run("Too long", func(t *testing.T) {
+		var condition = CredsPhysical
+		for len(condition) <= accreditationRuleMaxLength {
+			condition += " AND " + CredsPhysical
+		}
+		var _, err = ParseAccreditationRule(condition)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestEvaluateAccreditationRule(t *testing.T) {
+	var nationality = "CH"
+	var documentType = "PASSPORT"
+	var attributes = make(kc.Attributes)
+	attributes.SetString(constants.AttrbBirthDate, "18.06.2002")
+	attributes.SetString(constants.AttrbGender, "F")
+	attributes.SetString("level", "3")
+	var input = AccreditationRuleInput{
+		Check:  CredsPhysical,
+		User:   kc.UserRepresentation{Attributes: &attributes},
+		DBUser: dto.DBUser{Nationality: &nationality, IDDocumentType: &documentType},
+		Now:    time.Date(2020, 6, 17, 10, 0, 0, 0, time.UTC),
+	}
+
+	for condition, expected := range map[string]bool{
+		CredsPhysical: true,
+		CredsIDNow:    false,
+		CredsPhysical + " AND nationality in [CH, LI]":    true,
+		CredsPhysical + " AND nationality in [DE, FR]":    false,
+		CredsIDNow + " AND age >= 18":                     false,
+		"age >= 18":                                       false,
+		"age = 17":                                        true,
+		"idDocumentType = passport":                       true,
+		"NOT (" + CredsIDNow + " OR nationality != ch)":   true,
+		"attributes.level > 2 AND gender = F":             true,
+		"idDocumentCountry != CH":                         false,
+		"attributes.unknown = 1 OR " + CredsPhysical:      true,
+		CredsPhysical + " AND NOT idDocumentCountry = CH": true,
+	} {
+		var rule, err = ParseAccreditationRule(condition)
+		assert.Nil(t, err, condition)
+		assert.Equal(t, expected, rule.Evaluate(input), condition)
+	}
+
+	t.Run("Applicable rules", func(t *testing.T) {
+		var rule, _ = ParseAccreditationRule(CredsIDNow + " AND age >= 18")
+		assert.True(t, rule.AppliesTo(CredsIDNow))
+		assert.False(t, rule.AppliesTo(CredsPhysical))
+		rule, _ = ParseAccreditationRule("age >= 18")
+		assert.True(t, rule.AppliesTo(CredsPhysical))
+	})
+}
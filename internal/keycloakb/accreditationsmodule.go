@@ -10,6 +10,7 @@ import (
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	"github.com/cloudtrust/common-service/validation"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
 	kc "github.com/cloudtrust/keycloak-client"
 )
 
@@ -26,7 +27,11 @@ const (
 
 // AccreditationsModule interface
 type AccreditationsModule interface {
-	GetUserAndPrepareAccreditations(ctx context.Context, accessToken, realmName, userID, condition string) (kc.UserRepresentation, int, error)
+	GetUserAndPrepareAccreditations(ctx context.Context, accessToken, realmName, userID, condition string) (kc.UserRepresentation, []dto.DBAccreditation, error)
+	PrepareAccreditations(ctx context.Context, accessToken, realmName string, kcUser *kc.UserRepresentation, dbUser dto.DBUser, condition string) ([]dto.DBAccreditation, error)
+	RecordAccreditations(ctx context.Context, accreds []dto.DBAccreditation, operator string, checkID *int64) error
+	RevokeAccreditations(ctx context.Context, realmName, userID, reason, operator string) error
+	RevokeAccreditation(ctx context.Context, realmName, userID, accredType, reason, operator string) error
 }
 
 // AccredsKeycloakClient is the minimum Keycloak client interface for accreditations
@@ -36,22 +41,51 @@ type AccredsKeycloakClient interface {
 	GetRealm(accessToken string, realmName string) (kc.RealmRepresentation, error)
 }
 
+// AccredsUsersDBModule is the minimum users DB module interface for accreditations
+type AccredsUsersDBModule interface {
+	GetUserDetails(ctx context.Context, realm string, userID string) (dto.DBUser, error)
+	CreateAccreditations(ctx context.Context, accreds []dto.DBAccreditation) error
+	RevokeAccreditations(ctx context.Context, revocation dto.DBAccreditationRevocation) error
+}
+
 // AdminConfigurationDBModule interface
 type AdminConfigurationDBModule interface {
-	GetAdminConfiguration(context.Context, string) (configuration.RealmAdminConfiguration, error)
+	GetAdminConfiguration(context.Context, string) (dto.RealmAdminConfiguration, error)
 }
 
 type accredsModule struct {
 	keycloakClient AccredsKeycloakClient
+	usersDBModule  AccredsUsersDBModule
 	confDBModule   AdminConfigurationDBModule
 	logger         Logger
 }
 
 // AccreditationRepresentation is a representation of accreditations
 type AccreditationRepresentation struct {
-	Type       *string `json:"type,omitempty"`
-	ExpiryDate *string `json:"expiryDate,omitempty"`
-	Revoked    *bool   `json:"revoked,omitempty"`
+	Type             *string `json:"type,omitempty"`
+	ExpiryDate       *string `json:"expiryDate,omitempty"`
+	Revoked          *bool   `json:"revoked,omitempty"`
+	RevocationDate   *string `json:"revocationDate,omitempty"`
+	RevocationReason *string `json:"revocationReason,omitempty"`
+	RevokedBy        *string `json:"revokedBy,omitempty"`
+}
+
+// ToDBAccreditation converts an accreditation granted to a user at the given date
+func (a AccreditationRepresentation) ToDBAccreditation(realmName, userID string, grantDate time.Time) dto.DBAccreditation {
+	var res = dto.DBAccreditation{
+		RealmID:   realmName,
+		UserID:    userID,
+		GrantDate: grantDate,
+	}
+	if a.Type != nil {
+		res.Type = *a.Type
+	}
+	if a.ExpiryDate != nil {
+		if expiry, err := time.Parse(dateLayout, *a.ExpiryDate); err == nil {
+			res.ExpiryDate = &expiry
+		}
+	}
+	return res
 }
 
 // IsUpdated checks if there are changes in provided values.
@@ -94,82 +128,264 @@ func revoke(accredJSON string) string {
 	return accredJSON
 }
 
+// RevokeAccreditation revokes the active accreditations of the given type. It returns false if the user has no such accreditation
+func RevokeAccreditation(kcUser *kc.UserRepresentation, accredType, reason, operator string) bool {
+	var now = time.Now()
+	var revocationDate = now.Format(dateLayout)
+	var bTrue = true
+	return updateAccreditations(kcUser, func(accred *AccreditationRepresentation) bool {
+		if !isAccreditationOfType(*accred, accredType) || isRevoked(*accred) || !isActiveAccreditation(*accred, now) {
+			return false
+		}
+		accred.Revoked = &bTrue
+		accred.RevocationDate = &revocationDate
+		accred.RevocationReason = &reason
+		accred.RevokedBy = &operator
+		return true
+	})
+}
+
+// ReinstateAccreditation reinstates the revoked accreditations of the given type which are not expired. It returns the reinstated
+// accreditations: none if the user has no such accreditation
+func ReinstateAccreditation(kcUser *kc.UserRepresentation, accredType string) []AccreditationRepresentation {
+	var now = time.Now()
+	var reinstated []AccreditationRepresentation
+	updateAccreditations(kcUser, func(accred *AccreditationRepresentation) bool {
+		if !isAccreditationOfType(*accred, accredType) || !isRevoked(*accred) || !isActiveAccreditation(*accred, now) {
+			return false
+		}
+		accred.Revoked = nil
+		accred.RevocationDate = nil
+		accred.RevocationReason = nil
+		accred.RevokedBy = nil
+		reinstated = append(reinstated, *accred)
+		return true
+	})
+	return reinstated
+}
+
+// updateAccreditations applies an update to each accreditation of the user. It returns true if at least one accreditation is updated
+func updateAccreditations(kcUser *kc.UserRepresentation, update func(*AccreditationRepresentation) bool) bool {
+	var kcAccreds = kcUser.GetAttribute(constants.AttrbAccreditations)
+	var newAccreds []string
+	var updated = false
+	for _, accredJSON := range kcAccreds {
+		var accred AccreditationRepresentation
+		if json.Unmarshal([]byte(accredJSON), &accred) == nil && update(&accred) {
+			var bytes, _ = json.Marshal(accred)
+			accredJSON = string(bytes)
+			updated = true
+		}
+		newAccreds = append(newAccreds, accredJSON)
+	}
+	if updated {
+		kcUser.SetAttribute(constants.AttrbAccreditations, newAccreds)
+	}
+	return updated
+}
+
+func isAccreditationOfType(accred AccreditationRepresentation, accredType string) bool {
+	return accred.Type != nil && *accred.Type == accredType
+}
+
+func isRevoked(accred AccreditationRepresentation) bool {
+	return accred.Revoked != nil && *accred.Revoked
+}
+
+func isActiveAccreditation(accred AccreditationRepresentation, now time.Time) bool {
+	if accred.ExpiryDate == nil {
+		return false
+	}
+	var expiry, err = time.Parse(dateLayout, *accred.ExpiryDate)
+	return err == nil && now.Before(expiry)
+}
+
 // NewAccreditationsModule creates an accreditations module
-func NewAccreditationsModule(keycloakClient AccredsKeycloakClient, confDBModule AdminConfigurationDBModule, logger Logger) AccreditationsModule {
+func NewAccreditationsModule(keycloakClient AccredsKeycloakClient, usersDBModule AccredsUsersDBModule, confDBModule AdminConfigurationDBModule,
+	logger Logger) AccreditationsModule {
 	return &accredsModule{
 		keycloakClient: keycloakClient,
+		usersDBModule:  usersDBModule,
 		confDBModule:   confDBModule,
 		logger:         logger,
 	}
 }
 
-func (am *accredsModule) GetUserAndPrepareAccreditations(ctx context.Context, accessToken, realmName, userID, condition string) (kc.UserRepresentation, int, error) {
+// GetUserAndPrepareAccreditations gets the user from Keycloak and adds the accreditations granted by a check to its attributes.
+// The user details stored in database are used to evaluate the conditions of the accreditations. The added accreditations are
+// returned to be recorded once the user is updated
+func (am *accredsModule) GetUserAndPrepareAccreditations(ctx context.Context, accessToken, realmName, userID, condition string) (kc.UserRepresentation, []dto.DBAccreditation, error) {
 	var kcUser kc.UserRepresentation
 
+	var accreds, err = am.getConfiguredAccreditations(ctx, accessToken, realmName)
+	if err != nil {
+		return kcUser, nil, err
+	}
+
+	// Get the user from Keycloak
+	kcUser, err = am.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		am.logger.Warn(ctx, "msg", "CreateAccreditations: can't get Keycloak user", "err", err.Error(), "realm", realmName, "user", userID)
+		return kcUser, nil, err
+	}
+
+	// Get the user details from database
+	var dbUser dto.DBUser
+	dbUser, err = am.usersDBModule.GetUserDetails(ctx, realmName, userID)
+	if err != nil {
+		am.logger.Warn(ctx, "msg", "CreateAccreditations: can't get user details from database", "err", err.Error(), "realm", realmName, "user", userID)
+		return kcUser, nil, err
+	}
+
+	var added []dto.DBAccreditation
+	added, err = am.addAccreditations(ctx, realmName, accreds, &kcUser, dbUser, condition)
+	return kcUser, added, err
+}
+
+// PrepareAccreditations adds the accreditations granted by a check to the attributes of a user. It is used when the check
+// updates the user: the conditions of the accreditations are evaluated against the updated values
+func (am *accredsModule) PrepareAccreditations(ctx context.Context, accessToken, realmName string, kcUser *kc.UserRepresentation, dbUser dto.DBUser, condition string) ([]dto.DBAccreditation, error) {
+	var accreds, err = am.getConfiguredAccreditations(ctx, accessToken, realmName)
+	if err != nil {
+		return nil, err
+	}
+	return am.addAccreditations(ctx, realmName, accreds, kcUser, dbUser, condition)
+}
+
+// RecordAccreditations records in the accreditations history the accreditations granted by an operator, with the check which
+// granted them if any. The signed credentials of the accreditations refer to this history: it is recorded once Keycloak is updated
+func (am *accredsModule) RecordAccreditations(ctx context.Context, accreds []dto.DBAccreditation, operator string, checkID *int64) error {
+	if len(accreds) == 0 {
+		return nil
+	}
+	for i := range accreds {
+		accreds[i].CheckID = checkID
+		accreds[i].Operator = &operator
+	}
+	if err := am.usersDBModule.CreateAccreditations(ctx, accreds); err != nil {
+		am.logger.Warn(ctx, "msg", "Can't record granted accreditations", "err", err.Error(), "realm", accreds[0].RealmID, "user", accreds[0].UserID)
+		return err
+	}
+	return nil
+}
+
+// RevokeAccreditations records in the accreditations history the revocation of all the active accreditations of a user.
+// Revocations are recorded before Keycloak is updated: the status list of the signed credentials never lags behind Keycloak
+func (am *accredsModule) RevokeAccreditations(ctx context.Context, realmName, userID, reason, operator string) error {
+	return am.recordRevocation(ctx, dto.DBAccreditationRevocation{
+		RealmID:   realmName,
+		UserID:    userID,
+		Date:      time.Now(),
+		Reason:    reason,
+		RevokedBy: &operator,
+	})
+}
+
+// RevokeAccreditation records in the accreditations history the revocation of the active accreditations of the given type
+func (am *accredsModule) RevokeAccreditation(ctx context.Context, realmName, userID, accredType, reason, operator string) error {
+	return am.recordRevocation(ctx, dto.DBAccreditationRevocation{
+		RealmID:   realmName,
+		UserID:    userID,
+		Type:      &accredType,
+		Date:      time.Now(),
+		Reason:    reason,
+		RevokedBy: &operator,
+	})
+}
+
+func (am *accredsModule) recordRevocation(ctx context.Context, revocation dto.DBAccreditationRevocation) error {
+	if err := am.usersDBModule.RevokeAccreditations(ctx, revocation); err != nil {
+		am.logger.Warn(ctx, "msg", "Can't record revoked accreditations", "err", err.Error(), "realm", revocation.RealmID, "user", revocation.UserID)
+		return err
+	}
+	return nil
+}
+
+func (am *accredsModule) getConfiguredAccreditations(ctx context.Context, accessToken, realmName string) ([]configuration.RealmAdminAccreditation, error) {
 	// Gets the realm
 	var realm, err = am.keycloakClient.GetRealm(accessToken, realmName)
 	if err != nil {
 		am.logger.Warn(ctx, "msg", "getKeycloakRealm: can't get realm from KC", "err", err.Error())
-		return kcUser, 0, errorhandler.CreateInternalServerError("keycloak")
+		return nil, errorhandler.CreateInternalServerError("keycloak")
 	}
 
 	// Retrieve admin configuration from configuration DB
-	var rac configuration.RealmAdminConfiguration
+	var rac dto.RealmAdminConfiguration
 	rac, err = am.confDBModule.GetAdminConfiguration(ctx, *realm.ID)
 	if err != nil {
 		am.logger.Warn(ctx, "msg", "CreateAccreditations: can't get admin configuration", "err", err.Error())
-		return kcUser, 0, errorhandler.CreateInternalServerError("keycloak")
+		return nil, errorhandler.CreateInternalServerError("keycloak")
 	}
+	return rac.Accreditations, nil
+}
 
+func (am *accredsModule) addAccreditations(ctx context.Context, realmName string, accreds []configuration.RealmAdminAccreditation,
+	kcUser *kc.UserRepresentation, dbUser dto.DBUser, condition string) ([]dto.DBAccreditation, error) {
 	// Evaluate accreditations to be created
-	var newAccreds []string
-	newAccreds, err = am.evaluateAccreditations(ctx, rac.Accreditations, condition)
+	var now = time.Now()
+	var input = AccreditationRuleInput{Check: condition, User: *kcUser, DBUser: dbUser, Now: now}
+	var newAccreds, applicable, err = am.evaluateAccreditations(ctx, accreds, input)
 	if err != nil {
 		am.logger.Warn(ctx, "msg", "Can't evaluate accreditations", "err", err.Error())
-		return kcUser, 0, err
+		return nil, err
 	}
 
-	// Get the user from Keycloak
-	kcUser, err = am.keycloakClient.GetUser(accessToken, realmName, userID)
-	if err != nil {
-		am.logger.Warn(ctx, "msg", "CreateAccreditations: can't get Keycloak user", "err", err.Error(), "realm", realmName, "user", userID)
-		return kcUser, 0, err
+	if applicable == 0 {
+		return nil, errorhandler.CreateInternalServerError("noConfiguredAccreditations")
 	}
 
-	if len(newAccreds) == 0 {
-		return kcUser, 0, errorhandler.CreateInternalServerError("noConfiguredAccreditations")
+	var userID = ""
+	if kcUser.ID != nil {
+		userID = *kcUser.ID
 	}
 
 	// Update attributes in kcUser
-	var added = 0
+	var added []dto.DBAccreditation
 	var kcAccreds = kcUser.GetAttribute(constants.AttrbAccreditations)
 	for _, newAccred := range newAccreds {
-		if !validation.IsStringInSlice(kcAccreds, newAccred) {
-			kcAccreds = append(kcAccreds, newAccred)
-			added++
+		var newAccreditationJSON, _ = json.Marshal(newAccred)
+		if !validation.IsStringInSlice(kcAccreds, string(newAccreditationJSON)) {
+			kcAccreds = append(kcAccreds, string(newAccreditationJSON))
+			added = append(added, newAccred.ToDBAccreditation(realmName, userID, now))
 		}
 	}
 	kcUser.SetAttribute(constants.AttrbAccreditations, kcAccreds)
 
-	return kcUser, added, nil
+	return added, nil
 }
 
-func (am *accredsModule) evaluateAccreditations(ctx context.Context, accreds []configuration.RealmAdminAccreditation, condition string) ([]string, error) {
-	var newAccreds []string
+// evaluateAccreditations returns the accreditations granted to a user and the number of configured accreditations which
+// could be granted by the check
+func (am *accredsModule) evaluateAccreditations(ctx context.Context, accreds []configuration.RealmAdminAccreditation, input AccreditationRuleInput) ([]AccreditationRepresentation, int, error) {
+	var newAccreds []AccreditationRepresentation
+	var applicable = 0
 	for _, modelAccred := range accreds {
-		if modelAccred.Condition == nil || *modelAccred.Condition == condition {
-			var expiry, err = am.convertDurationToDate(ctx, *modelAccred.Validity)
+		if modelAccred.Condition != nil {
+			var rule, err = ParseAccreditationRule(*modelAccred.Condition)
 			if err != nil {
-				return nil, err
+				am.logger.Warn(ctx, "msg", "Invalid accreditation condition", "err", err.Error(), "condition", *modelAccred.Condition)
+				continue
+			}
+			if !rule.AppliesTo(input.Check) {
+				continue
 			}
-			var newAccreditationJSON, _ = json.Marshal(AccreditationRepresentation{
-				Type:       modelAccred.Type,
-				ExpiryDate: expiry,
-			})
-			newAccreds = append(newAccreds, string(newAccreditationJSON))
+			applicable++
+			if !rule.Evaluate(input) {
+				continue
+			}
+		} else {
+			applicable++
+		}
+		var expiry, err = am.convertDurationToDate(ctx, *modelAccred.Validity)
+		if err != nil {
+			return nil, 0, err
 		}
+		newAccreds = append(newAccreds, AccreditationRepresentation{
+			Type:       modelAccred.Type,
+			ExpiryDate: expiry,
+		})
 	}
-	return newAccreds, nil
+	return newAccreds, applicable, nil
 }
 
 func (am *accredsModule) convertDurationToDate(ctx context.Context, validity string) (*string, error) {
@@ -2,6 +2,7 @@ package keycloakb
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	"github.com/cloudtrust/common-service/log"
 	"github.com/cloudtrust/common-service/validation"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
 	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/golang/mock/gomock"
@@ -32,7 +34,7 @@ const (
 	duration3 = "6m"
 )
 
-func createRealmAdminConfig(condition string) configuration.RealmAdminConfiguration {
+func createRealmAdminConfig(condition string) dto.RealmAdminConfiguration {
 	var otherCondition = "no-" + condition
 	var accreds = []configuration.RealmAdminAccreditation{
 		createRealmAdminCred("SHADOW1", duration1, condition),
@@ -41,7 +43,7 @@ func createRealmAdminConfig(condition string) configuration.RealmAdminConfigurat
 		createRealmAdminCred("SHADOW4", "3y", otherCondition),
 		createRealmAdminCred("SHADOW5", duration3, condition),
 	}
-	return configuration.RealmAdminConfiguration{Accreditations: accreds}
+	return dto.RealmAdminConfiguration{RealmAdminConfiguration: configuration.RealmAdminConfiguration{Accreditations: accreds}}
 }
 
 func TestIsUpdated(t *testing.T) {
@@ -59,6 +61,23 @@ func TestIsUpdated(t *testing.T) {
 	assert.True(t, IsUpdated(&newValue, &formerValue))
 }
 
+func TestToDBAccreditation(t *testing.T) {
+	var accredType = "SHADOW"
+	var expiry = "31.12.2030"
+	var invalidExpiry = "2030-12-31"
+	var grantDate = time.Now()
+
+	var accred = AccreditationRepresentation{Type: &accredType, ExpiryDate: &expiry}.ToDBAccreditation("realm", "user-id", grantDate)
+	assert.Equal(t, "realm", accred.RealmID)
+	assert.Equal(t, "user-id", accred.UserID)
+	assert.Equal(t, accredType, accred.Type)
+	assert.Equal(t, grantDate, accred.GrantDate)
+	assert.Equal(t, time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC), *accred.ExpiryDate)
+
+	accred = AccreditationRepresentation{Type: &accredType, ExpiryDate: &invalidExpiry}.ToDBAccreditation("realm", "user-id", grantDate)
+	assert.Nil(t, accred.ExpiryDate)
+}
+
 func TestRevokeAccreditation(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -87,15 +106,59 @@ func TestRevokeAccreditation(t *testing.T) {
 	})
 }
 
+func TestRevokeAndReinstateSingleAccreditation(t *testing.T) {
+	var future = time.Now().AddDate(1, 0, 0).Format(dateLayout)
+	var user = kc.UserRepresentation{}
+	var accreds = []string{`{"type":"ONE","expiryDate":"01.01.2015"}`,
+		`{"type":"TWO","expiryDate":"` + future + `"}`,
+		`{"type":"THREE","expiryDate":"` + future + `"}`,
+		"invalid"}
+	user.SetAttribute(constants.AttrbAccreditations, accreds)
+
+	t.Run("Expired accreditation can't be revoked", func(t *testing.T) {
+		assert.False(t, RevokeAccreditation(&user, "ONE", "fraud", "operator"))
+		assert.False(t, RevokeAccreditation(&user, "UNKNOWN", "fraud", "operator"))
+		assert.Equal(t, accreds, user.GetAttribute(constants.AttrbAccreditations))
+	})
+
+	t.Run("Revoke an accreditation", func(t *testing.T) {
+		assert.True(t, RevokeAccreditation(&user, "TWO", "fraud", "operator"))
+		var values = user.GetAttribute(constants.AttrbAccreditations)
+		assert.Equal(t, accreds[0], values[0])
+		assert.Equal(t, accreds[2], values[2])
+		assert.Equal(t, accreds[3], values[3])
+
+		var accred AccreditationRepresentation
+		assert.Nil(t, json.Unmarshal([]byte(values[1]), &accred))
+		assert.True(t, *accred.Revoked)
+		assert.Equal(t, time.Now().Format(dateLayout), *accred.RevocationDate)
+		assert.Equal(t, "fraud", *accred.RevocationReason)
+		assert.Equal(t, "operator", *accred.RevokedBy)
+	})
+
+	t.Run("Revoked accreditation can't be revoked again", func(t *testing.T) {
+		assert.False(t, RevokeAccreditation(&user, "TWO", "fraud", "operator"))
+	})
+
+	t.Run("Reinstate an accreditation", func(t *testing.T) {
+		assert.Len(t, ReinstateAccreditation(&user, "THREE"), 0)
+		var reinstated = ReinstateAccreditation(&user, "TWO")
+		assert.Len(t, reinstated, 1)
+		assert.Equal(t, "TWO", *reinstated[0].Type)
+		assert.Equal(t, `{"type":"TWO","expiryDate":"`+future+`"}`, user.GetAttribute(constants.AttrbAccreditations)[1])
+	})
+}
+
 func TestAccreditationsModule(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	var mockKeycloak = mock.NewAccredsKeycloakClient(mockCtrl)
+	var mockUsersDB = mock.NewAccredsUsersDBModule(mockCtrl)
 	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
 	var logger = log.NewNopLogger()
 
-	var accredsModule = NewAccreditationsModule(mockKeycloak, mockConfDB, logger)
+	var accredsModule = NewAccreditationsModule(mockKeycloak, mockUsersDB, mockConfDB, logger)
 
 	var ctx = context.TODO()
 	var accessToken = "access-token"
@@ -103,10 +166,11 @@ func TestAccreditationsModule(t *testing.T) {
 	var realmID = "the-realm-id"
 	var userID = "the-user-id"
 	var anyError = errors.New("I don't know")
-	var condition = "physical"
-	var otherCondition = "other"
+	var condition = CredsPhysical
+	var otherCondition = CredsIDNow
 	var kcRealm = kc.RealmRepresentation{ID: &realmID}
 	var kcUser = kc.UserRepresentation{ID: &userID}
+	var dbUser = dto.DBUser{UserID: &userID}
 
 	t.Run("Keycloak.GetRealm fails", func(t *testing.T) {
 		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, anyError)
@@ -115,7 +179,7 @@ func TestAccreditationsModule(t *testing.T) {
 	})
 	t.Run("Database.GetAdminConfiguration fails", func(t *testing.T) {
 		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kcRealm, nil)
-		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(configuration.RealmAdminConfiguration{}, anyError)
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, anyError)
 		var _, _, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
 		assert.NotNil(t, err)
 	})
@@ -123,6 +187,7 @@ func TestAccreditationsModule(t *testing.T) {
 		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kcRealm, nil)
 		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(createRealmAdminConfig(otherCondition), nil)
 		mockKeycloak.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
 		var _, _, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
 		assert.IsType(t, errorhandler.Error{}, err)
 		assert.Equal(t, 500, err.(errorhandler.Error).Status)
@@ -133,6 +198,8 @@ func TestAccreditationsModule(t *testing.T) {
 		credsConf.Accreditations[0].Validity = &invalidDuration
 		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kcRealm, nil)
 		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(credsConf, nil)
+		mockKeycloak.EXPECT().GetUser(accessToken, realmName, userID).Return(kcUser, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
 		var _, _, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
 		assert.NotNil(t, err)
 	})
@@ -143,16 +210,29 @@ func TestAccreditationsModule(t *testing.T) {
 		var _, _, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
 		assert.NotNil(t, err)
 	})
+	t.Run("Database.GetUserDetails fails", func(t *testing.T) {
+		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kcRealm, nil)
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(createRealmAdminConfig(condition), nil)
+		mockKeycloak.EXPECT().GetUser(accessToken, realmName, userID).Return(kcUser, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{}, anyError)
+		var _, _, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
+		assert.Equal(t, anyError, err)
+	})
 	t.Run("Accreditations creation is successful", func(t *testing.T) {
 		kcUser.Attributes = nil
 		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kcRealm, nil)
 		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(createRealmAdminConfig(condition), nil)
 		mockKeycloak.EXPECT().GetUser(accessToken, realmName, userID).Return(kcUser, nil)
-		var kcUser, count, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+		var kcUser, added, err = accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, condition)
 		assert.Nil(t, err)
 		var accreds = kcUser.GetAttribute(constants.AttrbAccreditations)
-		assert.Equal(t, 3, count)
+		assert.Len(t, added, 3)
 		assert.Len(t, accreds, 3)
+		assert.Equal(t, realmName, added[0].RealmID)
+		assert.Equal(t, userID, added[0].UserID)
+		assert.Equal(t, "SHADOW1", added[0].Type)
+		assert.Equal(t, validation.AddLargeDuration(time.Now(), duration1).Format(dateLayout), added[0].ExpiryDate.Format(dateLayout))
 		assert.Contains(t, accreds[0], "SHADOW1")
 		assert.Contains(t, accreds[1], "SHADOW3")
 		assert.Contains(t, accreds[2], "SHADOW5")
@@ -160,4 +240,90 @@ func TestAccreditationsModule(t *testing.T) {
 		assert.Contains(t, accreds[1], validation.AddLargeDuration(time.Now(), duration2).Format(dateLayout))
 		assert.Contains(t, accreds[2], validation.AddLargeDuration(time.Now(), duration3).Format(dateLayout))
 	})
+	t.Run("Accreditations granted by rules", func(t *testing.T) {
+		var nationality = "CH"
+		var user = kc.UserRepresentation{ID: &userID}
+		var credsConf = dto.RealmAdminConfiguration{RealmAdminConfiguration: configuration.RealmAdminConfiguration{Accreditations: []configuration.RealmAdminAccreditation{
+			createRealmAdminCred("SWISS", duration1, CredsPhysical+" AND nationality in [CH, LI]"),
+			createRealmAdminCred("ADULT", duration1, CredsPhysical+" AND age >= 18"),
+			createRealmAdminCred("REMOTE", duration1, CredsIDNow),
+			createRealmAdminCred("ANY", duration1, "nationality = ch"),
+		}}}
+		mockKeycloak.EXPECT().GetRealm(accessToken, realmName).Return(kcRealm, nil)
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(credsConf, nil)
+		var added, err = accredsModule.PrepareAccreditations(ctx, accessToken, realmName, &user, dto.DBUser{Nationality: &nationality}, condition)
+		assert.Nil(t, err)
+		assert.Len(t, added, 2)
+		var accreds = user.GetAttribute(constants.AttrbAccreditations)
+		assert.Len(t, accreds, 2)
+		assert.Contains(t, accreds[0], "SWISS")
+		assert.Contains(t, accreds[1], "ANY")
+	})
+}
+
+func TestAccreditationsHistory(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloak = mock.NewAccredsKeycloakClient(mockCtrl)
+	var mockUsersDB = mock.NewAccredsUsersDBModule(mockCtrl)
+	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
+
+	var accredsModule = NewAccreditationsModule(mockKeycloak, mockUsersDB, mockConfDB, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var realmName = "realm-name"
+	var userID = "the-user-id"
+	var operator = "operator"
+	var reason = "FRAUD"
+	var anyError = errors.New("any error")
+
+	t.Run("No granted accreditation", func(t *testing.T) {
+		assert.Nil(t, accredsModule.RecordAccreditations(ctx, nil, operator, nil))
+	})
+
+	t.Run("Record granted accreditations", func(t *testing.T) {
+		var checkID = int64(12)
+		var accreds = []dto.DBAccreditation{{RealmID: realmName, UserID: userID, Type: "SHADOW"}}
+		mockUsersDB.EXPECT().CreateAccreditations(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, recorded []dto.DBAccreditation) error {
+			assert.Len(t, recorded, 1)
+			assert.Equal(t, operator, *recorded[0].Operator)
+			assert.Equal(t, checkID, *recorded[0].CheckID)
+			return nil
+		})
+		assert.Nil(t, accredsModule.RecordAccreditations(ctx, accreds, operator, &checkID))
+	})
+
+	t.Run("Can't record granted accreditations", func(t *testing.T) {
+		var accreds = []dto.DBAccreditation{{RealmID: realmName, UserID: userID, Type: "SHADOW"}}
+		mockUsersDB.EXPECT().CreateAccreditations(ctx, gomock.Any()).Return(anyError)
+		assert.Equal(t, anyError, accredsModule.RecordAccreditations(ctx, accreds, operator, nil))
+	})
+
+	t.Run("Revoke all the accreditations", func(t *testing.T) {
+		mockUsersDB.EXPECT().RevokeAccreditations(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revocation dto.DBAccreditationRevocation) error {
+			assert.Equal(t, realmName, revocation.RealmID)
+			assert.Equal(t, userID, revocation.UserID)
+			assert.Nil(t, revocation.Type)
+			assert.Equal(t, dto.AccreditationRevocationIdentityUpdated, revocation.Reason)
+			assert.Equal(t, operator, *revocation.RevokedBy)
+			return nil
+		})
+		assert.Nil(t, accredsModule.RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationIdentityUpdated, operator))
+	})
+
+	t.Run("Revoke the accreditations of a type", func(t *testing.T) {
+		mockUsersDB.EXPECT().RevokeAccreditations(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revocation dto.DBAccreditationRevocation) error {
+			assert.Equal(t, "SHADOW", *revocation.Type)
+			assert.Equal(t, reason, revocation.Reason)
+			assert.Equal(t, operator, *revocation.RevokedBy)
+			return nil
+		})
+		assert.Nil(t, accredsModule.RevokeAccreditation(ctx, realmName, userID, "SHADOW", reason, operator))
+	})
+
+	t.Run("Can't record revocation", func(t *testing.T) {
+		mockUsersDB.EXPECT().RevokeAccreditations(ctx, gomock.Any()).Return(anyError)
+		assert.Equal(t, anyError, accredsModule.RevokeAccreditations(ctx, realmName, userID, reason, operator))
+	})
 }
@@ -0,0 +1,35 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+// RealmKeycloakClient is the minimum Keycloak client interface to get the admin configuration of a realm
+type RealmKeycloakClient interface {
+	GetRealm(accessToken string, realmName string) (kc.RealmRepresentation, error)
+}
+
+// GetRealmAdminConfiguration returns the admin configuration of a realm. The configuration is stored with the ID of the realm
+// which is read from Keycloak. Realms without admin configuration get an empty one
+func GetRealmAdminConfiguration(ctx context.Context, keycloakClient RealmKeycloakClient, confDBModule AdminConfigurationDBModule, accessToken string,
+	realmName string, logger Logger) (dto.RealmAdminConfiguration, error) {
+	var realm, err = keycloakClient.GetRealm(accessToken, realmName)
+	if err != nil {
+		logger.Warn(ctx, "msg", "Can't get realm from Keycloak", "err", err.Error(), "realm", realmName)
+		return dto.RealmAdminConfiguration{}, err
+	}
+
+	adminConfig, err := confDBModule.GetAdminConfiguration(ctx, *realm.ID)
+	if err == sql.ErrNoRows {
+		return dto.RealmAdminConfiguration{}, nil
+	}
+	if err != nil {
+		logger.Warn(ctx, "msg", "Can't get admin configuration", "err", err.Error(), "realm", realmName)
+		return dto.RealmAdminConfiguration{}, err
+	}
+	return adminConfig, nil
+}
@@ -0,0 +1,60 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRealmAdminConfiguration(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewRealmKeycloakClient(mockCtrl)
+	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realmName = "realm"
+	var realmID = "realm-id"
+	var anyError = errors.New("any error")
+	var logger = log.NewNopLogger()
+
+	t.Run("Can't get realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, anyError)
+		var _, err = GetRealmAdminConfiguration(ctx, mockKeycloakClient, mockConfDB, accessToken, realmName, logger)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil).AnyTimes()
+
+	t.Run("Can't get admin configuration", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, anyError)
+		var _, err = GetRealmAdminConfiguration(ctx, mockKeycloakClient, mockConfDB, accessToken, realmName, logger)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("No admin configuration", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
+		var adminConfig, err = GetRealmAdminConfiguration(ctx, mockKeycloakClient, mockConfDB, accessToken, realmName, logger)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.RealmAdminConfiguration{}, adminConfig)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var enabled = true
+		var expected = dto.RealmAdminConfiguration{BreachedPasswordCheck: &enabled}
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(expected, nil)
+		var adminConfig, err = GetRealmAdminConfiguration(ctx, mockKeycloakClient, mockConfDB, accessToken, realmName, logger)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, adminConfig)
+	})
+}
@@ -2,6 +2,7 @@ package keycloakb
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 
 	"github.com/cloudtrust/common-service/database/sqltypes"
@@ -15,11 +16,20 @@ const (
 	  INSERT users (realm_name, user_id, timestamp, details)
 	  VALUES (?, ?, UTC_TIMESTAMP, ?)
 	`
+	selectLastUserArchiveStmt = `
+	  SELECT details
+	  FROM users
+	  WHERE realm_name=?
+		AND user_id=?
+	  ORDER BY timestamp DESC
+	  LIMIT 1
+	`
 )
 
 // ArchiveDBModule interface
 type ArchiveDBModule interface {
 	StoreUserDetails(ctx context.Context, realm string, user dto.ArchiveUserRepresentation) error
+	GetLastUserDetails(ctx context.Context, realm string, userID string) (*dto.ArchiveUserRepresentation, error)
 }
 
 type archiveDBModule struct {
@@ -55,3 +65,29 @@ func (a *archiveDBModule) StoreUserDetails(ctx context.Context, realm string, us
 	_, err = a.db.Exec(storeUserArchiveStmt, realm, user.ID, encryptedData)
 	return err
 }
+
+// GetLastUserDetails returns the most recent archive of a user or nil if the user has never been archived
+func (a *archiveDBModule) GetLastUserDetails(ctx context.Context, realm string, userID string) (*dto.ArchiveUserRepresentation, error) {
+	var encryptedData []byte
+	var err = a.db.QueryRow(selectLastUserArchiveStmt, realm, userID).Scan(&encryptedData)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := a.cipher.Decrypt(encryptedData, []byte(userID))
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't decrypt the user archive", "error", err.Error(), "realmID", realm, "userID", userID)
+		return nil, err
+	}
+
+	var user dto.ArchiveUserRepresentation
+	if err = json.Unmarshal(details, &user); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't unmarshal the user archive", "error", err.Error(), "realmID", realm, "userID", userID)
+		return nil, err
+	}
+	user.ID = &userID
+	return &user, nil
+}
@@ -2,6 +2,7 @@ package keycloakb
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 
@@ -50,3 +51,54 @@ func TestStoreUserDetails(t *testing.T) {
 		assert.Nil(t, archiveModule.StoreUserDetails(ctx, realmID, user))
 	})
 }
+
+func TestGetLastUserDetails(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+
+	var archiveModule = NewArchiveDBModule(mockDB, mockCrypter, log.NewNopLogger())
+
+	var realmID = "my-realm"
+	var userID = "123456-789-123-987654"
+	var encryptedDetails = []byte("encrypted")
+	var anyError = errors.New("any error")
+	var ctx = context.TODO()
+	var scanDetails = func(dest ...interface{}) error {
+		*dest[0].(*[]byte) = encryptedDetails
+		return nil
+	}
+
+	t.Run("Never archived", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realmID, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+
+		var user, err = archiveModule.GetLastUserDetails(ctx, realmID, userID)
+		assert.Nil(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Decryption fails", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realmID, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanDetails)
+		mockCrypter.EXPECT().Decrypt(encryptedDetails, []byte(userID)).Return(nil, anyError)
+
+		var _, err = archiveModule.GetLastUserDetails(ctx, realmID, userID)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realmID, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanDetails)
+		mockCrypter.EXPECT().Decrypt(encryptedDetails, []byte(userID)).Return([]byte(`{"username":"jdoe","enabled":true}`), nil)
+
+		var user, err = archiveModule.GetLastUserDetails(ctx, realmID, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, userID, *user.ID)
+		assert.Equal(t, "jdoe", *user.Username)
+		assert.True(t, *user.Enabled)
+	})
+}
@@ -0,0 +1,141 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	autoUnlockPageSize = 100
+)
+
+// AutoUnlockKeycloakClient is the minimum Keycloak client interface for the automatic unlock of users
+type AutoUnlockKeycloakClient interface {
+	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
+	UpdateUser(accessToken string, realmName, userID string, user kc.UserRepresentation) error
+}
+
+// UserLocksDBModule is the minimum users DB module interface for the automatic unlock of users
+type UserLocksDBModule interface {
+	GetExpiredUserLocks(ctx context.Context, until time.Time, max int) ([]dto.DBUserLock, error)
+	DeleteUserLock(ctx context.Context, realm string, userID string) error
+	GetUserDeletion(ctx context.Context, realm string, userID string) (*dto.DBUserDeletion, error)
+}
+
+// EventsReporter stores audit events
+type EventsReporter interface {
+	ReportEvent(ctx context.Context, apiCall string, origin string, values ...string) error
+}
+
+// AutoUnlock unlocks the users whose lock reached its unlock date
+type AutoUnlock interface {
+	Run(ctx context.Context) (int, error)
+}
+
+type autoUnlock struct {
+	usersDBModule  UserLocksDBModule
+	keycloakClient AutoUnlockKeycloakClient
+	tokenProvider  TokenProvider
+	eventsReporter EventsReporter
+	logger         Logger
+}
+
+// NewAutoUnlock creates an AutoUnlock
+func NewAutoUnlock(usersDBModule UserLocksDBModule, keycloakClient AutoUnlockKeycloakClient, tokenProvider TokenProvider, eventsReporter EventsReporter, logger Logger) AutoUnlock {
+	return &autoUnlock{
+		usersDBModule:  usersDBModule,
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		eventsReporter: eventsReporter,
+		logger:         logger,
+	}
+}
+
+// Run unlocks a page of users whose unlock date is reached. Returns the number of unlocked users.
+// A user which can't be unlocked is skipped: it will be processed again during the next run
+func (a *autoUnlock) Run(ctx context.Context) (int, error) {
+	var locks, err = a.usersDBModule.GetExpiredUserLocks(ctx, time.Now(), autoUnlockPageSize)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get expired user locks", "err", err.Error())
+		return 0, err
+	}
+	if len(locks) == 0 {
+		return 0, nil
+	}
+
+	accessToken, err := a.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return 0, err
+	}
+
+	var count = 0
+	for _, lock := range locks {
+		if a.unlock(ctx, accessToken, lock) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (a *autoUnlock) unlock(ctx context.Context, accessToken string, lock dto.DBUserLock) bool {
+	var user, err = a.keycloakClient.GetUser(accessToken, lock.RealmID, lock.UserID)
+	if err != nil {
+		if httpErr, ok := err.(kc.HTTPError); ok && httpErr.HTTPStatus == http.StatusNotFound {
+			// User does not exist anymore: the lock is obsolete
+			a.deleteLock(ctx, lock)
+			return false
+		}
+		a.logger.Warn(ctx, "msg", "Can't get user from Keycloak", "err", err.Error(), "realmID", lock.RealmID, "userID", lock.UserID)
+		return false
+	}
+
+	// User may have been manually unlocked without using the bridge
+	if user.Enabled != nil && *user.Enabled {
+		a.deleteLock(ctx, lock)
+		return false
+	}
+
+	// A soft deleted user stays disabled until it is purged: the lock is obsolete
+	deletion, err := a.usersDBModule.GetUserDeletion(ctx, lock.RealmID, lock.UserID)
+	if err != nil {
+		a.logger.Warn(ctx, "msg", "Can't get user deletion", "err", err.Error(), "realmID", lock.RealmID, "userID", lock.UserID)
+		return false
+	}
+	if deletion != nil {
+		a.logger.Info(ctx, "msg", "Soft deleted user is not unlocked", "realmID", lock.RealmID, "userID", lock.UserID)
+		a.deleteLock(ctx, lock)
+		return false
+	}
+
+	ConvertLegacyAttribute(&user)
+	var enabled = true
+	user.Enabled = &enabled
+	if err = a.keycloakClient.UpdateUser(accessToken, lock.RealmID, lock.UserID, user); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't unlock user", "err", err.Error(), "realmID", lock.RealmID, "userID", lock.UserID)
+		return false
+	}
+	a.deleteLock(ctx, lock)
+
+	var username = ""
+	if user.Username != nil {
+		username = *user.Username
+	}
+	var values = []string{database.CtEventRealmName, lock.RealmID, database.CtEventUserID, lock.UserID, database.CtEventUsername, username,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("reason", lock.Reason, "automatic", "true")}
+	if err = a.eventsReporter.ReportEvent(ctx, "UNLOCK_ACCOUNT", "back-office", values...); err != nil {
+		LogUnrecordedEvent(ctx, a.logger, "UNLOCK_ACCOUNT", err.Error(), values...)
+	}
+	return true
+}
+
+func (a *autoUnlock) deleteLock(ctx context.Context, lock dto.DBUserLock) {
+	if err := a.usersDBModule.DeleteUserLock(ctx, lock.RealmID, lock.UserID); err != nil {
+		a.logger.Warn(ctx, "msg", "Can't delete user lock", "err", err.Error(), "realmID", lock.RealmID, "userID", lock.UserID)
+	}
+}
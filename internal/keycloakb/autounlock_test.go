@@ -0,0 +1,126 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoUnlock(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockUsersDB = mock.NewUserLocksDBModule(mockCtrl)
+	var mockKeycloakClient = mock.NewAutoUnlockKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+	var autoUnlock = NewAutoUnlock(mockUsersDB, mockKeycloakClient, mockTokenProvider, mockEventsReporter, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var anyError = errors.New("any error")
+	var lock = dto.DBUserLock{RealmID: "realm", UserID: "user-id", Reason: "FRAUD"}
+	var locks = []dto.DBUserLock{lock}
+	var username = "username"
+	var bFalse = false
+	var bTrue = true
+
+	t.Run("Can't get expired locks", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(nil, anyError)
+		var _, err = autoUnlock.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("No expired lock", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(nil, nil)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = autoUnlock.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get user", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{}, anyError)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("User does not exist anymore", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{}, kc.HTTPError{HTTPStatus: 404})
+		mockUsersDB.EXPECT().DeleteUserLock(ctx, lock.RealmID, lock.UserID).Return(nil)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("User already unlocked", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{Enabled: &bTrue}, nil)
+		mockUsersDB.EXPECT().DeleteUserLock(ctx, lock.RealmID, lock.UserID).Return(anyError)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Can't get user deletion", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
+		mockUsersDB.EXPECT().GetUserDeletion(ctx, lock.RealmID, lock.UserID).Return(nil, anyError)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Locked user soft deleted since is not unlocked", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
+		mockUsersDB.EXPECT().GetUserDeletion(ctx, lock.RealmID, lock.UserID).Return(&dto.DBUserDeletion{RealmID: lock.RealmID, UserID: lock.UserID}, nil)
+		mockUsersDB.EXPECT().DeleteUserLock(ctx, lock.RealmID, lock.UserID).Return(nil)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	mockUsersDB.EXPECT().GetUserDeletion(ctx, lock.RealmID, lock.UserID).Return(nil, nil).AnyTimes()
+
+	t.Run("Can't update user", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, lock.RealmID, lock.UserID, gomock.Any()).Return(anyError)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetExpiredUserLocks(ctx, gomock.Any(), autoUnlockPageSize).Return(locks, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, lock.RealmID, lock.UserID).Return(kc.UserRepresentation{Username: &username, Enabled: &bFalse}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, lock.RealmID, lock.UserID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.True(t, *user.Enabled)
+			return nil
+		})
+		mockUsersDB.EXPECT().DeleteUserLock(ctx, lock.RealmID, lock.UserID).Return(nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "UNLOCK_ACCOUNT", "back-office", gomock.Any()).Return(anyError)
+		var count, err = autoUnlock.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
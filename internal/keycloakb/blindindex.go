@@ -0,0 +1,105 @@
+package keycloakb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+)
+
+const (
+	blindIndexDocumentNumber = "docnum"
+	blindIndexIdentity       = "identity"
+)
+
+// BlindIndexer computes keyed hashes of personal data. These hashes can be stored next to the encrypted data and
+// used to search it without having to decrypt every row
+type BlindIndexer interface {
+	DocumentNumberIndex(documentNumber *string) *string
+	IdentityIndex(firstName, lastName, birthDate *string) *string
+}
+
+type hmacBlindIndexer struct {
+	key []byte
+}
+
+// NewBlindIndexerFromBase64 creates a BlindIndexer using HMAC-SHA256 with the given base64 encoded key
+func NewBlindIndexerFromBase64(base64Key string) (BlindIndexer, error) {
+	var key, err = base64.StdEncoding.DecodeString(base64Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) < 32 {
+		return nil, errors.New("HMAC key must be at least 256 bits long")
+	}
+	return &hmacBlindIndexer{key: key}, nil
+}
+
+// DocumentNumberIndex returns the blind index of an identity document number or nil if the document number is empty
+func (b *hmacBlindIndexer) DocumentNumberIndex(documentNumber *string) *string {
+	var value = NormalizeDocumentNumber(documentNumber)
+	if value == "" {
+		return nil
+	}
+	return b.hash(blindIndexDocumentNumber, value)
+}
+
+// IdentityIndex returns the blind index of the first name, last name and birth date of a person. Returns nil if one of
+// these values is missing
+func (b *hmacBlindIndexer) IdentityIndex(firstName, lastName, birthDate *string) *string {
+	var first = NormalizeName(firstName)
+	var last = NormalizeName(lastName)
+	var birth = NormalizeDate(birthDate)
+	if first == "" || last == "" || birth == "" {
+		return nil
+	}
+	return b.hash(blindIndexIdentity, first+"|"+last+"|"+birth)
+}
+
+func (b *hmacBlindIndexer) hash(indexName string, value string) *string {
+	// Index name is part of the hashed value so that two different indexes can't be correlated
+	var mac = hmac.New(sha256.New, b.key)
+	mac.Write([]byte(indexName + ":" + value))
+	var res = hex.EncodeToString(mac.Sum(nil))
+	return &res
+}
+
+// NormalizeDocumentNumber removes separators and converts the document number to upper case
+func NormalizeDocumentNumber(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, *value)
+}
+
+// NormalizeName converts a name to lower case and collapses spaces
+func NormalizeName(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(strings.ToLower(*value)), " ")
+}
+
+// NormalizeDate converts a date in one of the supported layouts to the first supported layout
+func NormalizeDate(value *string) string {
+	if value == nil {
+		return ""
+	}
+	for _, layout := range constants.SupportedDateLayouts {
+		if date, err := time.Parse(layout, strings.TrimSpace(*value)); err == nil {
+			return date.Format(constants.SupportedDateLayouts[0])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package keycloakb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBlindIndexerFromBase64(t *testing.T) {
+	t.Run("Invalid base64 value", func(t *testing.T) {
+		var _, err = NewBlindIndexerFromBase64("not base64!")
+		assert.NotNil(t, err)
+	})
+	t.Run("Key is too short", func(t *testing.T) {
+		var _, err = NewBlindIndexerFromBase64("c2hvcnQ=")
+		assert.NotNil(t, err)
+	})
+	t.Run("Valid key", func(t *testing.T) {
+		var _, err = NewBlindIndexerFromBase64("oYP5DhsaW8dLtBt89i9cvXqz+zQTJBHWdFejLWLN/28=")
+		assert.Nil(t, err)
+	})
+}
+
+func TestDocumentNumberIndex(t *testing.T) {
+	var indexer, _ = NewBlindIndexerFromBase64("oYP5DhsaW8dLtBt89i9cvXqz+zQTJBHWdFejLWLN/28=")
+	var otherIndexer, _ = NewBlindIndexerFromBase64("qz+BLWLNzQTJoYP5DhsaW8dLtBt89i9cvXHWdFej/28=")
+
+	assert.Nil(t, indexer.DocumentNumberIndex(nil))
+	assert.Nil(t, indexer.DocumentNumberIndex(ptr(" - ")))
+
+	var index = indexer.DocumentNumberIndex(ptr("X123-456"))
+	assert.NotNil(t, index)
+	assert.Len(t, *index, 64)
+	assert.Equal(t, *index, *indexer.DocumentNumberIndex(ptr("x123 456")))
+	assert.NotEqual(t, *index, *indexer.DocumentNumberIndex(ptr("X123457")))
+	assert.NotEqual(t, *index, *otherIndexer.DocumentNumberIndex(ptr("X123456")))
+}
+
+func TestIdentityIndex(t *testing.T) {
+	var indexer, _ = NewBlindIndexerFromBase64("oYP5DhsaW8dLtBt89i9cvXqz+zQTJBHWdFejLWLN/28=")
+
+	assert.Nil(t, indexer.IdentityIndex(nil, ptr("Doe"), ptr("25.12.1980")))
+	assert.Nil(t, indexer.IdentityIndex(ptr("John"), ptr("Doe"), ptr("not a date")))
+
+	var index = indexer.IdentityIndex(ptr("John"), ptr("Doe"), ptr("25.12.1980"))
+	assert.NotNil(t, index)
+	assert.Equal(t, *index, *indexer.IdentityIndex(ptr(" JOHN "), ptr("doe"), ptr("1980-12-25")))
+	assert.NotEqual(t, *index, *indexer.IdentityIndex(ptr("Jane"), ptr("Doe"), ptr("25.12.1980")))
+	// Index of a document number can't be matched with an identity index
+	assert.NotEqual(t, *index, *indexer.DocumentNumberIndex(ptr("John|Doe|25.12.1980")))
+}
@@ -0,0 +1,100 @@
+package keycloakb
+
+import (
+	"context"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	backfillPageSize = 100
+)
+
+// BackfillKeycloakClient is the minimum Keycloak client interface for the blind indexes backfill
+type BackfillKeycloakClient interface {
+	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
+}
+
+// TokenProvider provides OIDC tokens
+type TokenProvider interface {
+	ProvideToken(ctx context.Context) (string, error)
+}
+
+// BlindIndexBackfill computes the blind indexes of the users details stored before their introduction
+type BlindIndexBackfill interface {
+	Run(ctx context.Context) (int, error)
+}
+
+type blindIndexBackfill struct {
+	usersDBModule  UsersDetailsDBModule
+	keycloakClient BackfillKeycloakClient
+	tokenProvider  TokenProvider
+	logger         Logger
+}
+
+// NewBlindIndexBackfill creates a BlindIndexBackfill
+func NewBlindIndexBackfill(usersDBModule UsersDetailsDBModule, keycloakClient BackfillKeycloakClient, tokenProvider TokenProvider, logger Logger) BlindIndexBackfill {
+	return &blindIndexBackfill{
+		usersDBModule:  usersDBModule,
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		logger:         logger,
+	}
+}
+
+// Run stores again all the users details so that their blind indexes are computed. Returns the number of updated users
+func (b *blindIndexBackfill) Run(ctx context.Context) (int, error) {
+	var accessToken, err = b.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		b.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return 0, err
+	}
+
+	var count = 0
+	var last = dto.DBUserKey{}
+	for {
+		var keys []dto.DBUserKey
+		keys, err = b.usersDBModule.GetUserDetailsKeys(ctx, last, backfillPageSize)
+		if err != nil {
+			b.logger.Warn(ctx, "msg", "Can't get users details keys", "err", err.Error())
+			return count, err
+		}
+		for _, key := range keys {
+			var updated bool
+			if updated, err = b.reindex(ctx, accessToken, key); err != nil {
+				return count, err
+			}
+			if updated {
+				count++
+			}
+		}
+		if len(keys) < backfillPageSize {
+			return count, nil
+		}
+		last = keys[len(keys)-1]
+	}
+}
+
+// reindex stores again the details of a user. Returns false when the user is skipped
+func (b *blindIndexBackfill) reindex(ctx context.Context, accessToken string, key dto.DBUserKey) (bool, error) {
+	var userDetails, err = b.usersDBModule.GetUserDetails(ctx, key.RealmID, key.UserID)
+	if err != nil {
+		b.logger.Warn(ctx, "msg", "Can't get user details", "err", err.Error(), "realmID", key.RealmID, "userID", key.UserID)
+		return false, err
+	}
+
+	// Without the identity stored in Keycloak, storing the details would remove the identity index: the user is skipped
+	kcUser, err := b.keycloakClient.GetUser(accessToken, key.RealmID, key.UserID)
+	if err != nil {
+		b.logger.Warn(ctx, "msg", "Can't get user from Keycloak", "err", err.Error(), "realmID", key.RealmID, "userID", key.UserID)
+		return false, nil
+	}
+	userDetails.SetIdentity(kcUser)
+
+	if err = b.usersDBModule.StoreOrUpdateUserDetails(ctx, key.RealmID, userDetails); err != nil {
+		b.logger.Warn(ctx, "msg", "Can't update user details", "err", err.Error(), "realmID", key.RealmID, "userID", key.UserID)
+		return false, err
+	}
+	return true, nil
+}
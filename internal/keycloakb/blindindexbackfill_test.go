@@ -0,0 +1,96 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlindIndexBackfill(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockUsersDB = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockKeycloakClient = mock.NewBackfillKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var backfill = NewBlindIndexBackfill(mockUsersDB, mockKeycloakClient, mockTokenProvider, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var anyError = errors.New("any error")
+	var key = dto.DBUserKey{RealmID: "realm", UserID: "user-id"}
+	var docNumber = "X123456"
+	var userDetails = dto.DBUser{UserID: &key.UserID, IDDocumentNumber: &docNumber}
+	var firstName = "John"
+	var lastName = "Doe"
+	var birthDate = "25.12.1980"
+	var attributes = kc.Attributes{}
+	attributes.SetString("ENC_birthDate", birthDate)
+	var kcUser = kc.UserRepresentation{FirstName: &firstName, LastName: &lastName, Attributes: &attributes}
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = backfill.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get keys", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetUserDetailsKeys(ctx, dto.DBUserKey{}, backfillPageSize).Return(nil, anyError)
+		var _, err = backfill.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't get user details", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetUserDetailsKeys(ctx, dto.DBUserKey{}, backfillPageSize).Return([]dto.DBUserKey{key}, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, key.RealmID, key.UserID).Return(dto.DBUser{}, anyError)
+		var _, err = backfill.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Keycloak user not found: user is skipped", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetUserDetailsKeys(ctx, dto.DBUserKey{}, backfillPageSize).Return([]dto.DBUserKey{key}, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, key.RealmID, key.UserID).Return(userDetails, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, key.RealmID, key.UserID).Return(kc.UserRepresentation{}, anyError)
+		var count, err = backfill.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Can't store user details", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetUserDetailsKeys(ctx, dto.DBUserKey{}, backfillPageSize).Return([]dto.DBUserKey{key}, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, key.RealmID, key.UserID).Return(userDetails, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, key.RealmID, key.UserID).Return(kcUser, nil)
+		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, key.RealmID, gomock.Any()).Return(anyError)
+		var _, err = backfill.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Success on several pages", func(t *testing.T) {
+		var fullPage = make([]dto.DBUserKey, backfillPageSize)
+		for i := range fullPage {
+			fullPage[i] = key
+		}
+		var expected = userDetails
+		expected.FirstName = &firstName
+		expected.LastName = &lastName
+		expected.BirthDate = &birthDate
+
+		mockUsersDB.EXPECT().GetUserDetailsKeys(ctx, dto.DBUserKey{}, backfillPageSize).Return(fullPage, nil)
+		mockUsersDB.EXPECT().GetUserDetailsKeys(ctx, key, backfillPageSize).Return([]dto.DBUserKey{}, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, key.RealmID, key.UserID).Return(userDetails, nil).Times(backfillPageSize)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, key.RealmID, key.UserID).Return(kcUser, nil).Times(backfillPageSize)
+		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, key.RealmID, expected).Return(nil).Times(backfillPageSize)
+		var count, err = backfill.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, backfillPageSize, count)
+	})
+}
@@ -0,0 +1,143 @@
+package keycloakb
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	breachedPrefixLength = 5
+	breachedFileSuffix   = ".txt"
+)
+
+var (
+	// BreachedPasswordViolation is the rule violated by a password appearing in the corpus of breached passwords
+	BreachedPasswordViolation = constants.MsgErrInvalidParam + "." + constants.Password + ".breached"
+)
+
+// BreachedPasswordChecker tells whether a password appears in a corpus of breached passwords
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+type fileBreachedPasswordChecker struct {
+	directory string
+}
+
+// NewFileBreachedPasswordChecker creates a checker reading an offline dump of breached passwords partitioned like the
+// k-anonymity range API of Have I Been Pwned: the directory contains one file per 5 characters prefix of the SHA-1 hashes
+// (like 5BAA6.txt) and each line of a file is the rest of a hash followed by its number of occurrences
+// (like 1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493). Passwords never leave the bridge
+func NewFileBreachedPasswordChecker(directory string) BreachedPasswordChecker {
+	return &fileBreachedPasswordChecker{
+		directory: directory,
+	}
+}
+
+func (fc *fileBreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	var hash = sha1.Sum([]byte(password))
+	var hexHash = strings.ToUpper(hex.EncodeToString(hash[:]))
+	var prefix, suffix = hexHash[:breachedPrefixLength], hexHash[breachedPrefixLength:]
+
+	// A complete dump has a file for each prefix: a missing one is an error rather than a password considered as safe
+	var file, err = os.Open(filepath.Join(fc.directory, prefix+breachedFileSuffix))
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		var hashSuffix, count = line, ""
+		if idx := strings.IndexByte(line, ':'); idx >= 0 {
+			hashSuffix, count = line[:idx], line[idx+1:]
+		}
+		if strings.EqualFold(hashSuffix, suffix) {
+			// Padding entries added by the range API have no occurrence
+			return count != "0", nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// CreateBreachedPasswordError creates the error returned when a password appears in the corpus of breached passwords
+func CreateBreachedPasswordError() error {
+	return errorhandler.Error{
+		Status:  http.StatusBadRequest,
+		Message: ComponentName + "." + BreachedPasswordViolation,
+	}
+}
+
+// BreachedPasswordKeycloakClient is the minimum Keycloak client interface for the breached password module
+type BreachedPasswordKeycloakClient interface {
+	GetRealm(accessToken string, realmName string) (kc.RealmRepresentation, error)
+}
+
+// BreachedPasswordModule screens the passwords of the realms which enabled the breached password check
+type BreachedPasswordModule interface {
+	IsBreached(ctx context.Context, realmName string, password string) (bool, error)
+}
+
+type breachedPasswordModule struct {
+	checker        BreachedPasswordChecker
+	keycloakClient BreachedPasswordKeycloakClient
+	tokenProvider  TokenProvider
+	confDBModule   AdminConfigurationDBModule
+	logger         Logger
+}
+
+// NewBreachedPasswordModule creates a breached password module. Without checker, no password is considered as breached
+func NewBreachedPasswordModule(checker BreachedPasswordChecker, keycloakClient BreachedPasswordKeycloakClient, tokenProvider TokenProvider,
+	confDBModule AdminConfigurationDBModule, logger Logger) BreachedPasswordModule {
+	return &breachedPasswordModule{
+		checker:        checker,
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		confDBModule:   confDBModule,
+		logger:         logger,
+	}
+}
+
+// IsBreached returns true if the breached password check is enabled for the realm and the password appears in the corpus
+func (bm *breachedPasswordModule) IsBreached(ctx context.Context, realmName string, password string) (bool, error) {
+	if bm.checker == nil {
+		return false, nil
+	}
+
+	var enabled, err = bm.isCheckEnabled(ctx, realmName)
+	if err != nil || !enabled {
+		return false, err
+	}
+
+	breached, err := bm.checker.IsBreached(password)
+	if err != nil {
+		bm.logger.Warn(ctx, "msg", "Can't check password against breached passwords", "err", err.Error(), "realm", realmName)
+		return false, err
+	}
+	return breached, nil
+}
+
+func (bm *breachedPasswordModule) isCheckEnabled(ctx context.Context, realmName string) (bool, error) {
+	var accessToken, err = bm.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		bm.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return false, err
+	}
+
+	adminConfig, err := GetRealmAdminConfiguration(ctx, bm.keycloakClient, bm.confDBModule, accessToken, realmName, bm.logger)
+	if err != nil {
+		return false, err
+	}
+	return adminConfig.BreachedPasswordCheck != nil && *adminConfig.BreachedPasswordCheck, nil
+}
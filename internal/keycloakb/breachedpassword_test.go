@@ -0,0 +1,140 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileBreachedPasswordChecker(t *testing.T) {
+	var directory, err = ioutil.TempDir("", "breached")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8 and SHA-1 of "P@ssw0rd" is 21BD12DC183F740EE76F27B78EB39C8AD972A757
+	var content = "003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "5BAA6.txt"), []byte(content), 0600))
+	var padded = "2DC183F740EE76F27B78EB39C8AD972A757:0\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "21BD1.txt"), []byte(padded), 0600))
+
+	var checker = NewFileBreachedPasswordChecker(directory)
+
+	t.Run("Breached password", func(t *testing.T) {
+		var breached, err = checker.IsBreached("password")
+		assert.Nil(t, err)
+		assert.True(t, breached)
+	})
+
+	t.Run("Padding entry", func(t *testing.T) {
+		var breached, err = checker.IsBreached("P@ssw0rd")
+		assert.Nil(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("Unknown password", func(t *testing.T) {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "5BAA6.txt"), []byte("003D68EB55068C33ACE09247EE4C639306B:3\n"), 0600))
+		var breached, err = checker.IsBreached("password")
+		assert.Nil(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("Missing partition", func(t *testing.T) {
+		var _, err = checker.IsBreached("another password")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCreateBreachedPasswordError(t *testing.T) {
+	var err = CreateBreachedPasswordError()
+	assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+	assert.Equal(t, "keycloak-bridge.invalidParameter.password.breached", err.(errorhandler.Error).Message)
+}
+
+func TestBreachedPasswordModule(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockChecker = mock.NewBreachedPasswordChecker(mockCtrl)
+	var mockKeycloakClient = mock.NewBreachedPasswordKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
+
+	var module = NewBreachedPasswordModule(mockChecker, mockKeycloakClient, mockTokenProvider, mockConfDB, log.NewNopLogger())
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realmName = "realm"
+	var realmID = "realm-id"
+	var password = "password"
+	var enabled = true
+	var anyError = errors.New("any error")
+
+	t.Run("No checker", func(t *testing.T) {
+		var breached, err = NewBreachedPasswordModule(nil, mockKeycloakClient, mockTokenProvider, mockConfDB, log.NewNopLogger()).IsBreached(ctx, realmName, password)
+		assert.Nil(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = module.IsBreached(ctx, realmName, password)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, anyError)
+		var _, err = module.IsBreached(ctx, realmName, password)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil).AnyTimes()
+
+	t.Run("Can't get admin configuration", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, anyError)
+		var _, err = module.IsBreached(ctx, realmName, password)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("No admin configuration", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
+		var breached, err = module.IsBreached(ctx, realmName, password)
+		assert.Nil(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("Check disabled", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, nil)
+		var breached, err = module.IsBreached(ctx, realmName, password)
+		assert.Nil(t, err)
+		assert.False(t, breached)
+	})
+
+	mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{BreachedPasswordCheck: &enabled}, nil).AnyTimes()
+
+	t.Run("Checker fails", func(t *testing.T) {
+		mockChecker.EXPECT().IsBreached(password).Return(false, anyError)
+		var _, err = module.IsBreached(ctx, realmName, password)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Breached password", func(t *testing.T) {
+		mockChecker.EXPECT().IsBreached(password).Return(true, nil)
+		var breached, err = module.IsBreached(ctx, realmName, password)
+		assert.Nil(t, err)
+		assert.True(t, breached)
+	})
+}
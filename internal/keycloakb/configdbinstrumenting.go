@@ -28,8 +28,8 @@ type ConfigurationDBModule interface {
 	GetConfigurations(context.Context, string) (configuration.RealmConfiguration, configuration.RealmAdminConfiguration, error)
 	StoreOrUpdateConfiguration(context.Context, string, configuration.RealmConfiguration) error
 	GetConfiguration(context.Context, string) (configuration.RealmConfiguration, error)
-	StoreOrUpdateAdminConfiguration(context.Context, string, configuration.RealmAdminConfiguration) error
-	GetAdminConfiguration(context.Context, string) (configuration.RealmAdminConfiguration, error)
+	StoreOrUpdateAdminConfiguration(context.Context, string, dto.RealmAdminConfiguration) error
+	GetAdminConfiguration(context.Context, string) (dto.RealmAdminConfiguration, error)
 	GetBackOfficeConfiguration(context.Context, string, []string) (dto.BackOfficeConfiguration, error)
 	DeleteBackOfficeConfiguration(context.Context, string, string, string, *string, *string) error
 	InsertBackOfficeConfiguration(context.Context, string, string, string, string, []string) error
@@ -37,6 +37,18 @@ type ConfigurationDBModule interface {
 	CreateAuthorization(context context.Context, authz configuration.Authorization) error
 	DeleteAuthorizations(context context.Context, realmID string, groupName string) error
 	DeleteAllAuthorizationsWithGroup(context context.Context, realmName, groupName string) error
+	RenameGroup(context context.Context, realmID, groupName, newGroupName string) error
+	GetAuthorizationTemplates(context context.Context) (map[string][]configuration.Authorization, error)
+	GetAuthorizationTemplate(context context.Context, templateName string) ([]configuration.Authorization, error)
+	StoreAuthorizationTemplate(context context.Context, templateName string, authorizations []configuration.Authorization) error
+	DeleteAuthorizationTemplate(context context.Context, templateName string) error
+	CreateAuthorizationVersion(context context.Context, version dto.AuthorizationVersion) error
+	GetAuthorizationVersions(context context.Context, realmID string, groupName string) ([]dto.AuthorizationVersion, error)
+	GetAuthorizationVersion(context context.Context, realmID string, groupName string, version int) (*dto.AuthorizationVersion, error)
+	CreatePendingRequest(context context.Context, request dto.PendingRequest) (int64, error)
+	GetPendingRequests(context context.Context, realmName string) ([]dto.PendingRequest, error)
+	GetPendingRequest(context context.Context, realmName string, requestID int64) (*dto.PendingRequest, error)
+	UpdatePendingRequestStatus(context context.Context, request dto.PendingRequest, expectedStatus string) (bool, error)
 }
 
 // MakeConfigurationDBModuleInstrumentingMW makes an instrumenting middleware at module level.
@@ -82,7 +94,7 @@ func (m *configDBModuleInstrumentingMW) GetConfiguration(ctx context.Context, re
 }
 
 // configDBModuleInstrumentingMW implements Module.
-func (m *configDBModuleInstrumentingMW) StoreOrUpdateAdminConfiguration(ctx context.Context, realmName string, config configuration.RealmAdminConfiguration) error {
+func (m *configDBModuleInstrumentingMW) StoreOrUpdateAdminConfiguration(ctx context.Context, realmName string, config dto.RealmAdminConfiguration) error {
 	defer func(begin time.Time) {
 		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
 	}(time.Now())
@@ -90,7 +102,7 @@ func (m *configDBModuleInstrumentingMW) StoreOrUpdateAdminConfiguration(ctx cont
 }
 
 // configDBModuleInstrumentingMW implements Module.
-func (m *configDBModuleInstrumentingMW) GetAdminConfiguration(ctx context.Context, realmName string) (configuration.RealmAdminConfiguration, error) {
+func (m *configDBModuleInstrumentingMW) GetAdminConfiguration(ctx context.Context, realmName string) (dto.RealmAdminConfiguration, error) {
 	defer func(begin time.Time) {
 		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
 	}(time.Now())
@@ -150,3 +162,99 @@ func (m *configDBModuleInstrumentingMW) DeleteAllAuthorizationsWithGroup(ctx con
 	}(time.Now())
 	return m.next.DeleteAllAuthorizationsWithGroup(ctx, realmID, groupName)
 }
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) RenameGroup(ctx context.Context, realmID, groupName, newGroupName string) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.RenameGroup(ctx, realmID, groupName, newGroupName)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetAuthorizationTemplates(ctx context.Context) (map[string][]configuration.Authorization, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetAuthorizationTemplates(ctx)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetAuthorizationTemplate(ctx context.Context, templateName string) ([]configuration.Authorization, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetAuthorizationTemplate(ctx, templateName)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) StoreAuthorizationTemplate(ctx context.Context, templateName string, authorizations []configuration.Authorization) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.StoreAuthorizationTemplate(ctx, templateName, authorizations)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) DeleteAuthorizationTemplate(ctx context.Context, templateName string) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.DeleteAuthorizationTemplate(ctx, templateName)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) CreateAuthorizationVersion(ctx context.Context, version dto.AuthorizationVersion) error {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.CreateAuthorizationVersion(ctx, version)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetAuthorizationVersions(ctx context.Context, realmID string, groupName string) ([]dto.AuthorizationVersion, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetAuthorizationVersions(ctx, realmID, groupName)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetAuthorizationVersion(ctx context.Context, realmID string, groupName string, version int) (*dto.AuthorizationVersion, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetAuthorizationVersion(ctx, realmID, groupName, version)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) CreatePendingRequest(ctx context.Context, request dto.PendingRequest) (int64, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.CreatePendingRequest(ctx, request)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetPendingRequests(ctx context.Context, realmName string) ([]dto.PendingRequest, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetPendingRequests(ctx, realmName)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) GetPendingRequest(ctx context.Context, realmName string, requestID int64) (*dto.PendingRequest, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.GetPendingRequest(ctx, realmName, requestID)
+}
+
+// configDBModuleInstrumentingMW implements Module.
+func (m *configDBModuleInstrumentingMW) UpdatePendingRequestStatus(ctx context.Context, request dto.PendingRequest, expectedStatus string) (bool, error) {
+	defer func(begin time.Time) {
+		m.h.With(KeyCorrelationID, ctx.Value(cs.CtContextCorrelationID).(string)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return m.next.UpdatePendingRequestStatus(ctx, request, expectedStatus)
+}
@@ -95,13 +95,13 @@ func TestComponentInstrumentingMW(t *testing.T) {
 		mockComponent.EXPECT().StoreOrUpdateAdminConfiguration(ctx, "realmID", gomock.Any()).Return(nil).Times(1)
 		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
 		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
-		m.StoreOrUpdateAdminConfiguration(ctx, "realmID", configuration.RealmAdminConfiguration{})
+		m.StoreOrUpdateAdminConfiguration(ctx, "realmID", dto.RealmAdminConfiguration{})
 	})
 
 	t.Run("Update configuration without correlation ID", func(t *testing.T) {
 		mockComponent.EXPECT().StoreOrUpdateAdminConfiguration(context.Background(), "realmID", gomock.Any()).Return(nil).Times(1)
 		assert.Panics(t, func() {
-			m.StoreOrUpdateAdminConfiguration(context.Background(), "realmID", configuration.RealmAdminConfiguration{})
+			m.StoreOrUpdateAdminConfiguration(context.Background(), "realmID", dto.RealmAdminConfiguration{})
 		})
 	})
 
@@ -142,4 +142,85 @@ func TestComponentInstrumentingMW(t *testing.T) {
 			m.InsertBackOfficeConfiguration(context.Background(), realmID, groupName, confType, realmID, groupNames)
 		})
 	})
+	t.Run("Rename group", func(t *testing.T) {
+		mockComponent.EXPECT().RenameGroup(ctx, realmID, groupName, "new-name").Return(nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.RenameGroup(ctx, realmID, groupName, "new-name")
+	})
+	t.Run("Rename group without correlation ID", func(t *testing.T) {
+		mockComponent.EXPECT().RenameGroup(context.Background(), realmID, groupName, "new-name").Return(nil)
+		assert.Panics(t, func() {
+			m.RenameGroup(context.Background(), realmID, groupName, "new-name")
+		})
+	})
+	t.Run("Get authorization templates", func(t *testing.T) {
+		mockComponent.EXPECT().GetAuthorizationTemplates(ctx).Return(nil, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.GetAuthorizationTemplates(ctx)
+	})
+	t.Run("Get authorization template", func(t *testing.T) {
+		mockComponent.EXPECT().GetAuthorizationTemplate(ctx, "template").Return(nil, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.GetAuthorizationTemplate(ctx, "template")
+	})
+	t.Run("Store authorization template", func(t *testing.T) {
+		mockComponent.EXPECT().StoreAuthorizationTemplate(ctx, "template", nil).Return(nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.StoreAuthorizationTemplate(ctx, "template", nil)
+	})
+	t.Run("Delete authorization template", func(t *testing.T) {
+		mockComponent.EXPECT().DeleteAuthorizationTemplate(ctx, "template").Return(nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.DeleteAuthorizationTemplate(ctx, "template")
+	})
+	t.Run("Create authorization version", func(t *testing.T) {
+		var version = dto.AuthorizationVersion{RealmID: realmID, GroupName: groupName}
+		mockComponent.EXPECT().CreateAuthorizationVersion(ctx, version).Return(nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.CreateAuthorizationVersion(ctx, version)
+	})
+	t.Run("Get authorization versions", func(t *testing.T) {
+		mockComponent.EXPECT().GetAuthorizationVersions(ctx, realmID, groupName).Return(nil, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.GetAuthorizationVersions(ctx, realmID, groupName)
+	})
+	t.Run("Get authorization version", func(t *testing.T) {
+		mockComponent.EXPECT().GetAuthorizationVersion(ctx, realmID, groupName, 3).Return(nil, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.GetAuthorizationVersion(ctx, realmID, groupName, 3)
+	})
+	t.Run("Create pending request", func(t *testing.T) {
+		var request = dto.PendingRequest{RealmName: realmID, Action: "MGMT_DeleteUser"}
+		mockComponent.EXPECT().CreatePendingRequest(ctx, request).Return(int64(1), nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.CreatePendingRequest(ctx, request)
+	})
+	t.Run("Get pending requests", func(t *testing.T) {
+		mockComponent.EXPECT().GetPendingRequests(ctx, realmID).Return(nil, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.GetPendingRequests(ctx, realmID)
+	})
+	t.Run("Get pending request", func(t *testing.T) {
+		mockComponent.EXPECT().GetPendingRequest(ctx, realmID, int64(4)).Return(nil, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.GetPendingRequest(ctx, realmID, 4)
+	})
+	t.Run("Update pending request status", func(t *testing.T) {
+		var request = dto.PendingRequest{RealmName: realmID, ID: 4, Status: dto.PendingRequestStatusRejected}
+		mockComponent.EXPECT().UpdatePendingRequestStatus(ctx, request, dto.PendingRequestStatusPending).Return(true, nil)
+		mockHistogram.EXPECT().With("correlation_id", corrID).Return(mockHistogram).Times(1)
+		mockHistogram.EXPECT().Observe(gomock.Any()).Return().Times(1)
+		m.UpdatePendingRequestStatus(ctx, request, dto.PendingRequestStatusPending)
+	})
 }
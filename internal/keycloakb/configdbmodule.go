@@ -21,7 +21,8 @@ const (
 	updateAdminConfigStmt = `INSERT INTO realm_configuration (realm_id, admin_configuration)
 	  VALUES (?, ?)
 	  ON DUPLICATE KEY UPDATE admin_configuration = ?;`
-	selectBOConfigStmt = `
+	selectAdminConfigStmt = `SELECT admin_configuration FROM realm_configuration WHERE realm_id=?;`
+	selectBOConfigStmt    = `
 		SELECT distinct target_realm_id, target_type, target_group_name
 		FROM backoffice_configuration
 		WHERE realm_id=? AND group_name IN (???)
@@ -43,6 +44,47 @@ const (
 		VALUES (?, ?, ?, ?, ?);`
 	deleteAuthzStmt             = `DELETE FROM authorizations WHERE realm_id = ? AND group_name = ?;`
 	deleteAllAuthzWithGroupStmt = `DELETE FROM authorizations WHERE (realm_id = ? AND group_name = ?) OR (target_realm_id = ? AND target_group_name = ?);`
+	renameAuthzGroupStmt        = `UPDATE authorizations SET group_name = ? WHERE realm_id = ? AND group_name = ?;`
+	renameAuthzTargetGroupStmt  = `UPDATE authorizations SET target_group_name = ? WHERE target_realm_id = ? AND target_group_name = ?;`
+	renameBOConfigGroupStmt     = `UPDATE backoffice_configuration SET group_name = ? WHERE realm_id = ? AND group_name = ?;`
+	renameBOConfigTargetStmt    = `UPDATE backoffice_configuration SET target_group_name = ? WHERE target_realm_id = ? AND target_group_name = ?;`
+	renameAuthzVersionGroupStmt = `UPDATE authorization_versions SET group_name = ? WHERE realm_id = ? AND group_name = ?;`
+	selectAuthzTemplatesStmt    = `SELECT template_name, action, target_realm_id, target_group_name FROM authorization_templates ORDER BY template_name;`
+	selectAuthzTemplateStmt     = `SELECT template_name, action, target_realm_id, target_group_name FROM authorization_templates WHERE template_name = ?;`
+	createAuthzTemplateStmt     = `INSERT INTO authorization_templates (template_name, action, target_realm_id, target_group_name) 
+		VALUES (?, ?, ?, ?);`
+	deleteAuthzTemplateStmt = `DELETE FROM authorization_templates WHERE template_name = ?;`
+	insertAuthzVersionStmt  = `INSERT INTO authorization_versions (realm_id, group_name, version, author_realm, author, created_at, authorizations, diff)
+		SELECT ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ?
+		FROM authorization_versions
+		WHERE realm_id = ? AND group_name = ?;`
+	selectAuthzVersionsStmt = `
+		SELECT version, author_realm, author, unix_timestamp(created_at), authorizations, diff
+		FROM authorization_versions
+		WHERE realm_id = ? AND group_name = ?
+		ORDER BY version DESC;`
+	selectAuthzVersionStmt = `
+		SELECT version, author_realm, author, unix_timestamp(created_at), authorizations, diff
+		FROM authorization_versions
+		WHERE realm_id = ? AND group_name = ? AND version = ?;`
+	insertPendingRequestStmt = `
+		INSERT INTO pending_requests (realm_name, action, user_id, group_id, credential_id, payload, secret, requester_realm, requester, request_date, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	selectPendingRequestsStmt = `
+		SELECT id, realm_name, action, user_id, group_id, credential_id, payload, secret, requester_realm, requester, unix_timestamp(request_date),
+			status, approver_realm, approver, unix_timestamp(resolution_date)
+		FROM pending_requests
+		WHERE realm_name = ? AND status = ?
+		ORDER BY request_date;`
+	selectPendingRequestStmt = `
+		SELECT id, realm_name, action, user_id, group_id, credential_id, payload, secret, requester_realm, requester, unix_timestamp(request_date),
+			status, approver_realm, approver, unix_timestamp(resolution_date)
+		FROM pending_requests
+		WHERE realm_name = ? AND id = ?;`
+	updatePendingRequestStmt = `
+		UPDATE pending_requests
+		SET status = ?, approver_realm = ?, approver = ?, resolution_date = ?
+		WHERE realm_name = ? AND id = ? AND status = ?;`
 )
 
 // Scanner used to get data from SQL cursors
@@ -101,7 +143,7 @@ func (c *configurationDBModule) GetConfiguration(ctx context.Context, realmID st
 	return config, err
 }
 
-func (c *configurationDBModule) StoreOrUpdateAdminConfiguration(context context.Context, realmID string, config configuration.RealmAdminConfiguration) error {
+func (c *configurationDBModule) StoreOrUpdateAdminConfiguration(context context.Context, realmID string, config dto.RealmAdminConfiguration) error {
 	var bytes, _ = json.Marshal(config)
 	var configJSON = string(bytes)
 	// update value in DB
@@ -109,8 +151,23 @@ func (c *configurationDBModule) StoreOrUpdateAdminConfiguration(context context.
 	return err
 }
 
-func (c *configurationDBModule) GetAdminConfiguration(ctx context.Context, realmID string) (configuration.RealmAdminConfiguration, error) {
-	return c.ConfigurationReaderDBModule.GetAdminConfiguration(ctx, realmID)
+// GetAdminConfiguration reads the admin configuration including the bridge specific policies which are unknown from the common reader.
+// Returns sql.ErrNoRows if the realm has no admin configuration
+func (c *configurationDBModule) GetAdminConfiguration(ctx context.Context, realmID string) (dto.RealmAdminConfiguration, error) {
+	var configJSON sql.NullString
+	if err := c.db.QueryRow(selectAdminConfigStmt, realmID).Scan(&configJSON); err != nil {
+		return dto.RealmAdminConfiguration{}, err
+	}
+	if !configJSON.Valid {
+		return dto.RealmAdminConfiguration{}, sql.ErrNoRows
+	}
+
+	var config dto.RealmAdminConfiguration
+	if err := json.Unmarshal([]byte(configJSON.String), &config); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't unmarshal admin configuration", "error", err.Error(), "realmID", realmID)
+		return dto.RealmAdminConfiguration{}, err
+	}
+	return config, nil
 }
 
 func (c *configurationDBModule) GetBackOfficeConfiguration(ctx context.Context, realmID string, groupNames []string) (dto.BackOfficeConfiguration, error) {
@@ -209,6 +266,209 @@ func (c *configurationDBModule) DeleteAllAuthorizationsWithGroup(context context
 	return err
 }
 
+// RenameGroup replaces the name of a group in the authorizations and in the back-office configuration. All updates are done in a single transaction
+func (c *configurationDBModule) RenameGroup(context context.Context, realmID, groupName, newGroupName string) error {
+	tx, err := c.db.BeginTx(context, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	for _, stmt := range []string{renameAuthzGroupStmt, renameAuthzTargetGroupStmt, renameBOConfigGroupStmt, renameBOConfigTargetStmt, renameAuthzVersionGroupStmt} {
+		if _, err = tx.Exec(stmt, newGroupName, realmID, groupName); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAuthorizationTemplates returns all the authorization templates, by template name. RealmID and GroupName are not set in the returned authorizations
+func (c *configurationDBModule) GetAuthorizationTemplates(ctx context.Context) (map[string][]configuration.Authorization, error) {
+	rows, err := c.db.Query(selectAuthzTemplatesStmt)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get authorization templates", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make(map[string][]configuration.Authorization)
+	for rows.Next() {
+		templateName, authz, err := c.scanAuthorizationTemplate(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get authorization templates. Scan failed", "error", err.Error())
+			return nil, err
+		}
+		res[templateName] = append(res[templateName], authz)
+	}
+
+	return res, nil
+}
+
+// GetAuthorizationTemplate returns the authorizations of a template. The result is empty if the template does not exist
+func (c *configurationDBModule) GetAuthorizationTemplate(ctx context.Context, templateName string) ([]configuration.Authorization, error) {
+	rows, err := c.db.Query(selectAuthzTemplateStmt, templateName)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get authorization template", "error", err.Error(), "templateName", templateName)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]configuration.Authorization, 0)
+	for rows.Next() {
+		_, authz, err := c.scanAuthorizationTemplate(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get authorization template. Scan failed", "error", err.Error(), "templateName", templateName)
+			return nil, err
+		}
+		res = append(res, authz)
+	}
+
+	return res, nil
+}
+
+// StoreAuthorizationTemplate replaces the authorizations of a template in a single transaction. RealmID and GroupName of the authorizations are ignored
+func (c *configurationDBModule) StoreAuthorizationTemplate(context context.Context, templateName string, authorizations []configuration.Authorization) error {
+	tx, err := c.db.BeginTx(context, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	if _, err = tx.Exec(deleteAuthzTemplateStmt, templateName); err != nil {
+		return err
+	}
+	for _, authz := range authorizations {
+		_, err = tx.Exec(createAuthzTemplateStmt, templateName, nullableString(authz.Action), nullableString(authz.TargetRealmID), nullableString(authz.TargetGroupName))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteAuthorizationTemplate deletes an authorization template
+func (c *configurationDBModule) DeleteAuthorizationTemplate(context context.Context, templateName string) error {
+	_, err := c.db.Exec(deleteAuthzTemplateStmt, templateName)
+	return err
+}
+
+// CreateAuthorizationVersion records a new version of the authorizations of a group. The version number is the next one available for the group
+func (c *configurationDBModule) CreateAuthorizationVersion(context context.Context, version dto.AuthorizationVersion) error {
+	authorizationsJSON, err := json.Marshal(version.Authorizations)
+	if err != nil {
+		return err
+	}
+	diffJSON, err := json.Marshal(version.Diff)
+	if err != nil {
+		return err
+	}
+	_, err = c.db.Exec(insertAuthzVersionStmt, version.RealmID, version.GroupName, version.AuthorRealm, version.Author, version.Date,
+		string(authorizationsJSON), string(diffJSON), version.RealmID, version.GroupName)
+	return err
+}
+
+// GetAuthorizationVersions returns the versions of the authorizations of a group, the most recent first
+func (c *configurationDBModule) GetAuthorizationVersions(ctx context.Context, realmID string, groupName string) ([]dto.AuthorizationVersion, error) {
+	rows, err := c.db.Query(selectAuthzVersionsStmt, realmID, groupName)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get authorization versions", "error", err.Error(), "realmID", realmID, "groupName", groupName)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.AuthorizationVersion, 0)
+	for rows.Next() {
+		version, err := c.scanAuthorizationVersion(rows, realmID, groupName)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get authorization versions. Scan failed", "error", err.Error(), "realmID", realmID, "groupName", groupName)
+			return nil, err
+		}
+		res = append(res, version)
+	}
+
+	return res, nil
+}
+
+// GetAuthorizationVersion returns a version of the authorizations of a group. It returns nil if the version does not exist
+func (c *configurationDBModule) GetAuthorizationVersion(ctx context.Context, realmID string, groupName string, versionNumber int) (*dto.AuthorizationVersion, error) {
+	var row = c.db.QueryRow(selectAuthzVersionStmt, realmID, groupName, versionNumber)
+	version, err := c.scanAuthorizationVersion(row, realmID, groupName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get authorization version", "error", err.Error(), "realmID", realmID, "groupName", groupName, "version", versionNumber)
+		return nil, err
+	}
+	return &version, nil
+}
+
+// CreatePendingRequest stores a pending request and returns its identifier
+func (c *configurationDBModule) CreatePendingRequest(ctx context.Context, request dto.PendingRequest) (int64, error) {
+	res, err := c.db.Exec(insertPendingRequestStmt, request.RealmName, request.Action, nullableString(request.UserID), nullableString(request.GroupID),
+		nullableString(request.CredentialID), nullableString(request.Payload), request.Secret, request.RequesterRealm, request.Requester,
+		request.RequestDate, request.Status)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't store pending request", "error", err.Error(), "realm", request.RealmName, "action", request.Action)
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetPendingRequests returns the requests of a realm which are still waiting for an approval, the oldest first
+func (c *configurationDBModule) GetPendingRequests(ctx context.Context, realmName string) ([]dto.PendingRequest, error) {
+	rows, err := c.db.Query(selectPendingRequestsStmt, realmName, dto.PendingRequestStatusPending)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get pending requests", "error", err.Error(), "realm", realmName)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res = make([]dto.PendingRequest, 0)
+	for rows.Next() {
+		request, err := c.scanPendingRequest(rows)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't get pending requests. Scan failed", "error", err.Error(), "realm", realmName)
+			return nil, err
+		}
+		res = append(res, request)
+	}
+
+	return res, rows.Err()
+}
+
+// GetPendingRequest returns a request whatever its status. Returns nil if the request does not exist
+func (c *configurationDBModule) GetPendingRequest(ctx context.Context, realmName string, requestID int64) (*dto.PendingRequest, error) {
+	var row = c.db.QueryRow(selectPendingRequestStmt, realmName, requestID)
+	request, err := c.scanPendingRequest(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get pending request", "error", err.Error(), "realm", realmName, "id", requestID)
+		return nil, err
+	}
+	return &request, nil
+}
+
+// UpdatePendingRequestStatus changes the status of a request if its current status is the expected one. Returns false if the
+// request does not exist or does not have the expected status anymore
+func (c *configurationDBModule) UpdatePendingRequestStatus(ctx context.Context, request dto.PendingRequest, expectedStatus string) (bool, error) {
+	res, err := c.db.Exec(updatePendingRequestStmt, request.Status, nullableString(request.ApproverRealm), nullableString(request.Approver),
+		request.ResolutionDate, request.RealmName, request.ID, expectedStatus)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't update pending request", "error", err.Error(), "realm", request.RealmName, "id", request.ID)
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (c *configurationDBModule) NewTransaction(context context.Context) (sqltypes.Transaction, error) {
 	return c.db.BeginTx(context, nil)
 }
@@ -244,9 +504,96 @@ func (c *configurationDBModule) scanAuthorization(scanner Scanner) (configuratio
 	return authz, nil
 }
 
+func (c *configurationDBModule) scanAuthorizationTemplate(scanner Scanner) (string, configuration.Authorization, error) {
+	var (
+		templateName    string
+		action          string
+		targetRealmID   sql.NullString
+		targetGroupName sql.NullString
+	)
+
+	err := scanner.Scan(&templateName, &action, &targetRealmID, &targetGroupName)
+	if err != nil {
+		return "", configuration.Authorization{}, err
+	}
+
+	var authz = configuration.Authorization{
+		Action: &action,
+	}
+
+	if targetRealmID.Valid {
+		authz.TargetRealmID = &targetRealmID.String
+	}
+
+	if targetGroupName.Valid {
+		authz.TargetGroupName = &targetGroupName.String
+	}
+
+	return templateName, authz, nil
+}
+
+func (c *configurationDBModule) scanAuthorizationVersion(scanner Scanner, realmID, groupName string) (dto.AuthorizationVersion, error) {
+	var (
+		version            = dto.AuthorizationVersion{RealmID: realmID, GroupName: groupName}
+		createdAt          sql.NullString
+		authorizationsJSON string
+		diffJSON           string
+	)
+
+	err := scanner.Scan(&version.Version, &version.AuthorRealm, &version.Author, &createdAt, &authorizationsJSON, &diffJSON)
+	if err != nil {
+		return dto.AuthorizationVersion{}, err
+	}
+
+	if date := nullStringToDatePtr(createdAt); date != nil {
+		version.Date = *date
+	}
+	if err = json.Unmarshal([]byte(authorizationsJSON), &version.Authorizations); err != nil {
+		return dto.AuthorizationVersion{}, err
+	}
+	if err = json.Unmarshal([]byte(diffJSON), &version.Diff); err != nil {
+		return dto.AuthorizationVersion{}, err
+	}
+
+	return version, nil
+}
+
 func nullableString(value *string) interface{} {
 	if value != nil {
 		return value
 	}
 	return nil
 }
+
+func (c *configurationDBModule) scanPendingRequest(scanner Scanner) (dto.PendingRequest, error) {
+	var (
+		request        dto.PendingRequest
+		userID         sql.NullString
+		groupID        sql.NullString
+		credentialID   sql.NullString
+		payload        sql.NullString
+		requestDate    sql.NullString
+		approverRealm  sql.NullString
+		approver       sql.NullString
+		resolutionDate sql.NullString
+	)
+
+	err := scanner.Scan(&request.ID, &request.RealmName, &request.Action, &userID, &groupID, &credentialID, &payload, &request.Secret,
+		&request.RequesterRealm, &request.Requester, &requestDate, &request.Status, &approverRealm, &approver, &resolutionDate)
+	if err != nil {
+		return dto.PendingRequest{}, err
+	}
+
+	request.UserID = nullStringToPtr(userID)
+	request.GroupID = nullStringToPtr(groupID)
+	request.CredentialID = nullStringToPtr(credentialID)
+	request.Payload = nullStringToPtr(payload)
+	request.ApproverRealm = nullStringToPtr(approverRealm)
+	request.Approver = nullStringToPtr(approver)
+	if date := nullStringToDatePtr(requestDate); date != nil {
+		request.RequestDate = *date
+	}
+	request.ResolutionDate = nullStringToDatePtr(resolutionDate)
+
+	return request, nil
+}
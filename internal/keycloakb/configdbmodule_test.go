@@ -7,11 +7,13 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/common-service/log"
 
 	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
 	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -131,7 +133,11 @@ func TestStoreOrGetAdminConfiguration(t *testing.T) {
 
 	var configDBModule = NewConfigurationDBModule(mockDB, mockLogger)
 	var realmID = "myrealm"
-	var adminConfig configuration.RealmAdminConfiguration
+	var inactivityDays = 90
+	var adminConfig = dto.RealmAdminConfiguration{
+		RealmAdminConfiguration: configuration.RealmAdminConfiguration{Mode: ptr("trustID")},
+		AccountDeactivation:     &dto.AccountDeactivationPolicy{InactivityDays: &inactivityDays},
+	}
 	var adminConfigStr = toJSONString(adminConfig)
 	var sqlError = errors.New("sql")
 	var ctx = context.TODO()
@@ -158,10 +164,27 @@ func TestStoreOrGetAdminConfiguration(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("Get-SQL query returns a NULL admin configuration", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(nil)
+		var _, err = configDBModule.GetAdminConfiguration(ctx, realmID)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("Get-SQL query returns an invalid admin configuration", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realmID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(conf *sql.NullString) error {
+			*conf = sql.NullString{Valid: true, String: "{"}
+			return nil
+		})
+		var _, err = configDBModule.GetAdminConfiguration(ctx, realmID)
+		assert.NotNil(t, err)
+	})
+
 	t.Run("Get-SQL query returns an admin configuration", func(t *testing.T) {
 		mockDB.EXPECT().QueryRow(gomock.Any(), realmID).Return(mockSQLRow)
-		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(conf *string) error {
-			*conf = adminConfigStr
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(conf *sql.NullString) error {
+			*conf = sql.NullString{Valid: true, String: adminConfigStr}
 			return nil
 		})
 		var conf, err = configDBModule.GetAdminConfiguration(ctx, realmID)
@@ -256,3 +279,446 @@ func TestBackOfficeConfiguration(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestRenameGroup(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockTransaction = mock.NewTransaction(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+
+	var configDBModule = NewConfigurationDBModule(mockDB, mockLogger)
+	var expectedError = errors.New("error")
+	var realmID = "my-realm"
+	var groupName = "my-group"
+	var newGroupName = "my-new-group"
+	var ctx = context.TODO()
+
+	t.Run("Can't start transaction", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(nil, expectedError)
+		var err = configDBModule.RenameGroup(ctx, realmID, groupName, newGroupName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Update fails", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTransaction, nil)
+		mockTransaction.EXPECT().Exec(renameAuthzGroupStmt, newGroupName, realmID, groupName).Return(nil, nil)
+		mockTransaction.EXPECT().Exec(renameAuthzTargetGroupStmt, newGroupName, realmID, groupName).Return(nil, expectedError)
+		mockTransaction.EXPECT().Close()
+		var err = configDBModule.RenameGroup(ctx, realmID, groupName, newGroupName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("Success", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTransaction, nil)
+		mockTransaction.EXPECT().Exec(gomock.Any(), newGroupName, realmID, groupName).Return(nil, nil).Times(5)
+		mockTransaction.EXPECT().Commit().Return(nil)
+		mockTransaction.EXPECT().Close()
+		var err = configDBModule.RenameGroup(ctx, realmID, groupName, newGroupName)
+		assert.Nil(t, err)
+	})
+}
+
+func TestAuthorizationTemplates(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockTransaction = mock.NewTransaction(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+
+	var configDBModule = NewConfigurationDBModule(mockDB, mockLogger)
+	var expectedError = errors.New("error")
+	var templateName = "customer_admins"
+	var action = "MGMT_GetUser"
+	var targetRealmID = "DEP"
+	var targetGroupName = "agents"
+	var ctx = context.TODO()
+
+	var scanTemplateRow = func(name string, targetGroup sql.NullString) func(*string, *string, *sql.NullString, *sql.NullString) error {
+		return func(tplName *string, tplAction *string, tplTargetRealm *sql.NullString, tplTargetGroup *sql.NullString) error {
+			*tplName = name
+			*tplAction = action
+			*tplTargetRealm = sql.NullString{String: targetRealmID, Valid: true}
+			*tplTargetGroup = targetGroup
+			return nil
+		}
+	}
+
+	t.Run("GET ALL-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzTemplatesStmt).Return(nil, expectedError)
+		var _, err = configDBModule.GetAuthorizationTemplates(ctx)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ALL-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzTemplatesStmt).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetAuthorizationTemplates(ctx)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ALL-Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectAuthzTemplatesStmt).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanTemplateRow(templateName, sql.NullString{String: targetGroupName, Valid: true})),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanTemplateRow("other", sql.NullString{})),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var templates, err = configDBModule.GetAuthorizationTemplates(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, templates, 2)
+		assert.Equal(t, targetGroupName, *templates[templateName][0].TargetGroupName)
+		assert.Nil(t, templates["other"][0].TargetGroupName)
+		assert.Nil(t, templates["other"][0].RealmID)
+	})
+
+	t.Run("GET-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzTemplateStmt, templateName).Return(nil, expectedError)
+		var _, err = configDBModule.GetAuthorizationTemplate(ctx, templateName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzTemplateStmt, templateName).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetAuthorizationTemplate(ctx, templateName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET-Unknown template", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzTemplateStmt, templateName).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(false)
+		mockSQLRows.EXPECT().Close()
+		var authz, err = configDBModule.GetAuthorizationTemplate(ctx, templateName)
+		assert.Nil(t, err)
+		assert.Len(t, authz, 0)
+	})
+	t.Run("GET-Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectAuthzTemplateStmt, templateName).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanTemplateRow(templateName, sql.NullString{String: targetGroupName, Valid: true})),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var authz, err = configDBModule.GetAuthorizationTemplate(ctx, templateName)
+		assert.Nil(t, err)
+		assert.Equal(t, []configuration.Authorization{{Action: &action, TargetRealmID: &targetRealmID, TargetGroupName: &targetGroupName}}, authz)
+	})
+
+	var authorizations = []configuration.Authorization{
+		{Action: &action, TargetRealmID: &targetRealmID, TargetGroupName: &targetGroupName},
+		{Action: &action},
+	}
+
+	t.Run("STORE-Can't start transaction", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(nil, expectedError)
+		var err = configDBModule.StoreAuthorizationTemplate(ctx, templateName, authorizations)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("STORE-Delete fails", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTransaction, nil)
+		mockTransaction.EXPECT().Exec(deleteAuthzTemplateStmt, templateName).Return(nil, expectedError)
+		mockTransaction.EXPECT().Close()
+		var err = configDBModule.StoreAuthorizationTemplate(ctx, templateName, authorizations)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("STORE-Insert fails", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTransaction, nil)
+		mockTransaction.EXPECT().Exec(deleteAuthzTemplateStmt, templateName).Return(nil, nil)
+		mockTransaction.EXPECT().Exec(createAuthzTemplateStmt, templateName, &action, &targetRealmID, &targetGroupName).Return(nil, expectedError)
+		mockTransaction.EXPECT().Close()
+		var err = configDBModule.StoreAuthorizationTemplate(ctx, templateName, authorizations)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("STORE-Success", func(t *testing.T) {
+		mockDB.EXPECT().BeginTx(ctx, nil).Return(mockTransaction, nil)
+		mockTransaction.EXPECT().Exec(deleteAuthzTemplateStmt, templateName).Return(nil, nil)
+		mockTransaction.EXPECT().Exec(createAuthzTemplateStmt, templateName, &action, &targetRealmID, &targetGroupName).Return(nil, nil)
+		mockTransaction.EXPECT().Exec(createAuthzTemplateStmt, templateName, &action, nil, nil).Return(nil, nil)
+		mockTransaction.EXPECT().Commit().Return(nil)
+		mockTransaction.EXPECT().Close()
+		var err = configDBModule.StoreAuthorizationTemplate(ctx, templateName, authorizations)
+		assert.Nil(t, err)
+	})
+
+	t.Run("DELETE-Fails", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteAuthzTemplateStmt, templateName).Return(nil, expectedError)
+		var err = configDBModule.DeleteAuthorizationTemplate(ctx, templateName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("DELETE-Success", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteAuthzTemplateStmt, templateName).Return(nil, nil)
+		var err = configDBModule.DeleteAuthorizationTemplate(ctx, templateName)
+		assert.Nil(t, err)
+	})
+}
+
+func TestAuthorizationVersions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+
+	var configDBModule = NewConfigurationDBModule(mockDB, mockLogger)
+	var expectedError = errors.New("error")
+	var realmID = "DEP"
+	var groupName = "dep_admins"
+	var targetRealm = "DEP"
+	var now = time.Unix(1600000000, 0)
+	var rules = []dto.AuthorizationRule{{Action: "MGMT_GetUser", TargetRealmID: &targetRealm}}
+	var diff = dto.AuthorizationsDiff{Added: rules, Removed: []dto.AuthorizationRule{}}
+	var ctx = context.TODO()
+
+	var scanVersion = func(number int) func(...interface{}) error {
+		return func(dest ...interface{}) error {
+			*(dest[0].(*int)) = number
+			*(dest[1].(*string)) = "master"
+			*(dest[2].(*string)) = "admin"
+			*(dest[3].(*sql.NullString)) = sql.NullString{String: "1600000000", Valid: true}
+			*(dest[4].(*string)) = `[{"action":"MGMT_GetUser","target-realm-id":"DEP"}]`
+			*(dest[5].(*string)) = `{"added":[{"action":"MGMT_GetUser","target-realm-id":"DEP"}],"removed":[]}`
+			return nil
+		}
+	}
+
+	t.Run("CREATE", func(t *testing.T) {
+		var version = dto.AuthorizationVersion{
+			RealmID:        realmID,
+			GroupName:      groupName,
+			AuthorRealm:    "master",
+			Author:         "admin",
+			Date:           now,
+			Authorizations: rules,
+			Diff:           diff,
+		}
+		var authzJSON = `[{"action":"MGMT_GetUser","target-realm-id":"DEP"}]`
+		var diffJSON = `{"added":[{"action":"MGMT_GetUser","target-realm-id":"DEP"}],"removed":[]}`
+		mockDB.EXPECT().Exec(insertAuthzVersionStmt, realmID, groupName, "master", "admin", now, authzJSON, diffJSON, realmID, groupName).Return(nil, expectedError)
+		var err = configDBModule.CreateAuthorizationVersion(ctx, version)
+		assert.Equal(t, expectedError, err)
+	})
+
+	t.Run("GET ALL-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzVersionsStmt, realmID, groupName).Return(nil, expectedError)
+		var _, err = configDBModule.GetAuthorizationVersions(ctx, realmID, groupName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ALL-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAuthzVersionsStmt, realmID, groupName).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetAuthorizationVersions(ctx, realmID, groupName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ALL-Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectAuthzVersionsStmt, realmID, groupName).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanVersion(2)),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanVersion(1)),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var versions, err = configDBModule.GetAuthorizationVersions(ctx, realmID, groupName)
+		assert.Nil(t, err)
+		assert.Len(t, versions, 2)
+		assert.Equal(t, 2, versions[0].Version)
+		assert.Equal(t, realmID, versions[0].RealmID)
+		assert.Equal(t, groupName, versions[0].GroupName)
+		assert.Equal(t, now, versions[0].Date)
+		assert.Equal(t, rules, versions[0].Authorizations)
+		assert.Equal(t, diff, versions[0].Diff)
+	})
+
+	t.Run("GET-Not found", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAuthzVersionStmt, realmID, groupName, 3).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var version, err = configDBModule.GetAuthorizationVersion(ctx, realmID, groupName, 3)
+		assert.Nil(t, err)
+		assert.Nil(t, version)
+	})
+	t.Run("GET-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAuthzVersionStmt, realmID, groupName, 3).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		var _, err = configDBModule.GetAuthorizationVersion(ctx, realmID, groupName, 3)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET-Invalid JSON", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAuthzVersionStmt, realmID, groupName, 3).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+			scanVersion(3)(dest...)
+			*(dest[4].(*string)) = `[{`
+			return nil
+		})
+		var _, err = configDBModule.GetAuthorizationVersion(ctx, realmID, groupName, 3)
+		assert.NotNil(t, err)
+	})
+	t.Run("GET-Success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAuthzVersionStmt, realmID, groupName, 3).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanVersion(3))
+		var version, err = configDBModule.GetAuthorizationVersion(ctx, realmID, groupName, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, version.Version)
+		assert.Equal(t, "admin", version.Author)
+		assert.Equal(t, rules, version.Authorizations)
+	})
+}
+
+type sqlResult struct {
+	id   int64
+	rows int64
+}
+
+func (r sqlResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r sqlResult) RowsAffected() (int64, error) {
+	return r.rows, nil
+}
+
+func TestPendingRequests(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+
+	var configDBModule = NewConfigurationDBModule(mockDB, mockLogger)
+	var expectedError = errors.New("error")
+	var realmName = "DEP"
+	var userID = "7fd1ab16-2d52-4da5-8a28-3d0a9e2a1e16"
+	var now = time.Unix(1600000000, 0)
+	var ctx = context.TODO()
+
+	var request = dto.PendingRequest{
+		RealmName:      realmName,
+		Action:         "MGMT_DeleteUser",
+		UserID:         &userID,
+		RequesterRealm: "master",
+		Requester:      "admin",
+		RequestDate:    now,
+		Status:         dto.PendingRequestStatusPending,
+	}
+	var scanRequest = func(id int64) func(...interface{}) error {
+		return func(dest ...interface{}) error {
+			*(dest[0].(*int64)) = id
+			*(dest[1].(*string)) = realmName
+			*(dest[2].(*string)) = "MGMT_DeleteUser"
+			*(dest[3].(*sql.NullString)) = sql.NullString{String: userID, Valid: true}
+			*(dest[8].(*string)) = "master"
+			*(dest[9].(*string)) = "admin"
+			*(dest[10].(*sql.NullString)) = sql.NullString{String: "1600000000", Valid: true}
+			*(dest[11].(*string)) = dto.PendingRequestStatusPending
+			return nil
+		}
+	}
+
+	t.Run("CREATE-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertPendingRequestStmt, realmName, "MGMT_DeleteUser", &userID, nil, nil, nil, nil, "master", "admin", now,
+			dto.PendingRequestStatusPending).Return(nil, expectedError)
+		var _, err = configDBModule.CreatePendingRequest(ctx, request)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("CREATE-Success", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertPendingRequestStmt, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sqlResult{id: 12}, nil)
+		var id, err = configDBModule.CreatePendingRequest(ctx, request)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(12), id)
+	})
+
+	t.Run("GET ALL-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectPendingRequestsStmt, realmName, dto.PendingRequestStatusPending).Return(nil, expectedError)
+		var _, err = configDBModule.GetPendingRequests(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ALL-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectPendingRequestsStmt, realmName, dto.PendingRequestStatusPending).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		mockSQLRows.EXPECT().Close()
+		var _, err = configDBModule.GetPendingRequests(ctx, realmName)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ALL-Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectPendingRequestsStmt, realmName, dto.PendingRequestStatusPending).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanRequest(3)),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+		var requests, err = configDBModule.GetPendingRequests(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Len(t, requests, 1)
+		assert.Equal(t, int64(3), requests[0].ID)
+		assert.Equal(t, &userID, requests[0].UserID)
+		assert.Nil(t, requests[0].GroupID)
+		assert.Equal(t, now, requests[0].RequestDate)
+		assert.Nil(t, requests[0].ResolutionDate)
+	})
+
+	t.Run("GET ONE-Not found", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectPendingRequestStmt, realmName, int64(3)).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+		var res, err = configDBModule.GetPendingRequest(ctx, realmName, 3)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+	t.Run("GET ONE-Scan fails", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectPendingRequestStmt, realmName, int64(3)).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(expectedError)
+		var _, err = configDBModule.GetPendingRequest(ctx, realmName, 3)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("GET ONE-Success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectPendingRequestStmt, realmName, int64(3)).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanRequest(3))
+		var res, err = configDBModule.GetPendingRequest(ctx, realmName, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, "MGMT_DeleteUser", res.Action)
+	})
+
+	var approver = "approver"
+	var resolved = request
+	resolved.ID = 3
+	resolved.Status = dto.PendingRequestStatusApproved
+	resolved.ApproverRealm = &realmName
+	resolved.Approver = &approver
+	resolved.ResolutionDate = &now
+
+	t.Run("UPDATE STATUS-SQL query fails", func(t *testing.T) {
+		mockDB.EXPECT().Exec(updatePendingRequestStmt, dto.PendingRequestStatusApproved, &realmName, &approver, &now, realmName, int64(3),
+			dto.PendingRequestStatusPending).Return(nil, expectedError)
+		var _, err = configDBModule.UpdatePendingRequestStatus(ctx, resolved, dto.PendingRequestStatusPending)
+		assert.Equal(t, expectedError, err)
+	})
+	t.Run("UPDATE STATUS-Status already changed", func(t *testing.T) {
+		mockDB.EXPECT().Exec(updatePendingRequestStmt, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any()).Return(sqlResult{rows: 0}, nil)
+		var updated, err = configDBModule.UpdatePendingRequestStatus(ctx, resolved, dto.PendingRequestStatusPending)
+		assert.Nil(t, err)
+		assert.False(t, updated)
+	})
+	t.Run("UPDATE STATUS-Success", func(t *testing.T) {
+		mockDB.EXPECT().Exec(updatePendingRequestStmt, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any()).Return(sqlResult{rows: 1}, nil)
+		var updated, err = configDBModule.UpdatePendingRequestStatus(ctx, resolved, dto.PendingRequestStatusPending)
+		assert.Nil(t, err)
+		assert.True(t, updated)
+	})
+}
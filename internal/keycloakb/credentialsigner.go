@@ -0,0 +1,109 @@
+package keycloakb
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// CredentialSigner signs the verifiable credentials issued by the bridge and publishes the public key verifiers need to check them
+type CredentialSigner interface {
+	Sign(claims interface{}) (string, error)
+	PublicKeys() jose.JSONWebKeySet
+}
+
+type joseCredentialSigner struct {
+	signer    jose.Signer
+	publicKey jose.JSONWebKey
+}
+
+// NewCredentialSignerFromPEM creates a CredentialSigner with the given PEM encoded private key. ECDSA keys (P-256 or P-384) sign
+// with ES256/ES384 and RSA keys of at least 2048 bits with RS256. When no key ID is given, the JWK thumbprint of the key is used
+func NewCredentialSignerFromPEM(pemKey string, keyID string) (CredentialSigner, error) {
+	var block, _ = pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	var privateKey, err = parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var alg jose.SignatureAlgorithm
+	var publicKey crypto.PublicKey
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			alg = jose.ES256
+		case elliptic.P384():
+			alg = jose.ES384
+		default:
+			return nil, errors.New("unsupported elliptic curve for signing key")
+		}
+		publicKey = &key.PublicKey
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA signing key must be at least 2048 bits long")
+		}
+		alg = jose.RS256
+		publicKey = &key.PublicKey
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+
+	var jwk = jose.JSONWebKey{Key: publicKey, Algorithm: string(alg), Use: "sig", KeyID: keyID}
+	if jwk.KeyID == "" {
+		var thumbprint, err = jwk.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	}
+
+	var options = (&jose.SignerOptions{}).WithType("JWT")
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: privateKey, KeyID: jwk.KeyID}}, options)
+	if err != nil {
+		return nil, err
+	}
+	return &joseCredentialSigner{signer: signer, publicKey: jwk}, nil
+}
+
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("can't parse signing key")
+}
+
+// Sign serializes the claims as JSON and returns them as a compact JWS
+func (s *joseCredentialSigner) Sign(claims interface{}) (string, error) {
+	var payload, err = json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	jws, err := s.signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+// PublicKeys returns the key set verifiers use to check the signed credentials
+func (s *joseCredentialSigner) PublicKeys() jose.JSONWebKeySet {
+	return jose.JSONWebKeySet{Keys: []jose.JSONWebKey{s.publicKey}}
+}
@@ -0,0 +1,84 @@
+package keycloakb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func createPEMKey(t *testing.T, blockType string, der []byte, err error) string {
+	assert.Nil(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+func TestNewCredentialSignerFromPEM(t *testing.T) {
+	var p256Key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var p224Key, _ = ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	var rsaKey, _ = rsa.GenerateKey(rand.Reader, 1024)
+
+	t.Run("Not PEM encoded", func(t *testing.T) {
+		var _, err = NewCredentialSignerFromPEM("not a key", "")
+		assert.NotNil(t, err)
+	})
+	t.Run("Not a private key", func(t *testing.T) {
+		var _, err = NewCredentialSignerFromPEM(createPEMKey(t, "PRIVATE KEY", []byte("garbage"), nil), "")
+		assert.NotNil(t, err)
+	})
+	t.Run("Unsupported curve", func(t *testing.T) {
+		var der, err = x509.MarshalECPrivateKey(p224Key)
+		var _, errSigner = NewCredentialSignerFromPEM(createPEMKey(t, "EC PRIVATE KEY", der, err), "")
+		assert.NotNil(t, errSigner)
+	})
+	t.Run("RSA key is too short", func(t *testing.T) {
+		var _, err = NewCredentialSignerFromPEM(createPEMKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil), "")
+		assert.NotNil(t, err)
+	})
+	t.Run("Key ID defaults to the thumbprint of the key", func(t *testing.T) {
+		var der, err = x509.MarshalECPrivateKey(p256Key)
+		signer, err := NewCredentialSignerFromPEM(createPEMKey(t, "EC PRIVATE KEY", der, err), "")
+		assert.Nil(t, err)
+		var keys = signer.PublicKeys().Keys
+		assert.Len(t, keys, 1)
+		assert.Len(t, keys[0].KeyID, 43)
+		assert.Equal(t, "ES256", keys[0].Algorithm)
+		assert.True(t, keys[0].IsPublic())
+	})
+}
+
+func TestCredentialSignerSign(t *testing.T) {
+	var p256Key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var der, err = x509.MarshalPKCS8PrivateKey(p256Key)
+	signer, err := NewCredentialSignerFromPEM(createPEMKey(t, "PRIVATE KEY", der, err), "key-1")
+	assert.Nil(t, err)
+
+	t.Run("Claims can't be serialized", func(t *testing.T) {
+		var _, err = signer.Sign(map[string]interface{}{"invalid": make(chan int)})
+		assert.NotNil(t, err)
+	})
+	t.Run("Signature is verified with the published key", func(t *testing.T) {
+		var compact, err = signer.Sign(map[string]string{"sub": "user-id"})
+		assert.Nil(t, err)
+
+		jws, err := jose.ParseSigned(compact)
+		assert.Nil(t, err)
+		assert.Equal(t, "key-1", jws.Signatures[0].Header.KeyID)
+
+		var keySet = signer.PublicKeys()
+		var keys = keySet.Key("key-1")
+		assert.Len(t, keys, 1)
+		payload, err := jws.Verify(keys[0])
+		assert.Nil(t, err)
+
+		var claims map[string]string
+		assert.Nil(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "user-id", claims["sub"])
+	})
+}
@@ -0,0 +1,266 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	duplicatesPageSize = 100
+)
+
+// DuplicatesKeycloakClient is the minimum Keycloak client interface for the detection of duplicate users
+type DuplicatesKeycloakClient interface {
+	GetRealm(accessToken string, realmName string) (kc.RealmRepresentation, error)
+	GetUsers(accessToken string, reqRealmName, targetRealmName string, paramKV ...string) (kc.UsersPageRepresentation, error)
+}
+
+// DuplicatesUsersDBModule is the minimum users DB module interface for the detection of duplicate users
+type DuplicatesUsersDBModule interface {
+	FindUserIDsByDocumentNumber(ctx context.Context, realm string, documentNumber string) ([]string, error)
+	FindUserIDsByIdentity(ctx context.Context, realm string, firstName, lastName, birthDate string) ([]string, error)
+	FindDuplicateUsers(ctx context.Context, realm string) ([]dto.DuplicateUsers, error)
+}
+
+// DuplicatesModule detects users of a realm sharing the same identity (first name, last name and birth date) or the same
+// identity document. Values are normalized before being compared
+type DuplicatesModule interface {
+	FindDuplicates(ctx context.Context, accessToken, reqRealmName, realmName string, user dto.DBUser) ([]dto.DuplicateUsers, error)
+	CheckDuplicates(ctx context.Context, accessToken, reqRealmName, realmName string, user dto.DBUser) error
+	GetDuplicatesReport(ctx context.Context, accessToken, reqRealmName, realmName string) ([]dto.DuplicateUsers, error)
+}
+
+type duplicatesModule struct {
+	keycloakClient DuplicatesKeycloakClient
+	usersDBModule  DuplicatesUsersDBModule
+	confDBModule   AdminConfigurationDBModule
+	eventsReporter EventsReporter
+	logger         Logger
+}
+
+// NewDuplicatesModule creates a duplicates module
+func NewDuplicatesModule(keycloakClient DuplicatesKeycloakClient, usersDBModule DuplicatesUsersDBModule, confDBModule AdminConfigurationDBModule,
+	eventsReporter EventsReporter, logger Logger) DuplicatesModule {
+	return &duplicatesModule{
+		keycloakClient: keycloakClient,
+		usersDBModule:  usersDBModule,
+		confDBModule:   confDBModule,
+		eventsReporter: eventsReporter,
+		logger:         logger,
+	}
+}
+
+// FindDuplicates returns the other users of the realm sharing the identity or the identity document of the given user.
+// Identity is searched through the blind indexes of the users details and compared with the normalized identity of the
+// users of Keycloak
+func (dm *duplicatesModule) FindDuplicates(ctx context.Context, accessToken, reqRealmName, realmName string, user dto.DBUser) ([]dto.DuplicateUsers, error) {
+	var res = []dto.DuplicateUsers{}
+	var userID = ""
+	if user.UserID != nil {
+		userID = *user.UserID
+	}
+
+	if user.IDDocumentNumber != nil {
+		var userIDs, err = dm.usersDBModule.FindUserIDsByDocumentNumber(ctx, realmName, *user.IDDocumentNumber)
+		if err != nil {
+			dm.logger.Warn(ctx, "msg", "Can't find users by document number", "err", err.Error(), "realm", realmName)
+			return nil, err
+		}
+		if others := excludeUser(userIDs, userID); len(others) > 0 {
+			res = append(res, dto.DuplicateUsers{Criteria: dto.DuplicateCriteriaDocumentNumber, UserIDs: others})
+		}
+	}
+
+	var identity = identityKey(user.FirstName, user.LastName, user.BirthDate)
+	if identity != "" {
+		var userIDs, err = dm.usersDBModule.FindUserIDsByIdentity(ctx, realmName, *user.FirstName, *user.LastName, *user.BirthDate)
+		if err != nil {
+			dm.logger.Warn(ctx, "msg", "Can't find users by identity", "err", err.Error(), "realm", realmName)
+			return nil, err
+		}
+		// Users who never went through a validation have no details: their identity is only known by Keycloak. A search of
+		// Keycloak does not normalize the names, all the users are compared instead
+		err = dm.forEachUser(ctx, accessToken, reqRealmName, realmName, func(userKc kc.UserRepresentation) {
+			if userKc.ID != nil && identityKey(userKc.FirstName, userKc.LastName, userKc.GetAttributeString(constants.AttrbBirthDate)) == identity {
+				userIDs = append(userIDs, *userKc.ID)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if others := excludeUser(userIDs, userID); len(others) > 0 {
+			res = append(res, dto.DuplicateUsers{Criteria: dto.DuplicateCriteriaIdentity, UserIDs: others})
+		}
+	}
+
+	return res, nil
+}
+
+// CheckDuplicates applies the duplicate check configured for the realm: duplicates are reported and, when the realm
+// requires it, the operation is refused
+func (dm *duplicatesModule) CheckDuplicates(ctx context.Context, accessToken, reqRealmName, realmName string, user dto.DBUser) error {
+	var mode, err = dm.getCheckMode(ctx, accessToken, realmName)
+	if err != nil || mode == "" {
+		return err
+	}
+
+	duplicates, err := dm.FindDuplicates(ctx, accessToken, reqRealmName, realmName, user)
+	if err != nil || len(duplicates) == 0 {
+		return err
+	}
+
+	var criteria []string
+	var userIDs []string
+	for _, duplicate := range duplicates {
+		criteria = append(criteria, duplicate.Criteria)
+		userIDs = append(userIDs, duplicate.UserIDs...)
+	}
+	userIDs = excludeUser(userIDs, "")
+	dm.logger.Warn(ctx, "msg", "Duplicate users detected", "realm", realmName, "userID", *user.UserID, "duplicates", strings.Join(userIDs, ","))
+
+	var values = []string{database.CtEventRealmName, realmName, database.CtEventUserID, *user.UserID,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("criteria", strings.Join(criteria, ","), "duplicates", strings.Join(userIDs, ","), "action", mode)}
+	if err = dm.eventsReporter.ReportEvent(ctx, "DUPLICATE_USER_DETECTED", "back-office", values...); err != nil {
+		LogUnrecordedEvent(ctx, dm.logger, "DUPLICATE_USER_DETECTED", err.Error(), values...)
+	}
+
+	if mode == dto.DuplicateCheckBlock {
+		return errorhandler.Error{
+			Status:  http.StatusConflict,
+			Message: ComponentName + "." + constants.MsgErrDuplicate + "." + constants.User,
+		}
+	}
+	return nil
+}
+
+func (dm *duplicatesModule) getCheckMode(ctx context.Context, accessToken, realmName string) (string, error) {
+	var adminConfig, err = GetRealmAdminConfiguration(ctx, dm.keycloakClient, dm.confDBModule, accessToken, realmName, dm.logger)
+	if err != nil {
+		return "", err
+	}
+	if adminConfig.DuplicateCheck == nil {
+		return "", nil
+	}
+	return *adminConfig.DuplicateCheck, nil
+}
+
+// GetDuplicatesReport returns all the groups of duplicate users of a realm
+func (dm *duplicatesModule) GetDuplicatesReport(ctx context.Context, accessToken, reqRealmName, realmName string) ([]dto.DuplicateUsers, error) {
+	var dbDuplicates, err = dm.usersDBModule.FindDuplicateUsers(ctx, realmName)
+	if err != nil {
+		dm.logger.Warn(ctx, "msg", "Can't find duplicate users in database", "err", err.Error(), "realm", realmName)
+		return nil, err
+	}
+	var groups = map[string][][]string{}
+	for _, duplicate := range dbDuplicates {
+		groups[duplicate.Criteria] = append(groups[duplicate.Criteria], duplicate.UserIDs)
+	}
+
+	// Identities of the users are compared in Keycloak as some users have no details
+	var identities = map[string][]string{}
+	err = dm.forEachUser(ctx, accessToken, reqRealmName, realmName, func(userKc kc.UserRepresentation) {
+		var identity = identityKey(userKc.FirstName, userKc.LastName, userKc.GetAttributeString(constants.AttrbBirthDate))
+		if identity != "" && userKc.ID != nil {
+			identities[identity] = append(identities[identity], *userKc.ID)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, userIDs := range identities {
+		if len(userIDs) > 1 {
+			groups[dto.DuplicateCriteriaIdentity] = append(groups[dto.DuplicateCriteriaIdentity], userIDs)
+		}
+	}
+
+	var res = []dto.DuplicateUsers{}
+	for _, criteria := range []string{dto.DuplicateCriteriaDocumentNumber, dto.DuplicateCriteriaIdentity} {
+		res = append(res, mergeDuplicates(criteria, groups[criteria])...)
+	}
+	return res, nil
+}
+
+// forEachUser pages through all the users of a realm
+func (dm *duplicatesModule) forEachUser(ctx context.Context, accessToken, reqRealmName, realmName string, apply func(kc.UserRepresentation)) error {
+	for first := 0; ; first += duplicatesPageSize {
+		var page, err = dm.keycloakClient.GetUsers(accessToken, reqRealmName, realmName, "first", strconv.Itoa(first), "max", strconv.Itoa(duplicatesPageSize))
+		if err != nil {
+			dm.logger.Warn(ctx, "msg", "Can't get users page", "err", err.Error(), "realm", realmName, "first", first)
+			return err
+		}
+		for _, userKc := range page.Users {
+			apply(userKc)
+		}
+		if len(page.Users) < duplicatesPageSize {
+			return nil
+		}
+	}
+}
+
+func identityKey(firstName, lastName, birthDate *string) string {
+	var first = NormalizeName(firstName)
+	var last = NormalizeName(lastName)
+	var birth = NormalizeDate(birthDate)
+	if first == "" || last == "" || birth == "" {
+		return ""
+	}
+	return first + "|" + last + "|" + birth
+}
+
+// excludeUser returns the sorted distinct user IDs, except the given one
+func excludeUser(userIDs []string, userID string) []string {
+	var res []string
+	var found = map[string]bool{userID: true}
+	for _, id := range userIDs {
+		if !found[id] {
+			found[id] = true
+			res = append(res, id)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// mergeDuplicates merges the groups sharing at least one user: the same duplicates can be found both in Keycloak and in database
+func mergeDuplicates(criteria string, groups [][]string) []dto.DuplicateUsers {
+	var parent = map[string]string{}
+	var root = func(userID string) string {
+		for parent[userID] != userID {
+			userID = parent[userID]
+		}
+		return userID
+	}
+	for _, group := range groups {
+		for _, userID := range group {
+			if _, ok := parent[userID]; !ok {
+				parent[userID] = userID
+			}
+		}
+		for _, userID := range group[1:] {
+			parent[root(userID)] = root(group[0])
+		}
+	}
+
+	var merged = map[string][]string{}
+	for userID := range parent {
+		var r = root(userID)
+		merged[r] = append(merged[r], userID)
+	}
+	var res = []dto.DuplicateUsers{}
+	for _, userIDs := range merged {
+		res = append(res, dto.DuplicateUsers{Criteria: criteria, UserIDs: excludeUser(userIDs, "")})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].UserIDs[0] < res[j].UserIDs[0]
+	})
+	return res
+}
@@ -0,0 +1,237 @@
+package keycloakb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func createKcUser(userID, firstName, lastName, birthDate string) kc.UserRepresentation {
+	var user = kc.UserRepresentation{ID: &userID, FirstName: &firstName, LastName: &lastName}
+	user.SetAttributeString(constants.AttrbBirthDate, birthDate)
+	return user
+}
+
+func TestFindDuplicates(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewDuplicatesKeycloakClient(mockCtrl)
+	var mockUsersDB = mock.NewDuplicatesUsersDBModule(mockCtrl)
+	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+
+	var module = NewDuplicatesModule(mockKeycloakClient, mockUsersDB, mockConfDB, mockEventsReporter, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var userID = "user-id"
+	var docNumber = "X123456"
+	var firstName = "John"
+	var lastName = "Doe"
+	var birthDate = "25.12.1980"
+	var anyError = errors.New("any error")
+	var user = dto.DBUser{UserID: &userID, IDDocumentNumber: &docNumber, FirstName: &firstName, LastName: &lastName, BirthDate: &birthDate}
+
+	t.Run("Nothing to compare", func(t *testing.T) {
+		var duplicates, err = module.FindDuplicates(ctx, accessToken, realm, realm, dto.DBUser{UserID: &userID})
+		assert.Nil(t, err)
+		assert.Len(t, duplicates, 0)
+	})
+
+	t.Run("Can't find users by document number", func(t *testing.T) {
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return(nil, anyError)
+		var _, err = module.FindDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't search users in Keycloak", func(t *testing.T) {
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{userID}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByIdentity(ctx, realm, firstName, lastName, birthDate).Return([]string{userID}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realm, realm, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, anyError)
+		var _, err = module.FindDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("No duplicate", func(t *testing.T) {
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{userID}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByIdentity(ctx, realm, firstName, lastName, birthDate).Return([]string{}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realm, realm, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{
+			Users: []kc.UserRepresentation{createKcUser(userID, firstName, lastName, birthDate), createKcUser("other", "Johnny", lastName, birthDate)},
+		}, nil)
+		var duplicates, err = module.FindDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+		assert.Len(t, duplicates, 0)
+	})
+
+	t.Run("Duplicates found in database and Keycloak", func(t *testing.T) {
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{userID, "user-b"}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByIdentity(ctx, realm, firstName, lastName, birthDate).Return([]string{"user-c"}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realm, realm, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{
+			Users: []kc.UserRepresentation{createKcUser("user-a", " JOHN ", "doe", "1980-12-25"), createKcUser("user-c", firstName, lastName, birthDate)},
+		}, nil)
+		var duplicates, err = module.FindDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+		assert.Equal(t, []dto.DuplicateUsers{
+			{Criteria: dto.DuplicateCriteriaDocumentNumber, UserIDs: []string{"user-b"}},
+			{Criteria: dto.DuplicateCriteriaIdentity, UserIDs: []string{"user-a", "user-c"}},
+		}, duplicates)
+	})
+
+	t.Run("Identities are compared on all the users of Keycloak", func(t *testing.T) {
+		var fullPage []kc.UserRepresentation
+		for i := 0; i < duplicatesPageSize; i++ {
+			fullPage = append(fullPage, createKcUser("other", "Jane", lastName, birthDate))
+		}
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{userID}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByIdentity(ctx, realm, firstName, lastName, birthDate).Return([]string{}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realm, realm, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{Users: fullPage}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realm, realm, "first", "100", "max", "100").Return(kc.UsersPageRepresentation{
+			Users: []kc.UserRepresentation{createKcUser("user-d", "john", " DOE ", birthDate)},
+		}, nil)
+		var duplicates, err = module.FindDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+		assert.Equal(t, []dto.DuplicateUsers{{Criteria: dto.DuplicateCriteriaIdentity, UserIDs: []string{"user-d"}}}, duplicates)
+	})
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewDuplicatesKeycloakClient(mockCtrl)
+	var mockUsersDB = mock.NewDuplicatesUsersDBModule(mockCtrl)
+	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+
+	var module = NewDuplicatesModule(mockKeycloakClient, mockUsersDB, mockConfDB, mockEventsReporter, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realm = "my-realm"
+	var realmID = "my-realm-id"
+	var userID = "user-id"
+	var docNumber = "X123456"
+	var anyError = errors.New("any error")
+	var user = dto.DBUser{UserID: &userID, IDDocumentNumber: &docNumber}
+	var warn = dto.DuplicateCheckWarn
+	var block = dto.DuplicateCheckBlock
+
+	t.Run("Can't get realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realm).Return(kc.RealmRepresentation{}, anyError)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockKeycloakClient.EXPECT().GetRealm(accessToken, realm).Return(kc.RealmRepresentation{ID: &realmID}, nil).AnyTimes()
+
+	t.Run("Can't get admin configuration", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, anyError)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("No admin configuration", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Check is disabled", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, nil)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+	})
+
+	t.Run("No duplicate", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{DuplicateCheck: &block}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{userID}, nil)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Duplicate is reported", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{DuplicateCheck: &warn}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{"other"}, nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "DUPLICATE_USER_DETECTED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(anyError)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Duplicate is blocked", func(t *testing.T) {
+		mockConfDB.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{DuplicateCheck: &block}, nil)
+		mockUsersDB.EXPECT().FindUserIDsByDocumentNumber(ctx, realm, docNumber).Return([]string{"other"}, nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "DUPLICATE_USER_DETECTED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		var err = module.CheckDuplicates(ctx, accessToken, realm, realm, user)
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+}
+
+func TestGetDuplicatesReport(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewDuplicatesKeycloakClient(mockCtrl)
+	var mockUsersDB = mock.NewDuplicatesUsersDBModule(mockCtrl)
+	var mockConfDB = mock.NewConfigurationDBModule(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+
+	var module = NewDuplicatesModule(mockKeycloakClient, mockUsersDB, mockConfDB, mockEventsReporter, log.NewNopLogger())
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var reqRealm = "master"
+	var realm = "my-realm"
+	var anyError = errors.New("any error")
+
+	t.Run("Can't find duplicates in database", func(t *testing.T) {
+		mockUsersDB.EXPECT().FindDuplicateUsers(ctx, realm).Return(nil, anyError)
+		var _, err = module.GetDuplicatesReport(ctx, accessToken, reqRealm, realm)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't get users from Keycloak", func(t *testing.T) {
+		mockUsersDB.EXPECT().FindDuplicateUsers(ctx, realm).Return([]dto.DuplicateUsers{}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, reqRealm, realm, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{}, anyError)
+		var _, err = module.GetDuplicatesReport(ctx, accessToken, reqRealm, realm)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var fullPage []kc.UserRepresentation
+		for i := 0; i < duplicatesPageSize; i++ {
+			fullPage = append(fullPage, kc.UserRepresentation{})
+		}
+		fullPage[0] = createKcUser("user-a", "John", "Doe", "25.12.1980")
+		fullPage[1] = createKcUser("user-x", "Jane", "Doe", "25.12.1980")
+
+		mockUsersDB.EXPECT().FindDuplicateUsers(ctx, realm).Return([]dto.DuplicateUsers{
+			{Criteria: dto.DuplicateCriteriaDocumentNumber, UserIDs: []string{"user-d", "user-e"}},
+			{Criteria: dto.DuplicateCriteriaIdentity, UserIDs: []string{"user-b", "user-c"}},
+		}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, reqRealm, realm, "first", "0", "max", "100").Return(kc.UsersPageRepresentation{Users: fullPage}, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, reqRealm, realm, "first", "100", "max", "100").Return(kc.UsersPageRepresentation{
+			Users: []kc.UserRepresentation{createKcUser("user-b", "john", "DOE", "1980-12-25")},
+		}, nil)
+
+		var report, err = module.GetDuplicatesReport(ctx, accessToken, reqRealm, realm)
+		assert.Nil(t, err)
+		assert.Equal(t, []dto.DuplicateUsers{
+			{Criteria: dto.DuplicateCriteriaDocumentNumber, UserIDs: []string{"user-d", "user-e"}},
+			{Criteria: dto.DuplicateCriteriaIdentity, UserIDs: []string{"user-a", "user-b", "user-c"}},
+		}, report)
+	})
+}
@@ -0,0 +1,101 @@
+package keycloakb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	commonhttp "github.com/cloudtrust/common-service/http"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	// ReqIfMatch is the name of the request parameter containing the value of the If-Match header
+	ReqIfMatch = "ifMatch"
+
+	hdrETag        = "ETag"
+	hdrIfMatch     = "If-Match"
+	hdrContentType = "Content-Type"
+)
+
+// ETagHolder is implemented by the replies carrying the version of the returned resource
+type ETagHolder interface {
+	GetETag() *string
+}
+
+// ComputeUserETag computes the version of a user from its Keycloak representation, its details stored in database and the expiry of its account
+func ComputeUserETag(kcUser kc.UserRepresentation, dbUser dto.DBUser, accountExpiry *time.Time) string {
+	var kcJSON, _ = json.Marshal(kcUser)
+	var dbJSON, _ = json.Marshal(dbUser)
+
+	var hash = sha256.New()
+	hash.Write(kcJSON)
+	hash.Write([]byte{0})
+	hash.Write(dbJSON)
+	if accountExpiry != nil {
+		hash.Write([]byte{0})
+		hash.Write([]byte(accountExpiry.UTC().Format(time.RFC3339)))
+	}
+	return `"` + base64.RawURLEncoding.EncodeToString(hash.Sum(nil)) + `"`
+}
+
+// CheckUserETag returns a precondition failed error when the If-Match value does not match the current version of the user.
+// No check is done when no If-Match value is provided
+func CheckUserETag(ifMatch *string, kcUser kc.UserRepresentation, dbUser dto.DBUser, accountExpiry *time.Time) error {
+	if ifMatch == nil {
+		return nil
+	}
+	var etag = ComputeUserETag(kcUser, dbUser, accountExpiry)
+	for _, value := range strings.Split(*ifMatch, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == etag {
+			return nil
+		}
+	}
+	return errorhandler.Error{
+		Status:  http.StatusPreconditionFailed,
+		Message: ComponentName + "." + constants.MsgErrPreconditionFailed + "." + constants.User,
+	}
+}
+
+// DecodeRequestWithHeaders decodes a request like commonhttp.DecodeRequest and adds to the request parameters the value of
+// the If-Match header and whether the body is a JSON merge patch
+func DecodeRequestWithHeaders(ctx context.Context, req *http.Request, pathParams map[string]string, queryParams map[string]string) (interface{}, error) {
+	var request, err = commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := request.(map[string]string); ok {
+		if ifMatch := req.Header.Get(hdrIfMatch); ifMatch != "" {
+			m[ReqIfMatch] = ifMatch
+		}
+		if mediaType, _, _ := mime.ParseMediaType(req.Header.Get(hdrContentType)); mediaType == MediaTypeMergePatch {
+			m[ReqMergePatch] = "true"
+		}
+	}
+	return request, nil
+}
+
+// GetIfMatch returns the If-Match value of decoded request parameters or nil if the request has no If-Match header
+func GetIfMatch(m map[string]string) *string {
+	if ifMatch, ok := m[ReqIfMatch]; ok {
+		return &ifMatch
+	}
+	return nil
+}
+
+// EncodeReplyWithETag encodes a reply like commonhttp.EncodeReply and sets the ETag header when the reply carries a version
+func EncodeReplyWithETag(ctx context.Context, w http.ResponseWriter, rep interface{}) error {
+	if holder, ok := rep.(ETagHolder); ok && holder.GetETag() != nil {
+		w.Header().Set(hdrETag, *holder.GetETag())
+	}
+	return commonhttp.EncodeReply(ctx, w, rep)
+}
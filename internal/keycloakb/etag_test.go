@@ -0,0 +1,99 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/stretchr/testify/assert"
+)
+
+type etagReply struct {
+	Value string  `json:"value"`
+	ETag  *string `json:"-"`
+}
+
+func (r etagReply) GetETag() *string {
+	return r.ETag
+}
+
+func TestComputeUserETag(t *testing.T) {
+	var userID = "user-id"
+	var firstName = "John"
+	var otherFirstName = "Jane"
+	var birthLocation = "Lausanne"
+	var kcUser = kc.UserRepresentation{ID: &userID, FirstName: &firstName}
+	var dbUser = dto.DBUser{UserID: &userID, BirthLocation: &birthLocation}
+
+	var etag = ComputeUserETag(kcUser, dbUser, nil)
+	assert.Regexp(t, `^"[\w-]+"$`, etag)
+	assert.Equal(t, etag, ComputeUserETag(kcUser, dbUser, nil))
+
+	t.Run("Keycloak user updated", func(t *testing.T) {
+		var updated = kcUser
+		updated.FirstName = &otherFirstName
+		assert.NotEqual(t, etag, ComputeUserETag(updated, dbUser, nil))
+	})
+
+	t.Run("Details updated", func(t *testing.T) {
+		assert.NotEqual(t, etag, ComputeUserETag(kcUser, dto.DBUser{UserID: &userID}, nil))
+	})
+
+	t.Run("Account expiry updated", func(t *testing.T) {
+		var expiry = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+		var otherExpiry = time.Date(2031, 6, 30, 0, 0, 0, 0, time.UTC)
+		var expiryETag = ComputeUserETag(kcUser, dbUser, &expiry)
+		assert.NotEqual(t, etag, expiryETag)
+		assert.NotEqual(t, expiryETag, ComputeUserETag(kcUser, dbUser, &otherExpiry))
+		assert.Equal(t, expiryETag, ComputeUserETag(kcUser, dbUser, &expiry))
+	})
+}
+
+func TestCheckUserETag(t *testing.T) {
+	var userID = "user-id"
+	var kcUser = kc.UserRepresentation{ID: &userID}
+	var dbUser = dto.DBUser{UserID: &userID}
+	var etag = ComputeUserETag(kcUser, dbUser, nil)
+	var ptr = func(value string) *string { return &value }
+
+	t.Run("No If-Match", func(t *testing.T) {
+		assert.Nil(t, CheckUserETag(nil, kcUser, dbUser, nil))
+	})
+	t.Run("Matching value", func(t *testing.T) {
+		assert.Nil(t, CheckUserETag(&etag, kcUser, dbUser, nil))
+		assert.Nil(t, CheckUserETag(ptr(`"other", W/`+etag), kcUser, dbUser, nil))
+		assert.Nil(t, CheckUserETag(ptr("*"), kcUser, dbUser, nil))
+	})
+	t.Run("Outdated value", func(t *testing.T) {
+		var err = CheckUserETag(ptr(`"other"`), kcUser, dbUser, nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+}
+
+func TestGetIfMatch(t *testing.T) {
+	assert.Nil(t, GetIfMatch(map[string]string{}))
+	assert.Equal(t, `"abc"`, *GetIfMatch(map[string]string{ReqIfMatch: `"abc"`}))
+}
+
+func TestEncodeReplyWithETag(t *testing.T) {
+	var etag = `"abc"`
+
+	t.Run("With ETag", func(t *testing.T) {
+		var w = httptest.NewRecorder()
+		assert.Nil(t, EncodeReplyWithETag(context.TODO(), w, etagReply{Value: "value", ETag: &etag}))
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.NotContains(t, w.Body.String(), "abc")
+	})
+
+	t.Run("Without ETag", func(t *testing.T) {
+		var w = httptest.NewRecorder()
+		assert.Nil(t, EncodeReplyWithETag(context.TODO(), w, etagReply{Value: "value"}))
+		assert.Equal(t, "", w.Header().Get("ETag"))
+	})
+}
@@ -27,6 +27,7 @@ type EventsDBModule interface {
 	GetTotalConnectionsDaysCount(context.Context, string, *time.Location, int) ([][]int64, error)
 	GetTotalConnectionsMonthsCount(context.Context, string, *time.Location, int) ([][]int64, error)
 	GetLastConnections(context.Context, string, string) ([]api_stat.StatisticsConnectionRepresentation, error)
+	GetUsersLastConnectionBefore(context.Context, string, time.Time) (map[string]int64, error)
 }
 
 type eventsDBModule struct {
@@ -100,6 +101,15 @@ const (
 			GROUP by date_format(date_add(audit_time, INTERVAL ? MINUTE), '%Y-%m')
 			ORDER BY audit_time
 	`
+	selectUsersLastConnectionBeforeStmt = `
+			SELECT user_id, unix_timestamp(max(audit_time))
+			FROM audit
+			WHERE realm_name=?
+			  AND ct_event_type='LOGON_OK'
+			  AND user_id IS NOT NULL
+			GROUP BY user_id
+			HAVING max(audit_time) < ?
+	`
 	selectConnectionStmt = `SELECT unix_timestamp(audit_time), ct_event_type, username, additional_info 
 							FROM audit WHERE realm_name=? AND (ct_event_type='LOGON_OK' OR ct_event_type='LOGON_ERROR') 	
 							ORDER BY audit_time DESC
@@ -335,6 +345,27 @@ func (cm *eventsDBModule) GetLastConnections(_ context.Context, realmName string
 	return res, err
 }
 
+// GetUsersLastConnectionBefore gives the time of the last connection of the users of the given realm who did not log in since the given date
+func (cm *eventsDBModule) GetUsersLastConnectionBefore(_ context.Context, realmName string, before time.Time) (map[string]int64, error) {
+	var res = map[string]int64{}
+	rows, err := cm.db.Query(selectUsersLastConnectionBeforeStmt, realmName, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var lastConnection int64
+		if err = rows.Scan(&userID, &lastConnection); err != nil {
+			return nil, err
+		}
+		res[userID] = lastConnection
+	}
+
+	return res, rows.Err()
+}
+
 func getSQLParam(m map[string]string, name string, defaultValue interface{}) interface{} {
 	if value, ok := m[name]; ok {
 		return value
@@ -3,7 +3,9 @@ package keycloakb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	api "github.com/cloudtrust/keycloak-bridge/api/events"
@@ -91,6 +93,21 @@ func TestModuleGetTotalConnectionsCount(t *testing.T) {
 	}
 }
 
+func TestModuleGetUsersLastConnectionBefore(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dbEvents := mock.NewDBEvents(mockCtrl)
+	module := NewEventsDBModule(dbEvents)
+
+	var before = time.Now()
+	var expectedError = errors.New("db error")
+	dbEvents.EXPECT().Query(gomock.Any(), "realm", before).Return(nil, expectedError).Times(1)
+	_, err := module.GetUsersLastConnectionBefore(context.TODO(), "realm", before)
+
+	assert.Equal(t, expectedError, err)
+}
+
 func TestCreateStats(t *testing.T) {
 	assert.Equal(t, [][]int64{{3, 0}, {2, 0}, {9, 0}, {8, 0}, {7, 0}}, createStats(5, 3, 2, 9, true))
 	assert.Equal(t, [][]int64{{7, 0}, {8, 0}, {9, 0}, {2, 0}, {3, 0}}, createStats(5, 3, 2, 9, false))
@@ -0,0 +1,60 @@
+package keycloakb
+
+import (
+	"encoding/json"
+	"sort"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+)
+
+const (
+	// MediaTypeMergePatch is the media type of a JSON merge patch (RFC 7396)
+	MediaTypeMergePatch = "application/merge-patch+json"
+
+	// ReqMergePatch is the name of the request parameter set when the body of the request is a JSON merge patch
+	ReqMergePatch = "mergePatch"
+)
+
+// IsMergePatch tells whether the body of a decoded request is a JSON merge patch
+func IsMergePatch(m map[string]string) bool {
+	return m[ReqMergePatch] == "true"
+}
+
+// GetNullFields returns the sorted names of the fields explicitly set to null in a JSON object. In a JSON merge patch,
+// these fields have to be removed
+func GetNullFields(body string) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for name, value := range fields {
+		if string(value) == "null" {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// ValidateClearedFields checks that only removable fields are set to null
+func ValidateClearedFields(clearedFields []string, clearableFields map[string]bool) error {
+	for _, field := range clearedFields {
+		if !clearableFields[field] {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + field)
+		}
+	}
+	return nil
+}
+
+// IsCleared tells whether a field is removed by an update
+func IsCleared(clearedFields []string, field string) bool {
+	for _, cleared := range clearedFields {
+		if cleared == field {
+			return true
+		}
+	}
+	return false
+}
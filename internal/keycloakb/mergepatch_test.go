@@ -0,0 +1,45 @@
+package keycloakb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMergePatch(t *testing.T) {
+	assert.False(t, IsMergePatch(map[string]string{}))
+	assert.True(t, IsMergePatch(map[string]string{ReqMergePatch: "true"}))
+}
+
+func TestGetNullFields(t *testing.T) {
+	t.Run("Invalid JSON", func(t *testing.T) {
+		var _, err = GetNullFields(`{"phoneNumber":`)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("No null field", func(t *testing.T) {
+		var fields, err = GetNullFields(`{"phoneNumber":"+41221234567"}`)
+		assert.Nil(t, err)
+		assert.Len(t, fields, 0)
+	})
+
+	t.Run("Null fields", func(t *testing.T) {
+		var fields, err = GetNullFields(`{"phoneNumber":null, "firstName":"John", "nationality": null, "label":""}`)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"nationality", "phoneNumber"}, fields)
+	})
+}
+
+func TestValidateClearedFields(t *testing.T) {
+	var clearable = map[string]bool{"phoneNumber": true}
+
+	assert.Nil(t, ValidateClearedFields(nil, clearable))
+	assert.Nil(t, ValidateClearedFields([]string{"phoneNumber"}, clearable))
+	assert.NotNil(t, ValidateClearedFields([]string{"phoneNumber", "username"}, clearable))
+}
+
+func TestIsCleared(t *testing.T) {
+	assert.False(t, IsCleared(nil, "phoneNumber"))
+	assert.False(t, IsCleared([]string{"nationality"}, "phoneNumber"))
+	assert.True(t, IsCleared([]string{"nationality", "phoneNumber"}, "phoneNumber"))
+}
@@ -1,7 +1,16 @@
 package keycloakb
 
 //go:generate mockgen -destination=./mock/instrumenting.go -package=mock -mock_names=Histogram=Histogram github.com/cloudtrust/common-service/metrics Histogram
-//go:generate mockgen -destination=./mock/configdbinstrumenting.go -package=mock -mock_names=ConfigurationDBModule=ConfigurationDBModule,AccredsKeycloakClient=AccredsKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb ConfigurationDBModule,AccredsKeycloakClient
+//go:generate mockgen -destination=./mock/configdbinstrumenting.go -package=mock -mock_names=ConfigurationDBModule=ConfigurationDBModule,AccredsKeycloakClient=AccredsKeycloakClient,AccredsUsersDBModule=AccredsUsersDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb ConfigurationDBModule,AccredsKeycloakClient,AccredsUsersDBModule
 //go:generate mockgen -destination=./mock/keycloak_client.go -package=mock -mock_names=KeycloakClient=KeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb KeycloakClient
-//go:generate mockgen -destination=./mock/sqltypes.go -package=mock -mock_names=CloudtrustDB=CloudtrustDB,SQLRow=SQLRow,SQLRows=SQLRows github.com/cloudtrust/common-service/database/sqltypes CloudtrustDB,SQLRow,SQLRows
+//go:generate mockgen -destination=./mock/sqltypes.go -package=mock -mock_names=CloudtrustDB=CloudtrustDB,SQLRow=SQLRow,SQLRows=SQLRows,Transaction=Transaction github.com/cloudtrust/common-service/database/sqltypes CloudtrustDB,SQLRow,SQLRows,Transaction
 //go:generate mockgen -destination=./mock/security.go -package=mock -mock_names=EncrypterDecrypter=EncrypterDecrypter github.com/cloudtrust/common-service/security EncrypterDecrypter
+//go:generate mockgen -destination=./mock/blindindexbackfill.go -package=mock -mock_names=UsersDetailsDBModule=UsersDetailsDBModule,BackfillKeycloakClient=BackfillKeycloakClient,TokenProvider=TokenProvider github.com/cloudtrust/keycloak-bridge/internal/keycloakb UsersDetailsDBModule,BackfillKeycloakClient,TokenProvider
+//go:generate mockgen -destination=./mock/autounlock.go -package=mock -mock_names=UserLocksDBModule=UserLocksDBModule,AutoUnlockKeycloakClient=AutoUnlockKeycloakClient,EventsReporter=EventsReporter github.com/cloudtrust/keycloak-bridge/internal/keycloakb UserLocksDBModule,AutoUnlockKeycloakClient,EventsReporter
+//go:generate mockgen -destination=./mock/accountdeactivation.go -package=mock -mock_names=AccountDeactivationKeycloakClient=AccountDeactivationKeycloakClient,AccountDeactivationUsersDBModule=AccountDeactivationUsersDBModule,AccountDeactivationConfigDBModule=AccountDeactivationConfigDBModule,LastConnectionsDBModule=LastConnectionsDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb AccountDeactivationKeycloakClient,AccountDeactivationUsersDBModule,AccountDeactivationConfigDBModule,LastConnectionsDBModule
+//go:generate mockgen -destination=./mock/userpurge.go -package=mock -mock_names=UserDeletionsDBModule=UserDeletionsDBModule,UserPurgeKeycloakClient=UserPurgeKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb UserDeletionsDBModule,UserPurgeKeycloakClient
+//go:generate mockgen -destination=./mock/duplicates.go -package=mock -mock_names=DuplicatesKeycloakClient=DuplicatesKeycloakClient,DuplicatesUsersDBModule=DuplicatesUsersDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb DuplicatesKeycloakClient,DuplicatesUsersDBModule
+//go:generate mockgen -destination=./mock/passwordpolicy.go -package=mock -mock_names=PasswordPolicyKeycloakClient=PasswordPolicyKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb PasswordPolicyKeycloakClient
+//go:generate mockgen -destination=./mock/breachedpassword.go -package=mock -mock_names=BreachedPasswordChecker=BreachedPasswordChecker,BreachedPasswordKeycloakClient=BreachedPasswordKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb BreachedPasswordChecker,BreachedPasswordKeycloakClient
+//go:generate mockgen -destination=./mock/accreditationexpiry.go -package=mock -mock_names=AccreditationExpiryKeycloakClient=AccreditationExpiryKeycloakClient,AccreditationNotificationsDBModule=AccreditationNotificationsDBModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb AccreditationExpiryKeycloakClient,AccreditationNotificationsDBModule
+//go:generate mockgen -destination=./mock/adminconfiguration.go -package=mock -mock_names=RealmKeycloakClient=RealmKeycloakClient github.com/cloudtrust/keycloak-bridge/internal/keycloakb RealmKeycloakClient
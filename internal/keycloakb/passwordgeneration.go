@@ -4,9 +4,9 @@ import (
 	crand "crypto/rand"
 	"math/big"
 	mrand "math/rand"
-	"strconv"
 	"strings"
-	"unicode"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
 )
 
 const (
@@ -15,6 +15,8 @@ const (
 	specialChars = "?!#%$"
 	digits       = "2346789"
 	alphabet     = "abcdefghjkmnpqrtuvwxyzABCDEFGHJKLMNPQRTUVWXYZ2346789"
+
+	passwordGenerationAttempts = 100
 )
 
 // appendCharacters appends a number of characters from a certain alphabet to a string array
@@ -40,7 +42,7 @@ func GeneratePassword(policy *string, minLength int, userID string) (string, err
 		} else {
 			pwd = GeneratePasswordNoKeycloakPolicy(minLength)
 		}
-		if pwd != userID {
+		if err != nil || pwd != userID {
 			break
 		}
 	}
@@ -58,44 +60,50 @@ func GeneratePasswordNoKeycloakPolicy(minLength int) string {
 
 // GeneratePasswordFromKeycloakPolicy generates a random password respecting the keycloak password policy
 func GeneratePasswordFromKeycloakPolicy(policy string) (string, error) {
-	// Keycloak password policy is a string of the form
-	// "forceExpiredPasswordChange(365) and specialChars(1) and upperCase(1) and lowerCase(1) and length(4) and digits(1) and notUsername(undefined)"
-	var pwdElems = make([]string, 0)
-	policyItems := strings.Split(policy, "and")
-
-	// generate a random password that corresponds to the password policy
-	//reg := regexp.MustCompile(`[a-zA-z]+[(]{1}[0-9]+[)]{1}`)
-	//pwdReq := string(reg.Find([]byte()))
-
-	f := func(c rune) bool {
-		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	passwordPolicy, err := ParsePasswordPolicy(policy)
+	if err != nil {
+		return "", err
 	}
+	return passwordPolicy.GeneratePassword(0, "", "")
+}
 
-	for i := 0; i < len(policyItems); i++ {
-		keyValueItem := strings.FieldsFunc(policyItems[i], f)
-		minRequired, err := strconv.Atoi(keyValueItem[1])
-		switch keyValueItem[0] {
-		case "length", "lowerCase":
-			// make sure that the password has the minimum length required by choosing random lower case letters
-			pwdElems = appendCharacters(pwdElems, lowerCase, minRequired)
-		case "specialChars":
-			// pick randomly special characters from ?!#%$
-			pwdElems = appendCharacters(pwdElems, specialChars, minRequired)
-		case "upperCase":
-			pwdElems = appendCharacters(pwdElems, upperCase, minRequired)
-		case "digits":
-			pwdElems = appendCharacters(pwdElems, digits, minRequired)
-		default:
-			err = nil
+// GeneratePassword generates a random password of at least minLength characters respecting the policy for a user with the
+// given username and email
+func (p PasswordPolicy) GeneratePassword(minLength int, username, email string) (string, error) {
+	var required = p.Digits + p.LowerCase + p.UpperCase + p.SpecialChars
+	var length = maxInt(p.Length, maxInt(required, minLength))
+	if p.MaxLength > 0 {
+		if required > p.MaxLength || p.Length > p.MaxLength {
+			return "", errorhandler.CreateInternalServerError("passwordPolicy." + policyMaxLength)
 		}
-		if err != nil {
-			return "", err
+		if length > p.MaxLength {
+			length = p.MaxLength
 		}
 	}
-	mrand.Shuffle(len(pwdElems), func(i, j int) { pwdElems[i], pwdElems[j] = pwdElems[j], pwdElems[i] })
-	pwd := strings.Join(pwdElems, "")
-	return pwd, nil
 
+	// a random password can still be the username or not match a pattern: it is then generated again
+	for attempt := 0; attempt < passwordGenerationAttempts; attempt++ {
+		var pwdElems []string
+		pwdElems = appendCharacters(pwdElems, digits, p.Digits)
+		pwdElems = appendCharacters(pwdElems, lowerCase, p.LowerCase)
+		pwdElems = appendCharacters(pwdElems, upperCase, p.UpperCase)
+		pwdElems = appendCharacters(pwdElems, specialChars, p.SpecialChars)
+		pwdElems = appendCharacters(pwdElems, alphabet, length-required)
+		mrand.Shuffle(len(pwdElems), func(i, j int) { pwdElems[i], pwdElems[j] = pwdElems[j], pwdElems[i] })
+
+		var pwd = strings.Join(pwdElems, "")
+		if len(p.Evaluate(pwd, username, email)) == 0 {
+			return pwd, nil
+		}
+	}
+	return "", errorhandler.CreateInternalServerError("passwordPolicy." + policyRegexPattern)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // GenerateInitialCode generates a code of the format UpperCase +  digits + LowerCase
@@ -0,0 +1,245 @@
+package keycloakb
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+// Keycloak password policies
+const (
+	policyLength          = "length"
+	policyMaxLength       = "maxLength"
+	policyDigits          = "digits"
+	policyLowerCase       = "lowerCase"
+	policyUpperCase       = "upperCase"
+	policySpecialChars    = "specialChars"
+	policyNotUsername     = "notUsername"
+	policyNotEmail        = "notEmail"
+	policyRegexPattern    = "regexPattern"
+	policyPasswordHistory = "passwordHistory"
+
+	policySeparator = " and "
+	policyUndefined = "undefined"
+)
+
+var (
+	// Values used by Keycloak when a policy is configured without value
+	policyDefaultValues = map[string]int{
+		policyLength:          8,
+		policyMaxLength:       64,
+		policyDigits:          1,
+		policyLowerCase:       1,
+		policyUpperCase:       1,
+		policySpecialChars:    1,
+		policyPasswordHistory: 3,
+	}
+)
+
+// PasswordPolicy is a parsed Keycloak password policy. Zero values mean that the policy is not configured
+type PasswordPolicy struct {
+	Length        int
+	MaxLength     int
+	Digits        int
+	LowerCase     int
+	UpperCase     int
+	SpecialChars  int
+	NotUsername   bool
+	NotEmail      bool
+	RegexPatterns []*regexp.Regexp
+	// PasswordHistory is the number of former passwords which can't be reused. Keycloak checks it against the stored
+	// credentials: a generated password is random and is never one of them
+	PasswordHistory int
+}
+
+// ParsePasswordPolicy parses a Keycloak password policy such as "length(8) and digits(1) and notUsername(undefined)".
+// Policies which have no impact on the value of a password, like hashIterations or hashAlgorithm, are ignored whatever their value
+func ParsePasswordPolicy(policy string) (PasswordPolicy, error) {
+	var res PasswordPolicy
+	for _, item := range strings.Split(policy, policySeparator) {
+		var name, value = splitPolicyItem(item)
+		var err error
+		switch name {
+		case policyLength:
+			res.Length, err = parsePolicyValue(name, value)
+		case policyMaxLength:
+			res.MaxLength, err = parsePolicyValue(name, value)
+		case policyDigits:
+			res.Digits, err = parsePolicyValue(name, value)
+		case policyLowerCase:
+			res.LowerCase, err = parsePolicyValue(name, value)
+		case policyUpperCase:
+			res.UpperCase, err = parsePolicyValue(name, value)
+		case policySpecialChars:
+			res.SpecialChars, err = parsePolicyValue(name, value)
+		case policyPasswordHistory:
+			res.PasswordHistory, err = parsePolicyValue(name, value)
+		case policyNotUsername:
+			res.NotUsername = true
+		case policyNotEmail:
+			res.NotEmail = true
+		case policyRegexPattern:
+			// As in Keycloak, the whole password has to match the pattern
+			var pattern *regexp.Regexp
+			if pattern, err = regexp.Compile("^(?:" + value + ")$"); err == nil {
+				res.RegexPatterns = append(res.RegexPatterns, pattern)
+			}
+		}
+		if err != nil {
+			return PasswordPolicy{}, errorhandler.CreateInternalServerError("passwordPolicy." + name)
+		}
+	}
+	return res, nil
+}
+
+// splitPolicyItem splits a policy item like "digits(2)" into its name and its value. The value is everything between the
+// first opening and the last closing parenthesis as it can be a regular expression
+func splitPolicyItem(item string) (string, string) {
+	item = strings.TrimSpace(item)
+	var start = strings.Index(item, "(")
+	var end = strings.LastIndex(item, ")")
+	if start < 0 || end < start {
+		return item, ""
+	}
+	return strings.TrimSpace(item[:start]), item[start+1 : end]
+}
+
+func parsePolicyValue(name, value string) (int, error) {
+	if value == "" || value == policyUndefined {
+		return policyDefaultValues[name], nil
+	}
+	return strconv.Atoi(strings.TrimSpace(value))
+}
+
+// Evaluate returns the rules of the policy violated by a password, like "invalidParameter.password.digits.2" when the
+// password should contain at least two digits. The password history can only be checked by Keycloak
+func (p PasswordPolicy) Evaluate(password, username, email string) []string {
+	var violations []string
+	var addViolation = func(rule string, value ...int) {
+		var violation = constants.MsgErrInvalidParam + "." + constants.Password + "." + rule
+		for _, v := range value {
+			violation += "." + strconv.Itoa(v)
+		}
+		violations = append(violations, violation)
+	}
+
+	var length, nbDigits, nbLowerCase, nbUpperCase, nbSpecialChars int
+	for _, c := range password {
+		length++
+		switch {
+		case unicode.IsDigit(c):
+			nbDigits++
+		case unicode.IsLower(c):
+			nbLowerCase++
+		case unicode.IsUpper(c):
+			nbUpperCase++
+		case !unicode.IsLetter(c):
+			nbSpecialChars++
+		}
+	}
+
+	if length < p.Length {
+		addViolation(policyLength, p.Length)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		addViolation(policyMaxLength, p.MaxLength)
+	}
+	if nbDigits < p.Digits {
+		addViolation(policyDigits, p.Digits)
+	}
+	if nbLowerCase < p.LowerCase {
+		addViolation(policyLowerCase, p.LowerCase)
+	}
+	if nbUpperCase < p.UpperCase {
+		addViolation(policyUpperCase, p.UpperCase)
+	}
+	if nbSpecialChars < p.SpecialChars {
+		addViolation(policySpecialChars, p.SpecialChars)
+	}
+	if p.NotUsername && username != "" && strings.EqualFold(password, username) {
+		addViolation(policyNotUsername)
+	}
+	if p.NotEmail && email != "" && strings.EqualFold(password, email) {
+		addViolation(policyNotEmail)
+	}
+	for _, pattern := range p.RegexPatterns {
+		if !pattern.MatchString(password) {
+			addViolation(policyRegexPattern)
+			break
+		}
+	}
+	return violations
+}
+
+// CheckPassword returns a bad request error listing all the rules of the policy violated by a password
+func (p PasswordPolicy) CheckPassword(password, username, email string) error {
+	var violations = p.Evaluate(password, username, email)
+	if len(violations) == 0 {
+		return nil
+	}
+	for i, violation := range violations {
+		violations[i] = ComponentName + "." + violation
+	}
+	return errorhandler.Error{
+		Status:  http.StatusBadRequest,
+		Message: strings.Join(violations, ","),
+	}
+}
+
+// PasswordPolicyKeycloakClient is the minimum Keycloak client interface for the password policy module
+type PasswordPolicyKeycloakClient interface {
+	GetRealm(accessToken string, realmName string) (kc.RealmRepresentation, error)
+}
+
+// PasswordPolicyModule provides the password policy of the realms to the services which can't read the realm with the token
+// of the caller
+type PasswordPolicyModule interface {
+	GetPasswordPolicy(ctx context.Context, realmName string) (PasswordPolicy, error)
+}
+
+type passwordPolicyModule struct {
+	keycloakClient PasswordPolicyKeycloakClient
+	tokenProvider  TokenProvider
+	logger         Logger
+}
+
+// NewPasswordPolicyModule creates a password policy module
+func NewPasswordPolicyModule(keycloakClient PasswordPolicyKeycloakClient, tokenProvider TokenProvider, logger Logger) PasswordPolicyModule {
+	return &passwordPolicyModule{
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		logger:         logger,
+	}
+}
+
+// GetPasswordPolicy returns the parsed password policy of a realm. A realm without policy has an empty policy
+func (pm *passwordPolicyModule) GetPasswordPolicy(ctx context.Context, realmName string) (PasswordPolicy, error) {
+	var accessToken, err = pm.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		pm.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return PasswordPolicy{}, err
+	}
+
+	realm, err := pm.keycloakClient.GetRealm(accessToken, realmName)
+	if err != nil {
+		pm.logger.Warn(ctx, "msg", "Can't get realm from Keycloak", "err", err.Error(), "realm", realmName)
+		return PasswordPolicy{}, err
+	}
+	if realm.PasswordPolicy == nil {
+		return PasswordPolicy{}, nil
+	}
+
+	policy, err := ParsePasswordPolicy(*realm.PasswordPolicy)
+	if err != nil {
+		pm.logger.Warn(ctx, "msg", "Can't parse password policy", "err", err.Error(), "realm", realmName)
+		return PasswordPolicy{}, err
+	}
+	return policy, nil
+}
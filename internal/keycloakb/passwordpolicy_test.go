@@ -0,0 +1,191 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePasswordPolicy(t *testing.T) {
+	t.Run("Full policy", func(t *testing.T) {
+		var policy, err = ParsePasswordPolicy("hashIterations(27500) and length(10) and maxLength(20) and digits(2) and lowerCase(undefined) and " +
+			"upperCase(3) and specialChars(0) and notUsername(undefined) and notEmail(undefined) and regexPattern(^(ab|cd).*) and " +
+			"passwordHistory(5) and forceExpiredPasswordChange(365) and hashAlgorithm(pbkdf2-sha256)")
+		assert.Nil(t, err)
+		assert.Equal(t, 10, policy.Length)
+		assert.Equal(t, 20, policy.MaxLength)
+		assert.Equal(t, 2, policy.Digits)
+		assert.Equal(t, 1, policy.LowerCase)
+		assert.Equal(t, 3, policy.UpperCase)
+		assert.Equal(t, 0, policy.SpecialChars)
+		assert.True(t, policy.NotUsername)
+		assert.True(t, policy.NotEmail)
+		assert.Len(t, policy.RegexPatterns, 1)
+		assert.True(t, policy.RegexPatterns[0].MatchString("abXYZ"))
+		assert.False(t, policy.RegexPatterns[0].MatchString("XYZab"))
+		assert.Equal(t, 5, policy.PasswordHistory)
+	})
+
+	t.Run("Hash iterations are ignored", func(t *testing.T) {
+		var policy, err = ParsePasswordPolicy("hashIterations(undefined)")
+		assert.Nil(t, err)
+		assert.Equal(t, PasswordPolicy{}, policy)
+	})
+
+	t.Run("Empty policy", func(t *testing.T) {
+		var policy, err = ParsePasswordPolicy("")
+		assert.Nil(t, err)
+		assert.Equal(t, PasswordPolicy{}, policy)
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		var _, err = ParsePasswordPolicy("length(8) and digits(two)")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		var _, err = ParsePasswordPolicy("regexPattern([a-z)")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	var policy, _ = ParsePasswordPolicy("length(8) and maxLength(11) and digits(2) and lowerCase(1) and upperCase(1) and specialChars(1) and " +
+		"notUsername(undefined) and notEmail(undefined) and regexPattern([A-Z].*) and passwordHistory(3)")
+
+	t.Run("Valid password", func(t *testing.T) {
+		assert.Len(t, policy.Evaluate("Passw0rd!2", "username", "john@elca.ch"), 0)
+		assert.Nil(t, policy.CheckPassword("Passw0rd!2", "username", "john@elca.ch"))
+	})
+
+	t.Run("Every violated rule is returned", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"invalidParameter.password.length.8",
+			"invalidParameter.password.digits.2",
+			"invalidParameter.password.upperCase.1",
+			"invalidParameter.password.specialChars.1",
+			"invalidParameter.password.regexPattern",
+		}, policy.Evaluate("pass1", "username", "john@elca.ch"))
+		assert.Equal(t, []string{
+			"invalidParameter.password.maxLength.11",
+			"invalidParameter.password.digits.2",
+			"invalidParameter.password.upperCase.1",
+			"invalidParameter.password.notEmail",
+			"invalidParameter.password.regexPattern",
+		}, policy.Evaluate("john@elca.ch", "username", "JOHN@elca.ch"))
+		assert.Contains(t, policy.Evaluate("Username", "username", ""), "invalidParameter.password.notUsername")
+	})
+
+	t.Run("Bad request error", func(t *testing.T) {
+		var err = policy.CheckPassword("Passw0rd!", "username", "john@elca.ch")
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Equal(t, ComponentName+".invalidParameter.password.digits.2", err.(errorhandler.Error).Message)
+	})
+}
+
+func TestGeneratePasswordFromPolicy(t *testing.T) {
+	t.Run("Length limited by maxLength", func(t *testing.T) {
+		var policy = PasswordPolicy{Length: 8, MaxLength: 10, Digits: 2}
+		var pwd, err = policy.GeneratePassword(12, "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(pwd))
+	})
+
+	t.Run("Minimum length", func(t *testing.T) {
+		var policy = PasswordPolicy{Digits: 1}
+		var pwd, err = policy.GeneratePassword(8, "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 8, len(pwd))
+	})
+
+	t.Run("Too many required characters", func(t *testing.T) {
+		var policy = PasswordPolicy{MaxLength: 4, Digits: 3, UpperCase: 3}
+		var _, err = policy.GeneratePassword(0, "", "")
+		assert.NotNil(t, err)
+
+		policy = PasswordPolicy{Length: 12, MaxLength: 10}
+		_, err = policy.GeneratePassword(0, "", "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Matches the pattern", func(t *testing.T) {
+		var policy, _ = ParsePasswordPolicy("length(8) and regexPattern([0-9].*)")
+		var pwd, err = policy.GeneratePassword(0, "", "")
+		assert.Nil(t, err)
+		assert.Regexp(t, "^[0-9]", pwd)
+	})
+
+	t.Run("Pattern can't be matched", func(t *testing.T) {
+		var policy, _ = ParsePasswordPolicy("regexPattern(.*[\\s].*)")
+		var _, err = policy.GeneratePassword(8, "", "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Not the username", func(t *testing.T) {
+		var policy = PasswordPolicy{Length: 1, NotUsername: true}
+		for i := 0; i < 20; i++ {
+			var pwd, err = policy.GeneratePassword(0, "a", "")
+			assert.Nil(t, err)
+			assert.False(t, strings.EqualFold("a", pwd))
+		}
+	})
+}
+
+func TestGetPasswordPolicy(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewPasswordPolicyKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+
+	var module = NewPasswordPolicyModule(mockKeycloakClient, mockTokenProvider, log.NewNopLogger())
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var realmName = "realm"
+	var anyError = errors.New("any error")
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = module.GetPasswordPolicy(ctx, realmName)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get realm", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, anyError)
+		var _, err = module.GetPasswordPolicy(ctx, realmName)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Realm without policy", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil)
+		var policy, err = module.GetPasswordPolicy(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, PasswordPolicy{}, policy)
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		var passwordPolicy = "digits(two)"
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &passwordPolicy}, nil)
+		var _, err = module.GetPasswordPolicy(ctx, realmName)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var passwordPolicy = "length(10) and digits(2)"
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &passwordPolicy}, nil)
+		var policy, err = module.GetPasswordPolicy(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Equal(t, PasswordPolicy{Length: 10, Digits: 2}, policy)
+	})
+}
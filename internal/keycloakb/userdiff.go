@@ -0,0 +1,147 @@
+package keycloakb
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	maskedSuffix = "***"
+	// Shorter values are entirely masked: their first character would disclose them (gender, nationality, ...)
+	minPartiallyMaskedLength = 4
+)
+
+var (
+	// Personal information never appears in clear in the audit events
+	maskedUserFields = map[string]bool{
+		"email":                true,
+		"firstName":            true,
+		"lastName":             true,
+		"phoneNumber":          true,
+		"gender":               true,
+		"birthDate":            true,
+		"birthLocation":        true,
+		"nationality":          true,
+		"idDocumentNumber":     true,
+		"idDocumentExpiration": true,
+	}
+
+	userAttributeFields = []struct {
+		field string
+		key   kc.AttributeKey
+	}{
+		{"phoneNumber", constants.AttrbPhoneNumber},
+		{"phoneNumberVerified", constants.AttrbPhoneNumberVerified},
+		{"label", constants.AttrbLabel},
+		{"gender", constants.AttrbGender},
+		{"birthDate", constants.AttrbBirthDate},
+		{"locale", constants.AttrbLocale},
+	}
+)
+
+// UserChange is the update of a field of a user. Values of personal information are masked
+type UserChange struct {
+	Field    string  `json:"field"`
+	OldValue *string `json:"old,omitempty"`
+	NewValue *string `json:"new,omitempty"`
+}
+
+// DiffKeycloakUsers returns the changes applied to a Keycloak user. As in a Keycloak update, the fields of the new
+// representation which are not provided are left unchanged while its attributes replace the former ones
+func DiffKeycloakUsers(oldUser, newUser kc.UserRepresentation) []UserChange {
+	var changes = []UserChange{}
+	changes = appendUpdate(changes, "username", oldUser.Username, newUser.Username)
+	changes = appendUpdate(changes, "email", oldUser.Email, newUser.Email)
+	changes = appendUpdate(changes, "emailVerified", boolToString(oldUser.EmailVerified), boolToString(newUser.EmailVerified))
+	changes = appendUpdate(changes, "enabled", boolToString(oldUser.Enabled), boolToString(newUser.Enabled))
+	changes = appendUpdate(changes, "firstName", oldUser.FirstName, newUser.FirstName)
+	changes = appendUpdate(changes, "lastName", oldUser.LastName, newUser.LastName)
+
+	if newUser.Attributes != nil {
+		for _, attrb := range userAttributeFields {
+			changes = appendChange(changes, attrb.field, oldUser.GetAttributeString(attrb.key), newUser.GetAttributeString(attrb.key))
+		}
+	}
+	return changes
+}
+
+// DiffUserDetails returns the changes applied to the details of a user stored in database
+func DiffUserDetails(oldUser, newUser dto.DBUser) []UserChange {
+	var changes = []UserChange{}
+	changes = appendChange(changes, "birthLocation", oldUser.BirthLocation, newUser.BirthLocation)
+	changes = appendChange(changes, "nationality", oldUser.Nationality, newUser.Nationality)
+	changes = appendChange(changes, "idDocumentType", oldUser.IDDocumentType, newUser.IDDocumentType)
+	changes = appendChange(changes, "idDocumentNumber", oldUser.IDDocumentNumber, newUser.IDDocumentNumber)
+	changes = appendChange(changes, "idDocumentExpiration", oldUser.IDDocumentExpiration, newUser.IDDocumentExpiration)
+	changes = appendChange(changes, "idDocumentCountry", oldUser.IDDocumentCountry, newUser.IDDocumentCountry)
+	return changes
+}
+
+// DiffAccountExpiry returns the change of the expiry date of an account. A nil new date means the expiry is removed
+func DiffAccountExpiry(oldExpiryDate, newExpiryDate *string) []UserChange {
+	return appendChange([]UserChange{}, "accountExpiryDate", oldExpiryDate, newExpiryDate)
+}
+
+// CreateChangesAdditionalInfo creates the additional information of an event describing the changes of a user
+func CreateChangesAdditionalInfo(changes []UserChange) string {
+	if changes == nil {
+		changes = []UserChange{}
+	}
+	var res, _ = json.Marshal(map[string][]UserChange{"changes": changes})
+	return string(res)
+}
+
+// appendUpdate appends a change only when a new value is provided
+func appendUpdate(changes []UserChange, field string, oldValue, newValue *string) []UserChange {
+	if newValue == nil {
+		return changes
+	}
+	return appendChange(changes, field, oldValue, newValue)
+}
+
+// appendChange appends a change when values differ. An empty value is considered as removed
+func appendChange(changes []UserChange, field string, oldValue, newValue *string) []UserChange {
+	oldValue = nilWhenEmpty(oldValue)
+	newValue = nilWhenEmpty(newValue)
+	if oldValue == nil && newValue == nil || oldValue != nil && newValue != nil && *oldValue == *newValue {
+		return changes
+	}
+	if maskedUserFields[field] {
+		oldValue = maskValue(oldValue)
+		newValue = maskValue(newValue)
+	}
+	return append(changes, UserChange{Field: field, OldValue: oldValue, NewValue: newValue})
+}
+
+// maskValue only keeps the first character of a value. The length of the value is not disclosed either
+func maskValue(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	var runes = []rune(*value)
+	if len(runes) < minPartiallyMaskedLength {
+		var masked = maskedSuffix
+		return &masked
+	}
+	var masked = string(runes[:1]) + maskedSuffix
+	return &masked
+}
+
+func nilWhenEmpty(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
+
+func boolToString(value *bool) *string {
+	if value == nil {
+		return nil
+	}
+	var res = strconv.FormatBool(*value)
+	return &res
+}
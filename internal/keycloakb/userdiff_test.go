@@ -0,0 +1,115 @@
+package keycloakb
+
+import (
+	"testing"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffKeycloakUsers(t *testing.T) {
+	var ptr = func(value string) *string { return &value }
+	var verified = true
+	var attributes = make(kc.Attributes)
+	attributes.SetString(constants.AttrbPhoneNumber, "+41789456")
+	attributes.SetString(constants.AttrbLocale, "fr")
+	var oldUser = kc.UserRepresentation{
+		Username:      ptr("username"),
+		Email:         ptr("john@elca.ch"),
+		EmailVerified: &verified,
+		FirstName:     ptr("John"),
+		Attributes:    &attributes,
+	}
+
+	t.Run("No change", func(t *testing.T) {
+		assert.Len(t, DiffKeycloakUsers(oldUser, oldUser), 0)
+		assert.Len(t, DiffKeycloakUsers(oldUser, kc.UserRepresentation{}), 0)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		var notVerified = false
+		var newAttributes = make(kc.Attributes)
+		newAttributes.SetString(constants.AttrbLocale, "de")
+		newAttributes.SetString(constants.AttrbLabel, "label")
+		var newUser = kc.UserRepresentation{
+			Email:         ptr("jane@elca.ch"),
+			EmailVerified: &notVerified,
+			FirstName:     ptr("John"),
+			Attributes:    &newAttributes,
+		}
+
+		var changes = DiffKeycloakUsers(oldUser, newUser)
+		assert.Equal(t, []UserChange{
+			{Field: "email", OldValue: ptr("j***"), NewValue: ptr("j***")},
+			{Field: "emailVerified", OldValue: ptr("true"), NewValue: ptr("false")},
+			{Field: "phoneNumber", OldValue: ptr("+***")},
+			{Field: "label", NewValue: ptr("label")},
+			{Field: "locale", OldValue: ptr("fr"), NewValue: ptr("de")},
+		}, changes)
+	})
+
+	t.Run("Empty value is removed", func(t *testing.T) {
+		var changes = DiffKeycloakUsers(oldUser, kc.UserRepresentation{Email: ptr("")})
+		assert.Equal(t, []UserChange{{Field: "email", OldValue: ptr("j***")}}, changes)
+	})
+}
+
+func TestDiffUserDetails(t *testing.T) {
+	var ptr = func(value string) *string { return &value }
+	var oldUser = dto.DBUser{
+		BirthLocation:    ptr("Lausanne"),
+		IDDocumentType:   ptr("PASSPORT"),
+		IDDocumentNumber: ptr("123456"),
+	}
+
+	assert.Len(t, DiffUserDetails(oldUser, oldUser), 0)
+
+	var changes = DiffUserDetails(oldUser, dto.DBUser{
+		BirthLocation:    ptr("Lausanne"),
+		Nationality:      ptr("CH"),
+		IDDocumentType:   ptr("ID_CARD"),
+		IDDocumentNumber: ptr("654321"),
+	})
+	assert.Equal(t, []UserChange{
+		{Field: "nationality", NewValue: ptr("***")},
+		{Field: "idDocumentType", OldValue: ptr("PASSPORT"), NewValue: ptr("ID_CARD")},
+		{Field: "idDocumentNumber", OldValue: ptr("1***"), NewValue: ptr("6***")},
+	}, changes)
+}
+
+func TestDiffAccountExpiry(t *testing.T) {
+	var ptr = func(value string) *string { return &value }
+
+	assert.Len(t, DiffAccountExpiry(ptr("31.12.2030"), ptr("31.12.2030")), 0)
+	assert.Len(t, DiffAccountExpiry(nil, nil), 0)
+	assert.Equal(t, []UserChange{{Field: "accountExpiryDate", NewValue: ptr("31.12.2030")}}, DiffAccountExpiry(nil, ptr("31.12.2030")))
+	assert.Equal(t, []UserChange{{Field: "accountExpiryDate", OldValue: ptr("31.12.2030")}}, DiffAccountExpiry(ptr("31.12.2030"), nil))
+}
+
+func TestMaskValue(t *testing.T) {
+	var ptr = func(value string) *string { return &value }
+
+	assert.Nil(t, maskValue(nil))
+	assert.Equal(t, "***", *maskValue(ptr("M")))
+	assert.Equal(t, "***", *maskValue(ptr("CH")))
+	assert.Equal(t, "***", *maskValue(ptr("Zoë")))
+	assert.Equal(t, "J***", *maskValue(ptr("John")))
+	assert.Equal(t, "É***", *maskValue(ptr("Élodie")))
+
+	t.Run("Change of a one-character value is not disclosed", func(t *testing.T) {
+		var oldAttributes = make(kc.Attributes)
+		oldAttributes.SetString(constants.AttrbGender, "M")
+		var newAttributes = make(kc.Attributes)
+		newAttributes.SetString(constants.AttrbGender, "F")
+		var changes = DiffKeycloakUsers(kc.UserRepresentation{Attributes: &oldAttributes}, kc.UserRepresentation{Attributes: &newAttributes})
+		assert.Equal(t, []UserChange{{Field: "gender", OldValue: ptr("***"), NewValue: ptr("***")}}, changes)
+	})
+}
+
+func TestCreateChangesAdditionalInfo(t *testing.T) {
+	var value = "de"
+	assert.Equal(t, `{"changes":[]}`, CreateChangesAdditionalInfo(nil))
+	assert.Equal(t, `{"changes":[{"field":"locale","new":"de"}]}`, CreateChangesAdditionalInfo([]UserChange{{Field: "locale", NewValue: &value}}))
+}
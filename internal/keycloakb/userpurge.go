@@ -0,0 +1,131 @@
+package keycloakb
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudtrust/common-service/database"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	kc "github.com/cloudtrust/keycloak-client"
+)
+
+const (
+	userPurgePageSize = 100
+)
+
+// UserPurgeKeycloakClient is the minimum Keycloak client interface for the purge of soft deleted users
+type UserPurgeKeycloakClient interface {
+	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
+	DeleteUser(accessToken string, realmName, userID string) error
+}
+
+// UserDeletionsDBModule is the minimum users DB module interface for the purge of soft deleted users
+type UserDeletionsDBModule interface {
+	GetPurgeableUserDeletions(ctx context.Context, until time.Time, max int) ([]dto.DBUserDeletion, error)
+	DeleteUserDetails(ctx context.Context, realm string, userID string) error
+	DeleteUserDeletion(ctx context.Context, realm string, userID string) error
+	RevokeAccreditations(ctx context.Context, revocation dto.DBAccreditationRevocation) error
+}
+
+// UserPurge definitively deletes the soft deleted users once their retention period is over
+type UserPurge interface {
+	Run(ctx context.Context) (int, error)
+}
+
+type userPurge struct {
+	usersDBModule  UserDeletionsDBModule
+	keycloakClient UserPurgeKeycloakClient
+	tokenProvider  TokenProvider
+	eventsReporter EventsReporter
+	logger         Logger
+	now            func() time.Time
+}
+
+// NewUserPurge creates a UserPurge
+func NewUserPurge(usersDBModule UserDeletionsDBModule, keycloakClient UserPurgeKeycloakClient, tokenProvider TokenProvider,
+	eventsReporter EventsReporter, logger Logger) UserPurge {
+	return &userPurge{
+		usersDBModule:  usersDBModule,
+		keycloakClient: keycloakClient,
+		tokenProvider:  tokenProvider,
+		eventsReporter: eventsReporter,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Run purges the users whose retention period is over. Returns the number of purged users.
+// A user which can't be purged is skipped: it will be processed again during the next run
+func (p *userPurge) Run(ctx context.Context) (int, error) {
+	var deletions, err = p.usersDBModule.GetPurgeableUserDeletions(ctx, p.now(), userPurgePageSize)
+	if err != nil {
+		p.logger.Warn(ctx, "msg", "Can't get purgeable users", "err", err.Error())
+		return 0, err
+	}
+	if len(deletions) == 0 {
+		return 0, nil
+	}
+
+	accessToken, err := p.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		p.logger.Warn(ctx, "msg", "Can't get access token for technical user", "err", err.Error())
+		return 0, err
+	}
+
+	var count = 0
+	for _, deletion := range deletions {
+		if p.purge(ctx, accessToken, deletion) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (p *userPurge) purge(ctx context.Context, accessToken string, deletion dto.DBUserDeletion) bool {
+	var user, err = p.keycloakClient.GetUser(accessToken, deletion.RealmID, deletion.UserID)
+	if err != nil && !isNotFound(err) {
+		p.logger.Warn(ctx, "msg", "Can't get user from Keycloak", "err", err.Error(), "realm", deletion.RealmID, "userID", deletion.UserID)
+		return false
+	}
+	if err == nil && user.Enabled != nil && *user.Enabled {
+		// User has been enabled again since its deletion: it is not deleted anymore
+		p.logger.Info(ctx, "msg", "User enabled since its deletion is not purged", "realm", deletion.RealmID, "userID", deletion.UserID)
+		if err = p.usersDBModule.DeleteUserDeletion(ctx, deletion.RealmID, deletion.UserID); err != nil {
+			p.logger.Warn(ctx, "msg", "Can't delete user deletion", "err", err.Error(), "realm", deletion.RealmID, "userID", deletion.UserID)
+		}
+		return false
+	}
+
+	// Accreditations must not outlive the user: the ones still active are revoked before the user is deleted
+	var revocation = dto.DBAccreditationRevocation{
+		RealmID:   deletion.RealmID,
+		UserID:    deletion.UserID,
+		Date:      p.now(),
+		Reason:    dto.AccreditationRevocationUserDeleted,
+		RevokedBy: deletion.DeletedBy,
+	}
+	if err := p.usersDBModule.RevokeAccreditations(ctx, revocation); err != nil {
+		p.logger.Warn(ctx, "msg", "Can't record revoked accreditations", "err", err.Error(), "realm", deletion.RealmID, "userID", deletion.UserID)
+		return false
+	}
+
+	// A user already deleted from Keycloak still needs its details to be removed
+	if err := p.keycloakClient.DeleteUser(accessToken, deletion.RealmID, deletion.UserID); err != nil && !isNotFound(err) {
+		p.logger.Warn(ctx, "msg", "Can't delete user from Keycloak", "err", err.Error(), "realm", deletion.RealmID, "userID", deletion.UserID)
+		return false
+	}
+	if err := p.usersDBModule.DeleteUserDetails(ctx, deletion.RealmID, deletion.UserID); err != nil {
+		p.logger.Warn(ctx, "msg", "Can't delete user details", "err", err.Error(), "realm", deletion.RealmID, "userID", deletion.UserID)
+		return false
+	}
+	if err := p.usersDBModule.DeleteUserDeletion(ctx, deletion.RealmID, deletion.UserID); err != nil {
+		p.logger.Warn(ctx, "msg", "Can't delete user deletion", "err", err.Error(), "realm", deletion.RealmID, "userID", deletion.UserID)
+		return false
+	}
+
+	var values = []string{database.CtEventRealmName, deletion.RealmID, database.CtEventUserID, deletion.UserID}
+	if err := p.eventsReporter.ReportEvent(ctx, "ACCOUNT_PURGED", "back-office", values...); err != nil {
+		LogUnrecordedEvent(ctx, p.logger, "ACCOUNT_PURGED", err.Error(), values...)
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package keycloakb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPurge(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockUsersDB = mock.NewUserDeletionsDBModule(mockCtrl)
+	var mockKeycloakClient = mock.NewUserPurgeKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+
+	var purge = NewUserPurge(mockUsersDB, mockKeycloakClient, mockTokenProvider, mockEventsReporter, log.NewNopLogger())
+	var now = time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC)
+	purge.(*userPurge).now = func() time.Time { return now }
+
+	var ctx = context.TODO()
+	var accessToken = "TOKEN=="
+	var anyError = errors.New("any error")
+	var operator = "operator"
+	var deletion = dto.DBUserDeletion{RealmID: "realm", UserID: "user-id", DeletedBy: &operator, PurgeDate: now.Add(-time.Hour)}
+
+	t.Run("Can't get purgeable users", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetPurgeableUserDeletions(ctx, now, userPurgePageSize).Return(nil, anyError)
+		var _, err = purge.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Nothing to purge", func(t *testing.T) {
+		mockUsersDB.EXPECT().GetPurgeableUserDeletions(ctx, now, userPurgePageSize).Return(nil, nil)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	mockUsersDB.EXPECT().GetPurgeableUserDeletions(ctx, now, userPurgePageSize).Return([]dto.DBUserDeletion{deletion}, nil).AnyTimes()
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = purge.Run(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get user from Keycloak", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, deletion.RealmID, deletion.UserID).Return(kc.UserRepresentation{}, anyError)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("User enabled again since its deletion is not purged", func(t *testing.T) {
+		var enabled = true
+		mockKeycloakClient.EXPECT().GetUser(accessToken, deletion.RealmID, deletion.UserID).Return(kc.UserRepresentation{Enabled: &enabled}, nil)
+		mockUsersDB.EXPECT().DeleteUserDeletion(ctx, deletion.RealmID, deletion.UserID).Return(nil)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("User already deleted from Keycloak", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, deletion.RealmID, deletion.UserID).Return(kc.UserRepresentation{}, kc.HTTPError{HTTPStatus: 404})
+		mockUsersDB.EXPECT().RevokeAccreditations(ctx, gomock.Any()).Return(nil)
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, deletion.RealmID, deletion.UserID).Return(kc.HTTPError{HTTPStatus: 404})
+		mockUsersDB.EXPECT().DeleteUserDetails(ctx, deletion.RealmID, deletion.UserID).Return(nil)
+		mockUsersDB.EXPECT().DeleteUserDeletion(ctx, deletion.RealmID, deletion.UserID).Return(nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "ACCOUNT_PURGED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(anyError)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	var disabled = false
+	mockKeycloakClient.EXPECT().GetUser(accessToken, deletion.RealmID, deletion.UserID).Return(kc.UserRepresentation{Enabled: &disabled}, nil).AnyTimes()
+
+	t.Run("Can't revoke accreditations", func(t *testing.T) {
+		mockUsersDB.EXPECT().RevokeAccreditations(ctx, gomock.Any()).Return(anyError)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	mockUsersDB.EXPECT().RevokeAccreditations(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, revocation dto.DBAccreditationRevocation) error {
+		assert.Equal(t, deletion.RealmID, revocation.RealmID)
+		assert.Equal(t, deletion.UserID, revocation.UserID)
+		assert.Nil(t, revocation.Type)
+		assert.Equal(t, now, revocation.Date)
+		assert.Equal(t, dto.AccreditationRevocationUserDeleted, revocation.Reason)
+		assert.Equal(t, operator, *revocation.RevokedBy)
+		return nil
+	}).AnyTimes()
+
+	t.Run("Can't delete user from Keycloak", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, deletion.RealmID, deletion.UserID).Return(anyError)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Can't delete user details", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, deletion.RealmID, deletion.UserID).Return(nil)
+		mockUsersDB.EXPECT().DeleteUserDetails(ctx, deletion.RealmID, deletion.UserID).Return(anyError)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Can't delete user deletion", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, deletion.RealmID, deletion.UserID).Return(nil)
+		mockUsersDB.EXPECT().DeleteUserDetails(ctx, deletion.RealmID, deletion.UserID).Return(nil)
+		mockUsersDB.EXPECT().DeleteUserDeletion(ctx, deletion.RealmID, deletion.UserID).Return(anyError)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteUser(accessToken, deletion.RealmID, deletion.UserID).Return(nil)
+		mockUsersDB.EXPECT().DeleteUserDetails(ctx, deletion.RealmID, deletion.UserID).Return(nil)
+		mockUsersDB.EXPECT().DeleteUserDeletion(ctx, deletion.RealmID, deletion.UserID).Return(nil)
+		mockEventsReporter.EXPECT().ReportEvent(ctx, "ACCOUNT_PURGED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		var count, err = purge.Run(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
@@ -15,16 +15,46 @@ import (
 )
 
 const (
-	updateUserDetailsStmt = `INSERT INTO user_details (realm_id, user_id, details)
-	  VALUES (?, ?, ?) 
-	  ON DUPLICATE KEY UPDATE details=?;`
+	updateUserDetailsStmt = `INSERT INTO user_details (realm_id, user_id, details, doc_number_index, identity_index)
+	  VALUES (?, ?, ?, ?, ?) 
+	  ON DUPLICATE KEY UPDATE details=?, doc_number_index=?, identity_index=?;`
 	selectUserDetailsStmt = `
 	  SELECT details
 	  FROM user_details
 	  WHERE realm_id=?
 		AND user_id=?;`
-	deleteUserDetailsStmt = `DELETE FROM user_details WHERE realm_id=? AND user_id=?;`
-	createCheckStmt       = `INSERT INTO checks (realm_id, user_id, operator, datetime, status, type, nature, proof_type, proof_data, comment)
+	deleteUserDetailsStmt        = `DELETE FROM user_details WHERE realm_id=? AND user_id=?;`
+	selectUserIDsByDocNumberStmt = `
+	  SELECT user_id
+	  FROM user_details
+	  WHERE realm_id=?
+		AND doc_number_index=?;`
+	selectUserIDsByIdentityStmt = `
+	  SELECT user_id
+	  FROM user_details
+	  WHERE realm_id=?
+		AND identity_index=?;`
+	selectDuplicateDocNumbersStmt = `
+	  SELECT GROUP_CONCAT(user_id ORDER BY user_id)
+	  FROM user_details
+	  WHERE realm_id=?
+		AND doc_number_index IS NOT NULL
+	  GROUP BY doc_number_index
+	  HAVING COUNT(*)>1;`
+	selectDuplicateIdentitiesStmt = `
+	  SELECT GROUP_CONCAT(user_id ORDER BY user_id)
+	  FROM user_details
+	  WHERE realm_id=?
+		AND identity_index IS NOT NULL
+	  GROUP BY identity_index
+	  HAVING COUNT(*)>1;`
+	selectUserDetailsKeysStmt = `
+	  SELECT realm_id, user_id
+	  FROM user_details
+	  WHERE (realm_id, user_id) > (?, ?)
+	  ORDER BY realm_id, user_id
+	  LIMIT ?;`
+	createCheckStmt = `INSERT INTO checks (realm_id, user_id, operator, datetime, status, type, nature, proof_type, proof_data, comment)
 	  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
 	selectCheckStmt = `
 	  SELECT check_id, realm_id, user_id, operator, unix_timestamp(datetime), status, type, nature, proof_type, proof_data, comment
@@ -32,6 +62,102 @@ const (
 	  WHERE realm_id=?
 		AND user_id=?
 	  ORDER BY datetime DESC;`
+	upsertUserLockStmt = `INSERT INTO user_locks (realm_id, user_id, reason, comment, locked_by, lock_date, unlock_date)
+	  VALUES (?, ?, ?, ?, ?, ?, ?)
+	  ON DUPLICATE KEY UPDATE reason=?, comment=?, locked_by=?, lock_date=?, unlock_date=?;`
+	selectUserLockStmt = `
+	  SELECT realm_id, user_id, reason, comment, locked_by, unix_timestamp(lock_date), unix_timestamp(unlock_date)
+	  FROM user_locks
+	  WHERE realm_id=?
+		AND user_id=?;`
+	selectExpiredUserLocksStmt = `
+	  SELECT realm_id, user_id, reason, comment, locked_by, unix_timestamp(lock_date), unix_timestamp(unlock_date)
+	  FROM user_locks
+	  WHERE unlock_date<=?
+	  ORDER BY unlock_date
+	  LIMIT ?;`
+	deleteUserLockStmt      = `DELETE FROM user_locks WHERE realm_id=? AND user_id=?;`
+	upsertAccountExpiryStmt = `INSERT INTO account_expirations (realm_id, user_id, expiry_date, warning_date)
+	  VALUES (?, ?, ?, NULL)
+	  ON DUPLICATE KEY UPDATE expiry_date=?, warning_date=NULL;`
+	selectAccountExpiryStmt = `
+	  SELECT unix_timestamp(expiry_date)
+	  FROM account_expirations
+	  WHERE realm_id=?
+		AND user_id=?;`
+	selectExpiringAccountsStmt = `
+	  SELECT realm_id, user_id, unix_timestamp(expiry_date), unix_timestamp(warning_date)
+	  FROM account_expirations
+	  WHERE realm_id=?
+		AND expiry_date<=?
+		AND (warning_date IS NULL OR expiry_date<=?)
+	  ORDER BY expiry_date
+	  LIMIT ?;`
+	updateAccountExpiryWarningStmt = `UPDATE account_expirations SET warning_date=? WHERE realm_id=? AND user_id=?;`
+	deleteAccountExpiryStmt        = `DELETE FROM account_expirations WHERE realm_id=? AND user_id=?;`
+	upsertInactivityWarningStmt    = `INSERT INTO inactivity_warnings (realm_id, user_id, warning_date)
+	  VALUES (?, ?, ?)
+	  ON DUPLICATE KEY UPDATE warning_date=?;`
+	selectInactivityWarningStmt = `
+	  SELECT unix_timestamp(warning_date)
+	  FROM inactivity_warnings
+	  WHERE realm_id=?
+		AND user_id=?;`
+	deleteInactivityWarningStmt = `DELETE FROM inactivity_warnings WHERE realm_id=? AND user_id=?;`
+	upsertUserDeletionStmt      = `INSERT INTO user_deletions (realm_id, user_id, deleted_by, deletion_date, purge_date)
+	  VALUES (?, ?, ?, ?, ?)
+	  ON DUPLICATE KEY UPDATE deleted_by=?, deletion_date=?, purge_date=?;`
+	selectUserDeletionStmt = `
+	  SELECT realm_id, user_id, deleted_by, unix_timestamp(deletion_date), unix_timestamp(purge_date)
+	  FROM user_deletions
+	  WHERE realm_id=?
+		AND user_id=?;`
+	selectPurgeableUserDeletionsStmt = `
+	  SELECT realm_id, user_id, deleted_by, unix_timestamp(deletion_date), unix_timestamp(purge_date)
+	  FROM user_deletions
+	  WHERE purge_date<=?
+	  ORDER BY purge_date
+	  LIMIT ?;`
+	deleteUserDeletionStmt              = `DELETE FROM user_deletions WHERE realm_id=? AND user_id=?;`
+	insertAccreditationNotificationStmt = `INSERT IGNORE INTO accreditation_notifications (realm_id, user_id, accreditation_type, expiry_date, notification, notification_date)
+	  VALUES (?, ?, ?, ?, ?, ?);`
+	deleteAccreditationNotificationStmt = `
+	  DELETE FROM accreditation_notifications
+	  WHERE realm_id=?
+		AND user_id=?
+		AND accreditation_type=?
+		AND expiry_date=?
+		AND notification=?;`
+	insertAccreditationStmt = `INSERT INTO accreditations (realm_id, user_id, type, grant_date, expiry_date, check_id, operator)
+	  VALUES (?, ?, ?, ?, ?, ?, ?);`
+	revokeAccreditationsStmt = `
+	  UPDATE accreditations
+	  SET revocation_date=?, revocation_reason=?, revoked_by=?
+	  WHERE realm_id=?
+		AND user_id=?
+		AND revocation_date IS NULL
+		AND (expiry_date IS NULL OR expiry_date>?);`
+	revokeAccreditationsOfTypeStmt = `
+	  UPDATE accreditations
+	  SET revocation_date=?, revocation_reason=?, revoked_by=?
+	  WHERE realm_id=?
+		AND user_id=?
+		AND revocation_date IS NULL
+		AND (expiry_date IS NULL OR expiry_date>?)
+		AND type=?;`
+	selectAccreditationsStmt = `
+	  SELECT accreditation_id, realm_id, user_id, type, unix_timestamp(grant_date), unix_timestamp(expiry_date), check_id, operator,
+		unix_timestamp(revocation_date), revocation_reason, revoked_by
+	  FROM accreditations
+	  WHERE realm_id=?
+		AND user_id=?
+	  ORDER BY grant_date, accreditation_id;`
+	selectLastAccreditationIDStmt     = `SELECT MAX(accreditation_id) FROM accreditations WHERE realm_id=?;`
+	selectRevokedAccreditationIDsStmt = `
+	  SELECT accreditation_id
+	  FROM accreditations
+	  WHERE realm_id=?
+		AND revocation_date IS NOT NULL;`
 )
 
 // UsersDetailsDBModule interface
@@ -39,14 +165,40 @@ type UsersDetailsDBModule interface {
 	StoreOrUpdateUserDetails(ctx context.Context, realm string, user dto.DBUser) error
 	GetUserDetails(ctx context.Context, realm string, userID string) (dto.DBUser, error)
 	DeleteUserDetails(ctx context.Context, realm string, userID string) error
-	CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) error
+	CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) (int64, error)
 	GetChecks(ctx context.Context, realm string, userID string) ([]dto.DBCheck, error)
+	FindUserIDsByDocumentNumber(ctx context.Context, realm string, documentNumber string) ([]string, error)
+	FindUserIDsByIdentity(ctx context.Context, realm string, firstName, lastName, birthDate string) ([]string, error)
+	FindDuplicateUsers(ctx context.Context, realm string) ([]dto.DuplicateUsers, error)
+	GetUserDetailsKeys(ctx context.Context, after dto.DBUserKey, max int) ([]dto.DBUserKey, error)
+	StoreUserLock(ctx context.Context, lock dto.DBUserLock) error
+	GetUserLock(ctx context.Context, realm string, userID string) (*dto.DBUserLock, error)
+	DeleteUserLock(ctx context.Context, realm string, userID string) error
+	GetExpiredUserLocks(ctx context.Context, until time.Time, max int) ([]dto.DBUserLock, error)
+	StoreAccountExpiry(ctx context.Context, realm string, userID string, expiryDate *time.Time) error
+	GetAccountExpiry(ctx context.Context, realm string, userID string) (*time.Time, error)
+	GetExpiringAccounts(ctx context.Context, realm string, until time.Time, now time.Time, max int) ([]dto.DBAccountExpiry, error)
+	SetAccountExpiryWarned(ctx context.Context, realm string, userID string, warningDate time.Time) error
+	StoreInactivityWarning(ctx context.Context, realm string, userID string, warningDate time.Time) error
+	GetInactivityWarning(ctx context.Context, realm string, userID string) (*time.Time, error)
+	DeleteInactivityWarning(ctx context.Context, realm string, userID string) error
+	StoreUserDeletion(ctx context.Context, deletion dto.DBUserDeletion) error
+	GetUserDeletion(ctx context.Context, realm string, userID string) (*dto.DBUserDeletion, error)
+	DeleteUserDeletion(ctx context.Context, realm string, userID string) error
+	GetPurgeableUserDeletions(ctx context.Context, until time.Time, max int) ([]dto.DBUserDeletion, error)
+	ClaimAccreditationNotification(ctx context.Context, notification dto.DBAccreditationNotification) (bool, error)
+	ReleaseAccreditationNotification(ctx context.Context, notification dto.DBAccreditationNotification) error
+	CreateAccreditations(ctx context.Context, accreds []dto.DBAccreditation) error
+	RevokeAccreditations(ctx context.Context, revocation dto.DBAccreditationRevocation) error
+	GetAccreditations(ctx context.Context, realm string, userID string) ([]dto.DBAccreditation, error)
+	GetAccreditationsStatus(ctx context.Context, realm string) (dto.DBAccreditationsStatus, error)
 }
 
 type usersDBModule struct {
-	db     sqltypes.CloudtrustDB
-	cipher security.EncrypterDecrypter
-	logger log.Logger
+	db      sqltypes.CloudtrustDB
+	cipher  security.EncrypterDecrypter
+	indexer BlindIndexer
+	logger  log.Logger
 }
 
 func nullStringToPtr(value sql.NullString) *string {
@@ -66,11 +218,12 @@ func nullStringToDatePtr(value sql.NullString) *time.Time {
 }
 
 // NewUsersDetailsDBModule returns a UsersDB module.
-func NewUsersDetailsDBModule(db sqltypes.CloudtrustDB, cipher security.EncrypterDecrypter, logger log.Logger) UsersDetailsDBModule {
+func NewUsersDetailsDBModule(db sqltypes.CloudtrustDB, cipher security.EncrypterDecrypter, indexer BlindIndexer, logger log.Logger) UsersDetailsDBModule {
 	return &usersDBModule{
-		db:     db,
-		cipher: cipher,
-		logger: logger,
+		db:      db,
+		cipher:  cipher,
+		indexer: indexer,
+		logger:  logger,
 	}
 }
 
@@ -87,8 +240,12 @@ func (c *usersDBModule) StoreOrUpdateUserDetails(ctx context.Context, realm stri
 		return err
 	}
 
+	// compute the blind indexes. Identity index is removed when the identity is incomplete
+	var docNumberIndex = c.indexer.DocumentNumberIndex(user.IDDocumentNumber)
+	var identityIndex = c.indexer.IdentityIndex(user.FirstName, user.LastName, user.BirthDate)
+
 	// update value in DB
-	_, err = c.db.Exec(updateUserDetailsStmt, realm, user.UserID, encryptedData, encryptedData)
+	_, err = c.db.Exec(updateUserDetailsStmt, realm, user.UserID, encryptedData, docNumberIndex, identityIndex, encryptedData, docNumberIndex, identityIndex)
 	return err
 }
 
@@ -123,26 +280,108 @@ func (c *usersDBModule) DeleteUserDetails(ctx context.Context, realm string, use
 	return err
 }
 
-func (c *usersDBModule) CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) error {
+func (c *usersDBModule) FindUserIDsByDocumentNumber(ctx context.Context, realm string, documentNumber string) ([]string, error) {
+	var index = c.indexer.DocumentNumberIndex(&documentNumber)
+	if index == nil {
+		return []string{}, nil
+	}
+	return c.findUserIDs(selectUserIDsByDocNumberStmt, realm, *index)
+}
+
+func (c *usersDBModule) FindUserIDsByIdentity(ctx context.Context, realm string, firstName, lastName, birthDate string) ([]string, error) {
+	var index = c.indexer.IdentityIndex(&firstName, &lastName, &birthDate)
+	if index == nil {
+		return []string{}, nil
+	}
+	return c.findUserIDs(selectUserIDsByIdentityStmt, realm, *index)
+}
+
+func (c *usersDBModule) findUserIDs(query string, args ...interface{}) ([]string, error) {
+	var rows, err = c.db.Query(query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs = []string{}
+	var userID string
+	for rows.Next() {
+		if err = rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// FindDuplicateUsers returns the groups of users of the realm sharing the same identity document or the same identity
+func (c *usersDBModule) FindDuplicateUsers(ctx context.Context, realm string) ([]dto.DuplicateUsers, error) {
+	var res = []dto.DuplicateUsers{}
+	for _, search := range []struct {
+		criteria string
+		query    string
+	}{
+		{criteria: dto.DuplicateCriteriaDocumentNumber, query: selectDuplicateDocNumbersStmt},
+		{criteria: dto.DuplicateCriteriaIdentity, query: selectDuplicateIdentitiesStmt},
+	} {
+		var groups, err = c.findUserIDs(search.query, realm)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			res = append(res, dto.DuplicateUsers{Criteria: search.criteria, UserIDs: strings.Split(group, ",")})
+		}
+	}
+	return res, nil
+}
+
+func (c *usersDBModule) GetUserDetailsKeys(ctx context.Context, after dto.DBUserKey, max int) ([]dto.DBUserKey, error) {
+	var rows, err = c.db.Query(selectUserDetailsKeysStmt, after.RealmID, after.UserID, max)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []dto.DBUserKey
+	for rows.Next() {
+		var key dto.DBUserKey
+		if err = rows.Scan(&key.RealmID, &key.UserID); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// CreateCheck stores a check and returns its identifier
+func (c *usersDBModule) CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) (int64, error) {
 	var proofData *[]byte
-	var err error
 
 	if check.ProofData != nil {
 		// encrypt the proof data & protect integrity of userID associated to the proof data
 		encryptedData, err := c.cipher.Encrypt(*check.ProofData, []byte(userID))
 		if err != nil {
 			c.logger.Warn(ctx, "msg", "Can't encrypt the proof data", "error", err.Error(), "realmID", realm, "userID", userID)
-			return err
+			return 0, err
 		}
 		proofData = &encryptedData
 	}
 
 	// insert check in DB
-	_, err = c.db.Exec(createCheckStmt, realm, userID, check.Operator,
+	res, err := c.db.Exec(createCheckStmt, realm, userID, check.Operator,
 		check.DateTime, check.Status, check.Type, check.Nature,
 		check.ProofType, proofData, check.Comment)
+	if err != nil {
+		return 0, err
+	}
 
-	return err
+	return res.LastInsertId()
 }
 
 func (c *usersDBModule) GetChecks(ctx context.Context, realm string, userID string) ([]dto.DBCheck, error) {
@@ -192,3 +431,353 @@ func (c *usersDBModule) GetChecks(ctx context.Context, realm string, userID stri
 
 	return result, err
 }
+
+func (c *usersDBModule) StoreUserLock(ctx context.Context, lock dto.DBUserLock) error {
+	var comment []byte
+	if lock.Comment != nil {
+		// comments are free text written by operators: they may contain personal data
+		encryptedComment, err := c.cipher.Encrypt([]byte(*lock.Comment), []byte(lock.UserID))
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't encrypt the lock comment", "error", err.Error(), "realmID", lock.RealmID, "userID", lock.UserID)
+			return err
+		}
+		comment = encryptedComment
+	}
+
+	_, err := c.db.Exec(upsertUserLockStmt, lock.RealmID, lock.UserID, lock.Reason, comment, lock.LockedBy, lock.LockedAt, lock.UnlockAt,
+		lock.Reason, comment, lock.LockedBy, lock.LockedAt, lock.UnlockAt)
+	return err
+}
+
+func (c *usersDBModule) GetUserLock(ctx context.Context, realm string, userID string) (*dto.DBUserLock, error) {
+	var row = c.db.QueryRow(selectUserLockStmt, realm, userID)
+
+	var lock, err = c.scanUserLock(ctx, row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func (c *usersDBModule) DeleteUserLock(ctx context.Context, realm string, userID string) error {
+	_, err := c.db.Exec(deleteUserLockStmt, realm, userID)
+	return err
+}
+
+func (c *usersDBModule) GetExpiredUserLocks(ctx context.Context, until time.Time, max int) ([]dto.DBUserLock, error) {
+	var rows, err = c.db.Query(selectExpiredUserLocksStmt, until, max)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locks []dto.DBUserLock
+	for rows.Next() {
+		var lock dto.DBUserLock
+		if lock, err = c.scanUserLock(ctx, rows); err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return locks, rows.Err()
+}
+
+// StoreAccountExpiry sets the expiry date of a user account. A nil date removes the expiry
+func (c *usersDBModule) StoreAccountExpiry(ctx context.Context, realm string, userID string, expiryDate *time.Time) error {
+	var err error
+	if expiryDate == nil {
+		_, err = c.db.Exec(deleteAccountExpiryStmt, realm, userID)
+	} else {
+		_, err = c.db.Exec(upsertAccountExpiryStmt, realm, userID, *expiryDate, *expiryDate)
+	}
+	return err
+}
+
+func (c *usersDBModule) GetAccountExpiry(ctx context.Context, realm string, userID string) (*time.Time, error) {
+	return c.getDate(selectAccountExpiryStmt, realm, userID)
+}
+
+// GetExpiringAccounts returns the accounts of a realm expiring before the given limit which were not warned yet, and the accounts already expired
+func (c *usersDBModule) GetExpiringAccounts(ctx context.Context, realm string, until time.Time, now time.Time, max int) ([]dto.DBAccountExpiry, error) {
+	var rows, err = c.db.Query(selectExpiringAccountsStmt, realm, until, now, max)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expiries []dto.DBAccountExpiry
+	for rows.Next() {
+		var expiry dto.DBAccountExpiry
+		var expiryDate, warningDate sql.NullString
+		if err = rows.Scan(&expiry.RealmID, &expiry.UserID, &expiryDate, &warningDate); err != nil {
+			return nil, err
+		}
+		if date := nullStringToDatePtr(expiryDate); date != nil {
+			expiry.ExpiryDate = *date
+		}
+		expiry.WarningDate = nullStringToDatePtr(warningDate)
+		expiries = append(expiries, expiry)
+	}
+	return expiries, rows.Err()
+}
+
+func (c *usersDBModule) SetAccountExpiryWarned(ctx context.Context, realm string, userID string, warningDate time.Time) error {
+	_, err := c.db.Exec(updateAccountExpiryWarningStmt, warningDate, realm, userID)
+	return err
+}
+
+func (c *usersDBModule) StoreInactivityWarning(ctx context.Context, realm string, userID string, warningDate time.Time) error {
+	_, err := c.db.Exec(upsertInactivityWarningStmt, realm, userID, warningDate, warningDate)
+	return err
+}
+
+func (c *usersDBModule) GetInactivityWarning(ctx context.Context, realm string, userID string) (*time.Time, error) {
+	return c.getDate(selectInactivityWarningStmt, realm, userID)
+}
+
+func (c *usersDBModule) DeleteInactivityWarning(ctx context.Context, realm string, userID string) error {
+	_, err := c.db.Exec(deleteInactivityWarningStmt, realm, userID)
+	return err
+}
+
+func (c *usersDBModule) StoreUserDeletion(ctx context.Context, deletion dto.DBUserDeletion) error {
+	_, err := c.db.Exec(upsertUserDeletionStmt, deletion.RealmID, deletion.UserID, deletion.DeletedBy, deletion.DeletionDate, deletion.PurgeDate,
+		deletion.DeletedBy, deletion.DeletionDate, deletion.PurgeDate)
+	return err
+}
+
+func (c *usersDBModule) GetUserDeletion(ctx context.Context, realm string, userID string) (*dto.DBUserDeletion, error) {
+	var deletion, err = scanUserDeletion(c.db.QueryRow(selectUserDeletionStmt, realm, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+func (c *usersDBModule) DeleteUserDeletion(ctx context.Context, realm string, userID string) error {
+	_, err := c.db.Exec(deleteUserDeletionStmt, realm, userID)
+	return err
+}
+
+// GetPurgeableUserDeletions returns the soft deleted users whose purge date is reached
+func (c *usersDBModule) GetPurgeableUserDeletions(ctx context.Context, until time.Time, max int) ([]dto.DBUserDeletion, error) {
+	var rows, err = c.db.Query(selectPurgeableUserDeletionsStmt, until, max)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []dto.DBUserDeletion
+	for rows.Next() {
+		var deletion dto.DBUserDeletion
+		if deletion, err = scanUserDeletion(rows); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
+
+// ClaimAccreditationNotification records a notification about an accreditation. Returns false if the notification was already
+// recorded: as the primary key of accreditation_notifications covers the realm, the user, the accreditation type, its expiry date
+// and the notification, only one of the instances sharing the database claims a given notification
+func (c *usersDBModule) ClaimAccreditationNotification(ctx context.Context, notification dto.DBAccreditationNotification) (bool, error) {
+	res, err := c.db.Exec(insertAccreditationNotificationStmt, notification.RealmID, notification.UserID, notification.AccreditationType,
+		notification.ExpiryDate, notification.Notification, notification.NotificationDate)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ReleaseAccreditationNotification removes a claimed notification which could not be sent so that it is sent again later
+func (c *usersDBModule) ReleaseAccreditationNotification(ctx context.Context, notification dto.DBAccreditationNotification) error {
+	_, err := c.db.Exec(deleteAccreditationNotificationStmt, notification.RealmID, notification.UserID, notification.AccreditationType,
+		notification.ExpiryDate, notification.Notification)
+	return err
+}
+
+// CreateAccreditations records accreditations granted to users
+func (c *usersDBModule) CreateAccreditations(ctx context.Context, accreds []dto.DBAccreditation) error {
+	for _, accred := range accreds {
+		if _, err := c.db.Exec(insertAccreditationStmt, accred.RealmID, accred.UserID, accred.Type, accred.GrantDate, accred.ExpiryDate,
+			accred.CheckID, accred.Operator); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't store accreditation", "error", err.Error(), "realmID", accred.RealmID, "userID", accred.UserID,
+				"type", accred.Type)
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeAccreditations records the revocation of the accreditations of a user which are neither expired nor already revoked
+func (c *usersDBModule) RevokeAccreditations(ctx context.Context, revocation dto.DBAccreditationRevocation) error {
+	// reasons are free text written by operators: they may contain personal data
+	reason, err := c.cipher.Encrypt([]byte(revocation.Reason), []byte(revocation.UserID))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't encrypt the revocation reason", "error", err.Error(), "realmID", revocation.RealmID, "userID", revocation.UserID)
+		return err
+	}
+
+	if revocation.Type == nil {
+		_, err = c.db.Exec(revokeAccreditationsStmt, revocation.Date, reason, revocation.RevokedBy, revocation.RealmID, revocation.UserID,
+			revocation.Date)
+	} else {
+		_, err = c.db.Exec(revokeAccreditationsOfTypeStmt, revocation.Date, reason, revocation.RevokedBy, revocation.RealmID, revocation.UserID,
+			revocation.Date, *revocation.Type)
+	}
+	return err
+}
+
+// GetAccreditations returns all the accreditations ever granted to a user, the oldest first
+func (c *usersDBModule) GetAccreditations(ctx context.Context, realm string, userID string) ([]dto.DBAccreditation, error) {
+	var rows, err = c.db.Query(selectAccreditationsStmt, realm, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accreds []dto.DBAccreditation
+	for rows.Next() {
+		var accred dto.DBAccreditation
+		var grantDate, expiryDate, operator, revocationDate, revokedBy sql.NullString
+		var checkID sql.NullInt64
+		var encryptedReason []byte
+
+		if err = rows.Scan(&accred.ID, &accred.RealmID, &accred.UserID, &accred.Type, &grantDate, &expiryDate, &checkID, &operator,
+			&revocationDate, &encryptedReason, &revokedBy); err != nil {
+			return nil, err
+		}
+		if len(encryptedReason) != 0 {
+			reason, err := c.cipher.Decrypt(encryptedReason, []byte(accred.UserID))
+			if err != nil {
+				c.logger.Warn(ctx, "msg", "Can't decrypt the revocation reason", "error", err.Error(), "realmID", realm, "userID", userID)
+				return nil, err
+			}
+			var value = string(reason)
+			accred.RevocationReason = &value
+		}
+		if date := nullStringToDatePtr(grantDate); date != nil {
+			accred.GrantDate = *date
+		}
+		accred.ExpiryDate = nullStringToDatePtr(expiryDate)
+		if checkID.Valid {
+			accred.CheckID = &checkID.Int64
+		}
+		accred.Operator = nullStringToPtr(operator)
+		accred.RevocationDate = nullStringToDatePtr(revocationDate)
+		accred.RevokedBy = nullStringToPtr(revokedBy)
+		accreds = append(accreds, accred)
+	}
+	return accreds, rows.Err()
+}
+
+// GetAccreditationsStatus returns the greatest ID of the accreditations granted in a realm and the IDs of the revoked ones
+func (c *usersDBModule) GetAccreditationsStatus(ctx context.Context, realm string) (dto.DBAccreditationsStatus, error) {
+	var status dto.DBAccreditationsStatus
+	var lastID sql.NullInt64
+	if err := c.db.QueryRow(selectLastAccreditationIDStmt, realm).Scan(&lastID); err != nil && err != sql.ErrNoRows {
+		return status, err
+	}
+	status.LastID = lastID.Int64
+
+	var rows, err = c.db.Query(selectRevokedAccreditationIDsStmt, realm)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status, nil
+		}
+		return status, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return status, err
+		}
+		status.RevokedIDs = append(status.RevokedIDs, id)
+	}
+	return status, rows.Err()
+}
+
+func (c *usersDBModule) getDate(query string, realm string, userID string) (*time.Time, error) {
+	var date sql.NullString
+	var err = c.db.QueryRow(query, realm, userID).Scan(&date)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nullStringToDatePtr(date), nil
+}
+
+type scannable interface {
+	Scan(dest ...interface{}) error
+}
+
+func (c *usersDBModule) scanUserLock(ctx context.Context, row scannable) (dto.DBUserLock, error) {
+	var lock dto.DBUserLock
+	var lockedBy, lockDate, unlockDate sql.NullString
+	var encryptedComment []byte
+
+	if err := row.Scan(&lock.RealmID, &lock.UserID, &lock.Reason, &encryptedComment, &lockedBy, &lockDate, &unlockDate); err != nil {
+		return dto.DBUserLock{}, err
+	}
+
+	if len(encryptedComment) != 0 {
+		comment, err := c.cipher.Decrypt(encryptedComment, []byte(lock.UserID))
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't decrypt the lock comment", "error", err.Error(), "realmID", lock.RealmID, "userID", lock.UserID)
+			return dto.DBUserLock{}, err
+		}
+		var value = string(comment)
+		lock.Comment = &value
+	}
+	lock.LockedBy = nullStringToPtr(lockedBy)
+	if date := nullStringToDatePtr(lockDate); date != nil {
+		lock.LockedAt = *date
+	}
+	lock.UnlockAt = nullStringToDatePtr(unlockDate)
+
+	return lock, nil
+}
+
+func scanUserDeletion(row scannable) (dto.DBUserDeletion, error) {
+	var deletion dto.DBUserDeletion
+	var deletedBy, deletionDate, purgeDate sql.NullString
+
+	if err := row.Scan(&deletion.RealmID, &deletion.UserID, &deletedBy, &deletionDate, &purgeDate); err != nil {
+		return dto.DBUserDeletion{}, err
+	}
+	deletion.DeletedBy = nullStringToPtr(deletedBy)
+	if date := nullStringToDatePtr(deletionDate); date != nil {
+		deletion.DeletionDate = *date
+	}
+	if date := nullStringToDatePtr(purgeDate); date != nil {
+		deletion.PurgeDate = *date
+	}
+	return deletion, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/common-service/log"
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
@@ -14,6 +15,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func createBlindIndexer() BlindIndexer {
+	var indexer, _ = NewBlindIndexerFromBase64("oYP5DhsaW8dLtBt89i9cvXqz+zQTJBHWdFejLWLN/28=")
+	return indexer
+}
+
 func TestStoreOrUpdateUserDetails(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -23,28 +29,52 @@ func TestStoreOrUpdateUserDetails(t *testing.T) {
 	var userID = "123789"
 	t.Run("Update succesful", func(t *testing.T) {
 
-		mockDB.EXPECT().Exec(gomock.Any(), "realmId", &userID, gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		mockDB.EXPECT().Exec(gomock.Any(), "realmId", &userID, gomock.Any(), nil, nil, gomock.Any(), nil, nil).Return(nil, nil).Times(1)
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
 		var err = configDBModule.StoreOrUpdateUserDetails(context.Background(), "realmId", dto.DBUser{UserID: &userID})
 		assert.Nil(t, err)
 	})
 	t.Run("Update user: error at encryption", func(t *testing.T) {
 		var unexpectedError = errors.New("incorrect key")
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, unexpectedError).Times(1)
 		var err = configDBModule.StoreOrUpdateUserDetails(context.Background(), "realmId", dto.DBUser{UserID: &userID})
 		assert.Equal(t, unexpectedError, err)
 	})
 	t.Run("Update user: DB error", func(t *testing.T) {
 		var unexpectedError = errors.New("error")
-		mockDB.EXPECT().Exec(gomock.Any(), "realmId", &userID, gomock.Any(), gomock.Any()).Return(nil, unexpectedError).Times(1)
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		mockDB.EXPECT().Exec(gomock.Any(), "realmId", &userID, gomock.Any(), nil, nil, gomock.Any(), nil, nil).Return(nil, unexpectedError).Times(1)
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
 		var err = configDBModule.StoreOrUpdateUserDetails(context.Background(), "realmId", dto.DBUser{UserID: &userID})
 		assert.Equal(t, unexpectedError, err)
 	})
-
+	t.Run("Update user with blind indexes", func(t *testing.T) {
+		var indexer = createBlindIndexer()
+		var docNumber = "X123456"
+		var user = dto.DBUser{UserID: &userID, IDDocumentNumber: &docNumber, FirstName: ptr("John"), LastName: ptr("Doe"), BirthDate: ptr("25.12.1980")}
+		var docNumberIndex = indexer.DocumentNumberIndex(&docNumber)
+		var identityIndex = indexer.IdentityIndex(user.FirstName, user.LastName, user.BirthDate)
+
+		mockDB.EXPECT().Exec(gomock.Any(), "realmId", &userID, gomock.Any(), docNumberIndex, identityIndex, gomock.Any(), docNumberIndex, identityIndex).Return(nil, nil).Times(1)
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, indexer, log.NewNopLogger())
+		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+		var err = configDBModule.StoreOrUpdateUserDetails(context.Background(), "realmId", user)
+		assert.Nil(t, err)
+	})
+	t.Run("Update user with birth date cleared: identity index is removed", func(t *testing.T) {
+		var indexer = createBlindIndexer()
+		var docNumber = "X123456"
+		var user = dto.DBUser{UserID: &userID, IDDocumentNumber: &docNumber, FirstName: ptr("John"), LastName: ptr("Doe"), BirthDate: nil}
+		var docNumberIndex = indexer.DocumentNumberIndex(&docNumber)
+
+		mockDB.EXPECT().Exec(gomock.Any(), "realmId", &userID, gomock.Any(), docNumberIndex, nil, gomock.Any(), docNumberIndex, nil).Return(nil, nil).Times(1)
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, indexer, log.NewNopLogger())
+		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+		var err = configDBModule.StoreOrUpdateUserDetails(context.Background(), "realmId", user)
+		assert.Nil(t, err)
+	})
 }
 
 func TestGetUserDB(t *testing.T) {
@@ -64,7 +94,7 @@ func TestGetUserDB(t *testing.T) {
 		mockDB.EXPECT().QueryRow(gomock.Any(), realm, userID).Return(mockSQLRow)
 		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(unexpectedError)
 
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		var _, err = configDBModule.GetUserDetails(ctx, realm, userID)
 		assert.Equal(t, unexpectedError, err)
 	})
@@ -73,7 +103,7 @@ func TestGetUserDB(t *testing.T) {
 		mockDB.EXPECT().QueryRow(gomock.Any(), realm, userID).Return(mockSQLRow)
 		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
 
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		var user, err = configDBModule.GetUserDetails(ctx, realm, userID)
 		assert.Nil(t, err)
 		assert.NotNil(t, user)
@@ -87,7 +117,7 @@ func TestGetUserDB(t *testing.T) {
 			return nil
 		})
 		mockCrypter.EXPECT().Decrypt(gomock.Any(), gomock.Any()).Return(nil, unexpectedError).Times(1)
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		var _, err = configDBModule.GetUserDetails(ctx, realm, userID)
 		assert.Equal(t, unexpectedError, err)
 	})
@@ -100,7 +130,7 @@ func TestGetUserDB(t *testing.T) {
 			return nil
 		})
 		mockCrypter.EXPECT().Decrypt(gomock.Any(), gomock.Any()).Return([]byte(`{"birth_location": "Antananarivo"}`), nil).Times(1)
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		var user, err = configDBModule.GetUserDetails(ctx, realm, userID)
 		assert.Nil(t, err)
 		assert.Equal(t, "Antananarivo", *user.BirthLocation)
@@ -114,7 +144,7 @@ func TestGetUserInformation(t *testing.T) {
 	var mockDB = mock.NewCloudtrustDB(mockCtrl)
 	var mockSQLRows = mock.NewSQLRows(mockCtrl)
 	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
-	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 
 	var realm = "my-realm"
 	var userID = "user-id"
@@ -209,6 +239,314 @@ func TestGetUserInformation(t *testing.T) {
 	})
 }
 
+func TestFindUserIDs(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+	var indexer = createBlindIndexer()
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, indexer, log.NewNopLogger())
+
+	var realm = "my-realm"
+	var docNumber = "X123456"
+	var docNumberIndex = *indexer.DocumentNumberIndex(&docNumber)
+	var identityIndex = *indexer.IdentityIndex(ptr("John"), ptr("Doe"), ptr("25.12.1980"))
+	var ctx = context.TODO()
+	var unexpectedError = errors.New("unexpected")
+
+	t.Run("Empty document number", func(t *testing.T) {
+		var userIDs, err = usersDBModule.FindUserIDsByDocumentNumber(ctx, realm, " ")
+		assert.Nil(t, err)
+		assert.Len(t, userIDs, 0)
+	})
+
+	t.Run("Invalid birth date", func(t *testing.T) {
+		var userIDs, err = usersDBModule.FindUserIDsByIdentity(ctx, realm, "John", "Doe", "not a date")
+		assert.Nil(t, err)
+		assert.Len(t, userIDs, 0)
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		mockDB.EXPECT().Query(gomock.Any(), realm, docNumberIndex).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.FindUserIDsByDocumentNumber(ctx, realm, docNumber)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Can't fetch result", func(t *testing.T) {
+		mockDB.EXPECT().Query(gomock.Any(), realm, identityIndex).Return(mockSQLRows, nil)
+		mockSQLRows.EXPECT().Next().Return(true)
+		mockSQLRows.EXPECT().Scan(gomock.Any()).Return(unexpectedError)
+		mockSQLRows.EXPECT().Close()
+
+		var _, err = usersDBModule.FindUserIDsByIdentity(ctx, realm, "John", "Doe", "25.12.1980")
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(gomock.Any(), realm, identityIndex).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(userID *string) error {
+				*userID = "user-id"
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var userIDs, err = usersDBModule.FindUserIDsByIdentity(ctx, realm, " JOHN", "doe", "1980-12-25")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"user-id"}, userIDs)
+	})
+}
+
+func TestFindDuplicateUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
+
+	var realm = "my-realm"
+	var ctx = context.TODO()
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().Query(selectDuplicateDocNumbersStmt, realm).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.FindDuplicateUsers(ctx, realm)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var mockIdentityRows = mock.NewSQLRows(mockCtrl)
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectDuplicateDocNumbersStmt, realm).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(userIDs *string) error {
+				*userIDs = "user-a,user-b"
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+			mockDB.EXPECT().Query(selectDuplicateIdentitiesStmt, realm).Return(mockIdentityRows, nil),
+			mockIdentityRows.EXPECT().Next().Return(false),
+			mockIdentityRows.EXPECT().Err().Return(nil),
+			mockIdentityRows.EXPECT().Close(),
+		)
+
+		var duplicates, err = usersDBModule.FindDuplicateUsers(ctx, realm)
+		assert.Nil(t, err)
+		assert.Equal(t, []dto.DuplicateUsers{{Criteria: dto.DuplicateCriteriaDocumentNumber, UserIDs: []string{"user-a", "user-b"}}}, duplicates)
+	})
+}
+
+func TestGetUserDetailsKeys(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
+
+	var after = dto.DBUserKey{RealmID: "realm", UserID: "user-a"}
+	var ctx = context.TODO()
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().Query(gomock.Any(), after.RealmID, after.UserID, 10).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.GetUserDetailsKeys(ctx, after, 10)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(gomock.Any(), after.RealmID, after.UserID, 10).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(realmID *string, userID *string) error {
+				*realmID = "realm"
+				*userID = "user-b"
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var keys, err = usersDBModule.GetUserDetailsKeys(ctx, after, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []dto.DBUserKey{{RealmID: "realm", UserID: "user-b"}}, keys)
+	})
+}
+
+func TestStoreUserLock(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
+
+	var lockedAt = time.Now()
+	var lock = dto.DBUserLock{RealmID: "realm", UserID: "user-id", Reason: "FRAUD", LockedBy: ptr("agent"), LockedAt: lockedAt}
+	var encrypted = []byte("encrypted")
+	var ctx = context.TODO()
+
+	t.Run("Lock without comment", func(t *testing.T) {
+		var noComment []byte
+		mockDB.EXPECT().Exec(gomock.Any(), "realm", "user-id", "FRAUD", noComment, lock.LockedBy, lockedAt, lock.UnlockAt,
+			"FRAUD", noComment, lock.LockedBy, lockedAt, lock.UnlockAt).Return(nil, nil)
+		assert.Nil(t, usersDBModule.StoreUserLock(ctx, lock))
+	})
+
+	lock.Comment = ptr("comment")
+
+	t.Run("Can't encrypt comment", func(t *testing.T) {
+		var unexpectedError = errors.New("incorrect key")
+		mockCrypter.EXPECT().Encrypt([]byte("comment"), []byte("user-id")).Return(nil, unexpectedError)
+		assert.Equal(t, unexpectedError, usersDBModule.StoreUserLock(ctx, lock))
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		var unexpectedError = errors.New("error")
+		mockCrypter.EXPECT().Encrypt([]byte("comment"), []byte("user-id")).Return(encrypted, nil)
+		mockDB.EXPECT().Exec(gomock.Any(), "realm", "user-id", "FRAUD", encrypted, lock.LockedBy, lockedAt, lock.UnlockAt,
+			"FRAUD", encrypted, lock.LockedBy, lockedAt, lock.UnlockAt).Return(nil, unexpectedError)
+		assert.Equal(t, unexpectedError, usersDBModule.StoreUserLock(ctx, lock))
+	})
+}
+
+func TestGetUserLock(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
+
+	var realm = "my-realm"
+	var userID = "user-id"
+	var ctx = context.TODO()
+	var scanLock = func(dest ...interface{}) error {
+		*dest[0].(*string) = realm
+		*dest[1].(*string) = userID
+		*dest[2].(*string) = "FRAUD"
+		*dest[3].(*[]byte) = []byte("encrypted")
+		*dest[4].(*sql.NullString) = sql.NullString{Valid: true, String: "agent"}
+		*dest[5].(*sql.NullString) = sql.NullString{Valid: true, String: "1577836800.000000"}
+		*dest[6].(*sql.NullString) = sql.NullString{Valid: true, String: "1577923200.000000"}
+		return nil
+	}
+
+	t.Run("Not locked", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+
+		var lock, err = usersDBModule.GetUserLock(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Nil(t, lock)
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().QueryRow(gomock.Any(), realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(unexpectedError)
+
+		var _, err = usersDBModule.GetUserLock(ctx, realm, userID)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Can't decrypt comment", func(t *testing.T) {
+		var unexpectedError = errors.New("incorrect key")
+		mockDB.EXPECT().QueryRow(gomock.Any(), realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanLock)
+		mockCrypter.EXPECT().Decrypt([]byte("encrypted"), []byte(userID)).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.GetUserLock(ctx, realm, userID)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(gomock.Any(), realm, userID).Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanLock)
+		mockCrypter.EXPECT().Decrypt([]byte("encrypted"), []byte(userID)).Return([]byte("comment"), nil)
+
+		var lock, err = usersDBModule.GetUserLock(ctx, realm, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, "FRAUD", lock.Reason)
+		assert.Equal(t, "comment", *lock.Comment)
+		assert.Equal(t, "agent", *lock.LockedBy)
+		assert.Equal(t, int64(1577836800), lock.LockedAt.Unix())
+		assert.Equal(t, int64(1577923200), lock.UnlockAt.Unix())
+	})
+}
+
+func TestDeleteUserLock(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+	var unexpectedError = errors.New("unexpected")
+
+	mockDB.EXPECT().Exec(gomock.Any(), "realm", "user-id").Return(nil, unexpectedError)
+	assert.Equal(t, unexpectedError, usersDBModule.DeleteUserLock(context.TODO(), "realm", "user-id"))
+}
+
+func TestGetExpiredUserLocks(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+
+	var until = time.Now()
+	var ctx = context.TODO()
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().Query(gomock.Any(), until, 10).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.GetExpiredUserLocks(ctx, until, 10)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(gomock.Any(), until, 10).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+				*dest[0].(*string) = "realm"
+				*dest[1].(*string) = "user-id"
+				*dest[2].(*string) = "FRAUD"
+				*dest[5].(*sql.NullString) = sql.NullString{Valid: true, String: "1577836800"}
+				*dest[6].(*sql.NullString) = sql.NullString{Valid: true, String: "1577923200"}
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var locks, err = usersDBModule.GetExpiredUserLocks(ctx, until, 10)
+		assert.Nil(t, err)
+		assert.Len(t, locks, 1)
+		assert.Equal(t, "user-id", locks[0].UserID)
+		assert.Nil(t, locks[0].Comment)
+	})
+}
+
 func TestCreateCheck(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -221,26 +559,445 @@ func TestCreateCheck(t *testing.T) {
 	t.Run("Create check successful", func(t *testing.T) {
 
 		mockDB.EXPECT().Exec(gomock.Any(), realm, userID, gomock.Any(), gomock.Any(),
-			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(sqlResult{id: 42}, nil).Times(1)
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
-		var err = configDBModule.CreateCheck(context.Background(), realm, userID, dto.DBCheck{ProofData: &proofData})
+		var checkID, err = configDBModule.CreateCheck(context.Background(), realm, userID, dto.DBCheck{ProofData: &proofData})
 		assert.Nil(t, err)
+		assert.Equal(t, int64(42), checkID)
 	})
 	t.Run("Create check: error at encryption", func(t *testing.T) {
 		var unexpectedError = errors.New("incorrect key")
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, unexpectedError).Times(1)
-		var err = configDBModule.CreateCheck(context.Background(), realm, userID, dto.DBCheck{ProofData: &proofData})
+		var _, err = configDBModule.CreateCheck(context.Background(), realm, userID, dto.DBCheck{ProofData: &proofData})
 		assert.Equal(t, unexpectedError, err)
 	})
 	t.Run("Create check: DB error", func(t *testing.T) {
 		var unexpectedError = errors.New("error")
 		mockDB.EXPECT().Exec(gomock.Any(), realm, userID, gomock.Any(), gomock.Any(),
 			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, unexpectedError).Times(1)
-		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, log.NewNopLogger())
+		var configDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
 		mockCrypter.EXPECT().Encrypt(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
-		var err = configDBModule.CreateCheck(context.Background(), realm, userID, dto.DBCheck{ProofData: &proofData})
+		var _, err = configDBModule.CreateCheck(context.Background(), realm, userID, dto.DBCheck{ProofData: &proofData})
 		assert.Equal(t, unexpectedError, err)
 	})
 }
+
+func TestStoreAccountExpiry(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+	var expiry = time.Now()
+	var ctx = context.TODO()
+
+	t.Run("Remove expiry", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteAccountExpiryStmt, "realm", "user-id").Return(nil, nil)
+		assert.Nil(t, usersDBModule.StoreAccountExpiry(ctx, "realm", "user-id", nil))
+	})
+
+	t.Run("Set expiry", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().Exec(upsertAccountExpiryStmt, "realm", "user-id", expiry, expiry).Return(nil, unexpectedError)
+		assert.Equal(t, unexpectedError, usersDBModule.StoreAccountExpiry(ctx, "realm", "user-id", &expiry))
+	})
+}
+
+func TestGetAccountExpiry(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+	var ctx = context.TODO()
+
+	t.Run("No expiry", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAccountExpiryStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+
+		var date, err = usersDBModule.GetAccountExpiry(ctx, "realm", "user-id")
+		assert.Nil(t, err)
+		assert.Nil(t, date)
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().QueryRow(selectAccountExpiryStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(unexpectedError)
+
+		var _, err = usersDBModule.GetAccountExpiry(ctx, "realm", "user-id")
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectAccountExpiryStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+			*dest[0].(*sql.NullString) = sql.NullString{Valid: true, String: "1577836800.000000"}
+			return nil
+		})
+
+		var date, err = usersDBModule.GetAccountExpiry(ctx, "realm", "user-id")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1577836800), date.Unix())
+	})
+}
+
+func TestGetExpiringAccounts(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+
+	var now = time.Now()
+	var until = now.Add(48 * time.Hour)
+	var ctx = context.TODO()
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().Query(selectExpiringAccountsStmt, "realm", until, now, 10).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.GetExpiringAccounts(ctx, "realm", until, now, 10)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectExpiringAccountsStmt, "realm", until, now, 10).Return(nil, sql.ErrNoRows)
+
+		var expiries, err = usersDBModule.GetExpiringAccounts(ctx, "realm", until, now, 10)
+		assert.Nil(t, err)
+		assert.Len(t, expiries, 0)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectExpiringAccountsStmt, "realm", until, now, 10).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+				*dest[0].(*string) = "realm"
+				*dest[1].(*string) = "user-id"
+				*dest[2].(*sql.NullString) = sql.NullString{Valid: true, String: "1577836800"}
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var expiries, err = usersDBModule.GetExpiringAccounts(ctx, "realm", until, now, 10)
+		assert.Nil(t, err)
+		assert.Len(t, expiries, 1)
+		assert.Equal(t, "user-id", expiries[0].UserID)
+		assert.Equal(t, int64(1577836800), expiries[0].ExpiryDate.Unix())
+		assert.Nil(t, expiries[0].WarningDate)
+	})
+}
+
+func TestSetAccountExpiryWarned(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+	var now = time.Now()
+
+	mockDB.EXPECT().Exec(updateAccountExpiryWarningStmt, now, "realm", "user-id").Return(nil, nil)
+	assert.Nil(t, usersDBModule.SetAccountExpiryWarned(context.TODO(), "realm", "user-id", now))
+}
+
+func TestInactivityWarnings(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+	var now = time.Now()
+	var ctx = context.TODO()
+
+	t.Run("Store", func(t *testing.T) {
+		mockDB.EXPECT().Exec(upsertInactivityWarningStmt, "realm", "user-id", now, now).Return(nil, nil)
+		assert.Nil(t, usersDBModule.StoreInactivityWarning(ctx, "realm", "user-id", now))
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectInactivityWarningStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+
+		var date, err = usersDBModule.GetInactivityWarning(ctx, "realm", "user-id")
+		assert.Nil(t, err)
+		assert.Nil(t, date)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		var unexpectedError = errors.New("unexpected")
+		mockDB.EXPECT().Exec(deleteInactivityWarningStmt, "realm", "user-id").Return(nil, unexpectedError)
+		assert.Equal(t, unexpectedError, usersDBModule.DeleteInactivityWarning(ctx, "realm", "user-id"))
+	})
+}
+
+func TestUserDeletions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+
+	var now = time.Now()
+	var purgeDate = now.Add(30 * 24 * time.Hour)
+	var deletion = dto.DBUserDeletion{RealmID: "realm", UserID: "user-id", DeletedBy: ptr("agent"), DeletionDate: now, PurgeDate: purgeDate}
+	var unexpectedError = errors.New("unexpected")
+	var ctx = context.TODO()
+	var scanDeletion = func(dest ...interface{}) error {
+		*dest[0].(*string) = "realm"
+		*dest[1].(*string) = "user-id"
+		*dest[2].(*sql.NullString) = sql.NullString{Valid: true, String: "agent"}
+		*dest[3].(*sql.NullString) = sql.NullString{Valid: true, String: "1577836800.000000"}
+		*dest[4].(*sql.NullString) = sql.NullString{Valid: true, String: "1580428800.000000"}
+		return nil
+	}
+
+	t.Run("Store", func(t *testing.T) {
+		mockDB.EXPECT().Exec(upsertUserDeletionStmt, "realm", "user-id", deletion.DeletedBy, now, purgeDate, deletion.DeletedBy, now, purgeDate).Return(nil, nil)
+		assert.Nil(t, usersDBModule.StoreUserDeletion(ctx, deletion))
+	})
+
+	t.Run("Get: not deleted", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectUserDeletionStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(sql.ErrNoRows)
+
+		var res, err = usersDBModule.GetUserDeletion(ctx, "realm", "user-id")
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Get: unexpected error", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectUserDeletionStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(unexpectedError)
+
+		var _, err = usersDBModule.GetUserDeletion(ctx, "realm", "user-id")
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Get: success", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectUserDeletionStmt, "realm", "user-id").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(scanDeletion)
+
+		var res, err = usersDBModule.GetUserDeletion(ctx, "realm", "user-id")
+		assert.Nil(t, err)
+		assert.Equal(t, "agent", *res.DeletedBy)
+		assert.Equal(t, int64(1577836800), res.DeletionDate.Unix())
+		assert.Equal(t, int64(1580428800), res.PurgeDate.Unix())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteUserDeletionStmt, "realm", "user-id").Return(nil, unexpectedError)
+		assert.Equal(t, unexpectedError, usersDBModule.DeleteUserDeletion(ctx, "realm", "user-id"))
+	})
+
+	t.Run("Get purgeable: unexpected error", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectPurgeableUserDeletionsStmt, now, 10).Return(nil, unexpectedError)
+
+		var _, err = usersDBModule.GetPurgeableUserDeletions(ctx, now, 10)
+		assert.Equal(t, unexpectedError, err)
+	})
+
+	t.Run("Get purgeable: success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectPurgeableUserDeletionsStmt, now, 10).Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanDeletion),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var deletions, err = usersDBModule.GetPurgeableUserDeletions(ctx, now, 10)
+		assert.Nil(t, err)
+		assert.Len(t, deletions, 1)
+		assert.Equal(t, "user-id", deletions[0].UserID)
+	})
+}
+
+func TestAccreditationNotifications(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, nil, createBlindIndexer(), log.NewNopLogger())
+	var ctx = context.TODO()
+	var anyError = errors.New("any error")
+	var notification = dto.DBAccreditationNotification{
+		RealmID:           "realm",
+		UserID:            "user-id",
+		AccreditationType: "SHADOW",
+		ExpiryDate:        "31.12.2030",
+		Notification:      dto.AccreditationNotificationReminder,
+		NotificationDate:  time.Now(),
+	}
+
+	t.Run("Claim fails", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertAccreditationNotificationStmt, "realm", "user-id", "SHADOW", "31.12.2030", "REMINDER", notification.NotificationDate).Return(nil, anyError)
+		var _, err = usersDBModule.ClaimAccreditationNotification(ctx, notification)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Already claimed", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertAccreditationNotificationStmt, "realm", "user-id", "SHADOW", "31.12.2030", "REMINDER", notification.NotificationDate).Return(sqlResult{rows: 0}, nil)
+		var claimed, err = usersDBModule.ClaimAccreditationNotification(ctx, notification)
+		assert.Nil(t, err)
+		assert.False(t, claimed)
+	})
+	t.Run("Claimed", func(t *testing.T) {
+		mockDB.EXPECT().Exec(insertAccreditationNotificationStmt, "realm", "user-id", "SHADOW", "31.12.2030", "REMINDER", notification.NotificationDate).Return(sqlResult{rows: 1}, nil)
+		var claimed, err = usersDBModule.ClaimAccreditationNotification(ctx, notification)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+	})
+	t.Run("Release", func(t *testing.T) {
+		mockDB.EXPECT().Exec(deleteAccreditationNotificationStmt, "realm", "user-id", "SHADOW", "31.12.2030", "REMINDER").Return(nil, nil)
+		assert.Nil(t, usersDBModule.ReleaseAccreditationNotification(ctx, notification))
+	})
+}
+
+func TestAccreditations(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var mockCrypter = mock.NewEncrypterDecrypter(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mockCrypter, createBlindIndexer(), log.NewNopLogger())
+	var ctx = context.TODO()
+	var anyError = errors.New("any error")
+	var now = time.Now()
+	var checkID = int64(12)
+	var operator = "operator"
+	var accredType = "SHADOW"
+
+	t.Run("Create accreditations", func(t *testing.T) {
+		var accreds = []dto.DBAccreditation{
+			{RealmID: "realm", UserID: "user-id", Type: "SHADOW1", GrantDate: now, ExpiryDate: &now, CheckID: &checkID, Operator: &operator},
+			{RealmID: "realm", UserID: "user-id", Type: "SHADOW2", GrantDate: now},
+		}
+		mockDB.EXPECT().Exec(insertAccreditationStmt, "realm", "user-id", "SHADOW1", now, &now, &checkID, &operator).Return(nil, nil)
+		mockDB.EXPECT().Exec(insertAccreditationStmt, "realm", "user-id", "SHADOW2", now, nil, nil, nil).Return(nil, anyError)
+		assert.Equal(t, anyError, usersDBModule.CreateAccreditations(ctx, accreds))
+	})
+
+	t.Run("Revoke accreditations", func(t *testing.T) {
+		var revocation = dto.DBAccreditationRevocation{RealmID: "realm", UserID: "user-id", Date: now, Reason: "reason", RevokedBy: &operator}
+
+		mockCrypter.EXPECT().Encrypt([]byte("reason"), []byte("user-id")).Return(nil, anyError)
+		assert.Equal(t, anyError, usersDBModule.RevokeAccreditations(ctx, revocation))
+
+		mockCrypter.EXPECT().Encrypt([]byte("reason"), []byte("user-id")).Return([]byte("encrypted"), nil)
+		mockDB.EXPECT().Exec(revokeAccreditationsStmt, now, []byte("encrypted"), &operator, "realm", "user-id", now).Return(nil, nil)
+		assert.Nil(t, usersDBModule.RevokeAccreditations(ctx, revocation))
+
+		revocation.Type = &accredType
+		mockCrypter.EXPECT().Encrypt([]byte("reason"), []byte("user-id")).Return([]byte("encrypted"), nil)
+		mockDB.EXPECT().Exec(revokeAccreditationsOfTypeStmt, now, []byte("encrypted"), &operator, "realm", "user-id", now, accredType).Return(nil, anyError)
+		assert.Equal(t, anyError, usersDBModule.RevokeAccreditations(ctx, revocation))
+	})
+
+	t.Run("Get accreditations: query fails", func(t *testing.T) {
+		mockDB.EXPECT().Query(selectAccreditationsStmt, "realm", "user-id").Return(nil, anyError)
+		var _, err = usersDBModule.GetAccreditations(ctx, "realm", "user-id")
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Get accreditations", func(t *testing.T) {
+		var scanAccreditation = func(dest ...interface{}) error {
+			*(dest[0].(*int64)) = 3
+			*(dest[1].(*string)) = "realm"
+			*(dest[2].(*string)) = "user-id"
+			*(dest[3].(*string)) = accredType
+			*(dest[4].(*sql.NullString)) = sql.NullString{Valid: true, String: "1577836800"}
+			*(dest[5].(*sql.NullString)) = sql.NullString{Valid: true, String: "1640908800"}
+			*(dest[6].(*sql.NullInt64)) = sql.NullInt64{Valid: true, Int64: checkID}
+			*(dest[7].(*sql.NullString)) = sql.NullString{Valid: true, String: operator}
+			*(dest[8].(*sql.NullString)) = sql.NullString{Valid: true, String: "1593561600.000000"}
+			*(dest[9].(*[]byte)) = []byte("encrypted")
+			*(dest[10].(*sql.NullString)) = sql.NullString{Valid: true, String: "revoker"}
+			return nil
+		}
+		gomock.InOrder(
+			mockDB.EXPECT().Query(selectAccreditationsStmt, "realm", "user-id").Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(scanAccreditation),
+			mockCrypter.EXPECT().Decrypt([]byte("encrypted"), []byte("user-id")).Return([]byte("reason"), nil),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var accreds, err = usersDBModule.GetAccreditations(ctx, "realm", "user-id")
+		assert.Nil(t, err)
+		assert.Len(t, accreds, 1)
+		assert.Equal(t, int64(3), accreds[0].ID)
+		assert.Equal(t, int64(1577836800), accreds[0].GrantDate.Unix())
+		assert.Equal(t, int64(1640908800), accreds[0].ExpiryDate.Unix())
+		assert.Equal(t, checkID, *accreds[0].CheckID)
+		assert.Equal(t, operator, *accreds[0].Operator)
+		assert.Equal(t, int64(1593561600), accreds[0].RevocationDate.Unix())
+		assert.Equal(t, "reason", *accreds[0].RevocationReason)
+		assert.Equal(t, "revoker", *accreds[0].RevokedBy)
+	})
+}
+
+func TestGetAccreditationsStatus(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockDB = mock.NewCloudtrustDB(mockCtrl)
+	var mockSQLRow = mock.NewSQLRow(mockCtrl)
+	var mockSQLRows = mock.NewSQLRows(mockCtrl)
+	var usersDBModule = NewUsersDetailsDBModule(mockDB, mock.NewEncrypterDecrypter(mockCtrl), createBlindIndexer(), log.NewNopLogger())
+	var ctx = context.TODO()
+	var anyError = errors.New("any error")
+
+	t.Run("Can't get last accreditation ID", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectLastAccreditationIDStmt, "realm").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(anyError)
+		var _, err = usersDBModule.GetAccreditationsStatus(ctx, "realm")
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't get revoked accreditations", func(t *testing.T) {
+		mockDB.EXPECT().QueryRow(selectLastAccreditationIDStmt, "realm").Return(mockSQLRow)
+		mockSQLRow.EXPECT().Scan(gomock.Any()).Return(nil)
+		mockDB.EXPECT().Query(selectRevokedAccreditationIDsStmt, "realm").Return(nil, anyError)
+		var _, err = usersDBModule.GetAccreditationsStatus(ctx, "realm")
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		gomock.InOrder(
+			mockDB.EXPECT().QueryRow(selectLastAccreditationIDStmt, "realm").Return(mockSQLRow),
+			mockSQLRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+				*(dest[0].(*sql.NullInt64)) = sql.NullInt64{Valid: true, Int64: 42}
+				return nil
+			}),
+			mockDB.EXPECT().Query(selectRevokedAccreditationIDsStmt, "realm").Return(mockSQLRows, nil),
+			mockSQLRows.EXPECT().Next().Return(true),
+			mockSQLRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...interface{}) error {
+				*(dest[0].(*int64)) = 7
+				return nil
+			}),
+			mockSQLRows.EXPECT().Next().Return(false),
+			mockSQLRows.EXPECT().Err().Return(nil),
+			mockSQLRows.EXPECT().Close(),
+		)
+
+		var status, err = usersDBModule.GetAccreditationsStatus(ctx, "realm")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(42), status.LastID)
+		assert.Equal(t, []int64{7}, status.RevokedIDs)
+	})
+}
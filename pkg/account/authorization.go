@@ -26,6 +26,7 @@ const (
 	UpdateAccount             = "UpdateAccount"
 	DeleteAccount             = "DeleteAccount"
 	GetConfiguration          = "GetConfiguration"
+	CheckPassword             = "CheckPassword"
 
 	infosAction       = "Action"
 	infosCurrentRealm = "currentRealm"
@@ -51,7 +52,23 @@ func MakeAuthorizationAccountComponentMW(logger log.Logger, configDBModule keycl
 
 // authorizationComponentMW implements Component.
 func (c *authorizationComponentMW) UpdatePassword(ctx context.Context, currentPassword, newPassword, confirmPassword string) error {
-	var action = UpdatePassword
+	if err := c.checkPasswordChangeEnabled(ctx, UpdatePassword); err != nil {
+		return err
+	}
+
+	return c.next.UpdatePassword(ctx, currentPassword, newPassword, confirmPassword)
+}
+
+func (c *authorizationComponentMW) CheckPassword(ctx context.Context, password string) (api.PasswordCheckRepresentation, error) {
+	if err := c.checkPasswordChangeEnabled(ctx, CheckPassword); err != nil {
+		return api.PasswordCheckRepresentation{}, err
+	}
+
+	return c.next.CheckPassword(ctx, password)
+}
+
+// checkPasswordChangeEnabled returns an error if the users of the current realm are not allowed to change their password
+func (c *authorizationComponentMW) checkPasswordChangeEnabled(ctx context.Context, action string) error {
 	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
 
 	var config = configuration.RealmConfiguration{}
@@ -73,8 +90,7 @@ func (c *authorizationComponentMW) UpdatePassword(ctx context.Context, currentPa
 		c.logger.Debug(ctx, "ForbiddenError", "Password change disabled", "infos", string(infos))
 		return security.ForbiddenError{}
 	}
-
-	return c.next.UpdatePassword(ctx, currentPassword, newPassword, confirmPassword)
+	return nil
 }
 
 // authorizationComponentMW implements Component.
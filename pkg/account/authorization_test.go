@@ -121,6 +121,10 @@ func TestDeny(t *testing.T) {
 		err = authorizationMW.UpdatePassword(ctx, "currentPassword", "newPassword", "newPAssword")
 		assert.Equal(t, security.ForbiddenError{}, err)
 	})
+	t.Run("CheckPassword not allowed", func(t *testing.T) {
+		_, err = authorizationMW.CheckPassword(ctx, "newPassword")
+		assert.Equal(t, security.ForbiddenError{}, err)
+	})
 	t.Run("DeleteCredential not allowed", func(t *testing.T) {
 		err = authorizationMW.DeleteCredential(ctx, credentialID)
 		assert.Equal(t, security.ForbiddenError{}, err)
@@ -173,6 +177,10 @@ func TestAllowed(t *testing.T) {
 		err = authorizationMW.UpdatePassword(ctx, "currentPassword", "newPassword", "newPAssword")
 		assert.Nil(t, err)
 
+		mockAccountComponent.EXPECT().CheckPassword(ctx, "newPassword").Return(api.PasswordCheckRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.CheckPassword(ctx, "newPassword")
+		assert.Nil(t, err)
+
 		mockAccountComponent.EXPECT().DeleteCredential(ctx, credentialID).Return(nil).Times(1)
 		err = authorizationMW.DeleteCredential(ctx, credentialID)
 		assert.Nil(t, err)
@@ -217,6 +225,9 @@ func TestError(t *testing.T) {
 		err = authorizationMW.UpdatePassword(ctx, "currentPassword", "newPassword", "newPAssword")
 		assert.NotNil(t, err)
 
+		_, err = authorizationMW.CheckPassword(ctx, "newPassword")
+		assert.NotNil(t, err)
+
 		err = authorizationMW.DeleteCredential(ctx, credentialID)
 		assert.NotNil(t, err)
 
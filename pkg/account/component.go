@@ -5,8 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
-
-	"github.com/cloudtrust/common-service/configuration"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	"github.com/cloudtrust/common-service/database"
@@ -62,12 +61,14 @@ type Component interface {
 	GetConfiguration(context.Context, string) (api.Configuration, error)
 	SendVerifyEmail(ctx context.Context) error
 	SendVerifyPhoneNumber(ctx context.Context) error
+	CheckPassword(ctx context.Context, password string) (api.PasswordCheckRepresentation, error)
 }
 
 // UsersDetailsDBModule is the minimum required interface to access the users database
 type UsersDetailsDBModule interface {
 	StoreOrUpdateUserDetails(ctx context.Context, realm string, user dto.DBUser) error
 	GetUserDetails(ctx context.Context, realm string, userID string) (dto.DBUser, error)
+	GetAccountExpiry(ctx context.Context, realm string, userID string) (*time.Time, error)
 }
 
 // Component is the management component.
@@ -76,16 +77,23 @@ type component struct {
 	eventDBModule         database.EventsDBModule
 	configDBModule        keycloakb.ConfigurationDBModule
 	usersDBModule         UsersDetailsDBModule
+	accredsModule         keycloakb.AccreditationsModule
+	passwordPolicyModule  keycloakb.PasswordPolicyModule
+	breachedPwdModule     keycloakb.BreachedPasswordModule
 	logger                internal.Logger
 }
 
 // NewComponent returns the self-service component.
-func NewComponent(keycloakAccountClient KeycloakAccountClient, eventDBModule database.EventsDBModule, configDBModule keycloakb.ConfigurationDBModule, usersDBModule UsersDetailsDBModule, logger internal.Logger) Component {
+func NewComponent(keycloakAccountClient KeycloakAccountClient, eventDBModule database.EventsDBModule, configDBModule keycloakb.ConfigurationDBModule, usersDBModule UsersDetailsDBModule,
+	accredsModule keycloakb.AccreditationsModule, passwordPolicyModule keycloakb.PasswordPolicyModule, breachedPwdModule keycloakb.BreachedPasswordModule, logger internal.Logger) Component {
 	return &component{
 		keycloakAccountClient: keycloakAccountClient,
 		eventDBModule:         eventDBModule,
 		configDBModule:        configDBModule,
 		usersDBModule:         usersDBModule,
+		accredsModule:         accredsModule,
+		passwordPolicyModule:  passwordPolicyModule,
+		breachedPwdModule:     breachedPwdModule,
 		logger:                logger,
 	}
 }
@@ -111,7 +119,19 @@ func (c *component) UpdatePassword(ctx context.Context, currentPassword, newPass
 		}
 	}
 
-	_, err := c.keycloakAccountClient.UpdatePassword(accessToken, realm, currentPassword, newPassword, confirmPassword)
+	// the password is checked before calling Keycloak to explain all the rules it does not respect
+	policy, email, err := c.getPasswordPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if err = policy.CheckPassword(newPassword, username, email); err != nil {
+		return err
+	}
+	if err = c.checkBreachedPassword(ctx, newPassword); err != nil {
+		return err
+	}
+
+	_, err = c.keycloakAccountClient.UpdatePassword(accessToken, realm, currentPassword, newPassword, confirmPassword)
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
 		return err
@@ -129,6 +149,74 @@ func (c *component) UpdatePassword(ctx context.Context, currentPassword, newPass
 	return nil
 }
 
+func (c *component) CheckPassword(ctx context.Context, password string) (api.PasswordCheckRepresentation, error) {
+	var realm = ctx.Value(cs.CtContextRealm).(string)
+	var username = ctx.Value(cs.CtContextUsername).(string)
+
+	policy, email, err := c.getPasswordPolicy(ctx)
+	if err != nil {
+		return api.PasswordCheckRepresentation{}, err
+	}
+
+	var violations = policy.Evaluate(password, username, email)
+	if violations == nil {
+		violations = []string{}
+	}
+	breached, err := c.breachedPwdModule.IsBreached(ctx, realm, password)
+	if err != nil {
+		return api.PasswordCheckRepresentation{}, err
+	}
+	if breached {
+		violations = append(violations, keycloakb.BreachedPasswordViolation)
+	}
+	var valid = len(violations) == 0
+	return api.PasswordCheckRepresentation{
+		Valid:      &valid,
+		Violations: violations,
+	}, nil
+}
+
+// checkBreachedPassword rejects a breached password chosen by the connected user. Realm and user are read from the context
+func (c *component) checkBreachedPassword(ctx context.Context, password string) error {
+	var realm = ctx.Value(cs.CtContextRealm).(string)
+	var userID = ctx.Value(cs.CtContextUserID).(string)
+	var username = ctx.Value(cs.CtContextUsername).(string)
+
+	breached, err := c.breachedPwdModule.IsBreached(ctx, realm, password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		c.reportEvent(ctx, "BREACHED_PASSWORD_REJECTED", database.CtEventRealmName, realm, database.CtEventUserID, userID, database.CtEventUsername, username)
+		return keycloakb.CreateBreachedPasswordError()
+	}
+	return nil
+}
+
+// getPasswordPolicy returns the password policy of the realm of the user and, if the policy needs it, the email of the user
+func (c *component) getPasswordPolicy(ctx context.Context) (keycloakb.PasswordPolicy, string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var realm = ctx.Value(cs.CtContextRealm).(string)
+
+	policy, err := c.passwordPolicyModule.GetPasswordPolicy(ctx, realm)
+	if err != nil {
+		return keycloakb.PasswordPolicy{}, "", err
+	}
+	if !policy.NotEmail {
+		return policy, "", nil
+	}
+
+	userKc, err := c.keycloakAccountClient.GetAccount(accessToken, realm)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return keycloakb.PasswordPolicy{}, "", err
+	}
+	if userKc.Email == nil {
+		return policy, "", nil
+	}
+	return policy, *userKc.Email, nil
+}
+
 func (c *component) GetAccount(ctx context.Context) (api.AccountRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	var realm = ctx.Value(cs.CtContextRealm).(string)
@@ -157,6 +245,15 @@ func (c *component) GetAccount(ctx context.Context) (api.AccountRepresentation,
 	userRep.IDDocumentExpiration = dbUser.IDDocumentExpiration
 	userRep.IDDocumentCountry = dbUser.IDDocumentCountry
 
+	accountExpiry, err := c.usersDBModule.GetAccountExpiry(ctx, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get account expiry", "err", err.Error())
+		return api.AccountRepresentation{}, err
+	}
+
+	var etag = keycloakb.ComputeUserETag(userKc, dbUser, accountExpiry)
+	userRep.ETag = &etag
+
 	return userRep, nil
 }
 
@@ -164,6 +261,31 @@ func isUpdated(newValue *string, oldValue *string) bool {
 	return newValue != nil && (oldValue == nil || *newValue != *oldValue)
 }
 
+// isCleared returns true if at least one of the given fields having a value is removed
+func isCleared(clearedFields []string, formerValues map[string]*string) bool {
+	for _, field := range clearedFields {
+		if formerValues[field] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// removeClearedAttributes removes from the Keycloak attributes those cleared by a JSON merge patch
+func removeClearedAttributes(attributes kc.Attributes, clearedFields []string) {
+	var clearedAttributes = map[string][]kc.AttributeKey{
+		api.FieldPhoneNumber: {constants.AttrbPhoneNumber, constants.AttrbPhoneNumberVerified},
+		api.FieldGender:      {constants.AttrbGender},
+		api.FieldBirthDate:   {constants.AttrbBirthDate},
+		api.FieldLocale:      {constants.AttrbLocale},
+	}
+	for _, field := range clearedFields {
+		for _, key := range clearedAttributes[field] {
+			delete(attributes, key)
+		}
+	}
+}
+
 func (c *component) UpdateAccount(ctx context.Context, user api.AccountRepresentation) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	var realm = ctx.Value(cs.CtContextRealm).(string)
@@ -186,6 +308,18 @@ func (c *component) UpdateAccount(ctx context.Context, user api.AccountRepresent
 		return err
 	}
 
+	accountExpiry, err := c.usersDBModule.GetAccountExpiry(ctx, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get account expiry", "err", err.Error())
+		return err
+	}
+
+	// the account must not have been modified since it was read by the user
+	if err = keycloakb.CheckUserETag(user.ETag, oldUserKc, oldUser, accountExpiry); err != nil {
+		c.logger.Warn(ctx, "msg", "Account has been modified since it was read", "realm", realm, "userID", userID)
+		return err
+	}
+
 	var emailVerified, phoneNumberVerified *bool
 	var actions []string
 
@@ -199,11 +333,24 @@ func (c *component) UpdateAccount(ctx context.Context, user api.AccountRepresent
 		user.IDDocumentNumber, oldUser.IDDocumentNumber,
 		user.IDDocumentExpiration, oldUser.IDDocumentExpiration,
 		user.IDDocumentCountry, oldUser.IDDocumentCountry,
-	)
+	) || isCleared(user.ClearedFields, map[string]*string{
+		api.FieldGender:               oldUserKc.GetAttributeString(constants.AttrbGender),
+		api.FieldBirthDate:            oldUserKc.GetAttributeString(constants.AttrbBirthDate),
+		api.FieldBirthLocation:        oldUser.BirthLocation,
+		api.FieldNationality:          oldUser.Nationality,
+		api.FieldIDDocumentType:       oldUser.IDDocumentType,
+		api.FieldIDDocumentNumber:     oldUser.IDDocumentNumber,
+		api.FieldIDDocumentExpiration: oldUser.IDDocumentExpiration,
+		api.FieldIDDocumentCountry:    oldUser.IDDocumentCountry,
+	})
 
 	// profileUpdated: Add here all fields which are not accreditation-dependant...
 	// phone number is not added here as there is already an email sent for phone number verification
-	var profileUpdated = revokeAccreditations || keycloakb.IsUpdated(user.Locale, oldUserKc.GetAttributeString(constants.AttrbLocale))
+	var profileUpdated = revokeAccreditations || keycloakb.IsUpdated(user.Locale, oldUserKc.GetAttributeString(constants.AttrbLocale)) ||
+		isCleared(user.ClearedFields, map[string]*string{
+			api.FieldLocale:      oldUserKc.GetAttributeString(constants.AttrbLocale),
+			api.FieldPhoneNumber: oldUserKc.GetAttributeString(constants.AttrbPhoneNumber),
+		})
 	var prevEmail *string
 
 	// when the email changes, set the EmailVerified to false
@@ -237,10 +384,14 @@ func (c *component) UpdateAccount(ctx context.Context, user api.AccountRepresent
 	mergedAttributes.SetStringWhenNotNil(constants.AttrbGender, user.Gender)
 	mergedAttributes.SetDateWhenNotNil(constants.AttrbBirthDate, user.BirthDate, constants.SupportedDateLayouts)
 	mergedAttributes.SetStringWhenNotNil(constants.AttrbLocale, user.Locale)
+	removeClearedAttributes(mergedAttributes, user.ClearedFields)
 
 	userRep.Attributes = &mergedAttributes
 	if revokeAccreditations {
 		keycloakb.RevokeAccreditations(&userRep)
+		if err = c.accredsModule.RevokeAccreditations(ctx, realm, userID, dto.AccreditationRevocationIdentityUpdated, username); err != nil {
+			return err
+		}
 	}
 
 	err = c.keycloakAccountClient.UpdateAccount(accessToken, realm, userRep)
@@ -249,8 +400,19 @@ func (c *component) UpdateAccount(ctx context.Context, user api.AccountRepresent
 		return err
 	}
 
-	// store the API call into the DB - As user is partially update, report event even if database update fails
-	c.reportEvent(ctx, "UPDATE_ACCOUNT", database.CtEventRealmName, realm, database.CtEventUserID, userID, database.CtEventUsername, username)
+	var dbUser = c.mergeUser(userID, user, oldUser)
+	dbUser.SetIdentity(userRep)
+	if dbUser.FirstName == nil {
+		dbUser.FirstName = oldUserKc.FirstName
+	}
+	if dbUser.LastName == nil {
+		dbUser.LastName = oldUserKc.LastName
+	}
+
+	// store the API call and the changes of the account into the DB - As user is partially update, report event even if database update fails
+	var changes = append(keycloakb.DiffKeycloakUsers(oldUserKc, userRep), keycloakb.DiffUserDetails(oldUser, dbUser)...)
+	c.reportEvent(ctx, "UPDATE_ACCOUNT", database.CtEventRealmName, realm, database.CtEventUserID, userID, database.CtEventUsername, username,
+		database.CtEventAdditionalInfo, keycloakb.CreateChangesAdditionalInfo(changes))
 
 	if len(actions) > 0 {
 		err = c.executeActions(ctx, actions)
@@ -261,8 +423,6 @@ func (c *component) UpdateAccount(ctx context.Context, user api.AccountRepresent
 		}
 	}
 
-	var dbUser = c.mergeUser(userID, user, oldUser)
-
 	err = c.usersDBModule.StoreOrUpdateUserDetails(ctx, realm, dbUser)
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
@@ -312,23 +472,23 @@ func (c *component) mergeUser(userID string, user api.AccountRepresentation, old
 		IDDocumentCountry:    user.IDDocumentCountry,
 	}
 
-	// Keep old values when none was provided
-	if dbUser.BirthLocation == nil {
+	// Keep old values when none was provided, unless they are removed
+	if dbUser.BirthLocation == nil && !keycloakb.IsCleared(user.ClearedFields, api.FieldBirthLocation) {
 		dbUser.BirthLocation = oldUser.BirthLocation
 	}
-	if dbUser.Nationality == nil {
+	if dbUser.Nationality == nil && !keycloakb.IsCleared(user.ClearedFields, api.FieldNationality) {
 		dbUser.Nationality = oldUser.Nationality
 	}
-	if dbUser.IDDocumentType == nil {
+	if dbUser.IDDocumentType == nil && !keycloakb.IsCleared(user.ClearedFields, api.FieldIDDocumentType) {
 		dbUser.IDDocumentType = oldUser.IDDocumentType
 	}
-	if dbUser.IDDocumentNumber == nil {
+	if dbUser.IDDocumentNumber == nil && !keycloakb.IsCleared(user.ClearedFields, api.FieldIDDocumentNumber) {
 		dbUser.IDDocumentNumber = oldUser.IDDocumentNumber
 	}
-	if dbUser.IDDocumentExpiration == nil {
+	if dbUser.IDDocumentExpiration == nil && !keycloakb.IsCleared(user.ClearedFields, api.FieldIDDocumentExpiration) {
 		dbUser.IDDocumentExpiration = oldUser.IDDocumentExpiration
 	}
-	if dbUser.IDDocumentCountry == nil {
+	if dbUser.IDDocumentCountry == nil && !keycloakb.IsCleared(user.ClearedFields, api.FieldIDDocumentCountry) {
 		dbUser.IDDocumentCountry = oldUser.IDDocumentCountry
 	}
 
@@ -338,8 +498,15 @@ func (c *component) mergeUser(userID string, user api.AccountRepresentation, old
 func (c *component) DeleteAccount(ctx context.Context) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	var realm = ctx.Value(cs.CtContextRealm).(string)
+	var userID = ctx.Value(cs.CtContextUserID).(string)
+	var username = ctx.Value(cs.CtContextUsername).(string)
+
+	err := c.accredsModule.RevokeAccreditations(ctx, realm, userID, dto.AccreditationRevocationUserDeleted, username)
+	if err != nil {
+		return err
+	}
 
-	err := c.keycloakAccountClient.DeleteAccount(accessToken, realm)
+	err = c.keycloakAccountClient.DeleteAccount(accessToken, realm)
 
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
@@ -463,7 +630,7 @@ func (c *component) GetConfiguration(ctx context.Context, realmIDOverride string
 		return api.Configuration{}, err
 	}
 
-	var adminConfig configuration.RealmAdminConfiguration
+	var adminConfig dto.RealmAdminConfiguration
 	adminConfig, err = c.configDBModule.GetAdminConfiguration(ctx, currentRealm)
 	if err != nil {
 		return api.Configuration{}, err
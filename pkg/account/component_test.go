@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	cs "github.com/cloudtrust/common-service"
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
 	"github.com/cloudtrust/common-service/log"
 	account_api "github.com/cloudtrust/keycloak-bridge/api/account"
 	api "github.com/cloudtrust/keycloak-bridge/api/account"
@@ -21,6 +23,7 @@ import (
 
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 )
 
 func TestUpdatePassword(t *testing.T) {
@@ -31,8 +34,10 @@ func TestUpdatePassword(t *testing.T) {
 	mockEventDBModule := mock.NewEventsDBModule(mockCtrl)
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
+	mockPasswordPolicyModule := mock.NewPasswordPolicyModule(mockCtrl)
+	mockBreachedPwdModule := mock.NewBreachedPasswordModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockPasswordPolicyModule, mockBreachedPwdModule, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -59,10 +64,66 @@ func TestUpdatePassword(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("Update password: can't get the password policy", func(t *testing.T) {
+		newPasswd := "a p@55w0rd"
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{}, errors.New("error")).Times(1)
+
+		err := component.UpdatePassword(ctx, "prev10u5", newPasswd, newPasswd)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Update password: can't get the email of the user", func(t *testing.T) {
+		newPasswd := "a p@55w0rd"
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{NotEmail: true}, nil).Times(1)
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realm).Return(kc.UserRepresentation{}, errors.New("error")).Times(1)
+
+		err := component.UpdatePassword(ctx, "prev10u5", newPasswd, newPasswd)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Update password: policy not respected", func(t *testing.T) {
+		var email = "john.doe@elca.ch"
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{Length: 20, NotEmail: true}, nil).Times(1)
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realm).Return(kc.UserRepresentation{Email: &email}, nil).Times(1)
+
+		err := component.UpdatePassword(ctx, "prev10u5", email, email)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Equal(t, "keycloak-bridge.invalidParameter.password.length.20,keycloak-bridge.invalidParameter.password.notEmail", err.(errorhandler.Error).Message)
+	})
+
+	t.Run("Update password: can't check breached passwords", func(t *testing.T) {
+		newPasswd := "a p@55w0rd"
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, newPasswd).Return(false, errors.New("error")).Times(1)
+
+		err := component.UpdatePassword(ctx, "prev10u5", newPasswd, newPasswd)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Update password: breached password", func(t *testing.T) {
+		newPasswd := "P@ssw0rd"
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, newPasswd).Return(true, nil).Times(1)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "BREACHED_PASSWORD_REJECTED", "self-service", database.CtEventRealmName, realm, database.CtEventUserID, userID, database.CtEventUsername, username).Return(nil).Times(1)
+
+		err := component.UpdatePassword(ctx, "prev10u5", newPasswd, newPasswd)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Equal(t, "keycloak-bridge.invalidParameter.password.breached", err.(errorhandler.Error).Message)
+	})
+
 	t.Run("Update password: success", func(t *testing.T) {
 		oldPasswd := "prev10u5"
 		newPasswd := "a p@55w0rd"
 		confirmPasswd := "a p@55w0rd"
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{Length: 8, Digits: 2}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, newPasswd).Return(false, nil).Times(1)
 		mockKeycloakAccountClient.EXPECT().UpdatePassword(accessToken, realm, oldPasswd, newPasswd, confirmPasswd).Return("", nil).Times(1)
 		mockEventDBModule.EXPECT().ReportEvent(gomock.Any(), "PASSWORD_RESET", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 		mockKeycloakAccountClient.EXPECT().SendEmail(accessToken, realm, emailTemplateUpdatedPassword, emailSubjectUpdatedPassword, nil, gomock.Any()).Return(nil)
@@ -85,7 +146,9 @@ func TestUpdatePasswordWrongPwd(t *testing.T) {
 	mockEventDBModule := mock.NewEventsDBModule(mockCtrl)
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, log.NewNopLogger())
+	mockPasswordPolicyModule := mock.NewPasswordPolicyModule(mockCtrl)
+	mockBreachedPwdModule := mock.NewBreachedPasswordModule(mockCtrl)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, mockPasswordPolicyModule, mockBreachedPwdModule, log.NewNopLogger())
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -96,6 +159,9 @@ func TestUpdatePasswordWrongPwd(t *testing.T) {
 	ctx = context.WithValue(ctx, cs.CtContextUserID, userID)
 	ctx = context.WithValue(ctx, cs.CtContextUsername, username)
 
+	mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{}, nil).AnyTimes()
+	mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, newPasswd).Return(false, nil).AnyTimes()
+
 	t.Run("Error test case 1", func(t *testing.T) {
 		mockKeycloakAccountClient.EXPECT().UpdatePassword(accessToken, realm, oldPasswd, newPasswd, newPasswd).Return("", fmt.Errorf("invalidPasswordExistingMessage")).Times(1)
 
@@ -117,6 +183,60 @@ func TestUpdatePasswordWrongPwd(t *testing.T) {
 	})
 }
 
+func TestCheckPassword(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockKeycloakAccountClient := mock.NewKeycloakAccountClient(mockCtrl)
+	mockPasswordPolicyModule := mock.NewPasswordPolicyModule(mockCtrl)
+	mockBreachedPwdModule := mock.NewBreachedPasswordModule(mockCtrl)
+	component := NewComponent(mockKeycloakAccountClient, nil, nil, nil, nil, mockPasswordPolicyModule, mockBreachedPwdModule, log.NewNopLogger())
+
+	accessToken := "access token"
+	realm := "sample realm"
+	username := "username"
+	email := "john.doe@elca.ch"
+	ctx := context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realm)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, username)
+
+	t.Run("Can't get the password policy", func(t *testing.T) {
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{}, errors.New("error")).Times(1)
+
+		_, err := component.CheckPassword(ctx, "password")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Valid password", func(t *testing.T) {
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{Length: 8, NotUsername: true}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, "a p@55w0rd").Return(false, nil).Times(1)
+
+		res, err := component.CheckPassword(ctx, "a p@55w0rd")
+		assert.Nil(t, err)
+		assert.True(t, *res.Valid)
+		assert.Len(t, res.Violations, 0)
+	})
+
+	t.Run("Invalid password", func(t *testing.T) {
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{Digits: 1, NotUsername: true, NotEmail: true}, nil).Times(1)
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realm).Return(kc.UserRepresentation{Email: &email}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, username).Return(true, nil).Times(1)
+
+		res, err := component.CheckPassword(ctx, username)
+		assert.Nil(t, err)
+		assert.False(t, *res.Valid)
+		assert.Equal(t, []string{"invalidParameter.password.digits.1", "invalidParameter.password.notUsername", "invalidParameter.password.breached"}, res.Violations)
+	})
+
+	t.Run("Can't check breached passwords", func(t *testing.T) {
+		mockPasswordPolicyModule.EXPECT().GetPasswordPolicy(ctx, realm).Return(keycloakb.PasswordPolicy{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realm, "password").Return(false, errors.New("error")).Times(1)
+
+		_, err := component.CheckPassword(ctx, "password")
+		assert.NotNil(t, err)
+	})
+}
+
 func TestUpdateAccount(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -125,9 +245,10 @@ func TestUpdateAccount(t *testing.T) {
 	mockEventDBModule := mock.NewEventsDBModule(mockCtrl)
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
+	mockAccreditationsModule := mock.NewAccreditationsModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockAccreditationsModule, nil, nil, mockLogger)
 
 	accessToken := "access token"
 	realmName := "master"
@@ -207,8 +328,95 @@ func TestUpdateAccount(t *testing.T) {
 		assert.Equal(t, anError, err)
 	})
 
+	t.Run("Can't get account expiry", func(t *testing.T) {
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, userID).Return(nil, anError)
+
+		var err = accountComponent.UpdateAccount(ctx, userRep)
+
+		assert.Equal(t, anError, err)
+	})
+
+	t.Run("Account expiry modified since the account was read", func(t *testing.T) {
+		var etag = keycloakb.ComputeUserETag(kcUserRep, dbUser, nil)
+		var userWithETag = userRep
+		userWithETag.ETag = &etag
+		var accountExpiry = time.Now().Add(24 * time.Hour)
+
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, userID).Return(&accountExpiry, nil)
+
+		var err = accountComponent.UpdateAccount(ctx, userWithETag)
+
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+
+	mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, userID).Return(nil, nil).AnyTimes()
+
+	t.Run("Can't record the revocation of the accreditations", func(t *testing.T) {
+		var newFirstName = "Toto"
+		var accountUpdate = api.AccountRepresentation{
+			FirstName: &newFirstName,
+		}
+
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+		mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationIdentityUpdated, username).Return(anError)
+
+		var err = accountComponent.UpdateAccount(ctx, accountUpdate)
+
+		assert.Equal(t, anError, err)
+	})
+
+	// Updates of the identity revoke the accreditations
+	mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationIdentityUpdated, username).Return(nil).AnyTimes()
+
+	t.Run("Account modified since it was read", func(t *testing.T) {
+		var outdatedETag = `"outdated"`
+		var userWithETag = userRep
+		userWithETag.ETag = &outdatedETag
+
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+
+		var err = accountComponent.UpdateAccount(ctx, userWithETag)
+
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Changes of the account are reported", func(t *testing.T) {
+		var newLocale = "fr"
+		var newBirthLocation = "Genève"
+		var accountUpdate = api.AccountRepresentation{
+			Locale:        &newLocale,
+			BirthLocation: &newBirthLocation,
+		}
+
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockKeycloakAccountClient.EXPECT().UpdateAccount(accessToken, realmName, gomock.Any()).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventUsername, username, database.CtEventAdditionalInfo, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _ string, values ...string) error {
+				var additionalInfo = values[len(values)-1]
+				assert.Contains(t, additionalInfo, `{"field":"locale","old":"de","new":"fr"}`)
+				assert.Contains(t, additionalInfo, `{"field":"birthLocation","old":"A***","new":"G***"}`)
+				assert.NotContains(t, additionalInfo, newBirthLocation)
+				return nil
+			})
+		mockKeycloakAccountClient.EXPECT().SendEmail(accessToken, realmName, emailTemplateUpdatedProfile, emailSubjectUpdatedProfile, nil, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "PROFILE_CHANGED_EMAIL_SENT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		err := accountComponent.UpdateAccount(ctx, accountUpdate)
+
+		assert.Nil(t, err)
+	})
+
 	t.Run("Update account with succces", func(t *testing.T) {
-		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil).Times(1)
 		mockKeycloakAccountClient.EXPECT().UpdateAccount(accessToken, realmName, gomock.Any()).DoAndReturn(
 			func(accessToken, realmName string, kcUserRep kc.UserRepresentation) error {
@@ -227,7 +435,7 @@ func TestUpdateAccount(t *testing.T) {
 		assert.Nil(t, err)
 	})
 	t.Run("Keycloak update succces - DB get user fails", func(t *testing.T) {
-		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil).Times(1)
 		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{}, errors.New("db error"))
 
@@ -236,7 +444,7 @@ func TestUpdateAccount(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 	t.Run("Keycloak update succces - DB update fails", func(t *testing.T) {
-		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT", "self-service", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil).Times(1)
 		mockKeycloakAccountClient.EXPECT().UpdateAccount(accessToken, realmName, gomock.Any()).Return(nil).Times(1)
 		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{
@@ -370,6 +578,32 @@ func TestUpdateAccount(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("Clear fields with a merge patch", func(t *testing.T) {
+		var accountPatch = api.AccountRepresentation{
+			ClearedFields: []string{api.FieldPhoneNumber, api.FieldNationality},
+		}
+
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockKeycloakAccountClient.EXPECT().UpdateAccount(accessToken, realmName, gomock.Any()).DoAndReturn(
+			func(accessToken, realmName string, kcUserRep kc.UserRepresentation) error {
+				assert.Nil(t, kcUserRep.GetAttributeString(constants.AttrbPhoneNumber))
+				assert.Nil(t, kcUserRep.GetAttributeString(constants.AttrbPhoneNumberVerified))
+				assert.Equal(t, gender, *kcUserRep.GetAttributeString(constants.AttrbGender))
+				return nil
+			})
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dbUser, nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, user dto.DBUser) error {
+				assert.Nil(t, user.Nationality)
+				assert.Equal(t, birthLocation, *user.BirthLocation)
+				return nil
+			})
+
+		err := accountComponent.UpdateAccount(ctx, accountPatch)
+
+		assert.Nil(t, err)
+	})
+
 	t.Run("Error - get user", func(t *testing.T) {
 		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kc.UserRepresentation{}, fmt.Errorf("Unexpected error")).Times(1)
 
@@ -403,7 +637,7 @@ func TestGetUser(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -463,17 +697,29 @@ func TestGetUser(t *testing.T) {
 		CreatedTimestamp: &createdTimestamp,
 	}
 
+	t.Run("Can't get account expiry", func(t *testing.T) {
+		var dbError = errors.New("db error")
+		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{UserID: &userID}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, userID).Return(nil, dbError)
+		_, err := accountComponent.GetAccount(ctx)
+
+		assert.Equal(t, dbError, err)
+	})
+
 	t.Run("Get user with succces", func(t *testing.T) {
 		mockKeycloakAccountClient.EXPECT().GetAccount(accessToken, realmName).Return(kcUserRep, nil).Times(1)
 		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{
 			UserID: &userID,
 		}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, userID).Return(nil, nil)
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "GET_DETAILS", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 		apiUserRep, err := accountComponent.GetAccount(ctx)
 
 		assert.Nil(t, err)
 		assert.Equal(t, username, *apiUserRep.Username)
+		assert.NotNil(t, apiUserRep.ETag)
 		assert.Equal(t, email, *apiUserRep.Email)
 		assert.Equal(t, gender, *apiUserRep.Gender)
 		assert.Equal(t, firstName, *apiUserRep.FirstName)
@@ -504,6 +750,7 @@ func TestGetUser(t *testing.T) {
 			IDDocumentExpiration: &docExp,
 			IDDocumentCountry:    &docCountry,
 		}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, userID).Return(nil, nil)
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "GET_DETAILS", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 		apiUserRep, err := accountComponent.GetAccount(ctx)
@@ -532,18 +779,32 @@ func TestDeleteUser(t *testing.T) {
 	mockEventDBModule := mock.NewEventsDBModule(mockCtrl)
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockConfigurationDBModule := mock.NewConfigurationDBModule(mockCtrl)
+	mockAccreditationsModule := mock.NewAccreditationsModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	var accountComponent = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockAccreditationsModule, nil, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
+	var userID = "123-456-789"
 	var username = "username"
 
 	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
 	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, userID)
 	ctx = context.WithValue(ctx, cs.CtContextUsername, username)
 
+	t.Run("Can't revoke accreditations", func(t *testing.T) {
+		var anError = errors.New("db error")
+		mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationUserDeleted, username).Return(anError)
+
+		err := accountComponent.DeleteAccount(ctx)
+
+		assert.Equal(t, anError, err)
+	})
+
+	mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationUserDeleted, username).Return(nil).AnyTimes()
+
 	t.Run("Delete user with succces", func(t *testing.T) {
 		mockKeycloakAccountClient.EXPECT().DeleteAccount(accessToken, realmName).Return(nil).Times(1)
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "SELF_DELETE_ACCOUNT", "self-service", gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
@@ -571,7 +832,7 @@ func TestGetCredentials(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var currentRealm = "master"
@@ -631,7 +892,7 @@ func TestGetCredentialRegistrators(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var currentRealm = "master"
@@ -678,7 +939,7 @@ func TestUpdateLabelCredential(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -720,7 +981,7 @@ func TestDeleteCredential(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -760,7 +1021,7 @@ func TestMoveCredential(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	accessToken := "access token"
 	realm := "sample realm"
@@ -817,7 +1078,7 @@ func TestGetConfiguration(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+	component := NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var currentRealm = "master"
@@ -836,7 +1097,7 @@ func TestGetConfiguration(t *testing.T) {
 		ShowPasswordTab:                     &trueBool,
 		ShowProfileTab:                      &trueBool,
 	}
-	var adminConfig configuration.RealmAdminConfiguration
+	var adminConfig dto.RealmAdminConfiguration
 
 	t.Run("Get configuration with succces", func(t *testing.T) {
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -896,7 +1157,7 @@ func TestGetConfiguration(t *testing.T) {
 		ctx = context.WithValue(ctx, cs.CtContextUserID, currentUserID)
 
 		mockConfigurationDBModule.EXPECT().GetConfiguration(ctx, currentRealm).Return(configuration.RealmConfiguration{}, nil).Times(1)
-		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, currentRealm).Return(configuration.RealmAdminConfiguration{}, fmt.Errorf("Unexpected error")).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, currentRealm).Return(dto.RealmAdminConfiguration{}, fmt.Errorf("Unexpected error")).Times(1)
 
 		_, err := component.GetConfiguration(ctx, "")
 
@@ -915,7 +1176,7 @@ func TestSendVerify(t *testing.T) {
 		mockUsersDetailsDBModule  = mock.NewUsersDetailsDBModule(mockCtrl)
 		mockLogger                = log.NewNopLogger()
 
-		component     = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, mockLogger)
+		component     = NewComponent(mockKeycloakAccountClient, mockEventDBModule, mockConfigurationDBModule, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
 		accessToken   = "TOKEN=="
 		currentRealm  = "master"
 		currentUserID = "1234-789"
@@ -8,6 +8,7 @@ import (
 	errrorhandler "github.com/cloudtrust/common-service/errors"
 	api "github.com/cloudtrust/keycloak-bridge/api/account"
 	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -25,6 +26,7 @@ type Endpoints struct {
 	GetConfiguration          endpoint.Endpoint
 	SendVerifyEmail           endpoint.Endpoint
 	SendVerifyPhoneNumber     endpoint.Endpoint
+	CheckPassword             endpoint.Endpoint
 }
 
 // UpdatePasswordBody is the definition of the expected body content of UpdatePassword method
@@ -53,6 +55,25 @@ func MakeUpdatePasswordEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeCheckPasswordEndpoint makes the CheckPassword endpoint to evaluate a password against the password policy of the realm.
+func MakeCheckPasswordEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var body api.PasswordCheckBody
+
+		err := json.Unmarshal([]byte(m[ReqBody]), &body)
+		if err != nil {
+			return nil, errrorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = body.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.CheckPassword(ctx, body.Password)
+	}
+}
+
 // MakeGetCredentialsEndpoint makes the GetCredentials endpoint to list credentials of the current user.
 func MakeGetCredentialsEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, _ interface{}) (interface{}, error) {
@@ -127,9 +148,15 @@ func MakeUpdateAccountEndpoint(component Component) cs.Endpoint {
 			return nil, errrorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
 		}
 
+		// With a JSON merge patch, fields set to null are removed
+		if keycloakb.IsMergePatch(m) {
+			body.ClearedFields, _ = keycloakb.GetNullFields(m[ReqBody])
+		}
+
 		if err = body.Validate(); err != nil {
 			return nil, err
 		}
+		body.ETag = keycloakb.GetIfMatch(m)
 
 		return nil, component.UpdateAccount(ctx, body)
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	account_api "github.com/cloudtrust/keycloak-bridge/api/account"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/cloudtrust/keycloak-bridge/pkg/account/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,36 @@ func TestMakeUpdatePasswordEndpoint(t *testing.T) {
 
 }
 
+func TestMakeCheckPasswordEndpoint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAccountComponent := mock.NewComponent(mockCtrl)
+	var valid = true
+	mockAccountComponent.EXPECT().CheckPassword(gomock.Any(), "password").Return(account_api.PasswordCheckRepresentation{Valid: &valid}, nil).Times(1)
+
+	m := map[string]string{}
+
+	t.Run("Valid body", func(t *testing.T) {
+		m[ReqBody] = `{"password":"password"}`
+		res, err := MakeCheckPasswordEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.Nil(t, err)
+		assert.True(t, *res.(account_api.PasswordCheckRepresentation).Valid)
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		m[ReqBody] = "{"
+		_, err := MakeCheckPasswordEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing password", func(t *testing.T) {
+		m[ReqBody] = `{}`
+		_, err := MakeCheckPasswordEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.NotNil(t, err)
+	})
+}
+
 func TestMakeGetCredentialsEndpoint(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -152,7 +183,30 @@ func TestMakeUpdateAccountEndpoint(t *testing.T) {
 		_, err := MakeUpdateAccountEndpoint(mockAccountComponent)(context.Background(), m)
 		assert.NotNil(t, err)
 	}
-
+	{
+		var etag = `"abcdef"`
+		mockAccountComponent.EXPECT().UpdateAccount(gomock.Any(), account_api.AccountRepresentation{ETag: &etag}).Return(nil).Times(1)
+		m := map[string]string{}
+		m[ReqBody] = "{}"
+		m[keycloakb.ReqIfMatch] = etag
+		_, err := MakeUpdateAccountEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.Nil(t, err)
+	}
+	{
+		mockAccountComponent.EXPECT().UpdateAccount(gomock.Any(), account_api.AccountRepresentation{ClearedFields: []string{"locale"}}).Return(nil).Times(1)
+		m := map[string]string{}
+		m[ReqBody] = "{\"locale\": null}"
+		m[keycloakb.ReqMergePatch] = "true"
+		_, err := MakeUpdateAccountEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.Nil(t, err)
+	}
+	{
+		m := map[string]string{}
+		m[ReqBody] = "{\"email\": null}"
+		m[keycloakb.ReqMergePatch] = "true"
+		_, err := MakeUpdateAccountEndpoint(mockAccountComponent)(context.Background(), m)
+		assert.NotNil(t, err)
+	}
 }
 
 func TestSimpleEndpoints(t *testing.T) {
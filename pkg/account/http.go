@@ -7,6 +7,7 @@ import (
 	commonhttp "github.com/cloudtrust/common-service/http"
 	"github.com/cloudtrust/common-service/log"
 	account_api "github.com/cloudtrust/keycloak-bridge/api/account"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/go-kit/kit/endpoint"
 	http_transport "github.com/go-kit/kit/transport/http"
 )
@@ -25,7 +26,7 @@ const (
 func MakeAccountHandler(e endpoint.Endpoint, logger log.Logger) *http_transport.Server {
 	return http_transport.NewServer(e,
 		decodeAccountRequest,
-		commonhttp.EncodeReply,
+		keycloakb.EncodeReplyWithETag,
 		http_transport.ServerErrorEncoder(commonhttp.ErrorHandler(logger)),
 	)
 }
@@ -41,5 +42,5 @@ func decodeAccountRequest(ctx context.Context, req *http.Request) (interface{},
 		PrmQryRealmID: account_api.RegExpRealmName,
 	}
 
-	return commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
+	return keycloakb.DecodeRequestWithHeaders(ctx, req, pathParams, queryParams)
 }
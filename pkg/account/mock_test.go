@@ -5,3 +5,4 @@ package account
 //go:generate mockgen -destination=./mock/eventsdbmodule.go -package=mock -mock_names=EventsDBModule=EventsDBModule github.com/cloudtrust/common-service/database EventsDBModule
 //go:generate mockgen -destination=./mock/component.go -package=mock -mock_names=Component=Component github.com/cloudtrust/keycloak-bridge/pkg/account Component
 //go:generate mockgen -destination=./mock/logger.go -package=mock -mock_names=Logger=Logger github.com/cloudtrust/keycloak-bridge/internal/keycloakb Logger
+//go:generate mockgen -destination=./mock/passwordpolicy.go -package=mock -mock_names=AccreditationsModule=AccreditationsModule,PasswordPolicyModule=PasswordPolicyModule,BreachedPasswordModule=BreachedPasswordModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb AccreditationsModule,PasswordPolicyModule,BreachedPasswordModule
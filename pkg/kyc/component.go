@@ -31,7 +31,7 @@ type KeycloakClient interface {
 type UsersDetailsDBModule interface {
 	StoreOrUpdateUserDetails(ctx context.Context, realm string, user dto.DBUser) error
 	GetUserDetails(ctx context.Context, realm string, userID string) (dto.DBUser, error)
-	CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) error
+	CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) (int64, error)
 	GetChecks(ctx context.Context, realm string, userID string) ([]dto.DBCheck, error)
 }
 
@@ -57,27 +57,29 @@ type Component interface {
 
 // Component is the management component.
 type component struct {
-	tokenProvider   toolbox.OidcTokenProvider
-	socialRealmName string
-	keycloakClient  KeycloakClient
-	usersDBModule   UsersDetailsDBModule
-	archiveDBModule ArchiveDBModule
-	eventsDBModule  database.EventsDBModule
-	accredsModule   keycloakb.AccreditationsModule
-	logger          internal.Logger
+	tokenProvider    toolbox.OidcTokenProvider
+	socialRealmName  string
+	keycloakClient   KeycloakClient
+	usersDBModule    UsersDetailsDBModule
+	archiveDBModule  ArchiveDBModule
+	eventsDBModule   database.EventsDBModule
+	accredsModule    keycloakb.AccreditationsModule
+	duplicatesModule keycloakb.DuplicatesModule
+	logger           internal.Logger
 }
 
 // NewComponent returns the management component.
-func NewComponent(tokenProvider toolbox.OidcTokenProvider, socialRealmName string, keycloakClient KeycloakClient, usersDBModule UsersDetailsDBModule, archiveDBModule ArchiveDBModule, eventsDBModule EventsDBModule, accredsModule keycloakb.AccreditationsModule, logger internal.Logger) Component {
+func NewComponent(tokenProvider toolbox.OidcTokenProvider, socialRealmName string, keycloakClient KeycloakClient, usersDBModule UsersDetailsDBModule, archiveDBModule ArchiveDBModule, eventsDBModule EventsDBModule, accredsModule keycloakb.AccreditationsModule, duplicatesModule keycloakb.DuplicatesModule, logger internal.Logger) Component {
 	return &component{
-		tokenProvider:   tokenProvider,
-		socialRealmName: socialRealmName,
-		keycloakClient:  keycloakClient,
-		usersDBModule:   usersDBModule,
-		archiveDBModule: archiveDBModule,
-		eventsDBModule:  eventsDBModule,
-		accredsModule:   accredsModule,
-		logger:          logger,
+		tokenProvider:    tokenProvider,
+		socialRealmName:  socialRealmName,
+		keycloakClient:   keycloakClient,
+		usersDBModule:    usersDBModule,
+		archiveDBModule:  archiveDBModule,
+		eventsDBModule:   eventsDBModule,
+		accredsModule:    accredsModule,
+		duplicatesModule: duplicatesModule,
+		logger:           logger,
 	}
 }
 
@@ -157,7 +159,8 @@ func (c *component) getUser(ctx context.Context, userID string, kcUser kc.UserRe
 
 func (c *component) ValidateUser(ctx context.Context, realmName string, userID string, user apikyc.UserRepresentation) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
-	return c.validateUser(ctx, accessToken, realmName, userID, user)
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+	return c.validateUser(ctx, accessToken, ctxRealm, realmName, userID, user)
 }
 
 func (c *component) ValidateUserInSocialRealm(ctx context.Context, userID string, user apikyc.UserRepresentation) error {
@@ -167,17 +170,16 @@ func (c *component) ValidateUserInSocialRealm(ctx context.Context, userID string
 		return err
 	}
 
-	return c.validateUser(ctx, accessToken, c.socialRealmName, userID, user)
+	return c.validateUser(ctx, accessToken, c.socialRealmName, c.socialRealmName, userID, user)
 }
 
-func (c *component) validateUser(ctx context.Context, accessToken string, realmName string, userID string, user apikyc.UserRepresentation) error {
+func (c *component) validateUser(ctx context.Context, accessToken string, reqRealmName string, realmName string, userID string, user apikyc.UserRepresentation) error {
 	var operatorName = ctx.Value(cs.CtContextUsername).(string)
 
 	// Gets user from Keycloak
-	var kcUser kc.UserRepresentation
-	kcUser, _, err := c.accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, configuration.CheckKeyPhysical)
+	kcUser, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
 	if err != nil {
-		c.logger.Warn(ctx, "msg", "Can't get user/accreditations", "err", err.Error())
+		c.logger.Warn(ctx, "msg", "Can't get user from Keycloak", "err", err.Error())
 		return err
 	}
 	keycloakb.ConvertLegacyAttribute(&kcUser)
@@ -210,6 +212,21 @@ func (c *component) validateUser(ctx context.Context, accessToken string, realmN
 
 	user.ExportToDBUser(&dbUser)
 	user.ExportToKeycloak(&kcUser)
+	dbUser.SetIdentity(kcUser)
+
+	// Conditions of the accreditations are evaluated against the validated values
+	accreds, err := c.accredsModule.PrepareAccreditations(ctx, accessToken, realmName, &kcUser, dbUser, configuration.CheckKeyPhysical)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't prepare accreditations", "err", err.Error())
+		return err
+	}
+
+	// The same person should not be validated through several accounts
+	err = c.duplicatesModule.CheckDuplicates(ctx, accessToken, reqRealmName, realmName, dbUser)
+	if err != nil {
+		return err
+	}
+
 	err = c.keycloakClient.UpdateUser(accessToken, realmName, userID, kcUser)
 	if err != nil {
 		c.logger.Warn(ctx, "msg", "Failed to update user through Keycloak API", "err", err.Error())
@@ -233,12 +250,16 @@ func (c *component) validateUser(ctx context.Context, accessToken string, realmN
 		Comment:  user.Comment,
 	}
 
-	err = c.usersDBModule.CreateCheck(ctx, realmName, userID, validation)
+	checkID, err := c.usersDBModule.CreateCheck(ctx, realmName, userID, validation)
 	if err != nil {
 		c.logger.Warn(ctx, "msg", "Can't store validation check in database", "err", err.Error())
 		return err
 	}
 
+	if err = c.accredsModule.RecordAccreditations(ctx, accreds, operatorName, &checkID); err != nil {
+		return err
+	}
+
 	// store the API call into the DB
 	c.reportEvent(ctx, "VALIDATE_USER", database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, *user.Username)
 
@@ -21,7 +21,7 @@ func TestGetActions(t *testing.T) {
 
 	var mockTokenProvider = mock.NewOidcTokenProvider(mockCtrl)
 
-	var component = NewComponent(mockTokenProvider, "realm", nil, nil, nil, nil, nil, log.NewNopLogger())
+	var component = NewComponent(mockTokenProvider, "realm", nil, nil, nil, nil, nil, nil, log.NewNopLogger())
 
 	t.Run("GetActions", func(t *testing.T) {
 		var res, err = component.GetActions(context.TODO())
@@ -65,7 +65,7 @@ func TestGetUserByUsernameInSocialRealmComponent(t *testing.T) {
 	var kcGroupSearch = []kc.GroupRepresentation{kcGroup1, kcGroup2}
 	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
 
-	var component = NewComponent(mockTokenProvider, realm, mockKeycloakClient, mockUsersDB, nil, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockTokenProvider, realm, mockKeycloakClient, mockUsersDB, nil, mockEventsDB, mockAccreditations, nil, log.NewNopLogger())
 
 	t.Run("Failed to retrieve OIDC token", func(t *testing.T) {
 		var oidcError = errors.New("oidc error")
@@ -141,7 +141,7 @@ func TestGetUserInSocialRealmComponent(t *testing.T) {
 	}
 	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
 
-	var component = NewComponent(mockTokenProvider, realm, mockKeycloakClient, mockUsersDB, nil, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockTokenProvider, realm, mockKeycloakClient, mockUsersDB, nil, mockEventsDB, mockAccreditations, nil, log.NewNopLogger())
 
 	t.Run("Failed to retrieve OIDC token", func(t *testing.T) {
 		var oidcError = errors.New("oidc error")
@@ -201,6 +201,7 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	var mockArchiveDB = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
 	var mockAccreditations = mock.NewAccreditationsModule(mockCtrl)
+	var mockDuplicates = mock.NewDuplicatesModule(mockCtrl)
 	var mockTokenProvider = mock.NewOidcTokenProvider(mockCtrl)
 
 	var targetRealm = "cloudtrust"
@@ -211,8 +212,10 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	var accessToken = "abcdef"
 	var ctx = context.TODO()
 	var dbUser = dto.DBUser{UserID: &userID}
+	var checkID = int64(42)
+	var accreds = []dto.DBAccreditation{{RealmID: targetRealm, UserID: userID, Type: "SHADOW"}}
 
-	var component = NewComponent(mockTokenProvider, targetRealm, mockKeycloakClient, mockUsersDB, mockArchiveDB, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockTokenProvider, targetRealm, mockKeycloakClient, mockUsersDB, mockArchiveDB, mockEventsDB, mockAccreditations, mockDuplicates, log.NewNopLogger())
 
 	ctx = context.WithValue(ctx, cs.CtContextUsername, "operator")
 
@@ -223,9 +226,9 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
-	t.Run("Call to accreditations module fails", func(t *testing.T) {
+	t.Run("Failed to get user from Keycloak", func(t *testing.T) {
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, errors.New("failure"))
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, errors.New("failure"))
 		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
 		assert.NotNil(t, err)
 	})
@@ -233,7 +236,7 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	t.Run("Email not verified", func(t *testing.T) {
 		var searchResult = createUser(userID, username, false, true)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(searchResult, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(searchResult, nil)
 
 		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
 		assert.NotNil(t, err)
@@ -242,7 +245,7 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	t.Run("PhoneNumber not verified", func(t *testing.T) {
 		var searchResult = createUser(userID, username, true, false)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(searchResult, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(searchResult, nil)
 
 		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
 		assert.NotNil(t, err)
@@ -251,18 +254,53 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	t.Run("SQL error when searching user in database", func(t *testing.T) {
 		var sqlError = errors.New("sql error")
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dto.DBUser{}, sqlError)
 
 		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
 		assert.NotNil(t, err)
 	})
 
+	t.Run("Call to accreditations module fails", func(t *testing.T) {
+		var accredsError = errors.New("accreditations error")
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).DoAndReturn(
+			func(_ context.Context, _, _ string, _ *kc.UserRepresentation, user dto.DBUser, _ string) ([]dto.DBAccreditation, error) {
+				// Accreditations are evaluated against the validated values
+				assert.Equal(t, validUser.Nationality, user.Nationality)
+				return nil, accredsError
+			})
+
+		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
+		assert.Equal(t, accredsError, err)
+	})
+
+	t.Run("Duplicate user", func(t *testing.T) {
+		var duplicateError = errors.New("duplicate")
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _, _ string, user dto.DBUser) error {
+				assert.Equal(t, validUser.LastName, user.LastName)
+				assert.Equal(t, validUser.IDDocumentNumber, user.IDDocumentNumber)
+				return duplicateError
+			})
+
+		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
+		assert.Equal(t, duplicateError, err)
+	})
+
 	t.Run("Keycloak update fails", func(t *testing.T) {
 		var kcError = errors.New("keycloak error")
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(kcError)
 
 		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
@@ -272,8 +310,10 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	t.Run("Update user in DB fails", func(t *testing.T) {
 		var dbError = errors.New("db update error")
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(nil)
 		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(dbError)
 
@@ -284,11 +324,29 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 	t.Run("Store check in DB fails", func(t *testing.T) {
 		var dbError = errors.New("db update error")
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
+		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(int64(0), dbError)
+
+		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
+		assert.Equal(t, dbError, err)
+	})
+
+	t.Run("Record accreditations in the history fails", func(t *testing.T) {
+		var dbError = errors.New("db insert error")
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(nil)
 		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(dbError)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
+		mockAccreditations.EXPECT().RecordAccreditations(ctx, accreds, "operator", &checkID).Return(dbError)
 
 		var err = component.ValidateUserInSocialRealm(ctx, userID, validUser)
 		assert.Equal(t, dbError, err)
@@ -296,11 +354,14 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 
 	t.Run("ValidateUserInSocialRealm is successful", func(t *testing.T) {
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(nil)
 		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
+		mockAccreditations.EXPECT().RecordAccreditations(ctx, accreds, "operator", &checkID).Return(nil)
 		mockEventsDB.EXPECT().ReportEvent(gomock.Any(), "VALIDATE_USER", "back-office", gomock.Any())
 		mockUsersDB.EXPECT().GetChecks(gomock.Any(), targetRealm, userID).Return([]dto.DBCheck{}, errors.New("any error"))
 		mockArchiveDB.EXPECT().StoreUserDetails(gomock.Any(), targetRealm, gomock.Any()).Return(errors.New("any error"))
@@ -311,11 +372,14 @@ func TestValidateUserInSocialRealm(t *testing.T) {
 
 	t.Run("ValidateUserInSocialRealm is successful - Report event fails", func(t *testing.T) {
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+		mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(accreds, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(nil)
 		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
+		mockAccreditations.EXPECT().RecordAccreditations(ctx, accreds, "operator", &checkID).Return(nil)
 		mockEventsDB.EXPECT().ReportEvent(gomock.Any(), "VALIDATE_USER", "back-office", gomock.Any()).Return(errors.New("report fails"))
 		mockUsersDB.EXPECT().GetChecks(gomock.Any(), targetRealm, userID).Return([]dto.DBCheck{}, nil)
 		mockArchiveDB.EXPECT().StoreUserDetails(gomock.Any(), targetRealm, gomock.Any()).Return(nil)
@@ -334,6 +398,7 @@ func TestValidateUser(t *testing.T) {
 	var mockArchiveDB = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
 	var mockAccreditations = mock.NewAccreditationsModule(mockCtrl)
+	var mockDuplicates = mock.NewDuplicatesModule(mockCtrl)
 	var mockTokenProvider = mock.NewOidcTokenProvider(mockCtrl)
 
 	var targetRealm = "cloudtrust"
@@ -345,16 +410,20 @@ func TestValidateUser(t *testing.T) {
 	var ctx = context.TODO()
 	var dbUser = dto.DBUser{UserID: &userID}
 
-	var component = NewComponent(mockTokenProvider, targetRealm, mockKeycloakClient, mockUsersDB, mockArchiveDB, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockTokenProvider, targetRealm, mockKeycloakClient, mockUsersDB, mockArchiveDB, mockEventsDB, mockAccreditations, mockDuplicates, log.NewNopLogger())
 
 	ctx = context.WithValue(ctx, cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
 	ctx = context.WithValue(ctx, cs.CtContextUsername, "operator")
 
-	mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, configuration.CheckKeyPhysical).Return(kcUser, 0, nil)
+	mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kcUser, nil)
 	mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dbUser, nil)
+	mockAccreditations.EXPECT().PrepareAccreditations(ctx, accessToken, targetRealm, gomock.Any(), gomock.Any(), configuration.CheckKeyPhysical).Return(nil, nil)
+	mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, "master", targetRealm, gomock.Any()).Return(nil)
 	mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, gomock.Any()).Return(nil)
 	mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
-	mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+	mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(int64(1), nil)
+	mockAccreditations.EXPECT().RecordAccreditations(ctx, nil, "operator", gomock.Any()).Return(nil)
 	mockEventsDB.EXPECT().ReportEvent(gomock.Any(), "VALIDATE_USER", "back-office", gomock.Any())
 	mockUsersDB.EXPECT().GetChecks(gomock.Any(), targetRealm, userID).Return([]dto.DBCheck{}, nil)
 	mockArchiveDB.EXPECT().StoreUserDetails(gomock.Any(), targetRealm, gomock.Any()).Return(nil)
@@ -28,14 +28,28 @@ var (
 	MGMTGetRealm                            = newAction("MGMT_GetRealm", security.ScopeRealm)
 	MGMTGetClient                           = newAction("MGMT_GetClient", security.ScopeRealm)
 	MGMTGetClients                          = newAction("MGMT_GetClients", security.ScopeRealm)
+	MGMTCreateClient                        = newAction("MGMT_CreateClient", security.ScopeRealm)
+	MGMTUpdateClient                        = newAction("MGMT_UpdateClient", security.ScopeRealm)
+	MGMTDisableClient                       = newAction("MGMT_DisableClient", security.ScopeRealm)
+	MGMTUpdateClientRedirectURIs            = newAction("MGMT_UpdateClientRedirectURIs", security.ScopeRealm)
+	MGMTRotateClientSecret                  = newAction("MGMT_RotateClientSecret", security.ScopeRealm)
 	MGMTGetRequiredActions                  = newAction("MGMT_GetRequiredActions", security.ScopeRealm)
 	MGMTDeleteUser                          = newAction("MGMT_DeleteUser", security.ScopeGroup)
+	MGMTRestoreUser                         = newAction("MGMT_RestoreUser", security.ScopeGroup)
 	MGMTGetUser                             = newAction("MGMT_GetUser", security.ScopeGroup)
 	MGMTUpdateUser                          = newAction("MGMT_UpdateUser", security.ScopeGroup)
 	MGMTLockUser                            = newAction("MGMT_LockUser", security.ScopeGroup)
 	MGMTUnlockUser                          = newAction("MGMT_UnlockUser", security.ScopeGroup)
+	MGMTGetUserAccreditations               = newAction("MGMT_GetUserAccreditations", security.ScopeGroup)
+	MGMTGetUserAccreditationsHistory        = newAction("MGMT_GetUserAccreditationsHistory", security.ScopeGroup)
+	MGMTRevokeAccreditation                 = newAction("MGMT_RevokeAccreditation", security.ScopeGroup)
+	MGMTReinstateAccreditation              = newAction("MGMT_ReinstateAccreditation", security.ScopeGroup)
 	MGMTGetUsers                            = newAction("MGMT_GetUsers", security.ScopeGroup)
 	MGMTCreateUser                          = newAction("MGMT_CreateUser", security.ScopeGroup)
+	MGMTImportUsers                         = newAction("MGMT_ImportUsers", security.ScopeRealm)
+	MGMTExportUsers                         = newAction("MGMT_ExportUsers", security.ScopeRealm)
+	MGMTLookupUsers                         = newAction("MGMT_LookupUsers", security.ScopeRealm)
+	MGMTGetDuplicateUsers                   = newAction("MGMT_GetDuplicateUsers", security.ScopeRealm)
 	MGMTGetUserChecks                       = newAction("MGMT_GetUserChecks", security.ScopeGroup)
 	MGMTGetUserAccountStatus                = newAction("MGMT_GetUserAccountStatus", security.ScopeGroup)
 	MGMTGetRolesOfUser                      = newAction("MGMT_GetRolesOfUser", security.ScopeGroup)
@@ -47,6 +61,9 @@ var (
 	MGMTSetTrustIDGroups                    = newAction("MGMT_SetTrustIDGroups", security.ScopeGroup)
 	MGMTGetClientRolesForUser               = newAction("MGMT_GetClientRolesForUser", security.ScopeGroup)
 	MGMTAddClientRolesToUser                = newAction("MGMT_AddClientRolesToUser", security.ScopeGroup)
+	MGMTDeleteClientRolesFromUser           = newAction("MGMT_DeleteClientRolesFromUser", security.ScopeGroup)
+	MGMTAddRealmRolesToUser                 = newAction("MGMT_AddRealmRolesToUser", security.ScopeGroup)
+	MGMTDeleteRealmRolesFromUser            = newAction("MGMT_DeleteRealmRolesFromUser", security.ScopeGroup)
 	MGMTResetPassword                       = newAction("MGMT_ResetPassword", security.ScopeGroup)
 	MGMTExecuteActionsEmail                 = newAction("MGMT_ExecuteActionsEmail", security.ScopeGroup)
 	MGMTSendNewEnrolmentCode                = newAction("MGMT_SendNewEnrolmentCode", security.ScopeGroup)
@@ -60,11 +77,22 @@ var (
 	MGMTGetAttackDetectionStatus            = newAction("MGMT_GetAttackDetectionStatus", security.ScopeGroup)
 	MGMTGetRoles                            = newAction("MGMT_GetRoles", security.ScopeRealm)
 	MGMTGetRole                             = newAction("MGMT_GetRole", security.ScopeRealm)
+	MGMTCreateRole                          = newAction("MGMT_CreateRole", security.ScopeRealm)
+	MGMTDeleteRole                          = newAction("MGMT_DeleteRole", security.ScopeRealm)
 	MGMTGetGroups                           = newAction("MGMT_GetGroups", security.ScopeRealm)
 	MGMTCreateGroup                         = newAction("MGMT_CreateGroup", security.ScopeRealm)
+	MGMTUpdateGroup                         = newAction("MGMT_UpdateGroup", security.ScopeGroup)
 	MGMTDeleteGroup                         = newAction("MGMT_DeleteGroup", security.ScopeGroup)
 	MGMTGetAuthorizations                   = newAction("MGMT_GetAuthorizations", security.ScopeGroup)
 	MGMTUpdateAuthorizations                = newAction("MGMT_UpdateAuthorizations", security.ScopeGroup)
+	MGMTExplainAuthorization                = newAction("MGMT_ExplainAuthorization", security.ScopeRealm)
+	MGMTCopyAuthorizations                  = newAction("MGMT_CopyAuthorizations", security.ScopeGroup)
+	MGMTGetAuthorizationTemplates           = newAction("MGMT_GetAuthorizationTemplates", security.ScopeGlobal)
+	MGMTUpdateAuthorizationTemplate         = newAction("MGMT_UpdateAuthorizationTemplate", security.ScopeGlobal)
+	MGMTDeleteAuthorizationTemplate         = newAction("MGMT_DeleteAuthorizationTemplate", security.ScopeGlobal)
+	MGMTApplyAuthorizationTemplate          = newAction("MGMT_ApplyAuthorizationTemplate", security.ScopeGroup)
+	MGMTGetAuthorizationVersions            = newAction("MGMT_GetAuthorizationVersions", security.ScopeGroup)
+	MGMTRollbackAuthorizations              = newAction("MGMT_RollbackAuthorizations", security.ScopeGroup)
 	MGMTGetClientRoles                      = newAction("MGMT_GetClientRoles", security.ScopeRealm)
 	MGMTCreateClientRole                    = newAction("MGMT_CreateClientRole", security.ScopeRealm)
 	MGMTGetRealmCustomConfiguration         = newAction("MGMT_GetRealmCustomConfiguration", security.ScopeRealm)
@@ -75,6 +103,9 @@ var (
 	MGMTUpdateRealmBackOfficeConfiguration  = newAction("MGMT_UpdateRealmBackOfficeConfiguration", security.ScopeGroup)
 	MGMTGetUserRealmBackOfficeConfiguration = newAction("MGMT_GetUserRealmBackOfficeConfiguration", security.ScopeRealm)
 	MGMTLinkShadowUser                      = newAction("MGMT_LinkShadowUser", security.ScopeRealm)
+	MGMTGetPendingRequests                  = newAction("MGMT_GetPendingRequests", security.ScopeRealm)
+	MGMTApprovePendingRequest               = newAction("MGMT_ApprovePendingRequest", security.ScopeRealm)
+	MGMTRejectPendingRequest                = newAction("MGMT_RejectPendingRequest", security.ScopeRealm)
 )
 
 // Tracking middleware at component level.
@@ -162,6 +193,61 @@ func (c *authorizationComponentMW) GetClients(ctx context.Context, realmName str
 	return c.next.GetClients(ctx, realmName)
 }
 
+func (c *authorizationComponentMW) CreateClient(ctx context.Context, realmName string, client api.ClientRepresentation) (string, error) {
+	var action = MGMTCreateClient.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return "", err
+	}
+
+	return c.next.CreateClient(ctx, realmName, client)
+}
+
+func (c *authorizationComponentMW) UpdateClient(ctx context.Context, realmName, idClient string, client api.ClientRepresentation) error {
+	var action = MGMTUpdateClient.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateClient(ctx, realmName, idClient, client)
+}
+
+func (c *authorizationComponentMW) DisableClient(ctx context.Context, realmName, idClient string) error {
+	var action = MGMTDisableClient.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DisableClient(ctx, realmName, idClient)
+}
+
+func (c *authorizationComponentMW) UpdateClientRedirectURIs(ctx context.Context, realmName, idClient string, redirectURIs []string) error {
+	var action = MGMTUpdateClientRedirectURIs.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs)
+}
+
+func (c *authorizationComponentMW) RotateClientSecret(ctx context.Context, realmName, idClient string) (api.ClientSecretRepresentation, error) {
+	var action = MGMTRotateClientSecret.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.ClientSecretRepresentation{}, err
+	}
+
+	return c.next.RotateClientSecret(ctx, realmName, idClient)
+}
+
 func (c *authorizationComponentMW) GetRequiredActions(ctx context.Context, realmName string) ([]api.RequiredActionRepresentation, error) {
 	var action = MGMTGetRequiredActions.String()
 	var targetRealm = realmName
@@ -184,6 +270,17 @@ func (c *authorizationComponentMW) DeleteUser(ctx context.Context, realmName, us
 	return c.next.DeleteUser(ctx, realmName, userID)
 }
 
+func (c *authorizationComponentMW) RestoreUser(ctx context.Context, realmName, userID string) error {
+	var action = MGMTRestoreUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.RestoreUser(ctx, realmName, userID)
+}
+
 func (c *authorizationComponentMW) GetUser(ctx context.Context, realmName, userID string) (api.UserRepresentation, error) {
 	var action = MGMTGetUser.String()
 	var targetRealm = realmName
@@ -206,7 +303,7 @@ func (c *authorizationComponentMW) UpdateUser(ctx context.Context, realmName, us
 	return c.next.UpdateUser(ctx, realmName, userID, user)
 }
 
-func (c *authorizationComponentMW) LockUser(ctx context.Context, realmName, userID string) error {
+func (c *authorizationComponentMW) LockUser(ctx context.Context, realmName, userID string, lock api.UserLockRepresentation) error {
 	var action = MGMTLockUser.String()
 	var targetRealm = realmName
 
@@ -214,7 +311,7 @@ func (c *authorizationComponentMW) LockUser(ctx context.Context, realmName, user
 		return err
 	}
 
-	return c.next.LockUser(ctx, realmName, userID)
+	return c.next.LockUser(ctx, realmName, userID, lock)
 }
 
 func (c *authorizationComponentMW) UnlockUser(ctx context.Context, realmName, userID string) error {
@@ -228,6 +325,50 @@ func (c *authorizationComponentMW) UnlockUser(ctx context.Context, realmName, us
 	return c.next.UnlockUser(ctx, realmName, userID)
 }
 
+func (c *authorizationComponentMW) GetUserAccreditations(ctx context.Context, realmName, userID string) ([]api.AccreditationRepresentation, error) {
+	var action = MGMTGetUserAccreditations.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetUserAccreditations(ctx, realmName, userID)
+}
+
+func (c *authorizationComponentMW) GetUserAccreditationsHistory(ctx context.Context, realmName, userID string) ([]api.AccreditationHistoryRepresentation, error) {
+	var action = MGMTGetUserAccreditationsHistory.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetUserAccreditationsHistory(ctx, realmName, userID)
+}
+
+func (c *authorizationComponentMW) RevokeAccreditation(ctx context.Context, realmName, userID, accreditationType string, reason api.AccreditationReasonRepresentation) error {
+	var action = MGMTRevokeAccreditation.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.RevokeAccreditation(ctx, realmName, userID, accreditationType, reason)
+}
+
+func (c *authorizationComponentMW) ReinstateAccreditation(ctx context.Context, realmName, userID, accreditationType string, reason api.AccreditationReasonRepresentation) error {
+	var action = MGMTReinstateAccreditation.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.ReinstateAccreditation(ctx, realmName, userID, accreditationType, reason)
+}
+
 func (c *authorizationComponentMW) GetUsers(ctx context.Context, realmName string, groupIDs []string, paramKV ...string) (api.UsersPageRepresentation, error) {
 	var action = MGMTGetUsers.String()
 	var targetRealm = realmName
@@ -254,6 +395,63 @@ func (c *authorizationComponentMW) CreateUser(ctx context.Context, realmName str
 	return c.next.CreateUser(ctx, realmName, user)
 }
 
+func (c *authorizationComponentMW) ImportUsers(ctx context.Context, realmName string, users []api.UserImportEntry, dryRun bool) (api.UsersImportReport, error) {
+	var action = MGMTImportUsers.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.UsersImportReport{}, err
+	}
+
+	// Imported users must be created in groups where the caller is allowed to create users
+	var createAction = MGMTCreateUser.String()
+	for _, entry := range users {
+		if entry.Reason != nil || entry.User.Groups == nil {
+			continue
+		}
+		for _, targetGroup := range *entry.User.Groups {
+			if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, createAction, targetRealm, targetGroup); err != nil {
+				return api.UsersImportReport{}, err
+			}
+		}
+	}
+
+	return c.next.ImportUsers(ctx, realmName, users, dryRun)
+}
+
+func (c *authorizationComponentMW) ExportUsers(ctx context.Context, realmName string, format string) (UsersExport, error) {
+	var action = MGMTExportUsers.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return nil, err
+	}
+
+	return c.next.ExportUsers(ctx, realmName, format)
+}
+
+func (c *authorizationComponentMW) LookupUsers(ctx context.Context, realmName string, lookup api.UserLookupRepresentation) ([]string, error) {
+	var action = MGMTLookupUsers.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return nil, err
+	}
+
+	return c.next.LookupUsers(ctx, realmName, lookup)
+}
+
+func (c *authorizationComponentMW) GetDuplicateUsers(ctx context.Context, realmName string) ([]api.DuplicateUsersRepresentation, error) {
+	var action = MGMTGetDuplicateUsers.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return nil, err
+	}
+
+	return c.next.GetDuplicateUsers(ctx, realmName)
+}
+
 func (c *authorizationComponentMW) GetUserChecks(ctx context.Context, realmName, userID string) ([]api.UserCheck, error) {
 	var action = MGMTGetUserChecks.String()
 	var targetRealm = realmName
@@ -265,12 +463,12 @@ func (c *authorizationComponentMW) GetUserChecks(ctx context.Context, realmName,
 	return c.next.GetUserChecks(ctx, realmName, userID)
 }
 
-func (c *authorizationComponentMW) GetUserAccountStatus(ctx context.Context, realmName, userID string) (map[string]bool, error) {
+func (c *authorizationComponentMW) GetUserAccountStatus(ctx context.Context, realmName, userID string) (api.UserAccountStatusRepresentation, error) {
 	var action = MGMTGetUserAccountStatus.String()
 	var targetRealm = realmName
 
 	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
-		return nil, err
+		return api.UserAccountStatusRepresentation{}, err
 	}
 
 	return c.next.GetUserAccountStatus(ctx, realmName, userID)
@@ -385,6 +583,39 @@ func (c *authorizationComponentMW) AddClientRolesToUser(ctx context.Context, rea
 	return c.next.AddClientRolesToUser(ctx, realmName, userID, clientID, roles)
 }
 
+func (c *authorizationComponentMW) DeleteClientRolesFromUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error {
+	var action = MGMTDeleteClientRolesFromUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.DeleteClientRolesFromUser(ctx, realmName, userID, clientID, roles)
+}
+
+func (c *authorizationComponentMW) AddRealmRolesToUser(ctx context.Context, realmName, userID string, roles []api.RoleRepresentation) error {
+	var action = MGMTAddRealmRolesToUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.AddRealmRolesToUser(ctx, realmName, userID, roles)
+}
+
+func (c *authorizationComponentMW) DeleteRealmRolesFromUser(ctx context.Context, realmName, userID string, roles []api.RoleRepresentation) error {
+	var action = MGMTDeleteRealmRolesFromUser.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetUser(ctx, action, targetRealm, userID); err != nil {
+		return err
+	}
+
+	return c.next.DeleteRealmRolesFromUser(ctx, realmName, userID, roles)
+}
+
 func (c *authorizationComponentMW) ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error) {
 	var action = MGMTResetPassword.String()
 	var targetRealm = realmName
@@ -528,6 +759,28 @@ func (c *authorizationComponentMW) GetRole(ctx context.Context, realmName string
 	return c.next.GetRole(ctx, realmName, roleID)
 }
 
+func (c *authorizationComponentMW) CreateRole(ctx context.Context, realmName string, role api.RoleRepresentation) (string, error) {
+	var action = MGMTCreateRole.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return "", err
+	}
+
+	return c.next.CreateRole(ctx, realmName, role)
+}
+
+func (c *authorizationComponentMW) DeleteRole(ctx context.Context, realmName string, roleID string) error {
+	var action = MGMTDeleteRole.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DeleteRole(ctx, realmName, roleID)
+}
+
 func (c *authorizationComponentMW) GetGroups(ctx context.Context, realmName string) ([]api.GroupRepresentation, error) {
 	var action = MGMTGetGroups.String()
 	var targetRealm = realmName
@@ -550,6 +803,17 @@ func (c *authorizationComponentMW) CreateGroup(ctx context.Context, realmName st
 	return c.next.CreateGroup(ctx, realmName, group)
 }
 
+func (c *authorizationComponentMW) UpdateGroup(ctx context.Context, realmName string, groupID string, group api.GroupRepresentation) error {
+	var action = MGMTUpdateGroup.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, groupID); err != nil {
+		return err
+	}
+
+	return c.next.UpdateGroup(ctx, realmName, groupID, group)
+}
+
 func (c *authorizationComponentMW) DeleteGroup(ctx context.Context, realmName string, groupID string) error {
 	var action = MGMTDeleteGroup.String()
 	var targetRealm = realmName
@@ -581,6 +845,115 @@ func (c *authorizationComponentMW) UpdateAuthorizations(ctx context.Context, rea
 	return c.next.UpdateAuthorizations(ctx, realmName, groupID, group)
 }
 
+func (c *authorizationComponentMW) ExplainAuthorization(ctx context.Context, realmName string, check api.AuthorizationCheckRepresentation) (api.AuthorizationExplanationRepresentation, error) {
+	var action = MGMTExplainAuthorization.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, realmName); err != nil {
+		return api.AuthorizationExplanationRepresentation{}, err
+	}
+
+	return c.next.ExplainAuthorization(ctx, realmName, check)
+}
+
+func (c *authorizationComponentMW) CopyAuthorizations(ctx context.Context, realmName string, groupID string, copyReq api.AuthorizationsCopyRepresentation) error {
+	var action = MGMTCopyAuthorizations.String()
+
+	// The caller must be allowed on both the source and the target groups
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, realmName, groupID); err != nil {
+		return err
+	}
+
+	var targetRealm = realmName
+	if copyReq.TargetRealm != nil {
+		targetRealm = *copyReq.TargetRealm
+	}
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, targetRealm, *copyReq.TargetGroupID); err != nil {
+		return err
+	}
+
+	return c.next.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+}
+
+func (c *authorizationComponentMW) GetAuthorizationTemplates(ctx context.Context) ([]api.AuthorizationTemplateRepresentation, error) {
+	var action = MGMTGetAuthorizationTemplates.String()
+
+	// Templates do not belong to a realm, so we pick the current realm of the user.
+	var targetRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return []api.AuthorizationTemplateRepresentation{}, err
+	}
+
+	return c.next.GetAuthorizationTemplates(ctx)
+}
+
+func (c *authorizationComponentMW) UpdateAuthorizationTemplate(ctx context.Context, templateName string, auth api.AuthorizationsRepresentation) error {
+	var action = MGMTUpdateAuthorizationTemplate.String()
+
+	// Templates do not belong to a realm, so we pick the current realm of the user.
+	var targetRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.UpdateAuthorizationTemplate(ctx, templateName, auth)
+}
+
+func (c *authorizationComponentMW) DeleteAuthorizationTemplate(ctx context.Context, templateName string) error {
+	var action = MGMTDeleteAuthorizationTemplate.String()
+
+	// Templates do not belong to a realm, so we pick the current realm of the user.
+	var targetRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.DeleteAuthorizationTemplate(ctx, templateName)
+}
+
+func (c *authorizationComponentMW) ApplyAuthorizationTemplate(ctx context.Context, realmName string, groupID string, application api.AuthorizationTemplateApplicationRepresentation) error {
+	var action = MGMTApplyAuthorizationTemplate.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, realmName, groupID); err != nil {
+		return err
+	}
+
+	return c.next.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+}
+
+func (c *authorizationComponentMW) GetAuthorizationVersions(ctx context.Context, realmName string, groupID string) ([]api.AuthorizationVersionRepresentation, error) {
+	var action = MGMTGetAuthorizationVersions.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, realmName, groupID); err != nil {
+		return []api.AuthorizationVersionRepresentation{}, err
+	}
+
+	return c.next.GetAuthorizationVersions(ctx, realmName, groupID)
+}
+
+func (c *authorizationComponentMW) GetAuthorizationsDiff(ctx context.Context, realmName string, groupID string, fromVersion int, toVersion int) (api.AuthorizationsDiffRepresentation, error) {
+	var action = MGMTGetAuthorizationVersions.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, realmName, groupID); err != nil {
+		return api.AuthorizationsDiffRepresentation{}, err
+	}
+
+	return c.next.GetAuthorizationsDiff(ctx, realmName, groupID, fromVersion, toVersion)
+}
+
+func (c *authorizationComponentMW) RollbackAuthorizations(ctx context.Context, realmName string, groupID string, version int) error {
+	var action = MGMTRollbackAuthorizations.String()
+
+	if err := c.authManager.CheckAuthorizationOnTargetGroupID(ctx, action, realmName, groupID); err != nil {
+		return err
+	}
+
+	return c.next.RollbackAuthorizations(ctx, realmName, groupID, version)
+}
+
 func (c *authorizationComponentMW) GetClientRoles(ctx context.Context, realmName, idClient string) ([]api.RoleRepresentation, error) {
 	var action = MGMTGetClientRoles.String()
 	var targetRealm = realmName
@@ -689,3 +1062,83 @@ func (c *authorizationComponentMW) LinkShadowUser(ctx context.Context, realmName
 
 	return c.next.LinkShadowUser(ctx, realmName, userID, provider, fedID)
 }
+
+type authorizationPendingRequestsComponentMW struct {
+	authManager security.AuthorizationManager
+	logger      log.Logger
+	next        PendingRequestsComponent
+}
+
+// MakeAuthorizationPendingRequestsComponentMW checks authorization and return an error if the action is not allowed.
+// The approver of a request must also be allowed to perform the operation of the request
+func MakeAuthorizationPendingRequestsComponentMW(logger log.Logger, authorizationManager security.AuthorizationManager) func(PendingRequestsComponent) PendingRequestsComponent {
+	return func(next PendingRequestsComponent) PendingRequestsComponent {
+		return &authorizationPendingRequestsComponentMW{
+			authManager: authorizationManager,
+			logger:      logger,
+			next:        next,
+		}
+	}
+}
+
+func (c *authorizationPendingRequestsComponentMW) GetPendingRequests(ctx context.Context, realmName string) ([]api.PendingRequestRepresentation, error) {
+	var action = MGMTGetPendingRequests.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return []api.PendingRequestRepresentation{}, err
+	}
+
+	return c.next.GetPendingRequests(ctx, realmName)
+}
+
+func (c *authorizationPendingRequestsComponentMW) GetPendingRequest(ctx context.Context, realmName string, requestID int64) (api.PendingRequestRepresentation, error) {
+	var action = MGMTGetPendingRequests.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.PendingRequestRepresentation{}, err
+	}
+
+	return c.next.GetPendingRequest(ctx, realmName, requestID)
+}
+
+func (c *authorizationPendingRequestsComponentMW) ApprovePendingRequest(ctx context.Context, realmName string, requestID int64) (api.PendingRequestRepresentation, error) {
+	var action = MGMTApprovePendingRequest.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return api.PendingRequestRepresentation{}, err
+	}
+
+	request, err := c.next.GetPendingRequest(ctx, realmName, requestID)
+	if err != nil {
+		return api.PendingRequestRepresentation{}, err
+	}
+
+	// The approver executes the operation: it must be allowed to perform it on the same target
+	switch {
+	case request.UserID != nil:
+		err = c.authManager.CheckAuthorizationOnTargetUser(ctx, request.Action, targetRealm, *request.UserID)
+	case request.GroupID != nil:
+		err = c.authManager.CheckAuthorizationOnTargetGroupID(ctx, request.Action, targetRealm, *request.GroupID)
+	default:
+		err = c.authManager.CheckAuthorizationOnTargetRealm(ctx, request.Action, targetRealm)
+	}
+	if err != nil {
+		return api.PendingRequestRepresentation{}, err
+	}
+
+	return c.next.ApprovePendingRequest(ctx, realmName, requestID)
+}
+
+func (c *authorizationPendingRequestsComponentMW) RejectPendingRequest(ctx context.Context, realmName string, requestID int64) error {
+	var action = MGMTRejectPendingRequest.String()
+	var targetRealm = realmName
+
+	if err := c.authManager.CheckAuthorizationOnTargetRealm(ctx, action, targetRealm); err != nil {
+		return err
+	}
+
+	return c.next.RejectPendingRequest(ctx, realmName, requestID)
+}
@@ -2,6 +2,7 @@ package management
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/cloudtrust/common-service/configuration"
@@ -51,6 +52,7 @@ func TestDeny(t *testing.T) {
 	var clientURI = "https://wwww.cloudtrust.io"
 
 	var provider = "provider"
+	var accreditationType = "SHADOW"
 	mockAuthorizationDBReader.EXPECT().GetAuthorizations(gomock.Any()).Return([]configuration.Authorization{}, nil)
 
 	mockKeycloakClient.EXPECT().GetGroupNamesOfUser(gomock.Any(), accessToken, realmName, userID).Return([]string{
@@ -63,6 +65,8 @@ func TestDeny(t *testing.T) {
 		Groups:   &groupIDs,
 	}
 
+	var importedUsers = []api.UserImportEntry{{Line: 2, User: user}}
+
 	var role = api.RoleRepresentation{
 		ID:   &roleID,
 		Name: &roleName,
@@ -78,6 +82,19 @@ func TestDeny(t *testing.T) {
 		Matrix: &authzMatrix,
 	}
 
+	var check = api.AuthorizationCheckRepresentation{
+		GroupID: &groupID,
+	}
+
+	var copyReq = api.AuthorizationsCopyRepresentation{
+		TargetGroupID: &groupID,
+	}
+
+	var templateName = "template"
+	var application = api.AuthorizationTemplateApplicationRepresentation{
+		TemplateName: &templateName,
+	}
+
 	var password = api.PasswordRepresentation{
 		Value: &pass,
 	}
@@ -121,24 +138,54 @@ func TestDeny(t *testing.T) {
 		_, err = authorizationMW.GetClients(ctx, realmName)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		_, err = authorizationMW.CreateClient(ctx, realmName, api.ClientRepresentation{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.UpdateClient(ctx, realmName, clientID, api.ClientRepresentation{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.DisableClient(ctx, realmName, clientID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.UpdateClientRedirectURIs(ctx, realmName, clientID, []string{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.RotateClientSecret(ctx, realmName, clientID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		_, err = authorizationMW.GetRequiredActions(ctx, realmName)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
 		err = authorizationMW.DeleteUser(ctx, realmName, userID)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		err = authorizationMW.RestoreUser(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		_, err = authorizationMW.GetUser(ctx, realmName, userID)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
 		err = authorizationMW.UpdateUser(ctx, realmName, userID, user)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
-		err = authorizationMW.LockUser(ctx, realmName, userID)
+		err = authorizationMW.LockUser(ctx, realmName, userID, api.UserLockRepresentation{})
 		assert.Equal(t, security.ForbiddenError{}, err)
 
 		err = authorizationMW.UnlockUser(ctx, realmName, userID)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		_, err = authorizationMW.GetUserAccreditations(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.GetUserAccreditationsHistory(ctx, realmName, userID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.RevokeAccreditation(ctx, realmName, userID, accreditationType, api.AccreditationReasonRepresentation{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.ReinstateAccreditation(ctx, realmName, userID, accreditationType, api.AccreditationReasonRepresentation{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
 		_, err = authorizationMW.GetUsers(ctx, realmName, groupIDs)
 		assert.Equal(t, security.ForbiddenError{}, err)
@@ -147,6 +194,18 @@ func TestDeny(t *testing.T) {
 		_, err = authorizationMW.CreateUser(ctx, realmName, user)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		_, err = authorizationMW.ImportUsers(ctx, realmName, importedUsers, false)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.ExportUsers(ctx, realmName, "csv")
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.LookupUsers(ctx, realmName, api.UserLookupRepresentation{})
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.GetDuplicateUsers(ctx, realmName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		_, err = authorizationMW.GetUserChecks(ctx, realmName, userID)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
@@ -180,6 +239,15 @@ func TestDeny(t *testing.T) {
 		err = authorizationMW.AddClientRolesToUser(ctx, realmName, userID, clientID, roles)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		err = authorizationMW.DeleteClientRolesFromUser(ctx, realmName, userID, clientID, roles)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.AddRealmRolesToUser(ctx, realmName, userID, roles)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.DeleteRealmRolesFromUser(ctx, realmName, userID, roles)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		_, err = authorizationMW.ResetPassword(ctx, realmName, userID, password)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
@@ -219,12 +287,22 @@ func TestDeny(t *testing.T) {
 		_, err = authorizationMW.GetRole(ctx, realmName, roleID)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		_, err = authorizationMW.CreateRole(ctx, realmName, role)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.DeleteRole(ctx, realmName, roleID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		_, err = authorizationMW.GetGroups(ctx, realmName)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
 		_, err = authorizationMW.CreateGroup(ctx, realmName, group)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		err = authorizationMW.UpdateGroup(ctx, realmName, groupID, group)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
 		err = authorizationMW.DeleteGroup(ctx, realmName, groupID)
 		assert.Equal(t, security.ForbiddenError{}, err)
@@ -237,6 +315,38 @@ func TestDeny(t *testing.T) {
 		err = authorizationMW.UpdateAuthorizations(ctx, realmName, groupID, authz)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
+		_, err = authorizationMW.ExplainAuthorization(ctx, realmName, check)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		err = authorizationMW.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		_, err = authorizationMW.GetAuthorizationTemplates(ctx)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.UpdateAuthorizationTemplate(ctx, templateName, authz)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		err = authorizationMW.DeleteAuthorizationTemplate(ctx, templateName)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		err = authorizationMW.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		_, err = authorizationMW.GetAuthorizationVersions(ctx, realmName, groupID)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		_, err = authorizationMW.GetAuthorizationsDiff(ctx, realmName, groupID, 1, 2)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		err = authorizationMW.RollbackAuthorizations(ctx, realmName, groupID, 1)
+		assert.Equal(t, security.ForbiddenError{}, err)
+
 		_, err = authorizationMW.GetClientRoles(ctx, realmName, clientID)
 		assert.Equal(t, security.ForbiddenError{}, err)
 
@@ -302,6 +412,7 @@ func TestAllowed(t *testing.T) {
 	var clientURI = "https://wwww.cloudtrust.io"
 
 	var provider = "provider"
+	var accreditationType = "SHADOW"
 
 	mockKeycloakClient.EXPECT().GetGroupNamesOfUser(gomock.Any(), accessToken, realmName, userID).Return([]string{groupName}, nil).AnyTimes()
 
@@ -311,6 +422,8 @@ func TestAllowed(t *testing.T) {
 		Groups:   &groupIDs,
 	}
 
+	var importedUsers = []api.UserImportEntry{{Line: 2, User: user}}
+
 	var role = api.RoleRepresentation{
 		ID:   &roleID,
 		Name: &roleName,
@@ -326,6 +439,19 @@ func TestAllowed(t *testing.T) {
 		Matrix: &authzMatrix,
 	}
 
+	var check = api.AuthorizationCheckRepresentation{
+		GroupID: &groupID,
+	}
+
+	var copyReq = api.AuthorizationsCopyRepresentation{
+		TargetGroupID: &groupID,
+	}
+
+	var templateName = "template"
+	var application = api.AuthorizationTemplateApplicationRepresentation{
+		TemplateName: &templateName,
+	}
+
 	var password = api.PasswordRepresentation{
 		Value: &pass,
 	}
@@ -386,6 +512,26 @@ func TestAllowed(t *testing.T) {
 		_, err = authorizationMW.GetClients(ctx, realmName)
 		assert.Nil(t, err)
 
+		mockManagementComponent.EXPECT().CreateClient(ctx, realmName, api.ClientRepresentation{}).Return("", nil).Times(1)
+		_, err = authorizationMW.CreateClient(ctx, realmName, api.ClientRepresentation{})
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().UpdateClient(ctx, realmName, clientID, api.ClientRepresentation{}).Return(nil).Times(1)
+		err = authorizationMW.UpdateClient(ctx, realmName, clientID, api.ClientRepresentation{})
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().DisableClient(ctx, realmName, clientID).Return(nil).Times(1)
+		err = authorizationMW.DisableClient(ctx, realmName, clientID)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().UpdateClientRedirectURIs(ctx, realmName, clientID, []string{}).Return(nil).Times(1)
+		err = authorizationMW.UpdateClientRedirectURIs(ctx, realmName, clientID, []string{})
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().RotateClientSecret(ctx, realmName, clientID).Return(api.ClientSecretRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.RotateClientSecret(ctx, realmName, clientID)
+		assert.Nil(t, err)
+
 		mockManagementComponent.EXPECT().GetRequiredActions(ctx, realmName).Return([]api.RequiredActionRepresentation{}, nil).Times(1)
 		_, err = authorizationMW.GetRequiredActions(ctx, realmName)
 		assert.Nil(t, err)
@@ -394,6 +540,10 @@ func TestAllowed(t *testing.T) {
 		err = authorizationMW.DeleteUser(ctx, realmName, userID)
 		assert.Nil(t, err)
 
+		mockManagementComponent.EXPECT().RestoreUser(ctx, realmName, userID).Return(nil).Times(1)
+		err = authorizationMW.RestoreUser(ctx, realmName, userID)
+		assert.Nil(t, err)
+
 		mockManagementComponent.EXPECT().GetUser(ctx, realmName, userID).Return(api.UserRepresentation{}, nil).Times(1)
 		_, err = authorizationMW.GetUser(ctx, realmName, userID)
 		assert.Nil(t, err)
@@ -402,14 +552,30 @@ func TestAllowed(t *testing.T) {
 		err = authorizationMW.UpdateUser(ctx, realmName, userID, user)
 		assert.Nil(t, err)
 
-		mockManagementComponent.EXPECT().LockUser(ctx, realmName, userID).Return(nil).Times(1)
-		err = authorizationMW.LockUser(ctx, realmName, userID)
+		mockManagementComponent.EXPECT().LockUser(ctx, realmName, userID, api.UserLockRepresentation{}).Return(nil).Times(1)
+		err = authorizationMW.LockUser(ctx, realmName, userID, api.UserLockRepresentation{})
 		assert.Nil(t, err)
 
 		mockManagementComponent.EXPECT().UnlockUser(ctx, realmName, userID).Return(nil).Times(1)
 		err = authorizationMW.UnlockUser(ctx, realmName, userID)
 		assert.Nil(t, err)
 
+		mockManagementComponent.EXPECT().GetUserAccreditations(ctx, realmName, userID).Return(nil, nil).Times(1)
+		_, err = authorizationMW.GetUserAccreditations(ctx, realmName, userID)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().GetUserAccreditationsHistory(ctx, realmName, userID).Return(nil, nil).Times(1)
+		_, err = authorizationMW.GetUserAccreditationsHistory(ctx, realmName, userID)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().RevokeAccreditation(ctx, realmName, userID, accreditationType, api.AccreditationReasonRepresentation{}).Return(nil).Times(1)
+		err = authorizationMW.RevokeAccreditation(ctx, realmName, userID, accreditationType, api.AccreditationReasonRepresentation{})
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().ReinstateAccreditation(ctx, realmName, userID, accreditationType, api.AccreditationReasonRepresentation{}).Return(nil).Times(1)
+		err = authorizationMW.ReinstateAccreditation(ctx, realmName, userID, accreditationType, api.AccreditationReasonRepresentation{})
+		assert.Nil(t, err)
+
 		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
 		mockManagementComponent.EXPECT().GetUsers(ctx, realmName, groupIDs).Return(api.UsersPageRepresentation{}, nil).Times(1)
 		_, err = authorizationMW.GetUsers(ctx, realmName, groupIDs)
@@ -420,11 +586,28 @@ func TestAllowed(t *testing.T) {
 		_, err = authorizationMW.CreateUser(ctx, realmName, user)
 		assert.Nil(t, err)
 
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		mockManagementComponent.EXPECT().ImportUsers(ctx, realmName, importedUsers, true).Return(api.UsersImportReport{}, nil).Times(1)
+		_, err = authorizationMW.ImportUsers(ctx, realmName, importedUsers, true)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realmName, "csv").Return(nil, nil).Times(1)
+		_, err = authorizationMW.ExportUsers(ctx, realmName, "csv")
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().LookupUsers(ctx, realmName, api.UserLookupRepresentation{}).Return([]string{}, nil).Times(1)
+		_, err = authorizationMW.LookupUsers(ctx, realmName, api.UserLookupRepresentation{})
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().GetDuplicateUsers(ctx, realmName).Return([]api.DuplicateUsersRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.GetDuplicateUsers(ctx, realmName)
+		assert.Nil(t, err)
+
 		mockManagementComponent.EXPECT().GetUserChecks(ctx, realmName, userID).Return([]api.UserCheck{}, nil).Times(1)
 		_, err = authorizationMW.GetUserChecks(ctx, realmName, userID)
 		assert.Nil(t, err)
 
-		mockManagementComponent.EXPECT().GetUserAccountStatus(ctx, realmName, userID).Return(map[string]bool{"enabled": true}, nil).Times(1)
+		mockManagementComponent.EXPECT().GetUserAccountStatus(ctx, realmName, userID).Return(api.UserAccountStatusRepresentation{Enabled: true}, nil).Times(1)
 		_, err = authorizationMW.GetUserAccountStatus(ctx, realmName, userID)
 		assert.Nil(t, err)
 
@@ -454,6 +637,18 @@ func TestAllowed(t *testing.T) {
 		err = authorizationMW.AddClientRolesToUser(ctx, realmName, userID, clientID, roles)
 		assert.Nil(t, err)
 
+		mockManagementComponent.EXPECT().DeleteClientRolesFromUser(ctx, realmName, userID, clientID, roles).Return(nil).Times(1)
+		err = authorizationMW.DeleteClientRolesFromUser(ctx, realmName, userID, clientID, roles)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().AddRealmRolesToUser(ctx, realmName, userID, roles).Return(nil).Times(1)
+		err = authorizationMW.AddRealmRolesToUser(ctx, realmName, userID, roles)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().DeleteRealmRolesFromUser(ctx, realmName, userID, roles).Return(nil).Times(1)
+		err = authorizationMW.DeleteRealmRolesFromUser(ctx, realmName, userID, roles)
+		assert.Nil(t, err)
+
 		mockManagementComponent.EXPECT().ResetPassword(ctx, realmName, userID, password).Return("", nil).Times(1)
 		_, err = authorizationMW.ResetPassword(ctx, realmName, userID, password)
 		assert.Nil(t, err)
@@ -507,6 +702,14 @@ func TestAllowed(t *testing.T) {
 		_, err = authorizationMW.GetRole(ctx, realmName, roleID)
 		assert.Nil(t, err)
 
+		mockManagementComponent.EXPECT().CreateRole(ctx, realmName, role).Return("", nil).Times(1)
+		_, err = authorizationMW.CreateRole(ctx, realmName, role)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().DeleteRole(ctx, realmName, roleID).Return(nil).Times(1)
+		err = authorizationMW.DeleteRole(ctx, realmName, roleID)
+		assert.Nil(t, err)
+
 		mockManagementComponent.EXPECT().GetGroups(ctx, realmName).Return([]api.GroupRepresentation{}, nil).Times(1)
 		_, err = authorizationMW.GetGroups(ctx, realmName)
 		assert.Nil(t, err)
@@ -527,6 +730,11 @@ func TestAllowed(t *testing.T) {
 		_, err = authorizationMW.CreateGroup(ctx, realmName, group)
 		assert.Nil(t, err)
 
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		mockManagementComponent.EXPECT().UpdateGroup(ctx, realmName, groupID, group).Return(nil).Times(1)
+		err = authorizationMW.UpdateGroup(ctx, realmName, groupID, group)
+		assert.Nil(t, err)
+
 		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
 		mockManagementComponent.EXPECT().DeleteGroup(ctx, realmName, groupID).Return(nil).Times(1)
 		err = authorizationMW.DeleteGroup(ctx, realmName, groupID)
@@ -542,6 +750,47 @@ func TestAllowed(t *testing.T) {
 		err = authorizationMW.UpdateAuthorizations(ctx, realmName, groupID, authz)
 		assert.Nil(t, err)
 
+		mockManagementComponent.EXPECT().ExplainAuthorization(ctx, realmName, check).Return(api.AuthorizationExplanationRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.ExplainAuthorization(ctx, realmName, check)
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(2)
+		mockManagementComponent.EXPECT().CopyAuthorizations(ctx, realmName, groupID, copyReq).Return(nil).Times(1)
+		err = authorizationMW.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().GetAuthorizationTemplates(ctx).Return([]api.AuthorizationTemplateRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.GetAuthorizationTemplates(ctx)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().UpdateAuthorizationTemplate(ctx, templateName, authz).Return(nil).Times(1)
+		err = authorizationMW.UpdateAuthorizationTemplate(ctx, templateName, authz)
+		assert.Nil(t, err)
+
+		mockManagementComponent.EXPECT().DeleteAuthorizationTemplate(ctx, templateName).Return(nil).Times(1)
+		err = authorizationMW.DeleteAuthorizationTemplate(ctx, templateName)
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		mockManagementComponent.EXPECT().ApplyAuthorizationTemplate(ctx, realmName, groupID, application).Return(nil).Times(1)
+		err = authorizationMW.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		mockManagementComponent.EXPECT().GetAuthorizationVersions(ctx, realmName, groupID).Return([]api.AuthorizationVersionRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.GetAuthorizationVersions(ctx, realmName, groupID)
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		mockManagementComponent.EXPECT().GetAuthorizationsDiff(ctx, realmName, groupID, 1, 2).Return(api.AuthorizationsDiffRepresentation{}, nil).Times(1)
+		_, err = authorizationMW.GetAuthorizationsDiff(ctx, realmName, groupID, 1, 2)
+		assert.Nil(t, err)
+
+		mockKeycloakClient.EXPECT().GetGroupName(gomock.Any(), gomock.Any(), realmName, groupID).Return(groupName, nil).Times(1)
+		mockManagementComponent.EXPECT().RollbackAuthorizations(ctx, realmName, groupID, 1).Return(nil).Times(1)
+		err = authorizationMW.RollbackAuthorizations(ctx, realmName, groupID, 1)
+		assert.Nil(t, err)
+
 		mockManagementComponent.EXPECT().GetClientRoles(ctx, realmName, clientID).Return([]api.RoleRepresentation{}, nil).Times(1)
 		_, err = authorizationMW.GetClientRoles(ctx, realmName, clientID)
 		assert.Nil(t, err)
@@ -583,3 +832,87 @@ func TestAllowed(t *testing.T) {
 		assert.Nil(t, err)
 	}
 }
+
+func TestPendingRequestsAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockLogger = log.NewNopLogger()
+	var mockKeycloakClient = mock.NewKcClientAuth(mockCtrl)
+	var mockPendingRequests = mock.NewPendingRequestsComponent(mockCtrl)
+	var mockAuthorizationDBReader = mock.NewAuthorizationDBReader(mockCtrl)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var toe = "toe"
+	var any = "*"
+	var userID = "123-456-789"
+	var requestID = int64(12)
+	var deleteUser = MGMTDeleteUser.String()
+	var resetPassword = MGMTResetPassword.String()
+
+	var authorizations = []configuration.Authorization{}
+	for _, action := range []security.Action{MGMTGetPendingRequests, MGMTApprovePendingRequest, MGMTDeleteUser} {
+		var action = action.String()
+		authorizations = append(authorizations, configuration.Authorization{
+			RealmID:         &realmName,
+			GroupName:       &toe,
+			Action:          &action,
+			TargetRealmID:   &any,
+			TargetGroupName: &any,
+		})
+	}
+	mockAuthorizationDBReader.EXPECT().GetAuthorizations(gomock.Any()).Return(authorizations, nil)
+	mockKeycloakClient.EXPECT().GetGroupNamesOfUser(gomock.Any(), accessToken, realmName, userID).Return([]string{"titi"}, nil).AnyTimes()
+
+	var authorizationManager, err = security.NewAuthorizationManager(mockAuthorizationDBReader, mockKeycloakClient, log.NewNopLogger())
+	assert.Nil(t, err)
+
+	var authorizationMW = MakeAuthorizationPendingRequestsComponentMW(mockLogger, authorizationManager)(mockPendingRequests)
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextGroups, []string{toe})
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+	t.Run("Get pending requests", func(t *testing.T) {
+		mockPendingRequests.EXPECT().GetPendingRequests(ctx, realmName).Return([]api.PendingRequestRepresentation{}, nil)
+		_, err := authorizationMW.GetPendingRequests(ctx, realmName)
+		assert.Nil(t, err)
+
+		mockPendingRequests.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(api.PendingRequestRepresentation{}, nil)
+		_, err = authorizationMW.GetPendingRequest(ctx, realmName, requestID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Approve: approver is allowed to perform the operation", func(t *testing.T) {
+		var request = api.PendingRequestRepresentation{ID: requestID, Action: deleteUser, UserID: &userID}
+		mockPendingRequests.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(request, nil)
+		mockPendingRequests.EXPECT().ApprovePendingRequest(ctx, realmName, requestID).Return(request, nil)
+		_, err := authorizationMW.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Approve: approver is not allowed to perform the operation", func(t *testing.T) {
+		var request = api.PendingRequestRepresentation{ID: requestID, Action: resetPassword, UserID: &userID}
+		mockPendingRequests.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(request, nil)
+		_, err := authorizationMW.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.IsType(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Approve: request can't be loaded", func(t *testing.T) {
+		var expectedErr = errors.New("db error")
+		mockPendingRequests.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(api.PendingRequestRepresentation{}, expectedErr)
+		_, err := authorizationMW.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.Equal(t, expectedErr, err)
+	})
+
+	t.Run("Reject: not allowed", func(t *testing.T) {
+		var err = authorizationMW.RejectPendingRequest(ctx, realmName, requestID)
+		assert.IsType(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Get pending requests: other realm not allowed", func(t *testing.T) {
+		var ctx = context.WithValue(ctx, cs.CtContextGroups, []string{"other"})
+		_, err := authorizationMW.GetPendingRequests(ctx, realmName)
+		assert.IsType(t, security.ForbiddenError{}, err)
+	})
+}
@@ -4,7 +4,12 @@ import (
 	"errors"
 
 	"github.com/cloudtrust/common-service/configuration"
+	"github.com/cloudtrust/common-service/security"
 	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/pkg/events"
+	"github.com/cloudtrust/keycloak-bridge/pkg/kyc"
+	"github.com/cloudtrust/keycloak-bridge/pkg/statistics"
 )
 
 // Validate the content of the provided array. Returns an error if any issue is detected
@@ -53,3 +58,98 @@ func checkTarget(authorizations []configuration.Authorization, allowedTargetReal
 	}
 	return nil
 }
+
+// RemapAuthorizations copies authorizations for the given realm and group. Target realms found in realmMapping and
+// target group names found in groupMapping are renamed. The wildcard '*' is never renamed
+func RemapAuthorizations(authorizations []configuration.Authorization, realmID, groupName string, realmMapping, groupMapping map[string]string) []configuration.Authorization {
+	var res = make([]configuration.Authorization, 0, len(authorizations))
+	for _, authz := range authorizations {
+		var remapped = configuration.Authorization{
+			RealmID:         &realmID,
+			GroupName:       &groupName,
+			Action:          authz.Action,
+			TargetRealmID:   remapName(authz.TargetRealmID, realmMapping),
+			TargetGroupName: remapName(authz.TargetGroupName, groupMapping),
+		}
+		res = append(res, remapped)
+	}
+	return res
+}
+
+func remapName(name *string, mapping map[string]string) *string {
+	if name == nil || *name == "*" {
+		return name
+	}
+	if newName, ok := mapping[*name]; ok {
+		return &newName
+	}
+	return name
+}
+
+// FindAuthorizationAction looks for an action among the actions of all the components protected by the authorization manager
+func FindAuthorizationAction(name string) (security.Action, bool) {
+	for _, componentActions := range [][]security.Action{events.GetActions(), kyc.GetActions(), GetActions(), statistics.GetActions()} {
+		for _, action := range componentActions {
+			if action.Name == name {
+				return action, true
+			}
+		}
+	}
+	return security.Action{}, false
+}
+
+// ExplainGroupAuthorization evaluates the authorizations of a group for an action the same way the authorization manager does.
+// The target group is only considered for actions of scope group: without target group, any authorization on the target realm is enough
+func ExplainGroupAuthorization(groupName string, authorizations []configuration.Authorization, action security.Action, targetRealm string, targetGroup *string) api.GroupAuthorizationExplanationRepresentation {
+	var res = api.GroupAuthorizationExplanationRepresentation{
+		GroupName:    groupName,
+		MatchedRules: []api.AuthorizationRuleRepresentation{},
+		MissingRules: []api.AuthorizationRuleRepresentation{},
+	}
+	var checkGroup = action.Scope == security.ScopeGroup && targetGroup != nil
+
+	for _, authz := range authorizations {
+		if authz.Action == nil || *authz.Action != action.Name || authz.TargetRealmID == nil {
+			continue
+		}
+
+		var wildcards []string
+		if *authz.TargetRealmID == "*" {
+			wildcards = append(wildcards, constants.TargetRealm)
+		} else if *authz.TargetRealmID != targetRealm {
+			continue
+		}
+
+		if checkGroup {
+			if authz.TargetGroupName == nil {
+				continue
+			}
+			if *authz.TargetGroupName == "*" {
+				wildcards = append(wildcards, constants.TargetGroup)
+			} else if *authz.TargetGroupName != *targetGroup {
+				continue
+			}
+		}
+
+		res.MatchedRules = append(res.MatchedRules, api.AuthorizationRuleRepresentation{
+			Action:          action.Name,
+			TargetRealm:     authz.TargetRealmID,
+			TargetGroupName: authz.TargetGroupName,
+			Wildcards:       wildcards,
+		})
+	}
+
+	res.Allowed = len(res.MatchedRules) > 0
+	if !res.Allowed {
+		var missing = api.AuthorizationRuleRepresentation{
+			Action:      action.Name,
+			TargetRealm: &targetRealm,
+		}
+		if checkGroup {
+			missing.TargetGroupName = targetGroup
+		}
+		res.MissingRules = append(res.MissingRules, missing)
+	}
+
+	return res
+}
@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/cloudtrust/common-service/configuration"
+	"github.com/cloudtrust/keycloak-bridge/pkg/events"
+	"github.com/cloudtrust/keycloak-bridge/pkg/kyc"
+	"github.com/cloudtrust/keycloak-bridge/pkg/statistics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -124,3 +127,111 @@ func TestValidate(t *testing.T) {
 		assert.Nil(t, err)
 	}
 }
+
+func TestRemapAuthorizations(t *testing.T) {
+	var realmName = "NEWCUSTOMER"
+	var groupName = "newcustomer_admins"
+	var oldRealm = "DEP"
+	var otherRealm = "OTHER"
+	var oldGroup = "dep_agents"
+	var otherGroup = "other_agents"
+	var getUser = "MGMT_GetUser"
+	var getRealm = "MGMT_GetRealm"
+	var star = "*"
+	var authorizations = []configuration.Authorization{
+		{RealmID: &oldRealm, GroupName: &oldGroup, Action: &getUser, TargetRealmID: &oldRealm, TargetGroupName: &oldGroup},
+		{RealmID: &oldRealm, GroupName: &oldGroup, Action: &getUser, TargetRealmID: &otherRealm, TargetGroupName: &otherGroup},
+		{RealmID: &oldRealm, GroupName: &oldGroup, Action: &getRealm, TargetRealmID: &star},
+		{RealmID: &oldRealm, GroupName: &oldGroup, Action: &getRealm},
+	}
+	var realmMapping = map[string]string{oldRealm: realmName, star: realmName}
+	var groupMapping = map[string]string{oldGroup: "newcustomer_agents"}
+
+	var res = RemapAuthorizations(authorizations, realmName, groupName, realmMapping, groupMapping)
+	assert.Len(t, res, 4)
+	for _, authz := range res {
+		assert.Equal(t, realmName, *authz.RealmID)
+		assert.Equal(t, groupName, *authz.GroupName)
+	}
+	assert.Equal(t, realmName, *res[0].TargetRealmID)
+	assert.Equal(t, "newcustomer_agents", *res[0].TargetGroupName)
+	assert.Equal(t, otherRealm, *res[1].TargetRealmID)
+	assert.Equal(t, otherGroup, *res[1].TargetGroupName)
+	assert.Equal(t, star, *res[2].TargetRealmID)
+	assert.Nil(t, res[2].TargetGroupName)
+	assert.Nil(t, res[3].TargetRealmID)
+
+	// Source authorizations are not modified
+	assert.Equal(t, oldRealm, *authorizations[0].TargetRealmID)
+	assert.Equal(t, oldGroup, *authorizations[0].GroupName)
+}
+
+func TestFindAuthorizationAction(t *testing.T) {
+	for _, name := range []string{MGMTGetUser.Name, events.EVGetEvents.Name, statistics.STGetStatistics.Name, kyc.KYCValidateUser.Name} {
+		var action, ok = FindAuthorizationAction(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, name, action.Name)
+	}
+
+	var _, ok = FindAuthorizationAction("UNKNOWN_Action")
+	assert.False(t, ok)
+}
+
+func TestExplainGroupAuthorization(t *testing.T) {
+	var realmName = "master"
+	var groupName = "agents"
+	var targetRealm = "DEP"
+	var targetGroup = "customers"
+	var star = "*"
+	var newAuthz = func(action string, targetRealm *string, targetGroup *string) configuration.Authorization {
+		return configuration.Authorization{RealmID: &realmName, GroupName: &groupName, Action: &action, TargetRealmID: targetRealm, TargetGroupName: targetGroup}
+	}
+
+	t.Run("Group scope allowed on exact target", func(t *testing.T) {
+		var authorizations = []configuration.Authorization{
+			newAuthz(MGMTGetUser.Name, &targetRealm, &targetGroup),
+			newAuthz(MGMTDeleteUser.Name, &targetRealm, &targetGroup),
+		}
+		var res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetUser, targetRealm, &targetGroup)
+		assert.True(t, res.Allowed)
+		assert.Len(t, res.MatchedRules, 1)
+		assert.Nil(t, res.MatchedRules[0].Wildcards)
+		assert.Len(t, res.MissingRules, 0)
+	})
+
+	t.Run("Group scope allowed through wildcards", func(t *testing.T) {
+		var authorizations = []configuration.Authorization{newAuthz(MGMTGetUser.Name, &star, &star)}
+		var res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetUser, targetRealm, &targetGroup)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, []string{"targetRealm", "targetGroup"}, res.MatchedRules[0].Wildcards)
+	})
+
+	t.Run("Group scope denied on other group", func(t *testing.T) {
+		var otherGroup = "other"
+		var authorizations = []configuration.Authorization{newAuthz(MGMTGetUser.Name, &targetRealm, &otherGroup)}
+		var res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetUser, targetRealm, &targetGroup)
+		assert.False(t, res.Allowed)
+		assert.Len(t, res.MatchedRules, 0)
+		assert.Len(t, res.MissingRules, 1)
+		assert.Equal(t, targetRealm, *res.MissingRules[0].TargetRealm)
+		assert.Equal(t, targetGroup, *res.MissingRules[0].TargetGroupName)
+
+		// Without target group, any authorization on the realm is enough
+		res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetUser, targetRealm, nil)
+		assert.True(t, res.Allowed)
+	})
+
+	t.Run("Realm scope", func(t *testing.T) {
+		var authorizations = []configuration.Authorization{newAuthz(MGMTGetRealm.Name, &targetRealm, nil), newAuthz(MGMTGetRealms.Name, nil, nil)}
+		var res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetRealm, targetRealm, &targetGroup)
+		assert.True(t, res.Allowed)
+
+		res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetRealm, "other", nil)
+		assert.False(t, res.Allowed)
+		assert.Nil(t, res.MissingRules[0].TargetGroupName)
+
+		// Authorizations without target realm never grant anything
+		res = ExplainGroupAuthorization(groupName, authorizations, MGMTGetRealms, realmName, nil)
+		assert.False(t, res.Allowed)
+	})
+}
@@ -3,13 +3,19 @@ package management
 import (
 	"context"
 	"database/sql"
+	"io"
+	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/common-service/database"
 	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/security"
 	api "github.com/cloudtrust/keycloak-bridge/api/management"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
@@ -20,8 +26,13 @@ import (
 
 const (
 	initPasswordAction = "sms-password-set"
+
+	exportUsersPageSize = 500
 )
 
+// UsersExport writes an export of users in the given writer
+type UsersExport func(w io.Writer) error
+
 // KeycloakClient are methods from keycloak-client used by this component
 type KeycloakClient interface {
 	GetRealms(accessToken string) ([]kc.RealmRepresentation, error)
@@ -29,6 +40,9 @@ type KeycloakClient interface {
 	GetRequiredActions(accessToken string, realmName string) ([]kc.RequiredActionProviderRepresentation, error)
 	GetClient(accessToken string, realmName, idClient string) (kc.ClientRepresentation, error)
 	GetClients(accessToken string, realmName string, paramKV ...string) ([]kc.ClientRepresentation, error)
+	CreateClient(accessToken string, realmName string, client kc.ClientRepresentation) (string, error)
+	UpdateClient(accessToken string, realmName, idClient string, client kc.ClientRepresentation) error
+	RegenerateSecret(accessToken string, realmName, idClient string) (kc.CredentialRepresentation, error)
 	DeleteUser(accessToken string, realmName, userID string) error
 	GetUser(accessToken string, realmName, userID string) (kc.UserRepresentation, error)
 	GetGroupsOfUser(accessToken string, realmName, userID string) ([]kc.GroupRepresentation, error)
@@ -39,7 +53,10 @@ type KeycloakClient interface {
 	CreateUser(accessToken string, realmName string, targetRealmName string, user kc.UserRepresentation) (string, error)
 	GetClientRoleMappings(accessToken string, realmName, userID, clientID string) ([]kc.RoleRepresentation, error)
 	AddClientRolesToUserRoleMapping(accessToken string, realmName, userID, clientID string, roles []kc.RoleRepresentation) error
+	DeleteClientRolesFromUserRoleMapping(accessToken string, realmName, userID, clientID string, roles []kc.RoleRepresentation) error
 	GetRealmLevelRoleMappings(accessToken string, realmName, userID string) ([]kc.RoleRepresentation, error)
+	AddRealmLevelRoleMappings(accessToken string, realmName, userID string, roles []kc.RoleRepresentation) error
+	DeleteRealmLevelRoleMappings(accessToken string, realmName, userID string, roles []kc.RoleRepresentation) error
 	ResetPassword(accessToken string, realmName string, userID string, cred kc.CredentialRepresentation) error
 	ExecuteActionsEmail(accessToken string, realmName string, userID string, actions []string, paramKV ...string) error
 	SendNewEnrolmentCode(accessToken string, realmName string, userID string) (kc.SmsCodeRepresentation, error)
@@ -47,11 +64,14 @@ type KeycloakClient interface {
 	SendReminderEmail(accessToken string, realmName string, userID string, paramKV ...string) error
 	GetRoles(accessToken string, realmName string) ([]kc.RoleRepresentation, error)
 	GetRole(accessToken string, realmName string, roleID string) (kc.RoleRepresentation, error)
+	CreateRole(accessToken string, realmName string, role kc.RoleRepresentation) (string, error)
+	DeleteRole(accessToken string, realmName string, roleID string) error
 	GetGroups(accessToken string, realmName string) ([]kc.GroupRepresentation, error)
 	GetClientRoles(accessToken string, realmName, idClient string) ([]kc.RoleRepresentation, error)
 	CreateClientRole(accessToken string, realmName, clientID string, role kc.RoleRepresentation) (string, error)
 	GetGroup(accessToken string, realmName, groupID string) (kc.GroupRepresentation, error)
 	CreateGroup(accessToken string, realmName string, group kc.GroupRepresentation) (string, error)
+	UpdateGroup(accessToken string, realmName string, groupID string, group kc.GroupRepresentation) error
 	DeleteGroup(accessToken string, realmName string, groupID string) error
 	AssignClientRole(accessToken string, realmName string, groupID string, clientID string, role []kc.RoleRepresentation) error
 	RemoveClientRole(accessToken string, realmName string, groupID string, clientID string, role []kc.RoleRepresentation) error
@@ -72,6 +92,23 @@ type UsersDetailsDBModule interface {
 	GetUserDetails(ctx context.Context, realm string, userID string) (dto.DBUser, error)
 	DeleteUserDetails(ctx context.Context, realm string, userID string) error
 	GetChecks(ctx context.Context, realm string, userID string) ([]dto.DBCheck, error)
+	FindUserIDsByDocumentNumber(ctx context.Context, realm string, documentNumber string) ([]string, error)
+	FindUserIDsByIdentity(ctx context.Context, realm string, firstName, lastName, birthDate string) ([]string, error)
+	StoreUserLock(ctx context.Context, lock dto.DBUserLock) error
+	GetUserLock(ctx context.Context, realm string, userID string) (*dto.DBUserLock, error)
+	DeleteUserLock(ctx context.Context, realm string, userID string) error
+	StoreAccountExpiry(ctx context.Context, realm string, userID string, expiryDate *time.Time) error
+	GetAccountExpiry(ctx context.Context, realm string, userID string) (*time.Time, error)
+	GetAccreditations(ctx context.Context, realm string, userID string) ([]dto.DBAccreditation, error)
+	StoreUserDeletion(ctx context.Context, deletion dto.DBUserDeletion) error
+	GetUserDeletion(ctx context.Context, realm string, userID string) (*dto.DBUserDeletion, error)
+	DeleteUserDeletion(ctx context.Context, realm string, userID string) error
+}
+
+// ArchiveDBModule is the interface from the archive module
+type ArchiveDBModule interface {
+	StoreUserDetails(ctx context.Context, realm string, user dto.ArchiveUserRepresentation) error
+	GetLastUserDetails(ctx context.Context, realm string, userID string) (*dto.ArchiveUserRepresentation, error)
 }
 
 // Component is the management component interface.
@@ -82,17 +119,31 @@ type Component interface {
 	GetRealm(ctx context.Context, realmName string) (api.RealmRepresentation, error)
 	GetClient(ctx context.Context, realmName, idClient string) (api.ClientRepresentation, error)
 	GetClients(ctx context.Context, realmName string) ([]api.ClientRepresentation, error)
+	CreateClient(ctx context.Context, realmName string, client api.ClientRepresentation) (string, error)
+	UpdateClient(ctx context.Context, realmName, idClient string, client api.ClientRepresentation) error
+	DisableClient(ctx context.Context, realmName, idClient string) error
+	UpdateClientRedirectURIs(ctx context.Context, realmName, idClient string, redirectURIs []string) error
+	RotateClientSecret(ctx context.Context, realmName, idClient string) (api.ClientSecretRepresentation, error)
 	GetRequiredActions(ctx context.Context, realmName string) ([]api.RequiredActionRepresentation, error)
 
 	DeleteUser(ctx context.Context, realmName, userID string) error
+	RestoreUser(ctx context.Context, realmName, userID string) error
 	GetUser(ctx context.Context, realmName, userID string) (api.UserRepresentation, error)
 	UpdateUser(ctx context.Context, realmName, userID string, user api.UserRepresentation) error
-	LockUser(ctx context.Context, realmName, userID string) error
+	LockUser(ctx context.Context, realmName, userID string, lock api.UserLockRepresentation) error
 	UnlockUser(ctx context.Context, realmName, userID string) error
+	GetUserAccreditations(ctx context.Context, realmName, userID string) ([]api.AccreditationRepresentation, error)
+	GetUserAccreditationsHistory(ctx context.Context, realmName, userID string) ([]api.AccreditationHistoryRepresentation, error)
+	RevokeAccreditation(ctx context.Context, realmName, userID, accreditationType string, reason api.AccreditationReasonRepresentation) error
+	ReinstateAccreditation(ctx context.Context, realmName, userID, accreditationType string, reason api.AccreditationReasonRepresentation) error
 	GetUsers(ctx context.Context, realmName string, groupIDs []string, paramKV ...string) (api.UsersPageRepresentation, error)
 	CreateUser(ctx context.Context, realmName string, user api.UserRepresentation) (string, error)
+	ImportUsers(ctx context.Context, realmName string, users []api.UserImportEntry, dryRun bool) (api.UsersImportReport, error)
+	ExportUsers(ctx context.Context, realmName string, format string) (UsersExport, error)
+	LookupUsers(ctx context.Context, realmName string, lookup api.UserLookupRepresentation) ([]string, error)
+	GetDuplicateUsers(ctx context.Context, realmName string) ([]api.DuplicateUsersRepresentation, error)
 	GetUserChecks(ctx context.Context, realmName, userID string) ([]api.UserCheck, error)
-	GetUserAccountStatus(ctx context.Context, realmName, userID string) (map[string]bool, error)
+	GetUserAccountStatus(ctx context.Context, realmName, userID string) (api.UserAccountStatusRepresentation, error)
 	GetRolesOfUser(ctx context.Context, realmName, userID string) ([]api.RoleRepresentation, error)
 	GetGroupsOfUser(ctx context.Context, realmName, userID string) ([]api.GroupRepresentation, error)
 	AddGroupToUser(ctx context.Context, realmName, userID string, groupID string) error
@@ -102,6 +153,9 @@ type Component interface {
 	SetTrustIDGroupsToUser(ctx context.Context, realmName, userID string, groupNames []string) error
 	GetClientRolesForUser(ctx context.Context, realmName, userID, clientID string) ([]api.RoleRepresentation, error)
 	AddClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error
+	DeleteClientRolesFromUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error
+	AddRealmRolesToUser(ctx context.Context, realmName, userID string, roles []api.RoleRepresentation) error
+	DeleteRealmRolesFromUser(ctx context.Context, realmName, userID string, roles []api.RoleRepresentation) error
 
 	ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error)
 	ExecuteActionsEmail(ctx context.Context, realmName string, userID string, actions []api.RequiredAction, paramKV ...string) error
@@ -116,14 +170,26 @@ type Component interface {
 	GetAttackDetectionStatus(ctx context.Context, realmName, userID string) (api.AttackDetectionStatusRepresentation, error)
 	GetRoles(ctx context.Context, realmName string) ([]api.RoleRepresentation, error)
 	GetRole(ctx context.Context, realmName string, roleID string) (api.RoleRepresentation, error)
+	CreateRole(ctx context.Context, realmName string, role api.RoleRepresentation) (string, error)
+	DeleteRole(ctx context.Context, realmName string, roleID string) error
 	GetClientRoles(ctx context.Context, realmName, idClient string) ([]api.RoleRepresentation, error)
 	CreateClientRole(ctx context.Context, realmName, clientID string, role api.RoleRepresentation) (string, error)
 
 	GetGroups(ctx context.Context, realmName string) ([]api.GroupRepresentation, error)
 	CreateGroup(ctx context.Context, realmName string, group api.GroupRepresentation) (string, error)
+	UpdateGroup(ctx context.Context, realmName string, groupID string, group api.GroupRepresentation) error
 	DeleteGroup(ctx context.Context, realmName string, groupID string) error
 	GetAuthorizations(ctx context.Context, realmName string, groupID string) (api.AuthorizationsRepresentation, error)
 	UpdateAuthorizations(ctx context.Context, realmName string, groupID string, group api.AuthorizationsRepresentation) error
+	ExplainAuthorization(ctx context.Context, realmName string, check api.AuthorizationCheckRepresentation) (api.AuthorizationExplanationRepresentation, error)
+	CopyAuthorizations(ctx context.Context, realmName string, groupID string, copyReq api.AuthorizationsCopyRepresentation) error
+	GetAuthorizationTemplates(ctx context.Context) ([]api.AuthorizationTemplateRepresentation, error)
+	UpdateAuthorizationTemplate(ctx context.Context, templateName string, auth api.AuthorizationsRepresentation) error
+	DeleteAuthorizationTemplate(ctx context.Context, templateName string) error
+	ApplyAuthorizationTemplate(ctx context.Context, realmName string, groupID string, application api.AuthorizationTemplateApplicationRepresentation) error
+	GetAuthorizationVersions(ctx context.Context, realmName string, groupID string) ([]api.AuthorizationVersionRepresentation, error)
+	GetAuthorizationsDiff(ctx context.Context, realmName string, groupID string, fromVersion int, toVersion int) (api.AuthorizationsDiffRepresentation, error)
+	RollbackAuthorizations(ctx context.Context, realmName string, groupID string, version int) error
 
 	GetRealmCustomConfiguration(ctx context.Context, realmName string) (api.RealmCustomConfiguration, error)
 	UpdateRealmCustomConfiguration(ctx context.Context, realmID string, customConfig api.RealmCustomConfiguration) error
@@ -140,6 +206,10 @@ type Component interface {
 type component struct {
 	keycloakClient          KeycloakClient
 	usersDBModule           UsersDetailsDBModule
+	archiveDBModule         ArchiveDBModule
+	accredsModule           keycloakb.AccreditationsModule
+	duplicatesModule        keycloakb.DuplicatesModule
+	breachedPwdModule       keycloakb.BreachedPasswordModule
 	eventDBModule           database.EventsDBModule
 	configDBModule          keycloakb.ConfigurationDBModule
 	authorizedTrustIDGroups map[string]bool
@@ -147,8 +217,8 @@ type component struct {
 }
 
 // NewComponent returns the management component.
-func NewComponent(keycloakClient KeycloakClient, usersDBModule UsersDetailsDBModule, eventDBModule database.EventsDBModule,
-	configDBModule keycloakb.ConfigurationDBModule, authorizedTrustIDGroups []string, logger keycloakb.Logger) Component {
+func NewComponent(keycloakClient KeycloakClient, usersDBModule UsersDetailsDBModule, archiveDBModule ArchiveDBModule, accredsModule keycloakb.AccreditationsModule,
+	duplicatesModule keycloakb.DuplicatesModule, breachedPwdModule keycloakb.BreachedPasswordModule, eventDBModule database.EventsDBModule, configDBModule keycloakb.ConfigurationDBModule, authorizedTrustIDGroups []string, logger keycloakb.Logger) Component {
 
 	var authzedTrustIDGroups = make(map[string]bool)
 	for _, grp := range authorizedTrustIDGroups {
@@ -158,6 +228,10 @@ func NewComponent(keycloakClient KeycloakClient, usersDBModule UsersDetailsDBMod
 	return &component{
 		keycloakClient:          keycloakClient,
 		usersDBModule:           usersDBModule,
+		archiveDBModule:         archiveDBModule,
+		accredsModule:           accredsModule,
+		duplicatesModule:        duplicatesModule,
+		breachedPwdModule:       breachedPwdModule,
 		eventDBModule:           eventDBModule,
 		configDBModule:          configDBModule,
 		authorizedTrustIDGroups: authzedTrustIDGroups,
@@ -221,7 +295,6 @@ func (c *component) GetRealm(ctx context.Context, realm string) (api.RealmRepres
 func (c *component) GetClient(ctx context.Context, realmName, idClient string) (api.ClientRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
-	var clientRep api.ClientRepresentation
 	clientKc, err := c.keycloakClient.GetClient(accessToken, realmName, idClient)
 
 	if err != nil {
@@ -229,14 +302,7 @@ func (c *component) GetClient(ctx context.Context, realmName, idClient string) (
 		return api.ClientRepresentation{}, err
 	}
 
-	clientRep.ID = clientKc.ID
-	clientRep.Name = clientKc.Name
-	clientRep.BaseURL = clientKc.BaseURL
-	clientRep.ClientID = clientKc.ClientID
-	clientRep.Protocol = clientKc.Protocol
-	clientRep.Enabled = clientKc.Enabled
-
-	return clientRep, nil
+	return api.ConvertToAPIClient(clientKc), nil
 }
 
 func (c *component) GetClients(ctx context.Context, realmName string) ([]api.ClientRepresentation, error) {
@@ -251,19 +317,82 @@ func (c *component) GetClients(ctx context.Context, realmName string) ([]api.Cli
 
 	var clientsRep = []api.ClientRepresentation{}
 	for _, clientKc := range clientsKc {
-		var clientRep api.ClientRepresentation
-		clientRep.ID = clientKc.ID
-		clientRep.Name = clientKc.Name
-		clientRep.BaseURL = clientKc.BaseURL
-		clientRep.ClientID = clientKc.ClientID
-		clientRep.Protocol = clientKc.Protocol
-		clientRep.Enabled = clientKc.Enabled
-		clientsRep = append(clientsRep, clientRep)
+		clientsRep = append(clientsRep, api.ConvertToAPIClient(clientKc))
 	}
 
 	return clientsRep, nil
 }
 
+func (c *component) CreateClient(ctx context.Context, realmName string, client api.ClientRepresentation) (string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	// Keycloak chooses the technical ID of the new client
+	client.ID = nil
+
+	locationURL, err := c.keycloakClient.CreateClient(accessToken, realmName, api.ConvertToKCClient(client))
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", err
+	}
+
+	//retrieve the client ID
+	reg := regexp.MustCompile(`[0-9a-fA-F]{8}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{4}\-[0-9a-fA-F]{12}`)
+	idClient := string(reg.Find([]byte(locationURL)))
+
+	c.reportEvent(ctx, "API_CLIENT_CREATION", database.CtEventRealmName, realmName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient, "client_id", *client.ClientID))
+
+	return locationURL, nil
+}
+
+func (c *component) UpdateClient(ctx context.Context, realmName, idClient string, client api.ClientRepresentation) error {
+	client.ID = &idClient
+	return c.updateClient(ctx, "API_CLIENT_UPDATE", realmName, idClient, api.ConvertToKCClient(client))
+}
+
+func (c *component) DisableClient(ctx context.Context, realmName, idClient string) error {
+	var disabled = false
+	return c.updateClient(ctx, "API_CLIENT_DISABLE", realmName, idClient, kc.ClientRepresentation{ID: &idClient, Enabled: &disabled})
+}
+
+func (c *component) UpdateClientRedirectURIs(ctx context.Context, realmName, idClient string, redirectURIs []string) error {
+	return c.updateClient(ctx, "API_CLIENT_REDIRECT_URIS_UPDATE", realmName, idClient, kc.ClientRepresentation{ID: &idClient, RedirectUris: &redirectURIs},
+		"redirect_uris", strings.Join(redirectURIs, " "))
+}
+
+// updateClient sends a partial client representation to Keycloak: fields which are not provided are left unchanged
+func (c *component) updateClient(ctx context.Context, apiCall string, realmName, idClient string, client kc.ClientRepresentation, additionalInfo ...string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if err := c.keycloakClient.UpdateClient(accessToken, realmName, idClient, client); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	c.reportEvent(ctx, apiCall, database.CtEventRealmName, realmName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo(append([]string{"id", idClient}, additionalInfo...)...))
+
+	return nil
+}
+
+func (c *component) RotateClientSecret(ctx context.Context, realmName, idClient string) (api.ClientSecretRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	secret, err := c.keycloakClient.RegenerateSecret(accessToken, realmName, idClient)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.ClientSecretRepresentation{}, err
+	}
+
+	c.reportEvent(ctx, "API_CLIENT_SECRET_ROTATION", database.CtEventRealmName, realmName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient))
+
+	return api.ClientSecretRepresentation{
+		Type:  secret.Type,
+		Value: secret.Value,
+	}, nil
+}
+
 func (c *component) GetRequiredActions(ctx context.Context, realmName string) ([]api.RequiredActionRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -286,6 +415,11 @@ func (c *component) GetRequiredActions(ctx context.Context, realmName string) ([
 }
 
 func (c *component) CreateUser(ctx context.Context, realmName string, user api.UserRepresentation) (string, error) {
+	var locationURL, _, err = c.createUser(ctx, realmName, user)
+	return locationURL, err
+}
+
+func (c *component) createUser(ctx context.Context, realmName string, user api.UserRepresentation) (string, string, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
 
@@ -297,7 +431,7 @@ func (c *component) CreateUser(ctx context.Context, realmName string, user api.U
 	locationURL, err := c.keycloakClient.CreateUser(accessToken, ctxRealm, realmName, userRep)
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
-		return "", err
+		return "", "", err
 	}
 
 	var username = ""
@@ -315,6 +449,8 @@ func (c *component) CreateUser(ctx context.Context, realmName string, user api.U
 	userInfoToPersist = userInfoToPersist || user.IDDocumentNumber != nil
 	userInfoToPersist = userInfoToPersist || user.IDDocumentExpiration != nil
 	userInfoToPersist = userInfoToPersist || user.IDDocumentCountry != nil
+	// a complete identity must be indexed to be found by the duplicates detection
+	userInfoToPersist = userInfoToPersist || (user.FirstName != nil && user.LastName != nil && user.BirthDate != nil)
 
 	if userInfoToPersist {
 		// Store user in database
@@ -326,23 +462,156 @@ func (c *component) CreateUser(ctx context.Context, realmName string, user api.U
 			IDDocumentNumber:     user.IDDocumentNumber,
 			IDDocumentExpiration: user.IDDocumentExpiration,
 			IDDocumentCountry:    user.IDDocumentCountry,
+			FirstName:            user.FirstName,
+			LastName:             user.LastName,
+			BirthDate:            user.BirthDate,
 		})
 		if err != nil {
 			c.logger.Warn(ctx, "msg", "Can't store user details in database", "err", err.Error())
-			return "", err
+			return "", "", err
 		}
 	}
 
+	if err = c.storeAccountExpiry(ctx, realmName, userID, user.AccountExpiryDate); err != nil {
+		return "", "", err
+	}
+
 	//store the API call into the DB
 	c.reportEvent(ctx, "API_ACCOUNT_CREATION", database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, username)
 
-	return locationURL, nil
+	return locationURL, userID, nil
+}
+
+func (c *component) ImportUsers(ctx context.Context, realmName string, users []api.UserImportEntry, dryRun bool) (api.UsersImportReport, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	var report = api.UsersImportReport{
+		DryRun:  dryRun,
+		Results: []api.UserImportResult{},
+	}
+	var importedUsernames = make(map[string]bool)
+
+	for i, entry := range users {
+		var result = api.UserImportResult{
+			Line:     entry.Line,
+			Username: entry.User.Username,
+		}
+
+		if entry.Reason != nil {
+			result.Status = api.ImportStatusInvalid
+			result.Reason = entry.Reason
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		// Keycloak usernames are case insensitive
+		var username = strings.ToLower(*entry.User.Username)
+		exists, err := c.usernameExists(accessToken, ctxRealm, realmName, username)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't check if user already exists", "err", err.Error(), "line", entry.Line)
+			if reason, isRowFailure := importRowFailure(err); isRowFailure {
+				report.Results = append(report.Results, failImport(result, reason))
+				continue
+			}
+			return interruptImport(report, result, users[i+1:]), nil
+		}
+		if exists || importedUsernames[username] {
+			result.Status = api.ImportStatusSkippedDuplicate
+			report.Results = append(report.Results, result)
+			continue
+		}
+		importedUsernames[username] = true
+
+		if !dryRun {
+			var userID string
+			if _, userID, err = c.createUser(ctx, realmName, entry.User); err != nil {
+				if reason, isRowFailure := importRowFailure(err); isRowFailure {
+					c.logger.Warn(ctx, "msg", "Can't import user", "err", err.Error(), "line", entry.Line)
+					delete(importedUsernames, username)
+					report.Results = append(report.Results, failImport(result, reason))
+					continue
+				}
+				c.logger.Warn(ctx, "msg", "Users import interrupted", "err", err.Error(), "line", entry.Line)
+				return interruptImport(report, result, users[i+1:]), nil
+			}
+			result.UserID = &userID
+		}
+		result.Status = api.ImportStatusCreated
+		report.Results = append(report.Results, result)
+	}
+
+	return report, nil
+}
+
+// importRowFailure tells whether an error only concerns the imported user and returns the reason code reported for it. Only
+// Keycloak rejecting the user is a failure of the line: an invalid token, a server or a database error interrupts the import
+func importRowFailure(err error) (string, bool) {
+	var httpErr, ok = errors.Cause(err).(kc.HTTPError)
+	if !ok || httpErr.HTTPStatus < http.StatusBadRequest || httpErr.HTTPStatus >= http.StatusInternalServerError {
+		return "", false
+	}
+	switch httpErr.HTTPStatus {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", false
+	case http.StatusConflict:
+		return constants.MsgErrDuplicate, true
+	default:
+		return constants.MsgErrInvalidParam, true
+	}
+}
+
+// failImport reports the line as failed. The reason is a stable code: error messages are not exposed in the report
+func failImport(result api.UserImportResult, reason string) api.UserImportResult {
+	result.Status = api.ImportStatusFailed
+	result.Reason = &reason
+	return result
+}
+
+// interruptImport completes the report of an import stopped by an error: the users already created are kept, the failing line
+// is reported as failed and the remaining lines as not processed
+func interruptImport(report api.UsersImportReport, failed api.UserImportResult, remaining []api.UserImportEntry) api.UsersImportReport {
+	report.Results = append(report.Results, failImport(failed, constants.MsgErrUnknown))
+	for _, entry := range remaining {
+		report.Results = append(report.Results, api.UserImportResult{
+			Line:     entry.Line,
+			Username: entry.User.Username,
+			Status:   api.ImportStatusNotProcessed,
+		})
+	}
+	return report
+}
+
+func (c *component) usernameExists(accessToken, ctxRealm, realmName, username string) (bool, error) {
+	var usersKc, err = c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, "username", username)
+	if err != nil {
+		return false, err
+	}
+	for _, userKc := range usersKc.Users {
+		if userKc.Username != nil && strings.ToLower(*userKc.Username) == username {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (c *component) DeleteUser(ctx context.Context, realmName, userID string) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
-	err := c.keycloakClient.DeleteUser(accessToken, realmName, userID)
+	adminConfig, err := keycloakb.GetRealmAdminConfiguration(ctx, c.keycloakClient, c.configDBModule, accessToken, realmName, c.logger)
+	if err != nil {
+		return err
+	}
+	if adminConfig.SoftDeletion != nil && adminConfig.SoftDeletion.RetentionDays != nil {
+		return c.softDeleteUser(ctx, accessToken, realmName, userID, *adminConfig.SoftDeletion.RetentionDays)
+	}
+
+	var operator, _ = ctx.Value(cs.CtContextUsername).(string)
+	if err = c.accredsModule.RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationUserDeleted, operator); err != nil {
+		return err
+	}
+
+	err = c.keycloakClient.DeleteUser(accessToken, realmName, userID)
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
 		return err
@@ -360,6 +629,131 @@ func (c *component) DeleteUser(ctx context.Context, realmName, userID string) er
 	return nil
 }
 
+// softDeleteUser archives the user, revokes its accreditations, disables it and removes its lock. The user is purged once the retention
+// period is over
+func (c *component) softDeleteUser(ctx context.Context, accessToken, realmName, userID string, retentionDays int) error {
+	deletion, err := c.usersDBModule.GetUserDeletion(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user deletion", "err", err.Error())
+		return err
+	}
+	if deletion != nil {
+		// Already deleted: the archive must keep the state of the user before its first deletion
+		return nil
+	}
+
+	userKc, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+
+	userDetails, err := c.usersDBModule.GetUserDetails(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user details from database", "err", err.Error())
+		return err
+	}
+	checks, err := c.usersDBModule.GetChecks(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user checks from database", "err", err.Error())
+		return err
+	}
+
+	var archiveUser = dto.ToArchiveUserRepresentation(userKc)
+	archiveUser.SetDetails(userDetails)
+	archiveUser.Checks = checks
+	if err = c.archiveDBModule.StoreUserDetails(ctx, realmName, archiveUser); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't archive user", "err", err.Error())
+		return err
+	}
+
+	var operator, hasOperator = ctx.Value(cs.CtContextUsername).(string)
+	if err = c.accredsModule.RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationUserDeleted, operator); err != nil {
+		return err
+	}
+	keycloakb.RevokeAccreditations(&userKc)
+
+	var disabled = false
+	userKc.Enabled = &disabled
+	if err = c.keycloakClient.UpdateUser(accessToken, realmName, userID, userKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	// a timed lock must not enable the deleted user again
+	if err = c.usersDBModule.DeleteUserLock(ctx, realmName, userID); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete user lock", "err", err.Error())
+		return err
+	}
+
+	var now = time.Now()
+	deletion = &dto.DBUserDeletion{
+		RealmID:      realmName,
+		UserID:       userID,
+		DeletionDate: now,
+		PurgeDate:    now.AddDate(0, 0, retentionDays),
+	}
+	if hasOperator {
+		deletion.DeletedBy = &operator
+	}
+	if err = c.usersDBModule.StoreUserDeletion(ctx, *deletion); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't store user deletion", "err", err.Error())
+		return err
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("purge_date", deletion.PurgeDate.UTC().Format(time.RFC3339))
+	c.reportEvent(ctx, "API_ACCOUNT_SOFT_DELETION", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, additionalInfo)
+
+	return nil
+}
+
+// RestoreUser enables again a soft deleted user which has not been purged yet
+func (c *component) RestoreUser(ctx context.Context, realmName, userID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	deletion, err := c.usersDBModule.GetUserDeletion(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user deletion", "err", err.Error())
+		return err
+	}
+	if deletion == nil || !deletion.PurgeDate.After(time.Now()) {
+		return errorhandler.CreateNotFoundError(constants.DeletedUser)
+	}
+
+	archiveUser, err := c.archiveDBModule.GetLastUserDetails(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user archive", "err", err.Error())
+		return err
+	}
+
+	userKc, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+
+	// The user gets back the state it had when it was deleted
+	var enabled = true
+	if archiveUser != nil && archiveUser.Enabled != nil {
+		enabled = *archiveUser.Enabled
+	}
+	userKc.Enabled = &enabled
+	if err = c.keycloakClient.UpdateUser(accessToken, realmName, userID, userKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	if err = c.clearUserDeletion(ctx, realmName, userID); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_ACCOUNT_RESTORE", database.CtEventRealmName, realmName, database.CtEventUserID, userID)
+
+	return nil
+}
+
 func (c *component) GetUser(ctx context.Context, realmName, userID string) (api.UserRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -393,6 +787,22 @@ func (c *component) GetUser(ctx context.Context, realmName, userID string) (api.
 	userRep.IDDocumentExpiration = dbUser.IDDocumentExpiration
 	userRep.IDDocumentCountry = dbUser.IDDocumentCountry
 
+	accountExpiry, err := c.usersDBModule.GetAccountExpiry(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get account expiry", "err", err.Error())
+		return api.UserRepresentation{}, err
+	}
+	userRep.AccountExpiryDate = api.ConvertToAPIAccountExpiry(accountExpiry)
+
+	var etag = keycloakb.ComputeUserETag(userKc, dbUser, accountExpiry)
+	userRep.ETag = &etag
+
+	if userKc.Enabled != nil && !*userKc.Enabled {
+		if userRep.Lock, err = c.getUserLock(ctx, realmName, userID); err != nil {
+			return api.UserRepresentation{}, err
+		}
+	}
+
 	//store the API call into the DB
 	c.reportEvent(ctx, "GET_DETAILS", database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, username)
 
@@ -424,6 +834,25 @@ func (c *component) UpdateUser(ctx context.Context, realmName, userID string, us
 		// Log warning already performed in GetUser
 		return err
 	}
+	var formerDbUser = oldDbUser
+
+	formerExpiry, err := c.usersDBModule.GetAccountExpiry(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get account expiry", "err", err.Error())
+		return err
+	}
+
+	// the user must not have been modified since it was read by the caller
+	if err = keycloakb.CheckUserETag(user.ETag, oldUserKc, oldDbUser, formerExpiry); err != nil {
+		c.logger.Warn(ctx, "msg", "User has been modified since it was read", "realm", realmName, "userID", userID)
+		return err
+	}
+
+	// a removed email is cleared in Keycloak
+	if oldUserKc.Email != nil && keycloakb.IsCleared(user.ClearedFields, api.FieldEmail) {
+		var noEmail = ""
+		user.Email = &noEmail
+	}
 
 	// when the email changes, set the EmailVerified to false
 	if c.isUpdated(user.Email, oldUserKc.Email) {
@@ -437,11 +866,13 @@ func (c *component) UpdateUser(ctx context.Context, realmName, userID string, us
 		user.PhoneNumberVerified = &verified
 	}
 
-	var revokeAccreditations = keycloakb.IsUpdated(user.FirstName, oldUserKc.FirstName,
+	// removing a value is an update: accreditations are revoked the same way
+	var identityUpdated = keycloakb.IsUpdated(user.FirstName, oldUserKc.FirstName,
 		user.LastName, oldUserKc.LastName,
-		user.Gender, oldUserKc.GetAttributeString(constants.AttrbGender),
 		user.BirthDate, oldUserKc.GetAttributeString(constants.AttrbBirthDate),
-	)
+	) || (oldUserKc.GetAttributeString(constants.AttrbBirthDate) != nil && keycloakb.IsCleared(user.ClearedFields, api.FieldBirthDate))
+	var revokeAccreditations = identityUpdated || keycloakb.IsUpdated(user.Gender, oldUserKc.GetAttributeString(constants.AttrbGender)) ||
+		(oldUserKc.GetAttributeString(constants.AttrbGender) != nil && keycloakb.IsCleared(user.ClearedFields, api.FieldGender))
 
 	userRep = api.ConvertToKCUser(user)
 
@@ -449,10 +880,15 @@ func (c *component) UpdateUser(ctx context.Context, realmName, userID string, us
 	var mergedAttributes = make(kc.Attributes)
 	mergedAttributes.Merge(oldUserKc.Attributes)
 	mergedAttributes.Merge(userRep.Attributes)
+	removeClearedAttributes(mergedAttributes, user.ClearedFields)
 
 	userRep.Attributes = &mergedAttributes
 	if revokeAccreditations {
 		keycloakb.RevokeAccreditations(&userRep)
+		var operator, _ = ctx.Value(cs.CtContextUsername).(string)
+		if err = c.accredsModule.RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationIdentityUpdated, operator); err != nil {
+			return err
+		}
 	}
 
 	// Update in KC
@@ -464,11 +900,18 @@ func (c *component) UpdateUser(ctx context.Context, realmName, userID string, us
 	//store the API call into the DB in case where user.Enable is present
 	if user.Enabled != nil {
 		c.reportLockEvent(ctx, realmName, userID, user.Username, *user.Enabled)
+		if *user.Enabled && (oldUserKc.Enabled == nil || !*oldUserKc.Enabled) {
+			if err = c.clearUserDeletion(ctx, realmName, userID); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Update in DB user for extra infos
-	// Store user in database
-	var userInfosUpdated = keycloakb.IsUpdated(user.BirthLocation, oldDbUser.BirthLocation) ||
+	// Store user in database. Identity is also checked as it is used to compute the identity blind index
+	var detailsCleared = clearDetails(&oldDbUser, user.ClearedFields)
+	var userInfosUpdated = identityUpdated || detailsCleared ||
+		keycloakb.IsUpdated(user.BirthLocation, oldDbUser.BirthLocation) ||
 		keycloakb.IsUpdated(user.Nationality, oldDbUser.Nationality) ||
 		keycloakb.IsUpdated(user.IDDocumentType, oldDbUser.IDDocumentType) ||
 		keycloakb.IsUpdated(user.IDDocumentNumber, oldDbUser.IDDocumentNumber) ||
@@ -501,6 +944,19 @@ func (c *component) UpdateUser(ctx context.Context, realmName, userID string, us
 			oldDbUser.IDDocumentCountry = user.IDDocumentCountry
 		}
 
+		oldDbUser.SetIdentity(oldUserKc)
+		if user.FirstName != nil {
+			oldDbUser.FirstName = user.FirstName
+		}
+		if user.LastName != nil {
+			oldDbUser.LastName = user.LastName
+		}
+		if user.BirthDate != nil {
+			oldDbUser.BirthDate = user.BirthDate
+		} else if keycloakb.IsCleared(user.ClearedFields, api.FieldBirthDate) {
+			oldDbUser.BirthDate = nil
+		}
+
 		err = c.usersDBModule.StoreOrUpdateUserDetails(ctx, realmName, oldDbUser)
 		if err != nil {
 			c.logger.Warn(ctx, "msg", "Can't store user details in database", "err", err.Error())
@@ -508,10 +964,86 @@ func (c *component) UpdateUser(ctx context.Context, realmName, userID string, us
 		}
 	}
 
+	// a removed account expiry date removes the expiry of the account
+	if keycloakb.IsCleared(user.ClearedFields, api.FieldAccountExpiryDate) {
+		var noExpiry = ""
+		user.AccountExpiryDate = &noExpiry
+	}
+
+	var changes = append(keycloakb.DiffKeycloakUsers(oldUserKc, userRep), keycloakb.DiffUserDetails(formerDbUser, oldDbUser)...)
+	if user.AccountExpiryDate != nil {
+		if err = c.storeAccountExpiry(ctx, realmName, userID, user.AccountExpiryDate); err != nil {
+			return err
+		}
+		var newExpiry = api.ConvertToAPIAccountExpiry(api.ConvertToDBAccountExpiry(*user.AccountExpiryDate))
+		changes = append(changes, keycloakb.DiffAccountExpiry(api.ConvertToAPIAccountExpiry(formerExpiry), newExpiry)...)
+	}
+
+	// store the API call and the changes of the user into the DB
+	var username = ""
+	if oldUserKc.Username != nil {
+		username = *oldUserKc.Username
+	}
+	c.reportEvent(ctx, "API_ACCOUNT_UPDATE", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventUsername, username, database.CtEventAdditionalInfo, keycloakb.CreateChangesAdditionalInfo(changes))
+
+	return nil
+}
+
+// removeClearedAttributes removes from the Keycloak attributes those cleared by a JSON merge patch
+func removeClearedAttributes(attributes kc.Attributes, clearedFields []string) {
+	var clearedAttributes = map[string][]kc.AttributeKey{
+		api.FieldPhoneNumber: {constants.AttrbPhoneNumber, constants.AttrbPhoneNumberVerified},
+		api.FieldLabel:       {constants.AttrbLabel},
+		api.FieldGender:      {constants.AttrbGender},
+		api.FieldBirthDate:   {constants.AttrbBirthDate},
+		api.FieldLocale:      {constants.AttrbLocale},
+	}
+	for _, field := range clearedFields {
+		for _, key := range clearedAttributes[field] {
+			delete(attributes, key)
+		}
+	}
+}
+
+// clearDetails removes the details cleared by a JSON merge patch. Returns true if a value has been removed
+func clearDetails(dbUser *dto.DBUser, clearedFields []string) bool {
+	var details = map[string]**string{
+		api.FieldBirthLocation:        &dbUser.BirthLocation,
+		api.FieldNationality:          &dbUser.Nationality,
+		api.FieldIDDocumentType:       &dbUser.IDDocumentType,
+		api.FieldIDDocumentNumber:     &dbUser.IDDocumentNumber,
+		api.FieldIDDocumentExpiration: &dbUser.IDDocumentExpiration,
+		api.FieldIDDocumentCountry:    &dbUser.IDDocumentCountry,
+	}
+	var cleared = false
+	for _, field := range clearedFields {
+		if detail, ok := details[field]; ok && *detail != nil {
+			*detail = nil
+			cleared = true
+		}
+	}
+	return cleared
+}
+
+// storeAccountExpiry updates the expiry date of an account. An empty date removes the expiry, a nil one leaves it unchanged
+func (c *component) storeAccountExpiry(ctx context.Context, realmName, userID string, expiryDate *string) error {
+	if expiryDate == nil {
+		return nil
+	}
+	var expiry = api.ConvertToDBAccountExpiry(*expiryDate)
+	if err := c.usersDBModule.StoreAccountExpiry(ctx, realmName, userID, expiry); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't store account expiry", "err", err.Error())
+		return err
+	}
+
+	var additionalInfo = database.CreateAdditionalInfo("account_expiry_date", *expiryDate)
+	c.reportEvent(ctx, "UPDATE_ACCOUNT_EXPIRY", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+		database.CtEventAdditionalInfo, additionalInfo)
 	return nil
 }
 
-func (c *component) reportLockEvent(ctx context.Context, realmName, userID string, username *string, enabled bool) {
+func (c *component) reportLockEvent(ctx context.Context, realmName, userID string, username *string, enabled bool, values ...string) {
 	var blank = ""
 	if username == nil {
 		username = &blank
@@ -527,18 +1059,67 @@ func (c *component) reportLockEvent(ctx context.Context, realmName, userID strin
 		ctEventType = "LOCK_ACCOUNT"
 	}
 
-	c.reportEvent(ctx, ctEventType, database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, *username)
+	values = append([]string{database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, *username}, values...)
+	c.reportEvent(ctx, ctEventType, values...)
 }
 
-func (c *component) LockUser(ctx context.Context, realmName, userID string) error {
-	return c.setUserLock(ctx, realmName, userID, true)
+func (c *component) LockUser(ctx context.Context, realmName, userID string, lock api.UserLockRepresentation) error {
+	var additionalInfo []string
+
+	if lock.Reason == nil {
+		// Lock without details: lock information of a previous lock must not be kept
+		if err := c.usersDBModule.DeleteUserLock(ctx, realmName, userID); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't delete user lock", "err", err.Error())
+			return err
+		}
+	} else {
+		var dbLock = dto.DBUserLock{
+			RealmID:  realmName,
+			UserID:   userID,
+			Reason:   *lock.Reason,
+			Comment:  lock.Comment,
+			LockedAt: time.Now(),
+		}
+		if operator, ok := ctx.Value(cs.CtContextUsername).(string); ok {
+			dbLock.LockedBy = &operator
+		}
+		var infos = []string{"reason", *lock.Reason}
+		if lock.UnlockAt != nil {
+			var unlockAt = time.Unix(0, *lock.UnlockAt*int64(time.Millisecond))
+			dbLock.UnlockAt = &unlockAt
+			infos = append(infos, "unlock_at", unlockAt.UTC().Format(time.RFC3339))
+		}
+		if err := c.usersDBModule.StoreUserLock(ctx, dbLock); err != nil {
+			c.logger.Warn(ctx, "msg", "Can't store user lock", "err", err.Error())
+			return err
+		}
+		additionalInfo = []string{database.CtEventAdditionalInfo, database.CreateAdditionalInfo(infos...)}
+	}
+
+	return c.setUserLock(ctx, realmName, userID, true, additionalInfo...)
 }
 
 func (c *component) UnlockUser(ctx context.Context, realmName, userID string) error {
-	return c.setUserLock(ctx, realmName, userID, false)
+	if err := c.setUserLock(ctx, realmName, userID, false); err != nil {
+		return err
+	}
+	if err := c.usersDBModule.DeleteUserLock(ctx, realmName, userID); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete user lock", "err", err.Error())
+		return err
+	}
+	return c.clearUserDeletion(ctx, realmName, userID)
+}
+
+// clearUserDeletion removes the soft deletion of a user which is enabled again: the user must not be purged anymore
+func (c *component) clearUserDeletion(ctx context.Context, realmName, userID string) error {
+	if err := c.usersDBModule.DeleteUserDeletion(ctx, realmName, userID); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't delete user deletion", "err", err.Error())
+		return err
+	}
+	return nil
 }
 
-func (c *component) setUserLock(ctx context.Context, realmName, userID string, locked bool) error {
+func (c *component) setUserLock(ctx context.Context, realmName, userID string, locked bool, additionalInfo ...string) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
 	// get the "old" user representation
@@ -561,24 +1142,146 @@ func (c *component) setUserLock(ctx context.Context, realmName, userID string, l
 		return err
 	}
 
-	c.reportLockEvent(ctx, realmName, userID, oldUserKc.Username, enabled)
+	c.reportLockEvent(ctx, realmName, userID, oldUserKc.Username, enabled, additionalInfo...)
 
 	return nil
 }
 
-func (c *component) GetUsers(ctx context.Context, realmName string, groupIDs []string, paramKV ...string) (api.UsersPageRepresentation, error) {
-	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
-	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
-
-	for _, groupID := range groupIDs {
-		paramKV = append(paramKV, "groupId", groupID)
+func (c *component) getUserLock(ctx context.Context, realmName, userID string) (*api.UserLockRepresentation, error) {
+	var lock, err = c.usersDBModule.GetUserLock(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user lock", "err", err.Error())
+		return nil, err
 	}
+	if lock == nil {
+		return nil, nil
+	}
+	var res = api.ConvertToAPIUserLock(*lock)
+	return &res, nil
+}
 
-	usersKc, err := c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, paramKV...)
+func (c *component) GetUserAccreditations(ctx context.Context, realmName, userID string) ([]api.AccreditationRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
+	userKc, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
-		return api.UsersPageRepresentation{}, err
+		return nil, err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+
+	return api.ConvertToAPIAccreditations(ctx, userKc.GetAttribute(constants.AttrbAccreditations), c.logger), nil
+}
+
+func (c *component) GetUserAccreditationsHistory(ctx context.Context, realmName, userID string) ([]api.AccreditationHistoryRepresentation, error) {
+	var accreds, err = c.usersDBModule.GetAccreditations(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get accreditations history from database", "err", err.Error(), "realm", realmName, "user", userID)
+		return nil, err
+	}
+	return api.ConvertToAPIAccreditationsHistory(accreds), nil
+}
+
+func (c *component) RevokeAccreditation(ctx context.Context, realmName, userID, accreditationType string, reason api.AccreditationReasonRepresentation) error {
+	var operator, _ = ctx.Value(cs.CtContextUsername).(string)
+	return c.updateAccreditation(ctx, realmName, userID, accreditationType, *reason.Reason, "ACCREDITATION_REVOKED", func(userKc *kc.UserRepresentation) bool {
+		return keycloakb.RevokeAccreditation(userKc, accreditationType, *reason.Reason, operator)
+	}, func() error {
+		return c.accredsModule.RevokeAccreditation(ctx, realmName, userID, accreditationType, *reason.Reason, operator)
+	}, nil)
+}
+
+func (c *component) ReinstateAccreditation(ctx context.Context, realmName, userID, accreditationType string, reason api.AccreditationReasonRepresentation) error {
+	var operator, _ = ctx.Value(cs.CtContextUsername).(string)
+	var reinstated []keycloakb.AccreditationRepresentation
+	return c.updateAccreditation(ctx, realmName, userID, accreditationType, *reason.Reason, "ACCREDITATION_REINSTATED", func(userKc *kc.UserRepresentation) bool {
+		reinstated = keycloakb.ReinstateAccreditation(userKc, accreditationType)
+		return len(reinstated) > 0
+	}, nil, func() error {
+		// The revocation stays in the history: a reinstated accreditation is granted again
+		var now = time.Now()
+		var accreds []dto.DBAccreditation
+		for _, accred := range reinstated {
+			accreds = append(accreds, accred.ToDBAccreditation(realmName, userID, now))
+		}
+		return c.accredsModule.RecordAccreditations(ctx, accreds, operator, nil)
+	})
+}
+
+// updateAccreditation applies a change to an accreditation of a user, records it in the accreditations history, archives the new
+// state of the user and audits the change. The change is recorded either before or after Keycloak is updated
+func (c *component) updateAccreditation(ctx context.Context, realmName, userID, accreditationType, reason, ctEventType string,
+	update func(*kc.UserRepresentation) bool, recordBefore func() error, recordAfter func() error) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	userKc, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+
+	if !update(&userKc) {
+		return errorhandler.CreateNotFoundError(constants.Accreditation)
+	}
+
+	if recordBefore != nil {
+		if err = recordBefore(); err != nil {
+			return err
+		}
+	}
+
+	if err = c.keycloakClient.UpdateUser(accessToken, realmName, userID, userKc); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	if recordAfter != nil {
+		if err = recordAfter(); err != nil {
+			return err
+		}
+	}
+
+	c.archiveUser(ctx, realmName, userID, userKc)
+
+	var username = ""
+	if userKc.Username != nil {
+		username = *userKc.Username
+	}
+	c.reportEvent(ctx, ctEventType, database.CtEventRealmName, realmName, database.CtEventUserID, userID, database.CtEventUsername, username,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("accreditation_type", accreditationType, "reason", reason))
+
+	return nil
+}
+
+// archiveUser stores the current state of a user in the archive. The user is already updated: a failure is only logged
+func (c *component) archiveUser(ctx context.Context, realmName, userID string, userKc kc.UserRepresentation) {
+	userDetails, err := c.usersDBModule.GetUserDetails(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user details from database", "err", err.Error())
+		return
+	}
+
+	var archiveUser = dto.ToArchiveUserRepresentation(userKc)
+	archiveUser.SetDetails(userDetails)
+	if err = c.archiveDBModule.StoreUserDetails(ctx, realmName, archiveUser); err != nil {
+		c.logger.Warn(ctx, "msg", "Can't archive user", "err", err.Error())
+	}
+}
+
+func (c *component) GetUsers(ctx context.Context, realmName string, groupIDs []string, paramKV ...string) (api.UsersPageRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	for _, groupID := range groupIDs {
+		paramKV = append(paramKV, "groupId", groupID)
+	}
+
+	usersKc, err := c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, paramKV...)
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.UsersPageRepresentation{}, err
 	}
 
 	for i := 0; i < len(usersKc.Users); i++ {
@@ -587,6 +1290,133 @@ func (c *component) GetUsers(ctx context.Context, realmName string, groupIDs []s
 	return api.ConvertToAPIUsersPage(ctx, usersKc, c.logger), nil
 }
 
+func (c *component) ExportUsers(ctx context.Context, realmName string, format string) (UsersExport, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	// First page is loaded before starting to stream so that errors can still be returned to the caller
+	var page, err = c.getUsersPage(ctx, accessToken, ctxRealm, realmName, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w io.Writer) error {
+		var exportWriter, err = api.NewUsersExportWriter(format, w)
+		if err != nil {
+			return err
+		}
+
+		var count = 0
+		for first := 0; ; first += exportUsersPageSize {
+			if first > 0 {
+				if page, err = c.getUsersPage(ctx, accessToken, ctxRealm, realmName, first); err != nil {
+					return err
+				}
+			}
+			for _, userKc := range page.Users {
+				userRep, err := c.mergeUserDetails(ctx, realmName, userKc)
+				if err != nil {
+					return err
+				}
+				if err = exportWriter.Write(userRep); err != nil {
+					c.logger.Warn(ctx, "msg", "Can't write exported user", "err", err.Error())
+					return err
+				}
+				count++
+			}
+			if err = exportWriter.Flush(); err != nil {
+				c.logger.Warn(ctx, "msg", "Can't write exported users", "err", err.Error())
+				return err
+			}
+			if len(page.Users) < exportUsersPageSize {
+				break
+			}
+		}
+
+		//store the API call into the DB
+		var additionalInfo = database.CreateAdditionalInfo("format", format, "count", strconv.Itoa(count))
+		c.reportEvent(ctx, "API_USERS_EXPORT", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+		return nil
+	}, nil
+}
+
+func (c *component) LookupUsers(ctx context.Context, realmName string, lookup api.UserLookupRepresentation) ([]string, error) {
+	var userIDs []string
+	var criteria string
+	var err error
+
+	if lookup.IDDocumentNumber != nil {
+		criteria = "idDocumentNumber"
+		userIDs, err = c.usersDBModule.FindUserIDsByDocumentNumber(ctx, realmName, *lookup.IDDocumentNumber)
+	} else {
+		criteria = "identity"
+		userIDs, err = c.usersDBModule.FindUserIDsByIdentity(ctx, realmName, *lookup.FirstName, *lookup.LastName, *lookup.BirthDate)
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't lookup users", "err", err.Error(), "criteria", criteria)
+		return nil, err
+	}
+
+	//store the API call into the DB
+	var additionalInfo = database.CreateAdditionalInfo("criteria", criteria, "count", strconv.Itoa(len(userIDs)))
+	c.reportEvent(ctx, "API_USERS_LOOKUP", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+	return userIDs, nil
+}
+
+func (c *component) GetDuplicateUsers(ctx context.Context, realmName string) ([]api.DuplicateUsersRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+	var ctxRealm = ctx.Value(cs.CtContextRealm).(string)
+
+	var duplicates, err = c.duplicatesModule.GetDuplicatesReport(ctx, accessToken, ctxRealm, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get duplicate users", "err", err.Error())
+		return nil, err
+	}
+
+	var res = []api.DuplicateUsersRepresentation{}
+	for _, duplicate := range duplicates {
+		res = append(res, api.ConvertDuplicateUsers(duplicate))
+	}
+
+	//store the API call into the DB
+	var additionalInfo = database.CreateAdditionalInfo("count", strconv.Itoa(len(res)))
+	c.reportEvent(ctx, "API_DUPLICATE_USERS_REPORT", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, additionalInfo)
+
+	return res, nil
+}
+
+func (c *component) getUsersPage(ctx context.Context, accessToken, ctxRealm, realmName string, first int) (kc.UsersPageRepresentation, error) {
+	var usersKc, err = c.keycloakClient.GetUsers(accessToken, ctxRealm, realmName, prmQryFirst, strconv.Itoa(first), prmQryMax, strconv.Itoa(exportUsersPageSize))
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get users page", "err", err.Error(), "first", first)
+		return kc.UsersPageRepresentation{}, err
+	}
+	return usersKc, nil
+}
+
+// mergeUserDetails converts a Keycloak user and completes it with the details stored in database
+func (c *component) mergeUserDetails(ctx context.Context, realmName string, userKc kc.UserRepresentation) (api.UserRepresentation, error) {
+	keycloakb.ConvertLegacyAttribute(&userKc)
+	var userRep = api.ConvertToAPIUser(ctx, userKc, c.logger)
+
+	dbUser, err := c.usersDBModule.GetUserDetails(ctx, realmName, *userKc.ID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get user details", "err", err.Error(), "user", *userKc.ID)
+		return api.UserRepresentation{}, err
+	}
+
+	userRep.BirthLocation = dbUser.BirthLocation
+	userRep.Nationality = dbUser.Nationality
+	userRep.IDDocumentType = dbUser.IDDocumentType
+	userRep.IDDocumentNumber = dbUser.IDDocumentNumber
+	userRep.IDDocumentExpiration = dbUser.IDDocumentExpiration
+	userRep.IDDocumentCountry = dbUser.IDDocumentCountry
+
+	return userRep, nil
+}
+
 func (c *component) GetUserChecks(ctx context.Context, realmName, userID string) ([]api.UserCheck, error) {
 	// We can assume userID is valid as it is used to check authorizations...
 	var checks, err = c.usersDBModule.GetChecks(ctx, realmName, userID)
@@ -597,13 +1427,11 @@ func (c *component) GetUserChecks(ctx context.Context, realmName, userID string)
 	return api.ConvertToAPIUserChecks(checks), nil
 }
 
-// GetUserAccountStatus gets the user status : user should be enabled in Keycloak and have multifactor activated
-func (c *component) GetUserAccountStatus(ctx context.Context, realmName, userID string) (map[string]bool, error) {
+// GetUserAccountStatus gets the user status : user should be enabled in Keycloak and have multifactor activated. When the user
+// is disabled, the current lock information is also returned
+func (c *component) GetUserAccountStatus(ctx context.Context, realmName, userID string) (api.UserAccountStatusRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
-	var res map[string]bool
-
-	res = make(map[string]bool)
-	res["enabled"] = false
+	var res = api.UserAccountStatusRepresentation{Enabled: false}
 
 	userKc, err := c.keycloakClient.GetUser(accessToken, realmName, userID)
 	// Here, we don't call keycloakb.ConvertLegacyAttribute as attributes are not used
@@ -614,11 +1442,12 @@ func (c *component) GetUserAccountStatus(ctx context.Context, realmName, userID
 	}
 
 	if !*userKc.Enabled {
-		return res, nil
+		res.Lock, err = c.getUserLock(ctx, realmName, userID)
+		return res, err
 	}
 
 	creds, err := c.GetCredentialsForUser(ctx, realmName, userID)
-	res["enabled"] = len(creds) > 1
+	res.Enabled = len(creds) > 1
 	return res, err
 }
 
@@ -775,20 +1604,43 @@ func (c *component) GetClientRolesForUser(ctx context.Context, realmName, userID
 func (c *component) AddClientRolesToUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
-	var rolesRep = []kc.RoleRepresentation{}
-	for _, role := range roles {
-		var roleRep kc.RoleRepresentation
-		roleRep.ID = role.ID
-		roleRep.Name = role.Name
-		roleRep.Composite = role.Composite
-		roleRep.ClientRole = role.ClientRole
-		roleRep.ContainerID = role.ContainerID
-		roleRep.Description = role.Description
+	err := c.keycloakClient.AddClientRolesToUserRoleMapping(accessToken, realmName, userID, clientID, api.ConvertToKCRoles(roles))
 
-		rolesRep = append(rolesRep, roleRep)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+	}
+
+	return err
+}
+
+func (c *component) DeleteClientRolesFromUser(ctx context.Context, realmName, userID, clientID string, roles []api.RoleRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	err := c.keycloakClient.DeleteClientRolesFromUserRoleMapping(accessToken, realmName, userID, clientID, api.ConvertToKCRoles(roles))
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
 	}
 
-	err := c.keycloakClient.AddClientRolesToUserRoleMapping(accessToken, realmName, userID, clientID, rolesRep)
+	return err
+}
+
+func (c *component) AddRealmRolesToUser(ctx context.Context, realmName, userID string, roles []api.RoleRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	err := c.keycloakClient.AddRealmLevelRoleMappings(accessToken, realmName, userID, api.ConvertToKCRoles(roles))
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+	}
+
+	return err
+}
+
+func (c *component) DeleteRealmRolesFromUser(ctx context.Context, realmName, userID string, roles []api.RoleRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	err := c.keycloakClient.DeleteRealmLevelRoleMappings(accessToken, realmName, userID, api.ConvertToKCRoles(roles))
 
 	if err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
@@ -806,32 +1658,26 @@ func (c *component) ResetPassword(ctx context.Context, realmName string, userID
 	var passwordType = "password"
 	credKc.Type = &passwordType
 
+	policy, err := getPasswordPolicy(ctx, c.keycloakClient, c.logger, accessToken, realmName)
+	if err != nil {
+		return "", err
+	}
+	username, email, err := getPasswordPolicyUserValues(ctx, c.keycloakClient, c.logger, accessToken, realmName, userID, policy)
+	if err != nil {
+		return "", err
+	}
+
 	if password.Value == nil {
-		// the commented code respects the following scenario: if the realm has a pwd policy, the generated pwd respects this policy
-		/*
-			// no password value was provided; a new password, that respects the password policy of the realm, will be generated
-			var minLength int = 8
-
-			//obtain password policy
-			realmKc, err := c.keycloakClient.GetRealm(accessToken, realmName)
-			if err == nil {
-				// generate password according to the policy of the realm
-				pwd, err = internal.GeneratePassword(realmKc.PasswordPolicy, minLength, userID)
-				if err != nil {
-					return pwd, err
-				}
-				credKc.Value = &pwd
-			} else {
-				return "", err
-			}
-		*/
-		// generate a password of the format UpperCase + 6 digits + LowerCase
-		var nbUpperCase = 1
-		var nbDigits = 6
-		var nbLowerCase = 1
-		pwd = keycloakb.GenerateInitialCode(nbUpperCase, nbDigits, nbLowerCase)
+		// no password value was provided; a new password, that respects the password policy of the realm, will be generated
+		if pwd, err = c.generatePassword(ctx, realmName, policy, username, email); err != nil {
+			return "", err
+		}
 		credKc.Value = &pwd
 	} else {
+		// the password is checked before calling Keycloak to explain all the rules it does not respect
+		if err = checkChosenPassword(ctx, c.breachedPwdModule, c.reportEvent, realmName, userID, *password.Value, policy, username, email); err != nil {
+			return "", err
+		}
 		credKc.Value = password.Value
 	}
 
@@ -847,6 +1693,87 @@ func (c *component) ResetPassword(ctx context.Context, realmName string, userID
 	return pwd, nil
 }
 
+// checkChosenPassword checks a password set by an operator against the password policy of the realm and rejects a breached
+// password. The rejection of a breached password is reported as an event of the target user
+func checkChosenPassword(ctx context.Context, breachedPwdModule keycloakb.BreachedPasswordModule, reportEvent func(context.Context, string, ...string),
+	realmName, userID, password string, policy *keycloakb.PasswordPolicy, username, email string) error {
+	if policy != nil {
+		if err := policy.CheckPassword(password, username, email); err != nil {
+			return err
+		}
+	}
+	breached, err := breachedPwdModule.IsBreached(ctx, realmName, password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		reportEvent(ctx, "BREACHED_PASSWORD_REJECTED", database.CtEventRealmName, realmName, database.CtEventUserID, userID)
+		return keycloakb.CreateBreachedPasswordError()
+	}
+	return nil
+}
+
+// getPasswordPolicy returns the password policy of the realm or nil if the realm has no policy
+func getPasswordPolicy(ctx context.Context, keycloakClient KeycloakClient, logger keycloakb.Logger, accessToken, realmName string) (*keycloakb.PasswordPolicy, error) {
+	realmKc, err := keycloakClient.GetRealm(accessToken, realmName)
+	if err != nil {
+		logger.Warn(ctx, "msg", "Can't get realm from Keycloak", "err", err.Error(), "realm", realmName)
+		return nil, err
+	}
+	if realmKc.PasswordPolicy == nil || *realmKc.PasswordPolicy == "" {
+		return nil, nil
+	}
+
+	policy, err := keycloakb.ParsePasswordPolicy(*realmKc.PasswordPolicy)
+	if err != nil {
+		logger.Warn(ctx, "msg", "Can't parse password policy", "err", err.Error(), "realm", realmName)
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// getPasswordPolicyUserValues returns the username and the email of the user. They are only needed when the policy forbids them
+func getPasswordPolicyUserValues(ctx context.Context, keycloakClient KeycloakClient, logger keycloakb.Logger, accessToken, realmName, userID string,
+	policy *keycloakb.PasswordPolicy) (string, string, error) {
+	var username, email string
+	if policy == nil || !(policy.NotUsername || policy.NotEmail) {
+		return username, email, nil
+	}
+
+	userKc, err := keycloakClient.GetUser(accessToken, realmName, userID)
+	if err != nil {
+		logger.Warn(ctx, "msg", "Can't get user from Keycloak", "err", err.Error(), "realm", realmName, "userID", userID)
+		return "", "", err
+	}
+	if userKc.Username != nil {
+		username = *userKc.Username
+	}
+	if userKc.Email != nil {
+		email = *userKc.Email
+	}
+	return username, email, nil
+}
+
+// generatePassword generates a password respecting the password policy of the realm. Without policy, the password has the
+// format UpperCase + 6 digits + LowerCase
+func (c *component) generatePassword(ctx context.Context, realmName string, policy *keycloakb.PasswordPolicy, username, email string) (string, error) {
+	var minLength = 8
+
+	if policy == nil {
+		var nbUpperCase = 1
+		var nbDigits = 6
+		var nbLowerCase = 1
+		return keycloakb.GenerateInitialCode(nbUpperCase, nbDigits, nbLowerCase), nil
+	}
+
+	pwd, err := policy.GeneratePassword(minLength, username, email)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't generate a password respecting the policy", "err", err.Error(), "realm", realmName)
+		return "", err
+	}
+	return pwd, nil
+}
+
 func (c *component) ExecuteActionsEmail(ctx context.Context, realmName string, userID string, requiredActions []api.RequiredAction, paramKV ...string) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -1115,6 +2042,31 @@ func (c *component) GetRole(ctx context.Context, realmName string, roleID string
 	return roleRep, nil
 }
 
+func (c *component) CreateRole(ctx context.Context, realmName string, role api.RoleRepresentation) (string, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	locationURL, err := c.keycloakClient.CreateRole(accessToken, realmName, api.ConvertToKCRole(role))
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return "", err
+	}
+
+	return locationURL, nil
+}
+
+func (c *component) DeleteRole(ctx context.Context, realmName string, roleID string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	err := c.keycloakClient.DeleteRole(accessToken, realmName, roleID)
+
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+	}
+
+	return err
+}
+
 func (c *component) GetGroups(ctx context.Context, realmName string) ([]api.GroupRepresentation, error) {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -1159,6 +2111,47 @@ func (c *component) CreateGroup(ctx context.Context, realmName string, group api
 	return locationURL, nil
 }
 
+func (c *component) UpdateGroup(ctx context.Context, realmName, groupID string, group api.GroupRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	groupKc, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var groupName = *groupKc.Name
+	var newGroupName = *group.Name
+	if groupName == newGroupName {
+		return nil
+	}
+
+	groupKc.Name = &newGroupName
+	err = c.keycloakClient.UpdateGroup(accessToken, realmName, groupID, groupKc)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	// Authorizations and back-office configuration reference groups by name
+	err = c.configDBModule.RenameGroup(ctx, realmName, groupName, newGroupName)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't rename group in configuration database", "err", err.Error())
+		// Restore the former name in Keycloak to keep the authorizations consistent
+		groupKc.Name = &groupName
+		if errRestore := c.keycloakClient.UpdateGroup(accessToken, realmName, groupID, groupKc); errRestore != nil {
+			c.logger.Error(ctx, "msg", "Can't restore group name in Keycloak", "err", errRestore.Error(), "groupID", groupID, "groupName", groupName)
+		}
+		return err
+	}
+
+	//store the API call into the DB
+	c.reportEvent(ctx, "API_GROUP_UPDATE", database.CtEventRealmName, realmName, database.CtEventGroupID, groupID, database.CtEventGroupName, newGroupName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("previous_group_name", groupName))
+
+	return nil
+}
+
 func (c *component) DeleteGroup(ctx context.Context, realmName, groupID string) error {
 	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
 
@@ -1218,49 +2211,394 @@ func (c *component) UpdateAuthorizations(ctx context.Context, realmName string,
 
 	authorizations := api.ConvertToDBAuthorizations(realmName, groupName, auth)
 
-	if err = c.checkAllowedTargetRealmsAndGroupNames(ctx, realmName, authorizations); err != nil {
+	if err = c.replaceAuthorizations(ctx, realmName, groupID, groupName, authorizations); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_AUTHORIZATIONS_UPDATE", database.CtEventRealmName, realmName, database.CtEventGroupName, groupName)
+
+	return nil
+}
+
+// CopyAuthorizations replaces the authorizations of the target group by the ones of the given group, renaming their target realms and groups
+func (c *component) CopyAuthorizations(ctx context.Context, realmName string, groupID string, copyReq api.AuthorizationsCopyRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	group, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	authorizations, err := c.configDBModule.GetAuthorizations(ctx, realmName, *group.Name)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var targetRealmName = realmName
+	if copyReq.TargetRealm != nil {
+		targetRealmName = *copyReq.TargetRealm
+	}
+
+	targetGroup, err := c.keycloakClient.GetGroup(accessToken, targetRealmName, *copyReq.TargetGroupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var targetGroupName = *targetGroup.Name
+
+	authorizations = RemapAuthorizations(authorizations, targetRealmName, targetGroupName, mappingOrEmpty(copyReq.RealmMapping), mappingOrEmpty(copyReq.GroupMapping))
+
+	if err = c.replaceAuthorizations(ctx, targetRealmName, *copyReq.TargetGroupID, targetGroupName, authorizations); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_AUTHORIZATIONS_COPY", database.CtEventRealmName, targetRealmName, database.CtEventGroupName, targetGroupName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("source_realm_name", realmName, "source_group_name", *group.Name))
+
+	return nil
+}
+
+// GetAuthorizationTemplates returns all the authorization templates
+func (c *component) GetAuthorizationTemplates(ctx context.Context) ([]api.AuthorizationTemplateRepresentation, error) {
+	templates, err := c.configDBModule.GetAuthorizationTemplates(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var res = make([]api.AuthorizationTemplateRepresentation, 0, len(templates))
+	for templateName, authorizations := range templates {
+		var name = templateName
+		res = append(res, api.AuthorizationTemplateRepresentation{
+			Name:   &name,
+			Matrix: api.ConvertToAPIAuthorizations(authorizations).Matrix,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return *res[i].Name < *res[j].Name
+	})
+
+	return res, nil
+}
+
+// UpdateAuthorizationTemplate creates or replaces an authorization template. Target realms and groups are only checked when the template is applied
+func (c *component) UpdateAuthorizationTemplate(ctx context.Context, templateName string, auth api.AuthorizationsRepresentation) error {
+	var authorizations = api.ConvertToDBAuthorizations("", "", auth)
+	if len(authorizations) == 0 {
+		return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Authorization)
+	}
+	for _, authz := range authorizations {
+		if _, ok := FindAuthorizationAction(*authz.Action); !ok {
+			return errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Action)
+		}
+	}
+
+	if err := c.configDBModule.StoreAuthorizationTemplate(ctx, templateName, authorizations); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
+	c.reportEvent(ctx, "API_AUTHORIZATION_TEMPLATE_UPDATE", database.CtEventRealmName, currentRealm,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("template_name", templateName))
+
+	return nil
+}
+
+// DeleteAuthorizationTemplate deletes an authorization template. Groups it has been applied to keep their authorizations
+func (c *component) DeleteAuthorizationTemplate(ctx context.Context, templateName string) error {
+	if err := c.configDBModule.DeleteAuthorizationTemplate(ctx, templateName); err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var currentRealm = ctx.Value(cs.CtContextRealm).(string)
+	c.reportEvent(ctx, "API_AUTHORIZATION_TEMPLATE_DELETE", database.CtEventRealmName, currentRealm,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("template_name", templateName))
+
+	return nil
+}
+
+// ApplyAuthorizationTemplate replaces the authorizations of a group by the ones of a template, renaming their target realms and groups
+func (c *component) ApplyAuthorizationTemplate(ctx context.Context, realmName string, groupID string, application api.AuthorizationTemplateApplicationRepresentation) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	template, err := c.configDBModule.GetAuthorizationTemplate(ctx, *application.TemplateName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	if len(template) == 0 {
+		return errorhandler.CreateNotFoundError("authorizationTemplate")
+	}
+
+	group, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var groupName = *group.Name
+
+	authorizations := RemapAuthorizations(template, realmName, groupName, mappingOrEmpty(application.RealmMapping), mappingOrEmpty(application.GroupMapping))
+
+	if err = c.replaceAuthorizations(ctx, realmName, groupID, groupName, authorizations); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_AUTHORIZATIONS_TEMPLATE_APPLY", database.CtEventRealmName, realmName, database.CtEventGroupName, groupName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("template_name", *application.TemplateName))
+
+	return nil
+}
+
+// replaceAuthorizations validates the authorizations of a group, assigns the needed Keycloak roles to the group, replaces its authorizations in DB
+// and records them as a new version
+func (c *component) replaceAuthorizations(ctx context.Context, realmName, groupID, groupName string, authorizations []configuration.Authorization) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	if err := c.checkAllowedTargetRealmsAndGroupNames(ctx, realmName, authorizations); err != nil {
 		return err
 	}
 
 	// Assign KC roles to groups
-	if err = c.assignKCRolesToGroups(accessToken, realmName, groupID, authorizations); err != nil {
+	if err := c.assignKCRolesToGroups(accessToken, realmName, groupID, authorizations); err != nil {
 		c.logger.Warn(ctx, "err", err.Error())
 		return err
 	}
 
 	// Persists the new authorizations in DB
-	{
-		tx, err := c.configDBModule.NewTransaction(ctx)
+	tx, err := c.configDBModule.NewTransaction(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	defer tx.Close()
+
+	previousAuthorizations, err := c.configDBModule.GetAuthorizations(ctx, realmName, groupName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+	var previousRules = dto.ToAuthorizationRules(previousAuthorizations)
+
+	versions, err := c.configDBModule.GetAuthorizationVersions(ctx, realmName, groupName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var authorRealm = ctx.Value(cs.CtContextRealm).(string)
+	var author = ctx.Value(cs.CtContextUsername).(string)
+	var now = time.Now()
+	if len(versions) == 0 {
+		// The authorizations set before the versioning are recorded as the first version so that they can be restored
+		err = c.configDBModule.CreateAuthorizationVersion(ctx, dto.AuthorizationVersion{
+			RealmID:        realmName,
+			GroupName:      groupName,
+			AuthorRealm:    authorRealm,
+			Author:         author,
+			Date:           now,
+			Authorizations: previousRules,
+			Diff:           dto.DiffAuthorizationRules(nil, previousRules),
+		})
 		if err != nil {
 			c.logger.Warn(ctx, "err", err.Error())
 			return err
 		}
-		defer tx.Close()
+	}
 
-		err = c.configDBModule.DeleteAuthorizations(ctx, realmName, groupName)
+	err = c.configDBModule.DeleteAuthorizations(ctx, realmName, groupName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	for _, authorisation := range authorizations {
+		err = c.configDBModule.CreateAuthorization(ctx, authorisation)
 		if err != nil {
 			c.logger.Warn(ctx, "err", err.Error())
 			return err
 		}
+	}
 
-		for _, authorisation := range authorizations {
-			err = c.configDBModule.CreateAuthorization(ctx, authorisation)
-			if err != nil {
-				c.logger.Warn(ctx, "err", err.Error())
-				return err
-			}
-		}
+	var rules = dto.ToAuthorizationRules(authorizations)
+	err = c.configDBModule.CreateAuthorizationVersion(ctx, dto.AuthorizationVersion{
+		RealmID:        realmName,
+		GroupName:      groupName,
+		AuthorRealm:    authorRealm,
+		Author:         author,
+		Date:           now,
+		Authorizations: rules,
+		Diff:           dto.DiffAuthorizationRules(previousRules, rules),
+	})
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetAuthorizationVersions returns the recorded versions of the authorizations of a group, the most recent first
+func (c *component) GetAuthorizationVersions(ctx context.Context, realmName string, groupID string) ([]api.AuthorizationVersionRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	group, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	versions, err := c.configDBModule.GetAuthorizationVersions(ctx, realmName, *group.Name)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var res = make([]api.AuthorizationVersionRepresentation, 0, len(versions))
+	for _, version := range versions {
+		res = append(res, api.ConvertToAPIAuthorizationVersion(version))
+	}
+	return res, nil
+}
+
+// GetAuthorizationsDiff returns the authorization rules added and removed from a version of the authorizations of a group to another one
+func (c *component) GetAuthorizationsDiff(ctx context.Context, realmName string, groupID string, fromVersion int, toVersion int) (api.AuthorizationsDiffRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	group, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return api.AuthorizationsDiffRepresentation{}, err
+	}
+
+	from, err := c.getAuthorizationVersion(ctx, realmName, *group.Name, fromVersion)
+	if err != nil {
+		return api.AuthorizationsDiffRepresentation{}, err
+	}
+	to, err := c.getAuthorizationVersion(ctx, realmName, *group.Name, toVersion)
+	if err != nil {
+		return api.AuthorizationsDiffRepresentation{}, err
+	}
+
+	return api.ConvertToAPIAuthorizationsDiff(dto.DiffAuthorizationRules(from.Authorizations, to.Authorizations)), nil
+}
+
+// RollbackAuthorizations replaces the authorizations of a group by the ones of a previous version. The rollback is recorded as a new version
+func (c *component) RollbackAuthorizations(ctx context.Context, realmName string, groupID string, versionNumber int) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	group, err := c.keycloakClient.GetGroup(accessToken, realmName, groupID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	var groupName = *group.Name
+
+	version, err := c.getAuthorizationVersion(ctx, realmName, groupName, versionNumber)
+	if err != nil {
+		return err
+	}
+
+	authorizations := dto.ToAuthorizations(realmName, groupName, version.Authorizations)
+
+	if err = c.replaceAuthorizations(ctx, realmName, groupID, groupName, authorizations); err != nil {
+		return err
+	}
+
+	c.reportEvent(ctx, "API_AUTHORIZATIONS_ROLLBACK", database.CtEventRealmName, realmName, database.CtEventGroupName, groupName,
+		database.CtEventAdditionalInfo, database.CreateAdditionalInfo("version", strconv.Itoa(versionNumber)))
+
+	return nil
+}
+
+func (c *component) getAuthorizationVersion(ctx context.Context, realmName, groupName string, versionNumber int) (*dto.AuthorizationVersion, error) {
+	version, err := c.configDBModule.GetAuthorizationVersion(ctx, realmName, groupName, versionNumber)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	if version == nil {
+		return nil, errorhandler.CreateNotFoundError("authorizationVersion")
+	}
+	return version, nil
+}
+
+func mappingOrEmpty(mapping *map[string]string) map[string]string {
+	if mapping == nil {
+		return map[string]string{}
+	}
+	return *mapping
+}
+
+// ExplainAuthorization tells whether the members of a group, or a user, are allowed to perform an action and which authorizations grant it
+func (c *component) ExplainAuthorization(ctx context.Context, realmName string, check api.AuthorizationCheckRepresentation) (api.AuthorizationExplanationRepresentation, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	action, ok := FindAuthorizationAction(*check.Action)
+	if !ok {
+		return api.AuthorizationExplanationRepresentation{}, errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Action)
+	}
 
-		err = tx.Commit()
+	var groupNames []string
+	if check.GroupID != nil {
+		group, err := c.keycloakClient.GetGroup(accessToken, realmName, *check.GroupID)
 		if err != nil {
 			c.logger.Warn(ctx, "err", err.Error())
-			return err
+			return api.AuthorizationExplanationRepresentation{}, err
+		}
+		groupNames = append(groupNames, *group.Name)
+	} else {
+		groups, err := c.keycloakClient.GetGroupsOfUser(accessToken, realmName, *check.UserID)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return api.AuthorizationExplanationRepresentation{}, err
+		}
+		for _, group := range groups {
+			groupNames = append(groupNames, *group.Name)
 		}
 	}
 
-	c.reportEvent(ctx, "API_AUTHORIZATIONS_UPDATE", database.CtEventRealmName, realmName, database.CtEventGroupName, groupName)
+	// Actions of scope global are checked against the realm of the caller
+	var targetRealm = realmName
+	if action.Scope != security.ScopeGlobal && check.TargetRealm != nil {
+		targetRealm = *check.TargetRealm
+	}
+	var targetGroup *string
+	if action.Scope == security.ScopeGroup {
+		targetGroup = check.TargetGroup
+	}
 
-	return nil
+	var res = api.AuthorizationExplanationRepresentation{
+		Action:      action.Name,
+		Scope:       string(action.Scope),
+		TargetRealm: targetRealm,
+		TargetGroup: targetGroup,
+		Groups:      []api.GroupAuthorizationExplanationRepresentation{},
+	}
+	for _, groupName := range groupNames {
+		authorizations, err := c.configDBModule.GetAuthorizations(ctx, realmName, groupName)
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return api.AuthorizationExplanationRepresentation{}, err
+		}
+		var explanation = ExplainGroupAuthorization(groupName, authorizations, action, targetRealm, targetGroup)
+		res.Allowed = res.Allowed || explanation.Allowed
+		res.Groups = append(res.Groups, explanation)
+	}
+
+	return res, nil
 }
 
 func (c *component) checkAllowedTargetRealmsAndGroupNames(ctx context.Context, realmName string, authorizations []configuration.Authorization) error {
@@ -1596,7 +2934,7 @@ func (c *component) GetRealmAdminConfiguration(ctx context.Context, realmName st
 		return api.RealmAdminConfiguration{}, err
 	}
 
-	var config configuration.RealmAdminConfiguration
+	var config dto.RealmAdminConfiguration
 	config, err = c.configDBModule.GetAdminConfiguration(ctx, *realmConfig.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
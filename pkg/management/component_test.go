@@ -1,11 +1,15 @@
 package management
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +22,7 @@ import (
 	api "github.com/cloudtrust/keycloak-bridge/api/management"
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/cloudtrust/keycloak-client"
 
 	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
@@ -31,12 +36,13 @@ func TestGetActions(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -52,12 +58,13 @@ func TestGetRealms(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 
@@ -118,12 +125,13 @@ func TestGetRealm(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -185,12 +193,13 @@ func TestGetClient(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -255,12 +264,13 @@ func TestGetClients(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -320,17 +330,146 @@ func TestGetClients(t *testing.T) {
 	}
 }
 
+func TestCreateClient(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var idClient = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var clientID = "client-id"
+	var locationURL = "http://keycloak.url/auth/admin/realms/master/clients/" + idClient
+	var ignoredID = "ignored"
+	var client = api.ClientRepresentation{ID: &ignoredID, ClientID: &clientID}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateClient(accessToken, realmName, kc.ClientRepresentation{ClientID: &clientID}).Return("", errors.New("any error"))
+		var _, err = managementComponent.CreateClient(ctx, realmName, client)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateClient(accessToken, realmName, kc.ClientRepresentation{ClientID: &clientID}).Return(locationURL, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_CREATION", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient, "client_id", clientID)).Return(nil)
+		var location, err = managementComponent.CreateClient(ctx, realmName, client)
+		assert.Nil(t, err)
+		assert.Equal(t, locationURL, location)
+	})
+}
+
+func TestUpdateClient(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var idClient = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var name = "client name"
+	var disabled = false
+	var redirectURIs = []string{"https://app.example.com/*", "/callback"}
+	var anyError = errors.New("any error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Update client fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateClient(accessToken, realmName, idClient, kc.ClientRepresentation{ID: &idClient, Name: &name}).Return(anyError)
+		var err = managementComponent.UpdateClient(ctx, realmName, idClient, api.ClientRepresentation{Name: &name})
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Update client", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateClient(accessToken, realmName, idClient, kc.ClientRepresentation{ID: &idClient, Name: &name}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_UPDATE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient)).Return(nil)
+		var err = managementComponent.UpdateClient(ctx, realmName, idClient, api.ClientRepresentation{Name: &name})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Disable client", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateClient(accessToken, realmName, idClient, kc.ClientRepresentation{ID: &idClient, Enabled: &disabled}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_DISABLE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient)).Return(nil)
+		var err = managementComponent.DisableClient(ctx, realmName, idClient)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Update redirect URIs", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().UpdateClient(accessToken, realmName, idClient, kc.ClientRepresentation{ID: &idClient, RedirectUris: &redirectURIs}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_REDIRECT_URIS_UPDATE", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient, "redirect_uris", "https://app.example.com/* /callback")).Return(nil)
+		var err = managementComponent.UpdateClientRedirectURIs(ctx, realmName, idClient, redirectURIs)
+		assert.Nil(t, err)
+	})
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var idClient = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var secretType = "secret"
+	var secretValue = "n3w-s3cr3t"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().RegenerateSecret(accessToken, realmName, idClient).Return(kc.CredentialRepresentation{}, errors.New("any error"))
+		var _, err = managementComponent.RotateClientSecret(ctx, realmName, idClient)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().RegenerateSecret(accessToken, realmName, idClient).Return(kc.CredentialRepresentation{Type: &secretType, Value: &secretValue}, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_CLIENT_SECRET_ROTATION", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", idClient)).Return(nil)
+		var secret, err = managementComponent.RotateClientSecret(ctx, realmName, idClient)
+		assert.Nil(t, err)
+		assert.Equal(t, secretValue, *secret.Value)
+		assert.Equal(t, secretType, *secret.Type)
+	})
+}
+
 func TestGetRequiredActions(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -395,12 +534,13 @@ func TestCreateUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var username = "test"
@@ -432,6 +572,76 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, locationURL, location)
 	})
 
+	t.Run("Create with account expiry date", func(t *testing.T) {
+		var kcUserRep = kc.UserRepresentation{
+			Username: &username,
+		}
+		var expiryDate = "31.12.2030"
+		var expiry = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+		var userRep = api.UserRepresentation{
+			Username:          &username,
+			AccountExpiryDate: &expiryDate,
+		}
+
+		t.Run("Can't store expiry", func(t *testing.T) {
+			mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, kcUserRep).Return(locationURL, nil)
+			mockUsersDetailsDBModule.EXPECT().StoreAccountExpiry(ctx, targetRealmName, userID, &expiry).Return(errors.New("db error"))
+			mockLogger.EXPECT().Warn(ctx, "msg", "Can't store account expiry", "err", "db error")
+
+			_, err := managementComponent.CreateUser(ctx, targetRealmName, userRep)
+			assert.NotNil(t, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, kcUserRep).Return(locationURL, nil)
+			mockUsersDetailsDBModule.EXPECT().StoreAccountExpiry(ctx, targetRealmName, userID, &expiry).Return(nil)
+			mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT_EXPIRY", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventUserID, userID,
+				database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+			mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_CREATION", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+			location, err := managementComponent.CreateUser(ctx, targetRealmName, userRep)
+			assert.Nil(t, err)
+			assert.Equal(t, locationURL, location)
+		})
+	})
+
+	t.Run("Create with a complete identity only", func(t *testing.T) {
+		var firstName = "Titi"
+		var lastName = "Tutu"
+		var birthDate = "01.01.1988"
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+		mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return(locationURL, nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealmName, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, user dto.DBUser) error {
+				assert.Equal(t, userID, *user.UserID)
+				assert.Equal(t, firstName, *user.FirstName)
+				assert.Equal(t, lastName, *user.LastName)
+				assert.Equal(t, birthDate, *user.BirthDate)
+				assert.Nil(t, user.Nationality)
+				return nil
+			})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_CREATION", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var userRep = api.UserRepresentation{
+			Username:  &username,
+			FirstName: &firstName,
+			LastName:  &lastName,
+			BirthDate: &birthDate,
+		}
+
+		location, err := managementComponent.CreateUser(ctx, targetRealmName, userRep)
+
+		assert.Nil(t, err)
+		assert.Equal(t, locationURL, location)
+	})
+
 	t.Run("Create with minimum properties and having error when storing the event", func(t *testing.T) {
 		var kcUserRep = kc.UserRepresentation{
 			Username: &username,
@@ -591,24 +801,344 @@ func TestCreateUser(t *testing.T) {
 	})
 }
 
+func TestImportUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var targetRealmName = "DEP"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var locationURL = "http://toto.com/realms/" + userID
+	var groups = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"}
+	var newUsername = "new"
+	var existingUsername = "existing"
+	var nationality = "CH"
+	var reason = "invalidParameter.email"
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+	var entries = []api.UserImportEntry{
+		{Line: 2, User: api.UserRepresentation{Username: &newUsername, Groups: &groups, Nationality: &nationality}},
+		{Line: 3, User: api.UserRepresentation{Username: &existingUsername, Groups: &groups}},
+		{Line: 4, Reason: &reason},
+		{Line: 5, User: api.UserRepresentation{Username: &newUsername, Groups: &groups}},
+	}
+	var noUser = kc.UsersPageRepresentation{Users: []kc.UserRepresentation{}}
+	var existingUser = kc.UsersPageRepresentation{Users: []kc.UserRepresentation{{Username: &existingUsername}}}
+
+	t.Run("Dry run", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", newUsername).Return(noUser, nil).Times(2)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", existingUsername).Return(existingUser, nil)
+
+		var report, err = managementComponent.ImportUsers(ctx, targetRealmName, entries, true)
+		assert.Nil(t, err)
+		assert.True(t, report.DryRun)
+		assert.Len(t, report.Results, 4)
+		assert.Equal(t, api.ImportStatusCreated, report.Results[0].Status)
+		assert.Nil(t, report.Results[0].UserID)
+		assert.Equal(t, api.ImportStatusSkippedDuplicate, report.Results[1].Status)
+		assert.Equal(t, api.ImportStatusInvalid, report.Results[2].Status)
+		assert.Equal(t, reason, *report.Results[2].Reason)
+		assert.Equal(t, api.ImportStatusSkippedDuplicate, report.Results[3].Status)
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", newUsername).Return(noUser, nil).Times(2)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", existingUsername).Return(existingUser, nil)
+		mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return(locationURL, nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealmName, dto.DBUser{UserID: &userID, Nationality: &nationality}).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_CREATION", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventUserID, userID, database.CtEventUsername, newUsername).Return(nil)
+
+		var report, err = managementComponent.ImportUsers(ctx, targetRealmName, entries, false)
+		assert.Nil(t, err)
+		assert.False(t, report.DryRun)
+		assert.Equal(t, api.ImportStatusCreated, report.Results[0].Status)
+		assert.Equal(t, userID, *report.Results[0].UserID)
+		assert.Equal(t, api.ImportStatusSkippedDuplicate, report.Results[1].Status)
+		assert.Equal(t, api.ImportStatusInvalid, report.Results[2].Status)
+		assert.Equal(t, api.ImportStatusSkippedDuplicate, report.Results[3].Status)
+	})
+
+	t.Run("Can't check existing users", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", newUsername).Return(noUser, errors.New("error"))
+
+		var report, err = managementComponent.ImportUsers(ctx, targetRealmName, entries, true)
+		assert.Nil(t, err)
+		assert.Len(t, report.Results, 4)
+		assert.Equal(t, api.ImportStatusFailed, report.Results[0].Status)
+		assert.Equal(t, constants.MsgErrUnknown, *report.Results[0].Reason)
+		assert.Equal(t, api.ImportStatusNotProcessed, report.Results[1].Status)
+		assert.Equal(t, api.ImportStatusNotProcessed, report.Results[3].Status)
+	})
+
+	t.Run("Can't create user: users already created are reported", func(t *testing.T) {
+		var otherUsername = "other"
+		var entries = []api.UserImportEntry{
+			{Line: 2, User: api.UserRepresentation{Username: &newUsername}},
+			{Line: 3, User: api.UserRepresentation{Username: &otherUsername}},
+			{Line: 4, User: api.UserRepresentation{Username: &existingUsername}},
+		}
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", newUsername).Return(noUser, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", otherUsername).Return(noUser, nil)
+		gomock.InOrder(
+			mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return(locationURL, nil),
+			mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return("", errors.New("error")),
+		)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_CREATION", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventUserID, userID, database.CtEventUsername, newUsername).Return(nil)
+
+		var report, err = managementComponent.ImportUsers(ctx, targetRealmName, entries, false)
+		assert.Nil(t, err)
+		assert.Len(t, report.Results, 3)
+		assert.Equal(t, api.ImportStatusCreated, report.Results[0].Status)
+		assert.Equal(t, userID, *report.Results[0].UserID)
+		assert.Equal(t, api.ImportStatusFailed, report.Results[1].Status)
+		assert.Equal(t, otherUsername, *report.Results[1].Username)
+		assert.Equal(t, constants.MsgErrUnknown, *report.Results[1].Reason)
+		assert.Equal(t, api.ImportStatusNotProcessed, report.Results[2].Status)
+	})
+
+	t.Run("User rejected by Keycloak: the import goes on", func(t *testing.T) {
+		var otherUsername = "other"
+		var entries = []api.UserImportEntry{
+			{Line: 2, User: api.UserRepresentation{Username: &otherUsername}},
+			{Line: 3, User: api.UserRepresentation{Username: &existingUsername}},
+			{Line: 4, User: api.UserRepresentation{Username: &newUsername}},
+		}
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", otherUsername).Return(noUser, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", existingUsername).Return(noUser, kc.HTTPError{HTTPStatus: http.StatusBadRequest})
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", newUsername).Return(noUser, nil)
+		gomock.InOrder(
+			mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return("", kc.HTTPError{HTTPStatus: http.StatusConflict}),
+			mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return(locationURL, nil),
+		)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_CREATION", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventUserID, userID, database.CtEventUsername, newUsername).Return(nil)
+
+		var report, err = managementComponent.ImportUsers(ctx, targetRealmName, entries, false)
+		assert.Nil(t, err)
+		assert.Len(t, report.Results, 3)
+		assert.Equal(t, api.ImportStatusFailed, report.Results[0].Status)
+		assert.Equal(t, constants.MsgErrDuplicate, *report.Results[0].Reason)
+		assert.Equal(t, api.ImportStatusFailed, report.Results[1].Status)
+		assert.Equal(t, constants.MsgErrInvalidParam, *report.Results[1].Reason)
+		assert.Equal(t, api.ImportStatusCreated, report.Results[2].Status)
+		assert.Equal(t, userID, *report.Results[2].UserID)
+	})
+
+	t.Run("Invalid token: the import is interrupted", func(t *testing.T) {
+		var entries = []api.UserImportEntry{
+			{Line: 2, User: api.UserRepresentation{Username: &newUsername}},
+			{Line: 3, User: api.UserRepresentation{Username: &existingUsername}},
+		}
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "username", newUsername).Return(noUser, nil)
+		mockKeycloakClient.EXPECT().CreateUser(accessToken, realmName, targetRealmName, gomock.Any()).Return("", kc.HTTPError{HTTPStatus: http.StatusUnauthorized})
+
+		var report, err = managementComponent.ImportUsers(ctx, targetRealmName, entries, false)
+		assert.Nil(t, err)
+		assert.Len(t, report.Results, 2)
+		assert.Equal(t, api.ImportStatusFailed, report.Results[0].Status)
+		assert.Equal(t, constants.MsgErrUnknown, *report.Results[0].Reason)
+		assert.Equal(t, api.ImportStatusNotProcessed, report.Results[1].Status)
+	})
+}
+
+func TestExportUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var targetRealmName = "DEP"
+	var pageSize = strconv.Itoa(exportUsersPageSize)
+	var nationality = "CH"
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+	var fullPage = kc.UsersPageRepresentation{}
+	for i := 0; i < exportUsersPageSize; i++ {
+		var userID = fmt.Sprintf("user-%d", i)
+		fullPage.Users = append(fullPage.Users, kc.UserRepresentation{ID: &userID, Username: &userID})
+	}
+	var lastUserID = "last-user"
+	var lastPage = kc.UsersPageRepresentation{Users: []kc.UserRepresentation{{ID: &lastUserID, Username: &lastUserID}}}
+
+	t.Run("Can't get first page", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "first", "0", "max", pageSize).Return(kc.UsersPageRepresentation{}, errors.New("error"))
+
+		var _, err = managementComponent.ExportUsers(ctx, targetRealmName, "ndjson")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Export two pages", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "first", "0", "max", pageSize).Return(fullPage, nil)
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "first", pageSize, "max", pageSize).Return(lastPage, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, targetRealmName, gomock.Any()).Return(dto.DBUser{}, nil).Times(exportUsersPageSize)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, targetRealmName, lastUserID).Return(dto.DBUser{Nationality: &nationality}, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_EXPORT", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, "ndjson")
+		assert.Nil(t, err)
+
+		var buffer bytes.Buffer
+		err = export(&buffer)
+		assert.Nil(t, err)
+
+		var lines = strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		assert.Len(t, lines, exportUsersPageSize+1)
+		assert.Equal(t, `{"id":"last-user","username":"last-user","nationality":"CH"}`, lines[exportUsersPageSize])
+	})
+
+	t.Run("Can't get user details", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUsers(accessToken, realmName, targetRealmName, "first", "0", "max", pageSize).Return(lastPage, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, targetRealmName, lastUserID).Return(dto.DBUser{}, errors.New("error"))
+
+		var export, err = managementComponent.ExportUsers(ctx, targetRealmName, "csv")
+		assert.Nil(t, err)
+		assert.NotNil(t, export(&bytes.Buffer{}))
+	})
+}
+
+func TestLookupUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var realmName = "DEP"
+	var docNumber = "X123456"
+	var firstName = "John"
+	var lastName = "Doe"
+	var birthDate = "25.12.1980"
+	var userIDs = []string{"41dbf4a8-32a9-4000-8c17-edc854c31231"}
+	var ctx = context.TODO()
+
+	t.Run("Lookup by ID document number", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().FindUserIDsByDocumentNumber(ctx, realmName, docNumber).Return(userIDs, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_LOOKUP", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var res, err = managementComponent.LookupUsers(ctx, realmName, api.UserLookupRepresentation{IDDocumentNumber: &docNumber})
+		assert.Nil(t, err)
+		assert.Equal(t, userIDs, res)
+	})
+
+	t.Run("Lookup by identity", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().FindUserIDsByIdentity(ctx, realmName, firstName, lastName, birthDate).Return(userIDs, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_USERS_LOOKUP", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var res, err = managementComponent.LookupUsers(ctx, realmName, api.UserLookupRepresentation{FirstName: &firstName, LastName: &lastName, BirthDate: &birthDate})
+		assert.Nil(t, err)
+		assert.Equal(t, userIDs, res)
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().FindUserIDsByDocumentNumber(ctx, realmName, docNumber).Return(nil, errors.New("db error"))
+
+		var _, err = managementComponent.LookupUsers(ctx, realmName, api.UserLookupRepresentation{IDDocumentNumber: &docNumber})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetDuplicateUsers(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockDuplicatesModule = mock.NewDuplicatesModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+
+	var managementComponent = NewComponent(mockKeycloakClient, nil, nil, nil, mockDuplicatesModule, nil, mockEventDBModule, nil, nil, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+
+	t.Run("Can't get duplicates report", func(t *testing.T) {
+		mockDuplicatesModule.EXPECT().GetDuplicatesReport(ctx, accessToken, "master", realmName).Return(nil, errors.New("error"))
+
+		var _, err = managementComponent.GetDuplicateUsers(ctx, realmName)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var duplicates = []dto.DuplicateUsers{{Criteria: dto.DuplicateCriteriaIdentity, UserIDs: []string{"user-1", "user-2"}}}
+		mockDuplicatesModule.EXPECT().GetDuplicatesReport(ctx, accessToken, "master", realmName).Return(duplicates, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_DUPLICATE_USERS_REPORT", "back-office", database.CtEventRealmName, realmName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		var res, err = managementComponent.GetDuplicateUsers(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, dto.DuplicateCriteriaIdentity, *res[0].Criteria)
+		assert.Equal(t, []string{"user-1", "user-2"}, res[0].UserIDs)
+	})
+}
+
 func TestDeleteUser(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockAccreditationsModule = mock.NewAccreditationsModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, mockAccreditationsModule, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
 	var realmName = "master"
+	var realmID = "master-id"
 	var username = "username"
 
+	mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil).AnyTimes()
+
+	t.Run("Can't revoke accreditations", func(t *testing.T) {
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		ctx = context.WithValue(ctx, cs.CtContextUsername, username)
+		var anyError = errors.New("db error")
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(gomock.Any(), realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
+		mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, userID, dto.AccreditationRevocationUserDeleted, username).Return(anyError)
+
+		err := managementComponent.DeleteUser(ctx, realmName, userID)
+
+		assert.Equal(t, anyError, err)
+	})
+
+	// Deleting a user revokes its accreditations
+	mockAccreditationsModule.EXPECT().RevokeAccreditations(gomock.Any(), realmName, userID, dto.AccreditationRevocationUserDeleted, gomock.Any()).Return(nil).AnyTimes()
+
 	t.Run("Delete user with success", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(gomock.Any(), realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
 		mockKeycloakClient.EXPECT().DeleteUser(accessToken, realmName, userID).Return(nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -625,6 +1155,7 @@ func TestDeleteUser(t *testing.T) {
 	})
 
 	t.Run("Delete user with success but the having an error when storing the event in the DB", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(gomock.Any(), realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
 		mockKeycloakClient.EXPECT().DeleteUser(accessToken, realmName, userID).Return(nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -643,6 +1174,7 @@ func TestDeleteUser(t *testing.T) {
 	})
 
 	t.Run("Error from KC client", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(gomock.Any(), realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
 		mockKeycloakClient.EXPECT().DeleteUser(accessToken, realmName, userID).Return(fmt.Errorf("Invalid input")).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -654,6 +1186,7 @@ func TestDeleteUser(t *testing.T) {
 	})
 
 	t.Run("Error from DB users", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(gomock.Any(), realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
 		mockKeycloakClient.EXPECT().DeleteUser(accessToken, realmName, userID).Return(nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -665,6 +1198,177 @@ func TestDeleteUser(t *testing.T) {
 
 		assert.NotNil(t, err)
 	})
+
+	t.Run("Can't get admin configuration", func(t *testing.T) {
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, errors.New("db error"))
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get admin configuration", "err", "db error", "realm", realmName)
+
+		err := managementComponent.DeleteUser(ctx, realmName, userID)
+
+		assert.NotNil(t, err)
+	})
+
+	var retentionDays = 30
+	var softDeletionConfig = dto.RealmAdminConfiguration{SoftDeletion: &dto.SoftDeletionPolicy{RetentionDays: &retentionDays}}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, username)
+
+	t.Run("Soft delete user with success", func(t *testing.T) {
+		var enabled = true
+		var attributes = make(kc.Attributes)
+		attributes.Set(constants.AttrbAccreditations, []string{`{"type":"SHADOW","expiryDate":"` + time.Now().AddDate(1, 0, 0).Format("02.01.2006") + `"}`})
+		var userKc = kc.UserRepresentation{ID: &userID, Username: &username, Enabled: &enabled, Attributes: &attributes}
+		var nationality = "CH"
+
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(softDeletionConfig, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(nil, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(userKc, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{Nationality: &nationality}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetChecks(ctx, realmName, userID).Return([]dto.DBCheck{}, nil)
+		mockArchiveDBModule.EXPECT().StoreUserDetails(ctx, realmName, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, user dto.ArchiveUserRepresentation) error {
+			assert.True(t, *user.Enabled)
+			assert.Equal(t, nationality, *user.Nationality)
+			return nil
+		})
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.False(t, *user.Enabled)
+			assert.Contains(t, user.GetAttribute(constants.AttrbAccreditations)[0], `"revoked":true`)
+			return nil
+		})
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().StoreUserDeletion(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, deletion dto.DBUserDeletion) error {
+			assert.Equal(t, username, *deletion.DeletedBy)
+			assert.Equal(t, deletion.DeletionDate.AddDate(0, 0, retentionDays), deletion.PurgeDate)
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_SOFT_DELETION", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		err := managementComponent.DeleteUser(ctx, realmName, userID)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("Soft delete user: can't delete user lock", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(softDeletionConfig, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(nil, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{ID: &userID}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetChecks(ctx, realmName, userID).Return(nil, nil)
+		mockArchiveDBModule.EXPECT().StoreUserDetails(ctx, realmName, gomock.Any()).Return(nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(errors.New("db error"))
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't delete user lock", "err", "db error")
+
+		err := managementComponent.DeleteUser(ctx, realmName, userID)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Soft delete user already deleted", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(softDeletionConfig, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(&dto.DBUserDeletion{}, nil)
+
+		err := managementComponent.DeleteUser(ctx, realmName, userID)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("Soft delete user: archive fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(softDeletionConfig, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(nil, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{ID: &userID}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{}, nil)
+		mockUsersDetailsDBModule.EXPECT().GetChecks(ctx, realmName, userID).Return(nil, nil)
+		mockArchiveDBModule.EXPECT().StoreUserDetails(ctx, realmName, gomock.Any()).Return(errors.New("archive error"))
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't archive user", "err", "archive error")
+
+		err := managementComponent.DeleteUser(ctx, realmName, userID)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRestoreUser(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, nil, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var realmName = "master"
+	var disabled = false
+	var deletion = dto.DBUserDeletion{RealmID: realmName, UserID: userID, PurgeDate: time.Now().Add(time.Hour)}
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("User is not deleted", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(nil, nil)
+
+		err := managementComponent.RestoreUser(ctx, realmName, userID)
+
+		assert.IsType(t, errorhandler.Error{}, err)
+	})
+
+	t.Run("Retention period is over", func(t *testing.T) {
+		var expired = dto.DBUserDeletion{RealmID: realmName, UserID: userID, PurgeDate: time.Now().Add(-time.Hour)}
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(&expired, nil)
+
+		err := managementComponent.RestoreUser(ctx, realmName, userID)
+
+		assert.IsType(t, errorhandler.Error{}, err)
+	})
+
+	t.Run("Can't update user in Keycloak", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(&deletion, nil)
+		mockArchiveDBModule.EXPECT().GetLastUserDetails(ctx, realmName, userID).Return(nil, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{ID: &userID, Enabled: &disabled}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(errors.New("kc error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "kc error")
+
+		err := managementComponent.RestoreUser(ctx, realmName, userID)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Restore the state of the user before its deletion", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(&deletion, nil)
+		mockArchiveDBModule.EXPECT().GetLastUserDetails(ctx, realmName, userID).Return(&dto.ArchiveUserRepresentation{Enabled: &disabled}, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{ID: &userID, Enabled: &disabled}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.False(t, *user.Enabled)
+			return nil
+		})
+		mockUsersDetailsDBModule.EXPECT().DeleteUserDeletion(ctx, realmName, userID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_RESTORE", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID).Return(nil)
+
+		err := managementComponent.RestoreUser(ctx, realmName, userID)
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("Restore an enabled user", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetUserDeletion(ctx, realmName, userID).Return(&deletion, nil)
+		mockArchiveDBModule.EXPECT().GetLastUserDetails(ctx, realmName, userID).Return(nil, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{ID: &userID, Enabled: &disabled}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.True(t, *user.Enabled)
+			return nil
+		})
+		mockUsersDetailsDBModule.EXPECT().DeleteUserDeletion(ctx, realmName, userID).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_RESTORE", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID).Return(nil)
+
+		err := managementComponent.RestoreUser(ctx, realmName, userID)
+
+		assert.Nil(t, err)
+	})
 }
 
 func TestGetUser(t *testing.T) {
@@ -672,12 +1376,13 @@ func TestGetUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -704,6 +1409,7 @@ func TestGetUser(t *testing.T) {
 		var idDocumentNumber = "1234-4567-VD-3"
 		var idDocumentExpiration = "23.12.2019"
 		var idDocumentCountry = "MX"
+		var accountExpiry = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
 
 		var attributes = make(kc.Attributes)
 		attributes.SetString(constants.AttrbPhoneNumber, phoneNumber)
@@ -741,6 +1447,7 @@ func TestGetUser(t *testing.T) {
 			IDDocumentType:       &idDocumentType,
 			IDDocumentCountry:    &idDocumentCountry,
 		}, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(&accountExpiry, nil).Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "GET_DETAILS", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
@@ -748,6 +1455,7 @@ func TestGetUser(t *testing.T) {
 
 		assert.Nil(t, err)
 		assert.Equal(t, username, *apiUserRep.Username)
+		assert.NotNil(t, apiUserRep.ETag)
 		assert.Equal(t, email, *apiUserRep.Email)
 		assert.Equal(t, enabled, *apiUserRep.Enabled)
 		assert.Equal(t, emailVerified, *apiUserRep.EmailVerified)
@@ -767,6 +1475,7 @@ func TestGetUser(t *testing.T) {
 		assert.Equal(t, idDocumentNumber, *apiUserRep.IDDocumentNumber)
 		assert.Equal(t, idDocumentType, *apiUserRep.IDDocumentType)
 		assert.Equal(t, idDocumentCountry, *apiUserRep.IDDocumentCountry)
+		assert.Equal(t, "31.12.2030", *apiUserRep.AccountExpiryDate)
 	})
 
 	t.Run("Get user with succces with empty user info", func(t *testing.T) {
@@ -814,6 +1523,7 @@ func TestGetUser(t *testing.T) {
 		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dto.DBUser{
 			UserID: &id,
 		}, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, nil).Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "GET_DETAILS", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
@@ -868,6 +1578,7 @@ func TestGetUser(t *testing.T) {
 			IDDocumentType:       &idDocumentType,
 			IDDocumentCountry:    &idDocumentCountry,
 		}, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, nil).Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "GET_DETAILS", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, id, database.CtEventUsername, username).Return(errors.New("error")).Times(1)
 		m := map[string]interface{}{"event_name": "GET_DETAILS", database.CtEventRealmName: realmName, database.CtEventUserID: id, database.CtEventUsername: username}
@@ -879,6 +1590,65 @@ func TestGetUser(t *testing.T) {
 		assert.Equal(t, username, *apiUserRep.Username)
 	})
 
+	t.Run("Get locked user", func(t *testing.T) {
+		var disabled = false
+		var kcUserRep = kc.UserRepresentation{
+			ID:       &id,
+			Username: &username,
+			Enabled:  &disabled,
+		}
+		var lock = dto.DBUserLock{RealmID: realmName, UserID: id, Reason: "FRAUD", LockedAt: time.Now()}
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil).Times(1)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dto.DBUser{UserID: &id}, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetUserLock(ctx, realmName, id).Return(&lock, nil).Times(1)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "GET_DETAILS", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		apiUserRep, err := managementComponent.GetUser(ctx, realmName, id)
+		assert.Nil(t, err)
+		assert.Equal(t, "FRAUD", *apiUserRep.Lock.Reason)
+	})
+
+	t.Run("Can't get lock of disabled user", func(t *testing.T) {
+		var disabled = false
+		var kcUserRep = kc.UserRepresentation{
+			ID:       &id,
+			Username: &username,
+			Enabled:  &disabled,
+		}
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil).Times(1)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dto.DBUser{UserID: &id}, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetUserLock(ctx, realmName, id).Return(nil, fmt.Errorf("SQL Error")).Times(1)
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get user lock", "err", "SQL Error")
+
+		_, err := managementComponent.GetUser(ctx, realmName, id)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Can't get account expiry", func(t *testing.T) {
+		var kcUserRep = kc.UserRepresentation{
+			ID:       &id,
+			Username: &username,
+		}
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil).Times(1)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dto.DBUser{UserID: &id}, nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, fmt.Errorf("SQL Error")).Times(1)
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get account expiry", "err", "SQL Error")
+
+		_, err := managementComponent.GetUser(ctx, realmName, id)
+		assert.NotNil(t, err)
+	})
+
 	t.Run("Error with Users DB", func(t *testing.T) {
 		var kcUserRep = kc.UserRepresentation{
 			ID:       &id,
@@ -914,12 +1684,14 @@ func TestUpdateUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockAccreditationsModule = mock.NewAccreditationsModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, mockAccreditationsModule, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -989,9 +1761,111 @@ func TestUpdateUser(t *testing.T) {
 		Locale:              &locale,
 	}
 
-	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
-	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
-	ctx = context.WithValue(ctx, cs.CtContextUsername, username)
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, username)
+
+	t.Run("Changes of the user are reported", func(t *testing.T) {
+		var newEmail = "tutu@elca.ch"
+		var newNationality = "FR"
+		var userUpdate = api.UserRepresentation{
+			Email:       &newEmail,
+			Nationality: &newNationality,
+		}
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_UPDATE", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, id,
+			database.CtEventUsername, username, database.CtEventAdditionalInfo, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _ string, values ...string) error {
+				var additionalInfo = values[len(values)-1]
+				assert.Contains(t, additionalInfo, `{"field":"email","old":"t***","new":"t***"}`)
+				assert.Contains(t, additionalInfo, `{"field":"emailVerified","old":"true","new":"false"}`)
+				assert.Contains(t, additionalInfo, `{"field":"nationality","old":"***","new":"***"}`)
+				assert.NotContains(t, additionalInfo, newEmail)
+				return nil
+			})
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userUpdate)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Change of the account expiry is reported once stored", func(t *testing.T) {
+		var formerExpiry = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+		var newExpiryDate = "30.06.2031"
+		var newExpiry = time.Date(2031, 6, 30, 0, 0, 0, 0, time.UTC)
+		var userUpdate = api.UserRepresentation{
+			AccountExpiryDate: &newExpiryDate,
+		}
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(&formerExpiry, nil)
+		gomock.InOrder(
+			mockUsersDetailsDBModule.EXPECT().StoreAccountExpiry(ctx, realmName, id, &newExpiry).Return(nil),
+			mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT_EXPIRY", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, id,
+				database.CtEventAdditionalInfo, gomock.Any()).Return(nil),
+			mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_UPDATE", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, id,
+				database.CtEventUsername, username, database.CtEventAdditionalInfo, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _, _ string, values ...string) error {
+					var additionalInfo = values[len(values)-1]
+					assert.Contains(t, additionalInfo, `{"field":"accountExpiryDate","old":"31.12.2030","new":"30.06.2031"}`)
+					return nil
+				}),
+		)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userUpdate)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Can't store account expiry: the update is not reported", func(t *testing.T) {
+		var newExpiryDate = "30.06.2031"
+		var userUpdate = api.UserRepresentation{
+			AccountExpiryDate: &newExpiryDate,
+		}
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, nil)
+		mockUsersDetailsDBModule.EXPECT().StoreAccountExpiry(ctx, realmName, id, gomock.Any()).Return(errors.New("db error"))
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't store account expiry", "err", "db error")
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userUpdate)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Account expiry modified since the user was read", func(t *testing.T) {
+		var expiry = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+		var etag = keycloakb.ComputeUserETag(kcUserRep, dbUserRep, nil)
+		var userWithETag = userRep
+		userWithETag.ETag = &etag
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(&expiry, nil)
+		mockLogger.EXPECT().Warn(ctx, "msg", "User has been modified since it was read", "realm", realmName, "userID", id)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userWithETag)
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Can't get account expiry", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(ctx, realmName, id).Return(nil, errors.New("db error"))
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get account expiry", "err", "db error")
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userRep)
+		assert.NotNil(t, err)
+	})
+	mockEventDBModule.EXPECT().ReportEvent(ctx, "API_ACCOUNT_UPDATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockUsersDetailsDBModule.EXPECT().GetAccountExpiry(gomock.Any(), realmName, id).Return(nil, nil).AnyTimes()
 
 	t.Run("Update user with succces (without user info update)", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil).Times(1)
@@ -1019,6 +1893,94 @@ func TestUpdateUser(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("User modified since it was read", func(t *testing.T) {
+		var outdatedETag = `"outdated"`
+		var userWithETag = userRep
+		userWithETag.ETag = &outdatedETag
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockLogger.EXPECT().Warn(ctx, "msg", "User has been modified since it was read", "realm", realmName, "userID", id)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userWithETag)
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Update user with current version", func(t *testing.T) {
+		var etag = keycloakb.ComputeUserETag(kcUserRep, dbUserRep, nil)
+		var userWithETag = userRep
+		userWithETag.ETag = &etag
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).Return(nil)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userWithETag)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Clear fields with a merge patch", func(t *testing.T) {
+		var userPatch = api.UserRepresentation{
+			ClearedFields: []string{api.FieldPhoneNumber, api.FieldGender, api.FieldNationality, api.FieldAccountExpiryDate},
+		}
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).DoAndReturn(
+			func(accessToken, realmName, id string, kcUserRep kc.UserRepresentation) error {
+				assert.Nil(t, kcUserRep.GetAttributeString(constants.AttrbPhoneNumber))
+				assert.Nil(t, kcUserRep.GetAttributeString(constants.AttrbPhoneNumberVerified))
+				assert.Nil(t, kcUserRep.GetAttributeString(constants.AttrbGender))
+				assert.Equal(t, label, *kcUserRep.GetAttributeString(constants.AttrbLabel))
+				assert.Equal(t, birthDate, *kcUserRep.GetAttributeString(constants.AttrbBirthDate))
+				return nil
+			})
+		// removing the gender revokes the accreditations
+		mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, id, dto.AccreditationRevocationIdentityUpdated, username).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, dbUser dto.DBUser) error {
+				assert.Nil(t, dbUser.Nationality)
+				assert.Equal(t, birthLocation, *dbUser.BirthLocation)
+				return nil
+			})
+		mockUsersDetailsDBModule.EXPECT().StoreAccountExpiry(ctx, realmName, id, nil).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT_EXPIRY", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, id,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userPatch)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Can't record the revocation of the accreditations", func(t *testing.T) {
+		var userPatch = api.UserRepresentation{
+			ClearedFields: []string{api.FieldGender},
+		}
+		var anyError = errors.New("history failure")
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, realmName, id, dto.AccreditationRevocationIdentityUpdated, username).Return(anyError)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userPatch)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Remove account expiry", func(t *testing.T) {
+		var noExpiry = ""
+		var userWithExpiry = userRep
+		userWithExpiry.AccountExpiryDate = &noExpiry
+
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil)
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().StoreAccountExpiry(ctx, realmName, id, nil).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UPDATE_ACCOUNT_EXPIRY", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, id,
+			database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+
+		err := managementComponent.UpdateUser(ctx, realmName, id, userWithExpiry)
+		assert.Nil(t, err)
+	})
+
 	t.Run("Update user with succces (with user info update)", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil).Times(2)
 		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil).Times(2)
@@ -1145,11 +2107,16 @@ func TestUpdateUser(t *testing.T) {
 	})
 
 	t.Run("Update to unlock the user", func(t *testing.T) {
-		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(kcUserRep, nil).Times(1)
+		var disabled = false
+		var lockedKcUserRep = kcUserRep
+		lockedKcUserRep.Enabled = &disabled
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, id).Return(lockedKcUserRep, nil).Times(1)
 		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, id).Return(dbUserRep, nil).Times(1)
 
 		enabled = true
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, id, gomock.Any()).Return(nil).Times(1)
+		// a soft deleted user which is enabled again is not purged
+		mockUsersDetailsDBModule.EXPECT().DeleteUserDeletion(ctx, realmName, id).Return(nil).Times(1)
 
 		var userRepLocked = api.UserRepresentation{
 			Username:            &username,
@@ -1188,6 +2155,14 @@ func TestUpdateUser(t *testing.T) {
 				assert.Equal(t, false, *kcUserRep.EmailVerified)
 				return nil
 			}).Times(1)
+		// Identity changed: user details are stored again to update the identity blind index
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, realm string, user dto.DBUser) error {
+				assert.Equal(t, firstName, *user.FirstName)
+				assert.Equal(t, lastName, *user.LastName)
+				assert.Equal(t, birthDate, *user.BirthDate)
+				return nil
+			}).Times(1)
 
 		err := managementComponent.UpdateUser(ctx, "master", id, userRep)
 
@@ -1212,6 +2187,7 @@ func TestUpdateUser(t *testing.T) {
 				assert.Equal(t, false, *verified)
 				return nil
 			}).Times(1)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).Return(nil).Times(1)
 
 		err := managementComponent.UpdateUser(ctx, "master", id, userRep)
 
@@ -1244,6 +2220,8 @@ func TestUpdateUser(t *testing.T) {
 				assert.Equal(t, true, *verified)
 				return nil
 			}).Times(1)
+		mockAccreditationsModule.EXPECT().RevokeAccreditations(ctx, "master", id, dto.AccreditationRevocationIdentityUpdated, username).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().StoreOrUpdateUserDetails(ctx, realmName, gomock.Any()).Return(nil).Times(1)
 
 		err := managementComponent.UpdateUser(ctx, "master", id, userRepWithoutAttr)
 
@@ -1347,27 +2325,48 @@ func TestLockUnlockUser(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 
-	var managementComponent = NewComponent(mockKeycloakClient, nil, mockEventDBModule, nil, nil, log.NewNopLogger())
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, nil, nil, log.NewNopLogger())
 
 	var accessToken = "TOKEN=="
 	var realmName = "myrealm"
 	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var operator = "support-agent"
 	var anyError = errors.New("any")
 	var bTrue = true
 	var bFalse = false
+	var reason = "FRAUD_SUSPICION"
+	var comment = "Called by the bank"
+	var unlockAt = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	var unlockAtMillis = unlockAt.UnixNano() / int64(time.Millisecond)
+	var lock = api.UserLockRepresentation{Reason: &reason, Comment: &comment, UnlockAt: &unlockAtMillis}
 	var ctx = context.TODO()
 	ctx = context.WithValue(ctx, cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, operator)
 
+	t.Run("Can't delete previous lock information", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(anyError)
+		var err = managementComponent.LockUser(ctx, realmName, userID, api.UserLockRepresentation{})
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Can't store lock information", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().StoreUserLock(ctx, gomock.Any()).Return(anyError)
+		var err = managementComponent.LockUser(ctx, realmName, userID, lock)
+		assert.Equal(t, anyError, err)
+	})
 	t.Run("GetUser fails", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(nil)
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, anyError)
-		var err = managementComponent.LockUser(ctx, realmName, userID)
+		var err = managementComponent.LockUser(ctx, realmName, userID, api.UserLockRepresentation{})
 		assert.Equal(t, anyError, err)
 	})
 	t.Run("Can't lock disabled user", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(nil)
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
-		var err = managementComponent.LockUser(ctx, realmName, userID)
+		var err = managementComponent.LockUser(ctx, realmName, userID, api.UserLockRepresentation{})
 		assert.Nil(t, err)
 	})
 	t.Run("UpdateUser fails", func(t *testing.T) {
@@ -1377,17 +2376,198 @@ func TestLockUnlockUser(t *testing.T) {
 		assert.Equal(t, anyError, err)
 	})
 	t.Run("Lock success", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(nil)
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Enabled: &bTrue}, nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "LOCK_ACCOUNT", "back-office", gomock.Any()).Return(nil).Times(1)
-		var err = managementComponent.LockUser(ctx, realmName, userID)
+		var err = managementComponent.LockUser(ctx, realmName, userID, api.UserLockRepresentation{})
+		assert.Nil(t, err)
+	})
+	t.Run("Lock with reason and unlock date", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().StoreUserLock(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, dbLock dto.DBUserLock) error {
+			assert.Equal(t, realmName, dbLock.RealmID)
+			assert.Equal(t, userID, dbLock.UserID)
+			assert.Equal(t, reason, dbLock.Reason)
+			assert.Equal(t, comment, *dbLock.Comment)
+			assert.Equal(t, operator, *dbLock.LockedBy)
+			assert.True(t, unlockAt.Equal(*dbLock.UnlockAt))
+			return nil
+		})
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Enabled: &bTrue}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "LOCK_ACCOUNT", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventUsername, "", database.CtEventAdditionalInfo, gomock.Any()).Return(nil).Times(1)
+		var err = managementComponent.LockUser(ctx, realmName, userID, lock)
 		assert.Nil(t, err)
 	})
+	t.Run("Unlock success but can't delete lock information", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UNLOCK_ACCOUNT", "back-office", gomock.Any()).Return(nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(anyError)
+		var err = managementComponent.UnlockUser(ctx, realmName, userID)
+		assert.Equal(t, anyError, err)
+	})
 	t.Run("Unlock success", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "UNLOCK_ACCOUNT", "back-office", gomock.Any()).Return(nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().DeleteUserDeletion(ctx, realmName, userID).Return(nil)
+		var err = managementComponent.UnlockUser(ctx, realmName, userID)
+		assert.Nil(t, err)
+	})
+	t.Run("Unlock success but can't delete user deletion", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Enabled: &bFalse}, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "UNLOCK_ACCOUNT", "back-office", gomock.Any()).Return(nil).Times(1)
+		mockUsersDetailsDBModule.EXPECT().DeleteUserLock(ctx, realmName, userID).Return(nil)
+		mockUsersDetailsDBModule.EXPECT().DeleteUserDeletion(ctx, realmName, userID).Return(anyError)
 		var err = managementComponent.UnlockUser(ctx, realmName, userID)
+		assert.Equal(t, anyError, err)
+	})
+}
+
+func TestAccreditationsLifecycle(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockAccreditationsModule = mock.NewAccreditationsModule(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, mockAccreditationsModule, nil, nil, mockEventDBModule, nil, nil, log.NewNopLogger())
+
+	var accessToken = "TOKEN=="
+	var realmName = "myrealm"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var username = "username"
+	var operator = "support-agent"
+	var anyError = errors.New("any")
+	var reasonValue = "Identity document reported as stolen"
+	var reason = api.AccreditationReasonRepresentation{Reason: &reasonValue}
+	var future = time.Now().AddDate(1, 0, 0).Format("02.01.2006")
+	var ctx = context.TODO()
+	ctx = context.WithValue(ctx, cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, operator)
+
+	var createUser = func(accreds ...string) kc.UserRepresentation {
+		var attributes = make(kc.Attributes)
+		attributes.Set(constants.AttrbAccreditations, accreds)
+		return kc.UserRepresentation{ID: &userID, Username: &username, Attributes: &attributes}
+	}
+	var activeAccred = `{"type":"SHADOW","expiryDate":"` + future + `"}`
+	var revokedAccred = `{"type":"SHADOW","expiryDate":"` + future + `","revoked":true,"revocationDate":"01.01.2020","revocationReason":"fraud","revokedBy":"operator"}`
+
+	t.Run("Get accreditations: GetUser fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, anyError)
+		var _, err = managementComponent.GetUserAccreditations(ctx, realmName, userID)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Get accreditations: success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(revokedAccred, `{"type":"DEP","expiryDate":"01.01.2015"}`), nil)
+		var res, err = managementComponent.GetUserAccreditations(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		assert.True(t, *res[0].Revoked)
+		assert.Equal(t, "fraud", *res[0].RevocationReason)
+		assert.True(t, *res[1].Expired)
+	})
+
+	t.Run("Get accreditations history: database fails", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetAccreditations(ctx, realmName, userID).Return(nil, anyError)
+		var _, err = managementComponent.GetUserAccreditationsHistory(ctx, realmName, userID)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Get accreditations history: success", func(t *testing.T) {
+		var revocationDate = time.Now()
+		mockUsersDetailsDBModule.EXPECT().GetAccreditations(ctx, realmName, userID).Return([]dto.DBAccreditation{
+			{Type: "SHADOW", GrantDate: revocationDate.AddDate(-1, 0, 0), RevocationDate: &revocationDate, RevocationReason: &reasonValue},
+			{Type: "SHADOW", GrantDate: revocationDate, Operator: &operator},
+		}, nil)
+		var res, err = managementComponent.GetUserAccreditationsHistory(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, reasonValue, *res[0].RevocationReason)
+		assert.Equal(t, operator, *res[1].GrantedBy)
+	})
+
+	t.Run("Revoke: GetUser fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, anyError)
+		var err = managementComponent.RevokeAccreditation(ctx, realmName, userID, "SHADOW", reason)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Revoke: unknown accreditation", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(activeAccred), nil)
+		var err = managementComponent.RevokeAccreditation(ctx, realmName, userID, "DEP", reason)
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusNotFound, err.(errorhandler.Error).Status)
+	})
+	t.Run("Revoke: history can't be recorded", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(activeAccred), nil)
+		mockAccreditationsModule.EXPECT().RevokeAccreditation(ctx, realmName, userID, "SHADOW", reasonValue, operator).Return(anyError)
+		var err = managementComponent.RevokeAccreditation(ctx, realmName, userID, "SHADOW", reason)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Revoke: UpdateUser fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(activeAccred), nil)
+		mockAccreditationsModule.EXPECT().RevokeAccreditation(ctx, realmName, userID, "SHADOW", reasonValue, operator).Return(nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(anyError)
+		var err = managementComponent.RevokeAccreditation(ctx, realmName, userID, "SHADOW", reason)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Revoke: success even if archiving fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(activeAccred), nil)
+		mockAccreditationsModule.EXPECT().RevokeAccreditation(ctx, realmName, userID, "SHADOW", reasonValue, operator).Return(nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			var accreds = user.GetAttribute(constants.AttrbAccreditations)
+			assert.Contains(t, accreds[0], `"revoked":true`)
+			assert.Contains(t, accreds[0], `"revocationReason":"`+reasonValue+`"`)
+			assert.Contains(t, accreds[0], `"revokedBy":"`+operator+`"`)
+			return nil
+		})
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{}, anyError)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "ACCREDITATION_REVOKED", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventUsername, username, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = managementComponent.RevokeAccreditation(ctx, realmName, userID, "SHADOW", reason)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Reinstate: accreditation is not revoked", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(activeAccred), nil)
+		var err = managementComponent.ReinstateAccreditation(ctx, realmName, userID, "SHADOW", reason)
+		assert.NotNil(t, err)
+	})
+	t.Run("Reinstate: history can't be recorded", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(revokedAccred), nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).Return(nil)
+		mockAccreditationsModule.EXPECT().RecordAccreditations(ctx, gomock.Any(), operator, nil).Return(anyError)
+		var err = managementComponent.ReinstateAccreditation(ctx, realmName, userID, "SHADOW", reason)
+		assert.Equal(t, anyError, err)
+	})
+	t.Run("Reinstate: success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(createUser(revokedAccred), nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, realmName, userID, gomock.Any()).DoAndReturn(func(_, _, _ string, user kc.UserRepresentation) error {
+			assert.Equal(t, []string{activeAccred}, user.GetAttribute(constants.AttrbAccreditations))
+			return nil
+		})
+		mockAccreditationsModule.EXPECT().RecordAccreditations(ctx, gomock.Any(), operator, nil).DoAndReturn(func(_ context.Context, accreds []dto.DBAccreditation, _ string, _ *int64) error {
+			assert.Len(t, accreds, 1)
+			assert.Equal(t, "SHADOW", accreds[0].Type)
+			assert.Equal(t, future, accreds[0].ExpiryDate.Format("02.01.2006"))
+			return nil
+		})
+		mockUsersDetailsDBModule.EXPECT().GetUserDetails(ctx, realmName, userID).Return(dto.DBUser{}, nil)
+		mockArchiveDBModule.EXPECT().StoreUserDetails(ctx, realmName, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, user dto.ArchiveUserRepresentation) error {
+			assert.Len(t, user.Accreditations, 1)
+			assert.Nil(t, user.Accreditations[0].Revoked)
+			return nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "ACCREDITATION_REINSTATED", "back-office", database.CtEventRealmName, realmName,
+			database.CtEventUserID, userID, database.CtEventUsername, username, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = managementComponent.ReinstateAccreditation(ctx, realmName, userID, "SHADOW", reason)
 		assert.Nil(t, err)
 	})
 }
@@ -1397,12 +2577,13 @@ func TestGetUsers(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -1491,7 +2672,7 @@ func TestGetUserChecks(t *testing.T) {
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 
-	var managementComponent = NewComponent(nil, mockUsersDetailsDBModule, nil, nil, nil, mockLogger)
+	var managementComponent = NewComponent(nil, mockUsersDetailsDBModule, nil, nil, nil, nil, nil, nil, nil, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "aRealm"
@@ -1522,12 +2703,13 @@ func TestGetUserAccountStatus(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmReq = "master"
@@ -1543,16 +2725,45 @@ func TestGetUserAccountStatus(t *testing.T) {
 		assert.NotNil(t, err)
 	}
 
-	// GetUser returns a non-enabled user
+	// GetUser returns a non-enabled user but lock information can't be loaded
+	{
+		var userRep kc.UserRepresentation
+		enabled := false
+		userRep.Enabled = &enabled
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(userRep, nil).Times(1)
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		mockUsersDetailsDBModule.EXPECT().GetUserLock(ctx, realmName, userID).Return(nil, fmt.Errorf("SQL error")).Times(1)
+		_, err := managementComponent.GetUserAccountStatus(ctx, realmName, userID)
+		assert.NotNil(t, err)
+	}
+
+	// GetUser returns a non-enabled user without lock information
+	{
+		var userRep kc.UserRepresentation
+		enabled := false
+		userRep.Enabled = &enabled
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(userRep, nil).Times(1)
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		mockUsersDetailsDBModule.EXPECT().GetUserLock(ctx, realmName, userID).Return(nil, nil).Times(1)
+		status, err := managementComponent.GetUserAccountStatus(ctx, realmName, userID)
+		assert.Nil(t, err)
+		assert.False(t, status.Enabled)
+		assert.Nil(t, status.Lock)
+	}
+
+	// GetUser returns a locked user
 	{
 		var userRep kc.UserRepresentation
 		enabled := false
 		userRep.Enabled = &enabled
+		var lock = dto.DBUserLock{RealmID: realmName, UserID: userID, Reason: "FRAUD", LockedAt: time.Now()}
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(userRep, nil).Times(1)
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		mockUsersDetailsDBModule.EXPECT().GetUserLock(ctx, realmName, userID).Return(&lock, nil).Times(1)
 		status, err := managementComponent.GetUserAccountStatus(ctx, realmName, userID)
 		assert.Nil(t, err)
-		assert.False(t, status["enabled"])
+		assert.False(t, status.Enabled)
+		assert.Equal(t, "FRAUD", *status.Lock.Reason)
 	}
 
 	// GetUser returns an enabled user but GetCredentialsForUser fails
@@ -1579,7 +2790,7 @@ func TestGetUserAccountStatus(t *testing.T) {
 		ctx = context.WithValue(ctx, cs.CtContextRealm, realmReq)
 		status, err := managementComponent.GetUserAccountStatus(ctx, realmName, userID)
 		assert.Nil(t, err)
-		assert.False(t, status["enabled"])
+		assert.False(t, status.Enabled)
 	}
 
 	// GetUser returns an enabled user and GetCredentialsForUser have credentials
@@ -1594,7 +2805,7 @@ func TestGetUserAccountStatus(t *testing.T) {
 		ctx = context.WithValue(ctx, cs.CtContextRealm, realmReq)
 		status, err := managementComponent.GetUserAccountStatus(ctx, realmName, userID)
 		assert.Nil(t, err)
-		assert.True(t, status["enabled"])
+		assert.True(t, status.Enabled)
 	}
 }
 
@@ -1603,12 +2814,13 @@ func TestGetClientRolesForUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -1669,12 +2881,13 @@ func TestAddClientRolesToUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -1732,17 +2945,73 @@ func TestAddClientRolesToUser(t *testing.T) {
 	}
 }
 
+func TestUpdateUserRoleMappings(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var userID = "789-789-456"
+	var clientID = "456-789-147"
+	var roleID = "1234-7454-4516"
+	var roleName = "role_name"
+	var roles = []api.RoleRepresentation{{ID: &roleID, Name: &roleName}}
+	var kcRoles = []kc.RoleRepresentation{{ID: &roleID, Name: &roleName}}
+	var anyError = errors.New("any error")
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Delete client roles", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteClientRolesFromUserRoleMapping(accessToken, realmName, userID, clientID, kcRoles).Return(nil)
+		assert.Nil(t, managementComponent.DeleteClientRolesFromUser(ctx, realmName, userID, clientID, roles))
+	})
+
+	t.Run("Delete client roles fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteClientRolesFromUserRoleMapping(accessToken, realmName, userID, clientID, kcRoles).Return(anyError)
+		assert.Equal(t, anyError, managementComponent.DeleteClientRolesFromUser(ctx, realmName, userID, clientID, roles))
+	})
+
+	t.Run("Add realm roles", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().AddRealmLevelRoleMappings(accessToken, realmName, userID, kcRoles).Return(nil)
+		assert.Nil(t, managementComponent.AddRealmRolesToUser(ctx, realmName, userID, roles))
+	})
+
+	t.Run("Add realm roles fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().AddRealmLevelRoleMappings(accessToken, realmName, userID, kcRoles).Return(anyError)
+		assert.Equal(t, anyError, managementComponent.AddRealmRolesToUser(ctx, realmName, userID, roles))
+	})
+
+	t.Run("Delete realm roles", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteRealmLevelRoleMappings(accessToken, realmName, userID, kcRoles).Return(nil)
+		assert.Nil(t, managementComponent.DeleteRealmRolesFromUser(ctx, realmName, userID, roles))
+	})
+
+	t.Run("Delete realm roles fails", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteRealmLevelRoleMappings(accessToken, realmName, userID, kcRoles).Return(anyError)
+		assert.Equal(t, anyError, managementComponent.DeleteRealmRolesFromUser(ctx, realmName, userID, roles))
+	})
+}
+
 func TestGetRolesOfUser(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -1802,12 +3071,13 @@ func TestGetGroupsOfUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -1856,6 +3126,7 @@ func TestSetGroupsToUser(t *testing.T) {
 
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
@@ -1867,7 +3138,7 @@ func TestSetGroupsToUser(t *testing.T) {
 	var groupID = "user-group-1"
 	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	t.Run("AddGroupToUser: KC fails", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().AddGroupToUser(accessToken, realmName, userID, groupID).Return(errors.New("kc error"))
@@ -1897,6 +3168,7 @@ func TestGetAvailableTrustIDGroups(t *testing.T) {
 
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
@@ -1904,7 +3176,7 @@ func TestGetAvailableTrustIDGroups(t *testing.T) {
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 	var realmName = "master"
 
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var res, err = component.GetAvailableTrustIDGroups(context.TODO(), realmName)
 	assert.Nil(t, err)
@@ -1917,6 +3189,7 @@ func TestGetTrustIDGroupsOfUser(t *testing.T) {
 
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
@@ -1929,7 +3202,7 @@ func TestGetTrustIDGroupsOfUser(t *testing.T) {
 	var attrbs = keycloak.Attributes{constants.AttrbTrustIDGroups: groups}
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
 
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	t.Run("Keycloak fails", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, errors.New("kc error"))
@@ -1956,12 +3229,13 @@ func TestSetTrustIDGroupsToUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 
@@ -2042,12 +3316,14 @@ func TestResetPassword(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockBreachedPwdModule = mock.NewBreachedPasswordModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, mockBreachedPwdModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2063,6 +3339,8 @@ func TestResetPassword(t *testing.T) {
 			Value: &password,
 		}
 
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(gomock.Any(), realmName, password).Return(false, nil).Times(1)
 		mockKeycloakClient.EXPECT().ResetPassword(accessToken, realmName, userID, kcCredRep).Return(nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -2086,6 +3364,8 @@ func TestResetPassword(t *testing.T) {
 			Value: &password,
 		}
 
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(gomock.Any(), realmName, password).Return(false, nil).Times(1)
 		mockKeycloakClient.EXPECT().ResetPassword(accessToken, realmName, userID, kcCredRep).Return(nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -2105,6 +3385,49 @@ func TestResetPassword(t *testing.T) {
 		assert.Nil(t, err)
 	}
 
+	// Password does not respect the policy
+	{
+		var policy = "length(10) and digits(2) and notUsername(undefined)"
+		var weakPassword = "username"
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &policy}, nil).Times(1)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil).Times(1)
+
+		_, err := managementComponent.ResetPassword(ctx, "master", userID, api.PasswordRepresentation{Value: &weakPassword})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Equal(t, "keycloak-bridge.invalidParameter.password.length.10,keycloak-bridge.invalidParameter.password.digits.2,keycloak-bridge.invalidParameter.password.notUsername", err.(errorhandler.Error).Message)
+	}
+
+	// Password is breached
+	{
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realmName, password).Return(true, nil).Times(1)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "BREACHED_PASSWORD_REJECTED", "back-office", database.CtEventRealmName, realmName, database.CtEventUserID, userID).Return(nil).Times(1)
+
+		_, err := managementComponent.ResetPassword(ctx, "master", userID, api.PasswordRepresentation{Value: &password})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(errorhandler.Error).Status)
+		assert.Equal(t, "keycloak-bridge.invalidParameter.password.breached", err.(errorhandler.Error).Message)
+	}
+
+	// Can't check breached passwords
+	{
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realmName, password).Return(false, errors.New("error")).Times(1)
+
+		_, err := managementComponent.ResetPassword(ctx, "master", userID, api.PasswordRepresentation{Value: &password})
+
+		assert.NotNil(t, err)
+	}
+
 	// No password offered
 	{
 		var id = "master_id"
@@ -2124,7 +3447,8 @@ func TestResetPassword(t *testing.T) {
 		}
 
 		mockKeycloakClient.EXPECT().ResetPassword(accessToken, realmName, userID, gomock.Any()).Return(nil).Times(1)
-		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kcRealmRep, nil).AnyTimes()
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kcRealmRep, nil).Times(1)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{Username: &username}, nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
 		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
@@ -2139,7 +3463,11 @@ func TestResetPassword(t *testing.T) {
 		pwd, err := managementComponent.ResetPassword(ctx, "master", userID, passwordRep)
 
 		assert.Nil(t, err)
-		assert.NotNil(t, pwd)
+		assert.Len(t, pwd, 8)
+		assert.Regexp(t, "[?!#%$]", pwd)
+		assert.Regexp(t, "[A-Z]", pwd)
+		assert.Regexp(t, "[a-z]", pwd)
+		assert.Regexp(t, "[0-9]", pwd)
 	}
 
 	// No password offered, no keycloak policy
@@ -2151,7 +3479,7 @@ func TestResetPassword(t *testing.T) {
 		}
 
 		mockKeycloakClient.EXPECT().ResetPassword(accessToken, realmName, userID, gomock.Any()).Return(nil).Times(1)
-		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kcRealmRep, nil).AnyTimes()
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kcRealmRep, nil).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
 		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
@@ -2166,10 +3494,51 @@ func TestResetPassword(t *testing.T) {
 		pwd, err := managementComponent.ResetPassword(ctx, "master", userID, passwordRep)
 
 		assert.Nil(t, err)
-		assert.NotNil(t, pwd)
+		assert.Regexp(t, "^[A-Z][0-9]{6}[a-z]$", pwd)
+	}
+
+	// Can't get the realm
+	{
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, fmt.Errorf("Unexpected error")).Times(1)
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get realm from Keycloak", "err", "Unexpected error", "realm", realmName)
+
+		_, err := managementComponent.ResetPassword(ctx, "master", userID, api.PasswordRepresentation{})
+
+		assert.NotNil(t, err)
+	}
+
+	// No password offered, invalid password policy
+	{
+		var policy = "length(eight)"
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &policy}, nil).Times(1)
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't parse password policy", "err", gomock.Any(), "realm", realmName)
+
+		_, err := managementComponent.ResetPassword(ctx, "master", userID, api.PasswordRepresentation{})
+
+		assert.NotNil(t, err)
+	}
+
+	// No password offered, can't get the user
+	{
+		var policy = "length(8) and notEmail(undefined)"
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{PasswordPolicy: &policy}, nil).Times(1)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realmName, userID).Return(kc.UserRepresentation{}, fmt.Errorf("Unexpected error")).Times(1)
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get user from Keycloak", "err", "Unexpected error", "realm", realmName, "userID", userID)
+
+		_, err := managementComponent.ResetPassword(ctx, "master", userID, api.PasswordRepresentation{})
+
+		assert.NotNil(t, err)
 	}
 	// Error
 	{
+		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, nil).Times(1)
+		mockBreachedPwdModule.EXPECT().IsBreached(gomock.Any(), realmName, password).Return(false, nil).Times(1)
 		mockKeycloakClient.EXPECT().ResetPassword(accessToken, realmName, userID, gomock.Any()).Return(fmt.Errorf("Invalid input")).Times(1)
 
 		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
@@ -2190,12 +3559,13 @@ func TestRecoveryCode(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2265,12 +3635,13 @@ func TestExecuteActionsEmail(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2328,12 +3699,13 @@ func TestSendNewEnrolmentCode(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2386,12 +3758,13 @@ func TestSendReminderEmail(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2433,12 +3806,13 @@ func TestResetSmsCounter(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2518,12 +3892,13 @@ func TestGetCredentialsForUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 	var accessToken = "TOKEN=="
 	var realmReq = "master"
 	var realmName = "otherRealm"
@@ -2547,12 +3922,13 @@ func TestDeleteCredentialsForUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 	var accessToken = "TOKEN=="
 	var realmReq = "master"
 	var realmName = "master"
@@ -2675,7 +4051,7 @@ func TestUnlockCredentialForUser(t *testing.T) {
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 
-	var managementComponent = NewComponent(mockKeycloakClient, nil, mockEventDBModule, mockConfigurationDBModule, nil, log.NewNopLogger())
+	var managementComponent = NewComponent(mockKeycloakClient, nil, nil, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, nil, log.NewNopLogger())
 	var accessToken = "TOKEN=="
 	var realmName = "master"
 	var userID = "1245-7854-8963"
@@ -2724,6 +4100,7 @@ func TestClearUserLoginFailures(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var logger = log.NewNopLogger()
@@ -2733,7 +4110,7 @@ func TestClearUserLoginFailures(t *testing.T) {
 	var userID = "1245-7854-8963"
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
 
 	t.Run("Error occured", func(t *testing.T) {
 		var expectedError = errors.New("kc error")
@@ -2754,6 +4131,7 @@ func TestGetAttackDetectionStatus(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var logger = log.NewNopLogger()
@@ -2763,7 +4141,7 @@ func TestGetAttackDetectionStatus(t *testing.T) {
 	var userID = "1245-7854-8963"
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
 	var kcResult = map[string]interface{}{}
 
 	t.Run("Error occured", func(t *testing.T) {
@@ -2787,12 +4165,13 @@ func TestGetRoles(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2851,12 +4230,13 @@ func TestGetRole(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2912,12 +4292,13 @@ func TestGetGroups(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -2981,12 +4362,13 @@ func TestCreateGroup(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var username = "username"
@@ -3052,19 +4434,84 @@ func TestCreateGroup(t *testing.T) {
 	{
 		var kcGroupRep = kc.GroupRepresentation{}
 
-		mockKeycloakClient.EXPECT().CreateGroup(accessToken, targetRealmName, kcGroupRep).Return("", fmt.Errorf("Invalid input")).Times(1)
+		mockKeycloakClient.EXPECT().CreateGroup(accessToken, targetRealmName, kcGroupRep).Return("", fmt.Errorf("Invalid input")).Times(1)
+
+		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+
+		var groupRep = api.GroupRepresentation{}
+		mockLogger.EXPECT().Warn(ctx, "err", "Invalid input")
+
+		location, err := managementComponent.CreateGroup(ctx, targetRealmName, groupRep)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, "", location)
+	}
+}
+
+func TestUpdateGroup(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var groupName = "groupName"
+	var newGroupName = "newGroupName"
+	var targetRealmName = "DEP"
+	var anyError = errors.New("any error")
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	var group = api.GroupRepresentation{Name: &newGroupName}
+	var createGroupKc = func(name string) kc.GroupRepresentation {
+		return kc.GroupRepresentation{ID: &groupID, Name: &name}
+	}
+
+	t.Run("Can't get group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(kc.GroupRepresentation{}, anyError)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, group)
+		assert.Equal(t, anyError, err)
+	})
 
-		var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
-		ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+	t.Run("Name is unchanged", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(createGroupKc(newGroupName), nil)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, group)
+		assert.Nil(t, err)
+	})
 
-		var groupRep = api.GroupRepresentation{}
-		mockLogger.EXPECT().Warn(ctx, "err", "Invalid input")
+	t.Run("Can't update group in Keycloak", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(createGroupKc(groupName), nil)
+		mockKeycloakClient.EXPECT().UpdateGroup(accessToken, targetRealmName, groupID, createGroupKc(newGroupName)).Return(anyError)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, group)
+		assert.Equal(t, anyError, err)
+	})
 
-		location, err := managementComponent.CreateGroup(ctx, targetRealmName, groupRep)
+	t.Run("Can't rename group in database: name is restored in Keycloak", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(createGroupKc(groupName), nil)
+		mockKeycloakClient.EXPECT().UpdateGroup(accessToken, targetRealmName, groupID, createGroupKc(newGroupName)).Return(nil)
+		mockConfigurationDBModule.EXPECT().RenameGroup(ctx, targetRealmName, groupName, newGroupName).Return(anyError)
+		mockKeycloakClient.EXPECT().UpdateGroup(accessToken, targetRealmName, groupID, createGroupKc(groupName)).Return(nil)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, group)
+		assert.Equal(t, anyError, err)
+	})
 
-		assert.NotNil(t, err)
-		assert.Equal(t, "", location)
-	}
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, groupID).Return(createGroupKc(groupName), nil)
+		mockKeycloakClient.EXPECT().UpdateGroup(accessToken, targetRealmName, groupID, createGroupKc(newGroupName)).Return(nil)
+		mockConfigurationDBModule.EXPECT().RenameGroup(ctx, targetRealmName, groupName, newGroupName).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_GROUP_UPDATE", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventGroupID, groupID,
+			database.CtEventGroupName, newGroupName, database.CtEventAdditionalInfo, gomock.Any()).Return(nil)
+		var err = managementComponent.UpdateGroup(ctx, targetRealmName, groupID, group)
+		assert.Nil(t, err)
+	})
 }
 
 func TestDeleteGroup(t *testing.T) {
@@ -3072,12 +4519,13 @@ func TestDeleteGroup(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
@@ -3167,12 +4615,13 @@ func TestGetAuthorizations(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -3247,13 +4696,15 @@ func TestUpdateAuthorizations(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockTransaction = mock.NewTransaction(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var existingVersions = []dto.AuthorizationVersion{{Version: 1}}
 
 	var accessToken = "TOKEN=="
 	var currentRealmName = "master"
@@ -3348,9 +4799,12 @@ func TestUpdateAuthorizations(t *testing.T) {
 		mockKeycloakClient.EXPECT().AssignClientRole(accessToken, targetRealmName, groupID, ID, gomock.Any()).Return(nil).Times(1)
 
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, groupName).Return(existingVersions, nil)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, groupName).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Close().Times(1)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Commit().Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_UPDATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
@@ -3391,9 +4845,12 @@ func TestUpdateAuthorizations(t *testing.T) {
 		mockKeycloakClient.EXPECT().RemoveClientRole(accessToken, targetRealmName, groupID, ID, gomock.Any()).Return(nil).Times(1)
 
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, groupName).Return(existingVersions, nil)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, groupName).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Close().Times(1)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Commit().Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_UPDATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
@@ -3503,6 +4960,8 @@ func TestUpdateAuthorizations(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetGroupClientRoles(accessToken, targetRealmName, groupID, ID).Return(rolesCurrent, nil).Times(1)
 		mockKeycloakClient.EXPECT().AssignClientRole(accessToken, targetRealmName, groupID, ID, gomock.Any()).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, groupName).Return(existingVersions, nil)
 		mockTransaction.EXPECT().Close().Times(1)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, groupName).Return(fmt.Errorf("Unexpected error")).Times(1)
 		mockLogger.EXPECT().Warn(ctx, "err", "Unexpected error").Times(1)
@@ -3517,6 +4976,8 @@ func TestUpdateAuthorizations(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetGroupClientRoles(accessToken, targetRealmName, groupID, ID).Return(rolesCurrent, nil).Times(1)
 		mockKeycloakClient.EXPECT().AssignClientRole(accessToken, targetRealmName, groupID, ID, gomock.Any()).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, groupName).Return(existingVersions, nil)
 		mockTransaction.EXPECT().Close().Times(1)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, groupName).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(fmt.Errorf("Unexpected error")).Times(1)
@@ -3532,9 +4993,12 @@ func TestUpdateAuthorizations(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetGroupClientRoles(accessToken, targetRealmName, groupID, ID).Return(rolesCurrent, nil).Times(1)
 		mockKeycloakClient.EXPECT().AssignClientRole(accessToken, targetRealmName, groupID, ID, gomock.Any()).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, groupName).Return(existingVersions, nil)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, groupName).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Close().Times(1)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Commit().Return(fmt.Errorf("Unexpected error")).Times(1)
 		mockLogger.EXPECT().Warn(ctx, "err", "Unexpected error").Times(1)
 		err = managementComponent.UpdateAuthorizations(ctx, targetRealmName, groupID, apiAuthorizations)
@@ -3548,9 +5012,12 @@ func TestUpdateAuthorizations(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetGroupClientRoles(accessToken, targetRealmName, groupID, ID).Return(rolesCurrent, nil).Times(1)
 		mockKeycloakClient.EXPECT().AssignClientRole(accessToken, targetRealmName, groupID, ID, gomock.Any()).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, groupName).Return(existingVersions, nil)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, groupName).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Close().Times(1)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Commit().Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_UPDATE", "back-office", database.CtEventRealmName, targetRealmName, database.CtEventGroupName, groupName).Return(errors.New("error")).Times(1)
@@ -3629,13 +5096,15 @@ func TestUpdateAuthorizationsWithAny(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockTransaction = mock.NewTransaction(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var existingVersions = []dto.AuthorizationVersion{{Version: 1}}
 
 	var accessToken = "TOKEN=="
 	var currentRealmName = "master"
@@ -3710,9 +5179,12 @@ func TestUpdateAuthorizationsWithAny(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetClients(accessToken, targetMasterRealmName).Return(clients, nil).Times(1)
 
 		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetMasterRealmName, groupName).Return([]configuration.Authorization{}, nil).Times(1)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetMasterRealmName, groupName).Return(existingVersions, nil)
 		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetMasterRealmName, groupName).Return(nil).Times(1)
 		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Close().Times(1)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil).Times(1)
 		mockTransaction.EXPECT().Commit().Times(1)
 
 		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_UPDATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
@@ -3723,17 +5195,565 @@ func TestUpdateAuthorizationsWithAny(t *testing.T) {
 	}
 }
 
+func TestCopyAuthorizations(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockTransaction = mock.NewTransaction(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var existingVersions = []dto.AuthorizationVersion{{Version: 1}}
+
+	var accessToken = "TOKEN=="
+	var realmName = "TEMPLATE"
+	var targetRealmName = "DEP"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var targetGroupID = "8b5b2d25-4d6c-4a47-b3a4-1e4e45f5bd11"
+	var groupName = "admins"
+	var targetGroupName = "dep_admins"
+	var agents = "agents"
+	var depAgents = "dep_agents"
+	var getUser = "MGMT_GetUser"
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "admin")
+
+	var group = kc.GroupRepresentation{ID: &groupID, Name: &groupName}
+	var targetGroup = kc.GroupRepresentation{ID: &targetGroupID, Name: &targetGroupName}
+	var realms = []kc.RealmRepresentation{{ID: &targetRealmName}}
+	var targetGroups = []kc.GroupRepresentation{targetGroup, {Name: &depAgents}}
+	var sourceAuthorizations = []configuration.Authorization{
+		{RealmID: &realmName, GroupName: &groupName, Action: &getUser, TargetRealmID: &realmName, TargetGroupName: &agents},
+	}
+	var copyReq = api.AuthorizationsCopyRepresentation{
+		TargetRealm:   &targetRealmName,
+		TargetGroupID: &targetGroupID,
+		RealmMapping:  &map[string]string{realmName: targetRealmName},
+		GroupMapping:  &map[string]string{agents: depAgents},
+	}
+
+	t.Run("Can't get source group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(kc.GroupRepresentation{}, errors.New("kc error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "kc error")
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.NotNil(t, err)
+	})
+	t.Run("Can't get source authorizations", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(nil, errors.New("db error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "db error")
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.NotNil(t, err)
+	})
+	t.Run("Can't get target group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(sourceAuthorizations, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, targetGroupID).Return(kc.GroupRepresentation{}, errors.New("kc error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "kc error")
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.NotNil(t, err)
+	})
+	t.Run("Copied authorizations are not valid without mapping", func(t *testing.T) {
+		var noMapping = copyReq
+		noMapping.RealmMapping = nil
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(sourceAuthorizations, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, targetGroupID).Return(targetGroup, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(targetGroups, nil)
+		mockLogger.EXPECT().Warn(ctx, "err", gomock.Any())
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, noMapping)
+		assert.NotNil(t, err)
+	})
+	t.Run("Success", func(t *testing.T) {
+		var expected = configuration.Authorization{RealmID: &targetRealmName, GroupName: &targetGroupName, Action: &getUser, TargetRealmID: &targetRealmName, TargetGroupName: &depAgents}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(sourceAuthorizations, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, targetGroupID).Return(targetGroup, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(targetGroups, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, targetRealmName).Return([]kc.ClientRepresentation{}, nil)
+		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, targetGroupName).Return([]configuration.Authorization{}, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, targetGroupName).Return(existingVersions, nil)
+		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, targetGroupName).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, expected).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil)
+		mockTransaction.EXPECT().Commit()
+		mockTransaction.EXPECT().Close()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_COPY", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.Nil(t, err)
+	})
+	t.Run("Can't get versions of the target group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(sourceAuthorizations, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, targetGroupID).Return(targetGroup, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(targetGroups, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, targetRealmName).Return([]kc.ClientRepresentation{}, nil)
+		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, targetGroupName).Return([]configuration.Authorization{}, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, targetGroupName).Return(nil, errors.New("db error"))
+		mockTransaction.EXPECT().Close()
+		mockLogger.EXPECT().Warn(ctx, "err", "db error")
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.NotNil(t, err)
+	})
+	t.Run("Success-Authorizations set before the versioning are recorded as first version", func(t *testing.T) {
+		var previousAction = "MGMT_GetRealm"
+		var previousAuthorizations = []configuration.Authorization{
+			{RealmID: &targetRealmName, GroupName: &targetGroupName, Action: &previousAction, TargetRealmID: &targetRealmName},
+		}
+		var previousRules = dto.ToAuthorizationRules(previousAuthorizations)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(sourceAuthorizations, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, targetRealmName, targetGroupID).Return(targetGroup, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return(realms, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, targetRealmName).Return(targetGroups, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, targetRealmName).Return([]kc.ClientRepresentation{}, nil)
+		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, targetRealmName, targetGroupName).Return(previousAuthorizations, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, targetRealmName, targetGroupName).Return([]dto.AuthorizationVersion{}, nil)
+		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, targetRealmName, targetGroupName).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, gomock.Any()).Return(nil)
+		gomock.InOrder(
+			mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, version dto.AuthorizationVersion) error {
+				assert.Equal(t, previousRules, version.Authorizations)
+				assert.Equal(t, previousRules, version.Diff.Added)
+				return nil
+			}),
+			mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, version dto.AuthorizationVersion) error {
+				assert.Equal(t, previousRules, version.Diff.Removed)
+				return nil
+			}),
+		)
+		mockTransaction.EXPECT().Commit()
+		mockTransaction.EXPECT().Close()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_COPY", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = managementComponent.CopyAuthorizations(ctx, realmName, groupID, copyReq)
+		assert.Nil(t, err)
+	})
+}
+
+func TestAuthorizationTemplates(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockTransaction = mock.NewTransaction(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var existingVersions = []dto.AuthorizationVersion{{Version: 1}}
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var groupName = "dep_admins"
+	var templateName = "customer_admins"
+	var templateRealm = "TEMPLATE"
+	var star = "*"
+	var getUser = "MGMT_GetUser"
+	var dbError = errors.New("db error")
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "admin")
+
+	var template = []configuration.Authorization{
+		{Action: &getUser, TargetRealmID: &templateRealm, TargetGroupName: &star},
+	}
+
+	t.Run("Get templates fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAuthorizationTemplates(ctx).Return(nil, dbError)
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var _, err = managementComponent.GetAuthorizationTemplates(ctx)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Get templates", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAuthorizationTemplates(ctx).Return(map[string][]configuration.Authorization{
+			templateName: template,
+			"auditors":   template,
+		}, nil)
+
+		var res, err = managementComponent.GetAuthorizationTemplates(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, "auditors", *res[0].Name)
+		assert.Equal(t, templateName, *res[1].Name)
+		assert.Contains(t, (*res[1].Matrix)[getUser][templateRealm], star)
+	})
+
+	var matrix = map[string]map[string]map[string]struct{}{
+		getUser: {templateRealm: {star: {}}},
+	}
+	var auth = api.AuthorizationsRepresentation{Matrix: &matrix}
+
+	t.Run("Update template with empty matrix", func(t *testing.T) {
+		var err = managementComponent.UpdateAuthorizationTemplate(ctx, templateName, api.AuthorizationsRepresentation{})
+		assert.NotNil(t, err)
+	})
+	t.Run("Update template with unknown action", func(t *testing.T) {
+		var unknown = map[string]map[string]map[string]struct{}{"MGMT_Unknown": {}}
+		var err = managementComponent.UpdateAuthorizationTemplate(ctx, templateName, api.AuthorizationsRepresentation{Matrix: &unknown})
+		assert.NotNil(t, err)
+	})
+	t.Run("Update template fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().StoreAuthorizationTemplate(ctx, templateName, gomock.Any()).Return(dbError)
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var err = managementComponent.UpdateAuthorizationTemplate(ctx, templateName, auth)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Update template", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().StoreAuthorizationTemplate(ctx, templateName, gomock.Any()).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATION_TEMPLATE_UPDATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = managementComponent.UpdateAuthorizationTemplate(ctx, templateName, auth)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Delete template fails", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().DeleteAuthorizationTemplate(ctx, templateName).Return(dbError)
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var err = managementComponent.DeleteAuthorizationTemplate(ctx, templateName)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Delete template", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().DeleteAuthorizationTemplate(ctx, templateName).Return(nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATION_TEMPLATE_DELETE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = managementComponent.DeleteAuthorizationTemplate(ctx, templateName)
+		assert.Nil(t, err)
+	})
+
+	var application = api.AuthorizationTemplateApplicationRepresentation{
+		TemplateName: &templateName,
+		RealmMapping: &map[string]string{templateRealm: realmName},
+	}
+
+	t.Run("Apply template-Can't get template", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAuthorizationTemplate(ctx, templateName).Return(nil, dbError)
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var err = managementComponent.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Apply template-Unknown template", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAuthorizationTemplate(ctx, templateName).Return([]configuration.Authorization{}, nil)
+
+		var err = managementComponent.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+		assert.NotNil(t, err)
+	})
+	t.Run("Apply template-Can't get group", func(t *testing.T) {
+		mockConfigurationDBModule.EXPECT().GetAuthorizationTemplate(ctx, templateName).Return(template, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(kc.GroupRepresentation{}, errors.New("kc error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "kc error")
+
+		var err = managementComponent.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+		assert.NotNil(t, err)
+	})
+	t.Run("Apply template", func(t *testing.T) {
+		var expected = configuration.Authorization{RealmID: &realmName, GroupName: &groupName, Action: &getUser, TargetRealmID: &realmName, TargetGroupName: &star}
+		mockConfigurationDBModule.EXPECT().GetAuthorizationTemplate(ctx, templateName).Return(template, nil)
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(kc.GroupRepresentation{ID: &groupID, Name: &groupName}, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return([]kc.RealmRepresentation{{ID: &realmName}}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return([]kc.GroupRepresentation{{Name: &groupName}}, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return([]kc.ClientRepresentation{}, nil)
+		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return([]configuration.Authorization{}, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, realmName, groupName).Return(existingVersions, nil)
+		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, realmName, groupName).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, expected).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).Return(nil)
+		mockTransaction.EXPECT().Commit()
+		mockTransaction.EXPECT().Close()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_TEMPLATE_APPLY", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = managementComponent.ApplyAuthorizationTemplate(ctx, realmName, groupID, application)
+		assert.Nil(t, err)
+	})
+}
+
+func TestAuthorizationVersions(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockTransaction = mock.NewTransaction(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, []string{}, mockLogger)
+	var existingVersions = []dto.AuthorizationVersion{{Version: 1}}
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var groupName = "dep_admins"
+	var star = "*"
+	var group = kc.GroupRepresentation{ID: &groupID, Name: &groupName}
+	var kcError = errors.New("kc error")
+	var dbError = errors.New("db error")
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, "master")
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "admin")
+
+	var getUser = dto.AuthorizationRule{Action: "MGMT_GetUser", TargetRealmID: &realmName, TargetGroupName: &star}
+	var getRealm = dto.AuthorizationRule{Action: "MGMT_GetRealm", TargetRealmID: &realmName}
+	var version1 = dto.AuthorizationVersion{RealmID: realmName, GroupName: groupName, Version: 1, AuthorRealm: "master", Author: "admin",
+		Date: time.Now(), Authorizations: []dto.AuthorizationRule{getUser}, Diff: dto.DiffAuthorizationRules(nil, []dto.AuthorizationRule{getUser})}
+	var version2 = dto.AuthorizationVersion{RealmID: realmName, GroupName: groupName, Version: 2, AuthorRealm: "master", Author: "admin",
+		Date: time.Now(), Authorizations: []dto.AuthorizationRule{getRealm}, Diff: dto.DiffAuthorizationRules(version1.Authorizations, []dto.AuthorizationRule{getRealm})}
+
+	t.Run("Get versions-Can't get group", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(kc.GroupRepresentation{}, kcError)
+		mockLogger.EXPECT().Warn(ctx, "err", kcError.Error())
+
+		var _, err = managementComponent.GetAuthorizationVersions(ctx, realmName, groupID)
+		assert.Equal(t, kcError, err)
+	})
+	t.Run("Get versions-DB error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, realmName, groupName).Return(nil, dbError)
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var _, err = managementComponent.GetAuthorizationVersions(ctx, realmName, groupID)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Get versions-Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, realmName, groupName).Return([]dto.AuthorizationVersion{version2, version1}, nil)
+
+		var res, err = managementComponent.GetAuthorizationVersions(ctx, realmName, groupID)
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, 2, res[0].Version)
+		assert.Equal(t, 1, res[1].Version)
+	})
+
+	t.Run("Get diff-Unknown version", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 1).Return(&version1, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 7).Return(nil, nil)
+
+		var _, err = managementComponent.GetAuthorizationsDiff(ctx, realmName, groupID, 1, 7)
+		assert.NotNil(t, err)
+	})
+	t.Run("Get diff-Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 1).Return(&version1, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 2).Return(&version2, nil)
+
+		var res, err = managementComponent.GetAuthorizationsDiff(ctx, realmName, groupID, 1, 2)
+		assert.Nil(t, err)
+		assert.Len(t, res.Added, 1)
+		assert.Equal(t, "MGMT_GetRealm", res.Added[0].Action)
+		assert.Len(t, res.Removed, 1)
+		assert.Equal(t, "MGMT_GetUser", res.Removed[0].Action)
+	})
+
+	t.Run("Rollback-DB error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 1).Return(nil, dbError)
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var err = managementComponent.RollbackAuthorizations(ctx, realmName, groupID, 1)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Rollback-Unknown version", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 9).Return(nil, nil)
+
+		var err = managementComponent.RollbackAuthorizations(ctx, realmName, groupID, 9)
+		assert.NotNil(t, err)
+	})
+	t.Run("Rollback-Can't get current authorizations", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 1).Return(&version1, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return([]kc.RealmRepresentation{{ID: &realmName}}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return([]kc.GroupRepresentation{group}, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return([]kc.ClientRepresentation{}, nil)
+		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(nil, dbError)
+		mockTransaction.EXPECT().Close()
+		mockLogger.EXPECT().Warn(ctx, "err", dbError.Error())
+
+		var err = managementComponent.RollbackAuthorizations(ctx, realmName, groupID, 1)
+		assert.Equal(t, dbError, err)
+	})
+	t.Run("Rollback-Success", func(t *testing.T) {
+		var expected = configuration.Authorization{RealmID: &realmName, GroupName: &groupName, Action: &getUser.Action, TargetRealmID: &realmName, TargetGroupName: &star}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersion(ctx, realmName, groupName, 1).Return(&version1, nil)
+		mockKeycloakClient.EXPECT().GetRealms(accessToken).Return([]kc.RealmRepresentation{{ID: &realmName}}, nil)
+		mockKeycloakClient.EXPECT().GetGroups(accessToken, realmName).Return([]kc.GroupRepresentation{group}, nil)
+		mockKeycloakClient.EXPECT().GetClients(accessToken, realmName).Return([]kc.ClientRepresentation{}, nil)
+		mockConfigurationDBModule.EXPECT().NewTransaction(ctx).Return(mockTransaction, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName).Return(dto.ToAuthorizations(realmName, groupName, version2.Authorizations), nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizationVersions(ctx, realmName, groupName).Return(existingVersions, nil)
+		mockConfigurationDBModule.EXPECT().DeleteAuthorizations(ctx, realmName, groupName).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorization(ctx, expected).Return(nil)
+		mockConfigurationDBModule.EXPECT().CreateAuthorizationVersion(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, version dto.AuthorizationVersion) error {
+			assert.Equal(t, "admin", version.Author)
+			assert.Equal(t, version1.Authorizations, version.Authorizations)
+			assert.Equal(t, version1.Authorizations, version.Diff.Added)
+			assert.Equal(t, version2.Authorizations, version.Diff.Removed)
+			return nil
+		})
+		mockTransaction.EXPECT().Commit()
+		mockTransaction.EXPECT().Close()
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_AUTHORIZATIONS_ROLLBACK", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = managementComponent.RollbackAuthorizations(ctx, realmName, groupID, 1)
+		assert.Nil(t, err)
+	})
+}
+
+func TestExplainAuthorization(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "DEP"
+	var groupID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var userID = "1245-7854-8963"
+	var groupName1 = "groupName1"
+	var groupName2 = "groupName2"
+	var targetGroup = "targetGroup"
+	var getUser = MGMTGetUser.Name
+	var unknownAction = "MGMT_Unknown"
+	var star = "*"
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	var group1 = kc.GroupRepresentation{ID: &groupID, Name: &groupName1}
+	var group2 = kc.GroupRepresentation{Name: &groupName2}
+	var authorizations = []configuration.Authorization{
+		{RealmID: &realmName, GroupName: &groupName1, Action: &getUser, TargetRealmID: &realmName, TargetGroupName: &star},
+	}
+
+	t.Run("Unknown action", func(t *testing.T) {
+		var check = api.AuthorizationCheckRepresentation{GroupID: &groupID, Action: &unknownAction}
+		_, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Group is allowed", func(t *testing.T) {
+		var check = api.AuthorizationCheckRepresentation{GroupID: &groupID, Action: &getUser, TargetGroup: &targetGroup}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group1, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName1).Return(authorizations, nil)
+
+		res, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.Nil(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, realmName, res.TargetRealm)
+		assert.Equal(t, &targetGroup, res.TargetGroup)
+		assert.Len(t, res.Groups, 1)
+		assert.Equal(t, []string{"targetGroup"}, res.Groups[0].MatchedRules[0].Wildcards)
+	})
+
+	t.Run("User is allowed through one of its groups", func(t *testing.T) {
+		var check = api.AuthorizationCheckRepresentation{UserID: &userID, Action: &getUser}
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return([]kc.GroupRepresentation{group1, group2}, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName1).Return(authorizations, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName2).Return([]configuration.Authorization{}, nil)
+
+		res, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.Nil(t, err)
+		assert.True(t, res.Allowed)
+		assert.Len(t, res.Groups, 2)
+		assert.True(t, res.Groups[0].Allowed)
+		assert.False(t, res.Groups[1].Allowed)
+		assert.Len(t, res.Groups[1].MissingRules, 1)
+	})
+
+	t.Run("Denied on another target realm", func(t *testing.T) {
+		var otherRealm = "other"
+		var check = api.AuthorizationCheckRepresentation{GroupID: &groupID, Action: &getUser, TargetRealm: &otherRealm}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group1, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName1).Return(authorizations, nil)
+
+		res, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.Nil(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, otherRealm, res.TargetRealm)
+	})
+
+	t.Run("GetGroup fails", func(t *testing.T) {
+		var check = api.AuthorizationCheckRepresentation{GroupID: &groupID, Action: &getUser}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(kc.GroupRepresentation{}, errors.New("kc error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "kc error")
+
+		_, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("GetGroupsOfUser fails", func(t *testing.T) {
+		var check = api.AuthorizationCheckRepresentation{UserID: &userID, Action: &getUser}
+		mockKeycloakClient.EXPECT().GetGroupsOfUser(accessToken, realmName, userID).Return(nil, errors.New("kc error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "kc error")
+
+		_, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("GetAuthorizations fails", func(t *testing.T) {
+		var check = api.AuthorizationCheckRepresentation{GroupID: &groupID, Action: &getUser}
+		mockKeycloakClient.EXPECT().GetGroup(accessToken, realmName, groupID).Return(group1, nil)
+		mockConfigurationDBModule.EXPECT().GetAuthorizations(ctx, realmName, groupName1).Return(nil, errors.New("db error"))
+		mockLogger.EXPECT().Warn(ctx, "err", "db error")
+
+		_, err := managementComponent.ExplainAuthorization(ctx, realmName, check)
+		assert.NotNil(t, err)
+	})
+}
+
 func TestGetClientRoles(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -3788,17 +5808,84 @@ func TestGetClientRoles(t *testing.T) {
 	}
 }
 
+func TestCreateRole(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var name = "role_name"
+	var description = "description role"
+	var role = api.RoleRepresentation{Name: &name, Description: &description}
+	var kcRole = kc.RoleRepresentation{Name: &name, Description: &description}
+	var locationURL = "http://location.url"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateRole(accessToken, realmName, kcRole).Return(locationURL, nil)
+		var location, err = managementComponent.CreateRole(ctx, realmName, role)
+		assert.Nil(t, err)
+		assert.Equal(t, locationURL, location)
+	})
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().CreateRole(accessToken, realmName, kcRole).Return("", errors.New("any error"))
+		var _, err = managementComponent.CreateRole(ctx, realmName, role)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestDeleteRole(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockLogger = log.NewNopLogger()
+	var allowedTrustIDGroups = []string{"grp1", "grp2"}
+
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var roleID = "1234-7454-4516"
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+
+	t.Run("Success", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().DeleteRole(accessToken, realmName, roleID).Return(nil)
+		assert.Nil(t, managementComponent.DeleteRole(ctx, realmName, roleID))
+	})
+
+	t.Run("Keycloak error", func(t *testing.T) {
+		var anyError = errors.New("any error")
+		mockKeycloakClient.EXPECT().DeleteRole(accessToken, realmName, roleID).Return(anyError)
+		assert.Equal(t, anyError, managementComponent.DeleteRole(ctx, realmName, roleID))
+	})
+}
+
 func TestCreateClientRole(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmName = "master"
@@ -3860,12 +5947,13 @@ func TestGetRealmCustomConfiguration(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmID = "master_id"
@@ -3991,12 +6079,13 @@ func TestUpdateRealmCustomConfiguration(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var realmID = "master_id"
@@ -4132,6 +6221,7 @@ func TestGetRealmAdminConfiguration(t *testing.T) {
 
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var logger = log.NewNopLogger()
@@ -4141,11 +6231,11 @@ func TestGetRealmAdminConfiguration(t *testing.T) {
 	var realmID = "1234-5678"
 	var accessToken = "acce-ssto-ken"
 	var expectedError = errors.New("expectedError")
-	var dbAdminConfig configuration.RealmAdminConfiguration
+	var dbAdminConfig dto.RealmAdminConfiguration
 	var apiAdminConfig = api.ConvertRealmAdminConfigurationFromDBStruct(dbAdminConfig)
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
 
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
 
 	t.Run("Request to Keycloak client fails", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, expectedError)
@@ -4174,6 +6264,7 @@ func TestUpdateRealmAdminConfiguration(t *testing.T) {
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var logger = log.NewNopLogger()
 
@@ -4185,7 +6276,7 @@ func TestUpdateRealmAdminConfiguration(t *testing.T) {
 	var ctx = context.WithValue(context.TODO(), cs.CtContextAccessToken, accessToken)
 	var adminConfig api.RealmAdminConfiguration
 
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, logger)
 
 	t.Run("Request to Keycloak client fails", func(t *testing.T) {
 		mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{}, expectedError)
@@ -4218,11 +6309,12 @@ func TestRealmBackOfficeConfiguration(t *testing.T) {
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = log.NewNopLogger()
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var component = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var realmID = "master_id"
 	var groupName = "the.group"
@@ -4318,12 +6410,13 @@ func TestLinkShadowUser(t *testing.T) {
 	defer mockCtrl.Finish()
 	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
 	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockArchiveDBModule = mock.NewArchiveDBModule(mockCtrl)
 	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
 	var mockConfigurationDBModule = mock.NewConfigurationDBModule(mockCtrl)
 	var mockLogger = mock.NewLogger(mockCtrl)
 	var allowedTrustIDGroups = []string{"grp1", "grp2"}
 
-	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
+	var managementComponent = NewComponent(mockKeycloakClient, mockUsersDetailsDBModule, mockArchiveDBModule, nil, nil, nil, mockEventDBModule, mockConfigurationDBModule, allowedTrustIDGroups, mockLogger)
 
 	var accessToken = "TOKEN=="
 	var username = "test"
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 
 	cs "github.com/cloudtrust/common-service"
@@ -21,15 +23,29 @@ type Endpoints struct {
 	GetRealm           endpoint.Endpoint
 	GetClient          endpoint.Endpoint
 	GetClients         endpoint.Endpoint
+	CreateClient       endpoint.Endpoint
+	UpdateClient       endpoint.Endpoint
+	DisableClient      endpoint.Endpoint
+	UpdateRedirectURIs endpoint.Endpoint
+	RotateClientSecret endpoint.Endpoint
 	GetRequiredActions endpoint.Endpoint
 
 	DeleteUser                endpoint.Endpoint
+	RestoreUser               endpoint.Endpoint
 	GetUser                   endpoint.Endpoint
 	UpdateUser                endpoint.Endpoint
 	LockUser                  endpoint.Endpoint
 	UnlockUser                endpoint.Endpoint
+	GetUserAccreditations     endpoint.Endpoint
+	GetAccreditationsHistory  endpoint.Endpoint
+	RevokeAccreditation       endpoint.Endpoint
+	ReinstateAccreditation    endpoint.Endpoint
 	GetUsers                  endpoint.Endpoint
 	CreateUser                endpoint.Endpoint
+	ImportUsers               endpoint.Endpoint
+	ExportUsers               endpoint.Endpoint
+	LookupUsers               endpoint.Endpoint
+	GetDuplicateUsers         endpoint.Endpoint
 	GetRolesOfUser            endpoint.Endpoint
 	GetGroupsOfUser           endpoint.Endpoint
 	AddGroupToUser            endpoint.Endpoint
@@ -41,6 +57,9 @@ type Endpoints struct {
 	GetUserAccountStatus      endpoint.Endpoint
 	GetClientRoleForUser      endpoint.Endpoint
 	AddClientRoleToUser       endpoint.Endpoint
+	DeleteClientRoleFromUser  endpoint.Endpoint
+	AddRealmRoleToUser        endpoint.Endpoint
+	DeleteRealmRoleFromUser   endpoint.Endpoint
 
 	ResetPassword                  endpoint.Endpoint
 	ExecuteActionsEmail            endpoint.Endpoint
@@ -56,15 +75,29 @@ type Endpoints struct {
 
 	GetRoles         endpoint.Endpoint
 	GetRole          endpoint.Endpoint
+	CreateRole       endpoint.Endpoint
+	DeleteRole       endpoint.Endpoint
 	GetClientRoles   endpoint.Endpoint
 	CreateClientRole endpoint.Endpoint
 
 	GetGroups            endpoint.Endpoint
 	CreateGroup          endpoint.Endpoint
+	UpdateGroup          endpoint.Endpoint
 	DeleteGroup          endpoint.Endpoint
 	GetAuthorizations    endpoint.Endpoint
 	UpdateAuthorizations endpoint.Endpoint
-	GetActions           endpoint.Endpoint
+	ExplainAuthorization endpoint.Endpoint
+	CopyAuthorizations   endpoint.Endpoint
+
+	GetAuthorizationTemplates   endpoint.Endpoint
+	UpdateAuthorizationTemplate endpoint.Endpoint
+	DeleteAuthorizationTemplate endpoint.Endpoint
+	ApplyAuthorizationTemplate  endpoint.Endpoint
+	GetAuthorizationVersions    endpoint.Endpoint
+	GetAuthorizationsDiff       endpoint.Endpoint
+	RollbackAuthorizations      endpoint.Endpoint
+
+	GetActions endpoint.Endpoint
 
 	GetRealmCustomConfiguration         endpoint.Endpoint
 	UpdateRealmCustomConfiguration      endpoint.Endpoint
@@ -75,6 +108,11 @@ type Endpoints struct {
 	GetUserRealmBackOfficeConfiguration endpoint.Endpoint
 
 	LinkShadowUser endpoint.Endpoint
+
+	GetPendingRequests    endpoint.Endpoint
+	GetPendingRequest     endpoint.Endpoint
+	ApprovePendingRequest endpoint.Endpoint
+	RejectPendingRequest  endpoint.Endpoint
 }
 
 // MakeGetRealmsEndpoint makes the Realms endpoint to retrieve all available realms.
@@ -111,6 +149,106 @@ func MakeGetClientsEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeCreateClientEndpoint creates an endpoint for CreateClient
+func MakeCreateClientEndpoint(component Component, logger keycloakb.Logger) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var client api.ClientRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &client); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if client.ClientID == nil {
+			return nil, errorhandler.CreateMissingParameterError(msg.ClientID)
+		}
+
+		if err = client.Validate(); err != nil {
+			return nil, err
+		}
+
+		var keycloakLocation string
+		keycloakLocation, err = component.CreateClient(ctx, m[prmRealm], client)
+
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := convertLocationURL(keycloakLocation, m[reqScheme], m[reqHost])
+		if err != nil {
+			logger.Warn(ctx, "msg", "Invalid location", "location", keycloakLocation, "err", err.Error())
+		}
+
+		return LocationHeader{
+			URL: url,
+		}, nil
+	}
+}
+
+// MakeUpdateClientEndpoint creates an endpoint for UpdateClient
+func MakeUpdateClientEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var client api.ClientRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &client); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = client.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateClient(ctx, m[prmRealm], m[prmClientID], client)
+	}
+}
+
+// MakeDisableClientEndpoint creates an endpoint for DisableClient
+func MakeDisableClientEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DisableClient(ctx, m[prmRealm], m[prmClientID])
+	}
+}
+
+// MakeUpdateClientRedirectURIsEndpoint creates an endpoint for UpdateClientRedirectURIs
+func MakeUpdateClientRedirectURIsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var redirectURIs []string
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &redirectURIs); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if redirectURIs == nil {
+			redirectURIs = []string{}
+		}
+
+		if err = api.ValidateRedirectURIs(redirectURIs); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateClientRedirectURIs(ctx, m[prmRealm], m[prmClientID], redirectURIs)
+	}
+}
+
+// MakeRotateClientSecretEndpoint creates an endpoint for RotateClientSecret
+func MakeRotateClientSecretEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.RotateClientSecret(ctx, m[prmRealm], m[prmClientID])
+	}
+}
+
 // MakeGetRequiredActionsEndpoint creates an endpoint for GetRequiredActions
 func MakeGetRequiredActionsEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -158,12 +296,95 @@ func MakeCreateUserEndpoint(component Component, logger keycloakb.Logger) cs.End
 	}
 }
 
+// MakeImportUsersEndpoint creates an endpoint for ImportUsers
+func MakeImportUsersEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var format = api.FormatCSV
+		if value, ok := m[prmQryFormat]; ok && value != "" {
+			format = value
+		}
+
+		users, err := api.ParseUsersImport(format, m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return component.ImportUsers(ctx, m[prmRealm], users, m[prmQryDryRun] == "true")
+	}
+}
+
+// MakeExportUsersEndpoint creates an endpoint for ExportUsers
+func MakeExportUsersEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var format = api.FormatCSV
+		if value, ok := m[prmQryFormat]; ok && value != "" {
+			format = value
+		}
+
+		export, err := component.ExportUsers(ctx, m[prmRealm], format)
+		if err != nil {
+			return nil, err
+		}
+
+		var reply = StreamReply{
+			ContentType: "application/x-ndjson",
+			Filename:    m[prmRealm] + "-users." + format,
+			Write:       export,
+		}
+		if format == api.FormatCSV {
+			reply.ContentType = "text/csv; charset=utf-8"
+		}
+		return reply, nil
+	}
+}
+
+// MakeLookupUsersEndpoint creates an endpoint for LookupUsers
+func MakeLookupUsersEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var lookup api.UserLookupRepresentation
+		if err = json.Unmarshal([]byte(m[reqBody]), &lookup); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = lookup.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.LookupUsers(ctx, m[prmRealm], lookup)
+	}
+}
+
+// MakeGetDuplicateUsersEndpoint creates an endpoint for GetDuplicateUsers
+func MakeGetDuplicateUsersEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetDuplicateUsers(ctx, m[prmRealm])
+	}
+}
+
 // MakeDeleteUserEndpoint creates an endpoint for DeleteUser
 func MakeDeleteUserEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		var m = req.(map[string]string)
 
-		return nil, component.DeleteUser(ctx, m[prmRealm], m[prmUserID])
+		return acceptPendingApproval(component.DeleteUser(ctx, m[prmRealm], m[prmUserID]))
+	}
+}
+
+// MakeRestoreUserEndpoint creates an endpoint for RestoreUser
+func MakeRestoreUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.RestoreUser(ctx, m[prmRealm], m[prmUserID])
 	}
 }
 
@@ -187,9 +408,15 @@ func MakeUpdateUserEndpoint(component Component) cs.Endpoint {
 			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + msg.Body)
 		}
 
+		// With a JSON merge patch, fields set to null are removed
+		if keycloakb.IsMergePatch(m) {
+			user.ClearedFields, _ = keycloakb.GetNullFields(m[reqBody])
+		}
+
 		if err := user.Validate(); err != nil {
 			return nil, err
 		}
+		user.ETag = keycloakb.GetIfMatch(m)
 
 		return nil, component.UpdateUser(ctx, m[prmRealm], m[prmUserID], user)
 	}
@@ -200,7 +427,18 @@ func MakeLockUserEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		var m = req.(map[string]string)
 
-		return nil, component.LockUser(ctx, m[prmRealm], m[prmUserID])
+		// Lock details are optional
+		var lock api.UserLockRepresentation
+		if body := m[reqBody]; body != "" {
+			if err := json.Unmarshal([]byte(body), &lock); err != nil {
+				return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+			}
+			if err := lock.Validate(); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, component.LockUser(ctx, m[prmRealm], m[prmUserID], lock)
 	}
 }
 
@@ -213,6 +451,60 @@ func MakeUnlockUserEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeGetUserAccreditationsEndpoint creates an endpoint for GetUserAccreditations
+func MakeGetUserAccreditationsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetUserAccreditations(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeGetUserAccreditationsHistoryEndpoint creates an endpoint for GetUserAccreditationsHistory
+func MakeGetUserAccreditationsHistoryEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetUserAccreditationsHistory(ctx, m[prmRealm], m[prmUserID])
+	}
+}
+
+// MakeRevokeAccreditationEndpoint creates an endpoint for RevokeAccreditation
+func MakeRevokeAccreditationEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var reason, err = decodeAccreditationReason(m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.RevokeAccreditation(ctx, m[prmRealm], m[prmUserID], m[prmAccredType], reason)
+	}
+}
+
+// MakeReinstateAccreditationEndpoint creates an endpoint for ReinstateAccreditation
+func MakeReinstateAccreditationEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var reason, err = decodeAccreditationReason(m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.ReinstateAccreditation(ctx, m[prmRealm], m[prmUserID], m[prmAccredType], reason)
+	}
+}
+
+func decodeAccreditationReason(body string) (api.AccreditationReasonRepresentation, error) {
+	var reason api.AccreditationReasonRepresentation
+	if err := json.Unmarshal([]byte(body), &reason); err != nil {
+		return reason, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+	}
+	return reason, reason.Validate()
+}
+
 // MakeGetUsersEndpoint creates an endpoint for GetUsers
 func MakeGetUsersEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -354,6 +646,64 @@ func MakeAddClientRolesToUserEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeDeleteClientRolesFromUserEndpoint creates an endpoint for DeleteClientRolesFromUser
+func MakeDeleteClientRolesFromUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		roles, err := decodeRoles(m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.DeleteClientRolesFromUser(ctx, m[prmRealm], m[prmUserID], m[prmClientID], roles)
+	}
+}
+
+// MakeAddRealmRolesToUserEndpoint creates an endpoint for AddRealmRolesToUser
+func MakeAddRealmRolesToUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		roles, err := decodeRoles(m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.AddRealmRolesToUser(ctx, m[prmRealm], m[prmUserID], roles)
+	}
+}
+
+// MakeDeleteRealmRolesFromUserEndpoint creates an endpoint for DeleteRealmRolesFromUser
+func MakeDeleteRealmRolesFromUserEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		roles, err := decodeRoles(m[reqBody])
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, component.DeleteRealmRolesFromUser(ctx, m[prmRealm], m[prmUserID], roles)
+	}
+}
+
+func decodeRoles(body string) ([]api.RoleRepresentation, error) {
+	var roles []api.RoleRepresentation
+
+	if err := json.Unmarshal([]byte(body), &roles); err != nil {
+		return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+	}
+
+	for _, role := range roles {
+		if err := role.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return roles, nil
+}
+
 // MakeResetPasswordEndpoint creates an endpoint for ResetPassword
 func MakeResetPasswordEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -374,7 +724,7 @@ func MakeResetPasswordEndpoint(component Component) cs.Endpoint {
 		if pwd != "" {
 			return pwd, err
 		}
-		return nil, err
+		return acceptPendingApproval(err)
 	}
 }
 
@@ -466,7 +816,7 @@ func MakeDeleteCredentialsForUserEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
 		var m = req.(map[string]string)
 
-		return nil, component.DeleteCredentialsForUser(ctx, m[prmRealm], m[prmUserID], m[prmCredentialID])
+		return acceptPendingApproval(component.DeleteCredentialsForUser(ctx, m[prmRealm], m[prmUserID], m[prmCredentialID]))
 	}
 }
 
@@ -515,6 +865,53 @@ func MakeGetRoleEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeCreateRoleEndpoint creates an endpoint for CreateRole
+func MakeCreateRoleEndpoint(component Component, logger keycloakb.Logger) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var role api.RoleRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &role); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if role.Name == nil {
+			return nil, errorhandler.CreateMissingParameterError(msg.Name)
+		}
+
+		if err = role.Validate(); err != nil {
+			return nil, err
+		}
+
+		var keycloakLocation string
+		keycloakLocation, err = component.CreateRole(ctx, m[prmRealm], role)
+
+		if err != nil {
+			return nil, err
+		}
+
+		url, err := convertLocationURL(keycloakLocation, m[reqScheme], m[reqHost])
+		if err != nil {
+			logger.Warn(ctx, "msg", "Invalid location", "location", keycloakLocation, "err", err.Error())
+		}
+
+		return LocationHeader{
+			URL: url,
+		}, nil
+	}
+}
+
+// MakeDeleteRoleEndpoint creates an endpoint for DeleteRole
+func MakeDeleteRoleEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteRole(ctx, m[prmRealm], m[prmRoleID])
+	}
+}
+
 // MakeGetClientRolesEndpoint creates an endpoint for GetClientRoles
 func MakeGetClientRolesEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -601,6 +998,30 @@ func MakeCreateGroupEndpoint(component Component, logger keycloakb.Logger) cs.En
 	}
 }
 
+// MakeUpdateGroupEndpoint creates an endpoint for UpdateGroup
+func MakeUpdateGroupEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var group api.GroupRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &group); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if group.Name == nil {
+			return nil, errorhandler.CreateMissingParameterError(msg.Name)
+		}
+
+		if err = group.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.UpdateGroup(ctx, m[prmRealm], m[prmGroupID], group)
+	}
+}
+
 // MakeDeleteGroupEndpoint creates an endpoint for DeleteGroup
 func MakeDeleteGroupEndpoint(component Component) cs.Endpoint {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
@@ -631,7 +1052,140 @@ func MakeUpdateAuthorizationsEndpoint(component Component) cs.Endpoint {
 			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
 		}
 
-		return nil, component.UpdateAuthorizations(ctx, m[prmRealm], m[prmGroupID], authorizations)
+		return acceptPendingApproval(component.UpdateAuthorizations(ctx, m[prmRealm], m[prmGroupID], authorizations))
+	}
+}
+
+// MakeExplainAuthorizationEndpoint creates an endpoint for ExplainAuthorization
+func MakeExplainAuthorizationEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var check api.AuthorizationCheckRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &check); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = check.Validate(); err != nil {
+			return nil, err
+		}
+
+		return component.ExplainAuthorization(ctx, m[prmRealm], check)
+	}
+}
+
+// MakeCopyAuthorizationsEndpoint creates an endpoint for CopyAuthorizations
+func MakeCopyAuthorizationsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var copyReq api.AuthorizationsCopyRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &copyReq); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = copyReq.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.CopyAuthorizations(ctx, m[prmRealm], m[prmGroupID], copyReq)
+	}
+}
+
+// MakeGetAuthorizationTemplatesEndpoint creates an endpoint for GetAuthorizationTemplates
+func MakeGetAuthorizationTemplatesEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return component.GetAuthorizationTemplates(ctx)
+	}
+}
+
+// MakeUpdateAuthorizationTemplateEndpoint creates an endpoint for UpdateAuthorizationTemplate
+func MakeUpdateAuthorizationTemplateEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var authorizations api.AuthorizationsRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &authorizations); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		return nil, component.UpdateAuthorizationTemplate(ctx, m[prmTemplateName], authorizations)
+	}
+}
+
+// MakeDeleteAuthorizationTemplateEndpoint creates an endpoint for DeleteAuthorizationTemplate
+func MakeDeleteAuthorizationTemplateEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return nil, component.DeleteAuthorizationTemplate(ctx, m[prmTemplateName])
+	}
+}
+
+// MakeApplyAuthorizationTemplateEndpoint creates an endpoint for ApplyAuthorizationTemplate
+func MakeApplyAuthorizationTemplateEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+		var err error
+
+		var application api.AuthorizationTemplateApplicationRepresentation
+
+		if err = json.Unmarshal([]byte(m[reqBody]), &application); err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Body)
+		}
+
+		if err = application.Validate(); err != nil {
+			return nil, err
+		}
+
+		return nil, component.ApplyAuthorizationTemplate(ctx, m[prmRealm], m[prmGroupID], application)
+	}
+}
+
+// MakeGetAuthorizationVersionsEndpoint creates an endpoint for GetAuthorizationVersions
+func MakeGetAuthorizationVersionsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetAuthorizationVersions(ctx, m[prmRealm], m[prmGroupID])
+	}
+}
+
+// MakeGetAuthorizationsDiffEndpoint creates an endpoint for GetAuthorizationsDiff
+func MakeGetAuthorizationsDiffEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		fromVersion, err := strconv.Atoi(m[prmQryFromVersion])
+		if err != nil {
+			return nil, errorhandler.CreateMissingParameterError(msg.FromVersion)
+		}
+		toVersion, err := strconv.Atoi(m[prmQryToVersion])
+		if err != nil {
+			return nil, errorhandler.CreateMissingParameterError(msg.ToVersion)
+		}
+
+		return component.GetAuthorizationsDiff(ctx, m[prmRealm], m[prmGroupID], fromVersion, toVersion)
+	}
+}
+
+// MakeRollbackAuthorizationsEndpoint creates an endpoint for RollbackAuthorizations
+func MakeRollbackAuthorizationsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		version, err := strconv.Atoi(m[prmVersion])
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.Version)
+		}
+
+		return nil, component.RollbackAuthorizations(ctx, m[prmRealm], m[prmGroupID], version)
 	}
 }
 
@@ -699,7 +1253,7 @@ func MakeUpdateRealmAdminConfigurationEndpoint(component Component) cs.Endpoint
 			return nil, err
 		}
 
-		return nil, component.UpdateRealmAdminConfiguration(ctx, m[prmRealm], adminConfig)
+		return acceptPendingApproval(component.UpdateRealmAdminConfiguration(ctx, m[prmRealm], adminConfig))
 	}
 }
 
@@ -768,6 +1322,72 @@ func MakeLinkShadowUserEndpoint(component Component) cs.Endpoint {
 	}
 }
 
+// MakeGetPendingRequestsEndpoint creates an endpoint for GetPendingRequests
+func MakeGetPendingRequestsEndpoint(component PendingRequestsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		return component.GetPendingRequests(ctx, m[prmRealm])
+	}
+}
+
+// MakeGetPendingRequestEndpoint creates an endpoint for GetPendingRequest
+func MakeGetPendingRequestEndpoint(component PendingRequestsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		requestID, err := strconv.ParseInt(m[prmRequestID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.PendingRequestID)
+		}
+
+		return component.GetPendingRequest(ctx, m[prmRealm], requestID)
+	}
+}
+
+// MakeApprovePendingRequestEndpoint creates an endpoint for ApprovePendingRequest
+func MakeApprovePendingRequestEndpoint(component PendingRequestsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		requestID, err := strconv.ParseInt(m[prmRequestID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.PendingRequestID)
+		}
+
+		return component.ApprovePendingRequest(ctx, m[prmRealm], requestID)
+	}
+}
+
+// MakeRejectPendingRequestEndpoint creates an endpoint for RejectPendingRequest
+func MakeRejectPendingRequestEndpoint(component PendingRequestsComponent) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		requestID, err := strconv.ParseInt(m[prmRequestID], 10, 64)
+		if err != nil {
+			return nil, errorhandler.CreateBadRequestError(msg.MsgErrInvalidParam + "." + msg.PendingRequestID)
+		}
+
+		return nil, component.RejectPendingRequest(ctx, m[prmRealm], requestID)
+	}
+}
+
+// acceptPendingApproval replies the pending request instead of an error when an operation has to be approved by a second operator
+func acceptPendingApproval(err error) (interface{}, error) {
+	if pending, ok := err.(PendingApproval); ok {
+		return pending, nil
+	}
+	return nil, err
+}
+
+// StreamReply is a reply whose content is written in the response body as a stream
+type StreamReply struct {
+	ContentType string
+	Filename    string
+	Write       func(w io.Writer) error
+}
+
 // LocationHeader type
 type LocationHeader struct {
 	URL string
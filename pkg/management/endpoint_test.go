@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/cloudtrust/common-service/log"
@@ -106,6 +107,129 @@ func TestGetClientsEndpoint(t *testing.T) {
 	assert.NotNil(t, res)
 }
 
+func TestCreateClientEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeCreateClientEndpoint(mockManagementComponent, log.NewNopLogger())
+	var ctx = context.Background()
+	var location = "https://location.url/auth/admin/realms/master/clients/123456"
+	var realm = "master"
+	var clientID = "client-id"
+	var client = api.ClientRepresentation{ClientID: &clientID}
+	var clientJSON, _ = json.Marshal(client)
+
+	t.Run("No error", func(t *testing.T) {
+		var req = map[string]string{reqScheme: "https", reqHost: "elca.ch", prmRealm: realm, reqBody: string(clientJSON)}
+
+		mockManagementComponent.EXPECT().CreateClient(ctx, realm, client).Return(location, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/realms/master/clients/123456", res.(LocationHeader).URL)
+	})
+
+	t.Run("Cannot unmarshal", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: "JSON"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing clientId", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: "{}"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid client", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: `{"clientId":"client-id","protocol":"saml"}`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Keycloak client error", func(t *testing.T) {
+		var req = map[string]string{reqScheme: "https", reqHost: "elca.ch", prmRealm: realm, reqBody: string(clientJSON)}
+
+		mockManagementComponent.EXPECT().CreateClient(ctx, realm, client).Return("", errors.New("any error"))
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestUpdateClientEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var ctx = context.Background()
+	var realm = "master"
+	var idClient = "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"
+	var name = "client name"
+
+	t.Run("UpdateClient", func(t *testing.T) {
+		var e = MakeUpdateClientEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm, prmClientID: idClient, reqBody: `{"name":"client name"}`}
+
+		mockManagementComponent.EXPECT().UpdateClient(ctx, realm, idClient, api.ClientRepresentation{Name: &name}).Return(nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("UpdateClient - invalid body", func(t *testing.T) {
+		var e = MakeUpdateClientEndpoint(mockManagementComponent)
+		var _, err = e(ctx, map[string]string{reqBody: `{"webOrigins":["not an origin"]}`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DisableClient", func(t *testing.T) {
+		var e = MakeDisableClientEndpoint(mockManagementComponent)
+
+		mockManagementComponent.EXPECT().DisableClient(ctx, realm, idClient).Return(nil)
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmClientID: idClient})
+		assert.Nil(t, err)
+	})
+
+	t.Run("UpdateClientRedirectURIs", func(t *testing.T) {
+		var e = MakeUpdateClientRedirectURIsEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm, prmClientID: idClient, reqBody: `["https://app.example.com/*"]`}
+
+		mockManagementComponent.EXPECT().UpdateClientRedirectURIs(ctx, realm, idClient, []string{"https://app.example.com/*"}).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("UpdateClientRedirectURIs - empty list", func(t *testing.T) {
+		var e = MakeUpdateClientRedirectURIsEndpoint(mockManagementComponent)
+		var req = map[string]string{prmRealm: realm, prmClientID: idClient, reqBody: `null`}
+
+		mockManagementComponent.EXPECT().UpdateClientRedirectURIs(ctx, realm, idClient, []string{}).Return(nil)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("UpdateClientRedirectURIs - invalid URI", func(t *testing.T) {
+		var e = MakeUpdateClientRedirectURIsEndpoint(mockManagementComponent)
+		var _, err = e(ctx, map[string]string{reqBody: `["no uri"]`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("UpdateClientRedirectURIs - invalid body", func(t *testing.T) {
+		var e = MakeUpdateClientRedirectURIsEndpoint(mockManagementComponent)
+		var _, err = e(ctx, map[string]string{reqBody: `{}`})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("RotateClientSecret", func(t *testing.T) {
+		var e = MakeRotateClientSecretEndpoint(mockManagementComponent)
+		var secret = "s3cr3t"
+
+		mockManagementComponent.EXPECT().RotateClientSecret(ctx, realm, idClient).Return(api.ClientSecretRepresentation{Value: &secret}, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmClientID: idClient})
+		assert.Nil(t, err)
+		assert.Equal(t, secret, *res.(api.ClientSecretRepresentation).Value)
+	})
+}
+
 func TestGetRequiredActionsEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -179,6 +303,152 @@ func TestCreateUserEndpoint(t *testing.T) {
 	}
 }
 
+func TestImportUsersEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeImportUsersEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var ctx = context.Background()
+	var username = "toto"
+	var groups = []string{"f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"}
+	var expectedUsers = []api.UserImportEntry{{Line: 2, User: api.UserRepresentation{Username: &username, Groups: &groups}}}
+	var report = api.UsersImportReport{DryRun: true}
+
+	t.Run("CSV is the default format", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmQryDryRun: "true"}
+		req[reqBody] = "username,groups\n" + username + "," + groups[0] + "\n"
+
+		mockManagementComponent.EXPECT().ImportUsers(ctx, realm, expectedUsers, true).Return(report, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, report, res)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmQryFormat: api.FormatNDJSON}
+		req[reqBody] = "\n" + `{"username":"toto","groups":["f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee"]}`
+
+		mockManagementComponent.EXPECT().ImportUsers(ctx, realm, expectedUsers, false).Return(report, nil).Times(1)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Invalid CSV header", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, reqBody: "unknown,groups\nvalue,value"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Import fails", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, reqBody: "username,groups\n" + username + "," + groups[0]}
+
+		mockManagementComponent.EXPECT().ImportUsers(ctx, realm, expectedUsers, false).Return(api.UsersImportReport{}, fmt.Errorf("Unexpected error")).Times(1)
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestExportUsersEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeExportUsersEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var ctx = context.Background()
+	var export UsersExport = func(w io.Writer) error {
+		return nil
+	}
+
+	t.Run("CSV is the default format", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm}
+
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realm, "csv").Return(export, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "text/csv; charset=utf-8", res.(StreamReply).ContentType)
+		assert.Equal(t, "master-users.csv", res.(StreamReply).Filename)
+		assert.NotNil(t, res.(StreamReply).Write)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmQryFormat: "ndjson"}
+
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realm, "ndjson").Return(export, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "application/x-ndjson", res.(StreamReply).ContentType)
+	})
+
+	t.Run("Export fails", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm}
+
+		mockManagementComponent.EXPECT().ExportUsers(ctx, realm, "csv").Return(nil, fmt.Errorf("Unexpected error")).Times(1)
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestLookupUsersEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeLookupUsersEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var docNumber = "X123456"
+	var ctx = context.Background()
+
+	t.Run("Invalid body", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, reqBody: "{"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid lookup", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, reqBody: `{"firstName":"John"}`}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, reqBody: `{"idDocumentNumber":"X123456"}`}
+
+		mockManagementComponent.EXPECT().LookupUsers(ctx, realm, api.UserLookupRepresentation{IDDocumentNumber: &docNumber}).Return([]string{"user-id"}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"user-id"}, res)
+	})
+}
+
+func TestGetDuplicateUsersEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeGetDuplicateUsersEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var criteria = "identity"
+	var duplicates = []api.DuplicateUsersRepresentation{{Criteria: &criteria, UserIDs: []string{"user-1", "user-2"}}}
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm}
+
+	mockManagementComponent.EXPECT().GetDuplicateUsers(ctx, realm).Return(duplicates, nil).Times(1)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, duplicates, res)
+}
+
 func TestDeleteUserEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -198,6 +468,37 @@ func TestDeleteUserEndpoint(t *testing.T) {
 	var res, err = e(ctx, req)
 	assert.Nil(t, err)
 	assert.Nil(t, res)
+
+	// Operation waiting for approval
+	mockManagementComponent.EXPECT().DeleteUser(ctx, realm, userID).Return(PendingApproval{RequestID: 7}).Times(1)
+	res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Equal(t, PendingApproval{RequestID: 7}, res)
+}
+
+func TestRestoreUserEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeRestoreUserEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var userID = "1234-452-4578"
+	var ctx = context.Background()
+	var req = make(map[string]string)
+	req[prmRealm] = realm
+	req[prmUserID] = userID
+
+	mockManagementComponent.EXPECT().RestoreUser(ctx, realm, userID).Return(nil).Times(1)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+
+	mockManagementComponent.EXPECT().RestoreUser(ctx, realm, userID).Return(errors.New("error")).Times(1)
+	_, err = e(ctx, req)
+	assert.NotNil(t, err)
 }
 
 func TestGetUserEndpoint(t *testing.T) {
@@ -280,16 +581,33 @@ func TestLockUserEndpoints(t *testing.T) {
 		var e = MakeLockUserEndpoint(mockManagementComponent)
 
 		t.Run("No error", func(t *testing.T) {
-			mockManagementComponent.EXPECT().LockUser(ctx, realm, userID).Return(nil)
+			mockManagementComponent.EXPECT().LockUser(ctx, realm, userID, api.UserLockRepresentation{}).Return(nil)
 			var res, err = e(ctx, req)
 			assert.Nil(t, err)
 			assert.Nil(t, res)
 		})
 		t.Run("Error occured", func(t *testing.T) {
-			mockManagementComponent.EXPECT().LockUser(ctx, realm, userID).Return(anyError)
+			mockManagementComponent.EXPECT().LockUser(ctx, realm, userID, api.UserLockRepresentation{}).Return(anyError)
 			var _, err = e(ctx, req)
 			assert.Equal(t, anyError, err)
 		})
+		t.Run("Invalid body", func(t *testing.T) {
+			var reqWithBody = map[string]string{prmRealm: realm, prmUserID: userID, reqBody: "{"}
+			var _, err = e(ctx, reqWithBody)
+			assert.NotNil(t, err)
+		})
+		t.Run("Invalid lock", func(t *testing.T) {
+			var reqWithBody = map[string]string{prmRealm: realm, prmUserID: userID, reqBody: `{"comment":"no reason"}`}
+			var _, err = e(ctx, reqWithBody)
+			assert.NotNil(t, err)
+		})
+		t.Run("Lock with reason", func(t *testing.T) {
+			var reason = "FRAUD_SUSPICION"
+			var reqWithBody = map[string]string{prmRealm: realm, prmUserID: userID, reqBody: `{"reason":"FRAUD_SUSPICION"}`}
+			mockManagementComponent.EXPECT().LockUser(ctx, realm, userID, api.UserLockRepresentation{Reason: &reason}).Return(nil)
+			var _, err = e(ctx, reqWithBody)
+			assert.Nil(t, err)
+		})
 	})
 
 	t.Run("UnlockUser", func(t *testing.T) {
@@ -309,6 +627,73 @@ func TestLockUserEndpoints(t *testing.T) {
 	})
 }
 
+func TestAccreditationsEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var realm = "master"
+	var userID = "123-456-789"
+	var accredType = "SHADOW"
+	var reasonValue = "wrong identification"
+	var reason = api.AccreditationReasonRepresentation{Reason: &reasonValue}
+	var ctx = context.Background()
+	var anyError = errors.New("any")
+	var req = map[string]string{prmRealm: realm, prmUserID: userID, prmAccredType: accredType, reqBody: `{"reason":"wrong identification"}`}
+
+	t.Run("GetUserAccreditations", func(t *testing.T) {
+		var e = MakeGetUserAccreditationsEndpoint(mockManagementComponent)
+		var accreds = []api.AccreditationRepresentation{{Type: &accredType}}
+		mockManagementComponent.EXPECT().GetUserAccreditations(ctx, realm, userID).Return(accreds, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, accreds, res)
+	})
+
+	t.Run("GetUserAccreditationsHistory", func(t *testing.T) {
+		var e = MakeGetUserAccreditationsHistoryEndpoint(mockManagementComponent)
+		var history = []api.AccreditationHistoryRepresentation{{Type: &accredType, RevocationReason: &reasonValue}}
+		mockManagementComponent.EXPECT().GetUserAccreditationsHistory(ctx, realm, userID).Return(history, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, history, res)
+	})
+
+	t.Run("RevokeAccreditation", func(t *testing.T) {
+		var e = MakeRevokeAccreditationEndpoint(mockManagementComponent)
+
+		t.Run("Invalid body", func(t *testing.T) {
+			var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmAccredType: accredType, reqBody: "{"})
+			assert.NotNil(t, err)
+		})
+		t.Run("Missing reason", func(t *testing.T) {
+			var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmAccredType: accredType, reqBody: "{}"})
+			assert.NotNil(t, err)
+		})
+		t.Run("Error occured", func(t *testing.T) {
+			mockManagementComponent.EXPECT().RevokeAccreditation(ctx, realm, userID, accredType, reason).Return(anyError)
+			var _, err = e(ctx, req)
+			assert.Equal(t, anyError, err)
+		})
+	})
+
+	t.Run("ReinstateAccreditation", func(t *testing.T) {
+		var e = MakeReinstateAccreditationEndpoint(mockManagementComponent)
+
+		t.Run("Missing reason", func(t *testing.T) {
+			var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, prmAccredType: accredType, reqBody: "{}"})
+			assert.NotNil(t, err)
+		})
+		t.Run("No error", func(t *testing.T) {
+			mockManagementComponent.EXPECT().ReinstateAccreditation(ctx, realm, userID, accredType, reason).Return(nil)
+			var res, err = e(ctx, req)
+			assert.Nil(t, err)
+			assert.Nil(t, res)
+		})
+	})
+}
+
 func TestGetUsersEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -405,11 +790,9 @@ func TestGetUserAccountStatusEndpoint(t *testing.T) {
 		var req = make(map[string]string)
 		req[prmRealm] = realm
 		req[prmUserID] = userID
-		var m map[string]bool
-		m = make(map[string]bool)
-		m["enabled"] = false
+		var status = api.UserAccountStatusRepresentation{Enabled: false}
 
-		mockManagementComponent.EXPECT().GetUserAccountStatus(ctx, realm, userID).Return(m, nil).Times(1)
+		mockManagementComponent.EXPECT().GetUserAccountStatus(ctx, realm, userID).Return(status, nil).Times(1)
 		var _, err = e(ctx, req)
 		assert.Nil(t, err)
 	}
@@ -657,40 +1040,98 @@ func TestAddClientRolesToUserEndpoint(t *testing.T) {
 	}
 }
 
-func TestResetPasswordEndpoint(t *testing.T) {
+func TestUpdateUserRolesEndpoints(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
 
-	var e = MakeResetPasswordEndpoint(mockManagementComponent)
+	var realm = "master"
+	var userID = "123-123-456"
+	var clientID = "456-789-741"
+	var ctx = context.Background()
+	var roleName = "role_name"
+	var roles = []api.RoleRepresentation{{Name: &roleName}}
+	var rolesJSON, _ = json.Marshal(roles)
+	var req = map[string]string{prmRealm: realm, prmUserID: userID, prmClientID: clientID, reqBody: string(rolesJSON)}
+	var invalidRoleName = "invalid role name !"
+	var invalidRolesJSON, _ = json.Marshal([]api.RoleRepresentation{{Name: &invalidRoleName}})
+	var anyError = errors.New("any error")
+
+	t.Run("DeleteClientRolesFromUser", func(t *testing.T) {
+		var e = MakeDeleteClientRolesFromUserEndpoint(mockManagementComponent)
+
+		mockManagementComponent.EXPECT().DeleteClientRolesFromUser(ctx, realm, userID, clientID, roles).Return(anyError)
+		var res, err = e(ctx, req)
+		assert.Equal(t, anyError, err)
+		assert.Nil(t, res)
+	})
 
-	// No error
-	{
-		var realm = "master"
-		var userID = "123-123-456"
-		var ctx = context.Background()
-		var req = make(map[string]string)
-		req[prmRealm] = realm
-		req[prmUserID] = userID
-		passwordJSON, _ := json.Marshal(api.PasswordRepresentation{})
-		req[reqBody] = string(passwordJSON)
+	t.Run("AddRealmRolesToUser", func(t *testing.T) {
+		var e = MakeAddRealmRolesToUserEndpoint(mockManagementComponent)
 
-		mockManagementComponent.EXPECT().ResetPassword(ctx, realm, userID, api.PasswordRepresentation{}).Return("", nil).Times(1)
+		mockManagementComponent.EXPECT().AddRealmRolesToUser(ctx, realm, userID, roles).Return(nil)
 		var res, err = e(ctx, req)
 		assert.Nil(t, err)
 		assert.Nil(t, res)
-	}
+	})
 
-	// Error - Unmarshalling error
-	{
-		var realm = "master"
-		var userID = "123-123-456"
-		var ctx = context.Background()
-		var req = make(map[string]string)
-		req[prmRealm] = realm
-		req[prmUserID] = userID
-		req[reqBody] = string("passwordJSON")
+	t.Run("DeleteRealmRolesFromUser", func(t *testing.T) {
+		var e = MakeDeleteRealmRolesFromUserEndpoint(mockManagementComponent)
+
+		mockManagementComponent.EXPECT().DeleteRealmRolesFromUser(ctx, realm, userID, roles).Return(nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Invalid body", func(t *testing.T) {
+		var e = MakeDeleteRealmRolesFromUserEndpoint(mockManagementComponent)
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, reqBody: "roleJSON"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		var e = MakeAddRealmRolesToUserEndpoint(mockManagementComponent)
+		var _, err = e(ctx, map[string]string{prmRealm: realm, prmUserID: userID, reqBody: string(invalidRolesJSON)})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestResetPasswordEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeResetPasswordEndpoint(mockManagementComponent)
+
+	// No error
+	{
+		var realm = "master"
+		var userID = "123-123-456"
+		var ctx = context.Background()
+		var req = make(map[string]string)
+		req[prmRealm] = realm
+		req[prmUserID] = userID
+		passwordJSON, _ := json.Marshal(api.PasswordRepresentation{})
+		req[reqBody] = string(passwordJSON)
+
+		mockManagementComponent.EXPECT().ResetPassword(ctx, realm, userID, api.PasswordRepresentation{}).Return("", nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	}
+
+	// Error - Unmarshalling error
+	{
+		var realm = "master"
+		var userID = "123-123-456"
+		var ctx = context.Background()
+		var req = make(map[string]string)
+		req[prmRealm] = realm
+		req[prmUserID] = userID
+		req[reqBody] = string("passwordJSON")
 
 		var res, err = e(ctx, req)
 		assert.NotNil(t, err)
@@ -1027,6 +1468,67 @@ func TestGetRoleEndpoint(t *testing.T) {
 	}
 }
 
+func TestCreateRoleEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeCreateRoleEndpoint(mockManagementComponent, log.NewNopLogger())
+	var ctx = context.Background()
+	var location = "https://location.url/auth/admin/master/roles/name"
+	var realm = "master"
+	var name = "name"
+	var role = api.RoleRepresentation{Name: &name}
+	var roleJSON, _ = json.Marshal(role)
+
+	t.Run("No error", func(t *testing.T) {
+		var req = map[string]string{reqScheme: "https", reqHost: "elca.ch", prmRealm: realm, reqBody: string(roleJSON)}
+
+		mockManagementComponent.EXPECT().CreateRole(ctx, realm, role).Return(location, nil)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Equal(t, "https://elca.ch/management/master/roles/name", res.(LocationHeader).URL)
+	})
+
+	t.Run("Cannot unmarshal", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: "JSON"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		var _, err = e(ctx, map[string]string{reqBody: "{}"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Keycloak client error", func(t *testing.T) {
+		var req = map[string]string{reqScheme: "https", reqHost: "elca.ch", prmRealm: realm, reqBody: string(roleJSON)}
+
+		mockManagementComponent.EXPECT().CreateRole(ctx, realm, role).Return("", errors.New("any error"))
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestDeleteRoleEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeDeleteRoleEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var roleID = "123456"
+	var ctx = context.Background()
+	var req = map[string]string{prmRealm: realm, prmRoleID: roleID}
+
+	mockManagementComponent.EXPECT().DeleteRole(ctx, realm, roleID).Return(nil)
+	var res, err = e(ctx, req)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
+
 func TestGetGroupsEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1104,6 +1606,40 @@ func TestCreateGroupEndpoint(t *testing.T) {
 	}
 }
 
+func TestUpdateGroupEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeUpdateGroupEndpoint(mockManagementComponent)
+
+	var realm = "master"
+	var groupID = "1234-452-4578"
+	var groupName = "new-name"
+	var ctx = context.Background()
+
+	t.Run("Invalid body", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, reqBody: "{"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, reqBody: "{}"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, reqBody: `{"name":"new-name"}`}
+		mockManagementComponent.EXPECT().UpdateGroup(ctx, realm, groupID, api.GroupRepresentation{Name: &groupName}).Return(nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	})
+}
+
 func TestDeleteGroupEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1216,6 +1752,307 @@ func TestUpdateAuthorizationsEndpoint(t *testing.T) {
 	}
 }
 
+func TestExplainAuthorizationEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeExplainAuthorizationEndpoint(mockManagementComponent)
+	var realmName = "master"
+	var ctx = context.Background()
+
+	// No error
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[reqBody] = `{"groupId":"f467ed7c-0a1d-4eee-9bb8-669c6f89c007", "action":"MGMT_GetUser"}`
+
+		mockManagementComponent.EXPECT().ExplainAuthorization(ctx, realmName, gomock.Any()).Return(api.AuthorizationExplanationRepresentation{Allowed: true}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.True(t, res.(api.AuthorizationExplanationRepresentation).Allowed)
+	}
+
+	// JSON error
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[reqBody] = `{"groupId":"f467ed7c-0a1d-4eee-9bb8-669c6f89c007"`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+
+	// Invalid check
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[reqBody] = `{"action":"MGMT_GetUser"}`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCopyAuthorizationsEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeCopyAuthorizationsEndpoint(mockManagementComponent)
+	var realmName = "master"
+	var groupID = "123456"
+	var ctx = context.Background()
+
+	// No error
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[prmGroupID] = groupID
+		req[reqBody] = `{"targetRealm":"DEP", "targetGroupId":"f467ed7c-0a1d-4eee-9bb8-669c6f89c007", "realmMapping":{"master":"DEP"}}`
+
+		mockManagementComponent.EXPECT().CopyAuthorizations(ctx, realmName, groupID, gomock.Any()).Return(nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	}
+
+	// JSON error
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[prmGroupID] = groupID
+		req[reqBody] = `{"targetGroupId":"f467ed7c-0a1d-4eee-9bb8-669c6f89c007"`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+
+	// Missing target group
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[prmGroupID] = groupID
+		req[reqBody] = `{"targetRealm":"DEP"}`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestGetAuthorizationTemplatesEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeGetAuthorizationTemplatesEndpoint(mockManagementComponent)
+
+	// No error
+	{
+		var ctx = context.Background()
+		var req = make(map[string]string)
+
+		mockManagementComponent.EXPECT().GetAuthorizationTemplates(ctx).Return([]api.AuthorizationTemplateRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	}
+}
+
+func TestUpdateAuthorizationTemplateEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeUpdateAuthorizationTemplateEndpoint(mockManagementComponent)
+	var templateName = "customer_admins"
+	var ctx = context.Background()
+
+	// No error
+	{
+		var req = make(map[string]string)
+		req[prmTemplateName] = templateName
+		req[reqBody] = `{"matrix":{"MGMT_GetUser":{"DEP":{"*":{}}}}}`
+
+		mockManagementComponent.EXPECT().UpdateAuthorizationTemplate(ctx, templateName, gomock.Any()).Return(nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	}
+
+	// JSON error
+	{
+		var req = make(map[string]string)
+		req[prmTemplateName] = templateName
+		req[reqBody] = `{"matrix":{}`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestDeleteAuthorizationTemplateEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeDeleteAuthorizationTemplateEndpoint(mockManagementComponent)
+
+	// No error
+	{
+		var templateName = "customer_admins"
+		var ctx = context.Background()
+		var req = make(map[string]string)
+		req[prmTemplateName] = templateName
+
+		mockManagementComponent.EXPECT().DeleteAuthorizationTemplate(ctx, templateName).Return(nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	}
+}
+
+func TestApplyAuthorizationTemplateEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeApplyAuthorizationTemplateEndpoint(mockManagementComponent)
+	var realmName = "DEP"
+	var groupID = "123456"
+	var ctx = context.Background()
+
+	// No error
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[prmGroupID] = groupID
+		req[reqBody] = `{"templateName":"customer_admins", "realmMapping":{"TEMPLATE":"DEP"}}`
+
+		mockManagementComponent.EXPECT().ApplyAuthorizationTemplate(ctx, realmName, groupID, gomock.Any()).Return(nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	}
+
+	// JSON error
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[prmGroupID] = groupID
+		req[reqBody] = `{"templateName":"customer_admins"`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+
+	// Missing template name
+	{
+		var req = make(map[string]string)
+		req[prmRealm] = realmName
+		req[prmGroupID] = groupID
+		req[reqBody] = `{}`
+
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestGetAuthorizationVersionsEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeGetAuthorizationVersionsEndpoint(mockManagementComponent)
+
+	// No error
+	{
+		var realm = "master"
+		var groupID = "123456"
+		var ctx = context.Background()
+		var req = make(map[string]string)
+		req[prmRealm] = realm
+		req[prmGroupID] = groupID
+
+		mockManagementComponent.EXPECT().GetAuthorizationVersions(ctx, realm, groupID).Return([]api.AuthorizationVersionRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	}
+}
+
+func TestGetAuthorizationsDiffEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeGetAuthorizationsDiffEndpoint(mockManagementComponent)
+	var realm = "master"
+	var groupID = "123456"
+	var ctx = context.Background()
+
+	// No error
+	{
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, prmQryFromVersion: "1", prmQryToVersion: "4"}
+
+		mockManagementComponent.EXPECT().GetAuthorizationsDiff(ctx, realm, groupID, 1, 4).Return(api.AuthorizationsDiffRepresentation{}, nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.NotNil(t, res)
+	}
+
+	// Missing from
+	{
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, prmQryToVersion: "4"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+
+	// Missing to
+	{
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, prmQryFromVersion: "1"}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestRollbackAuthorizationsEndpoint(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockManagementComponent = mock.NewManagementComponent(mockCtrl)
+
+	var e = MakeRollbackAuthorizationsEndpoint(mockManagementComponent)
+	var realm = "master"
+	var groupID = "123456"
+	var ctx = context.Background()
+
+	// No error
+	{
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, prmVersion: "3"}
+
+		mockManagementComponent.EXPECT().RollbackAuthorizations(ctx, realm, groupID, 3).Return(nil).Times(1)
+		var res, err = e(ctx, req)
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+	}
+
+	// Invalid version
+	{
+		var req = map[string]string{prmRealm: realm, prmGroupID: groupID, prmVersion: ""}
+		var _, err = e(ctx, req)
+		assert.NotNil(t, err)
+	}
+}
+
 func TestCreateClientRoleEndpoint(t *testing.T) {
 	var mockCtrl = gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -1476,3 +2313,55 @@ func TestConvertLocationUrl(t *testing.T) {
 	assert.Equal(t, ConvertLocationError{Location: "http://localhost:8080/toto"}, err)
 
 }
+
+func TestPendingRequestsEndpoints(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var mockPendingRequests = mock.NewPendingRequestsComponent(mockCtrl)
+
+	var realm = "master"
+	var ctx = context.Background()
+	var request = api.PendingRequestRepresentation{ID: 12, Action: "MGMT_DeleteUser"}
+
+	t.Run("Get pending requests", func(t *testing.T) {
+		var e = MakeGetPendingRequestsEndpoint(mockPendingRequests)
+		mockPendingRequests.EXPECT().GetPendingRequests(ctx, realm).Return([]api.PendingRequestRepresentation{request}, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm})
+		assert.Nil(t, err)
+		assert.Equal(t, []api.PendingRequestRepresentation{request}, res)
+	})
+
+	t.Run("Get pending request", func(t *testing.T) {
+		var e = MakeGetPendingRequestEndpoint(mockPendingRequests)
+		mockPendingRequests.EXPECT().GetPendingRequest(ctx, realm, int64(12)).Return(request, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmRequestID: "12"})
+		assert.Nil(t, err)
+		assert.Equal(t, request, res)
+
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmRequestID: ""})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Approve pending request", func(t *testing.T) {
+		var e = MakeApprovePendingRequestEndpoint(mockPendingRequests)
+		mockPendingRequests.EXPECT().ApprovePendingRequest(ctx, realm, int64(12)).Return(request, nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmRequestID: "12"})
+		assert.Nil(t, err)
+		assert.Equal(t, request, res)
+
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmRequestID: "abc"})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Reject pending request", func(t *testing.T) {
+		var e = MakeRejectPendingRequestEndpoint(mockPendingRequests)
+		mockPendingRequests.EXPECT().RejectPendingRequest(ctx, realm, int64(12)).Return(nil)
+		var res, err = e(ctx, map[string]string{prmRealm: realm, prmRequestID: "12"})
+		assert.Nil(t, err)
+		assert.Nil(t, res)
+
+		_, err = e(ctx, map[string]string{prmRealm: realm, prmRequestID: ""})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,357 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	cs "github.com/cloudtrust/common-service"
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/security"
+	"github.com/cloudtrust/common-service/validation"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+)
+
+// PendingApproval is returned instead of executing an operation which has to be approved by a second operator
+type PendingApproval struct {
+	RequestID int64 `json:"pendingRequestId"`
+}
+
+func (p PendingApproval) Error() string {
+	return "pending approval " + strconv.FormatInt(p.RequestID, 10)
+}
+
+// PendingRequestsComponent is the interface used to approve or reject the operations waiting for a second operator
+type PendingRequestsComponent interface {
+	GetPendingRequests(ctx context.Context, realmName string) ([]api.PendingRequestRepresentation, error)
+	GetPendingRequest(ctx context.Context, realmName string, requestID int64) (api.PendingRequestRepresentation, error)
+	ApprovePendingRequest(ctx context.Context, realmName string, requestID int64) (api.PendingRequestRepresentation, error)
+	RejectPendingRequest(ctx context.Context, realmName string, requestID int64) error
+}
+
+// FourEyesComponent is a management component which stores the sensitive operations until they are approved
+type FourEyesComponent interface {
+	Component
+	PendingRequestsComponent
+}
+
+type fourEyesComponent struct {
+	Component
+	keycloakClient    KeycloakClient
+	configDBModule    keycloakb.ConfigurationDBModule
+	breachedPwdModule keycloakb.BreachedPasswordModule
+	eventDBModule     database.EventsDBModule
+	cipher            security.EncrypterDecrypter
+	logger            keycloakb.Logger
+}
+
+// NewFourEyesComponent wraps a management component. The operations listed in the four eyes actions of the admin configuration
+// of the target realm are stored instead of being executed. They are executed once a second operator approves them
+func NewFourEyesComponent(next Component, keycloakClient KeycloakClient, configDBModule keycloakb.ConfigurationDBModule,
+	breachedPwdModule keycloakb.BreachedPasswordModule, eventDBModule database.EventsDBModule, cipher security.EncrypterDecrypter,
+	logger keycloakb.Logger) FourEyesComponent {
+	return &fourEyesComponent{
+		Component:         next,
+		keycloakClient:    keycloakClient,
+		configDBModule:    configDBModule,
+		breachedPwdModule: breachedPwdModule,
+		eventDBModule:     eventDBModule,
+		cipher:            cipher,
+		logger:            logger,
+	}
+}
+
+func (c *fourEyesComponent) reportEvent(ctx context.Context, apiCall string, values ...string) {
+	errEvent := c.eventDBModule.ReportEvent(ctx, apiCall, "back-office", values...)
+	if errEvent != nil {
+		//store in the logs also the event that failed to be stored in the DB
+		keycloakb.LogUnrecordedEvent(ctx, c.logger, apiCall, errEvent.Error(), values...)
+	}
+}
+
+func (c *fourEyesComponent) reportPendingRequestEvent(ctx context.Context, apiCall string, request dto.PendingRequest) {
+	var values = []string{database.CtEventRealmName, request.RealmName}
+	if request.UserID != nil {
+		values = append(values, database.CtEventUserID, *request.UserID)
+	}
+	values = append(values, database.CtEventAdditionalInfo, database.CreateAdditionalInfo("id", strconv.FormatInt(request.ID, 10),
+		"action", request.Action, "requester", request.Requester))
+	c.reportEvent(ctx, apiCall, values...)
+}
+
+func (c *fourEyesComponent) DeleteUser(ctx context.Context, realmName, userID string) error {
+	needed, err := c.isApprovalNeeded(ctx, realmName, MGMTDeleteUser)
+	if err != nil {
+		return err
+	}
+	if needed {
+		return c.storePendingRequest(ctx, realmName, MGMTDeleteUser, dto.PendingRequest{UserID: &userID})
+	}
+	return c.Component.DeleteUser(ctx, realmName, userID)
+}
+
+func (c *fourEyesComponent) ResetPassword(ctx context.Context, realmName string, userID string, password api.PasswordRepresentation) (string, error) {
+	needed, err := c.isApprovalNeeded(ctx, realmName, MGMTResetPassword)
+	if err != nil {
+		return "", err
+	}
+	if !needed {
+		return c.Component.ResetPassword(ctx, realmName, userID, password)
+	}
+
+	var request = dto.PendingRequest{UserID: &userID}
+	if password.Value != nil {
+		// a password which can't be set is refused now rather than when the request is approved
+		if err = c.checkChosenPassword(ctx, realmName, userID, *password.Value); err != nil {
+			return "", err
+		}
+		// the chosen password is kept encrypted until the request is approved
+		request.Secret, err = c.cipher.Encrypt([]byte(*password.Value), []byte(userID))
+		if err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return "", err
+		}
+	}
+	return "", c.storePendingRequest(ctx, realmName, MGMTResetPassword, request)
+}
+
+func (c *fourEyesComponent) checkChosenPassword(ctx context.Context, realmName, userID, password string) error {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	policy, err := getPasswordPolicy(ctx, c.keycloakClient, c.logger, accessToken, realmName)
+	if err != nil {
+		return err
+	}
+	username, email, err := getPasswordPolicyUserValues(ctx, c.keycloakClient, c.logger, accessToken, realmName, userID, policy)
+	if err != nil {
+		return err
+	}
+	return checkChosenPassword(ctx, c.breachedPwdModule, c.reportEvent, realmName, userID, password, policy, username, email)
+}
+
+func (c *fourEyesComponent) UpdateAuthorizations(ctx context.Context, realmName string, groupID string, authorizations api.AuthorizationsRepresentation) error {
+	needed, err := c.isApprovalNeeded(ctx, realmName, MGMTUpdateAuthorizations)
+	if err != nil {
+		return err
+	}
+	if !needed {
+		return c.Component.UpdateAuthorizations(ctx, realmName, groupID, authorizations)
+	}
+
+	payload, err := json.Marshal(authorizations)
+	if err != nil {
+		return err
+	}
+	var payloadJSON = string(payload)
+	return c.storePendingRequest(ctx, realmName, MGMTUpdateAuthorizations, dto.PendingRequest{GroupID: &groupID, Payload: &payloadJSON})
+}
+
+func (c *fourEyesComponent) DeleteCredentialsForUser(ctx context.Context, realmName string, userID string, credentialID string) error {
+	needed, err := c.isApprovalNeeded(ctx, realmName, MGMTDeleteCredentialsForUser)
+	if err != nil {
+		return err
+	}
+	if needed {
+		return c.storePendingRequest(ctx, realmName, MGMTDeleteCredentialsForUser, dto.PendingRequest{UserID: &userID, CredentialID: &credentialID})
+	}
+	return c.Component.DeleteCredentialsForUser(ctx, realmName, userID, credentialID)
+}
+
+func (c *fourEyesComponent) UpdateRealmAdminConfiguration(ctx context.Context, realmName string, adminConfig api.RealmAdminConfiguration) error {
+	needed, err := c.isApprovalNeeded(ctx, realmName, MGMTUpdateRealmAdminConfiguration)
+	if err != nil {
+		return err
+	}
+	if !needed {
+		return c.Component.UpdateRealmAdminConfiguration(ctx, realmName, adminConfig)
+	}
+
+	payload, err := json.Marshal(adminConfig)
+	if err != nil {
+		return err
+	}
+	var payloadJSON = string(payload)
+	return c.storePendingRequest(ctx, realmName, MGMTUpdateRealmAdminConfiguration, dto.PendingRequest{Payload: &payloadJSON})
+}
+
+// isApprovalNeeded tells whether the admin configuration of the realm requires the approval of a second operator for the action
+func (c *fourEyesComponent) isApprovalNeeded(ctx context.Context, realmName string, action security.Action) (bool, error) {
+	var accessToken = ctx.Value(cs.CtContextAccessToken).(string)
+
+	adminConfig, err := keycloakb.GetRealmAdminConfiguration(ctx, c.keycloakClient, c.configDBModule, accessToken, realmName, c.logger)
+	if err != nil {
+		return false, err
+	}
+
+	return validation.IsStringInSlice(adminConfig.FourEyesActions, action.String()), nil
+}
+
+// storePendingRequest stores an operation until it is approved. On success, the returned error is a PendingApproval
+func (c *fourEyesComponent) storePendingRequest(ctx context.Context, realmName string, action security.Action, request dto.PendingRequest) error {
+	request.RealmName = realmName
+	request.Action = action.String()
+	request.RequesterRealm = ctx.Value(cs.CtContextRealm).(string)
+	request.Requester = ctx.Value(cs.CtContextUsername).(string)
+	request.RequestDate = time.Now()
+	request.Status = dto.PendingRequestStatusPending
+
+	id, err := c.configDBModule.CreatePendingRequest(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return err
+	}
+
+	request.ID = id
+	c.reportPendingRequestEvent(ctx, "API_PENDING_REQUEST_CREATE", request)
+
+	return PendingApproval{RequestID: id}
+}
+
+func (c *fourEyesComponent) GetPendingRequests(ctx context.Context, realmName string) ([]api.PendingRequestRepresentation, error) {
+	requests, err := c.configDBModule.GetPendingRequests(ctx, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+
+	var res = make([]api.PendingRequestRepresentation, 0, len(requests))
+	for _, request := range requests {
+		res = append(res, api.ConvertToAPIPendingRequest(request))
+	}
+	return res, nil
+}
+
+func (c *fourEyesComponent) GetPendingRequest(ctx context.Context, realmName string, requestID int64) (api.PendingRequestRepresentation, error) {
+	request, err := c.getPendingRequest(ctx, realmName, requestID)
+	if err != nil {
+		return api.PendingRequestRepresentation{}, err
+	}
+	return api.ConvertToAPIPendingRequest(*request), nil
+}
+
+// ApprovePendingRequest executes a stored operation on behalf of the approver. The approver can't be the requester
+func (c *fourEyesComponent) ApprovePendingRequest(ctx context.Context, realmName string, requestID int64) (api.PendingRequestRepresentation, error) {
+	request, err := c.resolvePendingRequest(ctx, realmName, requestID, dto.PendingRequestStatusApproved)
+	if err != nil {
+		return api.PendingRequestRepresentation{}, err
+	}
+
+	pwd, err := c.executePendingRequest(ctx, *request)
+	if err != nil {
+		request.Status = dto.PendingRequestStatusFailed
+		if _, errUpdate := c.configDBModule.UpdatePendingRequestStatus(ctx, *request, dto.PendingRequestStatusApproved); errUpdate != nil {
+			c.logger.Warn(ctx, "err", errUpdate.Error())
+		}
+		return api.PendingRequestRepresentation{}, err
+	}
+
+	c.reportPendingRequestEvent(ctx, "API_PENDING_REQUEST_APPROVE", *request)
+
+	var res = api.ConvertToAPIPendingRequest(*request)
+	if pwd != "" {
+		res.Password = &pwd
+	}
+	return res, nil
+}
+
+// RejectPendingRequest discards a stored operation. The requester can't reject its own request
+func (c *fourEyesComponent) RejectPendingRequest(ctx context.Context, realmName string, requestID int64) error {
+	request, err := c.resolvePendingRequest(ctx, realmName, requestID, dto.PendingRequestStatusRejected)
+	if err != nil {
+		return err
+	}
+
+	c.reportPendingRequestEvent(ctx, "API_PENDING_REQUEST_REJECT", *request)
+
+	return nil
+}
+
+func (c *fourEyesComponent) getPendingRequest(ctx context.Context, realmName string, requestID int64) (*dto.PendingRequest, error) {
+	request, err := c.configDBModule.GetPendingRequest(ctx, realmName, requestID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	if request == nil {
+		return nil, errorhandler.CreateNotFoundError(constants.PendingRequest)
+	}
+	return request, nil
+}
+
+// resolvePendingRequest changes the status of a pending request. The status is only changed if nobody resolved the request in the meantime
+func (c *fourEyesComponent) resolvePendingRequest(ctx context.Context, realmName string, requestID int64, status string) (*dto.PendingRequest, error) {
+	request, err := c.getPendingRequest(ctx, realmName, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	var operatorRealm = ctx.Value(cs.CtContextRealm).(string)
+	var operator = ctx.Value(cs.CtContextUsername).(string)
+	if operatorRealm == request.RequesterRealm && operator == request.Requester {
+		c.logger.Debug(ctx, "ForbiddenError", "Requests must be resolved by a second operator", "id", requestID)
+		return nil, security.ForbiddenError{}
+	}
+
+	var now = time.Now()
+	request.Status = status
+	request.ApproverRealm = &operatorRealm
+	request.Approver = &operator
+	request.ResolutionDate = &now
+
+	updated, err := c.configDBModule.UpdatePendingRequestStatus(ctx, *request, dto.PendingRequestStatusPending)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	if !updated {
+		return nil, errorhandler.Error{
+			Status:  http.StatusConflict,
+			Message: keycloakb.ComponentName + "." + constants.MsgErrAlreadyResolved + "." + constants.PendingRequest,
+		}
+	}
+
+	return request, nil
+}
+
+// executePendingRequest calls the wrapped component with the stored parameters. Returns the password generated by a password reset
+func (c *fourEyesComponent) executePendingRequest(ctx context.Context, request dto.PendingRequest) (string, error) {
+	switch request.Action {
+	case MGMTDeleteUser.String():
+		return "", c.Component.DeleteUser(ctx, request.RealmName, *request.UserID)
+	case MGMTResetPassword.String():
+		var password api.PasswordRepresentation
+		if request.Secret != nil {
+			value, err := c.cipher.Decrypt(request.Secret, []byte(*request.UserID))
+			if err != nil {
+				c.logger.Warn(ctx, "err", err.Error())
+				return "", err
+			}
+			var pwd = string(value)
+			password.Value = &pwd
+		}
+		return c.Component.ResetPassword(ctx, request.RealmName, *request.UserID, password)
+	case MGMTUpdateAuthorizations.String():
+		var authorizations api.AuthorizationsRepresentation
+		if err := json.Unmarshal([]byte(*request.Payload), &authorizations); err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return "", err
+		}
+		return "", c.Component.UpdateAuthorizations(ctx, request.RealmName, *request.GroupID, authorizations)
+	case MGMTDeleteCredentialsForUser.String():
+		return "", c.Component.DeleteCredentialsForUser(ctx, request.RealmName, *request.UserID, *request.CredentialID)
+	case MGMTUpdateRealmAdminConfiguration.String():
+		var adminConfig api.RealmAdminConfiguration
+		if err := json.Unmarshal([]byte(*request.Payload), &adminConfig); err != nil {
+			c.logger.Warn(ctx, "err", err.Error())
+			return "", err
+		}
+		return "", c.Component.UpdateRealmAdminConfiguration(ctx, request.RealmName, adminConfig)
+	}
+	return "", errorhandler.CreateBadRequestError(constants.MsgErrInvalidParam + "." + constants.Action)
+}
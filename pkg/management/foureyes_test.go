@@ -0,0 +1,257 @@
+package management
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	cs "github.com/cloudtrust/common-service"
+	errorhandler "github.com/cloudtrust/common-service/errors"
+	"github.com/cloudtrust/common-service/security"
+	api "github.com/cloudtrust/keycloak-bridge/api/management"
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+	"github.com/cloudtrust/keycloak-bridge/pkg/management/mock"
+	kc "github.com/cloudtrust/keycloak-client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFourEyesOperations(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewManagementComponent(mockCtrl)
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockConfigDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockBreachedPwdModule = mock.NewBreachedPasswordModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockCipher = mock.NewEncrypterDecrypter(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var fourEyes = NewFourEyesComponent(mockComponent, mockKeycloakClient, mockConfigDBModule, mockBreachedPwdModule, mockEventDBModule, mockCipher, mockLogger)
+
+	var accessToken = "TOKEN=="
+	var realmName = "master"
+	var realmID = "master-id"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var groupID = "grp-id"
+	var credentialID = "cred-id"
+	var password = "P@ssw0rd"
+	var requestID = int64(42)
+	var anyError = errors.New("any error")
+	var adminConfig = dto.RealmAdminConfiguration{FourEyesActions: []string{MGMTDeleteUser.String(), MGMTResetPassword.String(),
+		MGMTUpdateAuthorizations.String(), MGMTDeleteCredentialsForUser.String(), MGMTUpdateRealmAdminConfiguration.String()}}
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextAccessToken, accessToken)
+	ctx = context.WithValue(ctx, cs.CtContextRealm, realmName)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "requester")
+
+	mockKeycloakClient.EXPECT().GetRealm(accessToken, realmName).Return(kc.RealmRepresentation{ID: &realmID}, nil).AnyTimes()
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	t.Run("Four eyes not configured", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, sql.ErrNoRows)
+		mockComponent.EXPECT().DeleteUser(ctx, realmName, userID).Return(nil)
+		assert.Nil(t, fourEyes.DeleteUser(ctx, realmName, userID))
+	})
+
+	t.Run("Action not listed", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{FourEyesActions: []string{MGMTResetPassword.String()}}, nil)
+		mockComponent.EXPECT().DeleteCredentialsForUser(ctx, realmName, userID, credentialID).Return(nil)
+		assert.Nil(t, fourEyes.DeleteCredentialsForUser(ctx, realmName, userID, credentialID))
+	})
+
+	t.Run("Can't get admin configuration", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(dto.RealmAdminConfiguration{}, anyError)
+		mockLogger.EXPECT().Warn(ctx, "msg", "Can't get admin configuration", "err", anyError.Error(), "realm", realmName)
+		assert.Equal(t, anyError, fourEyes.DeleteUser(ctx, realmName, userID))
+	})
+
+	t.Run("Delete user is stored", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockConfigDBModule.EXPECT().CreatePendingRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.PendingRequest) (int64, error) {
+			assert.Equal(t, MGMTDeleteUser.String(), request.Action)
+			assert.Equal(t, userID, *request.UserID)
+			assert.Equal(t, "requester", request.Requester)
+			assert.Equal(t, dto.PendingRequestStatusPending, request.Status)
+			return requestID, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_PENDING_REQUEST_CREATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = fourEyes.DeleteUser(ctx, realmName, userID)
+		assert.Equal(t, PendingApproval{RequestID: requestID}, err)
+	})
+
+	t.Run("Can't store the request", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockConfigDBModule.EXPECT().CreatePendingRequest(ctx, gomock.Any()).Return(int64(0), anyError)
+		assert.Equal(t, anyError, fourEyes.DeleteCredentialsForUser(ctx, realmName, userID, credentialID))
+	})
+
+	t.Run("Reset password is stored with an encrypted password", func(t *testing.T) {
+		var encrypted = []byte("encrypted")
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realmName, password).Return(false, nil)
+		mockCipher.EXPECT().Encrypt([]byte(password), []byte(userID)).Return(encrypted, nil)
+		mockConfigDBModule.EXPECT().CreatePendingRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.PendingRequest) (int64, error) {
+			assert.Equal(t, encrypted, request.Secret)
+			return requestID, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_PENDING_REQUEST_CREATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var pwd, err = fourEyes.ResetPassword(ctx, realmName, userID, api.PasswordRepresentation{Value: &password})
+		assert.Equal(t, "", pwd)
+		assert.Equal(t, PendingApproval{RequestID: requestID}, err)
+	})
+
+	t.Run("Reset password: breached password is refused before being stored", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realmName, password).Return(true, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "BREACHED_PASSWORD_REJECTED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var _, err = fourEyes.ResetPassword(ctx, realmName, userID, api.PasswordRepresentation{Value: &password})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Reset password: can't check breached password", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realmName, password).Return(false, anyError)
+
+		var _, err = fourEyes.ResetPassword(ctx, realmName, userID, api.PasswordRepresentation{Value: &password})
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Reset password: encryption fails", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockBreachedPwdModule.EXPECT().IsBreached(ctx, realmName, password).Return(false, nil)
+		mockCipher.EXPECT().Encrypt([]byte(password), []byte(userID)).Return(nil, anyError)
+
+		var _, err = fourEyes.ResetPassword(ctx, realmName, userID, api.PasswordRepresentation{Value: &password})
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Update authorizations is stored with its payload", func(t *testing.T) {
+		var matrix = map[string]map[string]map[string]struct{}{}
+		mockConfigDBModule.EXPECT().GetAdminConfiguration(ctx, realmID).Return(adminConfig, nil)
+		mockConfigDBModule.EXPECT().CreatePendingRequest(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request dto.PendingRequest) (int64, error) {
+			assert.Equal(t, groupID, *request.GroupID)
+			assert.Equal(t, `{"matrix":{}}`, *request.Payload)
+			return requestID, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_PENDING_REQUEST_CREATE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var err = fourEyes.UpdateAuthorizations(ctx, realmName, groupID, api.AuthorizationsRepresentation{Matrix: &matrix})
+		assert.Equal(t, PendingApproval{RequestID: requestID}, err)
+	})
+}
+
+func TestFourEyesPendingRequests(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockComponent = mock.NewManagementComponent(mockCtrl)
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockConfigDBModule = mock.NewConfigurationDBModule(mockCtrl)
+	var mockBreachedPwdModule = mock.NewBreachedPasswordModule(mockCtrl)
+	var mockEventDBModule = mock.NewEventDBModule(mockCtrl)
+	var mockCipher = mock.NewEncrypterDecrypter(mockCtrl)
+	var mockLogger = mock.NewLogger(mockCtrl)
+
+	var fourEyes = NewFourEyesComponent(mockComponent, mockKeycloakClient, mockConfigDBModule, mockBreachedPwdModule, mockEventDBModule, mockCipher, mockLogger)
+
+	var realmName = "master"
+	var userID = "41dbf4a8-32a9-4000-8c17-edc854c31231"
+	var requestID = int64(42)
+	var secret = []byte("encrypted")
+	var generated = "generated-password"
+	var anyError = errors.New("any error")
+
+	var ctx = context.WithValue(context.Background(), cs.CtContextRealm, realmName)
+	ctx = context.WithValue(ctx, cs.CtContextUsername, "approver")
+
+	var newRequest = func(action string) *dto.PendingRequest {
+		return &dto.PendingRequest{ID: requestID, RealmName: realmName, Action: action, UserID: &userID, RequesterRealm: realmName,
+			Requester: "requester", Status: dto.PendingRequestStatusPending}
+	}
+
+	mockLogger.EXPECT().Warn(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	t.Run("Get pending requests", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetPendingRequests(ctx, realmName).Return([]dto.PendingRequest{*newRequest(MGMTDeleteUser.String())}, nil)
+		var res, err = fourEyes.GetPendingRequests(ctx, realmName)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, requestID, res[0].ID)
+	})
+
+	t.Run("Get pending requests: db error", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetPendingRequests(ctx, realmName).Return(nil, anyError)
+		var _, err = fourEyes.GetPendingRequests(ctx, realmName)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Get pending request: not found", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(nil, nil)
+		var _, err = fourEyes.GetPendingRequest(ctx, realmName, requestID)
+		assert.Equal(t, http.StatusNotFound, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Approve: requester can't approve its own request", func(t *testing.T) {
+		var request = newRequest(MGMTDeleteUser.String())
+		request.Requester = "approver"
+		mockConfigDBModule.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(request, nil)
+		var _, err = fourEyes.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.IsType(t, security.ForbiddenError{}, err)
+	})
+
+	t.Run("Approve: request already resolved", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(newRequest(MGMTDeleteUser.String()), nil)
+		mockConfigDBModule.EXPECT().UpdatePendingRequestStatus(ctx, gomock.Any(), dto.PendingRequestStatusPending).Return(false, nil)
+		var _, err = fourEyes.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.Equal(t, http.StatusConflict, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Approve: reset password is executed with the decrypted password", func(t *testing.T) {
+		var request = newRequest(MGMTResetPassword.String())
+		request.Secret = secret
+		mockConfigDBModule.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(request, nil)
+		mockConfigDBModule.EXPECT().UpdatePendingRequestStatus(ctx, gomock.Any(), dto.PendingRequestStatusPending).DoAndReturn(func(_ context.Context, request dto.PendingRequest, _ string) (bool, error) {
+			assert.Equal(t, dto.PendingRequestStatusApproved, request.Status)
+			assert.Equal(t, "approver", *request.Approver)
+			return true, nil
+		})
+		mockCipher.EXPECT().Decrypt(secret, []byte(userID)).Return([]byte(generated), nil)
+		mockComponent.EXPECT().ResetPassword(ctx, realmName, userID, api.PasswordRepresentation{Value: &generated}).Return(generated, nil)
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_PENDING_REQUEST_APPROVE", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		var res, err = fourEyes.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.Nil(t, err)
+		assert.Equal(t, dto.PendingRequestStatusApproved, res.Status)
+		assert.Equal(t, generated, *res.Password)
+	})
+
+	t.Run("Approve: execution fails", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(newRequest(MGMTDeleteUser.String()), nil)
+		mockConfigDBModule.EXPECT().UpdatePendingRequestStatus(ctx, gomock.Any(), dto.PendingRequestStatusPending).Return(true, nil)
+		mockComponent.EXPECT().DeleteUser(ctx, realmName, userID).Return(anyError)
+		mockConfigDBModule.EXPECT().UpdatePendingRequestStatus(ctx, gomock.Any(), dto.PendingRequestStatusApproved).DoAndReturn(func(_ context.Context, request dto.PendingRequest, _ string) (bool, error) {
+			assert.Equal(t, dto.PendingRequestStatusFailed, request.Status)
+			return true, nil
+		})
+
+		var _, err = fourEyes.ApprovePendingRequest(ctx, realmName, requestID)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		mockConfigDBModule.EXPECT().GetPendingRequest(ctx, realmName, requestID).Return(newRequest(MGMTDeleteUser.String()), nil)
+		mockConfigDBModule.EXPECT().UpdatePendingRequestStatus(ctx, gomock.Any(), dto.PendingRequestStatusPending).DoAndReturn(func(_ context.Context, request dto.PendingRequest, _ string) (bool, error) {
+			assert.Equal(t, dto.PendingRequestStatusRejected, request.Status)
+			return true, nil
+		})
+		mockEventDBModule.EXPECT().ReportEvent(ctx, "API_PENDING_REQUEST_REJECT", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		assert.Nil(t, fourEyes.RejectPendingRequest(ctx, realmName, requestID))
+	})
+}
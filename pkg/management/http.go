@@ -2,6 +2,7 @@ package management
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 
 	commonhttp "github.com/cloudtrust/common-service/http"
@@ -29,6 +30,10 @@ const (
 	prmGroupID      = "groupID"
 	prmCredentialID = "credentialID"
 	prmProvider     = "provider"
+	prmTemplateName = "templateName"
+	prmVersion      = "version"
+	prmRequestID    = "requestID"
+	prmAccredType   = "accreditationType"
 
 	prmQryEmail       = "email"
 	prmQryFirstName   = "firstName"
@@ -42,13 +47,17 @@ const (
 	prmQryFirst       = "first"
 	prmQryMax         = "max"
 	prmQryGroupName   = "groupName"
+	prmQryFormat      = "format"
+	prmQryDryRun      = "dryRun"
+	prmQryFromVersion = "from"
+	prmQryToVersion   = "to"
 )
 
 // MakeManagementHandler make an HTTP handler for a Management endpoint.
 func MakeManagementHandler(e endpoint.Endpoint, logger log.Logger) *http_transport.Server {
 	return http_transport.NewServer(e,
 		decodeManagementRequest,
-		encodeManagementReply,
+		encodeManagementReply(logger),
 		http_transport.ServerErrorEncoder(managementErrorHandler(logger)),
 	)
 }
@@ -63,6 +72,10 @@ func decodeManagementRequest(ctx context.Context, req *http.Request) (interface{
 		prmGroupID:      api.RegExpID,
 		prmCredentialID: api.RegExpID,
 		prmProvider:     api.RegExpName,
+		prmTemplateName: api.RegExpName,
+		prmVersion:      api.RegExpNumber,
+		prmRequestID:    api.RegExpNumber,
+		prmAccredType:   api.RegExpName,
 	}
 
 	var queryParams = map[string]string{
@@ -78,20 +91,41 @@ func decodeManagementRequest(ctx context.Context, req *http.Request) (interface{
 		prmQryFirst:       api.RegExpNumber,
 		prmQryMax:         api.RegExpNumber,
 		prmQryGroupName:   api.RegExpName,
+		prmQryFormat:      api.RegExpUsersFileFormat,
+		prmQryDryRun:      api.RegExpBool,
+		prmQryFromVersion: api.RegExpNumber,
+		prmQryToVersion:   api.RegExpNumber,
 	}
 
-	return commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
+	return keycloakb.DecodeRequestWithHeaders(ctx, req, pathParams, queryParams)
 }
 
 // encodeManagementReply encodes the reply.
-func encodeManagementReply(ctx context.Context, w http.ResponseWriter, rep interface{}) error {
-	switch r := rep.(type) {
-	case LocationHeader:
-		w.Header().Set("Location", r.URL)
-		w.WriteHeader(http.StatusCreated)
-		return nil
-	default:
-		return commonhttp.EncodeReply(ctx, w, rep)
+func encodeManagementReply(logger log.Logger) http_transport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, rep interface{}) error {
+		switch r := rep.(type) {
+		case LocationHeader:
+			w.Header().Set("Location", r.URL)
+			w.WriteHeader(http.StatusCreated)
+			return nil
+		case StreamReply:
+			w.Header().Set("Content-Type", r.ContentType)
+			w.Header().Set("Content-Disposition", "attachment; filename=\""+r.Filename+"\"")
+			w.WriteHeader(http.StatusOK)
+			if err := r.Write(w); err != nil {
+				// Status and a part of the body are already sent: the connection is aborted so that the client
+				// can't mistake the truncated body for a complete one
+				logger.Warn(ctx, "msg", "Can't write stream reply", "err", err.Error())
+				panic(http.ErrAbortHandler)
+			}
+			return nil
+		case PendingApproval:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusAccepted)
+			return json.NewEncoder(w).Encode(r)
+		default:
+			return keycloakb.EncodeReplyWithETag(ctx, w, rep)
+		}
 	}
 }
 
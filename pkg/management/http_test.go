@@ -3,7 +3,9 @@ package management
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
@@ -31,12 +33,18 @@ func TestHTTPManagementHandler(t *testing.T) {
 	var managementHandler = MakeManagementHandler(keycloakb.ToGoKitEndpoint(MakeGetRealmEndpoint(mockComponent)), mockLogger)
 	var managementHandler2 = MakeManagementHandler(keycloakb.ToGoKitEndpoint(MakeCreateUserEndpoint(mockComponent, mockLogger)), mockLogger)
 	var managementHandler3 = MakeManagementHandler(keycloakb.ToGoKitEndpoint(MakeResetPasswordEndpoint(mockComponent)), mockLogger)
+	var managementHandler4 = MakeManagementHandler(keycloakb.ToGoKitEndpoint(MakeExportUsersEndpoint(mockComponent)), mockLogger)
+	var managementHandler5 = MakeManagementHandler(keycloakb.ToGoKitEndpoint(MakeGetUserEndpoint(mockComponent)), mockLogger)
+	var managementHandler6 = MakeManagementHandler(keycloakb.ToGoKitEndpoint(MakeUpdateUserEndpoint(mockComponent)), mockLogger)
 
 	r := mux.NewRouter()
 	r.Handle("/realms/{realm}", managementHandler)
 	r.Handle("/realms/{realm}?email={email}", managementHandler)
 	r.Handle("/realms/{realm}/users", managementHandler2)
 	r.Handle("/realms/{realm}/users/{userID}/reset-password", managementHandler3)
+	r.Handle("/realms/{realm}/users/export", managementHandler4)
+	r.Handle("/realms/{realm}/users/{userID}", managementHandler5).Methods("GET")
+	r.Handle("/realms/{realm}/users/{userID}", managementHandler6).Methods("PUT")
 
 	ts := httptest.NewServer(r)
 	defer ts.Close()
@@ -108,6 +116,106 @@ func TestHTTPManagementHandler(t *testing.T) {
 		assert.Equal(t, http.NoBody, res.Body)
 	}
 
+	// Post - 202 when the operation waits for a second operator
+	{
+		var password = "P@ssw0rd"
+		passwordJSON, _ := json.Marshal(api.PasswordRepresentation{Value: &password})
+
+		mockComponent.EXPECT().ResetPassword(gomock.Any(), "master", "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", gomock.Any()).Return("", PendingApproval{RequestID: 5}).Times(1)
+
+		var body = strings.NewReader(string(passwordJSON))
+		res, err := http.Post(ts.URL+"/realms/master/users/f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee/reset-password", "application/json", body)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(res.Body)
+		assert.Equal(t, "{\"pendingRequestId\":5}\n", buf.String())
+	}
+
+	// Get - 200 with streamed body content
+	{
+		var export UsersExport = func(w io.Writer) error {
+			w.Write([]byte("{\"username\":\"toto\"}\n"))
+			return nil
+		}
+		mockComponent.EXPECT().ExportUsers(gomock.Any(), "master", "ndjson").Return(export, nil).Times(1)
+
+		res, err := http.Get(ts.URL + "/realms/master/users/export?format=ndjson")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=\"master-users.ndjson\"", res.Header.Get("Content-Disposition"))
+
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(res.Body)
+		assert.Equal(t, "{\"username\":\"toto\"}\n", buf.String())
+	}
+
+	// Get - 200 with streamed body content interrupted by an error
+	{
+		var export UsersExport = func(w io.Writer) error {
+			// Enough content to get the status and the beginning of the body flushed to the client
+			w.Write([]byte(strings.Repeat("{\"username\":\"toto\"}\n", 1000)))
+			return errors.New("stream error")
+		}
+		mockComponent.EXPECT().ExportUsers(gomock.Any(), "master", "ndjson").Return(export, nil).Times(1)
+
+		res, err := http.Get(ts.URL + "/realms/master/users/export?format=ndjson")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		buf := new(bytes.Buffer)
+		_, err = buf.ReadFrom(res.Body)
+		assert.NotNil(t, err)
+	}
+
+	// Get - 200 with ETag header
+	{
+		var username = "toto"
+		var etag = `"abcdef"`
+		mockComponent.EXPECT().GetUser(gomock.Any(), "master", "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee").Return(api.UserRepresentation{Username: &username, ETag: &etag}, nil).Times(1)
+
+		res, err := http.Get(ts.URL + "/realms/master/users/f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, etag, res.Header.Get("ETag"))
+
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(res.Body)
+		assert.NotContains(t, buf.String(), "abcdef")
+	}
+
+	// Put - If-Match header is given to the component
+	{
+		var username = "toto"
+		var etag = `"abcdef"`
+		mockComponent.EXPECT().UpdateUser(gomock.Any(), "master", "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", api.UserRepresentation{Username: &username, ETag: &etag}).Return(nil).Times(1)
+
+		req, _ := http.NewRequest(http.MethodPut, ts.URL+"/realms/master/users/f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", strings.NewReader(`{"username":"toto"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", etag)
+		res, err := http.DefaultClient.Do(req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	// Patch - fields set to null in a JSON merge patch are cleared
+	{
+		mockComponent.EXPECT().UpdateUser(gomock.Any(), "master", "f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", api.UserRepresentation{ClearedFields: []string{"phoneNumber"}}).Return(nil).Times(1)
+
+		req, _ := http.NewRequest(http.MethodPut, ts.URL+"/realms/master/users/f467ed7c-0a1d-4eee-9bb8-669c6f89c0ee", strings.NewReader(`{"phoneNumber":null}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		res, err := http.DefaultClient.Do(req)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
 }
 
 func TestHTTPErrorHandler(t *testing.T) {
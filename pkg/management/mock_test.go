@@ -10,3 +10,7 @@ package management
 //go:generate mockgen -destination=./mock/database.go -package=mock -mock_names=Transaction=Transaction github.com/cloudtrust/common-service/database/sqltypes Transaction
 //go:generate mockgen -destination=./mock/authentication_db_reader.go -package=mock -mock_names=AuthorizationDBReader=AuthorizationDBReader github.com/cloudtrust/common-service/security AuthorizationDBReader
 //go:generate mockgen -destination=./mock/usersdbmodule.go -package=mock -mock_names=UsersDetailsDBModule=UsersDetailsDBModule github.com/cloudtrust/keycloak-bridge/pkg/management UsersDetailsDBModule
+//go:generate mockgen -destination=./mock/pendingrequests.go -package=mock -mock_names=PendingRequestsComponent=PendingRequestsComponent github.com/cloudtrust/keycloak-bridge/pkg/management PendingRequestsComponent
+//go:generate mockgen -destination=./mock/security.go -package=mock -mock_names=EncrypterDecrypter=EncrypterDecrypter github.com/cloudtrust/common-service/security EncrypterDecrypter
+//go:generate mockgen -destination=./mock/archivedbmodule.go -package=mock -mock_names=ArchiveDBModule=ArchiveDBModule github.com/cloudtrust/keycloak-bridge/pkg/management ArchiveDBModule
+//go:generate mockgen -destination=./mock/internal.go -package=mock -mock_names=AccreditationsModule=AccreditationsModule,DuplicatesModule=DuplicatesModule,BreachedPasswordModule=BreachedPasswordModule github.com/cloudtrust/keycloak-bridge/internal/keycloakb AccreditationsModule,DuplicatesModule,BreachedPasswordModule
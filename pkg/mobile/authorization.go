@@ -6,11 +6,15 @@ import (
 	"github.com/cloudtrust/common-service/log"
 	api "github.com/cloudtrust/keycloak-bridge/api/mobile"
 	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 // Creates constants for API method names
 const (
-	GetUserInformation = "GetUserInformation"
+	GetUserInformation          = "GetUserInformation"
+	GetAccreditationCredentials = "GetAccreditationCredentials"
+	GetCredentialsJWKS          = "GetCredentialsJWKS"
+	GetCredentialsStatusList    = "GetCredentialsStatusList"
 )
 
 // Tracking middleware at component level.
@@ -36,3 +40,21 @@ func (c *authorizationComponentMW) GetUserInformation(ctx context.Context) (api.
 	// No restriction for this call
 	return c.next.GetUserInformation(ctx)
 }
+
+// authorizationComponentMW implements Component.
+func (c *authorizationComponentMW) GetAccreditationCredentials(ctx context.Context) ([]api.AccreditationCredentialRepresentation, error) {
+	// No restriction for this call: credentials are only issued for the current user
+	return c.next.GetAccreditationCredentials(ctx)
+}
+
+// authorizationComponentMW implements Component.
+func (c *authorizationComponentMW) GetCredentialsJWKS(ctx context.Context) (jose.JSONWebKeySet, error) {
+	// Public keys are published for verifiers
+	return c.next.GetCredentialsJWKS(ctx)
+}
+
+// authorizationComponentMW implements Component.
+func (c *authorizationComponentMW) GetCredentialsStatusList(ctx context.Context, realmName string) (string, error) {
+	// Status lists are published for verifiers
+	return c.next.GetCredentialsStatusList(ctx, realmName)
+}
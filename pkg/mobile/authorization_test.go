@@ -10,6 +10,7 @@ import (
 	"github.com/cloudtrust/keycloak-bridge/pkg/mobile/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 func TestNoRestrictions(t *testing.T) {
@@ -28,4 +29,25 @@ func TestNoRestrictions(t *testing.T) {
 		_, err := authorizationMW.GetUserInformation(ctx)
 		assert.Nil(t, err)
 	})
+
+	t.Run("GetAccreditationCredentials", func(t *testing.T) {
+		var authorizationMW = MakeAuthorizationMobileComponentMW(mockLogger, mockConfigurationDBModule)(mockMobileComponent)
+		mockMobileComponent.EXPECT().GetAccreditationCredentials(ctx).Return(nil, nil)
+		_, err := authorizationMW.GetAccreditationCredentials(ctx)
+		assert.Nil(t, err)
+	})
+
+	t.Run("GetCredentialsJWKS", func(t *testing.T) {
+		var authorizationMW = MakeAuthorizationMobileComponentMW(mockLogger, mockConfigurationDBModule)(mockMobileComponent)
+		mockMobileComponent.EXPECT().GetCredentialsJWKS(ctx).Return(jose.JSONWebKeySet{}, nil)
+		_, err := authorizationMW.GetCredentialsJWKS(ctx)
+		assert.Nil(t, err)
+	})
+
+	t.Run("GetCredentialsStatusList", func(t *testing.T) {
+		var authorizationMW = MakeAuthorizationMobileComponentMW(mockLogger, mockConfigurationDBModule)(mockMobileComponent)
+		mockMobileComponent.EXPECT().GetCredentialsStatusList(ctx, "realm").Return("", nil)
+		_, err := authorizationMW.GetCredentialsStatusList(ctx, "realm")
+		assert.Nil(t, err)
+	})
 }
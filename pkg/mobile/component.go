@@ -2,6 +2,11 @@ package mobilepkg
 
 import (
 	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	cs "github.com/cloudtrust/common-service"
 	api "github.com/cloudtrust/keycloak-bridge/api/mobile"
@@ -10,6 +15,7 @@ import (
 	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	internal "github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	kc "github.com/cloudtrust/keycloak-client"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 // KeycloakClient interface exposes methods we need to call to send requests to Keycloak API
@@ -20,11 +26,16 @@ type KeycloakClient interface {
 // Component interface exposes methods used by the bridge API
 type Component interface {
 	GetUserInformation(ctx context.Context) (api.UserInformationRepresentation, error)
+	GetAccreditationCredentials(ctx context.Context) ([]api.AccreditationCredentialRepresentation, error)
+	GetCredentialsJWKS(ctx context.Context) (jose.JSONWebKeySet, error)
+	GetCredentialsStatusList(ctx context.Context, realmName string) (string, error)
 }
 
 // UsersDetailsDBModule is the minimum required interface to access the users database
 type UsersDetailsDBModule interface {
 	GetChecks(ctx context.Context, realm string, userID string) ([]dto.DBCheck, error)
+	GetAccreditations(ctx context.Context, realm string, userID string) ([]dto.DBAccreditation, error)
+	GetAccreditationsStatus(ctx context.Context, realm string) (dto.DBAccreditationsStatus, error)
 }
 
 // TokenProvider is the interface to retrieve accessToken to access KC
@@ -34,21 +45,29 @@ type TokenProvider interface {
 
 // Component is the management component
 type component struct {
-	keycloakClient KeycloakClient
-	configDBModule keycloakb.ConfigurationDBModule
-	usersDBModule  UsersDetailsDBModule
-	tokenProvider  TokenProvider
-	logger         internal.Logger
+	keycloakClient    KeycloakClient
+	configDBModule    keycloakb.ConfigurationDBModule
+	usersDBModule     UsersDetailsDBModule
+	tokenProvider     TokenProvider
+	credentialSigner  keycloakb.CredentialSigner
+	credentialsIssuer string
+	logger            internal.Logger
+	now               func() time.Time
 }
 
-// NewComponent returns the self-service component.
-func NewComponent(keycloakClient KeycloakClient, configDBModule keycloakb.ConfigurationDBModule, usersDBModule UsersDetailsDBModule, tokenProvider TokenProvider, logger internal.Logger) Component {
+// NewComponent returns the self-service component. The credentials issuer is the public URL of the mobile API: it identifies
+// the bridge in the signed credentials and is the base of the URLs of the status lists
+func NewComponent(keycloakClient KeycloakClient, configDBModule keycloakb.ConfigurationDBModule, usersDBModule UsersDetailsDBModule, tokenProvider TokenProvider,
+	credentialSigner keycloakb.CredentialSigner, credentialsIssuer string, logger internal.Logger) Component {
 	return &component{
-		keycloakClient: keycloakClient,
-		configDBModule: configDBModule,
-		usersDBModule:  usersDBModule,
-		tokenProvider:  tokenProvider,
-		logger:         logger,
+		keycloakClient:    keycloakClient,
+		configDBModule:    configDBModule,
+		usersDBModule:     usersDBModule,
+		tokenProvider:     tokenProvider,
+		credentialSigner:  credentialSigner,
+		credentialsIssuer: strings.TrimSuffix(credentialsIssuer, "/"),
+		logger:            logger,
+		now:               time.Now,
 	}
 }
 
@@ -89,3 +108,144 @@ func (c *component) GetUserInformation(ctx context.Context) (api.UserInformation
 
 	return userInfo, nil
 }
+
+// GetAccreditationCredentials issues a signed credential for each active accreditation of the current user. The credential refers
+// to the status list of the realm so that a verifier can check offline whether the accreditation has been revoked since then
+func (c *component) GetAccreditationCredentials(ctx context.Context) ([]api.AccreditationCredentialRepresentation, error) {
+	var realm = ctx.Value(cs.CtContextRealm).(string)
+	var userID = ctx.Value(cs.CtContextUserID).(string)
+
+	accessToken, err := c.tokenProvider.ProvideToken(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get OIDC token", "err", err.Error())
+		return nil, err
+	}
+
+	userKc, err := c.keycloakClient.GetUser(accessToken, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "err", err.Error())
+		return nil, err
+	}
+	keycloakb.ConvertLegacyAttribute(&userKc)
+
+	history, err := c.usersDBModule.GetAccreditations(ctx, realm, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get accreditations history", "err", err.Error())
+		return nil, err
+	}
+
+	var now = c.now()
+	var statusListURL = c.statusListURL(realm)
+	var credentials = []api.AccreditationCredentialRepresentation{}
+	for _, accredJSON := range userKc.GetAttribute(constants.AttrbAccreditations) {
+		var accred keycloakb.AccreditationRepresentation
+		if json.Unmarshal([]byte(accredJSON), &accred) != nil || accred.Type == nil || accred.ExpiryDate == nil || (accred.Revoked != nil && *accred.Revoked) {
+			continue
+		}
+		var expiryDate, err = time.Parse(constants.SupportedDateLayouts[0], *accred.ExpiryDate)
+		if err != nil || !now.Before(expiryDate) {
+			continue
+		}
+		// Accreditations granted before their history was recorded can't be revoked offline: no credential is issued for them
+		var accredID = findGrantedAccreditation(history, *accred.Type, expiryDate)
+		if accredID == nil {
+			c.logger.Warn(ctx, "msg", "Accreditation not found in history", "type", *accred.Type, "realm", realm, "userID", userID)
+			continue
+		}
+
+		var index = strconv.FormatInt(*accredID, 10)
+		var claims = jwtCredentialClaims{
+			Issuer:    c.credentialsIssuer,
+			Subject:   userID,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			Expiry:    expiryDate.Unix(),
+			VC: verifiableCredential{
+				Context: []string{credentialsContext, statusListContext},
+				Type:    []string{"VerifiableCredential", "AccreditationCredential"},
+				CredentialSubject: accreditationSubject{
+					Accreditation: accreditationClaim{Type: *accred.Type, ExpiryDate: *accred.ExpiryDate, IssuerRealm: realm},
+				},
+				CredentialStatus: &statusListEntry{
+					ID:                   statusListURL + "#" + index,
+					Type:                 "StatusList2021Entry",
+					StatusPurpose:        statusListPurpose,
+					StatusListIndex:      index,
+					StatusListCredential: statusListURL,
+				},
+			},
+		}
+		credential, err := c.credentialSigner.Sign(claims)
+		if err != nil {
+			c.logger.Warn(ctx, "msg", "Can't sign accreditation credential", "err", err.Error())
+			return nil, err
+		}
+		credentials = append(credentials, api.AccreditationCredentialRepresentation{
+			Type:       accred.Type,
+			ExpiryDate: accred.ExpiryDate,
+			Credential: &credential,
+		})
+	}
+
+	return credentials, nil
+}
+
+// findGrantedAccreditation returns the ID of the last recorded grant of an accreditation which is still active
+func findGrantedAccreditation(history []dto.DBAccreditation, accredType string, expiryDate time.Time) *int64 {
+	for i := len(history) - 1; i >= 0; i-- {
+		var accred = history[i]
+		if accred.Type == accredType && accred.RevocationDate == nil && accred.ExpiryDate != nil && accred.ExpiryDate.Equal(expiryDate) {
+			return &accred.ID
+		}
+	}
+	return nil
+}
+
+func (c *component) statusListURL(realmName string) string {
+	return c.credentialsIssuer + "/mobile/credentials/status/" + url.PathEscape(realmName)
+}
+
+// GetCredentialsJWKS returns the public keys verifiers use to check the signature of the credentials
+func (c *component) GetCredentialsJWKS(ctx context.Context) (jose.JSONWebKeySet, error) {
+	return c.credentialSigner.PublicKeys(), nil
+}
+
+// GetCredentialsStatusList returns the signed status list of the accreditations granted in a realm
+func (c *component) GetCredentialsStatusList(ctx context.Context, realmName string) (string, error) {
+	var status, err = c.usersDBModule.GetAccreditationsStatus(ctx, realmName)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get accreditations status", "err", err.Error(), "realm", realmName)
+		return "", err
+	}
+	encodedList, err := encodeStatusList(status)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't encode status list", "err", err.Error(), "realm", realmName)
+		return "", err
+	}
+
+	var now = c.now()
+	var statusListURL = c.statusListURL(realmName)
+	var claims = jwtCredentialClaims{
+		Issuer:    c.credentialsIssuer,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		Expiry:    now.Add(statusListValidity).Unix(),
+		VC: verifiableCredential{
+			Context: []string{credentialsContext, statusListContext},
+			ID:      statusListURL,
+			Type:    []string{"VerifiableCredential", "StatusList2021Credential"},
+			CredentialSubject: statusListSubject{
+				ID:            statusListURL + "#list",
+				Type:          "StatusList2021",
+				StatusPurpose: statusListPurpose,
+				EncodedList:   encodedList,
+			},
+		},
+	}
+	credential, err := c.credentialSigner.Sign(claims)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't sign status list", "err", err.Error(), "realm", realmName)
+		return "", err
+	}
+	return credential, nil
+}
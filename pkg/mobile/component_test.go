@@ -1,11 +1,17 @@
 package mobilepkg
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"errors"
+	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 
 	"github.com/cloudtrust/common-service/configuration"
 	"github.com/cloudtrust/keycloak-bridge/internal/dto"
@@ -16,6 +22,7 @@ import (
 	kc "github.com/cloudtrust/keycloak-client"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 func TestGetUser(t *testing.T) {
@@ -27,7 +34,7 @@ func TestGetUser(t *testing.T) {
 	mockUsersDetailsDBModule := mock.NewUsersDetailsDBModule(mockCtrl)
 	mockLogger := log.NewNopLogger()
 
-	var component = NewComponent(mockKeycloakClient, mockConfigurationDBModule, mockUsersDetailsDBModule, mockTokenProvider, mockLogger)
+	var component = NewComponent(mockKeycloakClient, mockConfigurationDBModule, mockUsersDetailsDBModule, mockTokenProvider, mock.NewCredentialSigner(mockCtrl), "", mockLogger)
 
 	var accessToken = "the-access-token"
 	var realm = "the-realm"
@@ -64,7 +71,7 @@ func TestGetUser(t *testing.T) {
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{}, nil)
 		mockUsersDetailsDBModule.EXPECT().GetChecks(ctx, realm, userID).Return([]dto.DBCheck{}, nil)
-		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realm).Return(configuration.RealmAdminConfiguration{}, dbError)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realm).Return(dto.RealmAdminConfiguration{}, dbError)
 		var _, err = component.GetUserInformation(ctx)
 		assert.Equal(t, dbError, err)
 	})
@@ -80,7 +87,7 @@ func TestGetUser(t *testing.T) {
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{Attributes: &attrbs}, nil)
 		mockUsersDetailsDBModule.EXPECT().GetChecks(ctx, realm, userID).Return(checks, nil)
-		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realm).Return(configuration.RealmAdminConfiguration{AvailableChecks: availableChecks}, nil)
+		mockConfigurationDBModule.EXPECT().GetAdminConfiguration(ctx, realm).Return(dto.RealmAdminConfiguration{RealmAdminConfiguration: configuration.RealmAdminConfiguration{AvailableChecks: availableChecks}}, nil)
 		var userInfo, err = component.GetUserInformation(ctx)
 		assert.Nil(t, err)
 		assert.Len(t, *userInfo.Accreditations, 2)
@@ -88,3 +95,269 @@ func TestGetUser(t *testing.T) {
 		assert.Len(t, *userInfo.Actions, len(availableChecks))
 	})
 }
+
+func TestGetAccreditationCredentials(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockCredentialSigner = mock.NewCredentialSigner(mockCtrl)
+
+	var mobileComponent = NewComponent(mockKeycloakClient, mock.NewConfigurationDBModule(mockCtrl), mockUsersDetailsDBModule, mockTokenProvider,
+		mockCredentialSigner, "https://bridge.example.com/", log.NewNopLogger())
+	var now = time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC)
+	mobileComponent.(*component).now = func() time.Time { return now }
+
+	var accessToken = "the-access-token"
+	var realm = "the-realm"
+	var userID = "the-user-id"
+	var ctx = context.WithValue(context.TODO(), cs.CtContextRealm, realm)
+	ctx = context.WithValue(ctx, cs.CtContextUserID, userID)
+	var anyError = errors.New("any error")
+
+	var attrbs = make(kc.Attributes)
+	attrbs.Set(constants.AttrbAccreditations, []string{
+		`{"type":"SHADOW","expiryDate":"31.12.2030"}`,
+		`{"type":"EXPIRED","expiryDate":"01.01.2020"}`,
+		`{"type":"REVOKED","expiryDate":"31.12.2030","revoked":true}`,
+		`{"type":"LEGACY","expiryDate":"31.12.2030"}`,
+		`invalid`,
+	})
+	var user = kc.UserRepresentation{Attributes: &attrbs}
+	var expiry = time.Date(2030, 12, 31, 0, 0, 0, 0, time.UTC)
+	var revocationDate = now.Add(-time.Hour)
+	var history = []dto.DBAccreditation{
+		{ID: 12, Type: "SHADOW", ExpiryDate: &expiry, RevocationDate: &revocationDate},
+		{ID: 34, Type: "SHADOW", ExpiryDate: &expiry},
+		{ID: 56, Type: "REVOKED", ExpiryDate: &expiry},
+	}
+
+	t.Run("Can't get access token", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", anyError)
+		var _, err = mobileComponent.GetAccreditationCredentials(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil).AnyTimes()
+
+	t.Run("Can't get user from keycloak", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{}, anyError)
+		var _, err = mobileComponent.GetAccreditationCredentials(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't get accreditations history", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(user, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccreditations(ctx, realm, userID).Return(nil, anyError)
+		var _, err = mobileComponent.GetAccreditationCredentials(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't sign credential", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(user, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccreditations(ctx, realm, userID).Return(history, nil)
+		mockCredentialSigner.EXPECT().Sign(gomock.Any()).Return("", anyError)
+		var _, err = mobileComponent.GetAccreditationCredentials(ctx)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Only active accreditations found in history are issued", func(t *testing.T) {
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(user, nil)
+		mockUsersDetailsDBModule.EXPECT().GetAccreditations(ctx, realm, userID).Return(history, nil)
+		mockCredentialSigner.EXPECT().Sign(gomock.Any()).DoAndReturn(func(claims interface{}) (string, error) {
+			var credential = claims.(jwtCredentialClaims)
+			assert.Equal(t, "https://bridge.example.com", credential.Issuer)
+			assert.Equal(t, userID, credential.Subject)
+			assert.Equal(t, expiry.Unix(), credential.Expiry)
+			assert.Equal(t, accreditationClaim{Type: "SHADOW", ExpiryDate: "31.12.2030", IssuerRealm: realm},
+				credential.VC.CredentialSubject.(accreditationSubject).Accreditation)
+			assert.Equal(t, "34", credential.VC.CredentialStatus.StatusListIndex)
+			assert.Equal(t, "https://bridge.example.com/mobile/credentials/status/the-realm", credential.VC.CredentialStatus.StatusListCredential)
+			return "signed.credential", nil
+		})
+
+		var credentials, err = mobileComponent.GetAccreditationCredentials(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, credentials, 1)
+		assert.Equal(t, "SHADOW", *credentials[0].Type)
+		assert.Equal(t, "signed.credential", *credentials[0].Credential)
+	})
+}
+
+func TestGetCredentialsJWKS(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockCredentialSigner = mock.NewCredentialSigner(mockCtrl)
+
+	var mobileComponent = NewComponent(mock.NewKeycloakClient(mockCtrl), mock.NewConfigurationDBModule(mockCtrl), mock.NewUsersDetailsDBModule(mockCtrl),
+		mock.NewTokenProvider(mockCtrl), mockCredentialSigner, "", log.NewNopLogger())
+	var keySet = jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "key-id"}}}
+
+	mockCredentialSigner.EXPECT().PublicKeys().Return(keySet)
+	var res, err = mobileComponent.GetCredentialsJWKS(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, keySet, res)
+}
+
+func TestGetCredentialsStatusList(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockUsersDetailsDBModule = mock.NewUsersDetailsDBModule(mockCtrl)
+	var mockCredentialSigner = mock.NewCredentialSigner(mockCtrl)
+
+	var mobileComponent = NewComponent(mock.NewKeycloakClient(mockCtrl), mock.NewConfigurationDBModule(mockCtrl), mockUsersDetailsDBModule,
+		mock.NewTokenProvider(mockCtrl), mockCredentialSigner, "https://bridge.example.com", log.NewNopLogger())
+	var now = time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC)
+	mobileComponent.(*component).now = func() time.Time { return now }
+
+	var ctx = context.TODO()
+	var realm = "the-realm"
+	var anyError = errors.New("any error")
+	var status = dto.DBAccreditationsStatus{LastID: 200000, RevokedIDs: []int64{0, 9, 199999}}
+
+	t.Run("Can't get accreditations status", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetAccreditationsStatus(ctx, realm).Return(dto.DBAccreditationsStatus{}, anyError)
+		var _, err = mobileComponent.GetCredentialsStatusList(ctx, realm)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Can't sign status list", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetAccreditationsStatus(ctx, realm).Return(status, nil)
+		mockCredentialSigner.EXPECT().Sign(gomock.Any()).Return("", anyError)
+		var _, err = mobileComponent.GetCredentialsStatusList(ctx, realm)
+		assert.Equal(t, anyError, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockUsersDetailsDBModule.EXPECT().GetAccreditationsStatus(ctx, realm).Return(status, nil)
+		mockCredentialSigner.EXPECT().Sign(gomock.Any()).DoAndReturn(func(claims interface{}) (string, error) {
+			var credential = claims.(jwtCredentialClaims)
+			assert.Equal(t, now.Add(statusListValidity).Unix(), credential.Expiry)
+			assert.Equal(t, "https://bridge.example.com/mobile/credentials/status/the-realm", credential.VC.ID)
+
+			var bitstring = decodeStatusList(t, credential.VC.CredentialSubject.(statusListSubject).EncodedList)
+
+			// The list grows by blocks to cover the last accreditation ID
+			assert.Len(t, bitstring, 2*statusListBlockSize/8)
+			assert.Equal(t, byte(0x80), bitstring[0])
+			assert.Equal(t, byte(0x40), bitstring[1])
+			assert.Equal(t, byte(0x01), bitstring[199999/8])
+			return "signed.status.list", nil
+		})
+
+		var res, err = mobileComponent.GetCredentialsStatusList(ctx, realm)
+		assert.Nil(t, err)
+		assert.Equal(t, "signed.status.list", res)
+	})
+}
+
+func TestCredentialsStatusListAfterUserPurge(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockKeycloakClient = mock.NewUserPurgeKeycloakClient(mockCtrl)
+	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
+	var mockEventsReporter = mock.NewEventsReporter(mockCtrl)
+	var mockCredentialSigner = mock.NewCredentialSigner(mockCtrl)
+
+	var ctx = context.TODO()
+	var accessToken = "the-access-token"
+	var realm = "the-realm"
+	var deletedUserID = "deleted-user"
+	var operator = "operator"
+	var expiry = time.Now().AddDate(1, 0, 0)
+	var disabled = false
+	var history = &accreditationsHistory{
+		accreds: []dto.DBAccreditation{
+			{ID: 3, RealmID: realm, UserID: deletedUserID, Type: "SHADOW", ExpiryDate: &expiry},
+			{ID: 5, RealmID: realm, UserID: "other-user", Type: "SHADOW", ExpiryDate: &expiry},
+		},
+		deletions: []dto.DBUserDeletion{{RealmID: realm, UserID: deletedUserID, DeletedBy: &operator}},
+	}
+
+	var purge = keycloakb.NewUserPurge(history, mockKeycloakClient, mockTokenProvider, mockEventsReporter, log.NewNopLogger())
+	var mobileComponent = NewComponent(mock.NewKeycloakClient(mockCtrl), mock.NewConfigurationDBModule(mockCtrl), history, mockTokenProvider,
+		mockCredentialSigner, "https://bridge.example.com", log.NewNopLogger())
+
+	mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+	mockKeycloakClient.EXPECT().GetUser(accessToken, realm, deletedUserID).Return(kc.UserRepresentation{Enabled: &disabled}, nil)
+	mockKeycloakClient.EXPECT().DeleteUser(accessToken, realm, deletedUserID).Return(nil)
+	mockEventsReporter.EXPECT().ReportEvent(ctx, "ACCOUNT_PURGED", "back-office", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	var count, err = purge.Run(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	// Credentials of the purged user are revoked in the status list, the ones of the other users are not
+	mockCredentialSigner.EXPECT().Sign(gomock.Any()).DoAndReturn(func(claims interface{}) (string, error) {
+		var credential = claims.(jwtCredentialClaims)
+		var bitstring = decodeStatusList(t, credential.VC.CredentialSubject.(statusListSubject).EncodedList)
+		assert.Equal(t, byte(0x10), bitstring[0])
+		return "signed.status.list", nil
+	})
+	_, err = mobileComponent.GetCredentialsStatusList(ctx, realm)
+	assert.Nil(t, err)
+}
+
+func decodeStatusList(t *testing.T, encodedList string) []byte {
+	compressed, err := base64.RawURLEncoding.DecodeString(encodedList)
+	assert.Nil(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.Nil(t, err)
+	bitstring, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	return bitstring
+}
+
+// accreditationsHistory is an in-memory users database revoking and listing the accreditations the way the SQL statements do
+type accreditationsHistory struct {
+	accreds   []dto.DBAccreditation
+	deletions []dto.DBUserDeletion
+}
+
+func (h *accreditationsHistory) GetPurgeableUserDeletions(_ context.Context, _ time.Time, _ int) ([]dto.DBUserDeletion, error) {
+	return h.deletions, nil
+}
+
+func (h *accreditationsHistory) DeleteUserDetails(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+func (h *accreditationsHistory) DeleteUserDeletion(_ context.Context, _ string, _ string) error {
+	h.deletions = nil
+	return nil
+}
+
+func (h *accreditationsHistory) RevokeAccreditations(_ context.Context, revocation dto.DBAccreditationRevocation) error {
+	for i, accred := range h.accreds {
+		if accred.RealmID == revocation.RealmID && accred.UserID == revocation.UserID && accred.RevocationDate == nil &&
+			(accred.ExpiryDate == nil || accred.ExpiryDate.After(revocation.Date)) {
+			var date = revocation.Date
+			h.accreds[i].RevocationDate = &date
+		}
+	}
+	return nil
+}
+
+func (h *accreditationsHistory) GetChecks(_ context.Context, _ string, _ string) ([]dto.DBCheck, error) {
+	return nil, nil
+}
+
+func (h *accreditationsHistory) GetAccreditations(_ context.Context, _ string, _ string) ([]dto.DBAccreditation, error) {
+	return nil, nil
+}
+
+func (h *accreditationsHistory) GetAccreditationsStatus(_ context.Context, realm string) (dto.DBAccreditationsStatus, error) {
+	var status dto.DBAccreditationsStatus
+	for _, accred := range h.accreds {
+		if accred.RealmID != realm {
+			continue
+		}
+		if accred.ID > status.LastID {
+			status.LastID = accred.ID
+		}
+		if accred.RevocationDate != nil {
+			status.RevokedIDs = append(status.RevokedIDs, accred.ID)
+		}
+	}
+	return status, nil
+}
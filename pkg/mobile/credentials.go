@@ -0,0 +1,91 @@
+package mobilepkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"time"
+
+	"github.com/cloudtrust/keycloak-bridge/internal/dto"
+)
+
+const (
+	credentialsContext = "https://www.w3.org/2018/credentials/v1"
+	statusListContext  = "https://w3id.org/vc/status-list/2021/v1"
+
+	statusListPurpose = "revocation"
+	// Status lists are at least 16KB long (uncompressed) so that a verifier can't guess which credential is checked
+	statusListBlockSize = 16 * 1024 * 8
+	// Verifiers working offline keep using a status list until it expires
+	statusListValidity = 24 * time.Hour
+)
+
+// jwtCredentialClaims are the claims of a verifiable credential encoded as a JWT
+type jwtCredentialClaims struct {
+	Issuer    string               `json:"iss"`
+	Subject   string               `json:"sub,omitempty"`
+	IssuedAt  int64                `json:"iat"`
+	NotBefore int64                `json:"nbf"`
+	Expiry    int64                `json:"exp"`
+	VC        verifiableCredential `json:"vc"`
+}
+
+type verifiableCredential struct {
+	Context           []string         `json:"@context"`
+	ID                string           `json:"id,omitempty"`
+	Type              []string         `json:"type"`
+	CredentialSubject interface{}      `json:"credentialSubject"`
+	CredentialStatus  *statusListEntry `json:"credentialStatus,omitempty"`
+}
+
+type accreditationSubject struct {
+	Accreditation accreditationClaim `json:"accreditation"`
+}
+
+type accreditationClaim struct {
+	Type        string `json:"type"`
+	ExpiryDate  string `json:"expiryDate"`
+	IssuerRealm string `json:"issuerRealm"`
+}
+
+// statusListEntry refers to the bit of a status list which is set when the credential is revoked
+type statusListEntry struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose"`
+	StatusListIndex      string `json:"statusListIndex"`
+	StatusListCredential string `json:"statusListCredential"`
+}
+
+type statusListSubject struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	StatusPurpose string `json:"statusPurpose"`
+	EncodedList   string `json:"encodedList"`
+}
+
+// encodeStatusList returns the bitstring of a status list, compressed with GZIP and base64url encoded. The ID of an accreditation
+// is its index in the list: the list is long enough to hold all the accreditations ever granted and the bits of the revoked ones
+// are set, the first index being the left-most bit of the first byte
+func encodeStatusList(status dto.DBAccreditationsStatus) (string, error) {
+	var size = int64(statusListBlockSize)
+	for size <= status.LastID {
+		size += statusListBlockSize
+	}
+	var bitstring = make([]byte, size/8)
+	for _, id := range status.RevokedIDs {
+		if id >= 0 && id < size {
+			bitstring[id/8] |= 0x80 >> uint(id%8)
+		}
+	}
+
+	var buffer bytes.Buffer
+	var writer = gzip.NewWriter(&buffer)
+	if _, err := writer.Write(bitstring); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer.Bytes()), nil
+}
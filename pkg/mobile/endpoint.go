@@ -9,7 +9,15 @@ import (
 
 // Endpoints wraps a service behind a set of endpoints.
 type Endpoints struct {
-	GetUserInformation endpoint.Endpoint
+	GetUserInformation          endpoint.Endpoint
+	GetAccreditationCredentials endpoint.Endpoint
+	GetCredentialsJWKS          endpoint.Endpoint
+	GetCredentialsStatusList    endpoint.Endpoint
+}
+
+// CredentialReply is a signed credential sent as is
+type CredentialReply struct {
+	Credential string
 }
 
 // MakeGetUserInformationEndpoint makes the GetUserInformation endpoint
@@ -18,3 +26,30 @@ func MakeGetUserInformationEndpoint(component Component) cs.Endpoint {
 		return component.GetUserInformation(ctx)
 	}
 }
+
+// MakeGetAccreditationCredentialsEndpoint makes the GetAccreditationCredentials endpoint
+func MakeGetAccreditationCredentialsEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return component.GetAccreditationCredentials(ctx)
+	}
+}
+
+// MakeGetCredentialsJWKSEndpoint makes the GetCredentialsJWKS endpoint
+func MakeGetCredentialsJWKSEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return component.GetCredentialsJWKS(ctx)
+	}
+}
+
+// MakeGetCredentialsStatusListEndpoint makes the GetCredentialsStatusList endpoint
+func MakeGetCredentialsStatusListEndpoint(component Component) cs.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m = req.(map[string]string)
+
+		var credential, err = component.GetCredentialsStatusList(ctx, m[prmRealm])
+		if err != nil {
+			return nil, err
+		}
+		return CredentialReply{Credential: credential}, nil
+	}
+}
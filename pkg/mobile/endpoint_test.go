@@ -2,12 +2,14 @@ package mobilepkg
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	api "github.com/cloudtrust/keycloak-bridge/api/mobile"
 	"github.com/cloudtrust/keycloak-bridge/pkg/mobile/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 func TestMakeGetUserInformationEndpoint(t *testing.T) {
@@ -21,3 +23,48 @@ func TestMakeGetUserInformationEndpoint(t *testing.T) {
 	_, err := MakeGetUserInformationEndpoint(mockMobileComponent)(context.Background(), m)
 	assert.Nil(t, err)
 }
+
+func TestMakeGetAccreditationCredentialsEndpoint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockMobileComponent := mock.NewComponent(mockCtrl)
+	m := map[string]string{}
+
+	mockMobileComponent.EXPECT().GetAccreditationCredentials(gomock.Any()).Return([]api.AccreditationCredentialRepresentation{}, nil)
+	_, err := MakeGetAccreditationCredentialsEndpoint(mockMobileComponent)(context.Background(), m)
+	assert.Nil(t, err)
+}
+
+func TestMakeGetCredentialsJWKSEndpoint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockMobileComponent := mock.NewComponent(mockCtrl)
+	m := map[string]string{}
+
+	mockMobileComponent.EXPECT().GetCredentialsJWKS(gomock.Any()).Return(jose.JSONWebKeySet{}, nil)
+	_, err := MakeGetCredentialsJWKSEndpoint(mockMobileComponent)(context.Background(), m)
+	assert.Nil(t, err)
+}
+
+func TestMakeGetCredentialsStatusListEndpoint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockMobileComponent := mock.NewComponent(mockCtrl)
+	m := map[string]string{prmRealm: "realm"}
+
+	t.Run("Error", func(t *testing.T) {
+		mockMobileComponent.EXPECT().GetCredentialsStatusList(gomock.Any(), "realm").Return("", errors.New("any error"))
+		_, err := MakeGetCredentialsStatusListEndpoint(mockMobileComponent)(context.Background(), m)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockMobileComponent.EXPECT().GetCredentialsStatusList(gomock.Any(), "realm").Return("signed.status.list", nil)
+		res, err := MakeGetCredentialsStatusListEndpoint(mockMobileComponent)(context.Background(), m)
+		assert.Nil(t, err)
+		assert.Equal(t, CredentialReply{Credential: "signed.status.list"}, res)
+	})
+}
@@ -6,15 +6,21 @@ import (
 
 	commonhttp "github.com/cloudtrust/common-service/http"
 	"github.com/cloudtrust/common-service/log"
+	"github.com/cloudtrust/keycloak-bridge/internal/constants"
 	"github.com/go-kit/kit/endpoint"
 	http_transport "github.com/go-kit/kit/transport/http"
 )
 
+// Path parameters
+const (
+	prmRealm = "realm"
+)
+
 // MakeMobileHandler make an HTTP handler for a Mobile endpoint.
 func MakeMobileHandler(e endpoint.Endpoint, logger log.Logger) *http_transport.Server {
 	return http_transport.NewServer(e,
 		decodeAccountRequest,
-		commonhttp.EncodeReply,
+		encodeMobileReply,
 		http_transport.ServerErrorEncoder(commonhttp.ErrorHandler(logger)),
 	)
 }
@@ -22,7 +28,7 @@ func MakeMobileHandler(e endpoint.Endpoint, logger log.Logger) *http_transport.S
 // decodeEventsRequest gets the HTTP parameters and body content
 func decodeAccountRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	var pathParams = map[string]string{
-		// No path parameters
+		prmRealm: constants.RegExpRealmName,
 	}
 
 	var queryParams = map[string]string{
@@ -31,3 +37,16 @@ func decodeAccountRequest(ctx context.Context, req *http.Request) (interface{},
 
 	return commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
 }
+
+// encodeMobileReply encodes the reply. Signed credentials are sent as JWT
+func encodeMobileReply(ctx context.Context, w http.ResponseWriter, rep interface{}) error {
+	switch r := rep.(type) {
+	case CredentialReply:
+		w.Header().Set("Content-Type", "application/jwt")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(r.Credential))
+		return err
+	default:
+		return commonhttp.EncodeReply(ctx, w, rep)
+	}
+}
@@ -41,3 +41,29 @@ func TestHTTPMobileHandler(t *testing.T) {
 		assert.Equal(t, "{}", buf.String())
 	}
 }
+
+func TestHTTPMobileCredentialReply(t *testing.T) {
+	var mockCtrl = gomock.NewController(t)
+	defer mockCtrl.Finish()
+	var mockMobileComponent = mock.NewComponent(mockCtrl)
+
+	r := mux.NewRouter()
+	r.Handle("/path/to/status/{realm}", MakeMobileHandler(keycloakb.ToGoKitEndpoint(MakeGetCredentialsStatusListEndpoint(mockMobileComponent)), log.NewNopLogger()))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	{
+		mockMobileComponent.EXPECT().GetCredentialsStatusList(gomock.Any(), "my-realm").Return("signed.status.list", nil).Times(1)
+
+		res, err := http.Get(ts.URL + "/path/to/status/my-realm")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/jwt", res.Header.Get("Content-Type"))
+
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(res.Body)
+		assert.Equal(t, "signed.status.list", buf.String())
+	}
+}
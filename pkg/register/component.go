@@ -224,7 +224,7 @@ func (c *component) storeUser(ctx context.Context, accessToken string, targetRea
 	}
 
 	// Store user in database
-	err = c.usersDBModule.StoreOrUpdateUserDetails(ctx, targetRealmName, dto.DBUser{
+	var dbUser = dto.DBUser{
 		UserID:               &userID,
 		BirthLocation:        user.BirthLocation,
 		Nationality:          user.Nationality,
@@ -232,7 +232,9 @@ func (c *component) storeUser(ctx context.Context, accessToken string, targetRea
 		IDDocumentNumber:     user.IDDocumentNumber,
 		IDDocumentExpiration: user.IDDocumentExpiration,
 		IDDocumentCountry:    user.IDDocumentCountry,
-	})
+	}
+	dbUser.SetIdentity(kcUser)
+	err = c.usersDBModule.StoreOrUpdateUserDetails(ctx, targetRealmName, dbUser)
 	if err != nil {
 		c.logger.Warn(ctx, "msg", "Can't store user details in database", "err", err.Error())
 		return "", "", err
@@ -19,6 +19,9 @@ var (
 	dateLayout = constants.SupportedDateLayouts[0]
 )
 
+// revocationOperator is recorded as the author of the accreditations revoked through the validation API which has no connected user
+const revocationOperator = "validation-api"
+
 // KeycloakClient are methods from keycloak-client used by this component
 type KeycloakClient interface {
 	UpdateUser(accessToken string, realmName, userID string, user kc.UserRepresentation) error
@@ -35,7 +38,8 @@ type TokenProvider interface {
 type UsersDetailsDBModule interface {
 	StoreOrUpdateUserDetails(ctx context.Context, realm string, user dto.DBUser) error
 	GetUserDetails(ctx context.Context, realm string, userID string) (dto.DBUser, error)
-	CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) error
+	GetAccountExpiry(ctx context.Context, realm string, userID string) (*time.Time, error)
+	CreateCheck(ctx context.Context, realm string, userID string, check dto.DBCheck) (int64, error)
 }
 
 // ArchiveDBModule is the interface from the archive module
@@ -58,25 +62,27 @@ type Component interface {
 
 // Component is the management component.
 type component struct {
-	keycloakClient  KeycloakClient
-	tokenProvider   toolbox.OidcTokenProvider
-	usersDBModule   UsersDetailsDBModule
-	archiveDBModule ArchiveDBModule
-	eventsDBModule  database.EventsDBModule
-	accredsModule   keycloakb.AccreditationsModule
-	logger          internal.Logger
+	keycloakClient   KeycloakClient
+	tokenProvider    toolbox.OidcTokenProvider
+	usersDBModule    UsersDetailsDBModule
+	archiveDBModule  ArchiveDBModule
+	eventsDBModule   database.EventsDBModule
+	accredsModule    keycloakb.AccreditationsModule
+	duplicatesModule keycloakb.DuplicatesModule
+	logger           internal.Logger
 }
 
 // NewComponent returns the management component.
-func NewComponent(keycloakClient KeycloakClient, tokenProvider TokenProvider, usersDBModule UsersDetailsDBModule, archiveDBModule ArchiveDBModule, eventsDBModule database.EventsDBModule, accredsModule keycloakb.AccreditationsModule, logger internal.Logger) Component {
+func NewComponent(keycloakClient KeycloakClient, tokenProvider TokenProvider, usersDBModule UsersDetailsDBModule, archiveDBModule ArchiveDBModule, eventsDBModule database.EventsDBModule, accredsModule keycloakb.AccreditationsModule, duplicatesModule keycloakb.DuplicatesModule, logger internal.Logger) Component {
 	return &component{
-		keycloakClient:  keycloakClient,
-		tokenProvider:   tokenProvider,
-		usersDBModule:   usersDBModule,
-		archiveDBModule: archiveDBModule,
-		eventsDBModule:  eventsDBModule,
-		accredsModule:   accredsModule,
-		logger:          logger,
+		keycloakClient:   keycloakClient,
+		tokenProvider:    tokenProvider,
+		usersDBModule:    usersDBModule,
+		archiveDBModule:  archiveDBModule,
+		eventsDBModule:   eventsDBModule,
+		accredsModule:    accredsModule,
+		duplicatesModule: duplicatesModule,
+		logger:           logger,
 	}
 }
 
@@ -117,6 +123,15 @@ func (c *component) GetUser(ctx context.Context, realmName string, userID string
 	res.IDDocumentNumber = dbUser.IDDocumentNumber
 	res.IDDocumentCountry = dbUser.IDDocumentCountry
 
+	accountExpiry, err := c.usersDBModule.GetAccountExpiry(ctx, realmName, userID)
+	if err != nil {
+		c.logger.Warn(ctx, "msg", "Can't get account expiry", "err", err.Error(), "realmName", realmName, "userID", userID)
+		return api.UserRepresentation{}, err
+	}
+
+	var etag = keycloakb.ComputeUserETag(kcUser, dbUser, accountExpiry)
+	res.ETag = &etag
+
 	if dbUser.IDDocumentExpiration != nil {
 		expirationTime, err := time.Parse(dateLayout, *dbUser.IDDocumentExpiration)
 		if err != nil {
@@ -140,8 +155,20 @@ func (c *component) UpdateUser(ctx context.Context, realmName string, userID str
 	var dbUpdate = needDBProcessing(user)
 	var shouldRevokeAccreditations bool
 
+	if user.ETag != nil {
+		if err = c.checkETag(validationCtx, user.ETag); err != nil {
+			return err
+		}
+	}
+
+	if needDuplicatesCheck(user) {
+		if err = c.checkDuplicates(validationCtx, user); err != nil {
+			return err
+		}
+	}
+
 	if dbUpdate {
-		shouldRevokeAccreditations, err = c.updateUserDatabase(ctx, realmName, userID, user)
+		shouldRevokeAccreditations, err = c.updateUserDatabase(validationCtx, user)
 		if err != nil {
 			return err
 		}
@@ -155,8 +182,9 @@ func (c *component) UpdateUser(ctx context.Context, realmName string, userID str
 	}
 
 	if kcUpdate || dbUpdate {
-		// store the API call into the DB
-		c.reportEvent(ctx, "VALIDATION_UPDATE_USER", database.CtEventRealmName, realmName, database.CtEventUserID, userID)
+		// store the API call and the changes of the user into the DB
+		c.reportEvent(ctx, "VALIDATION_UPDATE_USER", database.CtEventRealmName, realmName, database.CtEventUserID, userID,
+			database.CtEventAdditionalInfo, keycloakb.CreateChangesAdditionalInfo(validationCtx.changes))
 
 		// archive user
 		c.archiveUser(validationCtx)
@@ -165,31 +193,42 @@ func (c *component) UpdateUser(ctx context.Context, realmName string, userID str
 	return nil
 }
 
-func (c *component) updateUserDatabase(ctx context.Context, realmName, userID string, user api.UserRepresentation) (bool, error) {
+func (c *component) updateUserDatabase(v *validationContext, user api.UserRepresentation) (bool, error) {
 	var shouldRevokeAccreditations bool
+	var kcUser, err = c.getKeycloakUserCtx(v)
+	if err != nil {
+		return false, err
+	}
+	keycloakb.ConvertLegacyAttribute(kcUser)
+
 	var userDB = dto.DBUser{
-		UserID:            &userID,
+		UserID:            &v.userID,
 		BirthLocation:     user.BirthLocation,
 		Nationality:       user.Nationality,
 		IDDocumentType:    user.IDDocumentType,
 		IDDocumentNumber:  user.IDDocumentNumber,
 		IDDocumentCountry: user.IDDocumentCountry,
 	}
-
-	if existingUser, err := c.usersDBModule.GetUserDetails(ctx, realmName, userID); err == nil {
-		shouldRevokeAccreditations = user.HasUpdateOfAccreditationDependantInformationDB(existingUser)
-	}
+	// The identity blind index is computed from the identity the user has once updated
+	setUpdatedIdentity(&userDB, *kcUser, user)
 
 	if user.IDDocumentExpiration != nil {
 		var expiration = (*user.IDDocumentExpiration).Format(dateLayout)
 		userDB.IDDocumentExpiration = &expiration
 	}
 
-	var err = c.usersDBModule.StoreOrUpdateUserDetails(ctx, realmName, userDB)
+	if existingUser, err := c.getDbUser(v); err == nil {
+		shouldRevokeAccreditations = user.HasUpdateOfAccreditationDependantInformationDB(*existingUser)
+		v.changes = append(v.changes, keycloakb.DiffUserDetails(*existingUser, userDB)...)
+	}
+
+	err = c.usersDBModule.StoreOrUpdateUserDetails(v.ctx, v.realmName, userDB)
 	if err != nil {
-		c.logger.Warn(ctx, "msg", "Can't update user in DB", "err", err.Error())
+		c.logger.Warn(v.ctx, "msg", "Can't update user in DB", "err", err.Error())
 		return false, err
 	}
+	// Cached details are outdated: they are read again when the user is archived
+	v.dbUser = nil
 	return shouldRevokeAccreditations, nil
 }
 
@@ -205,14 +244,99 @@ func (c *component) updateUserKeycloak(validationCtx *validationContext, user ap
 		shouldRevokeAccreditations = true
 	}
 
+	// keep the former values: the user is updated in place
+	var formerKcUser = *kcUser
+	if kcUser.Attributes != nil {
+		var formerAttributes = make(kc.Attributes)
+		formerAttributes.Merge(kcUser.Attributes)
+		formerKcUser.Attributes = &formerAttributes
+	}
+
 	user.ExportToKeycloak(kcUser)
 	if shouldRevokeAccreditations {
 		keycloakb.RevokeAccreditations(kcUser)
+		err = c.accredsModule.RevokeAccreditations(validationCtx.ctx, validationCtx.realmName, validationCtx.userID, dto.AccreditationRevocationIdentityUpdated, revocationOperator)
+		if err != nil {
+			return err
+		}
 	}
+	validationCtx.changes = append(validationCtx.changes, keycloakb.DiffKeycloakUsers(formerKcUser, *kcUser)...)
 
 	return c.updateKeycloakUser(validationCtx)
 }
 
+// checkETag ensures the user has not been modified since it was read by the caller
+func (c *component) checkETag(v *validationContext, ifMatch *string) error {
+	var kcUser, err = c.getKeycloakUserCtx(v)
+	if err != nil {
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(kcUser)
+	dbUser, err := c.getDbUser(v)
+	if err != nil {
+		return err
+	}
+
+	accountExpiry, err := c.usersDBModule.GetAccountExpiry(v.ctx, v.realmName, v.userID)
+	if err != nil {
+		c.logger.Warn(v.ctx, "msg", "Can't get account expiry", "err", err.Error(), "realm", v.realmName, "user", v.userID)
+		return err
+	}
+
+	if err = keycloakb.CheckUserETag(ifMatch, *kcUser, *dbUser, accountExpiry); err != nil {
+		c.logger.Warn(v.ctx, "msg", "User has been modified since it was read", "realm", v.realmName, "user", v.userID)
+		return err
+	}
+	return nil
+}
+
+// checkDuplicates completes the updated identity with the current values of the user before searching for duplicates
+func (c *component) checkDuplicates(v *validationContext, user api.UserRepresentation) error {
+	var kcUser, err = c.getKeycloakUserCtx(v)
+	if err != nil {
+		return err
+	}
+	keycloakb.ConvertLegacyAttribute(kcUser)
+	dbUser, err := c.getDbUser(v)
+	if err != nil {
+		return err
+	}
+
+	var identity = dto.DBUser{
+		UserID:           &v.userID,
+		IDDocumentNumber: dbUser.IDDocumentNumber,
+	}
+	setUpdatedIdentity(&identity, *kcUser, user)
+	if user.IDDocumentNumber != nil {
+		identity.IDDocumentNumber = user.IDDocumentNumber
+	}
+
+	accessToken, err := c.getAccessToken(v)
+	if err != nil {
+		return err
+	}
+	return c.duplicatesModule.CheckDuplicates(v.ctx, accessToken, v.realmName, v.realmName, identity)
+}
+
+// setUpdatedIdentity sets the identity the user has once updated: the provided values replace the ones stored in Keycloak
+func setUpdatedIdentity(dbUser *dto.DBUser, kcUser kc.UserRepresentation, user api.UserRepresentation) {
+	dbUser.SetIdentity(kcUser)
+	if user.FirstName != nil {
+		dbUser.FirstName = user.FirstName
+	}
+	if user.LastName != nil {
+		dbUser.LastName = user.LastName
+	}
+	if user.BirthDate != nil {
+		var birthDate = (*user.BirthDate).Format(dateLayout)
+		dbUser.BirthDate = &birthDate
+	}
+}
+
+func needDuplicatesCheck(user api.UserRepresentation) bool {
+	return user.FirstName != nil || user.LastName != nil || user.BirthDate != nil || user.IDDocumentNumber != nil
+}
+
 func needKcProcessing(user api.UserRepresentation) bool {
 	var kcUserAttrs = []*string{
 		user.Gender,
@@ -267,7 +391,7 @@ func (c *component) CreateCheck(ctx context.Context, realmName string, userID st
 	var err error
 
 	dbCheck := check.ConvertToDBCheck()
-	err = c.usersDBModule.CreateCheck(ctx, realmName, userID, dbCheck)
+	checkID, err := c.usersDBModule.CreateCheck(ctx, realmName, userID, dbCheck)
 	if err != nil {
 		c.logger.Warn(ctx, "msg", "Can't store check in DB", "err", err.Error())
 		return err
@@ -281,7 +405,8 @@ func (c *component) CreateCheck(ctx context.Context, realmName string, userID st
 		}
 
 		var kcUser kc.UserRepresentation
-		kcUser, _, err = c.accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, keycloakb.CredsIDNow)
+		var accreds []dto.DBAccreditation
+		kcUser, accreds, err = c.accredsModule.GetUserAndPrepareAccreditations(ctx, accessToken, realmName, userID, keycloakb.CredsIDNow)
 		if err != nil {
 			return err
 		}
@@ -290,6 +415,9 @@ func (c *component) CreateCheck(ctx context.Context, realmName string, userID st
 		if err != nil {
 			return err
 		}
+		if err = c.accredsModule.RecordAccreditations(ctx, accreds, *check.Operator, &checkID); err != nil {
+			return err
+		}
 	}
 
 	// Event
@@ -316,6 +444,7 @@ type validationContext struct {
 	userID      string
 	kcUser      *kc.UserRepresentation
 	dbUser      *dto.DBUser
+	changes     []keycloakb.UserChange
 }
 
 func (c *component) getAccessToken(v *validationContext) (string, error) {
@@ -3,9 +3,12 @@ package validation
 import (
 	"context"
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/cloudtrust/common-service/database"
+	errorhandler "github.com/cloudtrust/common-service/errors"
 	log "github.com/cloudtrust/common-service/log"
 	apikyc "github.com/cloudtrust/keycloak-bridge/api/kyc"
 	api "github.com/cloudtrust/keycloak-bridge/api/validation"
@@ -66,7 +69,7 @@ func TestGetUserComponent(t *testing.T) {
 
 	var ctx = context.Background()
 
-	var component = NewComponent(mockKeycloakClient, mockTokenProvider, mockUsersDB, nil, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockKeycloakClient, mockTokenProvider, mockUsersDB, nil, mockEventsDB, mockAccreditations, nil, log.NewNopLogger())
 
 	t.Run("Fails to retrieve token for technical user", func(t *testing.T) {
 		var kcError = errors.New("kc error")
@@ -92,14 +95,27 @@ func TestGetUserComponent(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("Can't get account expiry", func(t *testing.T) {
+		mockTokenProvider.EXPECT().ProvideToken(gomock.Any()).Return(accessToken, nil)
+		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{}, nil)
+		var dbError = errors.New("DB error")
+		mockUsersDB.EXPECT().GetUserDetails(ctx, realm, userID).Return(dto.DBUser{UserID: &userID}, nil)
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, realm, userID).Return(nil, dbError)
+		var _, err = component.GetUser(ctx, realm, userID)
+		assert.Equal(t, dbError, err)
+	})
+
 	t.Run("No user found in DB", func(t *testing.T) {
+		var accountExpiry = time.Now()
 		mockTokenProvider.EXPECT().ProvideToken(gomock.Any()).Return(accessToken, nil)
 		mockKeycloakClient.EXPECT().GetUser(accessToken, realm, userID).Return(kc.UserRepresentation{}, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, realm, userID).Return(dto.DBUser{
 			UserID: &userID,
 		}, nil)
-		var _, err = component.GetUser(ctx, realm, userID)
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, realm, userID).Return(&accountExpiry, nil)
+		var user, err = component.GetUser(ctx, realm, userID)
 		assert.Nil(t, err)
+		assert.Equal(t, keycloakb.ComputeUserETag(kc.UserRepresentation{}, dto.DBUser{UserID: &userID}, &accountExpiry), *user.ETag)
 	})
 
 	t.Run("Date parsing error", func(t *testing.T) {
@@ -109,6 +125,7 @@ func TestGetUserComponent(t *testing.T) {
 		mockUsersDB.EXPECT().GetUserDetails(ctx, realm, userID).Return(dto.DBUser{
 			IDDocumentExpiration: &expirationDate,
 		}, nil)
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, realm, userID).Return(nil, nil)
 		var _, err = component.GetUser(ctx, realm, userID)
 		assert.NotNil(t, err)
 	})
@@ -120,6 +137,7 @@ func TestGetUserComponent(t *testing.T) {
 		mockUsersDB.EXPECT().GetUserDetails(ctx, realm, userID).Return(dto.DBUser{
 			IDDocumentExpiration: &expirationDate,
 		}, nil)
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, realm, userID).Return(nil, nil)
 		var _, err = component.GetUser(ctx, realm, userID)
 		assert.Nil(t, err)
 	})
@@ -136,13 +154,14 @@ func TestUpdateUser(t *testing.T) {
 	var mockEventsDB = mock.NewEventsDBModule(mockCtrl)
 	var mockTokenProvider = mock.NewTokenProvider(mockCtrl)
 	var mockAccreditations = mock.NewAccreditationsModule(mockCtrl)
+	var mockDuplicates = mock.NewDuplicatesModule(mockCtrl)
 
 	var targetRealm = "cloudtrust"
 	var userID = "abc789def"
 	var accessToken = "abcdef"
 	var ctx = context.TODO()
 
-	var component = NewComponent(mockKeycloakClient, mockTokenProvider, mockUsersDB, mockArchiveUsersDB, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockKeycloakClient, mockTokenProvider, mockUsersDB, mockArchiveUsersDB, mockEventsDB, mockAccreditations, mockDuplicates, log.NewNopLogger())
 
 	t.Run("Fails to retrieve token for technical user", func(t *testing.T) {
 		var user = api.UserRepresentation{
@@ -166,9 +185,11 @@ func TestUpdateUser(t *testing.T) {
 			FirstName:      ptr("newFirstname"),
 			IDDocumentType: ptr("type"),
 		}
+		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kc.UserRepresentation{}, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dto.DBUser{
 			UserID: &userID,
 		}, nil)
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil)
 		var dbError = errors.New("db error")
 		mockUsersDB.EXPECT().StoreOrUpdateUserDetails(ctx, targetRealm, gomock.Any()).Return(dbError)
 		var err = component.UpdateUser(ctx, targetRealm, userID, user)
@@ -190,6 +211,67 @@ func TestUpdateUser(t *testing.T) {
 	})
 	mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kc.UserRepresentation{}, nil).AnyTimes()
 
+	t.Run("Can't get account expiry", func(t *testing.T) {
+		var user = api.UserRepresentation{
+			FirstName: ptr("newFirstname"),
+			ETag:      ptr(`"outdated"`),
+		}
+		var dbError = errors.New("db error")
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, targetRealm, userID).Return(nil, dbError)
+		var err = component.UpdateUser(ctx, targetRealm, userID, user)
+		assert.Equal(t, dbError, err)
+	})
+
+	t.Run("User modified since it was read", func(t *testing.T) {
+		var user = api.UserRepresentation{
+			FirstName: ptr("newFirstname"),
+			ETag:      ptr(`"outdated"`),
+		}
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, targetRealm, userID).Return(nil, nil)
+		var err = component.UpdateUser(ctx, targetRealm, userID, user)
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Account expiry modified since the user was read", func(t *testing.T) {
+		var accountExpiry = time.Now()
+		var user = api.UserRepresentation{
+			FirstName: ptr("newFirstname"),
+			ETag:      ptr(keycloakb.ComputeUserETag(kc.UserRepresentation{}, dto.DBUser{UserID: &userID}, nil)),
+		}
+		mockUsersDB.EXPECT().GetAccountExpiry(ctx, targetRealm, userID).Return(&accountExpiry, nil)
+		var err = component.UpdateUser(ctx, targetRealm, userID, user)
+		assert.Equal(t, http.StatusPreconditionFailed, err.(errorhandler.Error).Status)
+	})
+
+	t.Run("Duplicate user", func(t *testing.T) {
+		var user = api.UserRepresentation{
+			LastName:         ptr("newLastname"),
+			IDDocumentNumber: ptr("X123456"),
+		}
+		var duplicateError = errors.New("duplicate")
+		mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _, _ string, identity dto.DBUser) error {
+				assert.Equal(t, userID, *identity.UserID)
+				assert.Equal(t, "newLastname", *identity.LastName)
+				assert.Equal(t, "X123456", *identity.IDDocumentNumber)
+				return duplicateError
+			})
+		var err = component.UpdateUser(ctx, targetRealm, userID, user)
+		assert.Equal(t, duplicateError, err)
+	})
+	mockDuplicates.EXPECT().CheckDuplicates(ctx, accessToken, targetRealm, targetRealm, gomock.Any()).Return(nil).AnyTimes()
+
+	t.Run("Fails to record the revocation of the accreditations", func(t *testing.T) {
+		var user = api.UserRepresentation{
+			FirstName: ptr("newFirstname"),
+		}
+		var dbError = errors.New("db error")
+		mockAccreditations.EXPECT().RevokeAccreditations(ctx, targetRealm, userID, dto.AccreditationRevocationIdentityUpdated, revocationOperator).Return(dbError)
+		var err = component.UpdateUser(ctx, targetRealm, userID, user)
+		assert.Equal(t, dbError, err)
+	})
+	mockAccreditations.EXPECT().RevokeAccreditations(ctx, targetRealm, userID, dto.AccreditationRevocationIdentityUpdated, revocationOperator).Return(nil).AnyTimes()
+
 	t.Run("Fails to update user in KC", func(t *testing.T) {
 		var date = time.Now()
 		var user = api.UserRepresentation{
@@ -219,12 +301,20 @@ func TestUpdateUser(t *testing.T) {
 			IDDocumentExpiration: &date,
 		}
 		var e = errors.New("error")
-		mockEventsDB.EXPECT().ReportEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(e)
+		mockEventsDB.EXPECT().ReportEvent(gomock.Any(), "VALIDATION_UPDATE_USER", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			database.CtEventAdditionalInfo, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _ string, values ...string) error {
+				var additionalInfo = values[len(values)-1]
+				assert.Contains(t, additionalInfo, `{"field":"idDocumentExpiration","new":`)
+				assert.Contains(t, additionalInfo, `{"field":"firstName","new":"n***"}`)
+				return e
+			})
 		mockArchiveUsersDB.EXPECT().StoreUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
 		var err = component.UpdateUser(ctx, targetRealm, userID, user)
 		assert.Nil(t, err)
 	})
-	mockEventsDB.EXPECT().ReportEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockEventsDB.EXPECT().ReportEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	mockArchiveUsersDB.EXPECT().StoreUserDetails(ctx, targetRealm, gomock.Any()).Return(nil)
 
 	t.Run("Successful update", func(t *testing.T) {
@@ -258,19 +348,21 @@ func TestCreateCheck(t *testing.T) {
 		DateTime: &datetime,
 		Status:   ptr("status"),
 	}
+	var checkID = int64(42)
+	var accreds = []dto.DBAccreditation{{RealmID: targetRealm, UserID: userID, Type: "SHADOW", GrantDate: datetime}}
 
-	var component = NewComponent(mockKeycloakClient, mockTokenProvider, mockUsersDB, mockArchiveUsersDB, mockEventsDB, mockAccreditations, log.NewNopLogger())
+	var component = NewComponent(mockKeycloakClient, mockTokenProvider, mockUsersDB, mockArchiveUsersDB, mockEventsDB, mockAccreditations, nil, log.NewNopLogger())
 
 	t.Run("Fails to store check in DB", func(t *testing.T) {
 		var dbError = errors.New("db error")
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(dbError)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(int64(0), dbError)
 		var err = component.CreateCheck(ctx, targetRealm, userID, check)
 		assert.NotNil(t, err)
 	})
 
 	t.Run("Can't get access token", func(t *testing.T) {
 		check.Status = ptr("SUCCESS")
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return("", errors.New("no token"))
 		var err = component.CreateCheck(ctx, targetRealm, userID, check)
 		assert.NotNil(t, err)
@@ -278,16 +370,16 @@ func TestCreateCheck(t *testing.T) {
 	t.Run("Accreditation module fails", func(t *testing.T) {
 		var kcUser kc.UserRepresentation
 		check.Status = ptr("SUCCESS")
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, 0, errors.New("Accreds failed"))
+		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, nil, errors.New("Accreds failed"))
 		var err = component.CreateCheck(ctx, targetRealm, userID, check)
 		assert.NotNil(t, err)
 	})
 
 	t.Run("Success w/o accreditations", func(t *testing.T) {
 		check.Status = ptr("FRAUD_SUSPICION_CONFIRMED")
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
 		mockEventsDB.EXPECT().ReportEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
 			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
 			gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
@@ -301,9 +393,9 @@ func TestCreateCheck(t *testing.T) {
 	t.Run("Computed accreditations, fails to store them in Keycloak", func(t *testing.T) {
 		var kcUser kc.UserRepresentation
 		check.Status = ptr("SUCCESS")
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, 1, nil)
+		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, accreds, nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, kcUser).Return(errors.New("KC fails"))
 		mockKeycloakClient.EXPECT().GetUser(accessToken, targetRealm, userID).Return(kc.UserRepresentation{}, nil)
 		mockUsersDB.EXPECT().GetUserDetails(ctx, targetRealm, userID).Return(dto.DBUser{}, nil)
@@ -311,13 +403,26 @@ func TestCreateCheck(t *testing.T) {
 		var err = component.CreateCheck(ctx, targetRealm, userID, check)
 		assert.NotNil(t, err)
 	})
+	t.Run("Computed accreditations, fails to record them in the history", func(t *testing.T) {
+		var kcUser kc.UserRepresentation
+		var dbError = errors.New("db error")
+		check.Status = ptr("SUCCESS")
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
+		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
+		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, accreds, nil)
+		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, kcUser).Return(nil)
+		mockAccreditations.EXPECT().RecordAccreditations(ctx, accreds, "operator", &checkID).Return(dbError)
+		var err = component.CreateCheck(ctx, targetRealm, userID, check)
+		assert.Equal(t, dbError, err)
+	})
 	t.Run("Success with accreditations", func(t *testing.T) {
 		var kcUser kc.UserRepresentation
 		check.Status = ptr("SUCCESS")
-		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(nil)
+		mockUsersDB.EXPECT().CreateCheck(ctx, targetRealm, userID, gomock.Any()).Return(checkID, nil)
 		mockTokenProvider.EXPECT().ProvideToken(ctx).Return(accessToken, nil)
-		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, 1, nil)
+		mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(ctx, accessToken, targetRealm, userID, keycloakb.CredsIDNow).Return(kcUser, accreds, nil)
 		mockKeycloakClient.EXPECT().UpdateUser(accessToken, targetRealm, userID, kcUser).Return(nil)
+		mockAccreditations.EXPECT().RecordAccreditations(ctx, accreds, "operator", &checkID).Return(nil)
 		mockEventsDB.EXPECT().ReportEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
 			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
 			gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
@@ -388,14 +493,14 @@ func TestValidationContext(t *testing.T) {
 		t.Run("Fails to get user/accreditations", func(t *testing.T) {
 			mockTokenProvider.EXPECT().ProvideToken(validationCtx.ctx).Return(accessToken, nil)
 			mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(validationCtx.ctx, accessToken, validationCtx.realmName,
-				validationCtx.userID, gomock.Any()).Return(kc.UserRepresentation{}, 0, anyError)
+				validationCtx.userID, gomock.Any()).Return(kc.UserRepresentation{}, nil, anyError)
 			var _, err = component.getUserWithAccreditations(validationCtx)
 			assert.Equal(t, anyError, err)
 		})
 		t.Run("Success", func(t *testing.T) {
 			// already got an access token : won't retry
 			mockAccreditations.EXPECT().GetUserAndPrepareAccreditations(validationCtx.ctx, accessToken, validationCtx.realmName,
-				validationCtx.userID, gomock.Any()).Return(kc.UserRepresentation{}, 0, nil)
+				validationCtx.userID, gomock.Any()).Return(kc.UserRepresentation{}, nil, nil)
 			var _, err = component.getUserWithAccreditations(validationCtx)
 			assert.Nil(t, err)
 		})
@@ -8,6 +8,7 @@ import (
 	errorhandler "github.com/cloudtrust/common-service/errors"
 	api "github.com/cloudtrust/keycloak-bridge/api/validation"
 	msg "github.com/cloudtrust/keycloak-bridge/internal/constants"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -42,6 +43,7 @@ func MakeUpdateUserEndpoint(component Component) cs.Endpoint {
 		if err = user.Validate(); err != nil {
 			return nil, err
 		}
+		user.ETag = keycloakb.GetIfMatch(m)
 
 		return nil, component.UpdateUser(ctx, m[PrmRealm], m[PrmUserID], user)
 	}
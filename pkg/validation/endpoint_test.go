@@ -7,6 +7,7 @@ import (
 	"time"
 
 	api "github.com/cloudtrust/keycloak-bridge/api/validation"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/cloudtrust/keycloak-bridge/pkg/validation/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -54,6 +55,15 @@ func TestUpdateUserEndpoint(t *testing.T) {
 		assert.Nil(t, res)
 	})
 
+	t.Run("With If-Match header", func(t *testing.T) {
+		var etag = `"abcdef"`
+		var req = map[string]string{PrmRealm: realm, PrmUserID: userID, ReqBody: "{}", keycloakb.ReqIfMatch: etag}
+
+		mockComponent.EXPECT().UpdateUser(ctx, realm, userID, api.UserRepresentation{ETag: &etag}).Return(nil).Times(1)
+		var _, err = e(ctx, req)
+		assert.Nil(t, err)
+	})
+
 	t.Run("Invalid input", func(t *testing.T) {
 		userJSON, _ := json.Marshal(api.UserRepresentation{Gender: ptr("unknown")})
 		var req = map[string]string{PrmRealm: realm, PrmUserID: userID, ReqBody: string(userJSON)}
@@ -7,6 +7,7 @@ import (
 	commonhttp "github.com/cloudtrust/common-service/http"
 	"github.com/cloudtrust/common-service/log"
 	api "github.com/cloudtrust/keycloak-bridge/api/validation"
+	"github.com/cloudtrust/keycloak-bridge/internal/keycloakb"
 	"github.com/go-kit/kit/endpoint"
 	http_transport "github.com/go-kit/kit/transport/http"
 )
@@ -23,7 +24,7 @@ const (
 func MakeValidationHandler(e endpoint.Endpoint, logger log.Logger) *http_transport.Server {
 	return http_transport.NewServer(e,
 		decodeManagementRequest,
-		commonhttp.EncodeReply,
+		keycloakb.EncodeReplyWithETag,
 		http_transport.ServerErrorEncoder(commonhttp.ErrorHandler(logger)),
 	)
 }
@@ -37,5 +38,5 @@ func decodeManagementRequest(ctx context.Context, req *http.Request) (interface{
 
 	var queryParams = map[string]string{}
 
-	return commonhttp.DecodeRequest(ctx, req, pathParams, queryParams)
+	return keycloakb.DecodeRequestWithHeaders(ctx, req, pathParams, queryParams)
 }